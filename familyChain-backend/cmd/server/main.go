@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 
 	"eth-for-babies-backend/internal/api/routes"
 	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
 	"eth-for-babies-backend/pkg/blockchain"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -25,6 +31,22 @@ func main() {
 	cfg := config.Load()
 	log.Println("Configuration loaded")
 
+	// 模拟签名会绕过钱包签名校验，只允许在开发环境开启
+	if cfg.Auth.AllowMockSignatures {
+		if cfg.Environment == "production" {
+			log.Fatal("AUTH_ALLOW_MOCK_SIGNATURES must not be enabled in production")
+		}
+		log.Println("Warning: mock signature login is enabled, do not use this outside local development")
+	}
+
+	// 邀请码的签名密钥是公开的默认值时任何人都能伪造邀请码，只允许在开发环境使用
+	if cfg.Auth.InviteCodeSecret == config.DefaultInviteCodeSecret {
+		if cfg.Environment != "development" {
+			log.Fatal("INVITE_CODE_SECRET must be set outside development")
+		}
+		log.Println("Warning: using the default invite code secret, set INVITE_CODE_SECRET outside local development")
+	}
+
 	// 初始化数据库
 	log.Println("Initializing database...")
 	db, err := config.InitDatabase(cfg)
@@ -35,11 +57,16 @@ func main() {
 
 	// 初始化区块链客户端和合约管理器
 	var contractManager *blockchain.ContractManager
-	if cfg.Blockchain.RPCURL != "" && cfg.Blockchain.PrivateKey != "" {
+	if cfg.Blockchain.RPCURL != "" && cfg.Blockchain.SignerConfigured() {
 		log.Println("Initializing blockchain client...")
 
-		// 创建以太坊客户端
-		ethClient, err := blockchain.NewEthClient(cfg.Blockchain.RPCURL, cfg.Blockchain.PrivateKey)
+		// 创建交易签名器和以太坊客户端
+		var ethClient *blockchain.EthClient
+		signer, err := newSigner(cfg.Blockchain)
+		if err == nil {
+			log.Printf("Using %s signer for account %s", cfg.Blockchain.Signer.Type, signer.Address().Hex())
+			ethClient, err = blockchain.NewEthClient(cfg.Blockchain.RPCURL, signer)
+		}
 		if err != nil {
 			log.Printf("Warning: Failed to initialize blockchain client: %v", err)
 			log.Println("Continuing without blockchain functionality...")
@@ -85,6 +112,9 @@ func main() {
 					}
 				}
 
+				// 交易和事件达到确认数后才视为最终结果
+				contractManager.SetRequiredConfirmations(cfg.Blockchain.Confirmations)
+
 				log.Println("Blockchain client and contract manager initialized successfully")
 			}
 		}
@@ -99,9 +129,66 @@ func main() {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	// 实时推送的事件代理，API、调度器和铸币分发器共用
+	broker, err := newBroker(cfg.Realtime)
+	if err != nil {
+		log.Fatal("Failed to initialize realtime broker:", err)
+	}
+	// 发布的事件同时写入家庭webhook的投递队列，并转换为站内通知和通知邮件
+	webhookDispatcher := services.NewWebhookDispatcher(repository.NewWebhookRepository(db), cfg.Webhook)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationService := services.NewNotificationService(
+		notificationRepo, repository.NewFamilyMemberRepository(db), repository.NewChildRepository(db),
+		repository.NewUserRepository(db), cfg.Notification.SMTP.Configured(),
+	)
+	broker = realtime.WithHook(broker, webhookDispatcher.Enqueue, notificationService.Notify)
+
+	// 启动后台任务
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if contractManager != nil {
+		// 链上副作用分发器：发送任务批准后的铸币交易，失败时重试
+		outboxDispatcher := services.NewOutboxDispatcher(repository.NewOutboxRepository(db), contractManager, cfg.Outbox, broker)
+		go outboxDispatcher.Run(ctx)
+
+		// 链上事件索引器：把合约事件（包括前端钱包直接发起的交易）同步到数据库
+		if cfg.Indexer.Enabled {
+			eventIndexer := services.NewEventIndexer(db, contractManager, cfg.Indexer)
+			go eventIndexer.Run(ctx)
+		}
+	} else {
+		log.Println("Outbox dispatcher disabled: blockchain not configured, token rewards stay queued")
+	}
+
+	// 任务调度器：按日程为孩子生成重复任务实例，并把超过截止时间的任务标记为过期，不依赖区块链
+	if cfg.Scheduler.Enabled {
+		familyRepo := repository.NewFamilyRepository(db)
+		childRepo := repository.NewChildRepository(db)
+		membershipService := services.NewMembershipService(
+			repository.NewFamilyMemberRepository(db), repository.NewFamilyInvitationRepository(db), familyRepo, childRepo,
+		)
+		seriesService := services.NewTaskSeriesService(repository.NewTaskSeriesRepository(db), childRepo, familyRepo, membershipService, broker)
+		expiryService := services.NewTaskExpiryService(repository.NewTaskRepository(db), familyRepo)
+		go services.NewTaskScheduler(seriesService, expiryService, cfg.Scheduler).Run(ctx)
+	}
+
+	// webhook分发器：签名发送家庭事件，失败时重试，不依赖区块链
+	if cfg.Webhook.Enabled {
+		go webhookDispatcher.Run(ctx)
+	}
+
+	// 邮件分发器：发送队列中的通知邮件，没有配置SMTP时只写入站内通知
+	if cfg.Notification.SMTP.Configured() {
+		mailer := services.NewSMTPMailer(cfg.Notification.SMTP)
+		go services.NewEmailDispatcher(notificationRepo, mailer, cfg.Notification).Run(ctx)
+	} else {
+		log.Println("Email notifications disabled: SMTP_HOST or SMTP_FROM not configured")
+	}
+
 	// 初始化路由
 	log.Println("Setting up routes...")
-	router := routes.SetupRoutes(db, cfg, contractManager)
+	router := routes.SetupRoutes(db, cfg, contractManager, broker)
 	log.Println("Routes setup completed")
 
 	// 启动服务器
@@ -116,6 +203,33 @@ func main() {
 	}
 }
 
+// newSigner 根据配置创建后端交易签名器
+func newSigner(cfg config.BlockchainConfig) (blockchain.Signer, error) {
+	switch cfg.Signer.Type {
+	case config.SignerTypeKeystore:
+		return blockchain.NewKeystoreSigner(cfg.Signer.KeystorePath, cfg.Signer.KeystorePassword)
+	case config.SignerTypeRemote:
+		if !common.IsHexAddress(cfg.Signer.Address) {
+			return nil, fmt.Errorf("invalid SIGNER_ADDRESS %q", cfg.Signer.Address)
+		}
+		return blockchain.NewRemoteSigner(cfg.Signer.RemoteURL, common.HexToAddress(cfg.Signer.Address))
+	case config.SignerTypeKey, "":
+		return blockchain.NewMemorySignerFromHex(cfg.PrivateKey)
+	default:
+		return nil, fmt.Errorf("unknown SIGNER_TYPE %q", cfg.Signer.Type)
+	}
+}
+
+// newBroker 根据配置创建实时推送的事件代理
+func newBroker(cfg config.RealtimeConfig) (realtime.Broker, error) {
+	switch cfg.Broker {
+	case config.BrokerTypeMemory, "":
+		return realtime.NewMemoryBroker(cfg.BufferSize), nil
+	default:
+		return nil, fmt.Errorf("unknown REALTIME_BROKER %q", cfg.Broker)
+	}
+}
+
 // 检查合约地址配置
 func checkContractAddresses(cfg *config.Config) {
 	if cfg.Blockchain.TaskRegistryAddress == "" {
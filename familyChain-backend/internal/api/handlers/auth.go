@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/services"
 	"eth-for-babies-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -12,14 +14,23 @@ import (
 )
 
 type AuthHandler struct {
-	db         *gorm.DB
-	jwtManager *utils.JWTManager
+	db                *gorm.DB
+	authService       *services.AuthService
+	sessionService    *services.SessionService
+	inviteCodeService *services.InviteCodeService
 }
 
-func NewAuthHandler(db *gorm.DB, jwtManager *utils.JWTManager) *AuthHandler {
+func NewAuthHandler(
+	db *gorm.DB,
+	authService *services.AuthService,
+	sessionService *services.SessionService,
+	inviteCodeService *services.InviteCodeService,
+) *AuthHandler {
 	return &AuthHandler{
-		db:         db,
-		jwtManager: jwtManager,
+		db:                db,
+		authService:       authService,
+		sessionService:    sessionService,
+		inviteCodeService: inviteCodeService,
 	}
 }
 
@@ -27,10 +38,18 @@ type GetNonceRequest struct {
 	WalletAddress string `uri:"wallet_address" binding:"required"`
 }
 
+// LoginRequest 使用签名后的EIP-4361消息登录
 type LoginRequest struct {
-	WalletAddress string `json:"wallet_address" binding:"required"`
+	Message       string `json:"message" binding:"required"`
 	Signature     string `json:"signature" binding:"required"`
-	Role          string `json:"role,omitempty"`
+	WalletAddress string `json:"wallet_address,omitempty"` // 可选，提供时必须与消息中的地址一致
+	Role          string `json:"role,omitempty"`           // 首次登录时必填，携带邀请码时由邀请码决定
+	InviteCode    string `json:"invite_code,omitempty"`    // 可选，登录的同时兑换家庭邀请码
+}
+
+// RefreshRequest 用刷新令牌换取新令牌
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type RegisterRequest struct {
@@ -38,7 +57,7 @@ type RegisterRequest struct {
 	Role          string `json:"role" binding:"required"`
 }
 
-// GetNonce 获取用于签名的nonce
+// GetNonce 签发一次性nonce，并返回包含该nonce的EIP-4361待签名消息
 func (h *AuthHandler) GetNonce(c *gin.Context) {
 	var req GetNonceRequest
 	if err := c.ShouldBindUri(&req); err != nil {
@@ -58,8 +77,7 @@ func (h *AuthHandler) GetNonce(c *gin.Context) {
 		return
 	}
 
-	// 生成nonce
-	nonce, err := utils.GenerateNonce()
+	message, err := h.authService.IssueNonce(req.WalletAddress)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -68,50 +86,18 @@ func (h *AuthHandler) GetNonce(c *gin.Context) {
 		return
 	}
 
-	// 查找或创建用户记录（仅用于存储nonce）
-	var user models.User
-	result := h.db.Where("wallet_address = ?", strings.ToLower(req.WalletAddress)).First(&user)
-	if result.Error == gorm.ErrRecordNotFound {
-		// 用户不存在，创建临时记录存储nonce
-		user = models.User{
-			WalletAddress: strings.ToLower(req.WalletAddress),
-			Role:          "temp", // 临时角色
-			Nonce:         nonce,
-		}
-		if err := h.db.Create(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to create user record",
-			})
-			return
-		}
-	} else if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Database error",
-		})
-		return
-	} else {
-		// 更新现有用户的nonce
-		user.Nonce = nonce
-		if err := h.db.Save(&user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Failed to update nonce",
-			})
-			return
-		}
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"nonce": nonce,
+			"nonce":      message.Nonce,
+			"message":    message.String(),
+			"issued_at":  message.IssuedAt,
+			"expires_at": message.ExpirationTime,
 		},
 	})
 }
 
-// Login 用户登录
+// Login 校验签名后的EIP-4361消息并签发JWT
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -122,24 +108,63 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 验证钱包地址格式
-	if !utils.IsValidEthereumAddress(req.WalletAddress) {
-		c.JSON(http.StatusBadRequest, gin.H{
+	// 验证消息和签名，并作废nonce
+	message, err := h.authService.VerifyLogin(req.Message, req.Signature)
+	if errors.Is(err, services.ErrLoginRejected) {
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"error":   "Invalid Ethereum address format",
+			"error":   err.Error(),
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to verify login",
 		})
 		return
 	}
 
-	// 查找用户
-	var user models.User
-	result := h.db.Where("wallet_address = ?", strings.ToLower(req.WalletAddress)).First(&user)
-	if result.Error == gorm.ErrRecordNotFound {
+	walletAddress := strings.ToLower(message.Address.Hex())
+	if req.WalletAddress != "" && !strings.EqualFold(req.WalletAddress, walletAddress) {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"error":   "User not found. Please register first.",
+			"error":   "Wallet address does not match the signed message",
 		})
 		return
+	}
+
+	// 携带邀请码时先兑换：按邀请码的角色创建用户或转换临时用户，并加入家庭
+	var joined *services.InviteRedemptionResult
+	if req.InviteCode != "" {
+		joined, err = h.inviteCodeService.Redeem(req.InviteCode, walletAddress)
+		if err != nil {
+			respondMembershipError(c, err)
+			return
+		}
+	}
+
+	// 查找用户，首次登录时按请求的角色创建
+	var user models.User
+	result := h.db.Where("wallet_address = ?", walletAddress).First(&user)
+	if result.Error == gorm.ErrRecordNotFound {
+		if req.Role == "" || !utils.IsValidRole(req.Role) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Valid role required for first login",
+			})
+			return
+		}
+		user = models.User{
+			WalletAddress: walletAddress,
+			Role:          req.Role,
+		}
+		if err := h.db.Create(&user).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to create user",
+			})
+			return
+		}
 	} else if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -148,18 +173,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 验证签名
-	message := utils.GetSignMessage(user.Nonce)
-	valid, err := utils.VerifySignature(req.WalletAddress, message, req.Signature)
-	if err != nil || !valid {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "Invalid signature",
-		})
-		return
-	}
-
-	// 如果用户角色是临时的，需要设置正确的角色
+	// 旧版本获取nonce时创建的临时用户，需要设置正确的角色
 	if user.Role == "temp" {
 		if req.Role == "" || !utils.IsValidRole(req.Role) {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -178,8 +192,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		}
 	}
 
-	// 生成JWT token
-	token, err := h.jwtManager.GenerateToken(user.ID, user.WalletAddress, user.Role)
+	// 创建会话，签发访问令牌和刷新令牌
+	tokens, err := h.sessionService.CreateSession(&user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -191,12 +205,48 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"token": token,
-			"user":  user,
+			"token":              tokens.AccessToken,
+			"expires_at":         tokens.AccessExpiresAt,
+			"refresh_token":      tokens.RefreshToken,
+			"refresh_expires_at": tokens.RefreshExpiresAt,
+			"user":               user,
+			"invite":             joined,
 		},
 	})
 }
 
+// Refresh 轮换刷新令牌并签发新的访问令牌
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(req.RefreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to refresh token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tokens,
+	})
+}
+
 // Register 用户注册
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -242,21 +292,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// 生成初始nonce
-	nonce, err := utils.GenerateNonce()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Failed to generate nonce",
-		})
-		return
-	}
-
 	// 创建新用户
 	user := models.User{
 		WalletAddress: strings.ToLower(req.WalletAddress),
 		Role:          req.Role,
-		Nonce:         nonce,
 	}
 
 	if err := h.db.Create(&user).Error; err != nil {
@@ -275,12 +314,40 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	})
 }
 
-// Logout 用户登出
+// Logout 用户登出，撤销当前会话
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// 在实际应用中，这里可以将token加入黑名单
-	// 目前只是返回成功响应
+	sessionID, _ := c.Get("session_id")
+	if err := h.sessionService.Revoke(sessionID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to revoke session",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Logged out successfully",
 	})
-}
\ No newline at end of file
+}
+
+// LogoutAll 撤销用户在所有设备上的会话
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	revoked, err := h.sessionService.RevokeAll(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to revoke sessions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logged out from all devices",
+		"data": gin.H{
+			"revoked_sessions": revoked,
+		},
+	})
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
 	"eth-for-babies-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,11 +16,12 @@ import (
 )
 
 type ChildHandler struct {
-	db *gorm.DB
+	db                *gorm.DB
+	membershipService *services.MembershipService
 }
 
-func NewChildHandler(db *gorm.DB) *ChildHandler {
-	return &ChildHandler{db: db}
+func NewChildHandler(db *gorm.DB, membershipService *services.MembershipService) *ChildHandler {
+	return &ChildHandler{db: db, membershipService: membershipService}
 }
 
 type CreateChildRequest struct {
@@ -25,6 +29,8 @@ type CreateChildRequest struct {
 	WalletAddress string `json:"wallet_address" binding:"required"`
 	Age           int    `json:"age" binding:"required,min=1"`
 	Avatar        string `json:"avatar,omitempty"`
+	// 家长属于多个家庭时需要指定孩子加入哪个家庭
+	FamilyID *uint `json:"family_id,omitempty"`
 }
 
 type UpdateChildRequest struct {
@@ -89,29 +95,25 @@ func (h *ChildHandler) CreateChild(c *gin.Context) {
 		return
 	}
 
-	// 确保父母有家庭
-	var family models.Family
-	result = h.db.Where("parent_address = ?", walletAddress).First(&family)
-	if result.Error == gorm.ErrRecordNotFound {
+	// 确定孩子加入的家庭，调用者必须是该家庭的所有者或共同家长
+	family, err := h.membershipService.ResolveManagedFamily(walletAddress.(string), req.FamilyID)
+	if errors.Is(err, services.ErrNotFamilyMember) && req.FamilyID == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Please create a family first",
 		})
 		return
-	} else if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Database error",
-		})
+	} else if err != nil {
+		respondMembershipError(c, err)
 		return
 	}
 
-	// 创建孩子记录
+	// 创建孩子记录，孩子通过家庭创建者的地址关联到家庭
 	child := models.Child{
 		Name:                utils.SanitizeString(req.Name),
 		WalletAddress:       strings.ToLower(req.WalletAddress),
 		Age:                 req.Age,
-		ParentAddress:       walletAddress.(string),
+		ParentAddress:       family.ParentAddress,
 		TotalTasksCompleted: 0,
 		TotalRewardsEarned:  "0",
 	}
@@ -158,8 +160,9 @@ func (h *ChildHandler) GetChildren(c *gin.Context) {
 	var query *gorm.DB
 
 	if role == "parent" {
-		// 父母可以看到自己的所有孩子
-		query = h.db.Where("parent_address = ?", walletAddress)
+		// 家长可以看到自己加入的所有家庭的孩子
+		familyIDs := h.db.Model(&models.FamilyMember{}).Select("family_id").Where("wallet_address = ?", walletAddress)
+		query = h.db.Where("parent_address IN (?)", h.db.Model(&models.Family{}).Select("parent_address").Where("id IN (?)", familyIDs))
 	} else {
 		// 孩子只能看到自己的信息
 		query = h.db.Where("wallet_address = ?", walletAddress)
@@ -218,12 +221,11 @@ func (h *ChildHandler) GetChildByID(c *gin.Context) {
 
 	// 检查权限
 	role, _ := c.Get("role")
-	if role == "parent" && child.ParentAddress != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Access denied",
-		})
-		return
+	if role == "parent" {
+		if err := h.membershipService.AuthorizeChild(&child, walletAddress.(string), false); err != nil {
+			respondMembershipError(c, err)
+			return
+		}
 	} else if role == "child" && child.WalletAddress != walletAddress.(string) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
@@ -285,14 +287,13 @@ func (h *ChildHandler) UpdateChild(c *gin.Context) {
 		return
 	}
 
-	// 检查权限（父母或孩子本人可以更新）
+	// 检查权限（家长或共同家长，或孩子本人可以更新）
 	role, _ := c.Get("role")
-	if role == "parent" && child.ParentAddress != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Access denied",
-		})
-		return
+	if role == "parent" {
+		if err := h.membershipService.AuthorizeChild(&child, walletAddress.(string), true); err != nil {
+			respondMembershipError(c, err)
+			return
+		}
 	} else if role == "child" && child.WalletAddress != walletAddress.(string) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
@@ -366,12 +367,11 @@ func (h *ChildHandler) GetChildProgress(c *gin.Context) {
 
 	// 检查权限
 	role, _ := c.Get("role")
-	if role == "parent" && child.ParentAddress != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Access denied",
-		})
-		return
+	if role == "parent" {
+		if err := h.membershipService.AuthorizeChild(&child, walletAddress.(string), false); err != nil {
+			respondMembershipError(c, err)
+			return
+		}
 	} else if role == "child" && child.WalletAddress != walletAddress.(string) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
@@ -395,10 +395,32 @@ func (h *ChildHandler) GetChildProgress(c *gin.Context) {
 	h.db.Model(&models.Task{}).Where("assigned_child_id = ? AND status = ?", id, "approved").Count(&taskStats.Approved)
 	h.db.Model(&models.Task{}).Where("assigned_child_id = ? AND status = ?", id, "rejected").Count(&taskStats.Rejected)
 
+	// 代币余额和累计收到的代币以账本为准，total_rewards_earned 兼容旧客户端，同样取账本中的铸币
+	ledgerRepo := repository.NewLedgerRepository(h.db)
+	account := strings.ToLower(child.WalletAddress)
+	balance, err := ledgerRepo.Balance(account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to read token ledger",
+		})
+		return
+	}
+	minted, err := ledgerRepo.Minted(account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to read token ledger",
+		})
+		return
+	}
+
 	progress := gin.H{
 		"child":                 child,
 		"total_tasks_completed": child.TotalTasksCompleted,
-		"total_rewards_earned":  child.TotalRewardsEarned,
+		"total_rewards_earned":  minted.String(),
+		"token_balance":         balance.String(),
+		"tokens_earned":         minted.String(),
 		"task_statistics":       taskStats,
 	}
 
@@ -446,15 +468,19 @@ func (h *ChildHandler) DeleteChild(c *gin.Context) {
 		return
 	}
 
-	// 只有父母能删除自己的孩子
+	// 只有家庭的所有者或共同家长能删除孩子
 	role, _ := c.Get("role")
-	if role != "parent" || child.ParentAddress != walletAddress.(string) {
+	if role != "parent" {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
 			"error":   "Access denied",
 		})
 		return
 	}
+	if err := h.membershipService.AuthorizeChild(&child, walletAddress.(string), true); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
 
 	// 使用 Unscoped().Delete 实现永久删除，而不是软删除
 	if err := h.db.Unscoped().Delete(&child).Error; err != nil {
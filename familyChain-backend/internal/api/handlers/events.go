@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"eth-for-babies-backend/internal/api/middleware"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventsHandler 通过Server-Sent Events向已登录用户推送家庭中的任务和兑换变化
+type EventsHandler struct {
+	broker            realtime.Broker
+	membershipService *services.MembershipService
+	childService      *services.ChildService
+	sessions          middleware.SessionChecker
+	heartbeat         time.Duration
+}
+
+// NewEventsHandler 创建实时推送处理器，heartbeat是空闲时发送心跳的间隔
+func NewEventsHandler(broker realtime.Broker, membershipService *services.MembershipService, childService *services.ChildService, sessions middleware.SessionChecker, heartbeat time.Duration) *EventsHandler {
+	if heartbeat <= 0 {
+		heartbeat = 25 * time.Second
+	}
+	if broker == nil {
+		broker = realtime.Nop{}
+	}
+	return &EventsHandler{
+		broker:            broker,
+		membershipService: membershipService,
+		childService:      childService,
+		sessions:          sessions,
+		heartbeat:         heartbeat,
+	}
+}
+
+// Stream 保持连接并推送事件，直到客户端断开或会话被撤销
+// 订阅范围在连接时确定：家长收到所在的所有家庭的事件，孩子只收到自己家庭中与自己相关的事件
+func (h *EventsHandler) Stream(c *gin.Context) {
+	sub, ok := h.subscription(c)
+	if !ok {
+		return
+	}
+
+	events, unsubscribe := h.broker.Subscribe(sub)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭Nginx的响应缓冲
+	c.Status(http.StatusOK)
+
+	// 先告诉客户端订阅已经建立，客户端可以在此之后重新获取一次数据，避免错过连接之前的变化
+	if !writeSSE(c, "", "ready", gin.H{"family_ids": sub.FamilyIDs}) {
+		return
+	}
+
+	sessionID := c.GetUint("session_id")
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !writeSSE(c, fmt.Sprint(event.ID), string(event.Type), event) {
+				return
+			}
+		case <-ticker.C:
+			// 登出或会话被撤销后关闭连接
+			if active, err := h.sessions.IsActive(sessionID); err == nil && !active {
+				writeSSE(c, "", "revoked", gin.H{"reason": "session revoked"})
+				return
+			}
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// subscription 根据当前用户确定订阅的家庭，失败时直接返回错误响应
+func (h *EventsHandler) subscription(c *gin.Context) (realtime.Subscription, bool) {
+	walletAddress := c.GetString("wallet_address")
+
+	if c.GetString("role") != "child" {
+		familyIDs, err := h.membershipService.FamilyIDs(walletAddress)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to load families",
+			})
+			return realtime.Subscription{}, false
+		}
+		return realtime.Subscription{FamilyIDs: familyIDs}, true
+	}
+
+	child, err := h.childService.GetChildByWalletAddress(walletAddress)
+	if err != nil || child == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Child record not found",
+		})
+		return realtime.Subscription{}, false
+	}
+	sub := realtime.Subscription{ChildID: &child.ID}
+	if familyID, err := h.membershipService.FamilyIDForChild(child); err == nil {
+		sub.FamilyIDs = []uint{familyID}
+	}
+	return sub, true
+}
+
+// writeSSE 写入一条SSE消息并立即发送，连接已断开时返回false
+func writeSSE(c *gin.Context, id, event string, data interface{}) bool {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("[realtime] 事件序列化失败: %v", err)
+		return true
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", id); err != nil {
+			return false
+		}
+	}
+	if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
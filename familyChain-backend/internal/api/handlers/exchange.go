@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,19 +15,22 @@ import (
 	"eth-for-babies-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // ExchangeHandler 处理兑换相关的API请求
 type ExchangeHandler struct {
-	rewardService *services.RewardService
-	childService  *services.ChildService
+	rewardService     *services.RewardService
+	childService      *services.ChildService
+	membershipService *services.MembershipService
 }
 
 // NewExchangeHandler 创建新的兑换处理器
-func NewExchangeHandler(rewardService *services.RewardService, childService *services.ChildService) *ExchangeHandler {
+func NewExchangeHandler(rewardService *services.RewardService, childService *services.ChildService, membershipService *services.MembershipService) *ExchangeHandler {
 	return &ExchangeHandler{
-		rewardService: rewardService,
-		childService:  childService,
+		rewardService:     rewardService,
+		childService:      childService,
+		membershipService: membershipService,
 	}
 }
 
@@ -114,7 +119,13 @@ func (h *ExchangeHandler) ExchangeReward(c *gin.Context) {
 		statusCode := http.StatusInternalServerError
 		errorMessage := "兑换奖品失败: " + err.Error()
 
-		if strings.Contains(err.Error(), "insufficient funds") {
+		if errors.Is(err, services.ErrInvalidExchange) {
+			statusCode = http.StatusBadRequest
+			errorMessage = err.Error()
+		} else if errors.Is(err, services.ErrOutOfStock) {
+			statusCode = http.StatusConflict
+			errorMessage = "奖品库存不足"
+		} else if errors.Is(err, services.ErrInsufficientBalance) || strings.Contains(err.Error(), "insufficient funds") {
 			statusCode = http.StatusBadRequest
 			errorMessage = "余额不足，无法兑换奖品"
 		} else if strings.Contains(err.Error(), "nonce too low") {
@@ -150,35 +161,33 @@ func (h *ExchangeHandler) ExchangeReward(c *gin.Context) {
 
 // GetChildExchanges 获取孩子的兑换记录
 func (h *ExchangeHandler) GetChildExchanges(c *gin.Context) {
-	// 获取当前用户信息
-	childID, exists := c.Get("user_id")
-	if !exists {
-		fmt.Printf("获取兑换记录失败: 用户未认证\n")
-		c.JSON(http.StatusUnauthorized, gin.H{
+	// 通过钱包地址获取当前孩子，孩子只能查看自己的兑换记录
+	child, err := h.childService.GetByWalletAddress(c.Request.Context(), c.GetString("wallet_address"))
+	if err != nil || child == nil {
+		fmt.Printf("获取兑换记录失败: 未找到孩子记录: %v\n", err)
+		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "用户未认证",
+			"error":   "未找到对应的孩子记录",
 		})
 		return
 	}
 
-	fmt.Printf("获取孩子兑换记录，childID: %v, 类型: %T\n", childID, childID)
-
-	// 检查URL参数是否包含child_id，如果有则使用该ID
-	childIDParam := c.Query("child_id")
-	if childIDParam != "" {
+	// 兼容旧客户端传入的child_id参数，但只能是自己的ID
+	if childIDParam := c.Query("child_id"); childIDParam != "" {
 		childIDInt, err := strconv.ParseUint(childIDParam, 10, 64)
-		if err == nil {
-			fmt.Printf("使用URL参数中的child_id: %s\n", childIDParam)
-			childID = uint(childIDInt)
-		} else {
-			fmt.Printf("解析URL参数child_id失败: %v\n", err)
+		if err != nil || uint(childIDInt) != child.ID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Access denied",
+			})
+			return
 		}
 	}
 
-	fmt.Printf("最终使用的childID: %v\n", childID)
+	fmt.Printf("获取孩子兑换记录，childID: %d\n", child.ID)
 
 	// 获取兑换记录
-	exchanges, err := h.rewardService.GetChildExchanges(c.Request.Context(), childID.(uint))
+	exchanges, err := h.rewardService.GetChildExchanges(c.Request.Context(), child.ID)
 	if err != nil {
 		fmt.Printf("获取兑换记录失败: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -213,20 +222,8 @@ func (h *ExchangeHandler) GetExchangeByID(c *gin.Context) {
 	}
 
 	// 获取兑换详情
-	exchange, err := h.rewardService.GetExchange(c.Request.Context(), uint(exchangeID))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "获取兑换详情失败: " + err.Error(),
-		})
-		return
-	}
-
-	if exchange == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "兑换记录不存在",
-		})
+	exchange, ok := h.authorizedExchange(c, uint(exchangeID), false)
+	if !ok {
 		return
 	}
 
@@ -263,9 +260,15 @@ func (h *ExchangeHandler) GetFamilyExchanges(c *gin.Context) {
 		return
 	}
 
-	userRole, _ := c.Get("user_role")
+	userRole := c.GetString("role")
 	fmt.Printf("请求用户信息 - ID: %v, 角色: %v\n", userID, userRole)
 
+	// 家庭成员和家庭中的孩子可以查看家庭的兑换记录
+	if err := h.membershipService.AuthorizeFamilyView(uint(familyID), c.GetString("wallet_address"), userRole); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
 	// 获取兑换记录
 	exchanges, err := h.rewardService.GetFamilyExchanges(c.Request.Context(), uint(familyID))
 	if err != nil {
@@ -295,7 +298,7 @@ func (h *ExchangeHandler) GetFamilyExchanges(c *gin.Context) {
 	})
 }
 
-// UpdateExchangeStatus 更新兑换状态
+// UpdateExchangeStatus 更新兑换状态，兼容旧客户端：completed表示批准并交付，cancelled表示拒绝
 func (h *ExchangeHandler) UpdateExchangeStatus(c *gin.Context) {
 	var req models.ExchangeUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -306,42 +309,60 @@ func (h *ExchangeHandler) UpdateExchangeStatus(c *gin.Context) {
 		return
 	}
 
-	// 获取兑换ID
-	exchangeIDStr := c.Param("id")
-	exchangeID, err := strconv.ParseUint(exchangeIDStr, 10, 64)
-	if err != nil {
+	h.decideExchange(c, func(ctx context.Context, exchangeID uint, actor, notes string) error {
+		return h.rewardService.UpdateExchangeStatus(ctx, exchangeID, actor, models.ExchangeUpdateRequest{Status: req.Status, Notes: notes})
+	}, req.Notes)
+}
+
+// ApproveExchange 批准待审核的兑换，托管的代币在链上销毁
+func (h *ExchangeHandler) ApproveExchange(c *gin.Context) {
+	h.decideExchangeWithBody(c, h.rewardService.ApproveExchange)
+}
+
+// DeclineExchange 拒绝待审核的兑换，托管的代币退还给孩子
+func (h *ExchangeHandler) DeclineExchange(c *gin.Context) {
+	h.decideExchangeWithBody(c, h.rewardService.DeclineExchange)
+}
+
+// FulfillExchange 标记已批准兑换的奖品已经交给孩子
+func (h *ExchangeHandler) FulfillExchange(c *gin.Context) {
+	h.decideExchangeWithBody(c, func(ctx context.Context, exchangeID uint, _ string, notes string) error {
+		return h.rewardService.FulfillExchange(ctx, exchangeID, notes)
+	})
+}
+
+// decideExchangeWithBody 读取可选的备注后处理兑换
+func (h *ExchangeHandler) decideExchangeWithBody(c *gin.Context, action func(ctx context.Context, exchangeID uint, actor, notes string) error) {
+	var req models.ExchangeDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "无效的兑换ID",
+			"error":   "无效的请求数据: " + err.Error(),
 		})
 		return
 	}
+	h.decideExchange(c, action, req.Notes)
+}
 
-	// 获取当前用户信息
-	_, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   "用户未认证",
-		})
+// decideExchange 检查当前用户可以管理兑换后执行操作，返回更新后的兑换详情
+func (h *ExchangeHandler) decideExchange(c *gin.Context, action func(ctx context.Context, exchangeID uint, actor, notes string) error, notes string) {
+	exchangeID, ok := uintParam(c, "id", "无效的兑换ID")
+	if !ok {
 		return
 	}
 
-	// 净化输入数据
-	req.Notes = utils.SanitizeString(req.Notes)
+	// 只有孩子所在家庭的所有者或共同家长可以处理兑换
+	if _, ok := h.authorizedExchange(c, exchangeID, true); !ok {
+		return
+	}
 
-	// 更新兑换状态
-	err = h.rewardService.UpdateExchangeStatus(c.Request.Context(), uint(exchangeID), req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "更新兑换状态失败: " + err.Error(),
-		})
+	if err := action(c.Request.Context(), exchangeID, c.GetString("wallet_address"), utils.SanitizeString(notes)); err != nil {
+		respondExchangeError(c, err)
 		return
 	}
 
 	// 获取更新后的兑换详情
-	exchange, err := h.rewardService.GetExchange(c.Request.Context(), uint(exchangeID))
+	exchange, err := h.rewardService.GetExchange(c.Request.Context(), exchangeID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -355,3 +376,67 @@ func (h *ExchangeHandler) UpdateExchangeStatus(c *gin.Context) {
 		"data":    exchange,
 	})
 }
+
+// respondExchangeError 参数错误返回400，状态不允许的操作返回409，其他错误按家庭成员检查的规则处理
+func respondExchangeError(c *gin.Context, err error) {
+	status := 0
+	switch {
+	case errors.Is(err, services.ErrInvalidExchange), errors.Is(err, services.ErrInsufficientBalance):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrExchangeState), errors.Is(err, services.ErrOutOfStock):
+		status = http.StatusConflict
+	default:
+		respondMembershipError(c, err)
+		return
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// authorizedExchange 获取兑换记录并检查当前用户的权限，失败时直接返回错误响应
+// 孩子只能访问自己的兑换记录；家长必须是孩子所在家庭的成员，manage为true时要求所有者或共同家长
+func (h *ExchangeHandler) authorizedExchange(c *gin.Context, exchangeID uint, manage bool) (*models.Exchange, bool) {
+	exchange, err := h.rewardService.GetExchange(c.Request.Context(), exchangeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && exchange == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "兑换记录不存在",
+		})
+		return nil, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取兑换详情失败: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	child, err := h.childService.GetChildByID(exchange.ChildID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Access denied",
+		})
+		return nil, false
+	}
+
+	walletAddress := c.GetString("wallet_address")
+	if c.GetString("role") == "child" {
+		if manage || child.WalletAddress != walletAddress {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Access denied",
+			})
+			return nil, false
+		}
+		return exchange, true
+	}
+
+	if err := h.membershipService.AuthorizeChild(child, walletAddress, manage); err != nil {
+		respondMembershipError(c, err)
+		return nil, false
+	}
+	return exchange, true
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
 	"eth-for-babies-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -12,11 +14,12 @@ import (
 )
 
 type FamilyHandler struct {
-	db *gorm.DB
+	db                *gorm.DB
+	membershipService *services.MembershipService
 }
 
-func NewFamilyHandler(db *gorm.DB) *FamilyHandler {
-	return &FamilyHandler{db: db}
+func NewFamilyHandler(db *gorm.DB, membershipService *services.MembershipService) *FamilyHandler {
+	return &FamilyHandler{db: db, membershipService: membershipService}
 }
 
 type CreateFamilyRequest struct {
@@ -25,10 +28,9 @@ type CreateFamilyRequest struct {
 
 type UpdateFamilyRequest struct {
 	Name string `json:"name"`
-}
-
-type AddMemberRequest struct {
-	WalletAddress string `json:"wallet_address" binding:"required"`
+	// 迟交规则，nil表示不修改
+	AllowLateSubmissions *bool `json:"allow_late_submissions,omitempty"`
+	LatePenaltyPercent   *int  `json:"late_penalty_percent,omitempty"`
 }
 
 // CreateFamily 创建家庭
@@ -78,13 +80,13 @@ func (h *FamilyHandler) CreateFamily(c *gin.Context) {
 		return
 	}
 
-	// 创建家庭
+	// 创建家庭，创建者成为所有者
 	family := models.Family{
 		Name:          utils.SanitizeString(req.Name),
 		ParentAddress: walletAddress.(string),
 	}
 
-	if err := h.db.Create(&family).Error; err != nil {
+	if err := repository.NewFamilyRepository(h.db).CreateWithOwner(&family); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to create family",
@@ -93,7 +95,7 @@ func (h *FamilyHandler) CreateFamily(c *gin.Context) {
 	}
 
 	// 预加载关联数据
-	h.db.Preload("Children").First(&family, family.ID)
+	h.db.Preload("Children").Preload("Members").First(&family, family.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -125,14 +127,14 @@ func (h *FamilyHandler) GetFamilies(c *gin.Context) {
 	var query *gorm.DB
 
 	if role == "parent" {
-		// 父母只能看到自己的家庭
-		query = h.db.Where("parent_address = ?", walletAddress)
+		// 家长可以看到自己作为成员加入的所有家庭
+		query = h.db.Where("id IN (?)", h.db.Model(&models.FamilyMember{}).Select("family_id").Where("wallet_address = ?", walletAddress))
 	} else {
 		// 孩子可以看到自己所属的家庭
 		query = h.db.Joins("JOIN children ON families.parent_address = children.parent_address").Where("children.wallet_address = ?", walletAddress)
 	}
 
-	if err := query.Preload("Children").Find(&families).Error; err != nil {
+	if err := query.Preload("Children").Preload("Members").Find(&families).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to fetch families",
@@ -168,7 +170,7 @@ func (h *FamilyHandler) GetFamilyByID(c *gin.Context) {
 	}
 
 	var family models.Family
-	result := h.db.Preload("Children").First(&family, uint(id))
+	result := h.db.Preload("Children").Preload("Members").First(&family, uint(id))
 	if result.Error == gorm.ErrRecordNotFound {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -185,12 +187,11 @@ func (h *FamilyHandler) GetFamilyByID(c *gin.Context) {
 
 	// 检查权限
 	role, _ := c.Get("role")
-	if role == "parent" && family.ParentAddress != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Access denied",
-		})
-		return
+	if role == "parent" {
+		if err := h.membershipService.Authorize(family.ID, walletAddress.(string), false); err != nil {
+			respondMembershipError(c, err)
+			return
+		}
 	} else if role == "child" {
 		// 检查孩子是否属于这个家庭
 		var child models.Child
@@ -257,12 +258,9 @@ func (h *FamilyHandler) UpdateFamily(c *gin.Context) {
 		return
 	}
 
-	// 检查权限（只有家庭的父母可以更新）
-	if family.ParentAddress != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Only the family parent can update family information",
-		})
+	// 检查权限（只有家庭的所有者可以更新）
+	if err := h.membershipService.AuthorizeOwner(family.ID, walletAddress.(string)); err != nil {
+		respondMembershipError(c, err)
 		return
 	}
 
@@ -270,6 +268,19 @@ func (h *FamilyHandler) UpdateFamily(c *gin.Context) {
 	if req.Name != "" {
 		family.Name = utils.SanitizeString(req.Name)
 	}
+	if req.AllowLateSubmissions != nil {
+		family.AllowLateSubmissions = *req.AllowLateSubmissions
+	}
+	if req.LatePenaltyPercent != nil {
+		if *req.LatePenaltyPercent < 0 || *req.LatePenaltyPercent > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "late_penalty_percent must be between 0 and 100",
+			})
+			return
+		}
+		family.LatePenaltyPercent = *req.LatePenaltyPercent
+	}
 
 	if err := h.db.Save(&family).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -280,7 +291,7 @@ func (h *FamilyHandler) UpdateFamily(c *gin.Context) {
 	}
 
 	// 预加载关联数据
-	h.db.Preload("Children").First(&family, family.ID)
+	h.db.Preload("Children").Preload("Members").First(&family, family.ID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
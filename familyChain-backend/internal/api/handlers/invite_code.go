@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InviteCodeHandler 处理家庭邀请码相关的API请求
+type InviteCodeHandler struct {
+	inviteCodeService *services.InviteCodeService
+}
+
+// NewInviteCodeHandler 创建一个新的邀请码处理器
+func NewInviteCodeHandler(inviteCodeService *services.InviteCodeService) *InviteCodeHandler {
+	return &InviteCodeHandler{inviteCodeService: inviteCodeService}
+}
+
+// CreateInviteCodeRequest 生成邀请码，邀请孩子时需要填写孩子的名字和年龄
+type CreateInviteCodeRequest struct {
+	Role      string `json:"role" binding:"required"`
+	ChildName string `json:"child_name,omitempty"`
+	ChildAge  int    `json:"child_age,omitempty"`
+}
+
+// InviteCodeRequest 预览或兑换邀请码
+type InviteCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// CreateInviteCode 为家庭生成一次性邀请码和邀请链接
+func (h *InviteCodeHandler) CreateInviteCode(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+
+	var req CreateInviteCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	issued, err := h.inviteCodeService.Create(familyID, c.GetString("wallet_address"), req.Role, req.ChildName, req.ChildAge)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    issued,
+	})
+}
+
+// ListInviteCodes 获取家庭生成的邀请码（不包含明文邀请码）
+func (h *InviteCodeHandler) ListInviteCodes(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+
+	codes, err := h.inviteCodeService.List(familyID, c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    codes,
+	})
+}
+
+// RevokeInviteCode 撤销尚未兑换的邀请码
+func (h *InviteCodeHandler) RevokeInviteCode(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+	codeID, ok := uintParam(c, "code_id", "Invalid invite code ID")
+	if !ok {
+		return
+	}
+
+	if err := h.inviteCodeService.Revoke(familyID, codeID, c.GetString("wallet_address")); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Invite code revoked",
+	})
+}
+
+// PreviewInviteCode 登录前查看邀请码对应的家庭和角色
+func (h *InviteCodeHandler) PreviewInviteCode(c *gin.Context) {
+	var req InviteCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	preview, err := h.inviteCodeService.Preview(req.Code)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    preview,
+	})
+}
+
+// RedeemInviteCode 已登录的用户兑换邀请码加入家庭
+func (h *InviteCodeHandler) RedeemInviteCode(c *gin.Context) {
+	var req InviteCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	result, err := h.inviteCodeService.Redeem(req.Code, c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
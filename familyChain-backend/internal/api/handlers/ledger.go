@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LedgerHandler 处理代币账本相关的API请求
+type LedgerHandler struct {
+	ledgerService     *services.LedgerService
+	childService      *services.ChildService
+	membershipService *services.MembershipService
+}
+
+// NewLedgerHandler 创建一个新的代币账本处理器
+func NewLedgerHandler(ledgerService *services.LedgerService, childService *services.ChildService, membershipService *services.MembershipService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService:     ledgerService,
+		childService:      childService,
+		membershipService: membershipService,
+	}
+}
+
+// GetChildLedger 获取孩子的账本余额和最近的分录，孩子只能查看自己的账本
+func (h *LedgerHandler) GetChildLedger(c *gin.Context) {
+	childID, ok := uintParam(c, "id", "Invalid child ID")
+	if !ok {
+		return
+	}
+
+	child, err := h.childService.GetChildByID(childID)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+	walletAddress := c.GetString("wallet_address")
+	if c.GetString("role") == "child" {
+		if !strings.EqualFold(child.WalletAddress, walletAddress) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Access denied",
+			})
+			return
+		}
+	} else if err := h.membershipService.AuthorizeChild(child, walletAddress, false); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	statement, err := h.ledgerService.Statement(child, limit)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    statement,
+	})
+}
+
+// ReconcileFamilyLedger 把家庭中每个孩子的账本余额与链上余额核对，返回差异
+func (h *LedgerHandler) ReconcileFamilyLedger(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+	if err := h.membershipService.Authorize(familyID, c.GetString("wallet_address"), false); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	report, err := h.ledgerService.Reconcile(c.Request.Context(), familyID)
+	if errors.Is(err, services.ErrLedgerUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
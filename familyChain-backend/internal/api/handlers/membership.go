@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MembershipHandler 处理家庭成员和邀请相关的API请求
+type MembershipHandler struct {
+	membershipService *services.MembershipService
+}
+
+// NewMembershipHandler 创建一个新的家庭成员处理器
+func NewMembershipHandler(membershipService *services.MembershipService) *MembershipHandler {
+	return &MembershipHandler{membershipService: membershipService}
+}
+
+type InviteMemberRequest struct {
+	WalletAddress string `json:"wallet_address" binding:"required"`
+	Role          string `json:"role" binding:"required"`
+}
+
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ListMembers 获取家庭成员列表
+func (h *MembershipHandler) ListMembers(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+
+	members, err := h.membershipService.ListMembers(familyID, c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    members,
+	})
+}
+
+// UpdateMemberRole 修改家庭成员的角色（仅所有者）
+func (h *MembershipHandler) UpdateMemberRole(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+	memberID, ok := uintParam(c, "member_id", "Invalid member ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	member, err := h.membershipService.UpdateMemberRole(familyID, memberID, req.Role, c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    member,
+	})
+}
+
+// RemoveMember 移除家庭成员；所有者可以移除其他成员，成员也可以移除自己退出家庭
+func (h *MembershipHandler) RemoveMember(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+	memberID, ok := uintParam(c, "member_id", "Invalid member ID")
+	if !ok {
+		return
+	}
+
+	if err := h.membershipService.RemoveMember(familyID, memberID, c.GetString("wallet_address")); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Member removed from family",
+	})
+}
+
+// CreateInvitation 邀请钱包以指定角色加入家庭（仅所有者）
+func (h *MembershipHandler) CreateInvitation(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+
+	var req InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	invitation, err := h.membershipService.Invite(familyID, c.GetString("wallet_address"), req.WalletAddress, req.Role)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    invitation,
+	})
+}
+
+// ListFamilyInvitations 获取家庭发出的邀请（仅所有者）
+func (h *MembershipHandler) ListFamilyInvitations(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.membershipService.ListFamilyInvitations(familyID, c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    invitations,
+	})
+}
+
+// RevokeInvitation 撤销尚未处理的邀请（仅所有者）
+func (h *MembershipHandler) RevokeInvitation(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+	invitationID, ok := uintParam(c, "invitation_id", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	if err := h.membershipService.RevokeInvitation(familyID, invitationID, c.GetString("wallet_address")); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Invitation revoked",
+	})
+}
+
+// GetMyInvitations 获取当前用户收到的待处理邀请
+func (h *MembershipHandler) GetMyInvitations(c *gin.Context) {
+	invitations, err := h.membershipService.PendingInvitations(c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    invitations,
+	})
+}
+
+// AcceptInvitation 接受邀请并加入家庭
+func (h *MembershipHandler) AcceptInvitation(c *gin.Context) {
+	invitationID, ok := uintParam(c, "id", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	member, err := h.membershipService.AcceptInvitation(invitationID, c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    member,
+	})
+}
+
+// DeclineInvitation 拒绝邀请
+func (h *MembershipHandler) DeclineInvitation(c *gin.Context) {
+	invitationID, ok := uintParam(c, "id", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	if err := h.membershipService.DeclineInvitation(invitationID, c.GetString("wallet_address")); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Invitation declined",
+	})
+}
+
+// uintParam 解析路径中的ID参数，失败时直接返回400
+func uintParam(c *gin.Context, name, message string) (uint, bool) {
+	value, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return 0, false
+	}
+	return uint(value), true
+}
+
+// respondMembershipError 把家庭成员检查的错误转换为HTTP响应
+func respondMembershipError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	message := err.Error()
+
+	switch {
+	case errors.Is(err, services.ErrNotFamilyMember):
+		status = http.StatusForbidden
+		message = "Access denied: you are not a member of this family"
+	case errors.Is(err, services.ErrFamilyPermission):
+		status = http.StatusForbidden
+		message = "Access denied: your family role does not allow this action"
+	case errors.Is(err, services.ErrInvitationNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+		message = "Not found"
+	case errors.Is(err, services.ErrAlreadyMember),
+		errors.Is(err, services.ErrInvitationPending),
+		errors.Is(err, services.ErrInvitationClosed),
+		errors.Is(err, services.ErrFamilyCreator):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrFamilyRequired),
+		errors.Is(err, services.ErrInvalidMembership),
+		errors.Is(err, services.ErrInvalidInviteCode):
+		status = http.StatusBadRequest
+	default:
+		log.Printf("家庭成员操作失败: %v", err)
+		status = http.StatusInternalServerError
+		message = "Database error"
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 处理站内通知和通知设置相关的API请求
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationHandler 创建一个新的通知处理器
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// NotificationPreferenceRequest 一种通知的渠道设置
+type NotificationPreferenceRequest struct {
+	Type  string `json:"type" binding:"required"`
+	InApp bool   `json:"in_app"`
+	Email bool   `json:"email"`
+}
+
+// UpdateNotificationSettingsRequest 修改通知设置
+type UpdateNotificationSettingsRequest struct {
+	Email       *string                         `json:"email,omitempty"` // 空字符串表示删除邮件地址
+	Preferences []NotificationPreferenceRequest `json:"preferences,omitempty"`
+}
+
+// GetNotifications 按时间倒序获取当前用户的通知和未读数，unread=true时只返回未读的
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	notifications, unread, err := h.notificationService.List(c.GetString("wallet_address"), c.Query("unread") == "true", limit)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"unread":        unread,
+			"notifications": notifications,
+		},
+	})
+}
+
+// MarkNotificationRead 把一条通知标记为已读
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid notification ID")
+	if !ok {
+		return
+	}
+
+	notification, err := h.notificationService.MarkRead(id, c.GetString("wallet_address"))
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notification,
+	})
+}
+
+// MarkAllNotificationsRead 把当前用户的所有通知标记为已读
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	count, err := h.notificationService.MarkAllRead(c.GetString("wallet_address"))
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"marked": count},
+	})
+}
+
+// GetNotificationSettings 获取当前用户的邮件地址和每种通知的渠道设置
+func (h *NotificationHandler) GetNotificationSettings(c *gin.Context) {
+	settings, err := h.notificationService.GetSettings(c.GetString("wallet_address"))
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// UpdateNotificationSettings 修改邮件地址和提交的通知类型的渠道设置，没有提交的类型保持不变
+func (h *NotificationHandler) UpdateNotificationSettings(c *gin.Context) {
+	var req UpdateNotificationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	preferences := make([]*models.NotificationPreference, 0, len(req.Preferences))
+	for _, preference := range req.Preferences {
+		preferences = append(preferences, &models.NotificationPreference{
+			Type:  models.NotificationType(preference.Type),
+			InApp: preference.InApp,
+			Email: preference.Email,
+		})
+	}
+
+	settings, err := h.notificationService.UpdateSettings(c.GetString("wallet_address"), req.Email, preferences)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// respondNotificationError 参数错误返回400，其他错误按家庭成员检查的规则处理
+func respondNotificationError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidNotificationPreference) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	respondMembershipError(c, err)
+}
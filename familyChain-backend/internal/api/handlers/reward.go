@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,17 +11,20 @@ import (
 	"eth-for-babies-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // RewardHandler 处理奖品相关的API请求
 type RewardHandler struct {
-	rewardService *services.RewardService
+	rewardService     *services.RewardService
+	membershipService *services.MembershipService
 }
 
 // NewRewardHandler 创建一个新的奖品处理器
-func NewRewardHandler(rewardService *services.RewardService) *RewardHandler {
+func NewRewardHandler(rewardService *services.RewardService, membershipService *services.MembershipService) *RewardHandler {
 	return &RewardHandler{
-		rewardService: rewardService,
+		rewardService:     rewardService,
+		membershipService: membershipService,
 	}
 }
 
@@ -97,6 +101,12 @@ func (h *RewardHandler) CreateReward(c *gin.Context) {
 		return
 	}
 
+	// 只有家庭的所有者或共同家长可以创建奖品
+	if err := h.membershipService.Authorize(uint(familyID), c.GetString("wallet_address"), true); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
 	// 净化输入数据
 	req.Name = utils.SanitizeString(req.Name)
 	req.Description = utils.SanitizeString(req.Description)
@@ -146,6 +156,12 @@ func (h *RewardHandler) GetRewards(c *gin.Context) {
 		return
 	}
 
+	// 家庭成员和家庭中的孩子可以查看奖品
+	if err := h.membershipService.AuthorizeFamilyView(uint(familyID), c.GetString("wallet_address"), c.GetString("role")); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
 	// 获取查询参数
 	activeOnly := true
 	if c.Query("active_only") == "false" {
@@ -183,7 +199,13 @@ func (h *RewardHandler) GetRewardByID(c *gin.Context) {
 
 	// 获取奖品详情
 	reward, err := h.rewardService.GetReward(c.Request.Context(), uint(rewardID))
-	if err != nil {
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && reward == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "奖品不存在",
+		})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "获取奖品详情失败: " + err.Error(),
@@ -191,11 +213,8 @@ func (h *RewardHandler) GetRewardByID(c *gin.Context) {
 		return
 	}
 
-	if reward == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "奖品不存在",
-		})
+	if err := h.membershipService.AuthorizeFamilyView(reward.FamilyID, c.GetString("wallet_address"), c.GetString("role")); err != nil {
+		respondMembershipError(c, err)
 		return
 	}
 
@@ -245,6 +264,11 @@ func (h *RewardHandler) UpdateReward(c *gin.Context) {
 	}
 	fmt.Printf("当前用户ID: %v\n", userID)
 
+	// 只有奖品所在家庭的所有者或共同家长可以更新奖品
+	if !h.authorizeRewardManage(c, uint(rewardID)) {
+		return
+	}
+
 	// 更新奖品
 	fmt.Printf("准备更新奖品: ID=%d\n", uint(rewardID))
 	if req.Name != nil && *req.Name == "" {
@@ -335,6 +359,11 @@ func (h *RewardHandler) DeleteReward(c *gin.Context) {
 		return
 	}
 
+	// 只有奖品所在家庭的所有者或共同家长可以删除奖品
+	if !h.authorizeRewardManage(c, uint(rewardID)) {
+		return
+	}
+
 	// 更新奖品状态为非活跃
 	active := false
 	req := models.RewardUpdateRequest{
@@ -355,3 +384,27 @@ func (h *RewardHandler) DeleteReward(c *gin.Context) {
 		"message": "奖品已成功删除",
 	})
 }
+
+// authorizeRewardManage 检查当前用户是否可以管理奖品所在的家庭，失败时直接返回错误响应
+func (h *RewardHandler) authorizeRewardManage(c *gin.Context, rewardID uint) bool {
+	reward, err := h.rewardService.GetReward(c.Request.Context(), rewardID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && reward == nil) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "奖品不存在",
+		})
+		return false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取奖品详情失败: " + err.Error(),
+		})
+		return false
+	}
+
+	if err := h.membershipService.Authorize(reward.FamilyID, c.GetString("wallet_address"), true); err != nil {
+		respondMembershipError(c, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RewardProposalHandler 处理孩子提出的奖品愿望相关的API请求
+type RewardProposalHandler struct {
+	proposalService   *services.RewardProposalService
+	childService      *services.ChildService
+	membershipService *services.MembershipService
+}
+
+// NewRewardProposalHandler 创建一个新的奖品愿望处理器
+func NewRewardProposalHandler(proposalService *services.RewardProposalService, childService *services.ChildService, membershipService *services.MembershipService) *RewardProposalHandler {
+	return &RewardProposalHandler{
+		proposalService:   proposalService,
+		childService:      childService,
+		membershipService: membershipService,
+	}
+}
+
+// CreateRewardProposal 孩子提出一个希望加入奖品列表的愿望
+func (h *RewardProposalHandler) CreateRewardProposal(c *gin.Context) {
+	var req models.RewardProposalCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	child, err := h.childService.GetByWalletAddress(c.Request.Context(), c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+	proposal, err := h.proposalService.Propose(child, req)
+	if err != nil {
+		respondRewardProposalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    proposal,
+	})
+}
+
+// GetMyRewardProposals 孩子查看自己提出的愿望
+func (h *RewardProposalHandler) GetMyRewardProposals(c *gin.Context) {
+	child, err := h.childService.GetByWalletAddress(c.Request.Context(), c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+	proposals, err := h.proposalService.ListByChild(child.ID)
+	if err != nil {
+		respondRewardProposalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    proposals,
+	})
+}
+
+// GetFamilyRewardProposals 家长查看家庭中孩子提出的愿望，可以按status过滤
+func (h *RewardProposalHandler) GetFamilyRewardProposals(c *gin.Context) {
+	familyID, ok := uintParam(c, "id", "Invalid family ID")
+	if !ok {
+		return
+	}
+	status := models.RewardProposalStatus(c.Query("status"))
+	switch status {
+	case "", models.RewardProposalPending, models.RewardProposalAccepted, models.RewardProposalDeclined:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid status",
+		})
+		return
+	}
+	if err := h.membershipService.Authorize(familyID, c.GetString("wallet_address"), false); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	proposals, err := h.proposalService.ListByFamily(familyID, status)
+	if err != nil {
+		respondRewardProposalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    proposals,
+	})
+}
+
+// GetRewardProposal 获取愿望详情
+func (h *RewardProposalHandler) GetRewardProposal(c *gin.Context) {
+	proposal, ok := h.authorizedProposal(c, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    proposal,
+	})
+}
+
+// AcceptRewardProposal 家长为愿望定价，把它变成家庭的奖品
+func (h *RewardProposalHandler) AcceptRewardProposal(c *gin.Context) {
+	var req models.RewardProposalAcceptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+	proposal, ok := h.authorizedProposal(c, true)
+	if !ok {
+		return
+	}
+
+	proposal, err := h.proposalService.Accept(proposal, userID.(uint), c.GetString("wallet_address"), req)
+	if err != nil {
+		respondRewardProposalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    proposal,
+	})
+}
+
+// DeclineRewardProposal 家长拒绝愿望并说明原因
+func (h *RewardProposalHandler) DeclineRewardProposal(c *gin.Context) {
+	var req models.RewardProposalDeclineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+	proposal, ok := h.authorizedProposal(c, true)
+	if !ok {
+		return
+	}
+
+	proposal, err := h.proposalService.Decline(proposal, c.GetString("wallet_address"), req.Note)
+	if err != nil {
+		respondRewardProposalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    proposal,
+	})
+}
+
+// authorizedProposal 获取愿望并检查当前用户的权限，失败时直接返回错误响应
+// 孩子只能访问自己的愿望；家长必须是愿望所在家庭的成员，manage为true时要求所有者或共同家长
+func (h *RewardProposalHandler) authorizedProposal(c *gin.Context, manage bool) (*models.RewardProposal, bool) {
+	proposalID, ok := uintParam(c, "id", "Invalid reward proposal ID")
+	if !ok {
+		return nil, false
+	}
+	proposal, err := h.proposalService.Get(proposalID)
+	if err != nil {
+		respondRewardProposalError(c, err)
+		return nil, false
+	}
+
+	walletAddress := c.GetString("wallet_address")
+	if c.GetString("role") == "child" {
+		if proposal.Child == nil || !strings.EqualFold(proposal.Child.WalletAddress, walletAddress) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Access denied",
+			})
+			return nil, false
+		}
+		return proposal, true
+	}
+	if err := h.membershipService.Authorize(proposal.FamilyID, walletAddress, manage); err != nil {
+		respondMembershipError(c, err)
+		return nil, false
+	}
+	return proposal, true
+}
+
+// respondRewardProposalError 参数错误返回400，愿望已经处理过返回409，其他错误按家庭成员检查的规则处理
+func respondRewardProposalError(c *gin.Context, err error) {
+	status := 0
+	switch {
+	case errors.Is(err, services.ErrInvalidRewardProposal):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrRewardProposalState):
+		status = http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+	default:
+		respondMembershipError(c, err)
+		return
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
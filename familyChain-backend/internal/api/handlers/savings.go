@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SavingsHandler 处理储蓄目标相关的API请求
+type SavingsHandler struct {
+	savingsService    *services.SavingsService
+	childService      *services.ChildService
+	membershipService *services.MembershipService
+}
+
+// NewSavingsHandler 创建一个新的储蓄目标处理器
+func NewSavingsHandler(savingsService *services.SavingsService, childService *services.ChildService, membershipService *services.MembershipService) *SavingsHandler {
+	return &SavingsHandler{
+		savingsService:    savingsService,
+		childService:      childService,
+		membershipService: membershipService,
+	}
+}
+
+// CreateSavingsGoalRequest 孩子把一个奖品设为储蓄目标
+type CreateSavingsGoalRequest struct {
+	RewardID uint `json:"reward_id" binding:"required"`
+}
+
+// SavingsAmountRequest 锁定或解锁的代币数量（整数个代币）
+type SavingsAmountRequest struct {
+	Amount int `json:"amount" binding:"required,min=1"`
+}
+
+// SavingsContributionRequest 家长配捐，amount为空时配捐孩子锁定的数量中还没有配捐的部分
+type SavingsContributionRequest struct {
+	Amount *int   `json:"amount,omitempty" binding:"omitempty,min=1"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+// CreateSavingsGoal 孩子把一个奖品设为储蓄目标
+func (h *SavingsHandler) CreateSavingsGoal(c *gin.Context) {
+	var req CreateSavingsGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	child, err := h.childService.GetByWalletAddress(c.Request.Context(), c.GetString("wallet_address"))
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+	goal, err := h.savingsService.Create(child, req.RewardID)
+	if err != nil {
+		respondSavingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    goal,
+	})
+}
+
+// GetChildSavingsGoals 获取孩子的储蓄目标，active=true时只返回进行中的
+func (h *SavingsHandler) GetChildSavingsGoals(c *gin.Context) {
+	childID, ok := uintParam(c, "id", "Invalid child ID")
+	if !ok {
+		return
+	}
+	child, err := h.childService.GetChildByID(childID)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+	if !h.authorizeChild(c, child, false) {
+		return
+	}
+
+	goals, err := h.savingsService.ListByChild(child.ID, c.Query("active") == "true")
+	if err != nil {
+		respondSavingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    goals,
+	})
+}
+
+// GetSavingsGoal 获取储蓄目标和家长的配捐记录
+func (h *SavingsHandler) GetSavingsGoal(c *gin.Context) {
+	goal, _, ok := h.authorizedGoal(c, false)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    goal,
+	})
+}
+
+// GetSavingsProgress 获取储蓄目标的进度和预计完成日期
+func (h *SavingsHandler) GetSavingsProgress(c *gin.Context) {
+	goal, child, ok := h.authorizedGoal(c, false)
+	if !ok {
+		return
+	}
+
+	progress, err := h.savingsService.Progress(goal, child)
+	if err != nil {
+		respondSavingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// LockSavings 孩子把代币锁定到储蓄目标中
+func (h *SavingsHandler) LockSavings(c *gin.Context) {
+	h.changeLock(c, h.savingsService.Lock)
+}
+
+// UnlockSavings 孩子把锁定的代币释放回可用余额
+func (h *SavingsHandler) UnlockSavings(c *gin.Context) {
+	h.changeLock(c, h.savingsService.Unlock)
+}
+
+func (h *SavingsHandler) changeLock(c *gin.Context, change func(*models.SavingsGoal, *models.Child, int) (*models.SavingsGoal, error)) {
+	var req SavingsAmountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+	goal, child, ok := h.authorizedGoal(c, false)
+	if !ok {
+		return
+	}
+
+	goal, err := change(goal, child, req.Amount)
+	if err != nil {
+		respondSavingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    goal,
+	})
+}
+
+// ContributeSavings 家长为储蓄目标配捐，代币铸造给孩子并锁定在目标中
+func (h *SavingsHandler) ContributeSavings(c *gin.Context) {
+	var req SavingsContributionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+	goal, child, ok := h.authorizedGoal(c, true)
+	if !ok {
+		return
+	}
+
+	contribution, err := h.savingsService.Contribute(goal, child, c.GetString("wallet_address"), req.Amount, req.Notes)
+	if err != nil {
+		respondSavingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    contribution,
+	})
+}
+
+// CancelSavingsGoal 孩子或可以管理家庭的家长取消储蓄目标
+func (h *SavingsHandler) CancelSavingsGoal(c *gin.Context) {
+	goal, _, ok := h.authorizedGoal(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.savingsService.Cancel(goal); err != nil {
+		respondSavingsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Savings goal cancelled",
+	})
+}
+
+// authorizedGoal 获取储蓄目标和它的孩子并检查当前用户的权限，失败时直接返回错误响应
+// 孩子只能访问自己的目标；家长必须是孩子所在家庭的成员，manage为true时要求所有者或共同家长
+func (h *SavingsHandler) authorizedGoal(c *gin.Context, manage bool) (*models.SavingsGoal, *models.Child, bool) {
+	goalID, ok := uintParam(c, "id", "Invalid savings goal ID")
+	if !ok {
+		return nil, nil, false
+	}
+	goal, err := h.savingsService.Get(goalID)
+	if err != nil {
+		respondSavingsError(c, err)
+		return nil, nil, false
+	}
+	child, err := h.childService.GetChildByID(goal.ChildID)
+	if err != nil {
+		respondMembershipError(c, err)
+		return nil, nil, false
+	}
+	if !h.authorizeChild(c, child, manage) {
+		return nil, nil, false
+	}
+	return goal, child, true
+}
+
+// authorizeChild 孩子只能访问自己，家长按家庭角色检查
+func (h *SavingsHandler) authorizeChild(c *gin.Context, child *models.Child, manage bool) bool {
+	walletAddress := c.GetString("wallet_address")
+	if c.GetString("role") == "child" {
+		if !strings.EqualFold(child.WalletAddress, walletAddress) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Access denied",
+			})
+			return false
+		}
+		return true
+	}
+	if err := h.membershipService.AuthorizeChild(child, walletAddress, manage); err != nil {
+		respondMembershipError(c, err)
+		return false
+	}
+	return true
+}
+
+// respondSavingsError 参数错误和余额不足返回400，目标已经结束或重复返回409，其他错误按家庭成员检查的规则处理
+func respondSavingsError(c *gin.Context, err error) {
+	status := 0
+	switch {
+	case errors.Is(err, services.ErrInvalidSavingsGoal), errors.Is(err, services.ErrInsufficientBalance):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrSavingsGoalState), errors.Is(err, services.ErrSavingsGoalExists):
+		status = http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusNotFound
+	default:
+		respondMembershipError(c, err)
+		return
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
@@ -1,10 +1,10 @@
 package handlers
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,24 +13,30 @@ import (
 	"time"
 
 	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
 	"eth-for-babies-backend/internal/utils"
 	"eth-for-babies-backend/pkg/blockchain"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type TaskHandler struct {
-	db              *gorm.DB
-	contractManager *blockchain.ContractManager
+	db                *gorm.DB
+	contractManager   *blockchain.ContractManager
+	membershipService *services.MembershipService
+	broker            realtime.Broker
 }
 
-func NewTaskHandler(db *gorm.DB, contractManager *blockchain.ContractManager) *TaskHandler {
+func NewTaskHandler(db *gorm.DB, contractManager *blockchain.ContractManager, membershipService *services.MembershipService, broker realtime.Broker) *TaskHandler {
 	return &TaskHandler{
-		db:              db,
-		contractManager: contractManager,
+		db:                db,
+		contractManager:   contractManager,
+		membershipService: membershipService,
+		broker:            broker,
 	}
 }
 
@@ -43,6 +49,14 @@ type CreateTaskRequest struct {
 	AssignedChildID *uint   `json:"assigned_child_id,omitempty"`
 	DueDate         string  `json:"due_date,omitempty"`
 	ContractTaskID  *uint64 `json:"contract_task_id,omitempty"`
+	// 完成任务需要的凭证：none、text（默认）或photo
+	ProofType         string `json:"proof_type,omitempty"`
+	ProofInstructions string `json:"proof_instructions,omitempty"`
+	// 家长属于多个家庭时需要指定任务所属的家庭
+	FamilyID *uint `json:"family_id,omitempty"`
+	// 任务清单，checklist_required为true时必做的步骤全部勾选后才能提交
+	Checklist         []ChecklistItemRequest `json:"checklist,omitempty"`
+	ChecklistRequired bool                   `json:"checklist_required,omitempty"`
 }
 
 type UpdateTaskRequest struct {
@@ -56,12 +70,25 @@ type UpdateTaskRequest struct {
 	DueDate         string `json:"due_date,omitempty"`
 }
 
+// CompleteTaskRequest 提交任务，凭证是否必填取决于任务的proof_type
 type CompleteTaskRequest struct {
-	CompletionProof string `json:"completion_proof" binding:"required"`
+	CompletionProof string `json:"completion_proof"`
+	TxHash          string `json:"tx_hash,omitempty"` // 孩子钱包调用completeTask的交易
 }
 
+// ApproveTaskRequest 批准任务，可以按百分比或金额只发放部分奖励；请求体可以为空，表示发放全部奖励
+type ApproveTaskRequest struct {
+	Percent      *int    `json:"percent,omitempty"`       // 发放奖励的百分比，0-100
+	RewardAmount *string `json:"reward_amount,omitempty"` // 发放的奖励金额，不能超过任务奖励
+	Reason       string  `json:"reason,omitempty"`        // 部分发放时必填
+	TxHash       string  `json:"tx_hash,omitempty"`       // 家长钱包调用approveTask的交易
+}
+
+// RejectTaskRequest 拒绝任务；rework为true时把任务退回给孩子重做，而不是结束任务
 type RejectTaskRequest struct {
 	Reason string `json:"reason,omitempty"`
+	Rework bool   `json:"rework,omitempty"`
+	TxHash string `json:"tx_hash,omitempty"` // 家长钱包调用rejectTask或returnForRework的交易
 }
 
 // CreateTask 创建任务
@@ -120,23 +147,23 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
-	// 如果指定了孩子，验证孩子是否属于当前父母
-	if req.AssignedChildID != nil {
-		var child models.Child
-		result := h.db.Where("id = ? AND parent_address = ?", *req.AssignedChildID, walletAddress).First(&child)
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Child not found or not belongs to you",
-			})
-			return
-		} else if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Database error",
-			})
-			return
-		}
+	// 验证凭证类型
+	if req.ProofType == "" {
+		req.ProofType = models.ProofTypeText
+	}
+	if !utils.IsValidProofType(req.ProofType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid proof type. Must be 'none', 'text', or 'photo'",
+		})
+		return
+	}
+
+	// 确定任务所属的家庭，调用者必须是该家庭的所有者或共同家长
+	family, err := h.membershipService.ResolveManagedFamily(walletAddress.(string), req.FamilyID)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
 	}
 
 	// 创建任务
@@ -147,7 +174,34 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		Difficulty:      req.Difficulty,
 		Status:          "pending",
 		CreatedBy:       walletAddress.(string),
+		FamilyID:        &family.ID,
 		AssignedChildID: req.AssignedChildID,
+		ProofType:       req.ProofType,
+	}
+	if req.ProofInstructions != "" {
+		task.ProofInstructions = &req.ProofInstructions
+	}
+
+	// 任务清单
+	checklist, ok := checklistItems(c, req.Checklist, nil)
+	if !ok {
+		return
+	}
+	if req.ChecklistRequired && len(checklist) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "checklist_required needs at least one checklist item",
+		})
+		return
+	}
+	task.Checklist = checklist
+	task.ChecklistRequired = req.ChecklistRequired
+
+	// 如果指定了孩子，验证孩子是否属于任务所在的家庭
+	if req.AssignedChildID != nil {
+		if _, ok := h.familyChild(c, &task, *req.AssignedChildID); !ok {
+			return
+		}
 	}
 
 	// 设置图片URL（如果前端提供了）
@@ -186,8 +240,33 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		task.Status = "in_progress"
 	}
 
+	// 事件索引器可能已经根据链上TaskCreated事件补建了该任务，此时用请求中的详情覆盖它而不是重复创建
+	event := &models.TaskEvent{
+		Action: models.TaskEventCreated,
+		Actor:  walletAddress.(string),
+	}
+	if task.ContractTaskID != nil {
+		var indexed models.Task
+		err := h.db.Where("contract_task_id = ? AND created_by = ?", *task.ContractTaskID, walletAddress).First(&indexed).Error
+		if err == nil {
+			task.ID = indexed.ID
+			task.CreatedAt = indexed.CreatedAt
+			if indexed.Status != "pending" {
+				task.Status = indexed.Status
+			}
+			event.Action = models.TaskEventUpdated
+			event.FromStatus = indexed.Status
+		} else if err != gorm.ErrRecordNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Database error",
+			})
+			return
+		}
+	}
+
 	// 保存到数据库
-	if err := h.db.Create(&task).Error; err != nil {
+	if err := h.saveTask(&task, event); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to create task",
@@ -196,7 +275,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	}
 
 	// 预加载关联数据
-	h.db.Preload("AssignedChild").First(&task, task.ID)
+	h.db.Preload("AssignedChild").Preload("Checklist", repository.OrderedChecklist).First(&task, task.ID)
 
 	// 返回响应
 	c.JSON(http.StatusCreated, gin.H{
@@ -230,36 +309,22 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 	status := c.Query("status")
 
 	var tasks []models.Task
-	query := h.db.Model(&models.Task{})
+	query, ok := h.visibleTasks(c, walletAddress.(string), role)
+	if !ok {
+		return
+	}
 
-	if role == "parent" {
-		// 父母可以看到自己创建的所有任务
-		query = query.Where("created_by = ?", walletAddress)
-
-		// 如果指定了孩子ID，过滤该孩子的任务
-		if childIDParam != "" {
-			childID, err := strconv.ParseUint(childIDParam, 10, 32)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"success": false,
-					"error":   "Invalid child ID",
-				})
-				return
-			}
-			query = query.Where("assigned_child_id = ?", uint(childID))
-		}
-	} else {
-		// 孩子只能看到分配给自己的任务
-		var child models.Child
-		result := h.db.Where("wallet_address = ?", walletAddress).First(&child)
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+	// 家长可以指定孩子ID，过滤该孩子的任务
+	if role == "parent" && childIDParam != "" {
+		childID, err := strconv.ParseUint(childIDParam, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
 				"success": false,
-				"error":   "Child record not found",
+				"error":   "Invalid child ID",
 			})
 			return
 		}
-		query = query.Where("assigned_child_id = ?", child.ID)
+		query = query.Where("assigned_child_id = ?", uint(childID))
 	}
 
 	// 按状态过滤
@@ -268,7 +333,7 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 	}
 
 	// 执行查询
-	if err := query.Preload("AssignedChild").Order("created_at DESC").Find(&tasks).Error; err != nil {
+	if err := query.Preload("AssignedChild").Preload("Mint", "kind = ?", models.OutboxKindMintReward).Preload("Checklist", repository.OrderedChecklist).Order("created_at DESC").Find(&tasks).Error; err != nil {
 		log.Println("查询任务时出错:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -284,6 +349,27 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 	})
 }
 
+// visibleTasks 返回当前用户可以看到的任务的查询，失败时直接返回错误响应
+// 家长可以看到自己加入的所有家庭的任务，以及自己创建的还没有家庭的任务；孩子只能看到分配给自己的任务
+func (h *TaskHandler) visibleTasks(c *gin.Context, walletAddress string, role interface{}) (*gorm.DB, bool) {
+	query := h.db.Model(&models.Task{})
+	if role == "parent" {
+		familyIDs := h.db.Model(&models.FamilyMember{}).Select("family_id").Where("wallet_address = ?", walletAddress)
+		return query.Where("family_id IN (?) OR (family_id IS NULL AND created_by = ?)", familyIDs, walletAddress), true
+	}
+
+	var child models.Child
+	result := h.db.Where("wallet_address = ?", walletAddress).First(&child)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Child record not found",
+		})
+		return nil, false
+	}
+	return query.Where("assigned_child_id = ?", child.ID), true
+}
+
 // GetTaskByID 获取任务详情
 func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 	idParam := c.Param("id")
@@ -306,7 +392,7 @@ func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 	}
 
 	var task models.Task
-	result := h.db.Preload("AssignedChild").First(&task, uint(id))
+	result := h.db.Preload("AssignedChild").Preload("Mint", "kind = ?", models.OutboxKindMintReward).Preload("Checklist", repository.OrderedChecklist).First(&task, uint(id))
 	if result.Error == gorm.ErrRecordNotFound {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -322,13 +408,75 @@ func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 	}
 
 	// 检查权限
-	role, _ := c.Get("role")
-	if role == "parent" && task.CreatedBy != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
+	if !h.canViewTask(c, &task, walletAddress.(string)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
+
+// GetTaskHistory 按发生顺序获取任务的全部状态变化，包括每次提交的凭证、原因和相关的链上交易
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	task, ok := h.viewableTask(c)
+	if !ok {
+		return
+	}
+
+	events, err := repository.NewTaskEventRepository(h.db).ListByTask(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Access denied",
+			"error":   "Failed to get task history",
 		})
 		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    events,
+	})
+}
+
+// viewableTask 按路径中的任务ID查找当前用户可以查看的任务，失败时直接返回错误响应
+func (h *TaskHandler) viewableTask(c *gin.Context) (*models.Task, bool) {
+	id, ok := uintParam(c, "id", "Invalid task ID")
+	if !ok {
+		return nil, false
+	}
+
+	var task models.Task
+	result := h.db.First(&task, id)
+	if result.Error == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Task not found",
+		})
+		return nil, false
+	} else if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return nil, false
+	}
+
+	if !h.canViewTask(c, &task, c.GetString("wallet_address")) {
+		return nil, false
+	}
+	return &task, true
+}
+
+// canViewTask 家长必须是任务所在家庭的成员，孩子只能查看分配给自己的任务；没有权限时直接返回错误响应
+func (h *TaskHandler) canViewTask(c *gin.Context, task *models.Task, walletAddress string) bool {
+	role, _ := c.Get("role")
+	if role == "parent" {
+		if err := h.membershipService.AuthorizeTask(task, walletAddress, false); err != nil {
+			respondMembershipError(c, err)
+			return false
+		}
 	} else if role == "child" {
 		// 检查任务是否分配给了这个孩子
 		var child models.Child
@@ -338,14 +486,10 @@ func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 				"success": false,
 				"error":   "Access denied",
 			})
-			return
+			return false
 		}
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    task,
-	})
+	return true
 }
 
 // UpdateTask 更新任务
@@ -395,16 +539,18 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	// 检查权限（只有任务创建者可以更新）
-	if task.CreatedBy != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Only task creator can update the task",
-		})
+	// 检查权限（任务所在家庭的所有者或共同家长可以更新）
+	if err := h.membershipService.AuthorizeTask(&task, walletAddress.(string), true); err != nil {
+		respondMembershipError(c, err)
 		return
 	}
 
 	// 更新任务信息
+	event := &models.TaskEvent{
+		Action:     models.TaskEventUpdated,
+		Actor:      walletAddress.(string),
+		FromStatus: task.Status,
+	}
 	if req.Title != "" {
 		task.Title = utils.SanitizeString(req.Title)
 	}
@@ -413,6 +559,7 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	}
 	if req.RewardAmount != "" {
 		task.RewardAmount = req.RewardAmount
+		event.RewardAmount = &req.RewardAmount
 	}
 	if req.Difficulty != "" && utils.IsValidDifficulty(req.Difficulty) {
 		task.Difficulty = req.Difficulty
@@ -425,25 +572,14 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		task.ImageUrl = &imageUrl
 	}
 	if req.AssignedChildID != nil {
-		// 验证孩子是否属于当前父母
-		var child models.Child
-		result := h.db.Where("id = ? AND parent_address = ?", *req.AssignedChildID, walletAddress).First(&child)
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Child not found or not belongs to you",
-			})
-			return
-		} else if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"error":   "Database error",
-			})
+		// 验证孩子是否属于任务所在的家庭
+		if _, ok := h.familyChild(c, &task, *req.AssignedChildID); !ok {
 			return
 		}
 
 		// 更新任务分配
 		task.AssignedChildID = req.AssignedChildID
+		event.Action = models.TaskEventAssigned
 
 		// 如果分配给了孩子且状态是pending，改为in_progress
 		if task.Status == "pending" {
@@ -462,7 +598,7 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		task.DueDate = &dueDate
 	}
 
-	if err := h.db.Save(&task).Error; err != nil {
+	if err := h.saveTask(&task, event); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to update task",
@@ -546,8 +682,17 @@ func (h *TaskHandler) CompleteTask(c *gin.Context) {
 		return
 	}
 
-	// 检查任务状态
-	if task.Status != "in_progress" {
+	// 检查任务状态，过期的任务只有在家庭允许迟交时才能提交
+	if task.Status == models.TaskStatusExpired {
+		var family models.Family
+		if task.FamilyID == nil || h.db.First(&family, *task.FamilyID).Error != nil || !family.AllowLateSubmissions {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Task has expired and late submissions are not accepted",
+			})
+			return
+		}
+	} else if task.Status != "in_progress" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Task is not in progress",
@@ -555,13 +700,53 @@ func (h *TaskHandler) CompleteTask(c *gin.Context) {
 		return
 	}
 
-	// 更新任务状态
+	// 检查凭证是否满足任务要求
+	if err := utils.ValidateCompletionProof(task.ProofType, req.CompletionProof); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 要求完成清单的任务，必做的步骤必须全部勾选
+	if task.ChecklistRequired {
+		unchecked, err := repository.NewTaskChecklistRepository(h.db).CountUncheckedRequired(task.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Database error",
+			})
+			return
+		}
+		if unchecked > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("All required checklist items must be checked before submitting (%d remaining)", unchecked),
+			})
+			return
+		}
+	}
+
+	txHash, ok := optionalTxHash(c, req.TxHash)
+	if !ok {
+		return
+	}
+
+	// 更新任务状态，每次提交的凭证都保留在任务历史中
+	event := &models.TaskEvent{
+		Action:     models.TaskEventSubmitted,
+		Actor:      walletAddress.(string),
+		FromStatus: task.Status,
+		Proof:      &req.CompletionProof,
+		TxHash:     txHash,
+	}
 	now := time.Now()
 	task.Status = "completed"
 	task.CompletionProof = &req.CompletionProof
 	task.SubmittedAt = &now
 
-	if err := h.db.Save(&task).Error; err != nil {
+	if err := h.saveTask(&task, event); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to complete task",
@@ -587,6 +772,15 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 		return
 	}
 
+	var req ApproveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
 	// 输出合约管理器和合约配置信息
 	log.Printf("[DEBUG] ===== 合约配置诊断 =====")
 	if h.contractManager == nil {
@@ -641,12 +835,9 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 		return
 	}
 
-	// 检查权限
-	if task.CreatedBy != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Only task creator can approve the task",
-		})
+	// 检查权限（任务所在家庭的所有者或共同家长可以审批）
+	if err := h.membershipService.AuthorizeTask(&task, walletAddress.(string), true); err != nil {
+		respondMembershipError(c, err)
 		return
 	}
 
@@ -659,6 +850,31 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 		return
 	}
 
+	txHash, ok := optionalTxHash(c, req.TxHash)
+	if !ok {
+		return
+	}
+
+	// 计算实际发放的奖励，部分完成时可以少于任务奖励
+	approvedReward, err := approvedRewardAmount(&task, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// 计算需要铸造的代币数量（数量是ETH奖励的10000倍）
+	tokenAmount, err := utils.RewardToTokenAmount(approvedReward)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid reward amount",
+		})
+		return
+	}
+
 	// 开始事务
 	tx := h.db.Begin()
 	defer func() {
@@ -668,11 +884,33 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 	}()
 
 	// 更新任务状态
+	event := &models.TaskEvent{
+		Action:       models.TaskEventApproved,
+		Actor:        walletAddress.(string),
+		FromStatus:   task.Status,
+		RewardAmount: &approvedReward,
+		TxHash:       txHash,
+	}
 	now := time.Now()
 	task.Status = "approved"
 	task.ApprovedAt = &now
-
-	if err := tx.Save(&task).Error; err != nil {
+	task.ApprovedRewardAmount = &approvedReward
+	if reason := utils.SanitizeString(req.Reason); reason != "" {
+		task.ApprovalReason = &reason
+		event.Reason = &reason
+	}
+	event.TaskID = task.ID
+	event.ToStatus = task.Status
+
+	// 只在任务仍是已提交状态时更新，并发的批准或退回只有一个成功，铸币请求不会重复写入
+	updated := tx.Model(&models.Task{}).Where("id = ? AND status = ?", task.ID, "completed").Updates(map[string]interface{}{
+		"status":                 task.Status,
+		"approved_at":            task.ApprovedAt,
+		"approved_reward_amount": task.ApprovedRewardAmount,
+		"approval_reason":        task.ApprovalReason,
+		"updated_at":             now,
+	})
+	if updated.Error != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -680,12 +918,27 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 		})
 		return
 	}
+	if updated.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Task was changed by another request",
+		})
+		return
+	}
+	if err := repository.NewTaskEventRepository(tx).Create(event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to record task history",
+		})
+		return
+	}
 
-	// 更新孩子的统计信息
+	// 更新孩子完成的任务数；收到的代币以账本为准，铸币上链并同步后才计入
 	if task.AssignedChild != nil {
 		if err := tx.Model(&models.Child{}).Where("id = ?", task.AssignedChild.ID).Updates(map[string]interface{}{
 			"total_tasks_completed": gorm.Expr("total_tasks_completed + ?", 1),
-			"total_rewards_earned":  gorm.Expr("CAST(total_rewards_earned AS REAL) + CAST(? AS REAL)", task.RewardAmount),
 		}).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -696,6 +949,26 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 		}
 	}
 
+	// 在同一事务中写入铸币请求，保证任务批准与铸币请求要么都提交要么都不提交
+	if task.AssignedChild != nil && task.AssignedChild.WalletAddress != "" && tokenAmount.Sign() > 0 {
+		mint := &models.OutboxMessage{
+			Kind:      models.OutboxKindMintReward,
+			TaskID:    &task.ID,
+			Recipient: task.AssignedChild.WalletAddress,
+			Amount:    tokenAmount.String(),
+		}
+		if err := repository.NewOutboxRepository(tx).Create(mint); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to queue token reward",
+			})
+			return
+		}
+	} else {
+		log.Printf("任务 %d 没有可接收代币的孩子钱包，跳过铸币", task.ID)
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -704,126 +977,59 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 		})
 		return
 	}
+	realtime.PublishTaskEvent(h.broker, &task, event)
 
-	// 铸造代币奖励给孩子（数量是ETH奖励的10000倍）
-	log.Printf("[DEBUG] 尝试铸造代币奖励，任务ID: %d", id)
+	// 铸币请求已在事务中写入outbox，由后台分发器发送并记录收据
+	h.db.Preload("AssignedChild").Preload("Mint", "kind = ?", models.OutboxKindMintReward).First(&task, task.ID)
 
-	if task.AssignedChild == nil {
-		log.Printf("[DEBUG] 没有分配的孩子，无法铸造代币")
-	} else if task.AssignedChild.WalletAddress == "" {
-		log.Printf("[DEBUG] 孩子(%d)没有钱包地址，无法铸造代币", task.AssignedChild.ID)
-	} else if h.contractManager == nil {
-		log.Printf("[DEBUG] 合约管理器未初始化，无法铸造代币")
-	} else if h.contractManager.RewardToken == nil {
-		log.Printf("[DEBUG] 奖励代币合约未初始化，尝试手动初始化...")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+		"message": "Task approved, token reward queued for minting",
+	})
+}
 
-		// 获取代币合约地址
-		tokenAddress := h.contractManager.GetContractAddresses()["token"]
-		if tokenAddress == "" || tokenAddress == "0x0000000000000000000000000000000000000000" {
-			log.Printf("[ERROR] 无法初始化代币合约：地址为空或零地址")
-		} else {
-			log.Printf("[DEBUG] 尝试使用地址初始化代币合约: %s", tokenAddress)
-
-			// 尝试手动初始化RewardToken合约
-			err := h.initializeRewardToken(tokenAddress)
-			if err != nil {
-				log.Printf("[ERROR] 手动初始化代币合约失败: %v", err)
-			} else {
-				log.Printf("[SUCCESS] 手动初始化代币合约成功！")
-			}
-		}
-	} else {
-		log.Printf("[DEBUG] 所有条件满足，开始铸造代币流程")
+// approvedRewardAmount 根据批准请求计算实际发放的奖励，percent和reward_amount最多提供一个
+// 少于任务奖励时必须说明原因
+func approvedRewardAmount(task *models.Task, req *ApproveTaskRequest) (string, error) {
+	if req.Percent != nil && req.RewardAmount != nil {
+		return "", errors.New("Provide either percent or reward_amount, not both")
+	}
 
-		// 解析奖励金额
-		log.Printf("[DEBUG] 原始奖励金额: %s", task.RewardAmount)
-		rewardFloat, err := strconv.ParseFloat(task.RewardAmount, 64)
+	approved := task.RewardAmount
+	switch {
+	case req.Percent != nil:
+		if *req.Percent < 0 || *req.Percent > 100 {
+			return "", errors.New("Percent must be between 0 and 100")
+		}
+		share, err := utils.ReduceReward(task.RewardAmount, 100-*req.Percent)
 		if err != nil {
-			log.Printf("[ERROR] 无法解析奖励金额：%v", err)
-		} else {
-			// 将奖励乘以10000并考虑代币小数位
-			tokenAmountFloat := rewardFloat * 10000 * 1e18 // 乘以10^18考虑代币的18个小数位
-
-			// 使用math/big处理大数，避免整数溢出
-			tokenAmountBig := new(big.Float).SetFloat64(tokenAmountFloat)
-			tokenAmountInt, _ := tokenAmountBig.Int(nil)
-
-			log.Printf("[DEBUG] 计算的代币金额: %s (原始奖励: %f × 10000 × 10^18)", tokenAmountInt.String(), rewardFloat)
-			log.Printf("[DEBUG] 这相当于 %f 个完整代币单位", rewardFloat*10000)
-
-			// 使用GetTransactOpts替代client.GetAuth()
-			log.Printf("[DEBUG] 尝试获取交易选项...")
-			ctx := context.Background()
-			auth, err := h.contractManager.GetTransactOpts(ctx)
-			if err != nil {
-				log.Printf("[ERROR] 获取交易选项失败：%v", err)
-			} else {
-				log.Printf("[DEBUG] 成功获取交易选项，使用地址: %s", auth.From.Hex())
-
-				// 设置更高的gas限制和价格
-				auth.GasLimit = 1000000 // 设置更高的gas限制
-				if auth.GasPrice != nil {
-					increasedGasPrice := new(big.Int).Mul(auth.GasPrice, big.NewInt(250))
-					increasedGasPrice = increasedGasPrice.Div(increasedGasPrice, big.NewInt(100)) // 增加150%
-					auth.GasPrice = increasedGasPrice
-					log.Printf("[DEBUG] 铸造代币: 增加gas价格至原价的250%%: %s", auth.GasPrice.String())
-				}
-
-				// 执行铸币操作
-				childAddress := common.HexToAddress(task.AssignedChild.WalletAddress)
-				log.Printf("[DEBUG] 孩子钱包地址: %s", childAddress.Hex())
-
-				log.Printf("[DEBUG] 开始调用RewardToken.Mint方法...")
-
-				// 检查RewardToken合约初始化状态
-				log.Printf("[DEBUG] RewardToken合约地址: %s", h.contractManager.GetContractAddresses()["token"])
-
-				// 尝试调用简单的查询方法来验证合约连接
-				symbol, err := h.contractManager.RewardToken.Symbol(nil)
-				if err != nil {
-					log.Printf("[ERROR] RewardToken合约连接测试失败: %v", err)
-					log.Printf("[DEBUG] 尝试继续铸币流程...")
-				} else {
-					log.Printf("[DEBUG] RewardToken合约连接成功，代币符号: %s", symbol)
-				}
-
-				// 检查调用账户是否有铸币权限
-				isMinter, err := h.contractManager.RewardToken.AuthorizedMinters(nil, auth.From)
-				if err != nil {
-					log.Printf("[ERROR] 检查铸币权限失败: %v", err)
-				} else if !isMinter {
-					log.Printf("[WARN] 当前账户 %s 可能没有铸币权限", auth.From.Hex())
-				} else {
-					log.Printf("[DEBUG] 当前账户有铸币权限")
-				}
-
-				// 执行铸币操作
-				tx, err := h.contractManager.RewardToken.Mint(auth, childAddress, tokenAmountInt)
-				if err != nil {
-					log.Printf("[ERROR] 铸造代币失败：%v", err)
-				} else {
-					log.Printf("[SUCCESS] 成功铸造 %s 代币给孩子（地址：%s），交易哈希：%s",
-						tokenAmountInt.String(), task.AssignedChild.WalletAddress, tx.Hash().Hex())
-
-					// 等待交易确认
-					log.Printf("[DEBUG] 等待交易确认...")
-					receipt, err := h.contractManager.WaitForTxReceipt(ctx, tx.Hash())
-					if err != nil {
-						log.Printf("[ERROR] 等待交易确认时出错: %v", err)
-					} else {
-						log.Printf("[SUCCESS] 交易已确认，区块号: %d，状态: %d",
-							receipt.BlockNumber.Uint64(), receipt.Status)
-					}
-				}
-			}
+			return "", errors.New("Invalid reward amount")
+		}
+		approved = share
+	case req.RewardAmount != nil:
+		amount := strings.TrimSpace(*req.RewardAmount)
+		if _, err := utils.RewardToTokenAmount(amount); err != nil {
+			return "", errors.New("Invalid reward amount")
+		}
+		cmp, err := utils.CompareRewards(amount, task.RewardAmount)
+		if err != nil {
+			return "", errors.New("Invalid reward amount")
 		}
+		if cmp > 0 {
+			return "", errors.New("Reward amount cannot exceed the task reward")
+		}
+		approved = amount
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    task,
-		"message": "Task approved and rewards transferred successfully",
-	})
+	cmp, err := utils.CompareRewards(approved, task.RewardAmount)
+	if err != nil {
+		return "", errors.New("Invalid reward amount")
+	}
+	if cmp < 0 && strings.TrimSpace(req.Reason) == "" {
+		return "", errors.New("A reason is required when approving with partial credit")
+	}
+	return approved, nil
 }
 
 // RejectTask 拒绝任务
@@ -882,12 +1088,9 @@ func (h *TaskHandler) RejectTask(c *gin.Context) {
 		return
 	}
 
-	// 检查权限
-	if task.CreatedBy != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Only task creator can reject the task",
-		})
+	// 检查权限（任务所在家庭的所有者或共同家长可以拒绝）
+	if err := h.membershipService.AuthorizeTask(&task, walletAddress.(string), true); err != nil {
+		respondMembershipError(c, err)
 		return
 	}
 
@@ -899,6 +1102,17 @@ func (h *TaskHandler) RejectTask(c *gin.Context) {
 		})
 		return
 	}
+	if req.Rework && strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "A reason is required when sending a task back for rework",
+		})
+		return
+	}
+	txHash, ok := optionalTxHash(c, req.TxHash)
+	if !ok {
+		return
+	}
 
 	// 开始事务
 	tx := h.db.Begin()
@@ -908,15 +1122,43 @@ func (h *TaskHandler) RejectTask(c *gin.Context) {
 		}
 	}()
 
-	// 更新任务状态
+	// 更新任务状态：退回重做时任务回到进行中，奖励继续锁定，孩子可以再次提交
+	event := &models.TaskEvent{
+		Action:     models.TaskEventRejected,
+		Actor:      walletAddress.(string),
+		FromStatus: task.Status,
+		TxHash:     txHash,
+	}
 	now := time.Now()
-	task.Status = "rejected"
-	task.RejectedAt = &now
+	message := "Task rejected and reward refunded to parent"
+	updates := map[string]interface{}{"updated_at": now}
+	if req.Rework {
+		event.Action = models.TaskEventReworkRequested
+		task.Status = "in_progress"
+		task.SubmittedAt = nil
+		task.ReworkCount++
+		task.ReworkRequestedAt = &now
+		updates["submitted_at"] = nil
+		updates["rework_count"] = gorm.Expr("rework_count + 1")
+		updates["rework_requested_at"] = now
+		message = "Task sent back for rework"
+	} else {
+		task.Status = "rejected"
+		task.RejectedAt = &now
+		updates["rejected_at"] = now
+	}
+	updates["status"] = task.Status
 	if req.Reason != "" {
 		task.RejectionReason = &req.Reason
+		event.Reason = &req.Reason
+		updates["rejection_reason"] = req.Reason
 	}
+	event.TaskID = task.ID
+	event.ToStatus = task.Status
 
-	if err := tx.Save(&task).Error; err != nil {
+	// 只在任务仍是已提交状态时更新，与并发的批准只有一个成功
+	updated := tx.Model(&models.Task{}).Where("id = ? AND status = ?", task.ID, "completed").Updates(updates)
+	if updated.Error != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -924,6 +1166,22 @@ func (h *TaskHandler) RejectTask(c *gin.Context) {
 		})
 		return
 	}
+	if updated.RowsAffected != 1 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Task was changed by another request",
+		})
+		return
+	}
+	if err := repository.NewTaskEventRepository(tx).Create(event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to record task history",
+		})
+		return
+	}
 
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
@@ -933,11 +1191,12 @@ func (h *TaskHandler) RejectTask(c *gin.Context) {
 		})
 		return
 	}
+	realtime.PublishTaskEvent(h.broker, &task, event)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    task,
-		"message": "Task rejected and reward refunded to parent",
+		"message": message,
 	})
 }
 
@@ -1001,33 +1260,23 @@ func (h *TaskHandler) DirectAssignTask(c *gin.Context) {
 		return
 	}
 
-	// 检查权限（只有任务创建者可以更新）
-	if task.CreatedBy != walletAddress.(string) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Only task creator can update the task",
-		})
+	// 检查权限（任务所在家庭的所有者或共同家长可以更新）
+	if err := h.membershipService.AuthorizeTask(&task, walletAddress.(string), true); err != nil {
+		respondMembershipError(c, err)
 		return
 	}
 
-	// 验证孩子是否属于当前父母
-	var child models.Child
-	result = h.db.Where("id = ? AND parent_address = ?", childID, walletAddress).First(&child)
-	if result.Error == gorm.ErrRecordNotFound {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Child not found or not belongs to you",
-		})
-		return
-	} else if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Database error",
-		})
+	// 验证孩子是否属于任务所在的家庭
+	if _, ok := h.familyChild(c, &task, childID); !ok {
 		return
 	}
 
 	// 更新任务分配
+	event := &models.TaskEvent{
+		Action:     models.TaskEventAssigned,
+		Actor:      walletAddress.(string),
+		FromStatus: task.Status,
+	}
 	task.AssignedChildID = &childID
 
 	// 如果分配给了孩子且状态是pending，改为in_progress
@@ -1036,7 +1285,7 @@ func (h *TaskHandler) DirectAssignTask(c *gin.Context) {
 	}
 
 	// 保存到数据库
-	if err := h.db.Save(&task).Error; err != nil {
+	if err := h.saveTask(&task, event); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to update task",
@@ -1053,87 +1302,64 @@ func (h *TaskHandler) DirectAssignTask(c *gin.Context) {
 	})
 }
 
-// initializeRewardToken 尝试手动初始化奖励代币合约
-func (h *TaskHandler) initializeRewardToken(tokenAddress string) error {
-	if h.contractManager == nil {
-		return fmt.Errorf("合约管理器未初始化")
-	}
-
-	// 检查是否已经初始化
-	if h.contractManager.RewardToken != nil {
-		// 合约已初始化，检查铸币权限
-		log.Printf("[DEBUG] 代币合约已初始化，检查铸币权限")
-		return h.ensureMinterRole()
-	}
-
-	// 使用新的公共方法初始化代币合约
-	err := h.contractManager.InitRewardToken(tokenAddress)
-	if err != nil {
-		return err
+// saveTask 在同一个事务中保存任务并追加一条任务历史，历史的目标状态取保存时任务的状态
+// 事务提交后把状态变化推送给家庭成员
+func (h *TaskHandler) saveTask(task *models.Task, event *models.TaskEvent) error {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(task).Error; err != nil {
+			return err
+		}
+		event.TaskID = task.ID
+		event.ToStatus = task.Status
+		return repository.NewTaskEventRepository(tx).Create(event)
+	})
+	if err == nil {
+		realtime.PublishTaskEvent(h.broker, task, event)
 	}
-
-	// 初始化后检查并确保铸币权限
-	return h.ensureMinterRole()
+	return err
 }
 
-// ensureMinterRole 确保当前账户有铸币权限
-func (h *TaskHandler) ensureMinterRole() error {
-	if h.contractManager == nil || h.contractManager.RewardToken == nil {
-		return fmt.Errorf("合约管理器或代币合约未初始化")
-	}
-
-	// 获取当前账户地址
-	auth, err := h.contractManager.GetTransactOpts(context.Background())
-	if err != nil {
-		return fmt.Errorf("获取交易选项失败: %v", err)
-	}
-
-	// 检查当前账户是否有铸币权限
-	isMinter, err := h.contractManager.RewardToken.AuthorizedMinters(nil, auth.From)
-	if err != nil {
-		log.Printf("[ERROR] 检查铸币权限失败: %v", err)
-		return err
-	}
-
-	if isMinter {
-		log.Printf("[DEBUG] 当前账户 %s 已有铸币权限", auth.From.Hex())
-		return nil
-	}
-
-	log.Printf("[WARN] 当前账户 %s 没有铸币权限，尝试添加...", auth.From.Hex())
-
-	// 尝试添加铸币权限（需要合约所有者权限）
-	ownerAuth, err := h.contractManager.GetTransactOpts(context.Background())
-	if err != nil {
-		return fmt.Errorf("获取所有者交易选项失败: %v", err)
+// optionalTxHash 检查请求中可选的链上交易哈希，格式无效时直接返回错误响应
+func optionalTxHash(c *gin.Context, hash string) (*string, bool) {
+	if hash == "" {
+		return nil, true
 	}
-
-	// 设置更高的gas限制和价格
-	ownerAuth.GasLimit = 1000000
-	if ownerAuth.GasPrice != nil {
-		increasedGasPrice := new(big.Int).Mul(ownerAuth.GasPrice, big.NewInt(250))
-		increasedGasPrice = increasedGasPrice.Div(increasedGasPrice, big.NewInt(100))
-		ownerAuth.GasPrice = increasedGasPrice
+	if !utils.IsValidTxHash(hash) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid transaction hash",
+		})
+		return nil, false
 	}
+	return &hash, true
+}
 
-	// 调用合约添加铸币权限
-	tx, err := h.contractManager.RewardToken.AddMinter(ownerAuth, auth.From)
-	if err != nil {
-		log.Printf("[ERROR] 添加铸币权限失败: %v", err)
-		return err
+// familyChild 查找属于任务所在家庭的孩子，找不到时直接返回错误响应
+// 孩子通过家庭创建者的地址关联家庭；没有家庭的任务只能分配给创建者自己的孩子
+func (h *TaskHandler) familyChild(c *gin.Context, task *models.Task, childID uint) (*models.Child, bool) {
+	query := h.db.Where("id = ?", childID)
+	if task.FamilyID != nil {
+		query = query.Where("parent_address = (?)", h.db.Model(&models.Family{}).Select("parent_address").Where("id = ?", *task.FamilyID))
+	} else {
+		query = query.Where("parent_address = ?", task.CreatedBy)
 	}
 
-	log.Printf("[DEBUG] 添加铸币权限交易已提交，哈希: %s", tx.Hash().Hex())
-
-	// 等待交易确认
-	receipt, err := h.contractManager.WaitForTxReceipt(context.Background(), tx.Hash())
-	if err != nil {
-		log.Printf("[ERROR] 等待添加铸币权限交易确认失败: %v", err)
-		return err
+	var child models.Child
+	result := query.First(&child)
+	if result.Error == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Child not found or not in this family",
+		})
+		return nil, false
+	} else if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return nil, false
 	}
-
-	log.Printf("[SUCCESS] 成功添加铸币权限，区块号: %d，状态: %d", receipt.BlockNumber.Uint64(), receipt.Status)
-	return nil
+	return &child, true
 }
 
 // UploadImage 处理图片上传并返回URL
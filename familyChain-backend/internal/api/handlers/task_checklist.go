@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxChecklistItems 一个任务清单最多的步骤数
+const maxChecklistItems = 50
+
+// ChecklistItemRequest 任务清单中的一项，按数组顺序排列
+type ChecklistItemRequest struct {
+	ID        *uint  `json:"id,omitempty"` // 修改清单时指定已有步骤的ID，保留它的勾选状态
+	Title     string `json:"title"`
+	Required  *bool  `json:"required,omitempty"`   // 是否必做，默认为true
+	ProofType string `json:"proof_type,omitempty"` // none（默认）或photo
+}
+
+// ReplaceChecklistRequest 用新的清单替换任务的清单
+type ReplaceChecklistRequest struct {
+	Items             []ChecklistItemRequest `json:"items"`
+	ChecklistRequired *bool                  `json:"checklist_required,omitempty"` // 不传时保持不变
+}
+
+// CheckChecklistItemRequest 孩子勾选一个步骤，需要照片的步骤必须提供proof_url
+type CheckChecklistItemRequest struct {
+	ProofUrl string `json:"proof_url,omitempty"`
+}
+
+// ReplaceChecklist 家长修改任务清单，已经勾选的步骤只要保留ID就不会丢失勾选状态
+func (h *TaskHandler) ReplaceChecklist(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid task ID")
+	if !ok {
+		return
+	}
+
+	var req ReplaceChecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	walletAddress := c.GetString("wallet_address")
+
+	var task models.Task
+	result := h.db.First(&task, id)
+	if result.Error == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Task not found",
+		})
+		return
+	} else if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if err := h.membershipService.AuthorizeTask(&task, walletAddress, true); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	if task.Status == "approved" || task.Status == "rejected" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Cannot change the checklist of a finished task",
+		})
+		return
+	}
+
+	checklistRepo := repository.NewTaskChecklistRepository(h.db)
+	existing, err := checklistRepo.ListByTask(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	items, ok := checklistItems(c, req.Items, existing)
+	if !ok {
+		return
+	}
+	if req.ChecklistRequired != nil {
+		task.ChecklistRequired = *req.ChecklistRequired
+	}
+	if task.ChecklistRequired && len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "checklist_required needs at least one checklist item",
+		})
+		return
+	}
+
+	event := &models.TaskEvent{
+		Action:     models.TaskEventUpdated,
+		Actor:      walletAddress,
+		FromStatus: task.Status,
+	}
+	if err := checklistRepo.Replace(&task, items, event); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update checklist",
+		})
+		return
+	}
+
+	h.db.Preload("AssignedChild").Preload("Checklist", repository.OrderedChecklist).First(&task, task.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
+
+// CheckChecklistItem 孩子勾选清单中的一个步骤
+func (h *TaskHandler) CheckChecklistItem(c *gin.Context) {
+	var req CheckChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	item, ok := h.childChecklistItem(c)
+	if !ok {
+		return
+	}
+
+	proofUrl := strings.TrimSpace(req.ProofUrl)
+	if proofUrl != "" && !utils.IsValidPhotoURL(proofUrl) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid proof_url: upload an image and submit its URL",
+		})
+		return
+	}
+	if item.ProofType == models.ProofTypePhoto && proofUrl == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "This step requires a photo: upload an image and submit its URL as proof_url",
+		})
+		return
+	}
+
+	now := time.Now()
+	item.CheckedAt = &now
+	item.CheckedBy = c.GetString("wallet_address")
+	item.ProofUrl = nil
+	if proofUrl != "" {
+		item.ProofUrl = &proofUrl
+	}
+
+	if err := repository.NewTaskChecklistRepository(h.db).Update(item); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update checklist item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    item,
+	})
+}
+
+// UncheckChecklistItem 孩子取消勾选清单中的一个步骤，同时清除步骤的照片
+func (h *TaskHandler) UncheckChecklistItem(c *gin.Context) {
+	item, ok := h.childChecklistItem(c)
+	if !ok {
+		return
+	}
+
+	item.CheckedAt = nil
+	item.CheckedBy = ""
+	item.ProofUrl = nil
+
+	if err := repository.NewTaskChecklistRepository(h.db).Update(item); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update checklist item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    item,
+	})
+}
+
+// childChecklistItem 查找分配给当前孩子的任务中的清单步骤，任务必须还可以提交；失败时直接返回错误响应
+func (h *TaskHandler) childChecklistItem(c *gin.Context) (*models.TaskChecklistItem, bool) {
+	taskID, ok := uintParam(c, "id", "Invalid task ID")
+	if !ok {
+		return nil, false
+	}
+	itemID, ok := uintParam(c, "item_id", "Invalid checklist item ID")
+	if !ok {
+		return nil, false
+	}
+
+	var task models.Task
+	result := h.db.First(&task, taskID)
+	if result.Error == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Task not found",
+		})
+		return nil, false
+	} else if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return nil, false
+	}
+
+	var child models.Child
+	result = h.db.Where("wallet_address = ?", c.GetString("wallet_address")).First(&child)
+	if result.Error != nil || task.AssignedChildID == nil || *task.AssignedChildID != child.ID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Task not assigned to you",
+		})
+		return nil, false
+	}
+
+	// 已经提交或结束的任务不能再修改清单，过期的任务是否能迟交由CompleteTask判断
+	if task.Status != "in_progress" && task.Status != models.TaskStatusExpired {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Task is not in progress",
+		})
+		return nil, false
+	}
+
+	item, err := repository.NewTaskChecklistRepository(h.db).GetItem(task.ID, itemID)
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Checklist item not found",
+		})
+		return nil, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return nil, false
+	}
+	return item, true
+}
+
+// checklistItems 检查请求中的清单并转换为模型，existing是任务已有的步骤，带ID的项在它的基础上修改
+// 检查失败时直接返回错误响应
+func checklistItems(c *gin.Context, reqs []ChecklistItemRequest, existing []models.TaskChecklistItem) ([]models.TaskChecklistItem, bool) {
+	fail := func(message string) ([]models.TaskChecklistItem, bool) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return nil, false
+	}
+
+	if len(reqs) > maxChecklistItems {
+		return fail("Too many checklist items")
+	}
+
+	byID := make(map[uint]models.TaskChecklistItem, len(existing))
+	for _, item := range existing {
+		byID[item.ID] = item
+	}
+	used := make(map[uint]bool, len(reqs))
+
+	items := make([]models.TaskChecklistItem, 0, len(reqs))
+	for _, req := range reqs {
+		title := utils.SanitizeString(req.Title)
+		if title == "" {
+			return fail("Checklist item title is required")
+		}
+		if req.ProofType == "" {
+			req.ProofType = models.ProofTypeNone
+		}
+		if req.ProofType != models.ProofTypeNone && req.ProofType != models.ProofTypePhoto {
+			return fail("Invalid checklist proof type. Must be 'none' or 'photo'")
+		}
+
+		var item models.TaskChecklistItem
+		if req.ID != nil {
+			found, ok := byID[*req.ID]
+			if !ok || used[*req.ID] {
+				return fail("Checklist item not found")
+			}
+			used[*req.ID] = true
+			item = found
+		}
+		item.Position = len(items)
+		item.Title = title
+		item.Required = req.Required == nil || *req.Required
+		item.ProofType = req.ProofType
+
+		// 改为需要照片后，之前没有照片的勾选不再有效
+		if item.ProofType == models.ProofTypePhoto && item.ProofUrl == nil {
+			item.CheckedAt = nil
+			item.CheckedBy = ""
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
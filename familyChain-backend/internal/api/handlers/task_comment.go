@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxCommentLength 一条留言最多的字符数
+const maxCommentLength = 2000
+
+// CreateTaskCommentRequest 发表留言，图片需要先通过上传接口上传
+type CreateTaskCommentRequest struct {
+	Body     string `json:"body" binding:"required"`
+	ImageUrl string `json:"image_url,omitempty"`
+}
+
+// GetTaskComments 按发表顺序获取任务的留言，查看留言不会改变已读状态
+func (h *TaskHandler) GetTaskComments(c *gin.Context) {
+	task, ok := h.viewableTask(c)
+	if !ok {
+		return
+	}
+
+	comments, err := repository.NewTaskCommentRepository(h.db).ListByTask(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get comments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    comments,
+	})
+}
+
+// CreateTaskComment 家长或孩子在任务下发表留言
+func (h *TaskHandler) CreateTaskComment(c *gin.Context) {
+	var req CreateTaskCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	task, ok := h.viewableTask(c)
+	if !ok {
+		return
+	}
+
+	body := utils.SanitizeString(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Comment body is required",
+		})
+		return
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Comment is too long",
+		})
+		return
+	}
+
+	comment := models.TaskComment{
+		TaskID:        task.ID,
+		AuthorAddress: c.GetString("wallet_address"),
+		AuthorRole:    c.GetString("role"),
+		Body:          body,
+	}
+	if imageUrl := strings.TrimSpace(req.ImageUrl); imageUrl != "" {
+		if !utils.IsValidPhotoURL(imageUrl) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid image_url: upload an image and submit its URL",
+			})
+			return
+		}
+		comment.ImageUrl = &imageUrl
+	}
+
+	if err := repository.NewTaskCommentRepository(h.db).Create(&comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    comment,
+	})
+}
+
+// DeleteTaskComment 删除自己发表的留言
+func (h *TaskHandler) DeleteTaskComment(c *gin.Context) {
+	task, ok := h.viewableTask(c)
+	if !ok {
+		return
+	}
+	commentID, ok := uintParam(c, "comment_id", "Invalid comment ID")
+	if !ok {
+		return
+	}
+
+	commentRepo := repository.NewTaskCommentRepository(h.db)
+	comment, err := commentRepo.GetByID(task.ID, commentID)
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Comment not found",
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Database error",
+		})
+		return
+	}
+
+	if comment.AuthorAddress != c.GetString("wallet_address") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Only the author can delete this comment",
+		})
+		return
+	}
+
+	if err := commentRepo.Delete(comment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete comment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Comment deleted successfully",
+	})
+}
+
+// MarkTaskCommentsRead 把任务中的留言全部标记为已读
+func (h *TaskHandler) MarkTaskCommentsRead(c *gin.Context) {
+	task, ok := h.viewableTask(c)
+	if !ok {
+		return
+	}
+
+	commentRepo := repository.NewTaskCommentRepository(h.db)
+	latestID, err := commentRepo.LatestID(task.ID)
+	if err == nil && latestID > 0 {
+		err = commentRepo.MarkRead(task.ID, c.GetString("wallet_address"), latestID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to mark comments as read",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"task_id":              task.ID,
+			"last_read_comment_id": latestID,
+		},
+	})
+}
+
+// GetUnreadComments 获取当前用户能看到的任务中每个任务的未读留言数
+func (h *TaskHandler) GetUnreadComments(c *gin.Context) {
+	walletAddress := c.GetString("wallet_address")
+	role, _ := c.Get("role")
+
+	tasks, ok := h.visibleTasks(c, walletAddress, role)
+	if !ok {
+		return
+	}
+
+	counts, err := repository.NewTaskCommentRepository(h.db).UnreadCounts(walletAddress, tasks.Select("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count unread comments",
+		})
+		return
+	}
+
+	if counts == nil {
+		counts = []models.TaskUnreadComments{}
+	}
+	var total int64
+	for _, count := range counts {
+		total += count.Unread
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"total": total,
+			"tasks": counts,
+		},
+	})
+}
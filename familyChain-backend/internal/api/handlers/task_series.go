@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/services"
+	"eth-for-babies-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskSeriesHandler 处理重复任务相关的API请求
+type TaskSeriesHandler struct {
+	seriesService     *services.TaskSeriesService
+	membershipService *services.MembershipService
+}
+
+// NewTaskSeriesHandler 创建一个新的重复任务处理器
+func NewTaskSeriesHandler(seriesService *services.TaskSeriesService, membershipService *services.MembershipService) *TaskSeriesHandler {
+	return &TaskSeriesHandler{
+		seriesService:     seriesService,
+		membershipService: membershipService,
+	}
+}
+
+// CreateTaskSeriesRequest 创建重复任务，日程参数的含义与RRULE相同
+type CreateTaskSeriesRequest struct {
+	Title        string `json:"title" binding:"required"`
+	Description  string `json:"description" binding:"required"`
+	RewardAmount string `json:"reward_amount" binding:"required"`
+	Difficulty   string `json:"difficulty" binding:"required"`
+	ImageUrl     string `json:"image_url,omitempty"`
+	// 家长属于多个家庭时需要指定重复任务属于哪个家庭
+	FamilyID  *uint  `json:"family_id,omitempty"`
+	ChildIDs  []uint `json:"child_ids" binding:"required"`
+	Frequency string `json:"frequency" binding:"required"` // daily, weekdays, weekly, monthly
+	Interval  int    `json:"interval,omitempty"`
+	Weekdays  string `json:"weekdays,omitempty"` // 每周重复的星期，例如 "MO,WE,FR"
+	MonthDay  int    `json:"month_day,omitempty"`
+	DueTime   string `json:"due_time,omitempty"`   // HH:MM，默认23:59
+	TimeZone  string `json:"time_zone,omitempty"`  // IANA时区，默认UTC
+	StartDate string `json:"start_date,omitempty"` // YYYY-MM-DD，默认今天
+	EndDate   string `json:"end_date,omitempty"`
+}
+
+// UpdateTaskSeriesRequest 修改重复任务，只影响之后生成的实例
+type UpdateTaskSeriesRequest struct {
+	Title        *string `json:"title,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	RewardAmount *string `json:"reward_amount,omitempty"`
+	Difficulty   *string `json:"difficulty,omitempty"`
+	ImageUrl     *string `json:"image_url,omitempty"`
+	ChildIDs     []uint  `json:"child_ids,omitempty"`
+	Frequency    *string `json:"frequency,omitempty"`
+	Interval     *int    `json:"interval,omitempty"`
+	Weekdays     *string `json:"weekdays,omitempty"`
+	MonthDay     *int    `json:"month_day,omitempty"`
+	DueTime      *string `json:"due_time,omitempty"`
+	TimeZone     *string `json:"time_zone,omitempty"`
+	EndDate      *string `json:"end_date,omitempty"` // 空字符串表示取消结束日期
+}
+
+// SkipOccurrenceRequest 跳过重复任务的某一次出现
+type SkipOccurrenceRequest struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+}
+
+// CreateTaskSeries 创建重复任务
+func (h *TaskSeriesHandler) CreateTaskSeries(c *gin.Context) {
+	var req CreateTaskSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	walletAddress := c.GetString("wallet_address")
+	family, err := h.membershipService.ResolveManagedFamily(walletAddress, req.FamilyID)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	series := &models.TaskSeries{
+		FamilyID:     family.ID,
+		CreatedBy:    walletAddress,
+		Title:        utils.SanitizeString(req.Title),
+		Description:  utils.SanitizeString(req.Description),
+		RewardAmount: req.RewardAmount,
+		Difficulty:   req.Difficulty,
+		Frequency:    req.Frequency,
+		Interval:     req.Interval,
+		Weekdays:     req.Weekdays,
+		MonthDay:     req.MonthDay,
+		DueTime:      req.DueTime,
+		TimeZone:     req.TimeZone,
+	}
+	if req.ImageUrl != "" {
+		series.ImageUrl = &req.ImageUrl
+	}
+	if req.StartDate != "" {
+		startDate, err := utils.ParseDate(req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid start_date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+		series.StartDate = startDate
+	}
+	if req.EndDate != "" {
+		endDate, err := utils.ParseDate(req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid end_date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+		series.EndDate = &endDate
+	}
+
+	if err := h.seriesService.Create(series, req.ChildIDs, time.Now()); err != nil {
+		respondTaskSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// GetTaskSeries 获取调用者所在家庭的重复任务，可以用family_id筛选
+func (h *TaskSeriesHandler) GetTaskSeries(c *gin.Context) {
+	var familyID *uint
+	if value := c.Query("family_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid family ID",
+			})
+			return
+		}
+		parsed := uint(id)
+		familyID = &parsed
+	}
+
+	list, err := h.seriesService.List(c.GetString("wallet_address"), familyID, time.Now())
+	if err != nil {
+		respondTaskSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// GetTaskSeriesByID 获取重复任务详情，包含接下来几次出现的日期
+func (h *TaskSeriesHandler) GetTaskSeriesByID(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid series ID")
+	if !ok {
+		return
+	}
+
+	series, err := h.seriesService.Get(id, c.GetString("wallet_address"), time.Now())
+	if err != nil {
+		respondTaskSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// UpdateTaskSeries 修改重复任务，已经生成的任务不受影响
+func (h *TaskSeriesHandler) UpdateTaskSeries(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid series ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateTaskSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	series, err := h.seriesService.Update(id, c.GetString("wallet_address"), services.TaskSeriesChanges{
+		Title:        req.Title,
+		Description:  req.Description,
+		RewardAmount: req.RewardAmount,
+		Difficulty:   req.Difficulty,
+		ImageUrl:     req.ImageUrl,
+		Frequency:    req.Frequency,
+		Interval:     req.Interval,
+		Weekdays:     req.Weekdays,
+		MonthDay:     req.MonthDay,
+		DueTime:      req.DueTime,
+		TimeZone:     req.TimeZone,
+		EndDate:      req.EndDate,
+		ChildIDs:     req.ChildIDs,
+	}, time.Now())
+	if err != nil {
+		respondTaskSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// PauseTaskSeries 暂停重复任务
+func (h *TaskSeriesHandler) PauseTaskSeries(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid series ID")
+	if !ok {
+		return
+	}
+	series, err := h.seriesService.Pause(id, c.GetString("wallet_address"))
+	respondTaskSeries(c, series, err)
+}
+
+// ResumeTaskSeries 恢复暂停的重复任务
+func (h *TaskSeriesHandler) ResumeTaskSeries(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid series ID")
+	if !ok {
+		return
+	}
+	series, err := h.seriesService.Resume(id, c.GetString("wallet_address"), time.Now())
+	respondTaskSeries(c, series, err)
+}
+
+// SkipOccurrence 跳过重复任务的某一次出现
+func (h *TaskSeriesHandler) SkipOccurrence(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid series ID")
+	if !ok {
+		return
+	}
+
+	var req SkipOccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+	series, err := h.seriesService.Skip(id, c.GetString("wallet_address"), req.Date)
+	respondTaskSeries(c, series, err)
+}
+
+// EndTaskSeries 结束重复任务，已经生成的任务保持不变
+func (h *TaskSeriesHandler) EndTaskSeries(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid series ID")
+	if !ok {
+		return
+	}
+	series, err := h.seriesService.End(id, c.GetString("wallet_address"))
+	respondTaskSeries(c, series, err)
+}
+
+// respondTaskSeries 返回修改状态后的重复任务
+func respondTaskSeries(c *gin.Context, series *models.TaskSeries, err error) {
+	if err != nil {
+		respondTaskSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// respondTaskSeriesError 参数错误返回400，其他错误按家庭成员检查的规则处理
+func respondTaskSeriesError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidTaskSeries) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	respondMembershipError(c, err)
+}
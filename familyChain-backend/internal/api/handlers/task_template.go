@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/services"
+	"eth-for-babies-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskTemplateHandler 处理任务模板相关的API请求
+type TaskTemplateHandler struct {
+	templateService   *services.TaskTemplateService
+	membershipService *services.MembershipService
+}
+
+// NewTaskTemplateHandler 创建一个新的任务模板处理器
+func NewTaskTemplateHandler(templateService *services.TaskTemplateService, membershipService *services.MembershipService) *TaskTemplateHandler {
+	return &TaskTemplateHandler{
+		templateService:   templateService,
+		membershipService: membershipService,
+	}
+}
+
+// CreateTaskTemplateRequest 创建任务模板；提供task_id时从该任务复制内容
+type CreateTaskTemplateRequest struct {
+	TaskID            *uint  `json:"task_id,omitempty"`
+	Title             string `json:"title,omitempty"`
+	Description       string `json:"description,omitempty"`
+	Category          string `json:"category,omitempty"`
+	RewardAmount      string `json:"reward_amount,omitempty"`
+	Difficulty        string `json:"difficulty,omitempty"`
+	ProofType         string `json:"proof_type,omitempty"` // none, text, photo，默认text
+	ProofInstructions string `json:"proof_instructions,omitempty"`
+	ImageUrl          string `json:"image_url,omitempty"`
+	// 家长属于多个家庭时需要指定模板属于哪个家庭
+	FamilyID *uint `json:"family_id,omitempty"`
+}
+
+// UpdateTaskTemplateRequest 修改任务模板，空字符串表示清除可选字段
+type UpdateTaskTemplateRequest struct {
+	Title             *string `json:"title,omitempty"`
+	Description       *string `json:"description,omitempty"`
+	Category          *string `json:"category,omitempty"`
+	RewardAmount      *string `json:"reward_amount,omitempty"`
+	Difficulty        *string `json:"difficulty,omitempty"`
+	ProofType         *string `json:"proof_type,omitempty"`
+	ProofInstructions *string `json:"proof_instructions,omitempty"`
+	ImageUrl          *string `json:"image_url,omitempty"`
+}
+
+// AssignTaskTemplateRequest 从模板为多个孩子创建任务
+type AssignTaskTemplateRequest struct {
+	ChildIDs     []uint  `json:"child_ids" binding:"required"`
+	FamilyID     *uint   `json:"family_id,omitempty"`     // 使用内置模板时任务所属的家庭
+	DueDate      string  `json:"due_date,omitempty"`      // RFC3339
+	RewardAmount *string `json:"reward_amount,omitempty"` // 覆盖模板的奖励
+}
+
+// CreateTaskTemplate 保存任务模板
+func (h *TaskTemplateHandler) CreateTaskTemplate(c *gin.Context) {
+	var req CreateTaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	walletAddress := c.GetString("wallet_address")
+	if req.TaskID != nil {
+		template, err := h.templateService.CreateFromTask(*req.TaskID, walletAddress, req.Category)
+		if err != nil {
+			respondTaskTemplateError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"data":    template,
+		})
+		return
+	}
+
+	family, err := h.membershipService.ResolveManagedFamily(walletAddress, req.FamilyID)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	template := &models.TaskTemplate{
+		FamilyID:     &family.ID,
+		CreatedBy:    walletAddress,
+		Title:        utils.SanitizeString(req.Title),
+		Description:  utils.SanitizeString(req.Description),
+		Category:     utils.SanitizeString(req.Category),
+		RewardAmount: req.RewardAmount,
+		Difficulty:   req.Difficulty,
+		ProofType:    req.ProofType,
+	}
+	if req.ProofInstructions != "" {
+		template.ProofInstructions = &req.ProofInstructions
+	}
+	if req.ImageUrl != "" {
+		template.ImageUrl = &req.ImageUrl
+	}
+
+	if err := h.templateService.Create(template); err != nil {
+		respondTaskTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// GetTaskTemplates 获取内置模板和调用者所在家庭的模板，可以用family_id筛选
+func (h *TaskTemplateHandler) GetTaskTemplates(c *gin.Context) {
+	var familyID *uint
+	if value := c.Query("family_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid family ID",
+			})
+			return
+		}
+		parsed := uint(id)
+		familyID = &parsed
+	}
+
+	templates, err := h.templateService.List(c.GetString("wallet_address"), familyID)
+	if err != nil {
+		respondTaskTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+// GetTaskTemplateByID 获取任务模板详情
+func (h *TaskTemplateHandler) GetTaskTemplateByID(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.Get(id, c.GetString("wallet_address"))
+	if err != nil {
+		respondTaskTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UpdateTaskTemplate 修改家庭的任务模板，内置模板不能修改
+func (h *TaskTemplateHandler) UpdateTaskTemplate(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateTaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	template, err := h.templateService.Update(id, c.GetString("wallet_address"), services.TaskTemplateChanges{
+		Title:             req.Title,
+		Description:       req.Description,
+		Category:          req.Category,
+		RewardAmount:      req.RewardAmount,
+		Difficulty:        req.Difficulty,
+		ProofType:         req.ProofType,
+		ProofInstructions: req.ProofInstructions,
+		ImageUrl:          req.ImageUrl,
+	})
+	if err != nil {
+		respondTaskTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// DeleteTaskTemplate 删除家庭的任务模板，已经创建的任务不受影响
+func (h *TaskTemplateHandler) DeleteTaskTemplate(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+
+	if err := h.templateService.Delete(id, c.GetString("wallet_address")); err != nil {
+		respondTaskTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Task template deleted successfully",
+	})
+}
+
+// AssignTaskTemplate 从模板为多个孩子各创建一个任务，全部成功或全部失败
+func (h *TaskTemplateHandler) AssignTaskTemplate(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid template ID")
+	if !ok {
+		return
+	}
+
+	var req AssignTaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	assignment := services.TemplateAssignment{
+		FamilyID:     req.FamilyID,
+		ChildIDs:     req.ChildIDs,
+		RewardAmount: req.RewardAmount,
+	}
+	if req.DueDate != "" {
+		dueDate, err := time.Parse(time.RFC3339, req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid due date format. Use RFC3339 format",
+			})
+			return
+		}
+		assignment.DueDate = &dueDate
+	}
+
+	tasks, err := h.templateService.Assign(id, c.GetString("wallet_address"), assignment)
+	if err != nil {
+		respondTaskTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    tasks,
+	})
+}
+
+// respondTaskTemplateError 参数错误返回400，修改内置模板返回403，其他错误按家庭成员检查的规则处理
+func respondTaskTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTaskTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrBuiltInTemplate):
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		respondMembershipError(c, err)
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler 处理家庭webhook相关的API请求
+type WebhookHandler struct {
+	webhookService    *services.WebhookService
+	membershipService *services.MembershipService
+}
+
+// NewWebhookHandler 创建一个新的webhook处理器
+func NewWebhookHandler(webhookService *services.WebhookService, membershipService *services.MembershipService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService:    webhookService,
+		membershipService: membershipService,
+	}
+}
+
+// CreateWebhookRequest 登记webhook
+type CreateWebhookRequest struct {
+	URL         string `json:"url" binding:"required"`
+	Description string `json:"description,omitempty"`
+	Events      string `json:"events,omitempty"` // 订阅的事件类型，例如 "task.approved,tokens.minted"，为空表示全部
+	// 家长属于多个家庭时需要指定webhook属于哪个家庭
+	FamilyID *uint `json:"family_id,omitempty"`
+}
+
+// UpdateWebhookRequest 修改webhook
+type UpdateWebhookRequest struct {
+	URL         *string `json:"url,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Events      *string `json:"events,omitempty"` // 空字符串表示订阅全部事件
+	Active      *bool   `json:"active,omitempty"`
+}
+
+// CreateWebhook 登记webhook，响应中包含只返回一次的签名密钥
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	walletAddress := c.GetString("wallet_address")
+	family, err := h.membershipService.ResolveManagedFamily(walletAddress, req.FamilyID)
+	if err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	webhook, err := h.webhookService.Create(&models.Webhook{
+		FamilyID:    family.ID,
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+		CreatedBy:   walletAddress,
+	})
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    webhook,
+	})
+}
+
+// GetWebhooks 获取调用者可以管理的家庭的webhook，可以用family_id筛选
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	var familyID *uint
+	if value := c.Query("family_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid family ID",
+			})
+			return
+		}
+		parsed := uint(id)
+		familyID = &parsed
+	}
+
+	webhooks, err := h.webhookService.List(c.GetString("wallet_address"), familyID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhooks,
+	})
+}
+
+// GetWebhookByID 获取webhook详情
+func (h *WebhookHandler) GetWebhookByID(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Get(id, c.GetString("wallet_address"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhook,
+	})
+}
+
+// UpdateWebhook 修改webhook的地址、说明、事件筛选或启用状态
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	webhook, err := h.webhookService.Update(id, c.GetString("wallet_address"), services.WebhookChanges{
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+		Active:      req.Active,
+	})
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhook,
+	})
+}
+
+// DeleteWebhook 删除webhook和它的投递日志
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(id, c.GetString("wallet_address")); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// TestWebhook 立即发送一条测试事件，返回包含响应状态码的投递记录
+// 接收方返回错误时接口仍然返回200，投递结果在记录的status和response_code中
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid webhook ID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.SendTest(c.Request.Context(), id, c.GetString("wallet_address"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+// GetWebhookDeliveries 获取webhook最近的投递记录，limit最大为50
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := uintParam(c, "id", "Invalid webhook ID")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	deliveries, err := h.webhookService.Deliveries(id, c.GetString("wallet_address"), limit)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    deliveries,
+	})
+}
+
+// respondWebhookError 参数错误返回400，其他错误按家庭成员检查的规则处理
+func respondWebhookError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidWebhook) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	respondMembershipError(c, err)
+}
@@ -9,7 +9,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
+// SessionChecker 检查访问令牌所属的会话是否仍然有效
+type SessionChecker interface {
+	IsActive(sessionID uint) (bool, error)
+}
+
+func AuthMiddleware(jwtManager *utils.JWTManager, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -43,10 +48,30 @@ func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 			return
 		}
 
+		// 会话被撤销（登出、所有设备登出、刷新令牌重用）后令牌立即失效
+		active, err := sessions.IsActive(claims.SessionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to check session",
+			})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Session has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存储到上下文中
 		c.Set("user_id", claims.UserID)
 		c.Set("wallet_address", claims.WalletAddress)
 		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
@@ -4,6 +4,7 @@ import (
 	"eth-for-babies-backend/internal/api/handlers"
 	"eth-for-babies-backend/internal/api/middleware"
 	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/realtime"
 	"eth-for-babies-backend/internal/repository"
 	"eth-for-babies-backend/internal/services"
 	"eth-for-babies-backend/internal/utils"
@@ -13,11 +14,11 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.ContractManager) *gin.Engine {
+func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.ContractManager, broker realtime.Broker) *gin.Engine {
 	router := gin.New()
 
 	// 创建JWT管理器
-	jwtManager := utils.NewJWTManager(cfg.JWTSecret)
+	jwtManager := utils.NewJWTManager(cfg.JWTSecret, cfg.Auth.AccessTokenTTL)
 
 	// 全局中间件
 	router.Use(middleware.LoggerMiddleware())
@@ -33,20 +34,57 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.Co
 	childRepo := repository.NewChildRepository(db)
 	familyRepo := repository.NewFamilyRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
+	authNonceRepo := repository.NewAuthNonceRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	familyMemberRepo := repository.NewFamilyMemberRepository(db)
+	familyInvitationRepo := repository.NewFamilyInvitationRepository(db)
+	familyInviteCodeRepo := repository.NewFamilyInviteCodeRepository(db)
+	taskSeriesRepo := repository.NewTaskSeriesRepository(db)
+	taskTemplateRepo := repository.NewTaskTemplateRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	savingsGoalRepo := repository.NewSavingsGoalRepository(db)
+	rewardProposalRepo := repository.NewRewardProposalRepository(db)
 
 	// 创建服务
 	contractService, _ := services.NewContractService(&cfg.Blockchain, contractManager)
-	rewardService := services.NewRewardService(rewardRepo, exchangeRepo, childRepo, contractManager)
-	childService := services.NewChildService(childRepo, familyRepo, taskRepo)
+	rewardService := services.NewRewardService(rewardRepo, exchangeRepo, childRepo, contractManager, broker, cfg.Notification.LowStockThreshold)
+	childService := services.NewChildService(childRepo, familyRepo, taskRepo, ledgerRepo)
+	authService := services.NewAuthService(authNonceRepo, cfg.Auth, cfg.Blockchain.ChainID)
+	sessionService := services.NewSessionService(sessionRepo, userRepo, jwtManager, cfg.Auth.RefreshTokenTTL)
+	membershipService := services.NewMembershipService(familyMemberRepo, familyInvitationRepo, familyRepo, childRepo)
+	inviteCodeService := services.NewInviteCodeService(
+		familyInviteCodeRepo, familyMemberRepo, childRepo, userRepo, membershipService,
+		utils.NewInviteCodeSigner(cfg.Auth.InviteCodeSecret), cfg.Auth.InviteCodeTTL, cfg.Auth.URI,
+	)
+	taskSeriesService := services.NewTaskSeriesService(taskSeriesRepo, childRepo, familyRepo, membershipService, broker)
+	taskTemplateService := services.NewTaskTemplateService(taskTemplateRepo, taskRepo, childRepo, familyRepo, membershipService, broker)
+	webhookService := services.NewWebhookService(webhookRepo, membershipService, services.NewWebhookDispatcher(webhookRepo, cfg.Webhook))
+	notificationService := services.NewNotificationService(notificationRepo, familyMemberRepo, childRepo, userRepo, cfg.Notification.SMTP.Configured())
+	ledgerService := services.NewLedgerService(ledgerRepo, repository.NewChainCursorRepository(db), familyRepo, contractManager)
+	savingsService := services.NewSavingsService(savingsGoalRepo, rewardRepo, ledgerRepo, contractManager)
+	rewardProposalService := services.NewRewardProposalService(rewardProposalRepo, broker)
 
 	// 创建处理器
-	authHandler := handlers.NewAuthHandler(db, jwtManager)
-	familyHandler := handlers.NewFamilyHandler(db)
-	childHandler := handlers.NewChildHandler(db)
-	taskHandler := handlers.NewTaskHandler(db, contractManager)
+	authHandler := handlers.NewAuthHandler(db, authService, sessionService, inviteCodeService)
+	familyHandler := handlers.NewFamilyHandler(db, membershipService)
+	membershipHandler := handlers.NewMembershipHandler(membershipService)
+	inviteCodeHandler := handlers.NewInviteCodeHandler(inviteCodeService)
+	childHandler := handlers.NewChildHandler(db, membershipService)
+	taskHandler := handlers.NewTaskHandler(db, contractManager, membershipService, broker)
+	taskSeriesHandler := handlers.NewTaskSeriesHandler(taskSeriesService, membershipService)
+	taskTemplateHandler := handlers.NewTaskTemplateHandler(taskTemplateService, membershipService)
 	contractHandler := handlers.NewContractHandler(db, contractService)
-	rewardHandler := handlers.NewRewardHandler(rewardService)
-	exchangeHandler := handlers.NewExchangeHandler(rewardService, childService)
+	rewardHandler := handlers.NewRewardHandler(rewardService, membershipService)
+	exchangeHandler := handlers.NewExchangeHandler(rewardService, childService, membershipService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, membershipService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService, childService, membershipService)
+	savingsHandler := handlers.NewSavingsHandler(savingsService, childService, membershipService)
+	rewardProposalHandler := handlers.NewRewardProposalHandler(rewardProposalService, childService, membershipService)
+	eventsHandler := handlers.NewEventsHandler(broker, membershipService, childService, sessionService, cfg.Realtime.HeartbeatInterval)
 
 	// API v1 路由组
 	v1 := router.Group("/api/v1")
@@ -57,14 +95,20 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.Co
 			auth.GET("/nonce/:wallet_address", authHandler.GetNonce)
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/register", authHandler.Register)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/invite-codes/preview", inviteCodeHandler.PreviewInviteCode)
 		}
 
 		// 需要认证的路由
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware(jwtManager))
+		protected.Use(middleware.AuthMiddleware(jwtManager, sessionService))
 		{
 			// 认证相关（需要认证）
 			protected.POST("/auth/logout", authHandler.Logout)
+			protected.POST("/auth/logout-all", authHandler.LogoutAll)
+
+			// 实时推送（Server-Sent Events）
+			protected.GET("/events", eventsHandler.Stream)
 
 			// 家庭管理路由
 			families := protected.Group("/families")
@@ -73,6 +117,32 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.Co
 				families.GET("", familyHandler.GetFamilies)
 				families.GET("/:id", familyHandler.GetFamilyByID)
 				families.PUT("/:id", middleware.RequireRole("parent"), familyHandler.UpdateFamily)
+				families.GET("/:id/ledger/reconciliation", middleware.RequireRole("parent"), ledgerHandler.ReconcileFamilyLedger)
+				families.GET("/:id/reward-proposals", middleware.RequireRole("parent"), rewardProposalHandler.GetFamilyRewardProposals)
+
+				// 家庭成员和邀请
+				families.GET("/:id/members", middleware.RequireRole("parent"), membershipHandler.ListMembers)
+				families.PUT("/:id/members/:member_id", middleware.RequireRole("parent"), membershipHandler.UpdateMemberRole)
+				families.DELETE("/:id/members/:member_id", middleware.RequireRole("parent"), membershipHandler.RemoveMember)
+				families.POST("/:id/invitations", middleware.RequireRole("parent"), membershipHandler.CreateInvitation)
+				families.GET("/:id/invitations", middleware.RequireRole("parent"), membershipHandler.ListFamilyInvitations)
+				families.DELETE("/:id/invitations/:invitation_id", middleware.RequireRole("parent"), membershipHandler.RevokeInvitation)
+
+				// 一次性邀请码（链接），用于邀请孩子和其他监护人
+				families.POST("/:id/invite-codes", middleware.RequireRole("parent"), inviteCodeHandler.CreateInviteCode)
+				families.GET("/:id/invite-codes", middleware.RequireRole("parent"), inviteCodeHandler.ListInviteCodes)
+				families.DELETE("/:id/invite-codes/:code_id", middleware.RequireRole("parent"), inviteCodeHandler.RevokeInviteCode)
+			}
+
+			// 已登录用户兑换邀请码
+			protected.POST("/invite-codes/redeem", inviteCodeHandler.RedeemInviteCode)
+
+			// 收到的家庭邀请
+			invitations := protected.Group("/invitations")
+			{
+				invitations.GET("", membershipHandler.GetMyInvitations)
+				invitations.POST("/:id/accept", middleware.RequireRole("parent"), membershipHandler.AcceptInvitation)
+				invitations.POST("/:id/decline", membershipHandler.DeclineInvitation)
 			}
 
 			// 孩子管理路由
@@ -83,6 +153,8 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.Co
 				children.GET("/:id", childHandler.GetChildByID)
 				children.PUT("/:id", childHandler.UpdateChild)
 				children.GET("/:id/progress", childHandler.GetChildProgress)
+				children.GET("/:id/ledger", ledgerHandler.GetChildLedger)
+				children.GET("/:id/savings-goals", savingsHandler.GetChildSavingsGoals)
 				children.DELETE("/:id", middleware.RequireRole("parent"), childHandler.DeleteChild)
 			}
 
@@ -92,15 +164,70 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.Co
 				tasks.POST("", middleware.RequireRole("parent"), taskHandler.CreateTask)
 				tasks.GET("", taskHandler.GetTasks)
 				tasks.GET("/:id", taskHandler.GetTaskByID)
+				tasks.GET("/:id/history", taskHandler.GetTaskHistory)
+				tasks.GET("/unread-comments", taskHandler.GetUnreadComments)
+				tasks.GET("/:id/comments", taskHandler.GetTaskComments)
+				tasks.POST("/:id/comments", taskHandler.CreateTaskComment)
+				tasks.POST("/:id/comments/read", taskHandler.MarkTaskCommentsRead)
+				tasks.DELETE("/:id/comments/:comment_id", taskHandler.DeleteTaskComment)
 				tasks.PUT("/:id", middleware.RequireRole("parent"), taskHandler.UpdateTask)
 				tasks.POST("/:id/direct-assign", middleware.RequireRole("parent"), taskHandler.DirectAssignTask)
 				tasks.POST("/:id/complete", middleware.RequireRole("child"), taskHandler.CompleteTask)
 				tasks.POST("/:id/approve", middleware.RequireRole("parent"), taskHandler.ApproveTask)
 				tasks.POST("/:id/reject", middleware.RequireRole("parent"), taskHandler.RejectTask)
+				tasks.PUT("/:id/checklist", middleware.RequireRole("parent"), taskHandler.ReplaceChecklist)
+				tasks.POST("/:id/checklist/:item_id/check", middleware.RequireRole("child"), taskHandler.CheckChecklistItem)
+				tasks.POST("/:id/checklist/:item_id/uncheck", middleware.RequireRole("child"), taskHandler.UncheckChecklistItem)
 				tasks.POST("/upload-image", taskHandler.UploadImage)
 			}
 
-			// 智能合约路由
+			// 重复任务路由
+			series := protected.Group("/task-series", middleware.RequireRole("parent"))
+			{
+				series.POST("", taskSeriesHandler.CreateTaskSeries)
+				series.GET("", taskSeriesHandler.GetTaskSeries)
+				series.GET("/:id", taskSeriesHandler.GetTaskSeriesByID)
+				series.PUT("/:id", taskSeriesHandler.UpdateTaskSeries)
+				series.POST("/:id/pause", taskSeriesHandler.PauseTaskSeries)
+				series.POST("/:id/resume", taskSeriesHandler.ResumeTaskSeries)
+				series.POST("/:id/skip", taskSeriesHandler.SkipOccurrence)
+				series.DELETE("/:id", taskSeriesHandler.EndTaskSeries)
+			}
+
+			// 任务模板路由，包含内置的入门模板
+			templates := protected.Group("/task-templates", middleware.RequireRole("parent"))
+			{
+				templates.POST("", taskTemplateHandler.CreateTaskTemplate)
+				templates.GET("", taskTemplateHandler.GetTaskTemplates)
+				templates.GET("/:id", taskTemplateHandler.GetTaskTemplateByID)
+				templates.PUT("/:id", taskTemplateHandler.UpdateTaskTemplate)
+				templates.DELETE("/:id", taskTemplateHandler.DeleteTaskTemplate)
+				templates.POST("/:id/assign", taskTemplateHandler.AssignTaskTemplate)
+			}
+
+			// 站内通知和通知设置
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", notificationHandler.GetNotifications)
+				notifications.POST("/read-all", notificationHandler.MarkAllNotificationsRead)
+				notifications.GET("/preferences", notificationHandler.GetNotificationSettings)
+				notifications.PUT("/preferences", notificationHandler.UpdateNotificationSettings)
+				notifications.POST("/:id/read", notificationHandler.MarkNotificationRead)
+			}
+
+			// 家庭webhook路由
+			webhooks := protected.Group("/webhooks", middleware.RequireRole("parent"))
+			{
+				webhooks.POST("", webhookHandler.CreateWebhook)
+				webhooks.GET("", webhookHandler.GetWebhooks)
+				webhooks.GET("/:id", webhookHandler.GetWebhookByID)
+				webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+				webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+				webhooks.POST("/:id/test", webhookHandler.TestWebhook)
+				webhooks.GET("/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+			}
+
+						// 智能合约路由
 			contracts := protected.Group("/contracts")
 			{
 				contracts.GET("/balance/:address", contractHandler.GetBalance)
@@ -118,17 +245,42 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config, contractManager *blockchain.Co
 				rewards.DELETE("/:id", middleware.RequireRole("parent"), rewardHandler.DeleteReward)
 			}
 
+			// 孩子的奖品愿望路由，家长接受后变成奖品
+			rewardProposals := protected.Group("/reward-proposals")
+			{
+				rewardProposals.POST("", middleware.RequireRole("child"), rewardProposalHandler.CreateRewardProposal)
+				rewardProposals.GET("/my", middleware.RequireRole("child"), rewardProposalHandler.GetMyRewardProposals)
+				rewardProposals.GET("/:id", rewardProposalHandler.GetRewardProposal)
+				rewardProposals.POST("/:id/accept", middleware.RequireRole("parent"), rewardProposalHandler.AcceptRewardProposal)
+				rewardProposals.POST("/:id/decline", middleware.RequireRole("parent"), rewardProposalHandler.DeclineRewardProposal)
+			}
+
 			// 兑换管理路由
 			exchanges := protected.Group("/exchanges")
 			{
 				exchanges.POST("", middleware.RequireRole("child"), exchangeHandler.ExchangeReward)
 				exchanges.GET("/my", middleware.RequireRole("child"), exchangeHandler.GetChildExchanges)
 				exchanges.GET("/:id", exchangeHandler.GetExchangeByID)
+				exchanges.POST("/:id/approve", middleware.RequireRole("parent"), exchangeHandler.ApproveExchange)
+				exchanges.POST("/:id/decline", middleware.RequireRole("parent"), exchangeHandler.DeclineExchange)
+				exchanges.POST("/:id/fulfill", middleware.RequireRole("parent"), exchangeHandler.FulfillExchange)
 				exchanges.PUT("/:id/status", middleware.RequireRole("parent"), exchangeHandler.UpdateExchangeStatus)
 			}
 
 			// 家庭兑换记录路由
 			protected.GET("/exchanges/family/:family_id", exchangeHandler.GetFamilyExchanges)
+
+			// 储蓄目标路由
+			savingsGoals := protected.Group("/savings-goals")
+			{
+				savingsGoals.POST("", middleware.RequireRole("child"), savingsHandler.CreateSavingsGoal)
+				savingsGoals.GET("/:id", savingsHandler.GetSavingsGoal)
+				savingsGoals.GET("/:id/progress", savingsHandler.GetSavingsProgress)
+				savingsGoals.POST("/:id/lock", middleware.RequireRole("child"), savingsHandler.LockSavings)
+				savingsGoals.POST("/:id/unlock", middleware.RequireRole("child"), savingsHandler.UnlockSavings)
+				savingsGoals.POST("/:id/contributions", middleware.RequireRole("parent"), savingsHandler.ContributeSavings)
+				savingsGoals.DELETE("/:id", savingsHandler.CancelSavingsGoal)
+			}
 		}
 
 		// 在 v1 路由组下添加健康检查路由
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 )
@@ -20,6 +21,13 @@ type Config struct {
 	RewardContractAddress string
 	Database              DatabaseConfig
 	Blockchain            BlockchainConfig
+	Outbox                OutboxConfig
+	Indexer               IndexerConfig
+	Auth                  AuthConfig
+	Scheduler             SchedulerConfig
+	Realtime              RealtimeConfig
+	Webhook               WebhookConfig
+	Notification          NotificationConfig
 }
 
 type DatabaseConfig struct {
@@ -35,9 +43,121 @@ type BlockchainConfig struct {
 	RewardTokenAddress    string
 	RewardRegistryAddress string
 	ChainID               int64
+	Confirmations         uint64 // 交易/事件所在区块之上（含该区块）需要的区块数，达到后才视为最终确认
+	Signer                SignerConfig
 	Client                *ethclient.Client
 }
 
+// 后端交易签名方式
+const (
+	SignerTypeKey      = "key"      // BLOCKCHAIN_PRIVATE_KEY_PARENT中的明文私钥，仅用于开发
+	SignerTypeKeystore = "keystore" // 加密的go-ethereum keystore文件
+	SignerTypeRemote   = "remote"   // Clef兼容的远程签名服务
+)
+
+// SignerConfig 后端交易签名器配置
+type SignerConfig struct {
+	Type             string
+	KeystorePath     string
+	KeystorePassword string
+	RemoteURL        string
+	Address          string // 远程签名器使用的账户地址
+}
+
+// OutboxConfig 链上副作用分发器配置
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	StuckAfter   time.Duration // 交易超过该时间仍未打包时提高gas价格重新发送
+}
+
+// IndexerConfig 链上事件索引器配置
+type IndexerConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BlockRange   uint64 // 每次Filter调用覆盖的最大区块数
+	StartBlock   uint64 // 首次索引的起始区块，通常为合约部署区块
+	ReorgWindow  uint64 // 保留最近多少个区块的哈希和事件撤销记录，用于处理链重组
+}
+
+// SchedulerConfig 任务调度器配置，调度器生成重复任务实例并处理过期任务
+type SchedulerConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+}
+
+// 实时推送的事件代理类型
+const (
+	BrokerTypeMemory = "memory" // 进程内代理，只推送给连接到同一个实例的客户端
+)
+
+// RealtimeConfig 实时推送配置
+type RealtimeConfig struct {
+	Broker            string
+	BufferSize        int           // 每个连接的事件缓冲区大小，客户端处理不过来时丢弃事件
+	HeartbeatInterval time.Duration // 空闲时发送心跳，避免代理服务器断开长连接
+}
+
+// WebhookConfig webhook投递队列配置
+type WebhookConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration // 单次请求的超时时间
+}
+
+// NotificationConfig 通知配置，邮件通过队列在后台发送
+type NotificationConfig struct {
+	LowStockThreshold int // 兑换后奖品库存降到这个数量时通知家长
+	SMTP              SMTPConfig
+	PollInterval      time.Duration
+	BatchSize         int
+	MaxAttempts       int
+	BaseBackoff       time.Duration
+	MaxBackoff        time.Duration
+}
+
+// SMTPConfig 发送通知邮件的SMTP服务器，没有配置Host时不发送邮件
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // 为空时不进行认证，例如本地的SMTP测试服务
+	Password string
+	From     string
+}
+
+// Configured 判断是否配置了SMTP服务器
+func (c SMTPConfig) Configured() bool {
+	return c.Host != "" && c.From != ""
+}
+
+// AuthConfig Sign-In with Ethereum登录配置
+type AuthConfig struct {
+	Domain    string        // 消息中的域名，必须与前端所在的域名一致
+	URI       string        // 消息中的URI
+	Statement string        // 展示给用户的声明
+	NonceTTL  time.Duration // nonce和登录消息的有效期
+	// AccessTokenTTL 访问令牌（JWT）的有效期，过期后用刷新令牌换取
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL 刷新令牌的有效期，每次刷新都会轮换出新的令牌
+	RefreshTokenTTL time.Duration
+	// AllowMockSignatures 允许前端手动输入地址时的模拟签名登录，仅用于开发
+	AllowMockSignatures bool
+	// InviteCodeTTL 家庭邀请码的有效期
+	InviteCodeTTL time.Duration
+	// InviteCodeSecret 家庭邀请码的签名密钥，与JWT密钥分开，轮换其中一个不会影响另一个
+	InviteCodeSecret string
+}
+
+// DefaultInviteCodeSecret 未配置INVITE_CODE_SECRET时使用的邀请码签名密钥，只能用于开发
+const DefaultInviteCodeSecret = "dev-invite-code-secret-change-in-production"
+
 func Load() *Config {
 	chainID, _ := strconv.ParseInt(getEnv("BLOCKCHAIN_CHAIN_ID", "1337"), 10, 64)
 
@@ -64,13 +184,113 @@ func Load() *Config {
 			RewardTokenAddress:    getEnv("TOKEN_CONTRACT_ADDRESS", ""),
 			RewardRegistryAddress: getEnv("REWARD_CONTRACT_ADDRESS", ""),
 			ChainID:               chainID,
+			Confirmations:         getEnvUint64("BLOCKCHAIN_CONFIRMATIONS", 3),
+			Signer: SignerConfig{
+				Type:             getEnv("SIGNER_TYPE", SignerTypeKey),
+				KeystorePath:     getEnv("SIGNER_KEYSTORE_PATH", ""),
+				KeystorePassword: getEnv("SIGNER_KEYSTORE_PASSWORD", ""),
+				RemoteURL:        getEnv("SIGNER_REMOTE_URL", ""),
+				Address:          getEnv("SIGNER_ADDRESS", ""),
+			},
+		},
+		Outbox: OutboxConfig{
+			PollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", 5*time.Second),
+			BatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 20),
+			MaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 8),
+			BaseBackoff:  getEnvDuration("OUTBOX_BASE_BACKOFF", 10*time.Second),
+			MaxBackoff:   getEnvDuration("OUTBOX_MAX_BACKOFF", 30*time.Minute),
+			StuckAfter:   getEnvDuration("OUTBOX_STUCK_AFTER", 10*time.Minute),
+		},
+		Indexer: IndexerConfig{
+			Enabled:      getEnv("INDEXER_ENABLED", "true") == "true",
+			PollInterval: getEnvDuration("INDEXER_POLL_INTERVAL", 15*time.Second),
+			BlockRange:   getEnvUint64("INDEXER_BLOCK_RANGE", 2000),
+			StartBlock:   getEnvUint64("INDEXER_START_BLOCK", 0),
+			ReorgWindow:  getEnvUint64("INDEXER_REORG_WINDOW", 128),
+		},
+		Auth: AuthConfig{
+			Domain:              getEnv("SIWE_DOMAIN", "localhost:5173"),
+			URI:                 getEnv("SIWE_URI", "http://localhost:5173"),
+			Statement:           getEnv("SIWE_STATEMENT", "Sign in to Family Task Chain. This request will not trigger a blockchain transaction or cost any gas fees."),
+			NonceTTL:            getEnvDuration("SIWE_NONCE_TTL", 5*time.Minute),
+			AccessTokenTTL:      getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:     getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+			AllowMockSignatures: getEnv("AUTH_ALLOW_MOCK_SIGNATURES", "false") == "true",
+			InviteCodeTTL:       getEnvDuration("INVITE_CODE_TTL", 72*time.Hour),
+			InviteCodeSecret:    getEnv("INVITE_CODE_SECRET", DefaultInviteCodeSecret),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:      getEnv("TASK_SCHEDULER_ENABLED", "true") == "true",
+			PollInterval: getEnvDuration("TASK_SCHEDULER_POLL_INTERVAL", time.Minute),
+		},
+		Realtime: RealtimeConfig{
+			Broker:            getEnv("REALTIME_BROKER", BrokerTypeMemory),
+			BufferSize:        getEnvInt("REALTIME_BUFFER_SIZE", 64),
+			HeartbeatInterval: getEnvDuration("REALTIME_HEARTBEAT_INTERVAL", 25*time.Second),
+		},
+		Webhook: WebhookConfig{
+			Enabled:      getEnv("WEBHOOK_ENABLED", "true") == "true",
+			PollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			BatchSize:    getEnvInt("WEBHOOK_BATCH_SIZE", 20),
+			MaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 6),
+			BaseBackoff:  getEnvDuration("WEBHOOK_BASE_BACKOFF", 30*time.Second),
+			MaxBackoff:   getEnvDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+			Timeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Notification: NotificationConfig{
+			LowStockThreshold: getEnvInt("NOTIFICATION_LOW_STOCK_THRESHOLD", 1),
+			SMTP: SMTPConfig{
+				Host:     getEnv("SMTP_HOST", ""),
+				Port:     getEnvInt("SMTP_PORT", 587),
+				Username: getEnv("SMTP_USERNAME", ""),
+				Password: getEnv("SMTP_PASSWORD", ""),
+				From:     getEnv("SMTP_FROM", ""),
+			},
+			PollInterval: getEnvDuration("EMAIL_POLL_INTERVAL", 10*time.Second),
+			BatchSize:    getEnvInt("EMAIL_BATCH_SIZE", 20),
+			MaxAttempts:  getEnvInt("EMAIL_MAX_ATTEMPTS", 5),
+			BaseBackoff:  getEnvDuration("EMAIL_BASE_BACKOFF", time.Minute),
+			MaxBackoff:   getEnvDuration("EMAIL_MAX_BACKOFF", time.Hour),
 		},
 	}
 }
 
+// SignerConfigured 判断是否配置了后端交易签名器
+func (c BlockchainConfig) SignerConfigured() bool {
+	switch c.Signer.Type {
+	case SignerTypeKeystore:
+		return c.Signer.KeystorePath != ""
+	case SignerTypeRemote:
+		return c.Signer.RemoteURL != "" && c.Signer.Address != ""
+	default:
+		return c.PrivateKey != ""
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvUint64(key string, defaultValue uint64) uint64 {
+	if value, err := strconv.ParseUint(os.Getenv(key), 10, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
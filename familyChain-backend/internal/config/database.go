@@ -1,6 +1,7 @@
 package config
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -33,11 +35,31 @@ func InitDatabase(cfg *Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// 旧数据库的任务状态约束不包含新增的状态，先删除再由自动迁移按模型重新创建
+	if err := upgradeTaskStatusCheck(db); err != nil {
+		return nil, fmt.Errorf("failed to upgrade task status constraint: %w", err)
+	}
+
 	// 自动迁移数据库表
 	if err := autoMigrate(db); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// 为引入家庭成员之前的数据补齐所有者成员和任务所属家庭
+	if err := backfillFamilyMembers(db); err != nil {
+		return nil, fmt.Errorf("failed to backfill family members: %w", err)
+	}
+
+	// 为引入任务历史之前的任务补建历史
+	if err := backfillTaskEvents(db); err != nil {
+		return nil, fmt.Errorf("failed to backfill task events: %w", err)
+	}
+
+	// 写入内置的入门任务模板
+	if err := seedTaskTemplates(db); err != nil {
+		return nil, fmt.Errorf("failed to seed task templates: %w", err)
+	}
+
 	return db, nil
 }
 
@@ -49,5 +71,107 @@ func autoMigrate(db *gorm.DB) error {
 		&models.Task{},
 		&models.Reward{},
 		&models.Exchange{},
+		&models.OutboxMessage{},
+		&models.OutboxTransaction{},
+		&models.ChainCursor{},
+		&models.IndexedBlock{},
+		&models.IndexedEvent{},
+		&models.AuthNonce{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.FamilyMember{},
+		&models.FamilyInvitation{},
+		&models.FamilyInviteCode{},
+		&models.TaskSeries{},
+		&models.TaskSeriesChild{},
+		&models.TaskSeriesSkip{},
+		&models.TaskTemplate{},
+		&models.TaskEvent{},
+		&models.TaskChecklistItem{},
+		&models.TaskComment{},
+		&models.TaskCommentRead{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.EmailMessage{},
+		&models.LedgerTransaction{},
+		&models.LedgerEntry{},
+		&models.SavingsGoal{},
+		&models.SavingsContribution{},
+		&models.RewardProposal{},
 	)
 }
+
+// backfillFamilyMembers 让每个家庭的创建者成为所有者成员，并把没有family_id的任务归属到创建者的家庭
+// 语句是幂等的，每次启动都可以执行
+func backfillFamilyMembers(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO family_members (family_id, wallet_address, role, created_at, updated_at)
+			SELECT f.id, f.parent_address, ?, f.created_at, f.created_at FROM families f
+			WHERE f.deleted_at IS NULL
+			  AND NOT EXISTS (
+			      SELECT 1 FROM family_members m
+			      WHERE m.family_id = f.id AND m.wallet_address = f.parent_address
+			  )`, models.FamilyRoleOwner).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			UPDATE tasks SET family_id = (
+			    SELECT f.id FROM families f WHERE f.parent_address = tasks.created_by AND f.deleted_at IS NULL
+			)
+			WHERE family_id IS NULL`).Error
+	})
+}
+
+// backfillTaskEvents 为还没有任何历史的任务按任务上的时间字段补建历史
+// 这些字段只保留了最近一次的值，补建的记录标记为backfill；语句是幂等的，每次启动都可以执行
+func backfillTaskEvents(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO task_events (task_id, action, source, actor, from_status, to_status, proof, reason, reward_amount, created_at)
+		SELECT id, 'created', @source, created_by, '', CASE WHEN assigned_child_id IS NULL THEN 'pending' ELSE 'in_progress' END, NULL, NULL, NULL, created_at
+		FROM tasks t WHERE NOT EXISTS (SELECT 1 FROM task_events e WHERE e.task_id = t.id)
+		UNION ALL
+		SELECT id, 'submitted', @source, '', 'in_progress', 'completed', completion_proof, NULL, NULL, submitted_at
+		FROM tasks t WHERE submitted_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM task_events e WHERE e.task_id = t.id)
+		UNION ALL
+		SELECT id, 'approved', @source, '', 'completed', 'approved', NULL, approval_reason, approved_reward_amount, approved_at
+		FROM tasks t WHERE approved_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM task_events e WHERE e.task_id = t.id)
+		UNION ALL
+		SELECT id, 'rejected', @source, '', 'completed', 'rejected', NULL, rejection_reason, NULL, rejected_at
+		FROM tasks t WHERE rejected_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM task_events e WHERE e.task_id = t.id)
+		UNION ALL
+		SELECT id, 'expired', @source, '', CASE WHEN assigned_child_id IS NULL THEN 'pending' ELSE 'in_progress' END, 'expired', NULL, expiry_reason, NULL, expired_at
+		FROM tasks t WHERE expired_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM task_events e WHERE e.task_id = t.id)`,
+		sql.Named("source", models.TaskEventSourceBackfill)).Error
+}
+
+// upgradeTaskStatusCheck 如果tasks表的状态检查约束还不允许expired，删除该约束
+// SQLite不能修改约束，删除时会重建tasks表并保留数据
+func upgradeTaskStatusCheck(db *gorm.DB) error {
+	const name = "chk_tasks_status"
+	if !db.Migrator().HasConstraint(&models.Task{}, name) {
+		return nil
+	}
+	var upgraded int64
+	if err := db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks' AND sql LIKE ?",
+		"%'"+models.TaskStatusExpired+"'%").Scan(&upgraded).Error; err != nil {
+		return err
+	}
+	if upgraded > 0 {
+		return nil
+	}
+	return db.Migrator().DropConstraint(&models.Task{}, name)
+}
+
+// seedTaskTemplates 按catalog_key写入内置模板，已存在的内置模板更新为最新的内容
+func seedTaskTemplates(db *gorm.DB) error {
+	templates := models.StarterTaskTemplates()
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "catalog_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"title", "description", "category", "reward_amount", "difficulty", "proof_type", "proof_instructions", "updated_at",
+		}),
+	}).Create(&templates).Error
+}
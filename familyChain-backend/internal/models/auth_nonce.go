@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AuthNonce 登录用的一次性nonce，绑定到请求它的钱包地址，过期或使用后作废
+type AuthNonce struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Nonce         string     `json:"nonce" gorm:"type:varchar(64);uniqueIndex;not null"`
+	WalletAddress string     `json:"wallet_address" gorm:"type:varchar(42);not null;index"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (AuthNonce) TableName() string {
+	return "auth_nonces"
+}
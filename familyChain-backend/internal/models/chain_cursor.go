@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// ChainCursor 记录事件索引器在某个合约上已处理到的区块
+type ChainCursor struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Contract  string    `json:"contract" gorm:"type:varchar(64);uniqueIndex;not null"` // 例如 task_registry
+	Address   string    `json:"address" gorm:"type:varchar(42);not null"`
+	LastBlock uint64    `json:"last_block" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ChainCursor) TableName() string {
+	return "chain_cursors"
+}
+
+// IndexedBlock 记录索引器处理过的最近区块的哈希，用于检测链重组
+type IndexedBlock struct {
+	Number     uint64    `json:"number" gorm:"primaryKey;autoIncrement:false"`
+	Hash       string    `json:"hash" gorm:"type:varchar(66);not null"`
+	ParentHash string    `json:"parent_hash" gorm:"type:varchar(66);not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (IndexedBlock) TableName() string {
+	return "indexed_blocks"
+}
+
+// EventEffectAction 表示事件对一行数据做的修改类型
+type EventEffectAction string
+
+const (
+	// EventEffectCreate 表示事件新建了一行，回滚时删除
+	EventEffectCreate EventEffectAction = "create"
+	// EventEffectUpdate 表示事件修改了一行，回滚时把仍是事件写入值的列恢复为旧值
+	EventEffectUpdate EventEffectAction = "update"
+)
+
+// EffectValueType 快照中一列的类型，回滚时据此把JSON中的值还原为数据库可以写入的类型
+type EffectValueType string
+
+const (
+	EffectValueNull   EffectValueType = "null" // 修改前后都是NULL
+	EffectValueInt    EffectValueType = "int"
+	EffectValueFloat  EffectValueType = "float"
+	EffectValueBool   EffectValueType = "bool"
+	EffectValueString EffectValueType = "string"
+	EffectValueTime   EffectValueType = "time"
+)
+
+// EffectColumn 事件修改的一列：修改前的值和事件写入后的值
+type EffectColumn struct {
+	Type     EffectValueType `json:"type"`
+	Previous interface{}     `json:"previous"`
+	Applied  interface{}     `json:"applied"`
+}
+
+// EventEffect 记录一条事件对数据库的一处修改
+type EventEffect struct {
+	Table   string                  `json:"table"`
+	RowID   uint                    `json:"row_id"`
+	Action  EventEffectAction       `json:"action"`
+	Columns map[string]EffectColumn `json:"columns,omitempty"`
+}
+
+// IndexedEvent 记录已写入数据库的合约事件及其修改，区块被重组掉时据此撤销
+type IndexedEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Contract    string    `json:"contract" gorm:"type:varchar(64);not null"`
+	Name        string    `json:"name" gorm:"type:varchar(64);not null"`
+	BlockNumber uint64    `json:"block_number" gorm:"not null;index"`
+	BlockHash   string    `json:"block_hash" gorm:"type:varchar(66);not null;uniqueIndex:idx_indexed_events_log"`
+	LogIndex    uint      `json:"log_index" gorm:"not null;uniqueIndex:idx_indexed_events_log"`
+	TxHash      string    `json:"tx_hash" gorm:"type:varchar(66);not null"`
+	Effects     string    `json:"effects" gorm:"type:text"` // EventEffect数组的JSON
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (IndexedEvent) TableName() string {
+	return "indexed_events"
+}
@@ -6,13 +6,21 @@ import "time"
 type ExchangeStatus string
 
 const (
-	// ExchangeStatusPending 表示待处理的兑换请求
+	// ExchangeStatusPending 表示等待家长审核的兑换请求，代币托管在奖品合约中
 	ExchangeStatusPending ExchangeStatus = "pending"
-	// ExchangeStatusCompleted 表示已完成的兑换
+	// ExchangeStatusApproved 表示家长已批准，托管的代币被销毁，等待交付奖品
+	ExchangeStatusApproved ExchangeStatus = "approved"
+	// ExchangeStatusDeclined 表示家长已拒绝，托管的代币退还给孩子，库存恢复
+	ExchangeStatusDeclined ExchangeStatus = "declined"
+	// ExchangeStatusFulfilled 表示奖品已交给孩子
+	ExchangeStatusFulfilled ExchangeStatus = "fulfilled"
+
+	// 以下是旧版本的状态，只在旧客户端调用 PUT /exchanges/:id/status 时使用
+	// ExchangeStatusCompleted 对应 fulfilled
 	ExchangeStatusCompleted ExchangeStatus = "completed"
-	// ExchangeStatusCancelled 表示已取消的兑换
+	// ExchangeStatusCancelled 对应 declined
 	ExchangeStatusCancelled ExchangeStatus = "cancelled"
-	// ExchangeStatusConfirmed 表示区块链交易已确认的兑换
+	// ExchangeStatusConfirmed 对应 approved
 	ExchangeStatusConfirmed ExchangeStatus = "confirmed"
 	// ExchangeStatusFailed 表示失败的兑换
 	ExchangeStatusFailed ExchangeStatus = "failed"
@@ -20,16 +28,21 @@ const (
 
 // Exchange 表示孩子兑换奖品的记录
 type Exchange struct {
-	ID            uint           `json:"id" gorm:"primaryKey"`
-	RewardID      uint           `json:"reward_id" gorm:"not null;index"`
-	ChildID       uint           `json:"child_id" gorm:"not null;index"`
-	TokenAmount   int            `json:"token_amount" gorm:"not null"`
-	Status        ExchangeStatus `json:"status" gorm:"type:varchar(20);default:'pending';index"`
-	ExchangeDate  time.Time      `json:"exchange_date" gorm:"autoCreateTime"`
-	CompletedDate *time.Time     `json:"completed_date"`
-	Notes         string         `json:"notes" gorm:"type:text"`
-	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	RewardID           uint           `json:"reward_id" gorm:"not null;index"`
+	ChildID            uint           `json:"child_id" gorm:"not null;index"`
+	TokenAmount        int            `json:"token_amount" gorm:"not null"`
+	Status             ExchangeStatus `json:"status" gorm:"type:varchar(20);default:'pending';index"`
+	ExchangeDate       time.Time      `json:"exchange_date" gorm:"autoCreateTime"`
+	CompletedDate      *time.Time     `json:"completed_date"`
+	Notes              string         `json:"notes" gorm:"type:text"`
+	ContractExchangeID *uint64        `json:"contract_exchange_id,omitempty" gorm:"uniqueIndex:idx_exchanges_contract_exchange"`
+	TxHash             *string        `json:"tx_hash,omitempty"`    // 托管代币的链上交易
+	DecidedBy          *string        `json:"decided_by,omitempty"` // 批准或拒绝兑换的家长钱包地址
+	DecidedAt          *time.Time     `json:"decided_at,omitempty"`
+	SettlementTxHash   *string        `json:"settlement_tx_hash,omitempty"` // 销毁或退还托管代币的链上交易
+	CreatedAt          time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 
 	// 关联字段，不在数据库中
 	RewardName  string `json:"reward_name,omitempty" gorm:"-"`
@@ -44,12 +57,20 @@ func (Exchange) TableName() string {
 
 // ExchangeCreateRequest 创建兑换请求的参数
 type ExchangeCreateRequest struct {
-	RewardID    uint   `json:"reward_id" validate:"required"`
-	Notes       string `json:"notes"`
-	TokenBurned bool   `json:"token_burned"` // 标记代币是否已在前端销毁
+	RewardID uint   `json:"reward_id" validate:"required"`
+	Notes    string `json:"notes"`
+	// 孩子已经用自己的钱包在链上兑换时传入链上兑换ID，后端在链上核对后关联，不再重复托管
+	ContractExchangeID *uint64 `json:"contract_exchange_id"`
+	// 已废弃：后端不再信任这个标记，没有链上兑换ID的请求按普通兑换处理
+	TokenBurned bool `json:"token_burned"`
+}
+
+// ExchangeDecisionRequest 家长批准、拒绝或交付兑换时的参数
+type ExchangeDecisionRequest struct {
+	Notes string `json:"notes"`
 }
 
-// ExchangeUpdateRequest 更新兑换状态的参数
+// ExchangeUpdateRequest 更新兑换状态的参数，旧接口 PUT /exchanges/:id/status 使用
 type ExchangeUpdateRequest struct {
 	Status ExchangeStatus `json:"status" validate:"required"`
 	Notes  string         `json:"notes"`
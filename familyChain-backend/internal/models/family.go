@@ -6,6 +6,8 @@ import (
 	"gorm.io/gorm"
 )
 
+// Family 表示一个家庭，ParentAddress是创建家庭的家长，也是孩子关联家庭用的键
+// 家庭的所有监护人（包括创建者）及其角色记录在family_members表中
 type Family struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
 	Name          string         `json:"name" gorm:"not null"`
@@ -14,9 +16,14 @@ type Family struct {
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// 迟交规则：任务过期后是否还可以提交，以及迟交时奖励减少的百分比（0-100）
+	AllowLateSubmissions bool `json:"allow_late_submissions" gorm:"not null;default:false"`
+	LatePenaltyPercent   int  `json:"late_penalty_percent" gorm:"not null;default:0"`
+
 	// 关联关系
-	Parent   *User   `json:"parent,omitempty" gorm:"foreignKey:ParentAddress;references:WalletAddress"`
-	Children []Child `json:"children,omitempty" gorm:"foreignKey:ParentAddress;references:ParentAddress"`
+	Parent   *User          `json:"parent,omitempty" gorm:"foreignKey:ParentAddress;references:WalletAddress"`
+	Children []Child        `json:"children,omitempty" gorm:"foreignKey:ParentAddress;references:ParentAddress"`
+	Members  []FamilyMember `json:"members,omitempty" gorm:"foreignKey:FamilyID;references:ID"`
 }
 
 func (Family) TableName() string {
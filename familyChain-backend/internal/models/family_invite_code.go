@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// InviteRoleChild 邀请码的角色为child时，兑换者以孩子身份加入家庭
+const InviteRoleChild = "child"
+
+// IsValidInviteCodeRole 判断是否为邀请码可以授予的角色
+// 邀请码不能授予owner，所有者只能通过定向邀请或修改角色产生
+func IsValidInviteCodeRole(role string) bool {
+	return role == InviteRoleChild || role == FamilyRoleCoParent || role == FamilyRoleViewer
+}
+
+// FamilyInviteCode 家长生成的一次性邀请码（或包含邀请码的链接）
+// 任何钱包登录时都可以兑换，兑换后以指定角色加入家庭；数据库只保存邀请码的哈希
+type FamilyInviteCode struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	FamilyID   uint       `json:"family_id" gorm:"not null;index"`
+	CodeHash   string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Role       string     `json:"role" gorm:"type:varchar(20);not null;check:role IN ('child', 'co_parent', 'viewer')"`
+	ChildName  *string    `json:"child_name,omitempty"` // 邀请孩子时预先填写的资料
+	ChildAge   *int       `json:"child_age,omitempty"`
+	CreatedBy  string     `json:"created_by" gorm:"type:varchar(42);not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RedeemedBy *string    `json:"redeemed_by,omitempty" gorm:"type:varchar(42)"`
+	RedeemedAt *time.Time `json:"redeemed_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// 关联关系
+	Family *Family `json:"family,omitempty" gorm:"foreignKey:FamilyID;references:ID"`
+}
+
+func (FamilyInviteCode) TableName() string {
+	return "family_invite_codes"
+}
+
+// Usable 判断邀请码在now时刻是否仍可兑换
+func (c *FamilyInviteCode) Usable(now time.Time) bool {
+	return c.RedeemedAt == nil && c.RevokedAt == nil && now.Before(c.ExpiresAt)
+}
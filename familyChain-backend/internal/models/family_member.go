@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// 家庭成员角色
+const (
+	// FamilyRoleOwner 家庭所有者，可以管理成员和邀请
+	FamilyRoleOwner = "owner"
+	// FamilyRoleCoParent 共同家长，可以管理孩子、任务、奖品和兑换
+	FamilyRoleCoParent = "co_parent"
+	// FamilyRoleViewer 只读成员（例如祖父母），只能查看家庭数据
+	FamilyRoleViewer = "viewer"
+)
+
+// IsValidFamilyRole 判断是否为有效的家庭成员角色
+func IsValidFamilyRole(role string) bool {
+	return role == FamilyRoleOwner || role == FamilyRoleCoParent || role == FamilyRoleViewer
+}
+
+// CanManageFamily 判断该角色是否可以修改家庭数据（孩子、任务、奖品、兑换）
+func CanManageFamily(role string) bool {
+	return role == FamilyRoleOwner || role == FamilyRoleCoParent
+}
+
+// FamilyMember 表示家庭中的一位监护人及其角色
+// 孩子不是家庭成员，仍然通过children.parent_address关联到家庭
+type FamilyMember struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	FamilyID      uint      `json:"family_id" gorm:"not null;uniqueIndex:idx_family_members_family_wallet"`
+	WalletAddress string    `json:"wallet_address" gorm:"type:varchar(42);not null;uniqueIndex:idx_family_members_family_wallet;index"`
+	Role          string    `json:"role" gorm:"type:varchar(20);not null;check:role IN ('owner', 'co_parent', 'viewer')"`
+	InvitedBy     string    `json:"invited_by,omitempty" gorm:"type:varchar(42)"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// 关联关系
+	User *User `json:"user,omitempty" gorm:"foreignKey:WalletAddress;references:WalletAddress"`
+}
+
+func (FamilyMember) TableName() string {
+	return "family_members"
+}
+
+// 家庭邀请状态
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusDeclined = "declined"
+	InvitationStatusRevoked  = "revoked"
+)
+
+// FamilyInvitation 邀请某个钱包以指定角色加入家庭，被邀请人登录后接受或拒绝
+type FamilyInvitation struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	FamilyID       uint       `json:"family_id" gorm:"not null;index"`
+	InviteeAddress string     `json:"invitee_address" gorm:"type:varchar(42);not null;index"`
+	Role           string     `json:"role" gorm:"type:varchar(20);not null;check:role IN ('owner', 'co_parent', 'viewer')"`
+	InvitedBy      string     `json:"invited_by" gorm:"type:varchar(42);not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null"`
+	RespondedAt    *time.Time `json:"responded_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// 关联关系
+	Family *Family `json:"family,omitempty" gorm:"foreignKey:FamilyID;references:ID"`
+}
+
+func (FamilyInvitation) TableName() string {
+	return "family_invitations"
+}
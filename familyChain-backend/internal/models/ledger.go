@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+// LedgerKind 表示一笔代币流水的类型，由RewardToken的Transfer事件的双方地址决定
+type LedgerKind string
+
+const (
+	// LedgerKindMint 铸币，从零地址转出
+	LedgerKindMint LedgerKind = "mint"
+	// LedgerKindBurn 销毁，转入零地址
+	LedgerKindBurn LedgerKind = "burn"
+	// LedgerKindTransfer 钱包之间的普通转账
+	LedgerKindTransfer LedgerKind = "transfer"
+	// LedgerKindEscrow 兑换时转入奖品合约托管
+	LedgerKindEscrow LedgerKind = "escrow"
+	// LedgerKindRefund 兑换被拒绝后奖品合约退还代币
+	LedgerKindRefund LedgerKind = "refund"
+)
+
+// LedgerAccountType 表示账本账户的类型，账户本身是小写的钱包或合约地址
+type LedgerAccountType string
+
+const (
+	// LedgerAccountChild 登记过的孩子钱包
+	LedgerAccountChild LedgerAccountType = "child"
+	// LedgerAccountEscrow 奖品合约，持有托管中的代币
+	LedgerAccountEscrow LedgerAccountType = "escrow"
+	// LedgerAccountIssuance 零地址，铸币从这里转出、销毁转回这里，余额是流通量的相反数
+	LedgerAccountIssuance LedgerAccountType = "issuance"
+	// LedgerAccountExternal 其他钱包，例如家长的钱包
+	LedgerAccountExternal LedgerAccountType = "external"
+)
+
+// LedgerTransaction 一笔代币流水，对应一条链上的Transfer事件
+// 每笔流水有两条分录，一借一贷，所有账户的余额之和始终为0
+type LedgerTransaction struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	Kind        LedgerKind    `json:"kind" gorm:"type:varchar(20);not null;index"`
+	Amount      string        `json:"amount" gorm:"type:varchar(78);not null"` // 以wei为单位的十进制整数，超出了int64的范围
+	TxHash      string        `json:"tx_hash" gorm:"type:varchar(66);not null;uniqueIndex:idx_ledger_transactions_log"`
+	LogIndex    uint          `json:"log_index" gorm:"not null;uniqueIndex:idx_ledger_transactions_log"`
+	BlockNumber uint64        `json:"block_number" gorm:"not null;index"`
+	OccurredAt  time.Time     `json:"occurred_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Entries     []LedgerEntry `json:"entries,omitempty" gorm:"foreignKey:TransactionID"`
+}
+
+func (LedgerTransaction) TableName() string {
+	return "ledger_transactions"
+}
+
+// LedgerEntry 一条分录，记录一个账户在一笔流水中的变化和变化后的余额
+type LedgerEntry struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	TransactionID uint              `json:"transaction_id" gorm:"not null;index"`
+	Account       string            `json:"account" gorm:"type:varchar(42);not null;index"`
+	AccountType   LedgerAccountType `json:"account_type" gorm:"type:varchar(20);not null"`
+	ChildID       *uint             `json:"child_id,omitempty" gorm:"index"`
+	Amount        string            `json:"amount" gorm:"type:varchar(79);not null"`  // 带符号的wei，转出为负，转入为正
+	Balance       string            `json:"balance" gorm:"type:varchar(79);not null"` // 记账后账户的余额（wei）
+	CreatedAt     time.Time         `json:"created_at"`
+
+	// 关联字段，不在数据库中
+	Kind   LedgerKind `json:"kind,omitempty" gorm:"-"`
+	TxHash string     `json:"tx_hash,omitempty" gorm:"-"`
+}
+
+func (LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
+
+// LedgerReconciliation 一个孩子的账本余额与链上 RewardToken.balanceOf 的核对结果
+type LedgerReconciliation struct {
+	ChildID       uint   `json:"child_id"`
+	ChildName     string `json:"child_name"`
+	WalletAddress string `json:"wallet_address"`
+	LedgerBalance string `json:"ledger_balance"`
+	ChainBalance  string `json:"chain_balance"`
+	Difference    string `json:"difference"` // 链上余额减去账本余额
+	Matched       bool   `json:"matched"`
+}
+
+// LedgerStatement 孩子的账本余额和最近的分录
+type LedgerStatement struct {
+	ChildID       uint           `json:"child_id"`
+	WalletAddress string         `json:"wallet_address"`
+	Balance       string         `json:"balance"` // wei
+	Minted        string         `json:"minted"`  // 累计收到的铸币（wei）
+	Entries       []*LedgerEntry `json:"entries"`
+}
+
+// LedgerReport 一个家庭的账本核对报告
+type LedgerReport struct {
+	FamilyID uint    `json:"family_id"`
+	Block    *uint64 `json:"block"` // 账本已同步到的区块，尚未同步时为nil
+	// 链上余额按最新区块而不是Block读取：账本尚未同步，或者节点不能读取历史状态
+	// 这时索引器还没有同步的转账也会显示为差异
+	ChainAtLatest bool                   `json:"chain_at_latest"`
+	Mismatches    int                    `json:"mismatches"`
+	Children      []LedgerReconciliation `json:"children"`
+}
@@ -0,0 +1,113 @@
+package models
+
+import "time"
+
+// NotificationType 通知类型，用户可以按类型设置接收的渠道
+type NotificationType string
+
+const (
+	// NotificationTaskSubmitted 孩子提交了任务，通知可以管理家庭的家长
+	NotificationTaskSubmitted NotificationType = "task_submitted"
+	// NotificationTaskApproved 任务被批准，通知孩子
+	NotificationTaskApproved NotificationType = "task_approved"
+	// NotificationTaskRejected 任务被拒绝或退回重做，通知孩子
+	NotificationTaskRejected NotificationType = "task_rejected"
+	// NotificationExchangeRequested 孩子申请兑换奖品，通知可以管理家庭的家长
+	NotificationExchangeRequested NotificationType = "exchange_requested"
+	// NotificationExchangeDecided 兑换被批准或拒绝，通知孩子
+	NotificationExchangeDecided NotificationType = "exchange_decided"
+	// NotificationRewardLowStock 奖品库存不足，通知可以管理家庭的家长
+	NotificationRewardLowStock NotificationType = "reward_low_stock"
+	// NotificationRewardProposed 孩子提出了奖品愿望，通知可以管理家庭的家长
+	NotificationRewardProposed NotificationType = "reward_proposed"
+	// NotificationProposalDecided 愿望被接受或拒绝，通知孩子
+	NotificationProposalDecided NotificationType = "reward_proposal_decided"
+)
+
+// NotificationTypes 所有通知类型
+var NotificationTypes = []NotificationType{
+	NotificationTaskSubmitted,
+	NotificationTaskApproved,
+	NotificationTaskRejected,
+	NotificationExchangeRequested,
+	NotificationExchangeDecided,
+	NotificationRewardLowStock,
+	NotificationRewardProposed,
+	NotificationProposalDecided,
+}
+
+// IsValidNotificationType 判断是否为有效的通知类型
+func IsValidNotificationType(name string) bool {
+	for _, notificationType := range NotificationTypes {
+		if string(notificationType) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification 站内通知
+type Notification struct {
+	ID            uint             `json:"id" gorm:"primaryKey"`
+	WalletAddress string           `json:"wallet_address" gorm:"type:varchar(42);not null;index"`
+	FamilyID      uint             `json:"family_id" gorm:"not null"`
+	Type          NotificationType `json:"type" gorm:"type:varchar(40);not null"`
+	Title         string           `json:"title" gorm:"not null"`
+	Body          string           `json:"body" gorm:"type:text"`
+	TaskID        *uint            `json:"task_id,omitempty"`
+	ExchangeID    *uint            `json:"exchange_id,omitempty"`
+	RewardID      *uint            `json:"reward_id,omitempty"`
+	ProposalID    *uint            `json:"proposal_id,omitempty"`
+	ReadAt        *time.Time       `json:"read_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference 用户对一种通知的渠道设置，没有记录时所有渠道都开启
+type NotificationPreference struct {
+	ID            uint             `json:"-" gorm:"primaryKey"`
+	WalletAddress string           `json:"-" gorm:"type:varchar(42);not null;uniqueIndex:idx_notification_preferences_wallet_type"`
+	Type          NotificationType `json:"type" gorm:"type:varchar(40);not null;uniqueIndex:idx_notification_preferences_wallet_type"`
+	InApp         bool             `json:"in_app" gorm:"not null"`
+	Email         bool             `json:"email" gorm:"not null"`
+	UpdatedAt     time.Time        `json:"-"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// EmailStatus 通知邮件的发送状态
+type EmailStatus string
+
+const (
+	// EmailPending 等待发送（或等待下一次重试）
+	EmailPending EmailStatus = "pending"
+	// EmailSent SMTP服务器已接收
+	EmailSent EmailStatus = "sent"
+	// EmailFailed 重试次数耗尽
+	EmailFailed EmailStatus = "failed"
+)
+
+// EmailMessage 通知邮件发送队列
+type EmailMessage struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	NotificationID *uint       `json:"notification_id,omitempty" gorm:"index"`
+	To             string      `json:"to" gorm:"column:recipient;not null"`
+	Subject        string      `json:"subject" gorm:"not null"`
+	Body           string      `json:"body" gorm:"type:text"`
+	Status         EmailStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts       int         `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time   `json:"next_attempt_at" gorm:"index"`
+	LastError      *string     `json:"last_error,omitempty" gorm:"type:text"`
+	SentAt         *time.Time  `json:"sent_at,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+func (EmailMessage) TableName() string {
+	return "email_messages"
+}
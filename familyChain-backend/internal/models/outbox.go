@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// OutboxStatus 表示链上副作用消息的处理状态
+type OutboxStatus string
+
+const (
+	// OutboxStatusPending 表示等待发送（或等待下一次重试）
+	OutboxStatusPending OutboxStatus = "pending"
+	// OutboxStatusSent 表示交易已提交，等待打包并达到确认数
+	OutboxStatusSent OutboxStatus = "sent"
+	// OutboxStatusConfirmed 表示交易已上链、执行成功并达到确认数
+	OutboxStatusConfirmed OutboxStatus = "confirmed"
+	// OutboxStatusFailed 表示重试次数耗尽，需要人工处理
+	OutboxStatusFailed OutboxStatus = "failed"
+	// OutboxStatusCancelled 表示消息不再需要发送，例如托管失败后没有代币可以退还
+	OutboxStatusCancelled OutboxStatus = "cancelled"
+)
+
+// OutboxKind 表示链上副作用的类型
+type OutboxKind string
+
+const (
+	// OutboxKindMintReward 任务批准后给孩子铸造奖励代币
+	OutboxKindMintReward OutboxKind = "mint_reward"
+	// OutboxKindMintContribution 家长为孩子的储蓄目标配捐时给孩子铸造代币
+	OutboxKindMintContribution OutboxKind = "mint_contribution"
+	// OutboxKindEscrowExchange 孩子通过API兑换奖品时，代孩子在链上兑换并托管代币
+	OutboxKindEscrowExchange OutboxKind = "escrow_exchange"
+	// OutboxKindFulfillExchange 家长批准兑换后销毁托管的代币
+	OutboxKindFulfillExchange OutboxKind = "fulfill_exchange"
+	// OutboxKindRefundExchange 家长拒绝兑换后把托管的代币退还给孩子
+	OutboxKindRefundExchange OutboxKind = "refund_exchange"
+	// OutboxKindBurnExchange 家长批准没有在链上托管的兑换后，从孩子的钱包销毁代币
+	OutboxKindBurnExchange OutboxKind = "burn_exchange"
+)
+
+// OutboxMessage 表示一条需要在数据库事务提交后发送到链上的操作
+// 它与业务数据在同一个事务中写入，由后台分发器负责发送、重试并记录收据
+type OutboxMessage struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	Kind          OutboxKind   `json:"kind" gorm:"type:varchar(32);not null;index"`
+	TaskID        *uint        `json:"task_id,omitempty" gorm:"index"`
+	ExchangeID    *uint        `json:"exchange_id,omitempty" gorm:"index"`
+	Recipient     string       `json:"recipient" gorm:"not null"`
+	Amount        string       `json:"amount" gorm:"not null"` // 以wei为单位的十进制字符串
+	Status        OutboxStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts      int          `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"index"`
+	LastError     *string      `json:"last_error,omitempty" gorm:"type:text"`
+	TxHash        *string      `json:"tx_hash,omitempty" gorm:"index"`
+	Nonce         *uint64      `json:"nonce,omitempty"` // 签名时使用的nonce，在广播之前记录
+	SentAt        *time.Time   `json:"sent_at,omitempty"`
+	BlockNumber   *uint64      `json:"block_number,omitempty"`
+	BlockHash     *string      `json:"block_hash,omitempty"`
+	GasUsed       *uint64      `json:"gas_used,omitempty"`
+	ReceiptStatus *uint64      `json:"receipt_status,omitempty"`
+	ConfirmedAt   *time.Time   `json:"confirmed_at,omitempty"`
+	LockedUntil   *time.Time   `json:"-" gorm:"index"` // 分发器领取消息的租约，到期前其他分发器不会处理
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// OutboxTransaction 消息广播过的一笔交易，替换卡住的交易时同一个nonce会有多笔
+// 任何一笔都可能被打包，因此在重新发送之前要检查所有记录
+type OutboxTransaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	MessageID uint      `json:"message_id" gorm:"not null;index"`
+	TxHash    string    `json:"tx_hash" gorm:"not null;uniqueIndex"`
+	Nonce     uint64    `json:"nonce" gorm:"not null"`
+	RawTx     string    `json:"-" gorm:"type:text;not null"` // 签名后的交易（十六进制），用于替换已经被节点丢弃的交易
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (OutboxTransaction) TableName() string {
+	return "outbox_transactions"
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// RewardProposalStatus 表示孩子提出的奖品愿望的状态
+type RewardProposalStatus string
+
+const (
+	// RewardProposalPending 等待家长处理
+	RewardProposalPending RewardProposalStatus = "pending"
+	// RewardProposalAccepted 家长定了价格，愿望变成了家庭的奖品
+	RewardProposalAccepted RewardProposalStatus = "accepted"
+	// RewardProposalDeclined 家长拒绝了愿望，decision_note 中说明原因
+	RewardProposalDeclined RewardProposalStatus = "declined"
+)
+
+// RewardProposal 孩子希望加入奖品列表的愿望
+type RewardProposal struct {
+	ID           uint                 `json:"id" gorm:"primaryKey"`
+	FamilyID     uint                 `json:"family_id" gorm:"not null;index"`
+	ChildID      uint                 `json:"child_id" gorm:"not null;index"`
+	Name         string               `json:"name" gorm:"not null;size:255"`
+	Description  string               `json:"description" gorm:"type:text"`
+	ImageURL     string               `json:"image_url" gorm:"type:text"`
+	Status       RewardProposalStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	RewardID     *uint                `json:"reward_id,omitempty"` // 接受后创建的奖品
+	DecidedBy    *string              `json:"decided_by,omitempty"`
+	DecidedAt    *time.Time           `json:"decided_at,omitempty"`
+	DecisionNote string               `json:"decision_note,omitempty" gorm:"type:text"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+
+	// 关联
+	Child  *Child  `json:"child,omitempty" gorm:"foreignKey:ChildID"`
+	Reward *Reward `json:"reward,omitempty" gorm:"foreignKey:RewardID"`
+}
+
+func (RewardProposal) TableName() string {
+	return "reward_proposals"
+}
+
+// RewardProposalCreateRequest 孩子提出愿望的请求
+type RewardProposalCreateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+}
+
+// RewardProposalAcceptRequest 家长接受愿望的请求，名称、描述和图片为空时沿用孩子填写的
+type RewardProposalAcceptRequest struct {
+	TokenPrice       int     `json:"token_price" binding:"required,min=1"`
+	Stock            *int    `json:"stock" binding:"omitempty,min=0"`
+	Name             *string `json:"name"`
+	Description      *string `json:"description"`
+	ImageURL         *string `json:"image_url"`
+	ContractRewardID *uint   `json:"contract_reward_id"`
+	Note             string  `json:"note"`
+}
+
+// RewardProposalDeclineRequest 家长拒绝愿望的请求
+type RewardProposalDeclineRequest struct {
+	Note string `json:"note" binding:"required"`
+}
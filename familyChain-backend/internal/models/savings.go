@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// SavingsGoalStatus 表示储蓄目标的状态
+type SavingsGoalStatus string
+
+const (
+	// SavingsGoalActive 正在储蓄
+	SavingsGoalActive SavingsGoalStatus = "active"
+	// SavingsGoalRedeemed 孩子已经兑换了目标奖品，锁定的代币随兑换一起释放
+	SavingsGoalRedeemed SavingsGoalStatus = "redeemed"
+	// SavingsGoalCancelled 目标被取消，锁定的代币回到可用余额
+	SavingsGoalCancelled SavingsGoalStatus = "cancelled"
+)
+
+// SavingsGoal 孩子为一个奖品设置的储蓄目标
+// 锁定的代币仍在孩子的钱包中，只是不能用来兑换其他奖品；数量都是整数个代币，与奖品价格一致
+type SavingsGoal struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	ChildID       uint              `json:"child_id" gorm:"not null;index"`
+	RewardID      uint              `json:"reward_id" gorm:"not null;index"`
+	Status        SavingsGoalStatus `json:"status" gorm:"type:varchar(20);not null;default:'active';index"`
+	LockedAmount  int               `json:"locked_amount" gorm:"not null;default:0"`  // 孩子自己锁定的代币
+	MatchedAmount int               `json:"matched_amount" gorm:"not null;default:0"` // 家长配捐的代币，铸造给孩子后锁定在目标中
+	ExchangeID    *uint             `json:"exchange_id,omitempty"`                    // 兑换目标奖品的记录
+	ClosedAt      *time.Time        `json:"closed_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+
+	// 关联
+	Reward        *Reward               `json:"reward,omitempty" gorm:"foreignKey:RewardID"`
+	Contributions []SavingsContribution `json:"contributions,omitempty" gorm:"foreignKey:GoalID"`
+}
+
+func (SavingsGoal) TableName() string {
+	return "savings_goals"
+}
+
+// Reserved 锁定在目标中的代币总数，包括家长的配捐
+func (g *SavingsGoal) Reserved() int {
+	return g.LockedAmount + g.MatchedAmount
+}
+
+// SavingsContribution 家长为储蓄目标配捐的一笔代币
+type SavingsContribution struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	GoalID          uint      `json:"goal_id" gorm:"not null;index"`
+	ContributedBy   string    `json:"contributed_by" gorm:"not null"` // 家长钱包地址
+	Amount          int       `json:"amount" gorm:"not null"`
+	Notes           string    `json:"notes,omitempty" gorm:"type:text"`
+	OutboxMessageID *uint     `json:"outbox_message_id,omitempty"` // 铸造配捐代币的链上消息，孩子没有钱包时为空
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (SavingsContribution) TableName() string {
+	return "savings_contributions"
+}
+
+// SavingsProgress 储蓄目标的进度，代币数量都是以wei为单位的十进制字符串
+type SavingsProgress struct {
+	Goal      *SavingsGoal `json:"goal"`
+	Target    string       `json:"target"`    // 奖品价格
+	Reserved  string       `json:"reserved"`  // 锁定在这个目标中的代币
+	Available string       `json:"available"` // 没有锁定、也没有托管中的余额，计入每一个目标
+	Saved     string       `json:"saved"`     // 锁定的加上可用的，不超过奖品价格
+	Remaining string       `json:"remaining"`
+	Percent   int          `json:"percent"`
+	// EarningRate 最近RateWindowDays天里平均每天铸造给孩子的代币
+	EarningRate    string `json:"earning_rate"`
+	RateWindowDays int    `json:"rate_window_days"`
+	// EstimatedCompletion 按最近的收入速度攒够的日期，已经攒够时是现在，最近没有收入时为nil
+	EstimatedCompletion *time.Time `json:"estimated_completion"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// 会话被撤销的原因
+const (
+	SessionRevokedLogout    = "logout"
+	SessionRevokedLogoutAll = "logout_all"
+	SessionRevokedReuse     = "refresh_token_reuse"
+)
+
+// Session 表示一次登录产生的会话，刷新令牌轮换时保持同一个会话
+// 撤销会话后，该会话签发的访问令牌和刷新令牌全部失效
+type Session struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	UserAgent     string     `json:"user_agent" gorm:"type:varchar(255)"`
+	IPAddress     string     `json:"ip_address" gorm:"type:varchar(64)"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+	RevokedReason string     `json:"revoked_reason,omitempty" gorm:"type:varchar(32)"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// RefreshToken 会话中签发的刷新令牌，只保存令牌的SHA-256哈希
+// 每个刷新令牌只能使用一次，使用后由ReplacedByID指向轮换出的新令牌
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	SessionID    uint       `json:"session_id" gorm:"not null;index"`
+	TokenHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	ReplacedByID *uint      `json:"replaced_by_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -6,30 +6,56 @@ import (
 	"gorm.io/gorm"
 )
 
+// TaskStatusExpired 超过截止时间还没有提交的任务
+const TaskStatusExpired = "expired"
+
+// 任务完成凭证的类型
+const (
+	ProofTypeNone  = "none"  // 不需要凭证
+	ProofTypeText  = "text"  // 需要文字说明
+	ProofTypePhoto = "photo" // 需要上传照片
+)
+
 type Task struct {
-	ID              uint           `json:"id" gorm:"primaryKey"`
-	Title           string         `json:"title" gorm:"not null"`
-	Description     string         `json:"description" gorm:"not null"`
-	RewardAmount    string         `json:"reward_amount" gorm:"not null"`
-	Difficulty      string         `json:"difficulty" gorm:"not null;check:difficulty IN ('easy', 'medium', 'hard')"`
-	Status          string         `json:"status" gorm:"not null;default:'pending';check:status IN ('pending', 'in_progress', 'completed', 'approved', 'rejected')"`
-	ImageUrl        *string        `json:"image_url,omitempty"`
-	AssignedChildID *uint          `json:"assigned_child_id,omitempty"`
-	CreatedBy       string         `json:"created_by" gorm:"not null"`
-	ContractTaskID  *uint64        `json:"contract_task_id,omitempty" gorm:"index"`
-	DueDate         *time.Time     `json:"due_date,omitempty"`
-	CompletionProof *string        `json:"completion_proof,omitempty" gorm:"type:text"`
-	SubmittedAt     *time.Time     `json:"submitted_at,omitempty"`
-	ApprovedAt      *time.Time     `json:"approved_at,omitempty"`
-	RejectedAt      *time.Time     `json:"rejected_at,omitempty"`
-	RejectionReason *string        `json:"rejection_reason,omitempty"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                   uint           `json:"id" gorm:"primaryKey"`
+	Title                string         `json:"title" gorm:"not null"`
+	Description          string         `json:"description" gorm:"not null"`
+	RewardAmount         string         `json:"reward_amount" gorm:"not null"`
+	Difficulty           string         `json:"difficulty" gorm:"not null;check:difficulty IN ('easy', 'medium', 'hard')"`
+	Status               string         `json:"status" gorm:"not null;default:'pending';check:status IN ('pending', 'in_progress', 'completed', 'approved', 'rejected', 'expired')"`
+	ImageUrl             *string        `json:"image_url,omitempty"`
+	ProofType            string         `json:"proof_type" gorm:"type:varchar(10);not null;default:'text'"`
+	ProofInstructions    *string        `json:"proof_instructions,omitempty" gorm:"type:text"` // 告诉孩子需要提交什么样的凭证
+	AssignedChildID      *uint          `json:"assigned_child_id,omitempty" gorm:"uniqueIndex:idx_tasks_series_occurrence"`
+	CreatedBy            string         `json:"created_by" gorm:"not null"`
+	FamilyID             *uint          `json:"family_id,omitempty" gorm:"index"`
+	SeriesID             *uint          `json:"series_id,omitempty" gorm:"uniqueIndex:idx_tasks_series_occurrence"` // 由重复任务生成时的模板ID
+	OccurrenceDate       *time.Time     `json:"occurrence_date,omitempty" gorm:"uniqueIndex:idx_tasks_series_occurrence"`
+	TemplateID           *uint          `json:"template_id,omitempty" gorm:"index"` // 从任务模板创建时的模板ID
+	ContractTaskID       *uint64        `json:"contract_task_id,omitempty" gorm:"index"`
+	DueDate              *time.Time     `json:"due_date,omitempty" gorm:"index"`
+	CompletionProof      *string        `json:"completion_proof,omitempty" gorm:"type:text"`
+	SubmittedAt          *time.Time     `json:"submitted_at,omitempty"`
+	ApprovedAt           *time.Time     `json:"approved_at,omitempty"`
+	RejectedAt           *time.Time     `json:"rejected_at,omitempty"`
+	RejectionReason      *string        `json:"rejection_reason,omitempty"`             // 拒绝或退回重做的原因
+	ApprovedRewardAmount *string        `json:"approved_reward_amount,omitempty"`       // 批准时实际发放的奖励，部分完成时低于RewardAmount
+	ApprovalReason       *string        `json:"approval_reason,omitempty"`              // 家长批准时的说明，部分发放奖励时必填
+	ReworkCount          int            `json:"rework_count" gorm:"not null;default:0"` // 被退回重做的次数
+	ReworkRequestedAt    *time.Time     `json:"rework_requested_at,omitempty"`
+	ExpiredAt            *time.Time     `json:"expired_at,omitempty"`
+	ExpiryReason         *string        `json:"expiry_reason,omitempty"`                          // 过期的原因和迟交规则，孩子和家长都能看到
+	OriginalRewardAmount *string        `json:"original_reward_amount,omitempty"`                 // 迟交减少奖励前的金额
+	ChecklistRequired    bool           `json:"checklist_required" gorm:"not null;default:false"` // 清单中必做的步骤全部勾选后才能提交
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// 关联关系
-	Creator       *User  `json:"creator,omitempty" gorm:"foreignKey:CreatedBy;references:WalletAddress"`
-	AssignedChild *Child `json:"assigned_child,omitempty" gorm:"foreignKey:AssignedChildID;references:ID"`
+	Creator       *User               `json:"creator,omitempty" gorm:"foreignKey:CreatedBy;references:WalletAddress"`
+	AssignedChild *Child              `json:"assigned_child,omitempty" gorm:"foreignKey:AssignedChildID;references:ID"`
+	Mint          *OutboxMessage      `json:"mint,omitempty" gorm:"foreignKey:TaskID;references:ID"`
+	Checklist     []TaskChecklistItem `json:"checklist,omitempty" gorm:"foreignKey:TaskID;references:ID"`
 }
 
 func (Task) TableName() string {
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TaskChecklistItem 任务清单中的一个步骤，孩子完成后逐项勾选
+// 任务开启ChecklistRequired时，所有必做的步骤都勾选后才能提交任务
+type TaskChecklistItem struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TaskID    uint       `json:"task_id" gorm:"not null;index"`
+	Position  int        `json:"position" gorm:"not null"` // 步骤在清单中的顺序，从0开始
+	Title     string     `json:"title" gorm:"not null"`
+	Required  bool       `json:"required" gorm:"not null"`
+	ProofType string     `json:"proof_type" gorm:"type:varchar(10);not null;default:'none'"` // none或photo，photo需要在勾选时上传照片
+	ProofUrl  *string    `json:"proof_url,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	CheckedBy string     `json:"checked_by,omitempty"` // 勾选的孩子的钱包地址
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (TaskChecklistItem) TableName() string {
+	return "task_checklist_items"
+}
+
+// Checked 步骤是否已经勾选
+func (i *TaskChecklistItem) Checked() bool {
+	return i.CheckedAt != nil
+}
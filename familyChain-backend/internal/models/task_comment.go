@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskComment 任务下家长和孩子之间的留言，可以附带一张通过上传接口上传的图片
+type TaskComment struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	TaskID        uint           `json:"task_id" gorm:"not null;index"`
+	AuthorAddress string         `json:"author_address" gorm:"not null"`
+	AuthorRole    string         `json:"author_role" gorm:"type:varchar(10);not null;check:author_role IN ('parent', 'child')"`
+	Body          string         `json:"body" gorm:"type:text;not null"`
+	ImageUrl      *string        `json:"image_url,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (TaskComment) TableName() string {
+	return "task_comments"
+}
+
+// TaskCommentRead 记录用户在每个任务中读到的最后一条留言，用于计算未读数
+type TaskCommentRead struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	TaskID            uint      `json:"task_id" gorm:"not null;uniqueIndex:idx_task_comment_reads_task_wallet"`
+	WalletAddress     string    `json:"wallet_address" gorm:"not null;uniqueIndex:idx_task_comment_reads_task_wallet;index"`
+	LastReadCommentID uint      `json:"last_read_comment_id" gorm:"not null;default:0"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (TaskCommentRead) TableName() string {
+	return "task_comment_reads"
+}
+
+// TaskUnreadComments 一个任务中别人发出的、当前用户还没有读过的留言数
+type TaskUnreadComments struct {
+	TaskID uint  `json:"task_id"`
+	Unread int64 `json:"unread"`
+}
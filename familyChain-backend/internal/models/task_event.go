@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// TaskEventAction 任务历史中的操作类型
+type TaskEventAction string
+
+const (
+	TaskEventCreated         TaskEventAction = "created"
+	TaskEventUpdated         TaskEventAction = "updated"
+	TaskEventAssigned        TaskEventAction = "assigned"
+	TaskEventSubmitted       TaskEventAction = "submitted"
+	TaskEventApproved        TaskEventAction = "approved"
+	TaskEventRejected        TaskEventAction = "rejected"
+	TaskEventReworkRequested TaskEventAction = "rework_requested"
+	TaskEventExpired         TaskEventAction = "expired"
+	TaskEventRewardMinted    TaskEventAction = "reward_minted"
+)
+
+// TaskEventSource 记录任务历史的来源
+type TaskEventSource string
+
+const (
+	TaskEventSourceAPI       TaskEventSource = "api"       // 用户通过API操作
+	TaskEventSourceScheduler TaskEventSource = "scheduler" // 重复任务生成和过期处理
+	TaskEventSourceChain     TaskEventSource = "chain"     // 索引器同步的链上事件
+	TaskEventSourceOutbox    TaskEventSource = "outbox"    // 后台分发器确认的链上交易
+	TaskEventSourceBackfill  TaskEventSource = "backfill"  // 引入任务历史之前的任务，根据任务上的时间字段补建
+)
+
+// TaskEvent 任务历史中的一条记录，只追加不修改
+// 任务上的SubmittedAt、ApprovedAt等字段只保留最近一次的值，完整的过程记录在这里
+type TaskEvent struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	TaskID       uint            `json:"task_id" gorm:"not null;index"`
+	Action       TaskEventAction `json:"action" gorm:"type:varchar(32);not null"`
+	Source       TaskEventSource `json:"source" gorm:"type:varchar(16);not null;default:'api'"`
+	Actor        string          `json:"actor,omitempty"` // 操作者的钱包地址，调度器等系统操作为空
+	FromStatus   string          `json:"from_status,omitempty" gorm:"type:varchar(20)"`
+	ToStatus     string          `json:"to_status" gorm:"type:varchar(20);not null"`
+	Proof        *string         `json:"proof,omitempty" gorm:"type:text"`
+	Reason       *string         `json:"reason,omitempty" gorm:"type:text"`
+	RewardAmount *string         `json:"reward_amount,omitempty"` // 发放或调整后的奖励
+	TxHash       *string         `json:"tx_hash,omitempty" gorm:"index"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+func (TaskEvent) TableName() string {
+	return "task_events"
+}
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// 重复任务状态
+const (
+	TaskSeriesStatusActive = "active"
+	TaskSeriesStatusPaused = "paused"
+	TaskSeriesStatusEnded  = "ended"
+)
+
+// TaskSeries 重复任务模板，调度器在每次出现的当天为每个孩子生成一个任务实例
+// 修改模板只影响之后生成的实例，已经生成的任务保持不变
+type TaskSeries struct {
+	ID           uint    `json:"id" gorm:"primaryKey"`
+	FamilyID     uint    `json:"family_id" gorm:"not null;index"`
+	CreatedBy    string  `json:"created_by" gorm:"not null"`
+	Title        string  `json:"title" gorm:"not null"`
+	Description  string  `json:"description" gorm:"not null"`
+	RewardAmount string  `json:"reward_amount" gorm:"not null"`
+	Difficulty   string  `json:"difficulty" gorm:"not null;check:difficulty IN ('easy', 'medium', 'hard')"`
+	ImageUrl     *string `json:"image_url,omitempty"`
+
+	// 重复规则，含义与RRULE的FREQ、INTERVAL、BYDAY、BYMONTHDAY相同
+	Frequency string     `json:"frequency" gorm:"type:varchar(20);not null;check:frequency IN ('daily', 'weekdays', 'weekly', 'monthly')"`
+	Interval  int        `json:"interval" gorm:"column:repeat_interval;not null;default:1"`
+	Weekdays  string     `json:"weekdays,omitempty" gorm:"type:varchar(32)"` // 例如 "MO,WE,FR"
+	MonthDay  int        `json:"month_day,omitempty"`
+	DueTime   string     `json:"due_time" gorm:"type:varchar(5);not null;default:'23:59'"` // 实例当天的截止时间 HH:MM
+	TimeZone  string     `json:"time_zone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	StartDate time.Time  `json:"start_date" gorm:"not null"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+
+	Status string `json:"status" gorm:"type:varchar(20);not null;default:'active';check:status IN ('active', 'paused', 'ended');index"`
+	// GeneratedThrough 已经生成实例的最后一个日期
+	GeneratedThrough *time.Time `json:"generated_through,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// NextOccurrences 接下来几次出现的日期，只在接口响应中返回
+	NextOccurrences []string `json:"next_occurrences,omitempty" gorm:"-"`
+
+	// 关联关系
+	Children []TaskSeriesChild `json:"children,omitempty" gorm:"foreignKey:SeriesID;references:ID"`
+	Skips    []TaskSeriesSkip  `json:"skips,omitempty" gorm:"foreignKey:SeriesID;references:ID"`
+}
+
+func (TaskSeries) TableName() string {
+	return "task_series"
+}
+
+// ChildIDs 返回重复任务分配的孩子ID
+func (s *TaskSeries) ChildIDs() []uint {
+	ids := make([]uint, 0, len(s.Children))
+	for _, child := range s.Children {
+		ids = append(ids, child.ChildID)
+	}
+	return ids
+}
+
+// Skipped 判断某个日期是否被跳过
+func (s *TaskSeries) Skipped(date time.Time) bool {
+	for _, skip := range s.Skips {
+		if skip.OccurrenceDate.Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskSeriesChild 重复任务分配给的孩子，每次出现时为每个孩子生成一个任务
+type TaskSeriesChild struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SeriesID  uint      `json:"series_id" gorm:"not null;uniqueIndex:idx_task_series_children_series_child"`
+	ChildID   uint      `json:"child_id" gorm:"not null;uniqueIndex:idx_task_series_children_series_child;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TaskSeriesChild) TableName() string {
+	return "task_series_children"
+}
+
+// TaskSeriesSkip 被跳过的一次出现，该日期不会生成任务
+type TaskSeriesSkip struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SeriesID       uint      `json:"series_id" gorm:"not null;uniqueIndex:idx_task_series_skips_series_date"`
+	OccurrenceDate time.Time `json:"occurrence_date" gorm:"not null;uniqueIndex:idx_task_series_skips_series_date"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (TaskSeriesSkip) TableName() string {
+	return "task_series_skips"
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskTemplate 可重复使用的任务模板
+// FamilyID为空的是内置的入门模板，所有家庭都可以使用但不能修改；其余模板属于创建它的家庭
+type TaskTemplate struct {
+	ID                uint           `json:"id" gorm:"primaryKey"`
+	FamilyID          *uint          `json:"family_id,omitempty" gorm:"index"`
+	CatalogKey        *string        `json:"catalog_key,omitempty" gorm:"type:varchar(64);uniqueIndex"` // 内置模板的唯一标识
+	CreatedBy         string         `json:"created_by,omitempty"`
+	Title             string         `json:"title" gorm:"not null"`
+	Description       string         `json:"description" gorm:"not null"`
+	Category          string         `json:"category,omitempty" gorm:"type:varchar(32)"`
+	RewardAmount      string         `json:"reward_amount" gorm:"not null"`
+	Difficulty        string         `json:"difficulty" gorm:"not null;check:difficulty IN ('easy', 'medium', 'hard')"`
+	ProofType         string         `json:"proof_type" gorm:"type:varchar(10);not null;default:'text';check:proof_type IN ('none', 'text', 'photo')"`
+	ProofInstructions *string        `json:"proof_instructions,omitempty" gorm:"type:text"`
+	ImageUrl          *string        `json:"image_url,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (TaskTemplate) TableName() string {
+	return "task_templates"
+}
+
+// BuiltIn 是否为内置模板
+func (t *TaskTemplate) BuiltIn() bool {
+	return t.FamilyID == nil
+}
+
+// StarterTaskTemplates 内置的入门模板，启动时按CatalogKey写入数据库
+func StarterTaskTemplates() []TaskTemplate {
+	starter := func(key, category, title, description, difficulty, reward, proofType, instructions string) TaskTemplate {
+		template := TaskTemplate{
+			CatalogKey:   &key,
+			Category:     category,
+			Title:        title,
+			Description:  description,
+			Difficulty:   difficulty,
+			RewardAmount: reward,
+			ProofType:    proofType,
+		}
+		if instructions != "" {
+			template.ProofInstructions = &instructions
+		}
+		return template
+	}
+
+	return []TaskTemplate{
+		starter("make-bed", "chores", "Make your bed", "Straighten the sheets, fluff the pillow and fold the blanket.", "easy", "0.005", ProofTypePhoto, "Take a photo of your made bed."),
+		starter("tidy-room", "chores", "Tidy your room", "Put toys, books and clothes back where they belong.", "medium", "0.02", ProofTypePhoto, "Take a photo of your tidy room."),
+		starter("set-table", "chores", "Set the table", "Put out plates, cutlery and glasses for everyone before dinner.", "easy", "0.005", ProofTypeNone, ""),
+		starter("wash-dishes", "chores", "Wash the dishes", "Wash, dry and put away the dishes after a meal.", "medium", "0.015", ProofTypePhoto, "Take a photo of the clean, empty sink."),
+		starter("fold-laundry", "chores", "Fold the laundry", "Fold your clean clothes and put them in your drawers.", "medium", "0.01", ProofTypePhoto, "Take a photo of your folded clothes."),
+		starter("take-out-trash", "chores", "Take out the trash", "Empty the bins and take the bags outside.", "easy", "0.005", ProofTypeNone, ""),
+		starter("feed-pet", "chores", "Feed the pet", "Give your pet fresh food and water.", "easy", "0.005", ProofTypeNone, ""),
+		starter("homework", "learning", "Finish your homework", "Complete today's homework before playtime.", "medium", "0.02", ProofTypePhoto, "Take a photo of your finished homework."),
+		starter("read-20-minutes", "learning", "Read for 20 minutes", "Read a book of your choice for at least 20 minutes.", "easy", "0.01", ProofTypeText, "Write one or two sentences about what you read."),
+		starter("practice-instrument", "learning", "Practice your instrument", "Practice your instrument for 30 minutes.", "medium", "0.015", ProofTypeText, "Tell us what you practiced."),
+		starter("brush-teeth", "health", "Brush your teeth", "Brush your teeth for two minutes, morning and night.", "easy", "0.002", ProofTypeNone, ""),
+		starter("play-outside", "health", "Play outside for an hour", "Get some fresh air: ride a bike, play a game or go for a walk.", "easy", "0.01", ProofTypeText, "Tell us what you did outside."),
+	}
+}
@@ -10,7 +10,8 @@ type User struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
 	WalletAddress string         `json:"wallet_address" gorm:"uniqueIndex;not null"`
 	Role          string         `json:"role" gorm:"not null;check:role IN ('parent', 'child', 'temp')"`
-	Nonce         string         `json:"-" gorm:"not null"`
+	Nonce         string         `json:"-" gorm:"not null"` // 已弃用：登录nonce保存在auth_nonces表
+	Email         *string        `json:"email,omitempty"` // 接收通知邮件的地址，没有验证
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
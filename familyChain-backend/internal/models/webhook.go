@@ -0,0 +1,80 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook 家长为家庭登记的webhook，家庭中发生的事件会签名后POST到URL
+type Webhook struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	FamilyID    uint   `json:"family_id" gorm:"not null;index"`
+	URL         string `json:"url" gorm:"type:text;not null"`
+	Description string `json:"description"`
+	// Events 订阅的事件类型，例如 "task.approved,tokens.minted"，为空表示全部事件
+	Events    string    `json:"events"`
+	Secret    string    `json:"-" gorm:"type:varchar(80);not null"` // HMAC签名密钥，只在创建时返回一次
+	Active    bool      `json:"active" gorm:"not null"`
+	CreatedBy string    `json:"created_by" gorm:"type:varchar(42);not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes 判断webhook是否订阅了某个事件类型
+func (w *Webhook) Subscribes(eventType string) bool {
+	if w.Events == "" {
+		return true
+	}
+	for _, name := range strings.Split(w.Events, ",") {
+		if name == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookWithSecret 创建webhook时的响应，包含签名密钥
+type WebhookWithSecret struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryStatus webhook投递状态
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending 等待投递（或等待下一次重试）
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered 接收方返回了2xx
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryFailed 重试次数耗尽
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery 一个事件向一个webhook的投递，同时作为投递日志保存最后一次请求的结果
+type WebhookDelivery struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	WebhookID uint   `json:"webhook_id" gorm:"not null;index"`
+	EventID   string `json:"event_id" gorm:"type:varchar(40);not null;index"` // 同一个事件投递到不同webhook时相同，接收方可用于去重
+	EventType string `json:"event_type" gorm:"type:varchar(40);not null"`
+	// Payload 请求体，重试时原样发送
+	Payload       string                `json:"payload" gorm:"type:text;not null"`
+	Status        WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts      int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time             `json:"next_attempt_at" gorm:"index"`
+	ResponseCode  *int                  `json:"response_code,omitempty"`
+	ResponseBody  *string               `json:"response_body,omitempty" gorm:"type:text"` // 截断后的响应内容
+	LastError     *string               `json:"last_error,omitempty" gorm:"type:text"`
+	DurationMs    *int64                `json:"duration_ms,omitempty"`
+	DeliveredAt   *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,120 @@
+package realtime
+
+import (
+	"time"
+)
+
+// EventType 推送给客户端的事件类型
+type EventType string
+
+const (
+	EventTaskAssigned      EventType = "task.assigned"
+	EventTaskSubmitted     EventType = "task.submitted"
+	EventTaskApproved      EventType = "task.approved"
+	EventTaskRejected      EventType = "task.rejected" // 包括退回重做，data中的rework为true
+	EventTokensMinted      EventType = "tokens.minted"
+	EventExchangeCreated   EventType = "exchange.created"
+	EventExchangeApproved  EventType = "exchange.approved"
+	EventExchangeDeclined  EventType = "exchange.declined"
+	EventExchangeFulfilled EventType = "exchange.fulfilled"
+	EventRewardLowStock    EventType = "reward.low_stock" // 只推送给家长
+	EventRewardProposed    EventType = "reward.proposed"
+	EventProposalAccepted  EventType = "reward.proposal_accepted"
+	EventProposalDeclined  EventType = "reward.proposal_declined"
+)
+
+// EventTypes 所有会发布的事件类型
+var EventTypes = []EventType{
+	EventTaskAssigned,
+	EventTaskSubmitted,
+	EventTaskApproved,
+	EventTaskRejected,
+	EventTokensMinted,
+	EventExchangeCreated,
+	EventExchangeApproved,
+	EventExchangeDeclined,
+	EventExchangeFulfilled,
+	EventRewardLowStock,
+	EventRewardProposed,
+	EventProposalAccepted,
+	EventProposalDeclined,
+}
+
+// IsValidEventType 判断是否为会发布的事件类型
+func IsValidEventType(name string) bool {
+	for _, eventType := range EventTypes {
+		if string(eventType) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Event 推送给家庭成员的一条事件
+type Event struct {
+	ID          uint64      `json:"id"` // 由代理分配，同一个进程内递增
+	Type        EventType   `json:"type"`
+	FamilyID    uint        `json:"family_id"`
+	ChildID     *uint       `json:"child_id,omitempty"` // 与某个孩子相关的事件只推送给这个孩子和家庭中的家长
+	ParentsOnly bool        `json:"-"`                  // 不推送给孩子，例如奖品库存不足
+	Data        interface{} `json:"data"`
+	OccurredAt  time.Time   `json:"occurred_at"`
+}
+
+// Subscription 订阅者能收到的事件范围：所在的家庭，孩子还只能收到与自己相关或与整个家庭相关的事件
+type Subscription struct {
+	FamilyIDs []uint
+	ChildID   *uint // 订阅者是孩子时设置
+}
+
+// Matches 判断事件是否应该推送给订阅者
+func (s Subscription) Matches(event Event) bool {
+	if s.ChildID != nil && (event.ParentsOnly || event.ChildID != nil && *s.ChildID != *event.ChildID) {
+		return false
+	}
+	for _, id := range s.FamilyIDs {
+		if id == event.FamilyID {
+			return true
+		}
+	}
+	return false
+}
+
+// Broker 在发布者和订阅者之间转发事件
+// 目前只有进程内实现；多实例部署时可以换成基于Redis、NATS等的实现，发布和订阅的代码不需要修改
+type Broker interface {
+	// Publish 发布事件，不阻塞调用方；订阅者处理不过来时事件会被丢弃
+	Publish(event Event)
+	// Subscribe 订阅事件，返回的函数用于取消订阅并关闭通道
+	Subscribe(sub Subscription) (<-chan Event, func())
+}
+
+// Nop 不转发任何事件的代理，用于不需要推送的场景
+type Nop struct{}
+
+func (Nop) Publish(Event) {}
+
+func (Nop) Subscribe(Subscription) (<-chan Event, func()) {
+	ch := make(chan Event)
+	return ch, func() {}
+}
+
+// WithHook 返回一个代理，发布的事件先交给broker推送，再按顺序同步调用hooks（例如写入webhook投递队列、发送通知）
+func WithHook(broker Broker, hooks ...func(Event)) Broker {
+	return &hookedBroker{Broker: broker, hooks: hooks}
+}
+
+type hookedBroker struct {
+	Broker
+	hooks []func(Event)
+}
+
+func (b *hookedBroker) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	b.Broker.Publish(event)
+	for _, hook := range b.hooks {
+		hook(event)
+	}
+}
@@ -0,0 +1,73 @@
+package realtime
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MemoryBroker 进程内的事件代理，只能把事件推送给连接到同一个实例的客户端
+type MemoryBroker struct {
+	buffer int
+	nextID atomic.Uint64
+
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	sub Subscription
+	ch  chan Event
+}
+
+// NewMemoryBroker 创建进程内的事件代理，buffer是每个订阅者的缓冲区大小
+func NewMemoryBroker(buffer int) *MemoryBroker {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	return &MemoryBroker{
+		buffer:      buffer,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish 把事件发送给所有匹配的订阅者，缓冲区已满的订阅者会丢失这条事件
+func (b *MemoryBroker) Publish(event Event) {
+	event.ID = b.nextID.Add(1)
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subscribers {
+		if !s.sub.Matches(event) {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			log.Printf("[realtime] 订阅者的缓冲区已满，丢弃事件 %d (%s)", event.ID, event.Type)
+		}
+	}
+}
+
+// Subscribe 注册订阅者，取消订阅后通道被关闭
+func (b *MemoryBroker) Subscribe(sub Subscription) (<-chan Event, func()) {
+	s := &subscriber{sub: sub, ch: make(chan Event, b.buffer)}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, s)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+}
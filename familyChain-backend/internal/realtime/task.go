@@ -0,0 +1,100 @@
+package realtime
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+)
+
+// TaskPayload 任务事件的内容
+type TaskPayload struct {
+	TaskID               uint    `json:"task_id"`
+	Title                string  `json:"title"`
+	Status               string  `json:"status"`
+	AssignedChildID      *uint   `json:"assigned_child_id,omitempty"`
+	RewardAmount         string  `json:"reward_amount"`
+	ApprovedRewardAmount *string `json:"approved_reward_amount,omitempty"`
+	Actor                string  `json:"actor,omitempty"`
+	Reason               *string `json:"reason,omitempty"`
+	Rework               bool    `json:"rework,omitempty"`
+}
+
+// MintPayload 奖励代币铸造确认后的事件内容
+type MintPayload struct {
+	TaskID    *uint  `json:"task_id,omitempty"`
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"` // 以wei为单位的十进制字符串
+	TxHash    string `json:"tx_hash"`
+}
+
+// ExchangePayload 兑换事件的内容
+type ExchangePayload struct {
+	ExchangeID  uint   `json:"exchange_id"`
+	RewardID    uint   `json:"reward_id"`
+	RewardName  string `json:"reward_name,omitempty"`
+	ChildID     uint   `json:"child_id"`
+	TokenAmount int    `json:"token_amount"`
+	Status      string `json:"status"`
+}
+
+// RewardStockPayload 奖品库存不足的事件内容
+type RewardStockPayload struct {
+	RewardID   uint   `json:"reward_id"`
+	RewardName string `json:"reward_name"`
+	Stock      int    `json:"stock"`
+}
+
+// RewardProposalPayload 孩子提出的奖品愿望的事件内容，接受后带有创建的奖品
+type RewardProposalPayload struct {
+	ProposalID   uint    `json:"proposal_id"`
+	ChildID      uint    `json:"child_id"`
+	Name         string  `json:"name"`
+	Status       string  `json:"status"`
+	RewardID     *uint   `json:"reward_id,omitempty"`
+	TokenPrice   *int    `json:"token_price,omitempty"`
+	DecisionNote string  `json:"decision_note,omitempty"`
+	Actor        *string `json:"actor,omitempty"`
+}
+
+// taskEventTypes 需要推送的任务历史操作
+var taskEventTypes = map[models.TaskEventAction]EventType{
+	models.TaskEventAssigned:        EventTaskAssigned,
+	models.TaskEventSubmitted:       EventTaskSubmitted,
+	models.TaskEventApproved:        EventTaskApproved,
+	models.TaskEventRejected:        EventTaskRejected,
+	models.TaskEventReworkRequested: EventTaskRejected,
+}
+
+// PublishTaskEvent 在任务历史写入（事务提交）之后调用，把需要推送的状态变化发布出去
+// 创建时就分配了孩子的任务视为分配；没有家庭的任务不推送
+func PublishTaskEvent(broker Broker, task *models.Task, event *models.TaskEvent) {
+	if broker == nil || task.FamilyID == nil {
+		return
+	}
+
+	eventType, ok := taskEventTypes[event.Action]
+	if event.Action == models.TaskEventCreated && task.AssignedChildID != nil {
+		eventType, ok = EventTaskAssigned, true
+	}
+	if !ok {
+		return
+	}
+
+	broker.Publish(Event{
+		Type:     eventType,
+		FamilyID: *task.FamilyID,
+		ChildID:  task.AssignedChildID,
+		Data: TaskPayload{
+			TaskID:               task.ID,
+			Title:                task.Title,
+			Status:               task.Status,
+			AssignedChildID:      task.AssignedChildID,
+			RewardAmount:         task.RewardAmount,
+			ApprovedRewardAmount: task.ApprovedRewardAmount,
+			Actor:                event.Actor,
+			Reason:               event.Reason,
+			Rework:               event.Action == models.TaskEventReworkRequested,
+		},
+		OccurredAt: time.Now(),
+	})
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuthNonceRepository 登录nonce的数据库操作
+type AuthNonceRepository struct {
+	db *gorm.DB
+}
+
+// NewAuthNonceRepository 创建一个新的AuthNonceRepository实例
+func NewAuthNonceRepository(db *gorm.DB) *AuthNonceRepository {
+	return &AuthNonceRepository{db: db}
+}
+
+// Create 保存新签发的nonce
+func (r *AuthNonceRepository) Create(nonce *models.AuthNonce) error {
+	return r.db.Create(nonce).Error
+}
+
+// Consume 把属于该钱包、未过期且未使用的nonce标记为已使用
+// 条件更新保证并发请求中只有一个能成功，返回false表示nonce无效
+func (r *AuthNonceRepository) Consume(nonce, walletAddress string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.AuthNonce{}).
+		Where("nonce = ? AND wallet_address = ? AND used_at IS NULL AND expires_at > ?", nonce, walletAddress, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteExpired 删除钱包已过期或已使用的nonce
+func (r *AuthNonceRepository) DeleteExpired(walletAddress string, now time.Time) error {
+	return r.db.Where("wallet_address = ? AND (expires_at <= ? OR used_at IS NOT NULL)", walletAddress, now).
+		Delete(&models.AuthNonce{}).Error
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"errors"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChainCursorRepository 事件索引进度的数据库操作
+type ChainCursorRepository struct {
+	db *gorm.DB
+}
+
+// NewChainCursorRepository 创建一个新的ChainCursorRepository实例
+func NewChainCursorRepository(db *gorm.DB) *ChainCursorRepository {
+	return &ChainCursorRepository{db: db}
+}
+
+// Get 获取合约的索引进度，尚未索引过时返回nil
+func (r *ChainCursorRepository) Get(contract string) (*models.ChainCursor, error) {
+	var cursor models.ChainCursor
+	err := r.db.Where("contract = ?", contract).First(&cursor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// Save 记录合约已处理到的区块
+func (r *ChainCursorRepository) Save(contract, address string, lastBlock uint64) error {
+	cursor := models.ChainCursor{
+		Contract:  contract,
+		Address:   address,
+		LastBlock: lastBlock,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "contract"}},
+		DoUpdates: clause.AssignmentColumns([]string{"address", "last_block", "updated_at"}),
+	}).Create(&cursor).Error
+}
+
+// Rewind 把处理进度超过指定区块的游标回退到该区块
+func (r *ChainCursorRepository) Rewind(block uint64) error {
+	return r.db.Model(&models.ChainCursor{}).Where("last_block > ?", block).
+		Update("last_block", block).Error
+}
+
+// SaveBlock 记录已处理区块的哈希
+func (r *ChainCursorRepository) SaveBlock(block *models.IndexedBlock) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hash", "parent_hash"}),
+	}).Create(block).Error
+}
+
+// GetBlocks 获取区块号在from到to之间（含两端）的已记录区块
+func (r *ChainCursorRepository) GetBlocks(from, to uint64) ([]*models.IndexedBlock, error) {
+	var blocks []*models.IndexedBlock
+	err := r.db.Where("number BETWEEN ? AND ?", from, to).Order("number ASC").Find(&blocks).Error
+	return blocks, err
+}
+
+// GetRecentBlocks 按区块号从高到低获取已记录的区块
+func (r *ChainCursorRepository) GetRecentBlocks(limit int) ([]*models.IndexedBlock, error) {
+	var blocks []*models.IndexedBlock
+	err := r.db.Order("number DESC").Limit(limit).Find(&blocks).Error
+	return blocks, err
+}
+
+// CreateEvent 记录一条已写入数据库的事件
+func (r *ChainCursorRepository) CreateEvent(event *models.IndexedEvent) error {
+	return r.db.Create(event).Error
+}
+
+// GetEventsAfter 按写入的逆序获取指定区块之后的事件
+// 各合约的事件分别按区块顺序写入，不同合约之间的写入顺序与区块顺序无关，所以按id排序
+func (r *ChainCursorRepository) GetEventsAfter(block uint64) ([]*models.IndexedEvent, error) {
+	var events []*models.IndexedEvent
+	err := r.db.Where("block_number > ?", block).
+		Order("id DESC").
+		Find(&events).Error
+	return events, err
+}
+
+// DeleteAfter 删除指定区块之后的区块和事件记录
+func (r *ChainCursorRepository) DeleteAfter(block uint64) error {
+	if err := r.db.Where("block_number > ?", block).Delete(&models.IndexedEvent{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("number > ?", block).Delete(&models.IndexedBlock{}).Error
+}
+
+// PruneBefore 删除指定区块之前的区块和事件记录，它们已经不可能被重组
+func (r *ChainCursorRepository) PruneBefore(block uint64) error {
+	if err := r.db.Where("block_number < ?", block).Delete(&models.IndexedEvent{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("number < ?", block).Delete(&models.IndexedBlock{}).Error
+}
+
+// WithTransaction 在事务中执行操作
+func (r *ChainCursorRepository) WithTransaction(fn func(*ChainCursorRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := &ChainCursorRepository{db: tx}
+		return fn(txRepo)
+	})
+}
@@ -1,11 +1,15 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/utils"
 
+	"github.com/mattn/go-sqlite3"
 	"gorm.io/gorm"
 )
 
@@ -36,6 +40,141 @@ func (r *ExchangeRepository) GetByID(id uint) (*models.Exchange, error) {
 	return &exchange, nil
 }
 
+// GetByContractExchangeID 根据链上兑换ID获取兑换记录
+func (r *ExchangeRepository) GetByContractExchangeID(contractExchangeID uint64) (*models.Exchange, error) {
+	var exchange models.Exchange
+	err := r.db.Where("contract_exchange_id = ?", contractExchangeID).First(&exchange).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exchange, nil
+}
+
+// Escrowed 判断兑换的代币是否托管在奖品合约中：已经关联链上兑换，或者后端代为托管的交易没有失败
+// 没有托管的兑换只在账本中扣留代币，代币仍在孩子的钱包里
+func (r *ExchangeRepository) Escrowed(exchange *models.Exchange) (bool, error) {
+	if exchange.ContractExchangeID != nil {
+		return true, nil
+	}
+	var count int64
+	err := r.db.Model(&models.OutboxMessage{}).
+		Where("kind = ? AND exchange_id = ? AND status <> ?", models.OutboxKindEscrowExchange, exchange.ID, models.OutboxStatusFailed).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// HeldInLedger 汇总孩子待审核、但没有在链上托管的兑换在账本中扣留的代币（wei）
+// 这部分代币仍在孩子的钱包里，兑换被批准并销毁、或者被拒绝之前不能再用
+func (r *ExchangeRepository) HeldInLedger(childID uint) (*big.Int, error) {
+	var held int64
+	err := r.db.Model(&models.Exchange{}).
+		Select("COALESCE(SUM(token_amount), 0)").
+		Where("child_id = ? AND status = ? AND contract_exchange_id IS NULL", childID, models.ExchangeStatusPending).
+		Where("id NOT IN (?)", r.db.Model(&models.OutboxMessage{}).Select("exchange_id").
+			Where("kind = ? AND exchange_id IS NOT NULL AND status <> ?", models.OutboxKindEscrowExchange, models.OutboxStatusFailed)).
+		Scan(&held).Error
+	if err != nil {
+		return nil, err
+	}
+	return utils.UnitsToTokenAmount(int(held)), nil
+}
+
+// ErrInsufficientBalance 孩子的代币余额不足以支付兑换的代币
+var ErrInsufficientBalance = errors.New("insufficient token balance")
+
+// ErrExchangeLinked 链上兑换已经关联到另一条兑换记录
+var ErrExchangeLinked = errors.New("on-chain exchange is already linked")
+
+// CreatePending 在事务中创建待审核的兑换记录并扣减库存，库存不足时返回ErrOutOfStock
+// escrow不为nil时同时写入托管代币的链上消息；checkBalance为true时按账本核对孩子钱包account的余额，
+// 扣除尚未记账的托管和销毁、其他兑换在账本中的扣留、以及为其他奖品的储蓄目标锁定的代币后不够支付兑换时返回ErrInsufficientBalance
+// 孩子有这个奖品的储蓄目标时，目标随兑换一起结束
+// 库存先扣减，事务从一开始就持有写锁，同一个孩子的并发兑换按顺序核对余额
+// 链上兑换已经被并发的请求或索引器关联时不做修改，把已有的记录读入exchange并返回ErrExchangeLinked
+func (r *ExchangeRepository) CreatePending(exchange *models.Exchange, account string, escrow *models.OutboxMessage, checkBalance bool) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := NewRewardRepository(tx).UpdateStock(exchange.RewardID, -1); err != nil {
+			return err
+		}
+
+		if checkBalance {
+			spendable, err := spendableBalance(tx, exchange.ChildID, account, exchange.RewardID)
+			if err != nil {
+				return err
+			}
+			if spendable.Cmp(utils.UnitsToTokenAmount(exchange.TokenAmount)) < 0 {
+				return ErrInsufficientBalance
+			}
+		}
+
+		if err := tx.Create(exchange).Error; err != nil {
+			return err
+		}
+		if err := NewSavingsGoalRepository(tx).redeem(exchange); err != nil {
+			return err
+		}
+		if escrow == nil {
+			return nil
+		}
+		escrow.ExchangeID = &exchange.ID
+		return NewOutboxRepository(tx).Create(escrow)
+	})
+	if exchange.ContractExchangeID == nil || !isUniqueViolation(err) {
+		return err
+	}
+
+	linked, err := r.GetByContractExchangeID(*exchange.ContractExchangeID)
+	if err != nil {
+		return err
+	}
+	*exchange = *linked
+	return ErrExchangeLinked
+}
+
+// isUniqueViolation 判断写入是否违反了唯一索引
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// Transition 在事务中把兑换从当前状态改为updates中的状态，restoreStock为true时恢复库存，
+// settlement不为nil时同时写入结算托管代币的链上消息
+// 兑换已经被其他请求改变了状态时不做修改并返回false
+func (r *ExchangeRepository) Transition(exchange *models.Exchange, updates map[string]interface{}, restoreStock bool, settlement *models.OutboxMessage) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		updates["updated_at"] = time.Now()
+		result := tx.Model(&models.Exchange{}).Where("id = ? AND status = ?", exchange.ID, exchange.Status).Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		updated = true
+
+		if restoreStock {
+			if err := NewRewardRepository(tx).UpdateStock(exchange.RewardID, 1); err != nil {
+				return err
+			}
+		}
+		if settlement == nil {
+			return nil
+		}
+		settlement.ExchangeID = &exchange.ID
+		return NewOutboxRepository(tx).Create(settlement)
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+// Update 更新兑换记录
+func (r *ExchangeRepository) Update(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Exchange{}).Where("id = ?", id).Updates(updates).Error
+}
+
 // GetByChildID 获取孩子的兑换记录
 func (r *ExchangeRepository) GetByChildID(childID uint) ([]*models.Exchange, error) {
 	var exchanges []*models.Exchange
@@ -153,7 +292,7 @@ func (r *ExchangeRepository) UpdateStatus(id uint, status models.ExchangeStatus,
 	}
 
 	// 如果状态是已完成，更新完成日期
-	if status == models.ExchangeStatusCompleted {
+	if status == models.ExchangeStatusFulfilled || status == models.ExchangeStatusCompleted {
 		updates["completed_date"] = time.Now()
 	}
 
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FamilyInvitationRepository 家庭邀请的数据库操作
+type FamilyInvitationRepository struct {
+	db *gorm.DB
+}
+
+// NewFamilyInvitationRepository 创建一个新的FamilyInvitationRepository实例
+func NewFamilyInvitationRepository(db *gorm.DB) *FamilyInvitationRepository {
+	return &FamilyInvitationRepository{db: db}
+}
+
+// Create 创建邀请
+func (r *FamilyInvitationRepository) Create(invitation *models.FamilyInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+// GetByID 根据ID获取邀请
+func (r *FamilyInvitationRepository) GetByID(id uint) (*models.FamilyInvitation, error) {
+	var invitation models.FamilyInvitation
+	if err := r.db.Preload("Family").First(&invitation, id).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// ListByFamily 获取家庭发出的所有邀请
+func (r *FamilyInvitationRepository) ListByFamily(familyID uint) ([]*models.FamilyInvitation, error) {
+	var invitations []*models.FamilyInvitation
+	err := r.db.Where("family_id = ?", familyID).Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+// ListPendingByInvitee 获取钱包收到的、尚未过期的待处理邀请
+func (r *FamilyInvitationRepository) ListPendingByInvitee(walletAddress string, now time.Time) ([]*models.FamilyInvitation, error) {
+	var invitations []*models.FamilyInvitation
+	err := r.db.Preload("Family").
+		Where("invitee_address = ? AND status = ? AND expires_at > ?", walletAddress, models.InvitationStatusPending, now).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// HasPending 判断家庭是否已经向该钱包发出了尚未过期的待处理邀请
+func (r *FamilyInvitationRepository) HasPending(familyID uint, walletAddress string, now time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.FamilyInvitation{}).
+		Where("family_id = ? AND invitee_address = ? AND status = ? AND expires_at > ?",
+			familyID, walletAddress, models.InvitationStatusPending, now).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Accept 在同一个事务中接受邀请并添加家庭成员
+// 邀请已被处理或已过期时返回false
+func (r *FamilyInvitationRepository) Accept(invitation *models.FamilyInvitation, member *models.FamilyMember, now time.Time) (bool, error) {
+	accepted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FamilyInvitation{}).
+			Where("id = ? AND status = ? AND expires_at > ?", invitation.ID, models.InvitationStatusPending, now).
+			Updates(map[string]interface{}{
+				"status":       models.InvitationStatusAccepted,
+				"responded_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return err
+		}
+		accepted = true
+		return nil
+	})
+	return accepted, err
+}
+
+// Respond 把待处理的邀请标记为拒绝或撤销，邀请已被处理时返回false
+func (r *FamilyInvitationRepository) Respond(id uint, status string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.FamilyInvitation{}).
+		Where("id = ? AND status = ?", id, models.InvitationStatusPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
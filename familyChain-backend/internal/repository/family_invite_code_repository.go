@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FamilyInviteCodeRepository 家庭邀请码的数据库操作
+type FamilyInviteCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewFamilyInviteCodeRepository 创建一个新的FamilyInviteCodeRepository实例
+func NewFamilyInviteCodeRepository(db *gorm.DB) *FamilyInviteCodeRepository {
+	return &FamilyInviteCodeRepository{db: db}
+}
+
+// Create 保存邀请码
+func (r *FamilyInviteCodeRepository) Create(code *models.FamilyInviteCode) error {
+	return r.db.Create(code).Error
+}
+
+// GetByID 根据ID获取邀请码
+func (r *FamilyInviteCodeRepository) GetByID(id uint) (*models.FamilyInviteCode, error) {
+	var code models.FamilyInviteCode
+	if err := r.db.First(&code, id).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// GetByHash 根据邀请码哈希获取邀请码
+func (r *FamilyInviteCodeRepository) GetByHash(hash string) (*models.FamilyInviteCode, error) {
+	var code models.FamilyInviteCode
+	if err := r.db.Preload("Family").Where("code_hash = ?", hash).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// ListByFamily 获取家庭生成的所有邀请码
+func (r *FamilyInviteCodeRepository) ListByFamily(familyID uint) ([]*models.FamilyInviteCode, error) {
+	var codes []*models.FamilyInviteCode
+	err := r.db.Where("family_id = ?", familyID).Order("created_at DESC").Find(&codes).Error
+	return codes, err
+}
+
+// Revoke 撤销尚未兑换的邀请码，邀请码已被兑换或撤销时返回false
+func (r *FamilyInviteCodeRepository) Revoke(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&models.FamilyInviteCode{}).
+		Where("id = ? AND redeemed_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// InviteRedemption 兑换邀请码时需要在同一个事务中写入的记录
+// User的ID为0时创建用户，否则更新用户角色（临时用户转为正式角色）；
+// Member和Child只设置其中一个，分别对应家长成员和孩子
+type InviteRedemption struct {
+	User   *models.User
+	Member *models.FamilyMember
+	Child  *models.Child
+}
+
+// Redeem 在同一个事务中标记邀请码已兑换，并写入用户、家庭成员或孩子记录
+// 邀请码已被兑换、撤销或已过期时返回false，不写入任何记录
+func (r *FamilyInviteCodeRepository) Redeem(code *models.FamilyInviteCode, redemption *InviteRedemption, now time.Time) (bool, error) {
+	redeemed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FamilyInviteCode{}).
+			Where("id = ? AND redeemed_at IS NULL AND revoked_at IS NULL AND expires_at > ?", code.ID, now).
+			Updates(map[string]interface{}{
+				"redeemed_by": redemption.User.WalletAddress,
+				"redeemed_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+
+		if redemption.User.ID == 0 {
+			if err := tx.Create(redemption.User).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(redemption.User).Update("role", redemption.User.Role).Error; err != nil {
+			return err
+		}
+		if redemption.Member != nil {
+			if err := tx.Create(redemption.Member).Error; err != nil {
+				return err
+			}
+		}
+		if redemption.Child != nil {
+			if err := tx.Create(redemption.Child).Error; err != nil {
+				return err
+			}
+		}
+		redeemed = true
+		return nil
+	})
+	return redeemed, err
+}
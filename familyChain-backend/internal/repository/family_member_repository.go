@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FamilyMemberRepository 家庭成员的数据库操作
+type FamilyMemberRepository struct {
+	db *gorm.DB
+}
+
+// NewFamilyMemberRepository 创建一个新的FamilyMemberRepository实例
+func NewFamilyMemberRepository(db *gorm.DB) *FamilyMemberRepository {
+	return &FamilyMemberRepository{db: db}
+}
+
+// Create 添加家庭成员
+func (r *FamilyMemberRepository) Create(member *models.FamilyMember) error {
+	return r.db.Create(member).Error
+}
+
+// GetByID 根据ID获取家庭成员
+func (r *FamilyMemberRepository) GetByID(id uint) (*models.FamilyMember, error) {
+	var member models.FamilyMember
+	if err := r.db.First(&member, id).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetByFamilyAndWallet 获取钱包在家庭中的成员记录，不是成员时返回nil
+func (r *FamilyMemberRepository) GetByFamilyAndWallet(familyID uint, walletAddress string) (*models.FamilyMember, error) {
+	var member models.FamilyMember
+	err := r.db.Where("family_id = ? AND wallet_address = ?", familyID, walletAddress).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListByFamily 获取家庭的所有成员，所有者排在前面
+func (r *FamilyMemberRepository) ListByFamily(familyID uint) ([]*models.FamilyMember, error) {
+	var members []*models.FamilyMember
+	err := r.db.Where("family_id = ?", familyID).
+		Order("CASE role WHEN 'owner' THEN 0 WHEN 'co_parent' THEN 1 ELSE 2 END, created_at").
+		Find(&members).Error
+	return members, err
+}
+
+// ListByWallet 获取钱包加入的所有家庭的成员记录，按加入时间排序
+func (r *FamilyMemberRepository) ListByWallet(walletAddress string) ([]*models.FamilyMember, error) {
+	var members []*models.FamilyMember
+	err := r.db.Where("wallet_address = ?", walletAddress).Order("created_at, id").Find(&members).Error
+	return members, err
+}
+
+// UpdateRole 修改成员角色
+func (r *FamilyMemberRepository) UpdateRole(id uint, role string) error {
+	return r.db.Model(&models.FamilyMember{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// Delete 移除家庭成员
+func (r *FamilyMemberRepository) Delete(id uint) error {
+	return r.db.Delete(&models.FamilyMember{}, id).Error
+}
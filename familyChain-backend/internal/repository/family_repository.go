@@ -18,6 +18,20 @@ func (r *FamilyRepository) Create(family *models.Family) error {
 	return r.db.Create(family).Error
 }
 
+// CreateWithOwner 在同一个事务中创建家庭并把创建者添加为所有者成员
+func (r *FamilyRepository) CreateWithOwner(family *models.Family) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(family).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.FamilyMember{
+			FamilyID:      family.ID,
+			WalletAddress: family.ParentAddress,
+			Role:          models.FamilyRoleOwner,
+		}).Error
+	})
+}
+
 // GetByID 根据ID获取家庭
 func (r *FamilyRepository) GetByID(id uint) (*models.Family, error) {
 	var family models.Family
@@ -38,6 +52,26 @@ func (r *FamilyRepository) GetByParentAddress(parentAddress string) (*models.Fam
 	return &family, nil
 }
 
+// GetIDByParentAddress 根据家长地址获取家庭ID，不加载关联数据
+func (r *FamilyRepository) GetIDByParentAddress(parentAddress string) (uint, error) {
+	var family models.Family
+	err := r.db.Select("id").Where("parent_address = ?", parentAddress).First(&family).Error
+	if err != nil {
+		return 0, err
+	}
+	return family.ID, nil
+}
+
+// ListByMember 获取钱包作为成员加入的所有家庭
+func (r *FamilyRepository) ListByMember(walletAddress string) ([]*models.Family, error) {
+	var families []*models.Family
+	err := r.db.Preload("Children").Preload("Members").
+		Where("id IN (?)", r.db.Model(&models.FamilyMember{}).Select("family_id").Where("wallet_address = ?", walletAddress)).
+		Order("created_at").
+		Find(&families).Error
+	return families, err
+}
+
 // Update 更新家庭
 func (r *FamilyRepository) Update(id uint, updates map[string]interface{}) error {
 	return r.db.Model(&models.Family{}).Where("id = ?", id).Updates(updates).Error
@@ -89,11 +123,11 @@ func (r *FamilyRepository) GetFamilyStatistics(id uint) (map[string]interface{},
 
 	// 统计任务数量
 	var taskCount int64
-	r.db.Model(&models.Task{}).Where("created_by = ?", family.ParentAddress).Count(&taskCount)
+	r.db.Model(&models.Task{}).Where("family_id = ?", id).Count(&taskCount)
 
 	// 统计完成的任务数量
 	var completedTaskCount int64
-	r.db.Model(&models.Task{}).Where("created_by = ? AND status = ?", family.ParentAddress, "approved").Count(&completedTaskCount)
+	r.db.Model(&models.Task{}).Where("family_id = ? AND status = ?", id, "approved").Count(&completedTaskCount)
 
 	// 计算总奖励
 	var totalRewards float64
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LedgerRepository 代币账本的数据库操作
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+// NewLedgerRepository 创建一个新的LedgerRepository实例
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// Exists 检查链上的一条Transfer事件是否已经记账
+func (r *LedgerRepository) Exists(txHash string, logIndex uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.LedgerTransaction{}).
+		Where("tx_hash = ? AND log_index = ?", txHash, logIndex).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Post 记一笔流水，分录的金额之和必须为0
+// 每条分录的余额在账户上一条分录的余额上累加，写入后txn.Entries中是带ID和余额的分录
+func (r *LedgerRepository) Post(txn *models.LedgerTransaction, entries []models.LedgerEntry) error {
+	sum := new(big.Int)
+	amounts := make([]*big.Int, len(entries))
+	for k, entry := range entries {
+		amount, ok := new(big.Int).SetString(entry.Amount, 10)
+		if !ok {
+			return fmt.Errorf("invalid ledger amount %q", entry.Amount)
+		}
+		amounts[k] = amount
+		sum.Add(sum, amount)
+	}
+	if len(entries) < 2 || sum.Sign() != 0 {
+		return fmt.Errorf("ledger transaction %s:%d is not balanced", txn.TxHash, txn.LogIndex)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(txn).Error; err != nil {
+			return err
+		}
+
+		txRepo := &LedgerRepository{db: tx}
+		for k := range entries {
+			balance, err := txRepo.Balance(entries[k].Account)
+			if err != nil {
+				return err
+			}
+			entries[k].TransactionID = txn.ID
+			entries[k].Balance = balance.Add(balance, amounts[k]).String()
+			if err := tx.Create(&entries[k]).Error; err != nil {
+				return err
+			}
+		}
+		txn.Entries = entries
+		return nil
+	})
+}
+
+// Balance 获取账户当前的余额（wei），没有分录时为0
+func (r *LedgerRepository) Balance(account string) (*big.Int, error) {
+	var entry models.LedgerEntry
+	err := r.db.Select("balance").Where("account = ?", account).Order("id DESC").First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return new(big.Int), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	balance, ok := new(big.Int).SetString(entry.Balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid ledger balance %q for %s", entry.Balance, account)
+	}
+	return balance, nil
+}
+
+// Minted 汇总账户收到的铸币（wei）
+func (r *LedgerRepository) Minted(account string) (*big.Int, error) {
+	return r.MintedSince(account, time.Time{})
+}
+
+// MintedSince 汇总账户从since开始收到的铸币（wei），since为零值时汇总全部
+func (r *LedgerRepository) MintedSince(account string, since time.Time) (*big.Int, error) {
+	query := r.db.Model(&models.LedgerEntry{}).
+		Joins("JOIN ledger_transactions ON ledger_transactions.id = ledger_entries.transaction_id").
+		Where("ledger_entries.account = ? AND ledger_transactions.kind = ?", account, models.LedgerKindMint)
+	if !since.IsZero() {
+		query = query.Where("ledger_transactions.occurred_at >= ?", since)
+	}
+	var amounts []string
+	if err := query.Pluck("ledger_entries.amount", &amounts).Error; err != nil {
+		return nil, err
+	}
+
+	total := new(big.Int)
+	for _, amount := range amounts {
+		value, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid ledger amount %q", amount)
+		}
+		total.Add(total, value)
+	}
+	return total, nil
+}
+
+// ListByAccount 获取账户最近的分录，附带流水的类型和交易哈希
+func (r *LedgerRepository) ListByAccount(account string, limit int) ([]*models.LedgerEntry, error) {
+	var entries []*models.LedgerEntry
+	if err := r.db.Where("account = ?", account).Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.TransactionID)
+	}
+	var txns []*models.LedgerTransaction
+	if err := r.db.Where("id IN ?", ids).Find(&txns).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.LedgerTransaction, len(txns))
+	for _, txn := range txns {
+		byID[txn.ID] = txn
+	}
+	for _, entry := range entries {
+		if txn, ok := byID[entry.TransactionID]; ok {
+			entry.Kind = txn.Kind
+			entry.TxHash = txn.TxHash
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository 站内通知、通知设置和邮件队列的数据库操作
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository 创建一个新的NotificationRepository实例
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create 写入一条站内通知
+func (r *NotificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// ListByWallet 按时间倒序获取用户的通知，unreadOnly为true时只返回未读的
+func (r *NotificationRepository) ListByWallet(walletAddress string, unreadOnly bool, limit int) ([]*models.Notification, error) {
+	notifications := []*models.Notification{}
+	query := r.db.Where("wallet_address = ?", walletAddress)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+// CountUnread 统计用户的未读通知数
+func (r *NotificationRepository) CountUnread(walletAddress string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("wallet_address = ? AND read_at IS NULL", walletAddress).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 把用户的一条通知标记为已读，通知不存在或不属于该用户时返回gorm.ErrRecordNotFound
+func (r *NotificationRepository) MarkRead(id uint, walletAddress string, at time.Time) (*models.Notification, error) {
+	var notification models.Notification
+	if err := r.db.Where("id = ? AND wallet_address = ?", id, walletAddress).First(&notification).Error; err != nil {
+		return nil, err
+	}
+	if notification.ReadAt != nil {
+		return &notification, nil
+	}
+	if err := r.db.Model(&notification).Update("read_at", at).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkAllRead 把用户的所有未读通知标记为已读，返回标记的数量
+func (r *NotificationRepository) MarkAllRead(walletAddress string, at time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("wallet_address = ? AND read_at IS NULL", walletAddress).
+		Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
+
+// ListPreferences 获取用户保存过的通知设置
+func (r *NotificationRepository) ListPreferences(walletAddress string) ([]*models.NotificationPreference, error) {
+	var preferences []*models.NotificationPreference
+	err := r.db.Where("wallet_address = ?", walletAddress).Find(&preferences).Error
+	return preferences, err
+}
+
+// SavePreferences 保存用户的通知设置，同一种通知已有记录时覆盖
+func (r *NotificationRepository) SavePreferences(preferences []*models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "wallet_address"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+	}).Create(&preferences).Error
+}
+
+// CreateEmail 把一封通知邮件加入发送队列
+func (r *NotificationRepository) CreateEmail(email *models.EmailMessage) error {
+	if email.Status == "" {
+		email.Status = models.EmailPending
+	}
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = time.Now()
+	}
+	return r.db.Create(email).Error
+}
+
+// GetDueEmails 获取已到发送时间的邮件
+func (r *NotificationRepository) GetDueEmails(now time.Time, limit int) ([]*models.EmailMessage, error) {
+	var emails []*models.EmailMessage
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", models.EmailPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&emails).Error
+	return emails, err
+}
+
+// SaveEmailAttempt 保存一次发送尝试的结果
+func (r *NotificationRepository) SaveEmailAttempt(email *models.EmailMessage) error {
+	return r.db.Model(&models.EmailMessage{}).Where("id = ?", email.ID).Updates(map[string]interface{}{
+		"status":          email.Status,
+		"attempts":        email.Attempts,
+		"next_attempt_at": email.NextAttemptAt,
+		"last_error":      email.LastError,
+		"sent_at":         email.SentAt,
+	}).Error
+}
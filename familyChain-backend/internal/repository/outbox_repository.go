@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository 链上副作用消息的数据库操作
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository 创建一个新的OutboxRepository实例
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Create 写入一条消息，调用方可以传入事务中的仓库以保证与业务数据原子提交
+func (r *OutboxRepository) Create(msg *models.OutboxMessage) error {
+	if msg.Status == "" {
+		msg.Status = models.OutboxStatusPending
+	}
+	if msg.NextAttemptAt.IsZero() {
+		msg.NextAttemptAt = time.Now()
+	}
+	return r.db.Create(msg).Error
+}
+
+// GetByID 根据ID获取消息
+func (r *OutboxRepository) GetByID(id uint) (*models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	if err := r.db.First(&msg, id).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetByTask 获取任务关联的指定类型消息
+func (r *OutboxRepository) GetByTask(taskID uint, kind models.OutboxKind) (*models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	err := r.db.Where("task_id = ? AND kind = ?", taskID, kind).Order("id DESC").First(&msg).Error
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetByExchange 获取兑换关联的指定类型消息
+func (r *OutboxRepository) GetByExchange(exchangeID uint, kind models.OutboxKind) (*models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	err := r.db.Where("exchange_id = ? AND kind = ?", exchangeID, kind).Order("id DESC").First(&msg).Error
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// GetByTxHash 根据交易哈希获取消息
+func (r *OutboxRepository) GetByTxHash(txHash string) (*models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	if err := r.db.Where("tx_hash = ?", txHash).First(&msg).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// ExchangeContractIDs 获取兑换对应的链上奖品ID和链上兑换ID，没有登记或尚未关联时为nil
+func (r *OutboxRepository) ExchangeContractIDs(exchangeID uint) (*uint, *uint64, error) {
+	var row struct {
+		ContractRewardID   *uint
+		ContractExchangeID *uint64
+	}
+	err := r.db.Table("exchanges").
+		Select("rewards.contract_reward_id, exchanges.contract_exchange_id").
+		Joins("JOIN rewards ON rewards.id = exchanges.reward_id").
+		Where("exchanges.id = ?", exchangeID).
+		Take(&row).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return row.ContractRewardID, row.ContractExchangeID, nil
+}
+
+// LinkExchange 把托管交易创建的链上兑换关联到兑换记录，已经关联（例如索引器先处理了事件）时不修改
+func (r *OutboxRepository) LinkExchange(exchangeID uint, contractExchangeID uint64, txHash string) error {
+	return r.db.Model(&models.Exchange{}).
+		Where("id = ? AND contract_exchange_id IS NULL", exchangeID).
+		Updates(map[string]interface{}{
+			"contract_exchange_id": contractExchangeID,
+			"tx_hash":              txHash,
+			"updated_at":           time.Now(),
+		}).Error
+}
+
+// SpendingInFlight 汇总孩子已经排队、但还没有记入账本的托管和销毁代币（wei）
+// 这部分代币在账本中仍然算在孩子的余额里：交易还没有上链，或者已经确认但索引器还没有同步到
+func (r *OutboxRepository) SpendingInFlight(childID uint) (*big.Int, error) {
+	var amounts []string
+	err := r.db.Model(&models.OutboxMessage{}).
+		Where("kind IN ?", []models.OutboxKind{models.OutboxKindEscrowExchange, models.OutboxKindBurnExchange}).
+		Where("exchange_id IN (?)", r.db.Model(&models.Exchange{}).Select("id").Where("child_id = ?", childID)).
+		Where(r.db.Where("status IN ?", []models.OutboxStatus{models.OutboxStatusPending, models.OutboxStatusSent}).
+			Or("status = ? AND tx_hash NOT IN (?)", models.OutboxStatusConfirmed,
+				r.db.Model(&models.LedgerTransaction{}).Select("tx_hash"))).
+		Pluck("amount", &amounts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	total := new(big.Int)
+	for _, amount := range amounts {
+		value, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid outbox amount %q", amount)
+		}
+		total.Add(total, value)
+	}
+	return total, nil
+}
+
+// GetDue 获取已到处理时间、并且没有被其他分发器领取的待发送或待确认消息
+func (r *OutboxRepository) GetDue(now time.Time, limit int) ([]*models.OutboxMessage, error) {
+	var msgs []*models.OutboxMessage
+	err := r.db.Where("status IN ? AND next_attempt_at <= ?",
+		[]models.OutboxStatus{models.OutboxStatusPending, models.OutboxStatusSent}, now).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&msgs).Error
+	return msgs, err
+}
+
+// ClaimDue 领取一批到期的消息，返回本次领取成功的消息（重新读取的最新状态）
+// 每条消息用条件更新设置租约，多个分发器或重叠的DispatchDue不会同时处理同一条消息；
+// 处理完成后调用Release，分发器中途退出时租约到期后消息会被重新领取
+func (r *OutboxRepository) ClaimDue(now time.Time, limit int, lease time.Duration) ([]*models.OutboxMessage, error) {
+	due, err := r.GetDue(now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	until := now.Add(lease)
+	claimed := make([]*models.OutboxMessage, 0, len(due))
+	for _, msg := range due {
+		// 其他分发器已经处理过的消息会被重新排期，不再满足到期条件
+		result := r.db.Model(&models.OutboxMessage{}).
+			Where("id = ? AND status IN ? AND next_attempt_at <= ?", msg.ID,
+				[]models.OutboxStatus{models.OutboxStatusPending, models.OutboxStatusSent}, now).
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			Update("locked_until", until)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		fresh, err := r.GetByID(msg.ID)
+		if err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, fresh)
+	}
+	return claimed, nil
+}
+
+// Release 释放领取消息时设置的租约
+func (r *OutboxRepository) Release(id uint) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Update("locked_until", nil).Error
+}
+
+// MarkSent 在广播之前记录已签名交易的哈希和nonce
+// 之后即使广播结果未知或者进程退出，下一轮也只会按哈希检查收据，而不会重新签名发送
+func (r *OutboxRepository) MarkSent(id uint, txHash string, nonce uint64, rawTx string, nextCheckAt time.Time) error {
+	return r.recordTransaction(id, txHash, nonce, rawTx, map[string]interface{}{
+		"status":          models.OutboxStatusSent,
+		"tx_hash":         txHash,
+		"nonce":           nonce,
+		"sent_at":         time.Now(),
+		"next_attempt_at": nextCheckAt,
+		"last_error":      nil,
+	})
+}
+
+// MarkReplaced 在广播之前记录替换卡住交易的新交易，原交易保留在交易记录中
+func (r *OutboxRepository) MarkReplaced(id uint, txHash string, nonce uint64, rawTx string, nextCheckAt time.Time) error {
+	return r.recordTransaction(id, txHash, nonce, rawTx, map[string]interface{}{
+		"tx_hash":         txHash,
+		"sent_at":         time.Now(),
+		"next_attempt_at": nextCheckAt,
+	})
+}
+
+// recordTransaction 在同一个事务中更新消息并追加交易记录
+func (r *OutboxRepository) recordTransaction(id uint, txHash string, nonce uint64, rawTx string, updates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OutboxTransaction{
+			MessageID: id,
+			TxHash:    txHash,
+			Nonce:     nonce,
+			RawTx:     rawTx,
+		}).Error
+	})
+}
+
+// Transactions 获取消息广播过的交易，按广播顺序
+func (r *OutboxRepository) Transactions(id uint) ([]*models.OutboxTransaction, error) {
+	var txs []*models.OutboxTransaction
+	err := r.db.Where("message_id = ?", id).Order("id ASC").Find(&txs).Error
+	return txs, err
+}
+
+// AdoptTransaction 同一个nonce之前广播的交易先被打包时，改为跟踪这一笔
+func (r *OutboxRepository) AdoptTransaction(id uint, txHash string) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Update("tx_hash", txHash).Error
+}
+
+// MarkMined 记录交易已被打包但尚未达到确认数
+func (r *OutboxRepository) MarkMined(id uint, blockNumber uint64, blockHash string, nextCheckAt time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"block_number":    blockNumber,
+		"block_hash":      blockHash,
+		"next_attempt_at": nextCheckAt,
+	}).Error
+}
+
+// MarkReorged 清除被重组掉的区块信息，交易重新等待打包
+func (r *OutboxRepository) MarkReorged(id uint, nextCheckAt time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"block_number":    nil,
+		"block_hash":      nil,
+		"sent_at":         time.Now(),
+		"next_attempt_at": nextCheckAt,
+	}).Error
+}
+
+// MarkConfirmed 记录交易收据并将消息标记为已确认；任务奖励的铸币同时追加到任务历史，
+// 兑换的批准和退还同时记录到兑换的结算交易
+// 返回铸币对应的任务（只包含ID、状态、家庭和孩子），不是任务奖励时返回nil
+func (r *OutboxRepository) MarkConfirmed(msg *models.OutboxMessage, blockNumber uint64, blockHash string, gasUsed uint64, receiptStatus uint64) (*models.Task, error) {
+	var task *models.Task
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OutboxMessage{}).Where("id = ?", msg.ID).Updates(map[string]interface{}{
+			"status":         models.OutboxStatusConfirmed,
+			"block_number":   blockNumber,
+			"block_hash":     blockHash,
+			"gas_used":       gasUsed,
+			"receipt_status": receiptStatus,
+			"confirmed_at":   time.Now(),
+			"last_error":     nil,
+		}).Error; err != nil {
+			return err
+		}
+		if (msg.Kind == models.OutboxKindFulfillExchange || msg.Kind == models.OutboxKindRefundExchange ||
+			msg.Kind == models.OutboxKindBurnExchange) && msg.ExchangeID != nil {
+			return tx.Model(&models.Exchange{}).Where("id = ?", *msg.ExchangeID).Updates(map[string]interface{}{
+				"settlement_tx_hash": msg.TxHash,
+				"updated_at":         time.Now(),
+			}).Error
+		}
+		if msg.Kind != models.OutboxKindMintReward || msg.TaskID == nil {
+			return nil
+		}
+
+		task = &models.Task{}
+		if err := tx.Unscoped().Select("id", "status", "family_id", "assigned_child_id").First(task, *msg.TaskID).Error; err != nil {
+			return err
+		}
+		return NewTaskEventRepository(tx).Create(&models.TaskEvent{
+			TaskID:     task.ID,
+			Action:     models.TaskEventRewardMinted,
+			Source:     models.TaskEventSourceOutbox,
+			FromStatus: task.Status,
+			ToStatus:   task.Status,
+			TxHash:     msg.TxHash,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Reschedule 推迟下一次检查时间（例如交易尚未被打包），不计入失败次数
+func (r *OutboxRepository) Reschedule(id uint, nextAttemptAt time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).
+		Update("next_attempt_at", nextAttemptAt).Error
+}
+
+// RecordError 记录不计入尝试次数的错误，消息在nextAttemptAt之后重新处理
+func (r *OutboxRepository) RecordError(id uint, lastError string, nextAttemptAt time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+	}).Error
+}
+
+// MarkAttemptFailed 记录一次失败尝试；clearTx为true时丢弃已记录的交易，下次重新发送
+// 代孩子托管代币的消息失败时代币仍在孩子的钱包中，同一个事务中改写这次兑换还没有发送的结算
+func (r *OutboxRepository) MarkAttemptFailed(id uint, attempts int, lastError string, status models.OutboxStatus, nextAttemptAt time.Time, clearTx bool) error {
+	updates := map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+	}
+	if clearTx {
+		updates["tx_hash"] = nil
+		updates["nonce"] = nil
+		updates["sent_at"] = nil
+		updates["block_number"] = nil
+		updates["block_hash"] = nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if status != models.OutboxStatusFailed {
+			return nil
+		}
+		var msg models.OutboxMessage
+		if err := tx.First(&msg, id).Error; err != nil {
+			return err
+		}
+		if msg.Kind != models.OutboxKindEscrowExchange || msg.ExchangeID == nil {
+			return nil
+		}
+		return NewOutboxRepository(tx).SettleWithoutEscrow(*msg.ExchangeID)
+	})
+}
+
+// SettleWithoutEscrow 改写托管失败的兑换还在排队的结算：批准改为从孩子的钱包销毁代币，退还不再需要发送
+func (r *OutboxRepository) SettleWithoutEscrow(exchangeID uint) error {
+	if err := r.db.Model(&models.OutboxMessage{}).
+		Where("exchange_id = ? AND kind = ? AND status = ?", exchangeID, models.OutboxKindFulfillExchange, models.OutboxStatusPending).
+		Updates(map[string]interface{}{
+			"kind":            models.OutboxKindBurnExchange,
+			"attempts":        0,
+			"last_error":      nil,
+			"next_attempt_at": time.Now(),
+		}).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&models.OutboxMessage{}).
+		Where("exchange_id = ? AND kind = ? AND status = ?", exchangeID, models.OutboxKindRefundExchange, models.OutboxStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.OutboxStatusCancelled,
+			"last_error": "escrow failed, no tokens to refund",
+		}).Error
+}
+
+// WithTransaction 在事务中执行操作
+func (r *OutboxRepository) WithTransaction(fn func(*OutboxRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := &OutboxRepository{db: tx}
+		return fn(txRepo)
+	})
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RewardProposalRepository 孩子提出的奖品愿望的数据库操作
+type RewardProposalRepository struct {
+	db *gorm.DB
+}
+
+// NewRewardProposalRepository 创建一个新的RewardProposalRepository实例
+func NewRewardProposalRepository(db *gorm.DB) *RewardProposalRepository {
+	return &RewardProposalRepository{db: db}
+}
+
+// Create 记录一个新的愿望
+func (r *RewardProposalRepository) Create(proposal *models.RewardProposal) error {
+	proposal.Status = models.RewardProposalPending
+	return r.db.Create(proposal).Error
+}
+
+// GetByID 根据ID获取愿望，附带孩子和接受后创建的奖品
+func (r *RewardProposalRepository) GetByID(id uint) (*models.RewardProposal, error) {
+	var proposal models.RewardProposal
+	if err := r.db.Preload("Child").Preload("Reward").First(&proposal, id).Error; err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// ListByFamily 获取家庭的愿望，最新的在前；status为空时返回全部
+func (r *RewardProposalRepository) ListByFamily(familyID uint, status models.RewardProposalStatus) ([]*models.RewardProposal, error) {
+	query := r.db.Preload("Child").Preload("Reward").Where("family_id = ?", familyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var proposals []*models.RewardProposal
+	err := query.Order("id DESC").Find(&proposals).Error
+	return proposals, err
+}
+
+// ListByChild 获取孩子的愿望，最新的在前
+func (r *RewardProposalRepository) ListByChild(childID uint) ([]*models.RewardProposal, error) {
+	var proposals []*models.RewardProposal
+	err := r.db.Preload("Reward").Where("child_id = ?", childID).Order("id DESC").Find(&proposals).Error
+	return proposals, err
+}
+
+// CountPending 统计孩子等待处理的愿望
+func (r *RewardProposalRepository) CountPending(childID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RewardProposal{}).
+		Where("child_id = ? AND status = ?", childID, models.RewardProposalPending).
+		Count(&count).Error
+	return count, err
+}
+
+// Accept 在事务中把待处理的愿望标记为已接受并创建奖品
+// 愿望已经被其他请求处理时不创建奖品并返回false
+func (r *RewardProposalRepository) Accept(proposal *models.RewardProposal, reward *models.Reward, actor, note string) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		ok, err := decideProposal(tx, proposal.ID, map[string]interface{}{
+			"status":        models.RewardProposalAccepted,
+			"decided_by":    actor,
+			"decision_note": note,
+		})
+		if err != nil || !ok {
+			return err
+		}
+		if err := tx.Create(reward).Error; err != nil {
+			return err
+		}
+		updated = true
+		return tx.Model(&models.RewardProposal{}).Where("id = ?", proposal.ID).Update("reward_id", reward.ID).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+// Decline 把待处理的愿望标记为已拒绝，愿望已经被其他请求处理时返回false
+func (r *RewardProposalRepository) Decline(proposal *models.RewardProposal, actor, note string) (bool, error) {
+	return decideProposal(r.db, proposal.ID, map[string]interface{}{
+		"status":        models.RewardProposalDeclined,
+		"decided_by":    actor,
+		"decision_note": note,
+	})
+}
+
+// decideProposal 只在愿望仍然待处理时更新
+func decideProposal(db *gorm.DB, id uint, updates map[string]interface{}) (bool, error) {
+	now := time.Now()
+	updates["decided_at"] = now
+	updates["updated_at"] = now
+	result := db.Model(&models.RewardProposal{}).
+		Where("id = ? AND status = ?", id, models.RewardProposalPending).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
 	"eth-for-babies-backend/internal/models"
@@ -35,6 +36,16 @@ func (r *RewardRepository) GetByID(id uint) (*models.Reward, error) {
 	return &reward, nil
 }
 
+// GetByContractRewardID 根据链上奖品ID获取奖品
+func (r *RewardRepository) GetByContractRewardID(contractRewardID uint) (*models.Reward, error) {
+	var reward models.Reward
+	err := r.db.Where("contract_reward_id = ?", contractRewardID).First(&reward).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reward, nil
+}
+
 // GetByFamilyID 根据家庭ID获取奖品列表
 func (r *RewardRepository) GetByFamilyID(familyID uint, activeOnly bool) ([]*models.Reward, error) {
 	var rewards []*models.Reward
@@ -55,35 +66,48 @@ func (r *RewardRepository) Update(id uint, updates map[string]interface{}) error
 }
 
 // Delete 删除奖品
+// 孩子为这个奖品设置的进行中的储蓄目标同时取消，锁定的代币回到可用余额
 func (r *RewardRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Reward{}, id).Error
-}
-
-// UpdateStock 更新奖品库存
-func (r *RewardRepository) UpdateStock(id uint, stockChange int) error {
-	var reward models.Reward
-
-	// 使用事务确保库存更新的原子性
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 查询当前库存
-		if err := tx.First(&reward, id).Error; err != nil {
+		now := time.Now()
+		if err := tx.Model(&models.SavingsGoal{}).
+			Where("reward_id = ? AND status = ?", id, models.SavingsGoalActive).
+			Updates(map[string]interface{}{
+				"status":     models.SavingsGoalCancelled,
+				"closed_at":  now,
+				"updated_at": now,
+			}).Error; err != nil {
 			return err
 		}
+		return tx.Delete(&models.Reward{}, id).Error
+	})
+}
 
-		// 计算新库存
-		newStock := reward.Stock + stockChange
-
-		// 检查库存是否足够
-		if newStock < 0 {
-			return gorm.ErrInvalidData
-		}
+// ErrOutOfStock 奖品库存不足
+var ErrOutOfStock = errors.New("reward is out of stock")
 
-		// 更新库存
-		return tx.Model(&models.Reward{}).Where("id = ?", id).Updates(map[string]interface{}{
-			"stock":      newStock,
+// UpdateStock 更新奖品库存
+// 库存在一条条件UPDATE中修改，并发扣减最后一件时只有一个成功，其余返回ErrOutOfStock
+func (r *RewardRepository) UpdateStock(id uint, stockChange int) error {
+	result := r.db.Model(&models.Reward{}).
+		Where("id = ? AND stock + ? >= 0", id, stockChange).
+		Updates(map[string]interface{}{
+			"stock":      gorm.Expr("stock + ?", stockChange),
 			"updated_at": time.Now(),
-		}).Error
-	})
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil
+	}
+
+	// 区分奖品不存在和库存不足
+	var reward models.Reward
+	if err := r.db.Select("id").First(&reward, id).Error; err != nil {
+		return err
+	}
+	return ErrOutOfStock
 }
 
 // WithTransaction 在事务中执行操作
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrSavingsGoalExists 孩子已经有这个奖品的储蓄目标
+var ErrSavingsGoalExists = errors.New("an active savings goal for this reward already exists")
+
+// SavingsGoalRepository 储蓄目标的数据库操作
+type SavingsGoalRepository struct {
+	db *gorm.DB
+}
+
+// NewSavingsGoalRepository 创建一个新的SavingsGoalRepository实例
+func NewSavingsGoalRepository(db *gorm.DB) *SavingsGoalRepository {
+	return &SavingsGoalRepository{db: db}
+}
+
+// Create 创建储蓄目标，孩子已经有同一个奖品的进行中目标时返回ErrSavingsGoalExists
+func (r *SavingsGoalRepository) Create(goal *models.SavingsGoal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.SavingsGoal{}).
+			Where("child_id = ? AND reward_id = ? AND status = ?", goal.ChildID, goal.RewardID, models.SavingsGoalActive).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrSavingsGoalExists
+		}
+		goal.Status = models.SavingsGoalActive
+		return tx.Create(goal).Error
+	})
+}
+
+// GetByID 根据ID获取储蓄目标，附带奖品和配捐记录
+func (r *SavingsGoalRepository) GetByID(id uint) (*models.SavingsGoal, error) {
+	var goal models.SavingsGoal
+	err := r.db.Preload("Reward").Preload("Contributions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&goal, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// ListByChild 获取孩子的储蓄目标，最新的在前；activeOnly为true时只返回进行中的
+func (r *SavingsGoalRepository) ListByChild(childID uint, activeOnly bool) ([]*models.SavingsGoal, error) {
+	query := r.db.Preload("Reward").Where("child_id = ?", childID)
+	if activeOnly {
+		query = query.Where("status = ?", models.SavingsGoalActive)
+	}
+	var goals []*models.SavingsGoal
+	err := query.Order("id DESC").Find(&goals).Error
+	return goals, err
+}
+
+// Available 孩子可以自由使用的余额（wei）：账本余额减去托管中、兑换扣留的代币和所有目标锁定的代币，可能为负
+func (r *SavingsGoalRepository) Available(childID uint, account string) (*big.Int, error) {
+	return spendableBalance(r.db, childID, account, 0)
+}
+
+// Lock 把孩子的代币锁定到目标中，amount为负时解锁
+// 锁定时在事务中核对可用余额，不足时返回ErrInsufficientBalance；checkBalance为false时不核对
+// 锁定后目标中的代币不能超过limit；目标已经结束或者数量超出范围时不做修改并返回false
+func (r *SavingsGoalRepository) Lock(goal *models.SavingsGoal, account string, amount, limit int, checkBalance bool) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if amount > 0 && checkBalance {
+			available, err := spendableBalance(tx, goal.ChildID, account, 0)
+			if err != nil {
+				return err
+			}
+			if available.Cmp(utils.UnitsToTokenAmount(amount)) < 0 {
+				return ErrInsufficientBalance
+			}
+		}
+
+		result := tx.Model(&models.SavingsGoal{}).
+			Where("id = ? AND status = ?", goal.ID, models.SavingsGoalActive).
+			Where("locked_amount + ? >= 0 AND locked_amount + matched_amount + ? <= ?", amount, amount, limit).
+			Updates(map[string]interface{}{
+				"locked_amount": gorm.Expr("locked_amount + ?", amount),
+				"updated_at":    time.Now(),
+			})
+		updated = result.RowsAffected > 0
+		return result.Error
+	})
+	return updated, err
+}
+
+// AddContribution 在事务中记录家长的配捐并锁定到目标中，mint不为nil时同时写入铸造配捐代币的链上消息
+// 目标已经结束或者配捐后超过limit时不做修改并返回false
+func (r *SavingsGoalRepository) AddContribution(goal *models.SavingsGoal, contribution *models.SavingsContribution, mint *models.OutboxMessage, limit int) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.SavingsGoal{}).
+			Where("id = ? AND status = ?", goal.ID, models.SavingsGoalActive).
+			Where("locked_amount + matched_amount + ? <= ?", contribution.Amount, limit).
+			Updates(map[string]interface{}{
+				"matched_amount": gorm.Expr("matched_amount + ?", contribution.Amount),
+				"updated_at":     time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		updated = true
+
+		if mint != nil {
+			if err := NewOutboxRepository(tx).Create(mint); err != nil {
+				return err
+			}
+			contribution.OutboxMessageID = &mint.ID
+		}
+		contribution.GoalID = goal.ID
+		return tx.Create(contribution).Error
+	})
+	return updated, err
+}
+
+// Close 结束进行中的目标，锁定的代币回到可用余额；目标已经结束时返回false
+func (r *SavingsGoalRepository) Close(goalID uint, status models.SavingsGoalStatus) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.SavingsGoal{}).
+		Where("id = ? AND status = ?", goalID, models.SavingsGoalActive).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"closed_at":  now,
+			"updated_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// redeem 孩子兑换了目标奖品，在兑换的事务中结束对应的目标
+func (r *SavingsGoalRepository) redeem(exchange *models.Exchange) error {
+	now := time.Now()
+	return r.db.Model(&models.SavingsGoal{}).
+		Where("child_id = ? AND reward_id = ? AND status = ?", exchange.ChildID, exchange.RewardID, models.SavingsGoalActive).
+		Updates(map[string]interface{}{
+			"status":      models.SavingsGoalRedeemed,
+			"exchange_id": exchange.ID,
+			"closed_at":   now,
+			"updated_at":  now,
+		}).Error
+}
+
+// spendableBalance 孩子的账本余额减去已经排队、或者已经上链但账本还没有同步的托管和销毁，
+// 减去待审核兑换在账本中扣留的代币，再减去进行中的储蓄目标锁定的代币（exceptRewardID对应奖品的目标除外），结果可能为负
+func spendableBalance(db *gorm.DB, childID uint, account string, exceptRewardID uint) (*big.Int, error) {
+	balance, err := NewLedgerRepository(db).Balance(strings.ToLower(account))
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := NewOutboxRepository(db).SpendingInFlight(childID)
+	if err != nil {
+		return nil, err
+	}
+	held, err := NewExchangeRepository(db).HeldInLedger(childID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reserved int64
+	if err := db.Model(&models.SavingsGoal{}).
+		Select("COALESCE(SUM(locked_amount + matched_amount), 0)").
+		Where("child_id = ? AND status = ? AND reward_id <> ?", childID, models.SavingsGoalActive, exceptRewardID).
+		Scan(&reserved).Error; err != nil {
+		return nil, err
+	}
+
+	balance.Sub(balance, inFlight)
+	balance.Sub(balance, held)
+	return balance.Sub(balance, utils.UnitsToTokenAmount(int(reserved))), nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository 登录会话和刷新令牌的数据库操作
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建一个新的SessionRepository实例
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create 在同一个事务中创建会话和它的第一个刷新令牌
+func (r *SessionRepository) Create(session *models.Session, token *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Create(token).Error
+	})
+}
+
+// GetByID 根据ID获取会话
+func (r *SessionRepository) GetByID(id uint) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetRefreshTokenByHash 根据哈希获取刷新令牌，不存在时返回nil
+func (r *SessionRepository) GetRefreshTokenByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate 把旧刷新令牌标记为已使用并保存轮换出的新令牌
+// 旧令牌已被并发请求使用时返回false，调用方应视为令牌重用
+func (r *SessionRepository) Rotate(old *models.RefreshToken, next *models.RefreshToken, now time.Time) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", old.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+
+		next.SessionID = old.SessionID
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).Where("id = ?", old.ID).
+			Update("replaced_by_id", next.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Session{}).Where("id = ?", old.SessionID).
+			Update("last_used_at", now).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	return rotated, err
+}
+
+// Revoke 撤销会话
+func (r *SessionRepository) Revoke(id uint, reason string, now time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"revoked_reason": reason,
+		}).Error
+}
+
+// RevokeAllForUser 撤销用户的所有会话，返回撤销的数量
+func (r *SessionRepository) RevokeAllForUser(userID uint, reason string, now time.Time) (int64, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"revoked_reason": reason,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// IsActive 判断会话是否存在且未被撤销
+func (r *SessionRepository) IsActive(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TaskChecklistRepository 任务清单的数据库操作
+type TaskChecklistRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskChecklistRepository 创建一个新的TaskChecklistRepository实例
+func NewTaskChecklistRepository(db *gorm.DB) *TaskChecklistRepository {
+	return &TaskChecklistRepository{db: db}
+}
+
+// ListByTask 按顺序获取任务的清单
+func (r *TaskChecklistRepository) ListByTask(taskID uint) ([]models.TaskChecklistItem, error) {
+	var items []models.TaskChecklistItem
+	err := OrderedChecklist(r.db.Where("task_id = ?", taskID)).Find(&items).Error
+	return items, err
+}
+
+// GetItem 获取任务清单中的一项
+func (r *TaskChecklistRepository) GetItem(taskID, itemID uint) (*models.TaskChecklistItem, error) {
+	var item models.TaskChecklistItem
+	if err := r.db.Where("task_id = ?", taskID).First(&item, itemID).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Update 保存勾选状态等修改
+func (r *TaskChecklistRepository) Update(item *models.TaskChecklistItem) error {
+	return r.db.Save(item).Error
+}
+
+// CountUncheckedRequired 统计还没有勾选的必做步骤
+func (r *TaskChecklistRepository) CountUncheckedRequired(taskID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TaskChecklistItem{}).
+		Where("task_id = ? AND required = ? AND checked_at IS NULL", taskID, true).
+		Count(&count).Error
+	return count, err
+}
+
+// Replace 在一个事务中用新的清单替换任务的清单，并保存任务和追加任务历史
+// 带ID的项是对已有步骤的修改，保留勾选状态；不在新清单中的步骤被删除；Position按传入的顺序重新编号
+func (r *TaskChecklistRepository) Replace(task *models.Task, items []models.TaskChecklistItem, event *models.TaskEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		keep := make([]uint, 0, len(items))
+		for _, item := range items {
+			if item.ID != 0 {
+				keep = append(keep, item.ID)
+			}
+		}
+		query := tx.Where("task_id = ?", task.ID)
+		if len(keep) > 0 {
+			query = query.Where("id NOT IN ?", keep)
+		}
+		if err := query.Delete(&models.TaskChecklistItem{}).Error; err != nil {
+			return err
+		}
+
+		for i := range items {
+			items[i].TaskID = task.ID
+			items[i].Position = i
+			if err := tx.Save(&items[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(task).Update("checklist_required", task.ChecklistRequired).Error; err != nil {
+			return err
+		}
+		event.TaskID = task.ID
+		event.ToStatus = task.Status
+		return NewTaskEventRepository(tx).Create(event)
+	})
+}
+
+// OrderedChecklist 清单按步骤顺序返回，用于预加载任务的Checklist
+func OrderedChecklist(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, id ASC")
+}
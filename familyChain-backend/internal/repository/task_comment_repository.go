@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TaskCommentRepository 任务留言和已读记录的数据库操作
+type TaskCommentRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskCommentRepository 创建一个新的TaskCommentRepository实例
+func NewTaskCommentRepository(db *gorm.DB) *TaskCommentRepository {
+	return &TaskCommentRepository{db: db}
+}
+
+// Create 发表留言，作者的已读位置推进到这条留言，回复时之前的留言也算已读
+func (r *TaskCommentRepository) Create(comment *models.TaskComment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return NewTaskCommentRepository(tx).MarkRead(comment.TaskID, comment.AuthorAddress, comment.ID)
+	})
+}
+
+// GetByID 获取任务中的一条留言
+func (r *TaskCommentRepository) GetByID(taskID, id uint) (*models.TaskComment, error) {
+	var comment models.TaskComment
+	if err := r.db.Where("task_id = ?", taskID).First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListByTask 按发表顺序获取任务的留言
+func (r *TaskCommentRepository) ListByTask(taskID uint) ([]*models.TaskComment, error) {
+	var comments []*models.TaskComment
+	err := r.db.Where("task_id = ?", taskID).Order("id ASC").Find(&comments).Error
+	return comments, err
+}
+
+// Delete 删除留言
+func (r *TaskCommentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.TaskComment{}, id).Error
+}
+
+// MarkRead 把用户在任务中的已读位置推进到指定留言，已读位置只会前进
+func (r *TaskCommentRepository) MarkRead(taskID uint, walletAddress string, commentID uint) error {
+	read := models.TaskCommentRead{
+		TaskID:            taskID,
+		WalletAddress:     walletAddress,
+		LastReadCommentID: commentID,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "task_id"}, {Name: "wallet_address"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_comment_id": gorm.Expr("MAX(task_comment_reads.last_read_comment_id, excluded.last_read_comment_id)"),
+			"updated_at":           gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&read).Error
+}
+
+// LatestID 获取任务中最新一条留言的ID，没有留言时返回0
+func (r *TaskCommentRepository) LatestID(taskID uint) (uint, error) {
+	var id uint
+	err := r.db.Model(&models.TaskComment{}).Where("task_id = ?", taskID).
+		Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+// UnreadCounts 统计用户在taskIDs（ID列表或子查询）中每个任务的未读留言数，只返回有未读的任务
+// 用户自己发表的留言不算未读
+func (r *TaskCommentRepository) UnreadCounts(walletAddress string, taskIDs interface{}) ([]models.TaskUnreadComments, error) {
+	var counts []models.TaskUnreadComments
+	err := r.db.Model(&models.TaskComment{}).
+		Select("task_comments.task_id, COUNT(*) AS unread").
+		Joins("LEFT JOIN task_comment_reads ON task_comment_reads.task_id = task_comments.task_id AND task_comment_reads.wallet_address = ?", walletAddress).
+		Where("task_comments.task_id IN (?)", taskIDs).
+		Where("task_comments.author_address <> ?", walletAddress).
+		Where("task_comments.id > COALESCE(task_comment_reads.last_read_comment_id, 0)").
+		Group("task_comments.task_id").
+		Order("task_comments.task_id").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TaskEventRepository 任务历史的数据库操作，只提供追加和查询
+type TaskEventRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskEventRepository 创建一个新的TaskEventRepository实例
+func NewTaskEventRepository(db *gorm.DB) *TaskEventRepository {
+	return &TaskEventRepository{db: db}
+}
+
+// Create 追加一条记录，调用方传入事务中的仓库以保证与任务状态的修改原子提交
+func (r *TaskEventRepository) Create(event *models.TaskEvent) error {
+	if event.Source == "" {
+		event.Source = models.TaskEventSourceAPI
+	}
+	return r.db.Create(event).Error
+}
+
+// ListByTask 按发生顺序获取任务的历史
+func (r *TaskEventRepository) ListByTask(taskID uint) ([]*models.TaskEvent, error) {
+	var events []*models.TaskEvent
+	err := r.db.Where("task_id = ?", taskID).Order("created_at ASC, id ASC").Find(&events).Error
+	return events, err
+}
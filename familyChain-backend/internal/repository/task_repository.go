@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"eth-for-babies-backend/internal/models"
 	"gorm.io/gorm"
 )
@@ -18,6 +20,37 @@ func (r *TaskRepository) Create(task *models.Task) error {
 	return r.db.Create(task).Error
 }
 
+// CreateBatch 在同一个事务中创建多个任务并为每个任务追加创建记录，任何一个失败时全部回滚
+func (r *TaskRepository) CreateBatch(tasks []*models.Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		events := NewTaskEventRepository(tx)
+		for _, task := range tasks {
+			if err := tx.Omit("AssignedChild", "Creator", "Mint").Create(task).Error; err != nil {
+				return err
+			}
+			if err := events.Create(&models.TaskEvent{
+				TaskID:   task.ID,
+				Action:   models.TaskEventCreated,
+				Actor:    task.CreatedBy,
+				ToStatus: task.Status,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Events 返回使用同一个数据库连接（或事务）的任务历史仓库
+func (r *TaskRepository) Events() *TaskEventRepository {
+	return NewTaskEventRepository(r.db)
+}
+
+// Checklist 返回使用同一个数据库连接（或事务）的任务清单仓库
+func (r *TaskRepository) Checklist() *TaskChecklistRepository {
+	return NewTaskChecklistRepository(r.db)
+}
+
 // GetByID 根据ID获取任务
 func (r *TaskRepository) GetByID(id uint) (*models.Task, error) {
 	var task models.Task
@@ -28,6 +61,16 @@ func (r *TaskRepository) GetByID(id uint) (*models.Task, error) {
 	return &task, nil
 }
 
+// GetByContractTaskID 根据链上任务ID获取任务
+func (r *TaskRepository) GetByContractTaskID(contractTaskID uint64) (*models.Task, error) {
+	var task models.Task
+	err := r.db.Where("contract_task_id = ?", contractTaskID).First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
 // GetByCreator 根据创建者获取任务列表
 func (r *TaskRepository) GetByCreator(creatorAddress string) ([]*models.Task, error) {
 	var tasks []*models.Task
@@ -185,13 +228,35 @@ func (r *TaskRepository) GetTaskStatistics(creatorAddress string) (map[string]in
 	return stats, nil
 }
 
-// GetOverdueTasks 获取过期任务
-func (r *TaskRepository) GetOverdueTasks() ([]*models.Task, error) {
+// GetOverdueTasks 获取截止时间早于now且还没有提交的任务，已经过期过一次（迟交后被退回重做）的任务不再处理
+func (r *TaskRepository) GetOverdueTasks(now time.Time) ([]*models.Task, error) {
 	var tasks []*models.Task
-	err := r.db.Preload("Creator").Preload("AssignedChild").Where("due_date < NOW() AND status IN ?", []string{"pending", "in_progress"}).Find(&tasks).Error
+	err := r.db.Preload("Creator").Preload("AssignedChild").Where("due_date < ? AND expired_at IS NULL AND status IN ?", now, []string{"pending", "in_progress"}).Find(&tasks).Error
 	return tasks, err
 }
 
+// Expire 把还没有提交的任务标记为过期并追加过期记录，updates中是过期原因和减少后的奖励
+// 孩子可能在查询之后刚好提交了任务，此时不做修改并返回false
+func (r *TaskRepository) Expire(task *models.Task, updates map[string]interface{}, event *models.TaskEvent) (bool, error) {
+	expired := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		updates["status"] = models.TaskStatusExpired
+		result := tx.Model(&models.Task{}).
+			Where("id = ? AND expired_at IS NULL AND status IN ?", task.ID, []string{"pending", "in_progress"}).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		expired = true
+
+		event.TaskID = task.ID
+		event.FromStatus = task.Status
+		event.ToStatus = models.TaskStatusExpired
+		return NewTaskEventRepository(tx).Create(event)
+	})
+	return expired, err
+}
+
 // WithTransaction 在事务中执行操作
 func (r *TaskRepository) WithTransaction(fn func(*TaskRepository) error) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TaskSeriesRepository 重复任务模板的数据库操作
+type TaskSeriesRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskSeriesRepository 创建一个新的TaskSeriesRepository实例
+func NewTaskSeriesRepository(db *gorm.DB) *TaskSeriesRepository {
+	return &TaskSeriesRepository{db: db}
+}
+
+// Create 在同一个事务中创建重复任务和它分配的孩子
+func (r *TaskSeriesRepository) Create(series *models.TaskSeries, childIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Children", "Skips").Create(series).Error; err != nil {
+			return err
+		}
+		return replaceSeriesChildren(tx, series, childIDs)
+	})
+}
+
+// GetByID 根据ID获取重复任务，包含分配的孩子和跳过的日期
+func (r *TaskSeriesRepository) GetByID(id uint) (*models.TaskSeries, error) {
+	var series models.TaskSeries
+	if err := r.db.Preload("Children").Preload("Skips").First(&series, id).Error; err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// ListByFamilies 获取多个家庭的重复任务
+func (r *TaskSeriesRepository) ListByFamilies(familyIDs []uint) ([]*models.TaskSeries, error) {
+	var series []*models.TaskSeries
+	if len(familyIDs) == 0 {
+		return series, nil
+	}
+	err := r.db.Preload("Children").Preload("Skips").
+		Where("family_id IN ?", familyIDs).
+		Order("created_at DESC").
+		Find(&series).Error
+	return series, err
+}
+
+// ListActive 获取所有进行中的重复任务，供调度器生成实例
+func (r *TaskSeriesRepository) ListActive() ([]*models.TaskSeries, error) {
+	var series []*models.TaskSeries
+	err := r.db.Preload("Children").Preload("Skips").
+		Where("status = ?", models.TaskSeriesStatusActive).
+		Order("id").
+		Find(&series).Error
+	return series, err
+}
+
+// Update 保存修改后的模板，childIDs不为nil时同时替换分配的孩子
+func (r *TaskSeriesRepository) Update(series *models.TaskSeries, childIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Children", "Skips", "CreatedAt").Save(series).Error; err != nil {
+			return err
+		}
+		if childIDs == nil {
+			return nil
+		}
+		return replaceSeriesChildren(tx, series, childIDs)
+	})
+}
+
+// UpdateStatus 修改重复任务的状态
+func (r *TaskSeriesRepository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&models.TaskSeries{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// Skip 跳过某一次出现；如果该日期的任务已经生成且孩子还没有提交，一并删除
+func (r *TaskSeriesRepository) Skip(seriesID uint, date time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		skip := &models.TaskSeriesSkip{SeriesID: seriesID, OccurrenceDate: date}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(skip).Error; err != nil {
+			return err
+		}
+		return tx.Where("series_id = ? AND occurrence_date = ? AND status IN ? AND submitted_at IS NULL",
+			seriesID, date, []string{"pending", "in_progress"}).
+			Delete(&models.Task{}).Error
+	})
+}
+
+// Materialize 在同一个事务中保存某一次出现生成的任务及其创建记录，并记录已生成到的日期
+// 同一孩子同一日期的任务已存在时跳过，返回实际创建的任务
+func (r *TaskSeriesRepository) Materialize(seriesID uint, date time.Time, tasks []*models.Task) ([]*models.Task, error) {
+	var created []*models.Task
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		events := NewTaskEventRepository(tx)
+		for _, task := range tasks {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(task)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			created = append(created, task)
+			if err := events.Create(&models.TaskEvent{
+				TaskID:   task.ID,
+				Action:   models.TaskEventCreated,
+				Source:   models.TaskEventSourceScheduler,
+				ToStatus: task.Status,
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.TaskSeries{}).
+			Where("id = ? AND (generated_through IS NULL OR generated_through < ?)", seriesID, date).
+			Update("generated_through", date).Error
+	})
+	return created, err
+}
+
+func replaceSeriesChildren(tx *gorm.DB, series *models.TaskSeries, childIDs []uint) error {
+	if err := tx.Where("series_id = ?", series.ID).Delete(&models.TaskSeriesChild{}).Error; err != nil {
+		return err
+	}
+	series.Children = make([]models.TaskSeriesChild, 0, len(childIDs))
+	for _, childID := range childIDs {
+		series.Children = append(series.Children, models.TaskSeriesChild{SeriesID: series.ID, ChildID: childID})
+	}
+	if len(series.Children) == 0 {
+		return nil
+	}
+	return tx.Create(&series.Children).Error
+}
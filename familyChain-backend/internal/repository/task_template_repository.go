@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TaskTemplateRepository 任务模板的数据库操作
+type TaskTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskTemplateRepository 创建一个新的TaskTemplateRepository实例
+func NewTaskTemplateRepository(db *gorm.DB) *TaskTemplateRepository {
+	return &TaskTemplateRepository{db: db}
+}
+
+// Create 创建任务模板
+func (r *TaskTemplateRepository) Create(template *models.TaskTemplate) error {
+	return r.db.Create(template).Error
+}
+
+// GetByID 根据ID获取任务模板
+func (r *TaskTemplateRepository) GetByID(id uint) (*models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	if err := r.db.First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListAvailable 获取内置模板和指定家庭的模板，内置模板排在前面
+func (r *TaskTemplateRepository) ListAvailable(familyIDs []uint) ([]*models.TaskTemplate, error) {
+	var templates []*models.TaskTemplate
+	query := r.db.Where("family_id IS NULL")
+	if len(familyIDs) > 0 {
+		query = r.db.Where("family_id IS NULL OR family_id IN ?", familyIDs)
+	}
+	err := query.
+		Order("family_id IS NOT NULL, category, title").
+		Find(&templates).Error
+	return templates, err
+}
+
+// Update 保存修改后的模板
+func (r *TaskTemplateRepository) Update(template *models.TaskTemplate) error {
+	return r.db.Omit("CreatedAt").Save(template).Error
+}
+
+// Delete 删除任务模板，已经从模板创建的任务不受影响
+func (r *TaskTemplateRepository) Delete(id uint) error {
+	return r.db.Delete(&models.TaskTemplate{}, id).Error
+}
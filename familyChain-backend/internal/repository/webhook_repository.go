@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRepository webhook和投递记录的数据库操作
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建一个新的WebhookRepository实例
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create 登记webhook
+func (r *WebhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// GetByID 根据ID获取webhook
+func (r *WebhookRepository) GetByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListByFamilies 获取多个家庭的webhook
+func (r *WebhookRepository) ListByFamilies(familyIDs []uint) ([]*models.Webhook, error) {
+	webhooks := []*models.Webhook{}
+	if len(familyIDs) == 0 {
+		return webhooks, nil
+	}
+	err := r.db.Where("family_id IN ?", familyIDs).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// CountByFamily 统计家庭的webhook数量
+func (r *WebhookRepository) CountByFamily(familyID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Webhook{}).Where("family_id = ?", familyID).Count(&count).Error
+	return count, err
+}
+
+// ListActiveByFamily 获取家庭中启用的webhook
+func (r *WebhookRepository) ListActiveByFamily(familyID uint) ([]*models.Webhook, error) {
+	var webhooks []*models.Webhook
+	err := r.db.Where("family_id = ? AND active = ?", familyID, true).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Update 保存修改后的webhook
+func (r *WebhookRepository) Update(webhook *models.Webhook) error {
+	return r.db.Omit("CreatedAt").Save(webhook).Error
+}
+
+// Delete 删除webhook和它的投递记录
+func (r *WebhookRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Webhook{}, id).Error
+	})
+}
+
+// CreateDeliveries 把一个事件的投递加入队列
+func (r *WebhookRepository) CreateDeliveries(deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, delivery := range deliveries {
+		if delivery.Status == "" {
+			delivery.Status = models.WebhookDeliveryPending
+		}
+		if delivery.NextAttemptAt.IsZero() {
+			delivery.NextAttemptAt = now
+		}
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+// ListDeliveries 按时间倒序获取webhook最近的投递记录
+func (r *WebhookRepository) ListDeliveries(webhookID uint, limit int) ([]*models.WebhookDelivery, error) {
+	deliveries := []*models.WebhookDelivery{}
+	err := r.db.Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDue 获取已到投递时间的记录，webhook被停用后不再投递
+func (r *WebhookRepository) GetDue(now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Where("webhook_id IN (?)", r.db.Model(&models.Webhook{}).Select("id").Where("active = ?", true)).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// SaveAttempt 保存一次投递尝试的结果
+func (r *WebhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return r.db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"response_code":   delivery.ResponseCode,
+		"response_body":   delivery.ResponseBody,
+		"last_error":      delivery.LastError,
+		"duration_ms":     delivery.DurationMs,
+		"delivered_at":    delivery.DeliveredAt,
+	}).Error
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrLoginRejected 登录消息或签名校验未通过
+var ErrLoginRejected = errors.New("login rejected")
+
+// siweClockSkew 允许客户端与服务器之间的时钟误差
+const siweClockSkew = time.Minute
+
+// AuthService 处理Sign-In with Ethereum（EIP-4361）登录
+type AuthService struct {
+	nonceRepo *repository.AuthNonceRepository
+	cfg       config.AuthConfig
+	chainID   int64
+	now       func() time.Time
+}
+
+// NewAuthService 创建一个新的登录服务，chainID是登录消息中要求的链ID
+func NewAuthService(nonceRepo *repository.AuthNonceRepository, cfg config.AuthConfig, chainID int64) *AuthService {
+	if cfg.NonceTTL <= 0 {
+		cfg.NonceTTL = 5 * time.Minute
+	}
+	return &AuthService{
+		nonceRepo: nonceRepo,
+		cfg:       cfg,
+		chainID:   chainID,
+		now:       time.Now,
+	}
+}
+
+// IssueNonce 为钱包签发一次性nonce，并返回包含该nonce的待签名消息
+func (s *AuthService) IssueNonce(walletAddress string) (*utils.SiweMessage, error) {
+	if !utils.IsValidEthereumAddress(walletAddress) {
+		return nil, errors.New("invalid Ethereum address format")
+	}
+	wallet := strings.ToLower(walletAddress)
+	now := s.now().UTC().Truncate(time.Second)
+
+	// 顺便清理该钱包用过或过期的nonce
+	if err := s.nonceRepo.DeleteExpired(wallet, now); err != nil {
+		log.Printf("清理过期nonce失败: %v", err)
+	}
+
+	nonce, err := utils.GenerateNonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	expiresAt := now.Add(s.cfg.NonceTTL)
+	if err := s.nonceRepo.Create(&models.AuthNonce{
+		Nonce:         nonce,
+		WalletAddress: wallet,
+		ExpiresAt:     expiresAt,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save nonce: %w", err)
+	}
+
+	return &utils.SiweMessage{
+		Domain:         s.cfg.Domain,
+		Address:        common.HexToAddress(walletAddress),
+		Statement:      s.cfg.Statement,
+		URI:            s.cfg.URI,
+		Version:        utils.SiweVersion,
+		ChainID:        s.chainID,
+		Nonce:          nonce,
+		IssuedAt:       now,
+		ExpirationTime: &expiresAt,
+	}, nil
+}
+
+// VerifyLogin 解析并校验登录消息的每个字段和签名，成功后作废消息中的nonce
+// 校验未通过时返回包装了ErrLoginRejected的错误
+func (s *AuthService) VerifyLogin(message, signature string) (*utils.SiweMessage, error) {
+	msg, err := utils.ParseSiweMessage(message)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLoginRejected, err)
+	}
+
+	if msg.Domain != s.cfg.Domain {
+		return nil, fmt.Errorf("%w: domain mismatch", ErrLoginRejected)
+	}
+	if !sameOrigin(msg.URI, s.cfg.URI) {
+		return nil, fmt.Errorf("%w: URI mismatch", ErrLoginRejected)
+	}
+	if msg.ChainID != s.chainID {
+		return nil, fmt.Errorf("%w: chain ID mismatch", ErrLoginRejected)
+	}
+
+	now := s.now()
+	if msg.IssuedAt.After(now.Add(siweClockSkew)) {
+		return nil, fmt.Errorf("%w: message issued in the future", ErrLoginRejected)
+	}
+	if now.Sub(msg.IssuedAt) > s.cfg.NonceTTL+siweClockSkew {
+		return nil, fmt.Errorf("%w: message is too old", ErrLoginRejected)
+	}
+	if err := msg.ValidAt(now); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLoginRejected, err)
+	}
+
+	// 校验签名；模拟签名只在开发开关打开时接受
+	if utils.IsMockSignature(signature) {
+		if !s.cfg.AllowMockSignatures {
+			return nil, fmt.Errorf("%w: invalid signature", ErrLoginRejected)
+		}
+		log.Printf("警告: 钱包 %s 使用模拟签名登录（AUTH_ALLOW_MOCK_SIGNATURES已开启）", msg.Address.Hex())
+	} else {
+		valid, err := utils.VerifySignature(msg.Address.Hex(), message, signature)
+		if err != nil || !valid {
+			return nil, fmt.Errorf("%w: invalid signature", ErrLoginRejected)
+		}
+	}
+
+	// nonce只能使用一次
+	consumed, err := s.nonceRepo.Consume(msg.Nonce, strings.ToLower(msg.Address.Hex()), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume nonce: %w", err)
+	}
+	if !consumed {
+		return nil, fmt.Errorf("%w: nonce is invalid, expired or already used", ErrLoginRejected)
+	}
+
+	return msg, nil
+}
+
+// sameOrigin 判断两个URI的协议和主机是否相同
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
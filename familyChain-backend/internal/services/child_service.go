@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"eth-for-babies-backend/internal/models"
 	"eth-for-babies-backend/internal/repository"
@@ -13,13 +14,15 @@ type ChildService struct {
 	childRepo  *repository.ChildRepository
 	familyRepo *repository.FamilyRepository
 	taskRepo   *repository.TaskRepository
+	ledgerRepo *repository.LedgerRepository
 }
 
-func NewChildService(childRepo *repository.ChildRepository, familyRepo *repository.FamilyRepository, taskRepo *repository.TaskRepository) *ChildService {
+func NewChildService(childRepo *repository.ChildRepository, familyRepo *repository.FamilyRepository, taskRepo *repository.TaskRepository, ledgerRepo *repository.LedgerRepository) *ChildService {
 	return &ChildService{
 		childRepo:  childRepo,
 		familyRepo: familyRepo,
 		taskRepo:   taskRepo,
+		ledgerRepo: ledgerRepo,
 	}
 }
 
@@ -188,6 +191,17 @@ func (s *ChildService) GetChildProgress(id uint) (map[string]interface{}, error)
 		}
 	}
 
+	// 代币余额和累计收到的代币以账本为准
+	account := strings.ToLower(child.WalletAddress)
+	balance, err := s.ledgerRepo.Balance(account)
+	if err != nil {
+		return nil, err
+	}
+	minted, err := s.ledgerRepo.Minted(account)
+	if err != nil {
+		return nil, err
+	}
+
 	// 计算完成率
 	completionRate := 0.0
 	if taskStats["total"] > 0 {
@@ -204,7 +218,9 @@ func (s *ChildService) GetChildProgress(id uint) (map[string]interface{}, error)
 		},
 		"statistics": map[string]interface{}{
 			"tasks_completed": child.TotalTasksCompleted,
-			"total_rewards":   child.TotalRewardsEarned,
+			"total_rewards":   minted.String(),
+			"token_balance":   balance.String(),
+			"tokens_earned":   minted.String(),
 			"completion_rate": completionRate,
 		},
 		"task_breakdown": taskStats,
@@ -237,26 +253,6 @@ func (s *ChildService) getRecentTasks(tasks []*models.Task, limit int) []map[str
 	return recentTasks
 }
 
-// ValidateChildAccess 验证用户是否有权限访问孩子信息
-func (s *ChildService) ValidateChildAccess(childID uint, userAddress string, userRole string) error {
-	child, err := s.childRepo.GetByID(childID)
-	if err != nil {
-		return err
-	}
-
-	// 家长可以访问自己的孩子
-	if userRole == "parent" && child.ParentAddress == userAddress {
-		return nil
-	}
-
-	// 孩子可以访问自己的信息
-	if userRole == "child" && child.WalletAddress == userAddress {
-		return nil
-	}
-
-	return errors.New("access denied")
-}
-
 // UpdateChildStatistics 更新孩子的统计信息
 func (s *ChildService) UpdateChildStatistics(childID uint, tasksCompleted int, rewardAmount string) error {
 	updates := map[string]interface{}{
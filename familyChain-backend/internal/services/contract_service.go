@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
@@ -17,13 +16,11 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type ContractService struct {
 	client         *ethclient.Client
-	privateKey     *ecdsa.PrivateKey
 	config         *config.BlockchainConfig
 	contractClient *blockchain.ContractManager
 }
@@ -35,18 +32,8 @@ func NewContractService(cfg *config.BlockchainConfig, contractClient *blockchain
 		return nil, errors.New("failed to connect to Ethereum client: " + err.Error())
 	}
 
-	// 解析私钥
-	var privateKey *ecdsa.PrivateKey
-	if cfg.PrivateKey != "" {
-		privateKey, err = crypto.HexToECDSA(strings.TrimPrefix(cfg.PrivateKey, "0x"))
-		if err != nil {
-			return nil, errors.New("failed to parse private key: " + err.Error())
-		}
-	}
-
 	return &ContractService{
 		client:         client,
-		privateKey:     privateKey,
 		config:         cfg,
 		contractClient: contractClient,
 	}, nil
@@ -147,55 +134,23 @@ func (s *ContractService) GetContractAddresses() map[string]string {
 	return map[string]string{}
 }
 
-// TransferETH 转移ETH
+// TransferETH 转移ETH，由合约管理器的签名器签名
 func (s *ContractService) TransferETH(to string, amount *big.Int) (*types.Transaction, error) {
-	if s.privateKey == nil {
-		return nil, errors.New("private key not configured")
+	if s.contractClient == nil {
+		return nil, errors.New("signer not configured")
 	}
 
 	if !utils.IsValidEthereumAddress(to) {
 		return nil, errors.New("invalid recipient address")
 	}
 
-	// 获取发送者地址
-	fromAddress := crypto.PubkeyToAddress(s.privateKey.PublicKey)
-
-	// 获取nonce
-	nonce, err := s.client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		return nil, errors.New("failed to get nonce: " + err.Error())
-	}
-
-	// 获取gas价格
-	gasPrice, err := s.client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, errors.New("failed to get gas price: " + err.Error())
-	}
-
-	// 创建交易
-	toAddress := common.HexToAddress(to)
-	tx := types.NewTransaction(nonce, toAddress, amount, 21000, gasPrice, nil)
-
-	// 签名交易
-	chainID := big.NewInt(s.config.ChainID)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), s.privateKey)
-	if err != nil {
-		return nil, errors.New("failed to sign transaction: " + err.Error())
-	}
-
-	// 发送交易
-	err = s.client.SendTransaction(context.Background(), signedTx)
-	if err != nil {
-		return nil, errors.New("failed to send transaction: " + err.Error())
-	}
-
-	return signedTx, nil
+	return s.contractClient.TransferETH(common.HexToAddress(to), amount)
 }
 
 // TransferToken 转移代币
 func (s *ContractService) TransferToken(tokenAddress, to string, amount *big.Int) (*types.Transaction, error) {
-	if s.privateKey == nil {
-		return nil, errors.New("private key not configured")
+	if s.contractClient == nil {
+		return nil, errors.New("signer not configured")
 	}
 
 	if !utils.IsValidEthereumAddress(tokenAddress) || !utils.IsValidEthereumAddress(to) {
@@ -244,13 +199,14 @@ func (s *ContractService) EstimateGas(to string, data []byte) (uint64, error) {
 		return 0, errors.New("invalid recipient address")
 	}
 
-	fromAddress := crypto.PubkeyToAddress(s.privateKey.PublicKey)
 	toAddress := common.HexToAddress(to)
 
 	msg := ethereum.CallMsg{
-		From: fromAddress,
-		To:   &toAddress,
 		Data: data,
+		To:   &toAddress,
+	}
+	if s.contractClient != nil {
+		msg.From = s.contractClient.SignerAddress()
 	}
 
 	gasLimit, err := s.client.EstimateGas(context.Background(), msg)
@@ -279,14 +235,13 @@ func (s *ContractService) GetBlockNumber() (uint64, error) {
 	return blockNumber, nil
 }
 
-// CreateTransactor 创建交易器
+// CreateTransactor 创建交易器，nonce由合约管理器预留；没有发送交易时需要调用ReleaseNonce归还
 func (s *ContractService) CreateTransactor() (*bind.TransactOpts, error) {
-	if s.privateKey == nil {
-		return nil, errors.New("private key not configured")
+	if s.contractClient == nil {
+		return nil, errors.New("signer not configured")
 	}
 
-	chainID := big.NewInt(s.config.ChainID)
-	auth, err := bind.NewKeyedTransactorWithChainID(s.privateKey, chainID)
+	auth, err := s.contractClient.GetTransactOpts(context.Background())
 	if err != nil {
 		return nil, errors.New("failed to create transactor: " + err.Error())
 	}
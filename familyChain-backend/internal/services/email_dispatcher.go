@@ -0,0 +1,135 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+)
+
+// Mailer 发送一封纯文本邮件
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer 通过SMTP服务器发送邮件，服务器支持时使用STARTTLS
+type SMTPMailer struct {
+	cfg config.SMTPConfig
+}
+
+// NewSMTPMailer 创建SMTP邮件发送器
+func NewSMTPMailer(cfg config.SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{cfg: cfg}
+}
+
+// Send 发送一封纯文本邮件
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	return smtp.SendMail(addr, auth, m.cfg.From, []string{to}, buildEmail(m.cfg.From, to, subject, body))
+}
+
+// buildEmail 生成邮件内容，去掉头部字段中的换行，避免头部注入
+func buildEmail(from, to, subject, body string) []byte {
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", clean.Replace(from))
+	fmt.Fprintf(&msg, "To: %s\r\n", clean.Replace(to))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", clean.Replace(subject)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return msg.Bytes()
+}
+
+// EmailDispatcher 在后台发送队列中的通知邮件，失败时按指数退避重试
+type EmailDispatcher struct {
+	notificationRepo *repository.NotificationRepository
+	mailer           Mailer
+	cfg              config.NotificationConfig
+}
+
+// NewEmailDispatcher 创建邮件分发器
+func NewEmailDispatcher(notificationRepo *repository.NotificationRepository, mailer Mailer, cfg config.NotificationConfig) *EmailDispatcher {
+	return &EmailDispatcher{
+		notificationRepo: notificationRepo,
+		mailer:           mailer,
+		cfg:              cfg,
+	}
+}
+
+// Run 循环发送到期的邮件，直到ctx被取消
+func (d *EmailDispatcher) Run(ctx context.Context) {
+	log.Printf("[email] 分发器已启动，轮询间隔: %v", d.cfg.PollInterval)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.DispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[email] 分发器已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue 发送一批到期的邮件
+func (d *EmailDispatcher) DispatchDue(ctx context.Context) {
+	emails, err := d.notificationRepo.GetDueEmails(time.Now(), d.cfg.BatchSize)
+	if err != nil {
+		log.Printf("[email] 查询待发送邮件失败: %v", err)
+		return
+	}
+
+	for _, email := range emails {
+		if ctx.Err() != nil {
+			return
+		}
+		d.recordAttempt(email, d.mailer.Send(email.To, email.Subject, email.Body))
+	}
+}
+
+// recordAttempt 记录一次发送尝试，失败时按退避时间重新排期，超过最大次数后标记为失败
+func (d *EmailDispatcher) recordAttempt(email *models.EmailMessage, cause error) {
+	now := time.Now()
+	email.Attempts++
+	email.LastError = nil
+
+	if cause == nil {
+		email.Status = models.EmailSent
+		email.SentAt = &now
+	} else {
+		message := cause.Error()
+		email.LastError = &message
+		email.Status = models.EmailPending
+		if email.Attempts >= d.cfg.MaxAttempts {
+			email.Status = models.EmailFailed
+		}
+		email.NextAttemptAt = now.Add(exponentialBackoff(d.cfg.BaseBackoff, d.cfg.MaxBackoff, email.Attempts))
+		log.Printf("[email] 邮件 %d 第 %d 次发送失败: %v，状态: %s", email.ID, email.Attempts, cause, email.Status)
+	}
+
+	if err := d.notificationRepo.SaveEmailAttempt(email); err != nil {
+		log.Printf("[email] 邮件 %d 发送结果写入失败: %v", email.ID, err)
+	}
+}
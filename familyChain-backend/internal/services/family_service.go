@@ -43,7 +43,7 @@ func (s *FamilyService) CreateFamily(family *models.Family) error {
 	// 清理输入数据
 	family.Name = utils.SanitizeString(family.Name)
 
-	return s.familyRepo.Create(family)
+	return s.familyRepo.CreateWithOwner(family)
 }
 
 // GetFamiliesByParent 获取家长的家庭
@@ -182,34 +182,6 @@ func (s *FamilyService) GetFamilyStatistics(familyID uint) (map[string]interface
 	return stats, nil
 }
 
-// ValidateFamilyAccess 验证用户是否有权限访问家庭
-func (s *FamilyService) ValidateFamilyAccess(familyID uint, userAddress string, userRole string) error {
-	family, err := s.familyRepo.GetByID(familyID)
-	if err != nil {
-		return err
-	}
-
-	// 家长可以访问自己的家庭
-	if userRole == "parent" && family.ParentAddress == userAddress {
-		return nil
-	}
-
-	// 孩子可以访问自己所属的家庭
-	if userRole == "child" {
-		children, err := s.childRepo.GetByFamilyID(familyID)
-		if err != nil {
-			return err
-		}
-		for _, child := range children {
-			if child.WalletAddress == userAddress {
-				return nil
-			}
-		}
-	}
-
-	return errors.New("access denied")
-}
-
 // UpdateFamilyStats 更新家庭统计信息
 func (s *FamilyService) UpdateFamilyStats(familyID uint) error {
 	// 检查家庭是否存在
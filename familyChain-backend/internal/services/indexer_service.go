@@ -0,0 +1,1208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+	"eth-for-babies-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+)
+
+// 索引进度使用的合约名称
+const (
+	IndexerContractTaskRegistry   = "task_registry"
+	IndexerContractRewardRegistry = "reward_registry"
+	IndexerContractRewardToken    = "reward_token"
+)
+
+// errChainReorg 表示检测到链重组并已回滚，本轮同步提前结束
+var errChainReorg = errors.New("chain reorganization detected")
+
+// taskStatusRank 任务状态的先后顺序，索引器只会把任务往后推进，不会回退数据库中更新的状态
+// 过期的任务与进行中同级：链上分配不会把它放回进行中，之后的状态只在允许迟交时接受
+var taskStatusRank = map[string]int{
+	"pending":                0,
+	"in_progress":            1,
+	models.TaskStatusExpired: 1,
+	"completed":              2,
+	"approved":               3,
+	"rejected":               3,
+}
+
+// taskStatusActions 链上状态事件在任务历史中对应的操作
+var taskStatusActions = map[string]models.TaskEventAction{
+	"completed": models.TaskEventSubmitted,
+	"approved":  models.TaskEventApproved,
+	"rejected":  models.TaskEventRejected,
+}
+
+// chainEvent 一条待写入数据库的合约事件
+type chainEvent struct {
+	raw   types.Log
+	name  string
+	apply func(j *effectJournal, blockTime time.Time) error
+}
+
+// indexerSource 一个被索引的合约
+type indexerSource struct {
+	name    string
+	address string
+	collect func(opts *bind.FilterOpts) ([]chainEvent, error)
+}
+
+// effectJournal 在事务中执行事件对数据库的修改，并记录修改前的值，区块被重组掉时据此撤销
+type effectJournal struct {
+	tx      *gorm.DB
+	effects []models.EventEffect
+}
+
+// update 修改一行数据，记录被修改列修改前后的值和类型
+// 写入后的值从数据库读回，回滚时据此判断这一列之后有没有被其他请求修改过
+func (j *effectJournal) update(table string, id uint, updates map[string]interface{}) error {
+	columns := make([]string, 0, len(updates))
+	for column := range updates {
+		columns = append(columns, column)
+	}
+
+	previous, err := readColumns(j.tx, table, id, columns)
+	if err != nil {
+		return err
+	}
+	if err := j.tx.Table(table).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return err
+	}
+	applied, err := readColumns(j.tx, table, id, columns)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make(map[string]models.EffectColumn, len(columns))
+	for _, column := range columns {
+		effectColumn, err := newEffectColumn(previous[column], applied[column])
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", table, column, err)
+		}
+		snapshot[column] = effectColumn
+	}
+
+	j.effects = append(j.effects, models.EventEffect{Table: table, RowID: id, Action: models.EventEffectUpdate, Columns: snapshot})
+	return nil
+}
+
+// created 记录事件新建的一行数据
+func (j *effectJournal) created(table string, id uint) {
+	j.effects = append(j.effects, models.EventEffect{Table: table, RowID: id, Action: models.EventEffectCreate})
+}
+
+// taskEvent 追加一条由链上事件引起的任务历史，所在区块被重组时随事件一起回滚
+func (j *effectJournal) taskEvent(event *models.TaskEvent, txHash string) error {
+	event.Source = models.TaskEventSourceChain
+	event.TxHash = &txHash
+	if err := repository.NewTaskEventRepository(j.tx).Create(event); err != nil {
+		return err
+	}
+	j.created(event.TableName(), event.ID)
+	return nil
+}
+
+// EventIndexer 把TaskRegistry/RewardRegistry的事件同步到数据库，并把RewardToken的转账记入代币账本
+// 首次运行时从配置的起始区块按区块范围回填，之后轮询新区块；
+// 使用Filter*而不是Watch*，因为订阅需要websocket连接，HTTP RPC节点不支持。
+// 只索引达到确认数的区块；记录最近区块的哈希，新区块的父哈希对不上时回滚被重组掉的区块中的事件
+type EventIndexer struct {
+	db              *gorm.DB
+	cursorRepo      *repository.ChainCursorRepository
+	contractManager *blockchain.ContractManager
+	cfg             config.IndexerConfig
+}
+
+// NewEventIndexer 创建链上事件索引器
+func NewEventIndexer(db *gorm.DB, contractManager *blockchain.ContractManager, cfg config.IndexerConfig) *EventIndexer {
+	if cfg.BlockRange == 0 {
+		cfg.BlockRange = 2000
+	}
+	if cfg.ReorgWindow == 0 {
+		cfg.ReorgWindow = 128
+	}
+	return &EventIndexer{
+		db:              db,
+		cursorRepo:      repository.NewChainCursorRepository(db),
+		contractManager: contractManager,
+		cfg:             cfg,
+	}
+}
+
+// Run 循环同步新区块，直到ctx被取消
+func (i *EventIndexer) Run(ctx context.Context) {
+	log.Printf("[indexer] 事件索引器已启动，轮询间隔: %v，区块范围: %d，确认数: %d",
+		i.cfg.PollInterval, i.cfg.BlockRange, i.contractManager.RequiredConfirmations())
+
+	ticker := time.NewTicker(i.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[indexer] 同步失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[indexer] 事件索引器已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync 把所有被索引合约同步到已达到确认数的最新区块
+func (i *EventIndexer) Sync(ctx context.Context) error {
+	head, err := i.contractManager.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	required := i.contractManager.RequiredConfirmations()
+	if head+1 < required {
+		return nil
+	}
+	safeHead := head + 1 - required
+
+	for _, src := range i.sources() {
+		err := i.syncSource(ctx, src, safeHead)
+		if errors.Is(err, errChainReorg) {
+			// 游标已回退，下一轮从共同祖先之后重新索引
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", src.name, err)
+		}
+	}
+
+	if safeHead > i.cfg.ReorgWindow {
+		if err := i.cursorRepo.PruneBefore(safeHead - i.cfg.ReorgWindow); err != nil {
+			log.Printf("[indexer] 清理旧区块记录失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// sources 返回已初始化的被索引合约
+func (i *EventIndexer) sources() []indexerSource {
+	addresses := i.contractManager.GetContractAddresses()
+
+	var sources []indexerSource
+	if i.contractManager.TaskRegistry != nil {
+		sources = append(sources, indexerSource{
+			name:    IndexerContractTaskRegistry,
+			address: strings.ToLower(addresses["task"]),
+			collect: i.collectTaskEvents,
+		})
+	}
+	if i.contractManager.RewardRegistry != nil {
+		sources = append(sources, indexerSource{
+			name:    IndexerContractRewardRegistry,
+			address: strings.ToLower(addresses["reward"]),
+			collect: i.collectRewardEvents,
+		})
+	}
+	if i.contractManager.RewardToken != nil {
+		sources = append(sources, indexerSource{
+			name:    IndexerContractRewardToken,
+			address: strings.ToLower(addresses["token"]),
+			collect: i.collectTokenEvents,
+		})
+	}
+	return sources
+}
+
+// syncSource 从上次处理的区块之后开始，按区块范围拉取并写入事件
+func (i *EventIndexer) syncSource(ctx context.Context, src indexerSource, safeHead uint64) error {
+	cursor, err := i.cursorRepo.Get(src.name)
+	if err != nil {
+		return fmt.Errorf("failed to load cursor: %w", err)
+	}
+
+	from := i.cfg.StartBlock
+	if cursor != nil {
+		if cursor.Address == src.address {
+			from = cursor.LastBlock + 1
+		} else {
+			// 合约重新部署后从头索引新地址
+			log.Printf("[indexer] %s 合约地址由 %s 变为 %s，从区块 %d 重新索引", src.name, cursor.Address, src.address, from)
+		}
+	}
+
+	for from <= safeHead {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		to := from + i.cfg.BlockRange - 1
+		if to > safeHead {
+			to = safeHead
+		}
+
+		// 检查范围内的每个区块都接在前一个区块之后，并且与已索引的同一区块一致
+		headers, err := i.rangeHeaders(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		reorged, err := i.detectReorg(headers)
+		if err != nil {
+			return err
+		}
+		if reorged {
+			if err := i.rollbackReorg(ctx); err != nil {
+				return fmt.Errorf("failed to roll back reorg: %w", err)
+			}
+			return errChainReorg
+		}
+
+		events, err := src.collect(&bind.FilterOpts{Start: from, End: &to, Context: ctx})
+		if err != nil {
+			return fmt.Errorf("failed to filter blocks %d-%d: %w", from, to, err)
+		}
+		sort.Slice(events, func(a, b int) bool {
+			if events[a].raw.BlockNumber != events[b].raw.BlockNumber {
+				return events[a].raw.BlockNumber < events[b].raw.BlockNumber
+			}
+			return events[a].raw.Index < events[b].raw.Index
+		})
+
+		// 事件、区块哈希与索引进度在同一个事务中提交，崩溃后会从上次提交的位置重新处理
+		err = i.db.Transaction(func(tx *gorm.DB) error {
+			cursorRepo := repository.NewChainCursorRepository(tx)
+
+			for _, ev := range events {
+				header := headers[ev.raw.BlockNumber-from]
+				if header.Hash() != ev.raw.BlockHash {
+					// 拉取日志和获取区块头之间发生了重组，下一轮重新处理
+					return fmt.Errorf("block %d changed while indexing", ev.raw.BlockNumber)
+				}
+
+				journal := &effectJournal{tx: tx}
+				if err := ev.apply(journal, time.Unix(int64(header.Time), 0)); err != nil {
+					return fmt.Errorf("failed to apply %s in tx %s: %w", ev.name, ev.raw.TxHash.Hex(), err)
+				}
+				if err := i.recordEvent(cursorRepo, src.name, ev, journal.effects); err != nil {
+					return err
+				}
+			}
+
+			for _, header := range headers {
+				if err := cursorRepo.SaveBlock(&models.IndexedBlock{
+					Number:     header.Number.Uint64(),
+					Hash:       header.Hash().Hex(),
+					ParentHash: header.ParentHash.Hex(),
+				}); err != nil {
+					return err
+				}
+			}
+			return cursorRepo.Save(src.name, src.address, to)
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(events) > 0 {
+			log.Printf("[indexer] %s 区块 %d-%d 已同步 %d 条事件", src.name, from, to, len(events))
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+// rangeHeaders 按顺序获取from到to的区块头，获取期间发生重组（区块不相连）时返回错误，下一轮重新处理
+func (i *EventIndexer) rangeHeaders(ctx context.Context, from, to uint64) ([]*types.Header, error) {
+	headers := make([]*types.Header, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		header, err := i.contractManager.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get header of block %d: %w", number, err)
+		}
+		if len(headers) > 0 && header.ParentHash != headers[len(headers)-1].Hash() {
+			return nil, fmt.Errorf("block %d changed while indexing", number-1)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
+// detectReorg 把区块头与已记录的区块比较：第一个区块的父哈希对不上前一个已索引区块，
+// 或者某个区块已被其他合约的索引记录过但哈希不同，说明已索引的区块被重组掉了
+func (i *EventIndexer) detectReorg(headers []*types.Header) (bool, error) {
+	from := headers[0].Number.Uint64()
+	to := headers[len(headers)-1].Number.Uint64()
+	first := from
+	if first > 0 {
+		first--
+	}
+	blocks, err := i.cursorRepo.GetBlocks(first, to)
+	if err != nil {
+		return false, err
+	}
+
+	for _, block := range blocks {
+		expected := ""
+		if block.Number < from {
+			expected = headers[0].ParentHash.Hex()
+		} else {
+			expected = headers[block.Number-from].Hash().Hex()
+		}
+		if block.Hash != expected {
+			log.Printf("[indexer] 区块 %d 的哈希 %s 与已索引的 %s 不一致", block.Number, expected, block.Hash)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordEvent 记录已写入的事件及其修改
+func (i *EventIndexer) recordEvent(cursorRepo *repository.ChainCursorRepository, contract string, ev chainEvent, effects []models.EventEffect) error {
+	data, err := json.Marshal(effects)
+	if err != nil {
+		return err
+	}
+	return cursorRepo.CreateEvent(&models.IndexedEvent{
+		Contract:    contract,
+		Name:        ev.name,
+		BlockNumber: ev.raw.BlockNumber,
+		BlockHash:   ev.raw.BlockHash.Hex(),
+		LogIndex:    ev.raw.Index,
+		TxHash:      ev.raw.TxHash.Hex(),
+		Effects:     string(data),
+	})
+}
+
+// rollbackReorg 找到仍在主链上的最近已索引区块，撤销其后所有事件对数据库的修改并回退游标
+func (i *EventIndexer) rollbackReorg(ctx context.Context) error {
+	blocks, err := i.cursorRepo.GetRecentBlocks(int(i.cfg.ReorgWindow) + 1)
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	var ancestor uint64
+	found := false
+	for _, block := range blocks {
+		header, err := i.contractManager.HeaderByNumber(ctx, new(big.Int).SetUint64(block.Number))
+		if err != nil {
+			return fmt.Errorf("failed to get header of block %d: %w", block.Number, err)
+		}
+		if header.Hash().Hex() == block.Hash {
+			ancestor = block.Number
+			found = true
+			break
+		}
+	}
+	if !found {
+		// 重组深度超过了保留的区块记录，只能回退到最早的记录之前
+		oldest := blocks[len(blocks)-1].Number
+		if oldest > 0 {
+			ancestor = oldest - 1
+		}
+		log.Printf("[indexer] 重组深度超过保留的 %d 个区块，回退到区块 %d，更早的数据可能不一致", len(blocks), ancestor)
+	}
+
+	return i.db.Transaction(func(tx *gorm.DB) error {
+		cursorRepo := repository.NewChainCursorRepository(tx)
+
+		events, err := cursorRepo.GetEventsAfter(ancestor)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := undoEvent(tx, event); err != nil {
+				return fmt.Errorf("failed to undo %s in tx %s: %w", event.Name, event.TxHash, err)
+			}
+		}
+
+		if err := cursorRepo.DeleteAfter(ancestor); err != nil {
+			return err
+		}
+		if err := cursorRepo.Rewind(ancestor); err != nil {
+			return err
+		}
+
+		log.Printf("[indexer] 链重组：回退到共同祖先区块 %d，撤销了 %d 条事件", ancestor, len(events))
+		return nil
+	})
+}
+
+// undoEvent 按相反顺序撤销一条事件的修改
+func undoEvent(tx *gorm.DB, event *models.IndexedEvent) error {
+	var effects []models.EventEffect
+	decoder := json.NewDecoder(strings.NewReader(event.Effects))
+	decoder.UseNumber()
+	if err := decoder.Decode(&effects); err != nil {
+		return err
+	}
+
+	for k := len(effects) - 1; k >= 0; k-- {
+		effect := effects[k]
+		switch effect.Action {
+		case models.EventEffectCreate:
+			// 表名来自索引器内部常量
+			if err := tx.Exec("DELETE FROM "+effect.Table+" WHERE id = ?", effect.RowID).Error; err != nil {
+				return err
+			}
+		case models.EventEffectUpdate:
+			restore, err := restorableColumns(tx, effect)
+			if err != nil {
+				return err
+			}
+			if len(restore) == 0 {
+				continue
+			}
+			if err := tx.Table(effect.Table).Where("id = ?", effect.RowID).Updates(restore).Error; err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown effect action %q", effect.Action)
+		}
+	}
+	return nil
+}
+
+// restorableColumns 返回仍然保存着事件写入值的列及其旧值
+// 事件之后被API等其他请求修改过的列保留新值，不会被回滚覆盖
+func restorableColumns(tx *gorm.DB, effect models.EventEffect) (map[string]interface{}, error) {
+	columns := make([]string, 0, len(effect.Columns))
+	for column := range effect.Columns {
+		columns = append(columns, column)
+	}
+	current, err := readColumns(tx, effect.Table, effect.RowID, columns)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	restore := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		snapshot := effect.Columns[column]
+		applied, err := effectValue(snapshot.Type, snapshot.Applied)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", effect.Table, column, err)
+		}
+		if !sameEffectValue(applied, current[column]) {
+			log.Printf("[indexer] %s %d 的 %s 在事件之后已被修改，回滚时保留当前值", effect.Table, effect.RowID, column)
+			continue
+		}
+		previous, err := effectValue(snapshot.Type, snapshot.Previous)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", effect.Table, column, err)
+		}
+		restore[column] = previous
+	}
+	return restore, nil
+}
+
+// readColumns 读取一行中指定列的值
+func readColumns(tx *gorm.DB, table string, id uint, columns []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if err := tx.Table(table).Select(columns).Where("id = ?", id).Take(&values).Error; err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// newEffectColumn 按数据库返回的值确定列的类型；修改前后有一个是NULL时按另一个的类型记录
+func newEffectColumn(previous, applied interface{}) (models.EffectColumn, error) {
+	previous, previousType, err := effectValueType(previous)
+	if err != nil {
+		return models.EffectColumn{}, err
+	}
+	applied, appliedType, err := effectValueType(applied)
+	if err != nil {
+		return models.EffectColumn{}, err
+	}
+
+	valueType := appliedType
+	if valueType == models.EffectValueNull {
+		valueType = previousType
+	} else if previousType != models.EffectValueNull && previousType != appliedType {
+		return models.EffectColumn{}, fmt.Errorf("type changed from %s to %s", previousType, appliedType)
+	}
+	return models.EffectColumn{Type: valueType, Previous: previous, Applied: applied}, nil
+}
+
+// effectValueType 返回数据库读出的值在快照中的类型
+func effectValueType(value interface{}) (interface{}, models.EffectValueType, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, models.EffectValueNull, nil
+	case int64:
+		return v, models.EffectValueInt, nil
+	case float64:
+		return v, models.EffectValueFloat, nil
+	case bool:
+		return v, models.EffectValueBool, nil
+	case string:
+		return v, models.EffectValueString, nil
+	case []byte:
+		return string(v), models.EffectValueString, nil
+	case time.Time:
+		return v, models.EffectValueTime, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported column value %T", value)
+	}
+}
+
+// effectValue 把JSON解码后的快照值还原为记录的类型
+func effectValue(valueType models.EffectValueType, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch valueType {
+	case models.EffectValueInt:
+		if n, ok := value.(json.Number); ok {
+			return n.Int64()
+		}
+	case models.EffectValueFloat:
+		if n, ok := value.(json.Number); ok {
+			return n.Float64()
+		}
+	case models.EffectValueBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case models.EffectValueString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case models.EffectValueTime:
+		if s, ok := value.(string); ok {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	}
+	return nil, fmt.Errorf("invalid %s value %v", valueType, value)
+}
+
+// sameEffectValue 比较快照中的值和数据库当前的值
+func sameEffectValue(snapshot, current interface{}) bool {
+	current, _, err := effectValueType(current)
+	if err != nil {
+		return false
+	}
+	if t, ok := snapshot.(time.Time); ok {
+		c, ok := current.(time.Time)
+		return ok && t.Equal(c)
+	}
+	return snapshot == current
+}
+
+// collectTaskEvents 拉取TaskRegistry的任务状态事件
+func (i *EventIndexer) collectTaskEvents(opts *bind.FilterOpts) ([]chainEvent, error) {
+	registry := i.contractManager.TaskRegistry
+	var events []chainEvent
+
+	created, err := registry.FilterTaskCreated(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for created.Next() {
+		ev := created.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "TaskCreated", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTaskCreated(j, ev.TaskId.Uint64(), strings.ToLower(ev.Creator.Hex()), ev.Title, ev.Reward, ev.Raw.TxHash.Hex())
+		}})
+	}
+	if err := created.Error(); err != nil {
+		return nil, err
+	}
+
+	assigned, err := registry.FilterTaskAssigned(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for assigned.Next() {
+		ev := assigned.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "TaskAssigned", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTaskAssigned(j, ev.TaskId.Uint64(), strings.ToLower(ev.AssignedTo.Hex()), ev.Raw.TxHash.Hex())
+		}})
+	}
+	if err := assigned.Error(); err != nil {
+		return nil, err
+	}
+
+	completed, err := registry.FilterTaskCompleted(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for completed.Next() {
+		ev := completed.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "TaskCompleted", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTaskStatus(j, ev.TaskId.Uint64(), "completed", "submitted_at", strings.ToLower(ev.CompletedBy.Hex()), ev.Raw.TxHash.Hex(), at)
+		}})
+	}
+	if err := completed.Error(); err != nil {
+		return nil, err
+	}
+
+	approved, err := registry.FilterTaskApproved(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for approved.Next() {
+		ev := approved.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "TaskApproved", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTaskStatus(j, ev.TaskId.Uint64(), "approved", "approved_at", strings.ToLower(ev.ApprovedBy.Hex()), ev.Raw.TxHash.Hex(), at)
+		}})
+	}
+	if err := approved.Error(); err != nil {
+		return nil, err
+	}
+
+	payouts, err := registry.FilterTaskApprovedWithPayout(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for payouts.Next() {
+		ev := payouts.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "TaskApprovedWithPayout", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTaskPayout(j, ev.TaskId.Uint64(), ev.Payout)
+		}})
+	}
+	if err := payouts.Error(); err != nil {
+		return nil, err
+	}
+
+	reworks, err := registry.FilterTaskReturnedForRework(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for reworks.Next() {
+		ev := reworks.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "TaskReturnedForRework", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTaskRework(j, ev.TaskId.Uint64(), strings.ToLower(ev.ReturnedBy.Hex()), ev.Raw.TxHash.Hex(), at)
+		}})
+	}
+	if err := reworks.Error(); err != nil {
+		return nil, err
+	}
+
+	rejected, err := registry.FilterTaskRejected(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for rejected.Next() {
+		ev := rejected.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "TaskRejected", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTaskStatus(j, ev.TaskId.Uint64(), "rejected", "rejected_at", strings.ToLower(ev.RejectedBy.Hex()), ev.Raw.TxHash.Hex(), at)
+		}})
+	}
+	if err := rejected.Error(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// collectRewardEvents 拉取RewardRegistry的兑换事件
+func (i *EventIndexer) collectRewardEvents(opts *bind.FilterOpts) ([]chainEvent, error) {
+	registry := i.contractManager.RewardRegistry
+	var events []chainEvent
+
+	exchanged, err := registry.FilterRewardExchanged(opts, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for exchanged.Next() {
+		ev := exchanged.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "RewardExchanged", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyRewardExchanged(j, ev.ExchangeId.Uint64(), ev.RewardId.Uint64(), strings.ToLower(ev.Child.Hex()), ev.TokenAmount, ev.Raw.TxHash.Hex(), at)
+		}})
+	}
+	if err := exchanged.Error(); err != nil {
+		return nil, err
+	}
+
+	fulfilled, err := registry.FilterExchangeFulfilled(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for fulfilled.Next() {
+		ev := fulfilled.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "ExchangeFulfilled", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyExchangeSettled(j, ev.ExchangeId.Uint64(), models.ExchangeStatusApproved, strings.ToLower(ev.Parent.Hex()), ev.Raw.TxHash.Hex(), at)
+		}})
+	}
+	if err := fulfilled.Error(); err != nil {
+		return nil, err
+	}
+
+	declined, err := registry.FilterExchangeDeclined(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for declined.Next() {
+		ev := declined.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "ExchangeDeclined", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyExchangeSettled(j, ev.ExchangeId.Uint64(), models.ExchangeStatusDeclined, strings.ToLower(ev.Parent.Hex()), ev.Raw.TxHash.Hex(), at)
+		}})
+	}
+	if err := declined.Error(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// collectTokenEvents 拉取RewardToken的转账事件，铸币和销毁也是转账事件
+func (i *EventIndexer) collectTokenEvents(opts *bind.FilterOpts) ([]chainEvent, error) {
+	transfers, err := i.contractManager.RewardToken.FilterTransfer(opts, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []chainEvent
+	for transfers.Next() {
+		ev := transfers.Event
+		events = append(events, chainEvent{raw: ev.Raw, name: "Transfer", apply: func(j *effectJournal, at time.Time) error {
+			return i.applyTokenTransfer(j, strings.ToLower(ev.From.Hex()), strings.ToLower(ev.To.Hex()), ev.Value, ev.Raw, at)
+		}})
+	}
+	if err := transfers.Error(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// applyTaskCreated 为直接在链上创建、数据库中还没有的任务补建记录
+func (i *EventIndexer) applyTaskCreated(j *effectJournal, contractTaskID uint64, creator, title string, reward *big.Int, txHash string) error {
+	taskRepo := repository.NewTaskRepository(j.tx)
+
+	_, err := taskRepo.GetByContractTaskID(contractTaskID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	task := &models.Task{
+		Title:          title,
+		Description:    "",
+		RewardAmount:   utils.WeiToEther(reward),
+		Difficulty:     "medium", // 链上没有难度信息
+		Status:         "pending",
+		CreatedBy:      creator,
+		ContractTaskID: &contractTaskID,
+	}
+
+	// 任务归属到创建者可以管理的第一个家庭；创建者还没有家庭时留空，只有创建者本人可见
+	members, err := repository.NewFamilyMemberRepository(j.tx).ListByWallet(creator)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if models.CanManageFamily(member.Role) {
+			familyID := member.FamilyID
+			task.FamilyID = &familyID
+			break
+		}
+	}
+
+	if err := taskRepo.Create(task); err != nil {
+		return err
+	}
+	j.created(task.TableName(), task.ID)
+	log.Printf("[indexer] 链上任务 %d 在数据库中不存在，已创建任务 %d", contractTaskID, task.ID)
+	return j.taskEvent(&models.TaskEvent{
+		TaskID:   task.ID,
+		Action:   models.TaskEventCreated,
+		Actor:    creator,
+		ToStatus: task.Status,
+	}, txHash)
+}
+
+// applyTaskAssigned 同步任务分配的孩子
+func (i *EventIndexer) applyTaskAssigned(j *effectJournal, contractTaskID uint64, childAddress, txHash string) error {
+	task, err := repository.NewTaskRepository(j.tx).GetByContractTaskID(contractTaskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上任务 %d 不存在，跳过分配事件", contractTaskID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{}
+	changed := false
+	child, err := repository.NewChildRepository(j.tx).GetByWalletAddress(childAddress)
+	if err == nil {
+		updates["assigned_child_id"] = child.ID
+		changed = task.AssignedChildID == nil || *task.AssignedChildID != child.ID
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上任务 %d 分配给了未登记的地址 %s", contractTaskID, childAddress)
+	} else {
+		return err
+	}
+	toStatus := task.Status
+	if taskStatusRank["in_progress"] > taskStatusRank[task.Status] {
+		updates["status"] = "in_progress"
+		toStatus = "in_progress"
+		changed = true
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	updates["updated_at"] = time.Now()
+	if err := j.update(task.TableName(), task.ID, updates); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	// 只有任务创建者可以在链上分配任务
+	return j.taskEvent(&models.TaskEvent{
+		TaskID:     task.ID,
+		Action:     models.TaskEventAssigned,
+		Actor:      task.CreatedBy,
+		FromStatus: task.Status,
+		ToStatus:   toStatus,
+	}, txHash)
+}
+
+// applyTaskStatus 把任务推进到链上的状态，并记录对应的时间字段
+func (i *EventIndexer) applyTaskStatus(j *effectJournal, contractTaskID uint64, status, timeColumn, actor, txHash string, at time.Time) error {
+	task, err := repository.NewTaskRepository(j.tx).GetByContractTaskID(contractTaskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上任务 %d 不存在，跳过状态 %s", contractTaskID, status)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if taskStatusRank[status] <= taskStatusRank[task.Status] {
+		return nil
+	}
+	if task.Status == models.TaskStatusExpired {
+		// 与API的迟交规则一致；迟交减少的奖励在过期时已经按家庭的规则保存
+		allowed, err := lateSubmissionAllowed(j.tx, task)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			log.Printf("[indexer] 任务 %d (链上 %d) 已过期且家庭不接受迟交，跳过状态 %s", task.ID, contractTaskID, status)
+			return nil
+		}
+	}
+
+	log.Printf("[indexer] 任务 %d (链上 %d) 状态 %s -> %s", task.ID, contractTaskID, task.Status, status)
+	if err := j.update(task.TableName(), task.ID, map[string]interface{}{
+		"status":     status,
+		timeColumn:   at,
+		"updated_at": time.Now(),
+	}); err != nil {
+		return err
+	}
+	return j.taskEvent(&models.TaskEvent{
+		TaskID:     task.ID,
+		Action:     taskStatusActions[status],
+		Actor:      actor,
+		FromStatus: task.Status,
+		ToStatus:   status,
+	}, txHash)
+}
+
+// lateSubmissionAllowed 判断过期任务所在的家庭是否接受迟交
+func lateSubmissionAllowed(tx *gorm.DB, task *models.Task) (bool, error) {
+	if task.FamilyID == nil {
+		return false, nil
+	}
+	family, err := repository.NewFamilyRepository(tx).GetByID(*task.FamilyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return family.AllowLateSubmissions, nil
+}
+
+// applyTaskPayout 记录链上部分批准时实际发放的奖励
+// 后端已经记录过时不修改（铸币按后端的记录排队），与链上发放的数量不一致时记录日志
+func (i *EventIndexer) applyTaskPayout(j *effectJournal, contractTaskID uint64, payout *big.Int) error {
+	task, err := repository.NewTaskRepository(j.tx).GetByContractTaskID(contractTaskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上任务 %d 不存在，跳过发放事件", contractTaskID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	paid := utils.WeiToEther(payout)
+	if task.ApprovedRewardAmount != nil {
+		cmp, err := utils.CompareRewards(*task.ApprovedRewardAmount, paid)
+		if err != nil {
+			return err
+		}
+		if cmp != 0 {
+			log.Printf("[indexer] 任务 %d (链上 %d) 链上发放 %s ETH，与后端批准的奖励 %s 不一致",
+				task.ID, contractTaskID, paid, *task.ApprovedRewardAmount)
+		}
+		return nil
+	}
+
+	return j.update(task.TableName(), task.ID, map[string]interface{}{
+		"approved_reward_amount": paid,
+		"updated_at":             time.Now(),
+	})
+}
+
+// applyTaskRework 把链上退回重做的任务放回进行中
+// 后端已经处理过这次退回（记录的退回时间不早于区块时间）时不修改，避免重复计数
+func (i *EventIndexer) applyTaskRework(j *effectJournal, contractTaskID uint64, actor, txHash string, at time.Time) error {
+	task, err := repository.NewTaskRepository(j.tx).GetByContractTaskID(contractTaskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上任务 %d 不存在，跳过退回事件", contractTaskID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if task.Status != "completed" || (task.ReworkRequestedAt != nil && !task.ReworkRequestedAt.Before(at)) {
+		return nil
+	}
+
+	log.Printf("[indexer] 任务 %d (链上 %d) 被退回重做", task.ID, contractTaskID)
+	if err := j.update(task.TableName(), task.ID, map[string]interface{}{
+		"status":              "in_progress",
+		"submitted_at":        nil,
+		"rework_count":        task.ReworkCount + 1,
+		"rework_requested_at": at,
+		"updated_at":          time.Now(),
+	}); err != nil {
+		return err
+	}
+	return j.taskEvent(&models.TaskEvent{
+		TaskID:     task.ID,
+		Action:     models.TaskEventReworkRequested,
+		Actor:      actor,
+		FromStatus: task.Status,
+		ToStatus:   "in_progress",
+	}, txHash)
+}
+
+// applyRewardExchanged 关联或补建链上兑换记录，链上兑换的代币托管在奖品合约中，兑换保持待审核
+func (i *EventIndexer) applyRewardExchanged(j *effectJournal, contractExchangeID, contractRewardID uint64, childAddress string, tokenAmount *big.Int, txHash string, at time.Time) error {
+	exchangeRepo := repository.NewExchangeRepository(j.tx)
+
+	linked, err := exchangeRepo.GetByContractExchangeID(contractExchangeID)
+	if err == nil {
+		// 孩子提交链上兑换ID时后端还不知道兑换交易
+		if linked.TxHash == nil {
+			return j.update(linked.TableName(), linked.ID, map[string]interface{}{
+				"tx_hash":    txHash,
+				"updated_at": time.Now(),
+			})
+		}
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	reward, err := repository.NewRewardRepository(j.tx).GetByContractRewardID(uint(contractRewardID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上兑换 %d 的奖品 %d 不存在，跳过", contractExchangeID, contractRewardID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	child, err := repository.NewChildRepository(j.tx).GetByWalletAddress(childAddress)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上兑换 %d 的孩子地址 %s 未登记，跳过", contractExchangeID, childAddress)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	links := map[string]interface{}{
+		"contract_exchange_id": contractExchangeID,
+		"tx_hash":              txHash,
+		"updated_at":           time.Now(),
+	}
+
+	// 后端代孩子兑换的托管交易，在分发器确认之前先关联
+	escrow, err := repository.NewOutboxRepository(j.tx).GetByTxHash(txHash)
+	if err == nil && escrow.Kind == models.OutboxKindEscrowExchange && escrow.ExchangeID != nil {
+		return j.update(models.Exchange{}.TableName(), *escrow.ExchangeID, links)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// 直接在链上兑换、后端不知道的兑换，代币已经托管，等待家长审核
+	exchange := &models.Exchange{
+		RewardID:           reward.ID,
+		ChildID:            child.ID,
+		TokenAmount:        utils.TokenAmountToUnits(tokenAmount),
+		Status:             models.ExchangeStatusPending,
+		ExchangeDate:       at,
+		Notes:              "从链上同步的兑换",
+		ContractExchangeID: &contractExchangeID,
+		TxHash:             &txHash,
+	}
+	if err := exchangeRepo.Create(exchange); err != nil {
+		return err
+	}
+	j.created(exchange.TableName(), exchange.ID)
+
+	if reward.Stock > 0 {
+		if err := j.update("rewards", reward.ID, map[string]interface{}{
+			"stock":      reward.Stock - 1,
+			"updated_at": time.Now(),
+		}); err != nil {
+			return err
+		}
+	} else {
+		log.Printf("[indexer] 兑换 %d 的奖品 %d 库存已为0", exchange.ID, reward.ID)
+	}
+	log.Printf("[indexer] 链上兑换 %d 已同步为兑换记录 %d", contractExchangeID, exchange.ID)
+	return nil
+}
+
+// applyExchangeSettled 同步链上批准（销毁托管代币）或拒绝（退还托管代币）的兑换
+// 还在审核中的兑换改为对应的状态，拒绝时恢复库存；已经在后端处理过的只记录结算交易
+func (i *EventIndexer) applyExchangeSettled(j *effectJournal, contractExchangeID uint64, status models.ExchangeStatus, actor, txHash string, at time.Time) error {
+	exchange, err := repository.NewExchangeRepository(j.tx).GetByContractExchangeID(contractExchangeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[indexer] 链上兑换 %d 不存在，跳过%s事件", contractExchangeID, status)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"updated_at": time.Now()}
+	if exchange.SettlementTxHash == nil {
+		updates["settlement_tx_hash"] = txHash
+	}
+	if exchange.Status != models.ExchangeStatusPending {
+		if len(updates) == 1 {
+			return nil
+		}
+		return j.update(exchange.TableName(), exchange.ID, updates)
+	}
+
+	updates["status"] = status
+	updates["decided_by"] = actor
+	updates["decided_at"] = at
+	if err := j.update(exchange.TableName(), exchange.ID, updates); err != nil {
+		return err
+	}
+	if status != models.ExchangeStatusDeclined {
+		return nil
+	}
+
+	reward, err := repository.NewRewardRepository(j.tx).GetByID(exchange.RewardID)
+	if err != nil {
+		return err
+	}
+	return j.update("rewards", reward.ID, map[string]interface{}{
+		"stock":      reward.Stock + 1,
+		"updated_at": time.Now(),
+	})
+}
+
+// applyTokenTransfer 把一条转账记入账本：转出方一条负的分录，转入方一条正的分录
+func (i *EventIndexer) applyTokenTransfer(j *effectJournal, from, to string, value *big.Int, raw types.Log, at time.Time) error {
+	ledgerRepo := repository.NewLedgerRepository(j.tx)
+	txHash := raw.TxHash.Hex()
+
+	// 合约重新部署后从头索引时，旧地址上已经记过的转账不会重复记账
+	exists, err := ledgerRepo.Exists(txHash, raw.Index)
+	if err != nil || exists {
+		return err
+	}
+
+	zero := strings.ToLower(common.Address{}.Hex())
+	escrow := strings.ToLower(i.contractManager.GetContractAddresses()["reward"])
+	kind := models.LedgerKindTransfer
+	switch {
+	case from == zero:
+		kind = models.LedgerKindMint
+	case to == zero:
+		kind = models.LedgerKindBurn
+	case to == escrow:
+		kind = models.LedgerKindEscrow
+	case from == escrow:
+		kind = models.LedgerKindRefund
+	}
+
+	debit, err := i.ledgerEntry(j.tx, from, zero, escrow, new(big.Int).Neg(value))
+	if err != nil {
+		return err
+	}
+	credit, err := i.ledgerEntry(j.tx, to, zero, escrow, value)
+	if err != nil {
+		return err
+	}
+
+	txn := &models.LedgerTransaction{
+		Kind:        kind,
+		Amount:      value.String(),
+		TxHash:      txHash,
+		LogIndex:    raw.Index,
+		BlockNumber: raw.BlockNumber,
+		OccurredAt:  at,
+	}
+	if err := ledgerRepo.Post(txn, []models.LedgerEntry{*debit, *credit}); err != nil {
+		return err
+	}
+
+	j.created(txn.TableName(), txn.ID)
+	for _, entry := range txn.Entries {
+		j.created(entry.TableName(), entry.ID)
+	}
+	return nil
+}
+
+// ledgerEntry 按地址确定账户类型，登记过的孩子钱包关联到孩子
+func (i *EventIndexer) ledgerEntry(tx *gorm.DB, account, zero, escrow string, amount *big.Int) (*models.LedgerEntry, error) {
+	entry := &models.LedgerEntry{Account: account, Amount: amount.String(), AccountType: models.LedgerAccountExternal}
+	switch account {
+	case zero:
+		entry.AccountType = models.LedgerAccountIssuance
+		return entry, nil
+	case escrow:
+		entry.AccountType = models.LedgerAccountEscrow
+		return entry, nil
+	}
+
+	child, err := repository.NewChildRepository(tx).GetByWalletAddress(account)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry.AccountType = models.LedgerAccountChild
+	entry.ChildID = &child.ID
+	return entry, nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidInviteCode 邀请码签名无效或不存在
+var ErrInvalidInviteCode = errors.New("invalid invite code")
+
+// defaultInviteCodeTTL 邀请码的默认有效期
+const defaultInviteCodeTTL = 72 * time.Hour
+
+// IssuedInviteCode 新生成的邀请码，明文邀请码只在生成时返回一次
+type IssuedInviteCode struct {
+	Invite *models.FamilyInviteCode `json:"invite"`
+	Code   string                   `json:"code"`
+	Link   string                   `json:"link"`
+}
+
+// InviteCodePreview 兑换前展示给被邀请人的邀请信息
+type InviteCodePreview struct {
+	FamilyID   uint      `json:"family_id"`
+	FamilyName string    `json:"family_name"`
+	Role       string    `json:"role"`
+	ChildName  *string   `json:"child_name,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// InviteRedemptionResult 兑换邀请码的结果
+type InviteRedemptionResult struct {
+	User   *models.User         `json:"user"`
+	Family *models.Family       `json:"family"`
+	Role   string               `json:"role"`
+	Member *models.FamilyMember `json:"member,omitempty"`
+	Child  *models.Child        `json:"child,omitempty"`
+}
+
+// InviteCodeService 管理一次性的家庭邀请码
+// 家长生成邀请码后把链接发给孩子或其他监护人，对方用钱包登录时兑换，
+// 自动以邀请码中的角色加入家庭，不需要家长手动输入对方的钱包地址
+type InviteCodeService struct {
+	codeRepo          *repository.FamilyInviteCodeRepository
+	memberRepo        *repository.FamilyMemberRepository
+	childRepo         *repository.ChildRepository
+	userRepo          *repository.UserRepository
+	membershipService *MembershipService
+	signer            *utils.InviteCodeSigner
+	ttl               time.Duration
+	linkBase          string
+}
+
+// NewInviteCodeService 创建一个新的邀请码服务，linkBase为前端地址，用于生成邀请链接
+func NewInviteCodeService(
+	codeRepo *repository.FamilyInviteCodeRepository,
+	memberRepo *repository.FamilyMemberRepository,
+	childRepo *repository.ChildRepository,
+	userRepo *repository.UserRepository,
+	membershipService *MembershipService,
+	signer *utils.InviteCodeSigner,
+	ttl time.Duration,
+	linkBase string,
+) *InviteCodeService {
+	if ttl <= 0 {
+		ttl = defaultInviteCodeTTL
+	}
+	return &InviteCodeService{
+		codeRepo:          codeRepo,
+		memberRepo:        memberRepo,
+		childRepo:         childRepo,
+		userRepo:          userRepo,
+		membershipService: membershipService,
+		signer:            signer,
+		ttl:               ttl,
+		linkBase:          strings.TrimRight(linkBase, "/"),
+	}
+}
+
+// Create 生成邀请码
+// 邀请孩子需要owner或co_parent角色，并预先填写孩子的名字和年龄；邀请监护人只有owner可以操作
+func (s *InviteCodeService) Create(familyID uint, actor, role, childName string, childAge int) (*IssuedInviteCode, error) {
+	if !models.IsValidInviteCodeRole(role) {
+		return nil, fmt.Errorf("%w: invalid invite role %q", ErrInvalidMembership, role)
+	}
+	if err := s.authorize(familyID, actor, role); err != nil {
+		return nil, err
+	}
+
+	invite := &models.FamilyInviteCode{
+		FamilyID:  familyID,
+		Role:      role,
+		CreatedBy: actor,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if role == models.InviteRoleChild {
+		name := utils.SanitizeString(childName)
+		if name == "" || childAge < 1 {
+			return nil, fmt.Errorf("%w: child_name and child_age are required for child invites", ErrInvalidMembership)
+		}
+		invite.ChildName = &name
+		invite.ChildAge = &childAge
+	}
+
+	code, hash, err := s.signer.Generate()
+	if err != nil {
+		return nil, err
+	}
+	invite.CodeHash = hash
+	if err := s.codeRepo.Create(invite); err != nil {
+		return nil, err
+	}
+
+	return &IssuedInviteCode{
+		Invite: invite,
+		Code:   code,
+		Link:   s.linkBase + "/join?code=" + url.QueryEscape(code),
+	}, nil
+}
+
+// List 获取家庭生成的邀请码，调用者必须可以管理家庭
+func (s *InviteCodeService) List(familyID uint, actor string) ([]*models.FamilyInviteCode, error) {
+	if err := s.membershipService.Authorize(familyID, actor, true); err != nil {
+		return nil, err
+	}
+	return s.codeRepo.ListByFamily(familyID)
+}
+
+// Revoke 撤销尚未兑换的邀请码，权限要求与生成该邀请码相同
+func (s *InviteCodeService) Revoke(familyID, codeID uint, actor string) error {
+	invite, err := s.codeRepo.GetByID(codeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && invite.FamilyID != familyID) {
+		return ErrInvitationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(familyID, actor, invite.Role); err != nil {
+		return err
+	}
+	revoked, err := s.codeRepo.Revoke(codeID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationClosed
+	}
+	return nil
+}
+
+// Preview 返回邀请码对应的家庭和角色，前端据此在登录前展示邀请信息
+func (s *InviteCodeService) Preview(code string) (*InviteCodePreview, error) {
+	invite, err := s.lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	preview := &InviteCodePreview{
+		FamilyID:  invite.FamilyID,
+		Role:      invite.Role,
+		ChildName: invite.ChildName,
+		ExpiresAt: invite.ExpiresAt,
+	}
+	if invite.Family != nil {
+		preview.FamilyName = invite.Family.Name
+	}
+	return preview, nil
+}
+
+// Redeem 钱包兑换邀请码并加入家庭
+// 钱包还没有用户记录时按邀请码的角色创建用户；获取nonce时创建的临时用户会自动转为对应角色；
+// 已有的用户必须是邀请码要求的账户类型（邀请孩子需要child账户，邀请监护人需要parent账户）
+func (s *InviteCodeService) Redeem(code, walletAddress string) (*InviteRedemptionResult, error) {
+	invite, err := s.lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	if invite.Family == nil {
+		return nil, ErrInvalidInviteCode
+	}
+
+	accountRole := "parent"
+	if invite.Role == models.InviteRoleChild {
+		accountRole = "child"
+	}
+
+	user, err := s.userRepo.GetByWalletAddress(walletAddress)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user = &models.User{WalletAddress: walletAddress, Role: accountRole}
+	case err != nil:
+		return nil, err
+	case user.Role == "temp":
+		user.Role = accountRole
+	case user.Role != accountRole:
+		return nil, fmt.Errorf("%w: this invite code is for a %s account", ErrInvalidMembership, accountRole)
+	}
+
+	redemption := &repository.InviteRedemption{User: user}
+	if invite.Role == models.InviteRoleChild {
+		redemption.Child, err = s.newChild(invite, walletAddress)
+	} else {
+		redemption.Member, err = s.newMember(invite, walletAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	redeemed, err := s.codeRepo.Redeem(invite, redemption, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, ErrInvitationClosed
+	}
+
+	return &InviteRedemptionResult{
+		User:   user,
+		Family: invite.Family,
+		Role:   invite.Role,
+		Member: redemption.Member,
+		Child:  redemption.Child,
+	}, nil
+}
+
+// lookup 校验签名并获取仍可兑换的邀请码
+func (s *InviteCodeService) lookup(code string) (*models.FamilyInviteCode, error) {
+	if !s.signer.Verify(code) {
+		return nil, ErrInvalidInviteCode
+	}
+	invite, err := s.codeRepo.GetByHash(utils.HashInviteCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidInviteCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !invite.Usable(time.Now()) {
+		return nil, ErrInvitationClosed
+	}
+	return invite, nil
+}
+
+// authorize 邀请孩子需要管理权限，邀请监护人需要所有者权限
+func (s *InviteCodeService) authorize(familyID uint, actor, role string) error {
+	if role == models.InviteRoleChild {
+		return s.membershipService.Authorize(familyID, actor, true)
+	}
+	return s.membershipService.AuthorizeOwner(familyID, actor)
+}
+
+func (s *InviteCodeService) newChild(invite *models.FamilyInviteCode, walletAddress string) (*models.Child, error) {
+	if _, err := s.childRepo.GetByWalletAddress(walletAddress); err == nil {
+		return nil, ErrAlreadyMember
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	memberships, err := s.memberRepo.ListByWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) > 0 {
+		return nil, fmt.Errorf("%w: family guardians cannot join as children", ErrInvalidMembership)
+	}
+
+	// 孩子通过家庭创建者的地址关联到家庭
+	child := &models.Child{
+		WalletAddress:      walletAddress,
+		ParentAddress:      invite.Family.ParentAddress,
+		TotalRewardsEarned: "0",
+	}
+	if invite.ChildName != nil {
+		child.Name = *invite.ChildName
+	}
+	if invite.ChildAge != nil {
+		child.Age = *invite.ChildAge
+	}
+	return child, nil
+}
+
+func (s *InviteCodeService) newMember(invite *models.FamilyInviteCode, walletAddress string) (*models.FamilyMember, error) {
+	if existing, err := s.memberRepo.GetByFamilyAndWallet(invite.FamilyID, walletAddress); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrAlreadyMember
+	}
+	if _, err := s.childRepo.GetByWalletAddress(walletAddress); err == nil {
+		return nil, fmt.Errorf("%w: children cannot join as family members", ErrInvalidMembership)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return &models.FamilyMember{
+		FamilyID:      invite.FamilyID,
+		WalletAddress: walletAddress,
+		Role:          invite.Role,
+		InvitedBy:     invite.CreatedBy,
+	}, nil
+}
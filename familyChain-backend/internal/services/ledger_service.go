@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrLedgerUnavailable 没有配置RewardToken合约，无法与链上余额核对
+var ErrLedgerUnavailable = errors.New("reward token is not configured")
+
+// LedgerService 查询代币账本并与链上余额核对
+// 账本由事件索引器根据RewardToken的Transfer事件写入
+type LedgerService struct {
+	ledgerRepo      *repository.LedgerRepository
+	cursorRepo      *repository.ChainCursorRepository
+	familyRepo      *repository.FamilyRepository
+	contractManager *blockchain.ContractManager
+}
+
+// NewLedgerService 创建代币账本服务
+func NewLedgerService(
+	ledgerRepo *repository.LedgerRepository,
+	cursorRepo *repository.ChainCursorRepository,
+	familyRepo *repository.FamilyRepository,
+	contractManager *blockchain.ContractManager,
+) *LedgerService {
+	return &LedgerService{
+		ledgerRepo:      ledgerRepo,
+		cursorRepo:      cursorRepo,
+		familyRepo:      familyRepo,
+		contractManager: contractManager,
+	}
+}
+
+// Statement 获取孩子的账本余额、累计铸币和最近的分录
+func (s *LedgerService) Statement(child *models.Child, limit int) (*models.LedgerStatement, error) {
+	account := strings.ToLower(child.WalletAddress)
+	balance, err := s.ledgerRepo.Balance(account)
+	if err != nil {
+		return nil, err
+	}
+	minted, err := s.ledgerRepo.Minted(account)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.ledgerRepo.ListByAccount(account, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LedgerStatement{
+		ChildID:       child.ID,
+		WalletAddress: account,
+		Balance:       balance.String(),
+		Minted:        minted.String(),
+		Entries:       entries,
+	}, nil
+}
+
+// Reconcile 把家庭中每个孩子的账本余额与链上 RewardToken.balanceOf 核对
+// 链上余额尽量按账本已同步到的区块读取，索引器的延迟不会被当成差异
+func (s *LedgerService) Reconcile(ctx context.Context, familyID uint) (*models.LedgerReport, error) {
+	if s.contractManager == nil || s.contractManager.RewardToken == nil {
+		return nil, ErrLedgerUnavailable
+	}
+
+	family, err := s.familyRepo.GetByID(familyID)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.LedgerReport{FamilyID: familyID, ChainAtLatest: true, Children: []models.LedgerReconciliation{}}
+
+	cursor, err := s.cursorRepo.Get(IndexerContractRewardToken)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != nil {
+		report.Block = &cursor.LastBlock
+	}
+	blockNumber, err := s.reconcileBlock(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range family.Children {
+		account := strings.ToLower(child.WalletAddress)
+		ledgerBalance, err := s.ledgerRepo.Balance(account)
+		if err != nil {
+			return nil, err
+		}
+		chainBalance, err := s.contractManager.TokenBalance(ctx, common.HexToAddress(account), blockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		difference := new(big.Int).Sub(chainBalance, ledgerBalance)
+		result := models.LedgerReconciliation{
+			ChildID:       child.ID,
+			ChildName:     child.Name,
+			WalletAddress: account,
+			LedgerBalance: ledgerBalance.String(),
+			ChainBalance:  chainBalance.String(),
+			Difference:    difference.String(),
+			Matched:       difference.Sign() == 0,
+		}
+		if !result.Matched {
+			report.Mismatches++
+			log.Printf("[ledger] 孩子 %d (%s) 的账本余额 %s 与链上余额 %s 不一致", child.ID, account, ledgerBalance, chainBalance)
+		}
+		report.Children = append(report.Children, result)
+	}
+	return report, nil
+}
+
+// reconcileBlock 在核对之前固定读取链上余额的区块，所有孩子按同一个区块比较
+// 优先用账本已同步到的区块；账本尚未同步或者节点不能读取这个区块的状态时，固定为当前的最新区块
+func (s *LedgerService) reconcileBlock(ctx context.Context, report *models.LedgerReport) (*big.Int, error) {
+	if report.Block != nil {
+		blockNumber := new(big.Int).SetUint64(*report.Block)
+		_, err := s.contractManager.TokenBalance(ctx, common.Address{}, blockNumber)
+		if err == nil {
+			report.ChainAtLatest = false
+			return blockNumber, nil
+		}
+		log.Printf("[ledger] 无法读取区块 %s 的链上余额，改为读取最新区块: %v", blockNumber, err)
+	}
+
+	head, err := s.contractManager.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.ChainAtLatest = true
+	return new(big.Int).SetUint64(head), nil
+}
+
+// Balance 获取钱包的账本余额（wei）
+func (s *LedgerService) Balance(walletAddress string) (*big.Int, error) {
+	balance, err := s.ledgerRepo.Balance(strings.ToLower(walletAddress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger balance: %w", err)
+	}
+	return balance, nil
+}
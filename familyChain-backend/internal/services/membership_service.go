@@ -0,0 +1,412 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFamilyMember 调用者不是该家庭的成员
+	ErrNotFamilyMember = errors.New("not a member of this family")
+	// ErrFamilyPermission 调用者是家庭成员，但角色不允许执行该操作
+	ErrFamilyPermission = errors.New("insufficient family role")
+	// ErrInvalidMembership 角色或钱包地址等参数无效
+	ErrInvalidMembership = errors.New("invalid membership request")
+	// ErrFamilyRequired 调用者属于多个家庭，需要明确指定family_id
+	ErrFamilyRequired = errors.New("family_id is required when you belong to several families")
+	// ErrFamilyCreator 家庭创建者的成员身份不能被移除或降级
+	ErrFamilyCreator = errors.New("the family creator must remain an owner")
+	// ErrAlreadyMember 被邀请的钱包已经是家庭成员
+	ErrAlreadyMember = errors.New("wallet is already a family member")
+	// ErrInvitationPending 已经向该钱包发出了待处理的邀请
+	ErrInvitationPending = errors.New("an invitation is already pending for this wallet")
+	// ErrInvitationNotFound 邀请不存在或不属于调用者
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvitationClosed 邀请已被处理、撤销或已过期
+	ErrInvitationClosed = errors.New("invitation is no longer pending")
+)
+
+// defaultInvitationTTL 邀请的默认有效期
+const defaultInvitationTTL = 7 * 24 * time.Hour
+
+// MembershipService 管理家庭成员和邀请，并根据成员角色判断家庭数据的访问权限
+// owner和co_parent可以修改孩子、任务、奖品和兑换；viewer只能查看；只有owner可以管理成员
+type MembershipService struct {
+	memberRepo     *repository.FamilyMemberRepository
+	invitationRepo *repository.FamilyInvitationRepository
+	familyRepo     *repository.FamilyRepository
+	childRepo      *repository.ChildRepository
+	invitationTTL  time.Duration
+}
+
+// NewMembershipService 创建一个新的家庭成员服务
+func NewMembershipService(
+	memberRepo *repository.FamilyMemberRepository,
+	invitationRepo *repository.FamilyInvitationRepository,
+	familyRepo *repository.FamilyRepository,
+	childRepo *repository.ChildRepository,
+) *MembershipService {
+	return &MembershipService{
+		memberRepo:     memberRepo,
+		invitationRepo: invitationRepo,
+		familyRepo:     familyRepo,
+		childRepo:      childRepo,
+		invitationTTL:  defaultInvitationTTL,
+	}
+}
+
+// RoleInFamily 返回钱包在家庭中的角色，不是成员时返回空字符串
+func (s *MembershipService) RoleInFamily(familyID uint, walletAddress string) (string, error) {
+	member, err := s.memberRepo.GetByFamilyAndWallet(familyID, walletAddress)
+	if err != nil {
+		return "", err
+	}
+	if member == nil {
+		return "", nil
+	}
+	return member.Role, nil
+}
+
+// Authorize 检查钱包是否可以访问家庭，manage为true时要求owner或co_parent角色
+func (s *MembershipService) Authorize(familyID uint, walletAddress string, manage bool) error {
+	role, err := s.RoleInFamily(familyID, walletAddress)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrNotFamilyMember
+	}
+	if manage && !models.CanManageFamily(role) {
+		return ErrFamilyPermission
+	}
+	return nil
+}
+
+// AuthorizeOwner 检查钱包是否是家庭的所有者
+func (s *MembershipService) AuthorizeOwner(familyID uint, walletAddress string) error {
+	role, err := s.RoleInFamily(familyID, walletAddress)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return ErrNotFamilyMember
+	}
+	if role != models.FamilyRoleOwner {
+		return ErrFamilyPermission
+	}
+	return nil
+}
+
+// AuthorizeFamilyView 检查用户是否可以查看家庭数据：家长必须是家庭成员，孩子必须属于该家庭
+func (s *MembershipService) AuthorizeFamilyView(familyID uint, walletAddress, userRole string) error {
+	if userRole != "child" {
+		return s.Authorize(familyID, walletAddress, false)
+	}
+	child, err := s.childRepo.GetByWalletAddress(walletAddress)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFamilyMember
+	}
+	if err != nil {
+		return err
+	}
+	childFamilyID, err := s.FamilyIDForChild(child)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && childFamilyID != familyID) {
+		return ErrNotFamilyMember
+	}
+	return err
+}
+
+// FamilyIDForChild 返回孩子所属家庭的ID
+func (s *MembershipService) FamilyIDForChild(child *models.Child) (uint, error) {
+	return s.familyRepo.GetIDByParentAddress(child.ParentAddress)
+}
+
+// AuthorizeChild 检查钱包是否可以访问孩子所属的家庭
+func (s *MembershipService) AuthorizeChild(child *models.Child, walletAddress string, manage bool) error {
+	familyID, err := s.FamilyIDForChild(child)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFamilyMember
+	}
+	if err != nil {
+		return err
+	}
+	return s.Authorize(familyID, walletAddress, manage)
+}
+
+// AuthorizeTask 检查钱包是否可以访问任务所属的家庭
+// 没有家庭的任务（例如创建者还没有家庭时从链上同步的任务）只有创建者本人可以访问
+func (s *MembershipService) AuthorizeTask(task *models.Task, walletAddress string, manage bool) error {
+	if task.FamilyID == nil {
+		if task.CreatedBy == walletAddress {
+			return nil
+		}
+		return ErrNotFamilyMember
+	}
+	return s.Authorize(*task.FamilyID, walletAddress, manage)
+}
+
+// FamilyIDs 返回钱包加入的所有家庭的ID
+func (s *MembershipService) FamilyIDs(walletAddress string) ([]uint, error) {
+	members, err := s.memberRepo.ListByWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.FamilyID)
+	}
+	return ids, nil
+}
+
+// ResolveManagedFamily 确定家长要操作的家庭
+// 指定了familyID时检查管理权限；否则使用自己创建的家庭，或唯一一个可以管理的家庭
+func (s *MembershipService) ResolveManagedFamily(walletAddress string, familyID *uint) (*models.Family, error) {
+	if familyID != nil {
+		if err := s.Authorize(*familyID, walletAddress, true); err != nil {
+			return nil, err
+		}
+		return s.familyRepo.GetByID(*familyID)
+	}
+
+	if family, err := s.familyRepo.GetByParentAddress(walletAddress); err == nil {
+		return family, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	members, err := s.memberRepo.ListByWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	var managed []*models.FamilyMember
+	for _, member := range members {
+		if models.CanManageFamily(member.Role) {
+			managed = append(managed, member)
+		}
+	}
+	switch len(managed) {
+	case 0:
+		if len(members) > 0 {
+			return nil, ErrFamilyPermission
+		}
+		return nil, ErrNotFamilyMember
+	case 1:
+		return s.familyRepo.GetByID(managed[0].FamilyID)
+	default:
+		return nil, ErrFamilyRequired
+	}
+}
+
+// ListMembers 获取家庭成员列表，调用者必须是家庭成员
+func (s *MembershipService) ListMembers(familyID uint, walletAddress string) ([]*models.FamilyMember, error) {
+	if err := s.Authorize(familyID, walletAddress, false); err != nil {
+		return nil, err
+	}
+	return s.memberRepo.ListByFamily(familyID)
+}
+
+// UpdateMemberRole 修改成员角色，只有所有者可以操作
+func (s *MembershipService) UpdateMemberRole(familyID, memberID uint, role, actor string) (*models.FamilyMember, error) {
+	if !models.IsValidFamilyRole(role) {
+		return nil, fmt.Errorf("%w: invalid family role %q", ErrInvalidMembership, role)
+	}
+	if err := s.AuthorizeOwner(familyID, actor); err != nil {
+		return nil, err
+	}
+	member, err := s.memberOf(familyID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.FamilyRoleOwner {
+		if err := s.ensureNotCreator(familyID, member); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.memberRepo.UpdateRole(member.ID, role); err != nil {
+		return nil, err
+	}
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember 移除家庭成员；所有者可以移除任何人，其他成员只能移除自己（退出家庭）
+func (s *MembershipService) RemoveMember(familyID, memberID uint, actor string) error {
+	member, err := s.memberOf(familyID, memberID)
+	if err != nil {
+		return err
+	}
+	if member.WalletAddress != actor {
+		if err := s.AuthorizeOwner(familyID, actor); err != nil {
+			return err
+		}
+	}
+	if err := s.ensureNotCreator(familyID, member); err != nil {
+		return err
+	}
+	return s.memberRepo.Delete(member.ID)
+}
+
+// Invite 邀请钱包以指定角色加入家庭，只有所有者可以邀请
+func (s *MembershipService) Invite(familyID uint, inviter, inviteeAddress, role string) (*models.FamilyInvitation, error) {
+	if !models.IsValidFamilyRole(role) {
+		return nil, fmt.Errorf("%w: invalid family role %q", ErrInvalidMembership, role)
+	}
+	if !utils.IsValidEthereumAddress(inviteeAddress) {
+		return nil, fmt.Errorf("%w: invalid Ethereum address format", ErrInvalidMembership)
+	}
+	if err := s.AuthorizeOwner(familyID, inviter); err != nil {
+		return nil, err
+	}
+
+	invitee := strings.ToLower(inviteeAddress)
+	if existing, err := s.memberRepo.GetByFamilyAndWallet(familyID, invitee); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrAlreadyMember
+	}
+	if _, err := s.childRepo.GetByWalletAddress(invitee); err == nil {
+		return nil, fmt.Errorf("%w: children cannot be invited as family members", ErrInvalidMembership)
+	}
+
+	now := time.Now()
+	pending, err := s.invitationRepo.HasPending(familyID, invitee, now)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrInvitationPending
+	}
+
+	invitation := &models.FamilyInvitation{
+		FamilyID:       familyID,
+		InviteeAddress: invitee,
+		Role:           role,
+		InvitedBy:      inviter,
+		Status:         models.InvitationStatusPending,
+		ExpiresAt:      now.Add(s.invitationTTL),
+	}
+	if err := s.invitationRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// ListFamilyInvitations 获取家庭发出的邀请，只有所有者可以查看
+func (s *MembershipService) ListFamilyInvitations(familyID uint, walletAddress string) ([]*models.FamilyInvitation, error) {
+	if err := s.AuthorizeOwner(familyID, walletAddress); err != nil {
+		return nil, err
+	}
+	return s.invitationRepo.ListByFamily(familyID)
+}
+
+// PendingInvitations 获取钱包收到的待处理邀请
+func (s *MembershipService) PendingInvitations(walletAddress string) ([]*models.FamilyInvitation, error) {
+	return s.invitationRepo.ListPendingByInvitee(walletAddress, time.Now())
+}
+
+// AcceptInvitation 被邀请人接受邀请，成为家庭成员
+func (s *MembershipService) AcceptInvitation(invitationID uint, walletAddress string) (*models.FamilyMember, error) {
+	invitation, err := s.invitationFor(invitationID, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.memberRepo.GetByFamilyAndWallet(invitation.FamilyID, walletAddress); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	member := &models.FamilyMember{
+		FamilyID:      invitation.FamilyID,
+		WalletAddress: walletAddress,
+		Role:          invitation.Role,
+		InvitedBy:     invitation.InvitedBy,
+	}
+	accepted, err := s.invitationRepo.Accept(invitation, member, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvitationClosed
+	}
+	return member, nil
+}
+
+// DeclineInvitation 被邀请人拒绝邀请
+func (s *MembershipService) DeclineInvitation(invitationID uint, walletAddress string) error {
+	if _, err := s.invitationFor(invitationID, walletAddress); err != nil {
+		return err
+	}
+	return s.respond(invitationID, models.InvitationStatusDeclined)
+}
+
+// RevokeInvitation 所有者撤销尚未处理的邀请
+func (s *MembershipService) RevokeInvitation(familyID, invitationID uint, actor string) error {
+	if err := s.AuthorizeOwner(familyID, actor); err != nil {
+		return err
+	}
+	invitation, err := s.invitationRepo.GetByID(invitationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && invitation.FamilyID != familyID) {
+		return ErrInvitationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.respond(invitationID, models.InvitationStatusRevoked)
+}
+
+func (s *MembershipService) respond(invitationID uint, status string) error {
+	updated, err := s.invitationRepo.Respond(invitationID, status, time.Now())
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrInvitationClosed
+	}
+	return nil
+}
+
+// invitationFor 获取发给该钱包的待处理邀请
+func (s *MembershipService) invitationFor(invitationID uint, walletAddress string) (*models.FamilyInvitation, error) {
+	invitation, err := s.invitationRepo.GetByID(invitationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && invitation.InviteeAddress != walletAddress) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != models.InvitationStatusPending || !time.Now().Before(invitation.ExpiresAt) {
+		return nil, ErrInvitationClosed
+	}
+	return invitation, nil
+}
+
+// memberOf 获取属于该家庭的成员记录
+func (s *MembershipService) memberOf(familyID, memberID uint) (*models.FamilyMember, error) {
+	member, err := s.memberRepo.GetByID(memberID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && member.FamilyID != familyID) {
+		return nil, ErrNotFamilyMember
+	}
+	return member, err
+}
+
+// ensureNotCreator 家庭创建者的地址是孩子关联家庭的键，必须始终是所有者
+func (s *MembershipService) ensureNotCreator(familyID uint, member *models.FamilyMember) error {
+	family, err := s.familyRepo.GetByID(familyID)
+	if err != nil {
+		return err
+	}
+	if family.ParentAddress == member.WalletAddress {
+		return ErrFamilyCreator
+	}
+	return nil
+}
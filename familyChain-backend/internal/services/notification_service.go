@@ -0,0 +1,319 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+)
+
+// ErrInvalidNotificationPreference 通知设置的参数无效
+var ErrInvalidNotificationPreference = errors.New("invalid notification preference")
+
+const (
+	// defaultNotificationLimit 通知列表默认返回的记录数
+	defaultNotificationLimit = 50
+	// maxNotificationLimit 通知列表最多返回的记录数
+	maxNotificationLimit = 100
+)
+
+// NotificationSettings 用户的通知设置，包含所有通知类型
+type NotificationSettings struct {
+	Email        *string                          `json:"email"`
+	EmailEnabled bool                             `json:"email_enabled"` // 服务器是否配置了SMTP
+	Preferences  []*models.NotificationPreference `json:"preferences"`
+}
+
+// NotificationService 把家庭事件转换为站内通知和通知邮件，用户可以按类型关闭渠道
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	familyMemberRepo *repository.FamilyMemberRepository
+	childRepo        *repository.ChildRepository
+	userRepo         *repository.UserRepository
+	emailEnabled     bool
+}
+
+// NewNotificationService 创建一个新的通知服务，emailEnabled为false时只写入站内通知
+func NewNotificationService(
+	notificationRepo *repository.NotificationRepository,
+	familyMemberRepo *repository.FamilyMemberRepository,
+	childRepo *repository.ChildRepository,
+	userRepo *repository.UserRepository,
+	emailEnabled bool,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		familyMemberRepo: familyMemberRepo,
+		childRepo:        childRepo,
+		userRepo:         userRepo,
+		emailEnabled:     emailEnabled,
+	}
+}
+
+// Notify 根据事件生成通知，通过realtime.WithHook在事件发布时调用
+// 提交任务、申请兑换、提出奖品愿望和库存不足通知可以管理家庭的家长，任务的审核结果和愿望的处理结果通知孩子
+func (s *NotificationService) Notify(event realtime.Event) {
+	notification, toParents, childID := s.compose(event)
+	if notification == nil {
+		return
+	}
+
+	var recipients []string
+	if toParents {
+		members, err := s.familyMemberRepo.ListByFamily(event.FamilyID)
+		if err != nil {
+			log.Printf("[notification] 查询家庭 %d 的成员失败: %v", event.FamilyID, err)
+			return
+		}
+		for _, member := range members {
+			if models.CanManageFamily(member.Role) {
+				recipients = append(recipients, member.WalletAddress)
+			}
+		}
+	} else if childID != nil {
+		child, err := s.childRepo.GetByID(*childID)
+		if err != nil {
+			log.Printf("[notification] 查询孩子 %d 失败: %v", *childID, err)
+			return
+		}
+		recipients = append(recipients, child.WalletAddress)
+	}
+
+	for _, walletAddress := range recipients {
+		recipient := *notification
+		recipient.WalletAddress = walletAddress
+		s.deliver(&recipient)
+	}
+}
+
+// compose 生成事件对应的通知内容，不需要通知的事件返回nil
+func (s *NotificationService) compose(event realtime.Event) (*models.Notification, bool, *uint) {
+	notification := &models.Notification{FamilyID: event.FamilyID}
+
+	switch data := event.Data.(type) {
+	case realtime.TaskPayload:
+		notification.TaskID = &data.TaskID
+		switch event.Type {
+		case realtime.EventTaskSubmitted:
+			notification.Type = models.NotificationTaskSubmitted
+			notification.Title = "Task submitted for review"
+			notification.Body = fmt.Sprintf("%s submitted \"%s\". Review it to release the reward.", s.childName(data.AssignedChildID), data.Title)
+			return notification, true, nil
+		case realtime.EventTaskApproved:
+			amount := data.RewardAmount
+			if data.ApprovedRewardAmount != nil {
+				amount = *data.ApprovedRewardAmount
+			}
+			notification.Type = models.NotificationTaskApproved
+			notification.Title = "Task approved"
+			notification.Body = fmt.Sprintf("\"%s\" was approved. You earned %s tokens.", data.Title, amount)
+			return notification, false, data.AssignedChildID
+		case realtime.EventTaskRejected:
+			notification.Type = models.NotificationTaskRejected
+			notification.Title = "Task rejected"
+			notification.Body = fmt.Sprintf("\"%s\" was rejected.", data.Title)
+			if data.Rework {
+				notification.Title = "Task returned for rework"
+				notification.Body = fmt.Sprintf("\"%s\" needs some more work.", data.Title)
+			}
+			if data.Reason != nil && *data.Reason != "" {
+				notification.Body += " Reason: " + *data.Reason
+			}
+			return notification, false, data.AssignedChildID
+		}
+	case realtime.ExchangePayload:
+		notification.ExchangeID = &data.ExchangeID
+		notification.RewardID = &data.RewardID
+		switch event.Type {
+		case realtime.EventExchangeCreated:
+			notification.Type = models.NotificationExchangeRequested
+			notification.Title = "Reward exchange requested"
+			notification.Body = fmt.Sprintf("%s wants to exchange %d tokens for \"%s\". Approve or decline the request.", s.childName(&data.ChildID), data.TokenAmount, data.RewardName)
+			return notification, true, nil
+		case realtime.EventExchangeApproved:
+			notification.Type = models.NotificationExchangeDecided
+			notification.Title = "Reward exchange approved"
+			notification.Body = fmt.Sprintf("Your exchange for \"%s\" was approved.", data.RewardName)
+			return notification, false, &data.ChildID
+		case realtime.EventExchangeDeclined:
+			notification.Type = models.NotificationExchangeDecided
+			notification.Title = "Reward exchange declined"
+			notification.Body = fmt.Sprintf("Your exchange for \"%s\" was declined and your %d tokens were returned.", data.RewardName, data.TokenAmount)
+			return notification, false, &data.ChildID
+		}
+	case realtime.RewardStockPayload:
+		notification.RewardID = &data.RewardID
+		notification.Type = models.NotificationRewardLowStock
+		notification.Title = "Reward running low"
+		notification.Body = fmt.Sprintf("Only %d of \"%s\" left in stock.", data.Stock, data.RewardName)
+		return notification, true, nil
+	case realtime.RewardProposalPayload:
+		notification.ProposalID = &data.ProposalID
+		notification.RewardID = data.RewardID
+		switch event.Type {
+		case realtime.EventRewardProposed:
+			notification.Type = models.NotificationRewardProposed
+			notification.Title = "New reward wish"
+			notification.Body = fmt.Sprintf("%s would like \"%s\". Set a price to add it to the rewards, or decline it.", s.childName(&data.ChildID), data.Name)
+			return notification, true, nil
+		case realtime.EventProposalAccepted:
+			notification.Type = models.NotificationProposalDecided
+			notification.Title = "Reward wish accepted"
+			notification.Body = fmt.Sprintf("\"%s\" was added to the rewards for %d tokens.", data.Name, *data.TokenPrice)
+			return notification, false, &data.ChildID
+		case realtime.EventProposalDeclined:
+			notification.Type = models.NotificationProposalDecided
+			notification.Title = "Reward wish declined"
+			notification.Body = fmt.Sprintf("\"%s\" was declined. Note: %s", data.Name, data.DecisionNote)
+			return notification, false, &data.ChildID
+		}
+	}
+	return nil, false, nil
+}
+
+// childName 获取孩子的名字，查询失败时使用通用的称呼
+func (s *NotificationService) childName(childID *uint) string {
+	if childID != nil {
+		if child, err := s.childRepo.GetByID(*childID); err == nil {
+			return child.Name
+		}
+	}
+	return "Your child"
+}
+
+// deliver 按用户的设置写入站内通知、把邮件加入发送队列
+func (s *NotificationService) deliver(notification *models.Notification) {
+	preference, err := s.preference(notification.WalletAddress, notification.Type)
+	if err != nil {
+		log.Printf("[notification] 读取 %s 的通知设置失败: %v", notification.WalletAddress, err)
+		return
+	}
+
+	if preference.InApp {
+		if err := s.notificationRepo.Create(notification); err != nil {
+			log.Printf("[notification] 写入 %s 的通知失败: %v", notification.WalletAddress, err)
+		}
+	}
+
+	if !s.emailEnabled || !preference.Email {
+		return
+	}
+	user, err := s.userRepo.GetByWalletAddress(notification.WalletAddress)
+	if err != nil || user.Email == nil || *user.Email == "" {
+		return
+	}
+	email := &models.EmailMessage{
+		To:      *user.Email,
+		Subject: "Family Task Chain: " + notification.Title,
+		Body:    notification.Body,
+	}
+	if notification.ID != 0 {
+		email.NotificationID = &notification.ID
+	}
+	if err := s.notificationRepo.CreateEmail(email); err != nil {
+		log.Printf("[notification] 通知邮件加入队列失败: %v", err)
+	}
+}
+
+// preference 获取用户对一种通知的设置，没有保存过时所有渠道都开启
+func (s *NotificationService) preference(walletAddress string, notificationType models.NotificationType) (*models.NotificationPreference, error) {
+	preferences, err := s.notificationRepo.ListPreferences(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	for _, preference := range preferences {
+		if preference.Type == notificationType {
+			return preference, nil
+		}
+	}
+	return &models.NotificationPreference{WalletAddress: walletAddress, Type: notificationType, InApp: true, Email: true}, nil
+}
+
+// List 按时间倒序获取用户的通知和未读数，limit最大为100
+func (s *NotificationService) List(walletAddress string, unreadOnly bool, limit int) ([]*models.Notification, int64, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	notifications, err := s.notificationRepo.ListByWallet(walletAddress, unreadOnly, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.notificationRepo.CountUnread(walletAddress)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, unread, nil
+}
+
+// MarkRead 把用户的一条通知标记为已读
+func (s *NotificationService) MarkRead(id uint, walletAddress string) (*models.Notification, error) {
+	return s.notificationRepo.MarkRead(id, walletAddress, time.Now())
+}
+
+// MarkAllRead 把用户的所有通知标记为已读，返回标记的数量
+func (s *NotificationService) MarkAllRead(walletAddress string) (int64, error) {
+	return s.notificationRepo.MarkAllRead(walletAddress, time.Now())
+}
+
+// GetSettings 获取用户的邮件地址和所有通知类型的渠道设置
+func (s *NotificationService) GetSettings(walletAddress string) (*NotificationSettings, error) {
+	user, err := s.userRepo.GetByWalletAddress(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &NotificationSettings{Email: user.Email, EmailEnabled: s.emailEnabled}
+	for _, notificationType := range models.NotificationTypes {
+		preference, err := s.preference(walletAddress, notificationType)
+		if err != nil {
+			return nil, err
+		}
+		settings.Preferences = append(settings.Preferences, preference)
+	}
+	return settings, nil
+}
+
+// UpdateSettings 修改用户的邮件地址（email为nil时不修改，空字符串表示删除）和提交的通知类型的渠道设置
+func (s *NotificationService) UpdateSettings(walletAddress string, email *string, preferences []*models.NotificationPreference) (*NotificationSettings, error) {
+	user, err := s.userRepo.GetByWalletAddress(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, preference := range preferences {
+		if !models.IsValidNotificationType(string(preference.Type)) {
+			return nil, fmt.Errorf("%w: unknown notification type %q", ErrInvalidNotificationPreference, preference.Type)
+		}
+		preference.WalletAddress = walletAddress
+	}
+
+	var address interface{}
+	if email != nil {
+		if trimmed := strings.TrimSpace(*email); trimmed != "" {
+			parsed, err := mail.ParseAddress(trimmed)
+			if err != nil || parsed.Address != trimmed {
+				return nil, fmt.Errorf("%w: invalid email address", ErrInvalidNotificationPreference)
+			}
+			address = trimmed
+		}
+	}
+
+	if email != nil {
+		if err := s.userRepo.Update(user.ID, map[string]interface{}{"email": address}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.notificationRepo.SavePreferences(preferences); err != nil {
+		return nil, err
+	}
+	return s.GetSettings(walletAddress)
+}
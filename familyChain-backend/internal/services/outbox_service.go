@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/pkg/blockchain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// outboxClaimLease 分发器领取消息后的租约时长，足够完成一次发送和收据检查
+const outboxClaimLease = 2 * time.Minute
+
+// errEscrowInFlight 兑换的托管交易还没有确认，批准或退还要等链上兑换关联之后再发送
+var errEscrowInFlight = errors.New("escrow transaction not confirmed yet")
+
+// errEscrowFailed 兑换的托管交易已经失败，批准改为从孩子的钱包销毁代币，退还被取消
+var errEscrowFailed = errors.New("escrow transaction failed")
+
+// OutboxDispatcher 后台发送链上副作用消息，失败时按指数退避重试，并记录交易收据
+type OutboxDispatcher struct {
+	outboxRepo      *repository.OutboxRepository
+	contractManager *blockchain.ContractManager
+	cfg             config.OutboxConfig
+	broker          realtime.Broker
+}
+
+// NewOutboxDispatcher 创建链上副作用分发器，铸币确认后通过broker通知家庭成员
+func NewOutboxDispatcher(outboxRepo *repository.OutboxRepository, contractManager *blockchain.ContractManager, cfg config.OutboxConfig, broker realtime.Broker) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		outboxRepo:      outboxRepo,
+		contractManager: contractManager,
+		cfg:             cfg,
+		broker:          broker,
+	}
+}
+
+// Run 循环处理到期消息，直到ctx被取消
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	log.Printf("[outbox] 分发器已启动，轮询间隔: %v", d.cfg.PollInterval)
+
+	// 铸币需要后端账户具有铸币权限，启动时检查一次
+	if d.contractManager != nil && d.contractManager.RewardToken != nil {
+		if err := d.contractManager.EnsureMinter(ctx); err != nil {
+			log.Printf("[outbox] 检查铸币权限失败: %v", err)
+		}
+	}
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.DispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[outbox] 分发器已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue 领取并处理一批到期的消息，多个分发器同时运行时每条消息只会被其中一个处理
+func (d *OutboxDispatcher) DispatchDue(ctx context.Context) {
+	msgs, err := d.outboxRepo.ClaimDue(time.Now(), d.cfg.BatchSize, outboxClaimLease)
+	if err != nil {
+		log.Printf("[outbox] 领取待处理消息失败: %v", err)
+		return
+	}
+
+	for i, msg := range msgs {
+		if ctx.Err() != nil {
+			// 没有处理的消息立即释放，不必等租约到期
+			for _, rest := range msgs[i:] {
+				d.release(rest)
+			}
+			return
+		}
+		d.process(ctx, msg)
+		d.release(msg)
+	}
+}
+
+// release 释放消息的租约
+func (d *OutboxDispatcher) release(msg *models.OutboxMessage) {
+	if err := d.outboxRepo.Release(msg.ID); err != nil {
+		log.Printf("[outbox] 消息 %d 释放租约失败: %v", msg.ID, err)
+	}
+}
+
+// process 发送消息（如尚未发送）并检查其交易收据
+func (d *OutboxDispatcher) process(ctx context.Context, msg *models.OutboxMessage) {
+	if msg.TxHash == nil {
+		// 交易签名后先记录哈希和nonce再广播，记录失败时不发送
+		hookCtx := blockchain.WithBeforeBroadcast(ctx, func(tx *types.Transaction) error {
+			rawTx, err := tx.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			txHash := tx.Hash().Hex()
+			nonce := tx.Nonce()
+			if err := d.outboxRepo.MarkSent(msg.ID, txHash, nonce, hexutil.Encode(rawTx), time.Now().Add(d.cfg.PollInterval)); err != nil {
+				return err
+			}
+			msg.TxHash = &txHash
+			msg.Nonce = &nonce
+			return nil
+		})
+		_, err := d.send(hookCtx, msg)
+		if errors.Is(err, errEscrowInFlight) || errors.Is(err, errEscrowFailed) {
+			// 不计入失败次数，托管交易确认后再发送；托管失败时下一轮按改写后的结算处理
+			if err := d.outboxRepo.Reschedule(msg.ID, time.Now().Add(d.cfg.PollInterval)); err != nil {
+				log.Printf("[outbox] 消息 %d 重新排期失败: %v", msg.ID, err)
+			}
+			return
+		}
+		if err != nil {
+			// 广播结果未知时保留已记录的交易，之后按收据跟踪；节点明确拒绝的交易不会上链，丢弃后重新发送
+			d.fail(msg, fmt.Errorf("send: %w", err), !errors.Is(err, blockchain.ErrNotBroadcast))
+			return
+		}
+	}
+
+	receipt, err := d.contractManager.TransactionReceipt(ctx, common.HexToHash(*msg.TxHash))
+	if errors.Is(err, ethereum.NotFound) {
+		if msg.BlockHash != nil {
+			// 之前已被打包，但所在区块被重组掉了，交易回到交易池等待重新打包
+			log.Printf("[outbox] 消息 %d 交易 %s 所在区块 %s 已被重组", msg.ID, *msg.TxHash, *msg.BlockHash)
+			if err := d.outboxRepo.MarkReorged(msg.ID, time.Now().Add(d.cfg.PollInterval)); err != nil {
+				log.Printf("[outbox] 消息 %d 重组状态写入失败: %v", msg.ID, err)
+			}
+			return
+		}
+
+		receipt, err = d.checkUnmined(ctx, msg)
+		if err != nil {
+			d.retry(msg, err)
+			return
+		}
+		if receipt == nil {
+			return
+		}
+	}
+	if err != nil {
+		d.retry(msg, fmt.Errorf("receipt: %w", err))
+		return
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		// 交易被回滚，丢弃该交易并在退避后重新发送
+		d.fail(msg, fmt.Errorf("transaction %s reverted in block %d", *msg.TxHash, receipt.BlockNumber.Uint64()), true)
+		return
+	}
+
+	// 达到确认数之前只记录所在区块，之后每轮重新获取收据，以便发现重组
+	head, err := d.contractManager.BlockNumber(ctx)
+	if err != nil {
+		d.retry(msg, fmt.Errorf("block number: %w", err))
+		return
+	}
+	required := d.contractManager.RequiredConfirmations()
+	if confirmations := blockchain.ConfirmationCount(receipt.BlockNumber.Uint64(), head); confirmations < required {
+		if msg.BlockHash == nil || *msg.BlockHash != receipt.BlockHash.Hex() {
+			log.Printf("[outbox] 消息 %d 交易 %s 已打包在区块 %d，等待确认 (%d/%d)",
+				msg.ID, *msg.TxHash, receipt.BlockNumber.Uint64(), confirmations, required)
+		}
+		if err := d.outboxRepo.MarkMined(msg.ID, receipt.BlockNumber.Uint64(), receipt.BlockHash.Hex(), time.Now().Add(d.cfg.PollInterval)); err != nil {
+			log.Printf("[outbox] 消息 %d 打包状态写入失败: %v", msg.ID, err)
+		}
+		return
+	}
+
+	// 代孩子兑换的交易确认后，从事件中取出链上兑换ID关联到兑换记录
+	if msg.Kind == models.OutboxKindEscrowExchange && msg.ExchangeID != nil {
+		contractExchangeID, err := d.contractManager.ExchangeIDFromReceipt(receipt)
+		if err != nil {
+			d.retry(msg, err)
+			return
+		}
+		if err := d.outboxRepo.LinkExchange(*msg.ExchangeID, contractExchangeID, *msg.TxHash); err != nil {
+			log.Printf("[outbox] 消息 %d 关联链上兑换 %d 失败: %v", msg.ID, contractExchangeID, err)
+			return
+		}
+	}
+
+	task, err := d.outboxRepo.MarkConfirmed(msg, receipt.BlockNumber.Uint64(), receipt.BlockHash.Hex(), receipt.GasUsed, receipt.Status)
+	if err != nil {
+		log.Printf("[outbox] 消息 %d 确认状态写入失败: %v", msg.ID, err)
+		return
+	}
+	log.Printf("[outbox] 消息 %d (%s) 已确认，交易: %s，区块: %d", msg.ID, msg.Kind, *msg.TxHash, receipt.BlockNumber.Uint64())
+
+	// 通知孩子和家长奖励已经到账
+	if d.broker != nil && task != nil && task.FamilyID != nil {
+		d.broker.Publish(realtime.Event{
+			Type:     realtime.EventTokensMinted,
+			FamilyID: *task.FamilyID,
+			ChildID:  task.AssignedChildID,
+			Data: realtime.MintPayload{
+				TaskID:    msg.TaskID,
+				Recipient: msg.Recipient,
+				Amount:    msg.Amount,
+				TxHash:    *msg.TxHash,
+			},
+		})
+	}
+}
+
+// checkUnmined 处理当前交易还没有收据的消息，返回同一个nonce下已经被打包的交易的收据
+// 替换之后原交易仍然可能先被打包，所以检查这个nonce广播过的所有交易，而不是只检查最新的一笔；
+// 没有一笔被打包、而nonce已经被其他交易用掉时，丢弃记录后重新发送；长时间未打包时提高gas价格替换
+func (d *OutboxDispatcher) checkUnmined(ctx context.Context, msg *models.OutboxMessage) (*types.Receipt, error) {
+	// 先读取nonce再检查收据：两次查询之间被打包的交易一定能在收据中找到，不会被误判为nonce被占用
+	var confirmedNonce uint64
+	if msg.Nonce != nil {
+		nonce, err := d.contractManager.ConfirmedNonce(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("nonce: %w", err)
+		}
+		confirmedNonce = nonce
+	}
+
+	txs, err := d.outboxRepo.Transactions(msg.ID)
+	if err != nil {
+		return nil, err
+	}
+	var current *models.OutboxTransaction
+	hashes := []string{*msg.TxHash}
+	for _, tx := range txs {
+		if tx.TxHash == *msg.TxHash {
+			current = tx
+		} else if msg.Nonce != nil && tx.Nonce == *msg.Nonce {
+			hashes = append(hashes, tx.TxHash)
+		}
+	}
+	for _, txHash := range hashes {
+		receipt, err := d.contractManager.TransactionReceipt(ctx, common.HexToHash(txHash))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("receipt: %w", err)
+		}
+		if txHash != *msg.TxHash {
+			log.Printf("[outbox] 消息 %d 替换前的交易 %s 已被打包，改为跟踪这一笔", msg.ID, txHash)
+			if err := d.outboxRepo.AdoptTransaction(msg.ID, txHash); err != nil {
+				return nil, err
+			}
+			msg.TxHash = &txHash
+		}
+		return receipt, nil
+	}
+
+	if msg.Nonce != nil && confirmedNonce > *msg.Nonce {
+		// 这个nonce上的交易都不是这条消息的，可以用新的nonce重新发送
+		d.fail(msg, fmt.Errorf("nonce %d was used by another transaction", *msg.Nonce), true)
+		return nil, nil
+	}
+
+	// 交易尚未被打包；长时间未打包时提高gas价格替换原交易
+	if msg.SentAt != nil && d.cfg.StuckAfter > 0 && time.Since(*msg.SentAt) > d.cfg.StuckAfter {
+		if d.replace(ctx, msg, current) {
+			return nil, nil
+		}
+	}
+	if err := d.outboxRepo.Reschedule(msg.ID, time.Now().Add(d.cfg.PollInterval)); err != nil {
+		log.Printf("[outbox] 消息 %d 重新排期失败: %v", msg.ID, err)
+	}
+	return nil, nil
+}
+
+// replace 用更高的gas价格和相同的nonce重新发送卡住的交易，成功时返回true
+// 新交易在广播之前记录，原交易保留在交易记录中
+func (d *OutboxDispatcher) replace(ctx context.Context, msg *models.OutboxMessage, current *models.OutboxTransaction) bool {
+	if current == nil {
+		// 升级之前发送的消息没有保存签名后的交易，只能等待原交易被打包
+		log.Printf("[outbox] 消息 %d 交易 %s 长时间未打包，但没有可以替换的签名交易", msg.ID, *msg.TxHash)
+		return false
+	}
+	original := new(types.Transaction)
+	rawTx, err := hexutil.Decode(current.RawTx)
+	if err == nil {
+		err = original.UnmarshalBinary(rawTx)
+	}
+	if err != nil {
+		log.Printf("[outbox] 消息 %d 交易 %s 解析失败: %v", msg.ID, current.TxHash, err)
+		return false
+	}
+
+	var txHash string
+	hookCtx := blockchain.WithBeforeBroadcast(ctx, func(tx *types.Transaction) error {
+		rawTx, err := tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		txHash = tx.Hash().Hex()
+		return d.outboxRepo.MarkReplaced(msg.ID, txHash, tx.Nonce(), hexutil.Encode(rawTx), time.Now().Add(d.cfg.PollInterval))
+	})
+	_, err = d.contractManager.ReplaceTransaction(hookCtx, original)
+	if errors.Is(err, blockchain.ErrNotBroadcast) {
+		// 替换交易已经记录，之后和原交易一起检查收据
+		log.Printf("[outbox] 消息 %d 替换交易 %s 广播失败: %v", msg.ID, txHash, err)
+		return true
+	}
+	if err != nil {
+		log.Printf("[outbox] 消息 %d 交易 %s 替换失败: %v", msg.ID, *msg.TxHash, err)
+		return false
+	}
+	log.Printf("[outbox] 消息 %d 交易 %s 长时间未打包，已替换为 %s", msg.ID, *msg.TxHash, txHash)
+	return true
+}
+
+// send 根据消息类型提交对应的链上交易
+func (d *OutboxDispatcher) send(ctx context.Context, msg *models.OutboxMessage) (*types.Transaction, error) {
+	if d.contractManager == nil {
+		return nil, errors.New("contract manager not initialized")
+	}
+
+	switch msg.Kind {
+	case models.OutboxKindMintReward, models.OutboxKindMintContribution:
+		amount, ok := new(big.Int).SetString(msg.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", msg.Amount)
+		}
+		return d.contractManager.MintReward(ctx, common.HexToAddress(msg.Recipient), amount)
+	case models.OutboxKindBurnExchange:
+		amount, ok := new(big.Int).SetString(msg.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", msg.Amount)
+		}
+		return d.contractManager.BurnTokens(ctx, common.HexToAddress(msg.Recipient), amount)
+	case models.OutboxKindEscrowExchange, models.OutboxKindFulfillExchange, models.OutboxKindRefundExchange:
+		return d.sendExchange(ctx, msg)
+	default:
+		return nil, fmt.Errorf("unknown outbox kind %q", msg.Kind)
+	}
+}
+
+// sendExchange 代孩子兑换并托管代币，或者销毁、退还托管的代币
+// 批准和退还需要链上兑换ID，托管交易还在途中时返回errEscrowInFlight，
+// 托管失败时改写这次兑换的结算并返回errEscrowFailed
+func (d *OutboxDispatcher) sendExchange(ctx context.Context, msg *models.OutboxMessage) (*types.Transaction, error) {
+	if msg.ExchangeID == nil {
+		return nil, errors.New("exchange message without exchange id")
+	}
+	contractRewardID, contractExchangeID, err := d.outboxRepo.ExchangeContractIDs(*msg.ExchangeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Kind == models.OutboxKindEscrowExchange {
+		if contractRewardID == nil {
+			return nil, fmt.Errorf("reward of exchange %d is not registered on chain", *msg.ExchangeID)
+		}
+		return d.contractManager.EscrowExchange(ctx, common.HexToAddress(msg.Recipient), new(big.Int).SetUint64(uint64(*contractRewardID)))
+	}
+
+	if contractExchangeID == nil {
+		escrow, err := d.outboxRepo.GetByExchange(*msg.ExchangeID, models.OutboxKindEscrowExchange)
+		if err == nil && (escrow.Status == models.OutboxStatusPending || escrow.Status == models.OutboxStatusSent) {
+			return nil, errEscrowInFlight
+		}
+		if err == nil && escrow.Status == models.OutboxStatusFailed {
+			// 托管失败之后才排队的结算
+			if err := d.outboxRepo.SettleWithoutEscrow(*msg.ExchangeID); err != nil {
+				return nil, err
+			}
+			return nil, errEscrowFailed
+		}
+		// 没有在途的托管交易（例如旧客户端的兑换还没有被索引器关联），按失败重试
+		return nil, fmt.Errorf("exchange %d is not linked to an on-chain exchange", *msg.ExchangeID)
+	}
+
+	exchangeID := new(big.Int).SetUint64(*contractExchangeID)
+	if msg.Kind == models.OutboxKindRefundExchange {
+		return d.contractManager.DeclineExchange(ctx, exchangeID)
+	}
+	return d.contractManager.FulfillExchange(ctx, exchangeID)
+}
+
+// fail 记录一次失败尝试，超过最大次数后标记为失败
+// 保留了交易的消息不会被标记为失败：交易仍可能被打包，之后继续检查收据
+func (d *OutboxDispatcher) fail(msg *models.OutboxMessage, cause error, clearTx bool) {
+	attempts := msg.Attempts + 1
+	status := models.OutboxStatusPending
+	if msg.TxHash != nil && !clearTx {
+		// 已有交易在途，下次只检查收据而不是重新发送
+		status = models.OutboxStatusSent
+	} else if attempts >= d.cfg.MaxAttempts {
+		status = models.OutboxStatusFailed
+	}
+	next := time.Now().Add(exponentialBackoff(d.cfg.BaseBackoff, d.cfg.MaxBackoff, attempts))
+
+	log.Printf("[outbox] 消息 %d (%s) 第 %d 次尝试失败: %v，状态: %s", msg.ID, msg.Kind, attempts, cause, status)
+
+	if err := d.outboxRepo.MarkAttemptFailed(msg.ID, attempts, cause.Error(), status, next, clearTx); err != nil {
+		log.Printf("[outbox] 消息 %d 失败状态写入失败: %v", msg.ID, err)
+	}
+}
+
+// retry 记录一次查询节点或读取记录的错误，不计入尝试次数，状态不变，下一轮重新检查
+func (d *OutboxDispatcher) retry(msg *models.OutboxMessage, cause error) {
+	log.Printf("[outbox] 消息 %d (%s) 检查交易失败: %v，稍后重试", msg.ID, msg.Kind, cause)
+
+	if err := d.outboxRepo.RecordError(msg.ID, cause.Error(), time.Now().Add(d.cfg.PollInterval)); err != nil {
+		log.Printf("[outbox] 消息 %d 错误写入失败: %v", msg.ID, err)
+	}
+}
+
+// exponentialBackoff 计算第attempts次失败后的等待时间，从base开始每次翻倍，不超过max
+func exponentialBackoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+)
+
+var (
+	// ErrInvalidRewardProposal 愿望的参数无效，例如孩子还没有加入家庭
+	ErrInvalidRewardProposal = errors.New("invalid reward proposal")
+	// ErrRewardProposalState 愿望已经被接受或拒绝
+	ErrRewardProposalState = errors.New("reward proposal was already decided")
+)
+
+// maxPendingProposals 每个孩子最多同时等待处理的愿望数
+const maxPendingProposals = 20
+
+// RewardProposalService 处理孩子提出的奖品愿望：家长定价后变成奖品，或者说明原因拒绝
+type RewardProposalService struct {
+	proposalRepo *repository.RewardProposalRepository
+	broker       realtime.Broker
+}
+
+// NewRewardProposalService 创建奖品愿望服务，愿望和处理结果通过broker推送给家庭成员
+func NewRewardProposalService(proposalRepo *repository.RewardProposalRepository, broker realtime.Broker) *RewardProposalService {
+	return &RewardProposalService{
+		proposalRepo: proposalRepo,
+		broker:       broker,
+	}
+}
+
+// Propose 孩子提出一个愿望，记录在孩子所在的家庭
+func (s *RewardProposalService) Propose(child *models.Child, req models.RewardProposalCreateRequest) (*models.RewardProposal, error) {
+	if child.Family == nil {
+		return nil, fmt.Errorf("%w: the child does not belong to a family", ErrInvalidRewardProposal)
+	}
+	name := utils.SanitizeString(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRewardProposal)
+	}
+	pending, err := s.proposalRepo.CountPending(child.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending >= maxPendingProposals {
+		return nil, fmt.Errorf("%w: at most %d wishes can wait for a parent at once", ErrInvalidRewardProposal, maxPendingProposals)
+	}
+
+	proposal := &models.RewardProposal{
+		FamilyID:    child.Family.ID,
+		ChildID:     child.ID,
+		Name:        name,
+		Description: utils.SanitizeString(req.Description),
+		ImageURL:    req.ImageURL,
+	}
+	if err := s.proposalRepo.Create(proposal); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[proposal] 孩子 %d 提出了奖品愿望 %d: %s", child.ID, proposal.ID, proposal.Name)
+	s.publish(realtime.EventRewardProposed, proposal, nil)
+	return proposal, nil
+}
+
+// Get 获取愿望
+func (s *RewardProposalService) Get(id uint) (*models.RewardProposal, error) {
+	return s.proposalRepo.GetByID(id)
+}
+
+// ListByFamily 获取家庭的愿望，status为空时返回全部
+func (s *RewardProposalService) ListByFamily(familyID uint, status models.RewardProposalStatus) ([]*models.RewardProposal, error) {
+	return s.proposalRepo.ListByFamily(familyID, status)
+}
+
+// ListByChild 获取孩子自己的愿望
+func (s *RewardProposalService) ListByChild(childID uint) ([]*models.RewardProposal, error) {
+	return s.proposalRepo.ListByChild(childID)
+}
+
+// Accept 家长为愿望定价，在同一个事务中创建家庭的奖品；没有指定的名称、描述和图片沿用孩子填写的
+func (s *RewardProposalService) Accept(proposal *models.RewardProposal, userID uint, actor string, req models.RewardProposalAcceptRequest) (*models.RewardProposal, error) {
+	if proposal.Status != models.RewardProposalPending {
+		return nil, fmt.Errorf("%w: proposal is %s", ErrRewardProposalState, proposal.Status)
+	}
+
+	reward := &models.Reward{
+		FamilyID:         proposal.FamilyID,
+		Name:             proposal.Name,
+		Description:      proposal.Description,
+		ImageURL:         proposal.ImageURL,
+		TokenPrice:       req.TokenPrice,
+		CreatedBy:        userID,
+		Active:           true,
+		Stock:            1,
+		ContractRewardID: req.ContractRewardID,
+	}
+	if req.Name != nil {
+		reward.Name = utils.SanitizeString(*req.Name)
+	}
+	if req.Description != nil {
+		reward.Description = utils.SanitizeString(*req.Description)
+	}
+	if req.ImageURL != nil {
+		reward.ImageURL = *req.ImageURL
+	}
+	if req.Stock != nil {
+		reward.Stock = *req.Stock
+	}
+	if reward.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRewardProposal)
+	}
+
+	updated, err := s.proposalRepo.Accept(proposal, reward, actor, req.Note)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: proposal was decided by another request", ErrRewardProposalState)
+	}
+
+	log.Printf("[proposal] 奖品愿望 %d 已接受，创建奖品 %d，价格 %d", proposal.ID, reward.ID, reward.TokenPrice)
+	proposal, err = s.proposalRepo.GetByID(proposal.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(realtime.EventProposalAccepted, proposal, reward)
+	return proposal, nil
+}
+
+// Decline 家长拒绝愿望，note说明原因
+func (s *RewardProposalService) Decline(proposal *models.RewardProposal, actor, note string) (*models.RewardProposal, error) {
+	if proposal.Status != models.RewardProposalPending {
+		return nil, fmt.Errorf("%w: proposal is %s", ErrRewardProposalState, proposal.Status)
+	}
+	note = utils.SanitizeString(note)
+	if note == "" {
+		return nil, fmt.Errorf("%w: a note is required to decline a wish", ErrInvalidRewardProposal)
+	}
+
+	updated, err := s.proposalRepo.Decline(proposal, actor, note)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: proposal was decided by another request", ErrRewardProposalState)
+	}
+
+	log.Printf("[proposal] 奖品愿望 %d 已拒绝，操作人: %s", proposal.ID, actor)
+	proposal, err = s.proposalRepo.GetByID(proposal.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(realtime.EventProposalDeclined, proposal, nil)
+	return proposal, nil
+}
+
+// publish 把愿望的变化推送给提出愿望的孩子和家庭中的家长
+func (s *RewardProposalService) publish(eventType realtime.EventType, proposal *models.RewardProposal, reward *models.Reward) {
+	if s.broker == nil {
+		return
+	}
+	payload := realtime.RewardProposalPayload{
+		ProposalID:   proposal.ID,
+		ChildID:      proposal.ChildID,
+		Name:         proposal.Name,
+		Status:       string(proposal.Status),
+		RewardID:     proposal.RewardID,
+		DecisionNote: proposal.DecisionNote,
+		Actor:        proposal.DecidedBy,
+	}
+	if reward != nil {
+		payload.Name = reward.Name
+		payload.TokenPrice = &reward.TokenPrice
+	}
+	s.broker.Publish(realtime.Event{
+		Type:     eventType,
+		FamilyID: proposal.FamilyID,
+		ChildID:  &proposal.ChildID,
+		Data:     payload,
+	})
+}
@@ -2,12 +2,29 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
 	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
 	"eth-for-babies-backend/pkg/blockchain"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidExchange 兑换请求的参数无效，例如奖品已下架或链上兑换不匹配
+	ErrInvalidExchange = errors.New("invalid exchange")
+	// ErrExchangeState 兑换当前的状态不允许这个操作
+	ErrExchangeState = errors.New("exchange status does not allow this action")
+	// ErrOutOfStock 奖品库存不足，并发兑换最后一件时只有一个成功
+	ErrOutOfStock = repository.ErrOutOfStock
+	// ErrInsufficientBalance 孩子的代币余额不足以兑换奖品
+	ErrInsufficientBalance = repository.ErrInsufficientBalance
 )
 
 // RewardService 处理奖品和兑换相关的业务逻辑
@@ -16,27 +33,33 @@ type RewardService struct {
 	exchangeRepo   *repository.ExchangeRepository
 	childRepo      *repository.ChildRepository
 	contractClient *blockchain.ContractManager
+	broker         realtime.Broker
+	// lowStockThreshold 兑换后库存降到这个数量时通知家长
+	lowStockThreshold int
 }
 
-// NewRewardService 创建一个新的奖励服务
+// NewRewardService 创建一个新的奖励服务，兑换和库存的变化通过broker推送给家庭成员
 func NewRewardService(
 	rewardRepo *repository.RewardRepository,
 	exchangeRepo *repository.ExchangeRepository,
 	childRepo *repository.ChildRepository,
 	contractClient *blockchain.ContractManager,
+	broker realtime.Broker,
+	lowStockThreshold int,
 ) *RewardService {
 	return &RewardService{
-		rewardRepo:     rewardRepo,
-		exchangeRepo:   exchangeRepo,
-		childRepo:      childRepo,
-		contractClient: contractClient,
+		rewardRepo:        rewardRepo,
+		exchangeRepo:      exchangeRepo,
+		childRepo:         childRepo,
+		contractClient:    contractClient,
+		broker:            broker,
+		lowStockThreshold: lowStockThreshold,
 	}
 }
 
 // CreateReward 创建新的实物奖励
 func (s *RewardService) CreateReward(ctx context.Context, userID uint, familyID uint, req models.RewardCreateRequest) (uint, error) {
-	// 验证家庭是否存在
-	// TODO: 检查用户是否是该家庭的家长
+	// 调用方已通过MembershipService检查用户可以管理该家庭
 
 	// 添加详细日志
 	fmt.Printf("开始创建奖品 - 用户ID: %d, 家庭ID: %d, 奖品名称: %s\n", userID, familyID, req.Name)
@@ -173,7 +196,9 @@ func (s *RewardService) UpdateReward(ctx context.Context, id uint, req models.Re
 	return nil
 }
 
-// ExchangeReward 兑换奖品
+// ExchangeReward 创建待家长审核的兑换请求
+// 孩子已经在链上兑换时核对并关联链上兑换；否则奖品登记在链上时，由后端代孩子在链上兑换，把代币托管到奖品合约，
+// 其余兑换在账本中扣留代币，家长批准后再从孩子的钱包销毁
 func (s *RewardService) ExchangeReward(ctx context.Context, childID uint, req models.ExchangeCreateRequest) (uint, error) {
 	// 获取孩子信息
 	child, err := s.childRepo.GetByID(childID)
@@ -193,91 +218,297 @@ func (s *RewardService) ExchangeReward(ctx context.Context, childID uint, req mo
 		return 0, fmt.Errorf("reward not found")
 	}
 
+	// 孩子只能兑换自己家庭的奖品
+	if child.Family == nil || child.Family.ID != reward.FamilyID {
+		return 0, fmt.Errorf("%w: reward does not belong to the child's family", ErrInvalidExchange)
+	}
+
 	// 检查奖品是否可用
 	if !reward.Active {
-		return 0, fmt.Errorf("reward is not active")
+		return 0, fmt.Errorf("%w: reward is not active", ErrInvalidExchange)
 	}
 	if reward.Stock <= 0 {
-		return 0, fmt.Errorf("reward is out of stock")
+		return 0, ErrOutOfStock
 	}
 
-	// 创建数据库兑换记录 - 直接设置为已完成状态
-	completedDate := time.Now()
 	exchange := &models.Exchange{
-		RewardID:      uint(req.RewardID),
-		ChildID:       childID,
-		TokenAmount:   reward.TokenPrice,
-		Status:        models.ExchangeStatusCompleted, // 直接设置为已完成
-		CompletedDate: &completedDate,                 // 设置完成时间
-		Notes:         req.Notes,
+		RewardID:    reward.ID,
+		ChildID:     childID,
+		TokenAmount: reward.TokenPrice,
+		Status:      models.ExchangeStatusPending,
+		Notes:       req.Notes,
+	}
+
+	var escrow *models.OutboxMessage
+	switch {
+	case req.ContractExchangeID != nil:
+		// 孩子用自己的钱包在链上兑换，代币已经托管在奖品合约中
+		existing, err := s.exchangeRepo.GetByContractExchangeID(*req.ContractExchangeID)
+		if err == nil {
+			// 索引器已经同步了这次链上兑换
+			return linkedExchange(existing, childID, reward.ID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("failed to get exchange: %w", err)
+		}
+		if err := s.verifyChainExchange(ctx, child, reward, *req.ContractExchangeID); err != nil {
+			return 0, err
+		}
+		exchange.ContractExchangeID = req.ContractExchangeID
+	case reward.ContractRewardID != nil && child.WalletAddress != "":
+		escrow = &models.OutboxMessage{
+			Kind:      models.OutboxKindEscrowExchange,
+			Recipient: child.WalletAddress,
+			Amount:    utils.UnitsToTokenAmount(reward.TokenPrice).String(),
+		}
 	}
 
-	if err := s.exchangeRepo.Create(exchange); err != nil {
+	// 兑换记录、库存和托管消息在同一个事务中写入，余额在事务中按账本核对
+	// 孩子已经在链上托管的兑换不再核对；其余兑换由后端托管或在账本中扣留，没有钱包的孩子没有代币
+	if err := s.exchangeRepo.CreatePending(exchange, child.WalletAddress, escrow, exchange.ContractExchangeID == nil); err != nil {
+		if errors.Is(err, repository.ErrExchangeLinked) {
+			// 并发的请求或索引器在核对期间关联了这次链上兑换，库存只扣减了一次
+			return linkedExchange(exchange, childID, reward.ID)
+		}
+		if errors.Is(err, ErrOutOfStock) || errors.Is(err, ErrInsufficientBalance) {
+			return 0, err
+		}
 		return 0, fmt.Errorf("failed to create exchange in database: %w", err)
 	}
 
-	// 更新奖品库存
-	if err := s.rewardRepo.UpdateStock(uint(req.RewardID), -1); err != nil {
-		fmt.Printf("更新奖品库存失败: %v\n", err)
-		// 继续执行，不返回错误
+	fmt.Printf("兑换请求已记录，等待家长审核，兑换ID: %d, 奖品ID: %d, 孩子ID: %d\n",
+		exchange.ID, reward.ID, childID)
+	s.publishExchange(realtime.EventExchangeCreated, exchange, reward)
+	// 只在库存第一次降到阈值时通知，避免每次兑换都提醒
+	if reward.Stock > s.lowStockThreshold && reward.Stock-1 <= s.lowStockThreshold {
+		s.publishLowStock(reward, reward.Stock-1)
 	}
 
-	// 记录兑换信息
-	notes := "兑换请求已自动完成"
-	if req.TokenBurned {
-		notes = "前端已完成代币销毁，兑换请求已自动完成"
-	} else {
-		notes = "兑换请求已自动完成，代币已销毁"
+	return exchange.ID, nil
+}
+
+// linkedExchange 返回已经关联了链上兑换的记录，记录属于其他孩子或奖品时返回ErrInvalidExchange
+func linkedExchange(existing *models.Exchange, childID, rewardID uint) (uint, error) {
+	if existing.ChildID != childID || existing.RewardID != rewardID {
+		return 0, fmt.Errorf("%w: on-chain exchange %d belongs to another request", ErrInvalidExchange, *existing.ContractExchangeID)
 	}
-	s.exchangeRepo.AddNotes(exchange.ID, notes)
+	return existing.ID, nil
+}
 
-	fmt.Printf("兑换请求已成功记录并自动完成，兑换ID: %d, 奖品ID: %d, 孩子ID: %d\n",
-		exchange.ID, req.RewardID, childID)
+// verifyChainExchange 在链上核对孩子提交的兑换：属于这个孩子和奖品、按当前价格托管了代币，并且还没有被处理
+func (s *RewardService) verifyChainExchange(ctx context.Context, child *models.Child, reward *models.Reward, contractExchangeID uint64) error {
+	if s.contractClient == nil || s.contractClient.RewardRegistry == nil {
+		return fmt.Errorf("%w: blockchain is not configured, cannot verify on-chain exchange", ErrInvalidExchange)
+	}
+	if reward.ContractRewardID == nil {
+		return fmt.Errorf("%w: reward is not registered on chain", ErrInvalidExchange)
+	}
 
-	// 立即返回兑换ID
-	return exchange.ID, nil
+	chainExchange, err := s.contractClient.GetExchange(ctx, contractExchangeID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !chainExchange.Pending():
+		return fmt.Errorf("%w: on-chain exchange %d does not exist or was already settled", ErrInvalidExchange, contractExchangeID)
+	case !strings.EqualFold(chainExchange.Child.Hex(), child.WalletAddress):
+		return fmt.Errorf("%w: on-chain exchange %d was made by another wallet", ErrInvalidExchange, contractExchangeID)
+	case chainExchange.RewardID != uint64(*reward.ContractRewardID):
+		return fmt.Errorf("%w: on-chain exchange %d is for another reward", ErrInvalidExchange, contractExchangeID)
+	case chainExchange.TokenAmount.Cmp(utils.UnitsToTokenAmount(reward.TokenPrice)) != 0:
+		return fmt.Errorf("%w: on-chain exchange %d escrowed a different amount", ErrInvalidExchange, contractExchangeID)
+	}
+	return nil
 }
 
-// UpdateExchangeStatus 更新兑换状态
-func (s *RewardService) UpdateExchangeStatus(ctx context.Context, exchangeID uint, req models.ExchangeUpdateRequest) error {
-	// 获取兑换记录
-	exchange, err := s.exchangeRepo.GetByID(exchangeID)
+// ApproveExchange 家长批准待审核的兑换，兑换的代币在链上销毁
+func (s *RewardService) ApproveExchange(ctx context.Context, exchangeID uint, actor, notes string) error {
+	return s.decide(exchangeID, actor, notes, models.ExchangeStatusApproved)
+}
+
+// DeclineExchange 家长拒绝待审核的兑换，恢复库存，托管的代币在链上退还给孩子，账本中的扣留随之释放
+func (s *RewardService) DeclineExchange(ctx context.Context, exchangeID uint, actor, notes string) error {
+	return s.decide(exchangeID, actor, notes, models.ExchangeStatusDeclined)
+}
+
+// FulfillExchange 家长把已批准兑换的奖品交给孩子
+func (s *RewardService) FulfillExchange(ctx context.Context, exchangeID uint, notes string) error {
+	exchange, reward, err := s.exchangeWithReward(exchangeID)
 	if err != nil {
-		return fmt.Errorf("failed to get exchange: %w", err)
+		return err
 	}
-	if exchange == nil {
-		return fmt.Errorf("exchange not found")
+	if exchange.Status != models.ExchangeStatusApproved {
+		return fmt.Errorf("%w: exchange is %s, only approved exchanges can be fulfilled", ErrExchangeState, exchange.Status)
 	}
 
-	// 获取奖品信息
-	reward, err := s.rewardRepo.GetByID(exchange.RewardID)
+	updates := map[string]interface{}{
+		"status":         models.ExchangeStatusFulfilled,
+		"completed_date": time.Now(),
+	}
+	if notes != "" {
+		updates["notes"] = notes
+	}
+	updated, err := s.exchangeRepo.Transition(exchange, updates, false, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get reward: %w", err)
+		return err
 	}
-	if reward == nil {
-		return fmt.Errorf("reward not found")
+	if !updated {
+		return fmt.Errorf("%w: exchange was changed by another request", ErrExchangeState)
 	}
 
-	// TODO: 检查用户是否有权限更新兑换状态
+	fmt.Printf("兑换记录 %d 的奖品已交付，奖品ID: %d\n", exchangeID, exchange.RewardID)
+	exchange.Status = models.ExchangeStatusFulfilled
+	s.publishExchange(realtime.EventExchangeFulfilled, exchange, reward)
+	return nil
+}
 
-	// 记录状态变更
-	if req.Status == models.ExchangeStatusCompleted {
-		fmt.Printf("兑换记录 %d 已标记为完成，奖品ID: %d\n", exchangeID, exchange.RewardID)
-		// 添加额外备注
-		if req.Notes == "" {
-			req.Notes = "奖品已发放，兑换完成"
+// decide 批准或拒绝待审核的兑换，同一个事务中写入结算兑换代币的链上消息
+func (s *RewardService) decide(exchangeID uint, actor, notes string, status models.ExchangeStatus) error {
+	exchange, reward, err := s.exchangeWithReward(exchangeID)
+	if err != nil {
+		return err
+	}
+	if exchange.Status != models.ExchangeStatusPending {
+		return fmt.Errorf("%w: exchange is %s, only pending exchanges can be approved or declined", ErrExchangeState, exchange.Status)
+	}
+
+	settlement, err := s.settlement(exchange, status)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"status":     status,
+		"decided_by": actor,
+		"decided_at": time.Now(),
+	}
+	if notes != "" {
+		updates["notes"] = notes
+	}
+	updated, err := s.exchangeRepo.Transition(exchange, updates, status == models.ExchangeStatusDeclined, settlement)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: exchange was changed by another request", ErrExchangeState)
+	}
+
+	fmt.Printf("兑换记录 %d 已更新为 %s，奖品ID: %d, 操作人: %s\n", exchangeID, status, exchange.RewardID, actor)
+	exchange.Status = status
+	eventType := realtime.EventExchangeApproved
+	if status == models.ExchangeStatusDeclined {
+		eventType = realtime.EventExchangeDeclined
+	}
+	s.publishExchange(eventType, exchange, reward)
+	return nil
+}
+
+// settlement 按兑换的代币实际所在的位置生成结算消息
+// 托管在奖品合约中的代币，批准时销毁、拒绝时退还；只在账本中扣留的代币，批准时从孩子的钱包销毁，
+// 拒绝时兑换离开待审核状态即释放扣留，不需要上链
+func (s *RewardService) settlement(exchange *models.Exchange, status models.ExchangeStatus) (*models.OutboxMessage, error) {
+	child, err := s.childRepo.GetByID(exchange.ChildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get child: %w", err)
+	}
+	if child.WalletAddress == "" {
+		// 没有钱包的孩子没有链上代币
+		return nil, nil
+	}
+	escrowed, err := s.exchangeRepo.Escrowed(exchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check exchange escrow: %w", err)
+	}
+
+	settlement := &models.OutboxMessage{
+		Recipient: child.WalletAddress,
+		Amount:    utils.UnitsToTokenAmount(exchange.TokenAmount).String(),
+	}
+	switch {
+	case escrowed && status == models.ExchangeStatusDeclined:
+		settlement.Kind = models.OutboxKindRefundExchange
+	case escrowed:
+		settlement.Kind = models.OutboxKindFulfillExchange
+	case status == models.ExchangeStatusDeclined:
+		return nil, nil
+	default:
+		settlement.Kind = models.OutboxKindBurnExchange
+	}
+	return settlement, nil
+}
+
+// UpdateExchangeStatus 兼容旧接口：按目标状态批准、拒绝或交付兑换
+// completed会先批准仍在审核中的兑换，再标记为已交付
+func (s *RewardService) UpdateExchangeStatus(ctx context.Context, exchangeID uint, actor string, req models.ExchangeUpdateRequest) error {
+	switch req.Status {
+	case models.ExchangeStatusApproved, models.ExchangeStatusConfirmed:
+		return s.ApproveExchange(ctx, exchangeID, actor, req.Notes)
+	case models.ExchangeStatusDeclined, models.ExchangeStatusCancelled:
+		return s.DeclineExchange(ctx, exchangeID, actor, req.Notes)
+	case models.ExchangeStatusFulfilled, models.ExchangeStatusCompleted:
+		exchange, err := s.exchangeRepo.GetByID(exchangeID)
+		if err != nil {
+			return err
 		}
-	} else if req.Status == models.ExchangeStatusCancelled {
-		fmt.Printf("兑换记录 %d 已取消，奖品ID: %d\n", exchangeID, exchange.RewardID)
-		// 取消时恢复库存
-		if err := s.rewardRepo.UpdateStock(exchange.RewardID, 1); err != nil {
-			fmt.Printf("恢复奖品库存失败: %v\n", err)
-			// 继续执行，不返回错误
+		if exchange.Status == models.ExchangeStatusPending {
+			if err := s.ApproveExchange(ctx, exchangeID, actor, ""); err != nil {
+				return err
+			}
 		}
+		return s.FulfillExchange(ctx, exchangeID, req.Notes)
+	default:
+		return fmt.Errorf("%w: unsupported status %q", ErrInvalidExchange, req.Status)
 	}
+}
 
-	// 更新数据库状态
-	return s.exchangeRepo.UpdateStatus(exchangeID, req.Status, req.Notes)
+// exchangeWithReward 获取兑换记录和它的奖品
+func (s *RewardService) exchangeWithReward(exchangeID uint) (*models.Exchange, *models.Reward, error) {
+	exchange, err := s.exchangeRepo.GetByID(exchangeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reward, err := s.rewardRepo.GetByID(exchange.RewardID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get reward: %w", err)
+	}
+	return exchange, reward, nil
+}
+
+// publishExchange 把兑换的变化推送给孩子和家庭中的家长
+func (s *RewardService) publishExchange(eventType realtime.EventType, exchange *models.Exchange, reward *models.Reward) {
+	if s.broker == nil {
+		return
+	}
+	s.broker.Publish(realtime.Event{
+		Type:     eventType,
+		FamilyID: reward.FamilyID,
+		ChildID:  &exchange.ChildID,
+		Data: realtime.ExchangePayload{
+			ExchangeID:  exchange.ID,
+			RewardID:    reward.ID,
+			RewardName:  reward.Name,
+			ChildID:     exchange.ChildID,
+			TokenAmount: exchange.TokenAmount,
+			Status:      string(exchange.Status),
+		},
+	})
+}
+
+// publishLowStock 通知家庭中的家长奖品库存不足
+func (s *RewardService) publishLowStock(reward *models.Reward, stock int) {
+	if s.broker == nil {
+		return
+	}
+	s.broker.Publish(realtime.Event{
+		Type:        realtime.EventRewardLowStock,
+		FamilyID:    reward.FamilyID,
+		ParentsOnly: true,
+		Data: realtime.RewardStockPayload{
+			RewardID:   reward.ID,
+			RewardName: reward.Name,
+			Stock:      stock,
+		},
+	})
 }
 
 // GetChildExchanges 获取孩子的兑换记录
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+	"eth-for-babies-backend/pkg/blockchain"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidSavingsGoal 储蓄目标的参数无效，例如奖品不属于孩子的家庭或者锁定的数量超过价格
+	ErrInvalidSavingsGoal = errors.New("invalid savings goal")
+	// ErrSavingsGoalState 储蓄目标已经结束，或者被其他请求修改了
+	ErrSavingsGoalState = errors.New("savings goal is not active")
+	// ErrSavingsGoalExists 孩子已经有这个奖品的储蓄目标
+	ErrSavingsGoalExists = repository.ErrSavingsGoalExists
+)
+
+// savingsRateWindowDays 按最近多少天的铸币估算孩子的收入速度
+const savingsRateWindowDays = 30
+
+// SavingsService 管理孩子的储蓄目标
+// 锁定只在后端记账，代币仍在孩子的钱包中；后端代为兑换其他奖品时不能使用锁定的代币
+type SavingsService struct {
+	goalRepo        *repository.SavingsGoalRepository
+	rewardRepo      *repository.RewardRepository
+	ledgerRepo      *repository.LedgerRepository
+	contractManager *blockchain.ContractManager
+}
+
+// NewSavingsService 创建储蓄目标服务
+func NewSavingsService(
+	goalRepo *repository.SavingsGoalRepository,
+	rewardRepo *repository.RewardRepository,
+	ledgerRepo *repository.LedgerRepository,
+	contractManager *blockchain.ContractManager,
+) *SavingsService {
+	return &SavingsService{
+		goalRepo:        goalRepo,
+		rewardRepo:      rewardRepo,
+		ledgerRepo:      ledgerRepo,
+		contractManager: contractManager,
+	}
+}
+
+// Create 孩子把一个奖品设为储蓄目标，奖品必须属于孩子的家庭并且没有下架
+func (s *SavingsService) Create(child *models.Child, rewardID uint) (*models.SavingsGoal, error) {
+	reward, err := s.rewardRepo.GetByID(rewardID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: reward not found", ErrInvalidSavingsGoal)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if child.Family == nil || child.Family.ID != reward.FamilyID {
+		return nil, fmt.Errorf("%w: reward does not belong to the child's family", ErrInvalidSavingsGoal)
+	}
+	if !reward.Active {
+		return nil, fmt.Errorf("%w: reward is not active", ErrInvalidSavingsGoal)
+	}
+
+	goal := &models.SavingsGoal{ChildID: child.ID, RewardID: reward.ID}
+	if err := s.goalRepo.Create(goal); err != nil {
+		return nil, err
+	}
+	goal.Reward = reward
+	return goal, nil
+}
+
+// Get 获取储蓄目标
+func (s *SavingsService) Get(id uint) (*models.SavingsGoal, error) {
+	return s.goalRepo.GetByID(id)
+}
+
+// ListByChild 获取孩子的储蓄目标
+func (s *SavingsService) ListByChild(childID uint, activeOnly bool) ([]*models.SavingsGoal, error) {
+	return s.goalRepo.ListByChild(childID, activeOnly)
+}
+
+// Lock 把孩子的amount个代币锁定到目标中，锁定后目标中的代币不能超过奖品价格
+// 配置了区块链时按账本核对可用余额
+func (s *SavingsService) Lock(goal *models.SavingsGoal, child *models.Child, amount int) (*models.SavingsGoal, error) {
+	if err := requireReward(goal); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidSavingsGoal)
+	}
+	if goal.Reserved()+amount > goal.Reward.TokenPrice {
+		return nil, fmt.Errorf("%w: the goal would hold more than the reward price of %d tokens", ErrInvalidSavingsGoal, goal.Reward.TokenPrice)
+	}
+	checkBalance := s.contractManager != nil && s.contractManager.RewardToken != nil
+	return s.lock(goal, child.WalletAddress, amount, checkBalance)
+}
+
+// Unlock 把孩子锁定的amount个代币释放回可用余额，家长的配捐不能解锁
+func (s *SavingsService) Unlock(goal *models.SavingsGoal, child *models.Child, amount int) (*models.SavingsGoal, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidSavingsGoal)
+	}
+	if err := requireReward(goal); err != nil {
+		return nil, err
+	}
+	if amount > goal.LockedAmount {
+		return nil, fmt.Errorf("%w: only %d tokens are locked", ErrInvalidSavingsGoal, goal.LockedAmount)
+	}
+	return s.lock(goal, child.WalletAddress, -amount, false)
+}
+
+// requireReward 目标的奖品被删除后，目标已经随之取消，不能再计算进度或修改
+func requireReward(goal *models.SavingsGoal) error {
+	if goal.Reward == nil {
+		return fmt.Errorf("%w: the reward was deleted", ErrSavingsGoalState)
+	}
+	return nil
+}
+
+func (s *SavingsService) lock(goal *models.SavingsGoal, account string, amount int, checkBalance bool) (*models.SavingsGoal, error) {
+	updated, err := s.goalRepo.Lock(goal, account, amount, goal.Reward.TokenPrice, checkBalance)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		return nil, ErrInsufficientBalance
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: savings goal was closed or changed by another request", ErrSavingsGoalState)
+	}
+	return s.goalRepo.GetByID(goal.ID)
+}
+
+// Contribute 家长为目标配捐代币，代币铸造给孩子并锁定在目标中
+// amount为nil时配捐孩子锁定的数量中还没有配捐的部分；配捐后目标中的代币不能超过奖品价格
+func (s *SavingsService) Contribute(goal *models.SavingsGoal, child *models.Child, actor string, amount *int, notes string) (*models.SavingsContribution, error) {
+	if err := requireReward(goal); err != nil {
+		return nil, err
+	}
+	remaining := goal.Reward.TokenPrice - goal.Reserved()
+	value := 0
+	if amount != nil {
+		value = *amount
+		if value <= 0 {
+			return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidSavingsGoal)
+		}
+		if value > remaining {
+			return nil, fmt.Errorf("%w: only %d tokens are missing from the goal", ErrInvalidSavingsGoal, remaining)
+		}
+	} else {
+		value = goal.LockedAmount - goal.MatchedAmount
+		if value > remaining {
+			value = remaining
+		}
+		if value <= 0 {
+			return nil, fmt.Errorf("%w: nothing to match, the child has not locked more tokens than were matched", ErrInvalidSavingsGoal)
+		}
+	}
+
+	contribution := &models.SavingsContribution{
+		ContributedBy: actor,
+		Amount:        value,
+		Notes:         notes,
+	}
+	var mint *models.OutboxMessage
+	if child.WalletAddress != "" {
+		mint = &models.OutboxMessage{
+			Kind:      models.OutboxKindMintContribution,
+			Recipient: child.WalletAddress,
+			Amount:    utils.UnitsToTokenAmount(value).String(),
+		}
+	}
+
+	updated, err := s.goalRepo.AddContribution(goal, contribution, mint, goal.Reward.TokenPrice)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: savings goal was closed or changed by another request", ErrSavingsGoalState)
+	}
+	log.Printf("[savings] 家长 %s 为储蓄目标 %d 配捐 %d 个代币", actor, goal.ID, value)
+	return contribution, nil
+}
+
+// Cancel 取消进行中的目标，锁定的代币和家长的配捐都回到孩子的可用余额
+func (s *SavingsService) Cancel(goal *models.SavingsGoal) error {
+	updated, err := s.goalRepo.Close(goal.ID, models.SavingsGoalCancelled)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: savings goal is %s", ErrSavingsGoalState, goal.Status)
+	}
+	return nil
+}
+
+// Progress 计算目标的进度：锁定在目标中的代币加上孩子的可用余额，不超过奖品价格
+// 预计完成日期按最近的铸币速度估算，家长的配捐也算作收入
+func (s *SavingsService) Progress(goal *models.SavingsGoal, child *models.Child) (*models.SavingsProgress, error) {
+	if err := requireReward(goal); err != nil {
+		return nil, err
+	}
+	target := utils.UnitsToTokenAmount(goal.Reward.TokenPrice)
+	reserved := utils.UnitsToTokenAmount(goal.Reserved())
+	available := new(big.Int)
+	if goal.Status == models.SavingsGoalActive {
+		balance, err := s.goalRepo.Available(child.ID, child.WalletAddress)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Sign() > 0 {
+			available = balance
+		}
+	}
+
+	saved := new(big.Int).Add(reserved, available)
+	if saved.Cmp(target) > 0 {
+		saved.Set(target)
+	}
+	remaining := new(big.Int).Sub(target, saved)
+	percent := 100
+	if target.Sign() > 0 {
+		percent = int(new(big.Int).Div(new(big.Int).Mul(saved, big.NewInt(100)), target).Int64())
+	}
+
+	now := time.Now()
+	minted, err := s.ledgerRepo.MintedSince(strings.ToLower(child.WalletAddress), now.AddDate(0, 0, -savingsRateWindowDays))
+	if err != nil {
+		return nil, err
+	}
+	rate := minted.Div(minted, big.NewInt(savingsRateWindowDays))
+
+	progress := &models.SavingsProgress{
+		Goal:           goal,
+		Target:         target.String(),
+		Reserved:       reserved.String(),
+		Available:      available.String(),
+		Saved:          saved.String(),
+		Remaining:      remaining.String(),
+		Percent:        percent,
+		EarningRate:    rate.String(),
+		RateWindowDays: savingsRateWindowDays,
+	}
+	switch {
+	case remaining.Sign() == 0:
+		progress.EstimatedCompletion = &now
+	case goal.Status == models.SavingsGoalActive && rate.Sign() > 0:
+		// 向上取整到天，超过一百年的不给出日期
+		days := new(big.Int).Add(remaining, new(big.Int).Sub(rate, big.NewInt(1)))
+		days.Div(days, rate)
+		if days.IsInt64() && days.Int64() <= 36500 {
+			eta := now.AddDate(0, 0, int(days.Int64()))
+			progress.EstimatedCompletion = &eta
+		}
+	}
+	return progress, nil
+}
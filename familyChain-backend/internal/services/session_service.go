@@ -0,0 +1,197 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+)
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或所属会话已被撤销
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused 已使用过的刷新令牌被再次提交，整个会话已被撤销
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// TokenPair 登录或刷新后返回给客户端的令牌
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	AccessExpiresAt  time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        uint      `json:"session_id"`
+}
+
+// SessionService 管理登录会话：签发短期访问令牌和轮换的刷新令牌，并支持撤销
+type SessionService struct {
+	sessionRepo *repository.SessionRepository
+	userRepo    *repository.UserRepository
+	jwtManager  *utils.JWTManager
+	refreshTTL  time.Duration
+}
+
+// NewSessionService 创建一个新的会话服务，refreshTTL为刷新令牌的有效期
+func NewSessionService(
+	sessionRepo *repository.SessionRepository,
+	userRepo *repository.UserRepository,
+	jwtManager *utils.JWTManager,
+	refreshTTL time.Duration,
+) *SessionService {
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		userRepo:    userRepo,
+		jwtManager:  jwtManager,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// CreateSession 为登录成功的用户创建会话并签发令牌
+func (s *SessionService) CreateSession(user *models.User, userAgent, ipAddress string) (*TokenPair, error) {
+	now := time.Now()
+	refreshToken, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:     user.ID,
+		UserAgent:  truncate(userAgent, 255),
+		IPAddress:  truncate(ipAddress, 64),
+		LastUsedAt: now,
+	}
+	token := &models.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(session, token); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issue(user, session.ID, refreshToken, token.ExpiresAt)
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌，旧的刷新令牌随即失效
+// 已使用过的刷新令牌再次出现说明令牌可能被盗用，此时撤销整个会话
+func (s *SessionService) Refresh(refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	token, err := s.sessionRepo.GetRefreshTokenByHash(hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load refresh token: %w", err)
+	}
+	if token == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessionRepo.GetByID(token.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil {
+		s.revokeReused(session.ID)
+		return nil, ErrRefreshTokenReused
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	nextToken, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	next := &models.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+	rotated, err := s.sessionRepo.Rotate(token, next, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !rotated {
+		// 并发请求抢先使用了同一个令牌
+		s.revokeReused(session.ID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	return s.issue(user, session.ID, nextToken, next.ExpiresAt)
+}
+
+// Revoke 撤销单个会话（当前设备登出）
+func (s *SessionService) Revoke(sessionID uint) error {
+	return s.sessionRepo.Revoke(sessionID, models.SessionRevokedLogout, time.Now())
+}
+
+// RevokeAll 撤销用户的所有会话（所有设备登出）
+func (s *SessionService) RevokeAll(userID uint) (int64, error) {
+	return s.sessionRepo.RevokeAllForUser(userID, models.SessionRevokedLogoutAll, time.Now())
+}
+
+// IsActive 判断会话是否仍然有效，供认证中间件检查访问令牌
+func (s *SessionService) IsActive(sessionID uint) (bool, error) {
+	if sessionID == 0 {
+		return false, nil
+	}
+	return s.sessionRepo.IsActive(sessionID)
+}
+
+func (s *SessionService) issue(user *models.User, sessionID uint, refreshToken string, refreshExpiresAt time.Time) (*TokenPair, error) {
+	accessToken, err := s.jwtManager.GenerateToken(user.ID, user.WalletAddress, user.Role, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  time.Now().Add(s.jwtManager.TTL()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		SessionID:        sessionID,
+	}, nil
+}
+
+func (s *SessionService) revokeReused(sessionID uint) {
+	log.Printf("警告: 会话 %d 的刷新令牌被重复使用，撤销整个会话", sessionID)
+	if err := s.sessionRepo.Revoke(sessionID, models.SessionRevokedReuse, time.Now()); err != nil {
+		log.Printf("撤销会话 %d 失败: %v", sessionID, err)
+	}
+}
+
+// newRefreshToken 生成随机刷新令牌及其哈希
+func newRefreshToken() (string, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := hex.EncodeToString(bytes)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
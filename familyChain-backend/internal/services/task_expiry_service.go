@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// TaskExpiryService 把超过截止时间还没有提交的任务标记为过期
+// 家庭允许迟交时，分配给孩子的任务在过期时按家庭设置的百分比减少奖励，孩子仍然可以提交
+type TaskExpiryService struct {
+	taskRepo   *repository.TaskRepository
+	familyRepo *repository.FamilyRepository
+}
+
+// NewTaskExpiryService 创建一个新的任务过期服务
+func NewTaskExpiryService(taskRepo *repository.TaskRepository, familyRepo *repository.FamilyRepository) *TaskExpiryService {
+	return &TaskExpiryService{
+		taskRepo:   taskRepo,
+		familyRepo: familyRepo,
+	}
+}
+
+// ExpireOverdue 处理所有截止时间早于now的任务，返回标记为过期的任务数
+// 单个任务失败时记录日志并继续处理其他任务，下一轮会重试
+func (s *TaskExpiryService) ExpireOverdue(now time.Time) (int, error) {
+	tasks, err := s.taskRepo.GetOverdueTasks(now)
+	if err != nil {
+		return 0, err
+	}
+
+	families := make(map[uint]*models.Family)
+	expired := 0
+	for _, task := range tasks {
+		var family *models.Family
+		if task.FamilyID != nil {
+			family = families[*task.FamilyID]
+			if family == nil {
+				family, err = s.familyRepo.GetByID(*task.FamilyID)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					log.Printf("[expiry] 查询任务 %d 的家庭失败: %v", task.ID, err)
+					continue
+				}
+				if family != nil {
+					families[*task.FamilyID] = family
+				}
+			}
+		}
+
+		updates, err := expiryUpdates(task, family, now)
+		if err != nil {
+			log.Printf("[expiry] 任务 %d 的奖励无效: %v", task.ID, err)
+			continue
+		}
+		ok, err := s.taskRepo.Expire(task, updates, expiryEvent(updates))
+		if err != nil {
+			log.Printf("[expiry] 标记任务 %d 过期失败: %v", task.ID, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	if expired > 0 {
+		log.Printf("[expiry] %d 个任务已过期", expired)
+	}
+	return expired, nil
+}
+
+// expiryUpdates 根据家庭的迟交规则生成过期时需要保存的字段，包括给孩子和家长看的过期原因
+func expiryUpdates(task *models.Task, family *models.Family, now time.Time) (map[string]interface{}, error) {
+	reason := fmt.Sprintf("Not submitted before the due date (%s).", task.DueDate.UTC().Format(time.RFC3339))
+	updates := map[string]interface{}{
+		"expired_at": now,
+	}
+
+	switch {
+	case task.AssignedChildID == nil:
+		reason += " The task was never assigned."
+	case family == nil || !family.AllowLateSubmissions:
+		reason += " Late submissions are not accepted."
+	case family.LatePenaltyPercent == 0:
+		reason += " It can still be submitted late for the full reward."
+	default:
+		reduced, err := utils.ReduceReward(task.RewardAmount, family.LatePenaltyPercent)
+		if err != nil {
+			return nil, err
+		}
+		reason += fmt.Sprintf(" It can still be submitted late for %d%% less reward (%s instead of %s).",
+			family.LatePenaltyPercent, reduced, task.RewardAmount)
+		updates["original_reward_amount"] = task.RewardAmount
+		updates["reward_amount"] = reduced
+	}
+
+	updates["expiry_reason"] = reason
+	return updates, nil
+}
+
+// expiryEvent 过期时追加的任务历史，记录过期原因和迟交减少后的奖励
+func expiryEvent(updates map[string]interface{}) *models.TaskEvent {
+	event := &models.TaskEvent{
+		Action: models.TaskEventExpired,
+		Source: models.TaskEventSourceScheduler,
+	}
+	if reason, ok := updates["expiry_reason"].(string); ok {
+		event.Reason = &reason
+	}
+	if reduced, ok := updates["reward_amount"].(string); ok {
+		event.RewardAmount = &reduced
+	}
+	return event
+}
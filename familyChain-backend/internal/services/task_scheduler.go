@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"eth-for-babies-backend/internal/config"
+)
+
+// TaskScheduler 定期为重复任务生成当天的任务实例，并把超过截止时间的任务标记为过期
+type TaskScheduler struct {
+	seriesService *TaskSeriesService
+	expiryService *TaskExpiryService
+	cfg           config.SchedulerConfig
+}
+
+// NewTaskScheduler 创建任务调度器
+func NewTaskScheduler(seriesService *TaskSeriesService, expiryService *TaskExpiryService, cfg config.SchedulerConfig) *TaskScheduler {
+	return &TaskScheduler{
+		seriesService: seriesService,
+		expiryService: expiryService,
+		cfg:           cfg,
+	}
+}
+
+// Run 循环生成到期的任务实例并处理过期任务，直到ctx被取消
+// 两者都是幂等的：同一孩子同一天只会有一个实例，已过期的任务不会再次处理，因此可以频繁轮询
+func (s *TaskScheduler) Run(ctx context.Context) {
+	log.Printf("[scheduler] 任务调度器已启动，轮询间隔: %v", s.cfg.PollInterval)
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.seriesService.MaterializeDue(time.Now()); err != nil {
+			log.Printf("[scheduler] 查询重复任务失败: %v", err)
+		}
+		if _, err := s.expiryService.ExpireOverdue(time.Now()); err != nil {
+			log.Printf("[scheduler] 查询过期任务失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[scheduler] 任务调度器已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTaskSeries 重复任务的参数无效
+var ErrInvalidTaskSeries = errors.New("invalid task series")
+
+// nextOccurrencesShown 详情接口返回的后续出现次数
+const nextOccurrencesShown = 5
+
+// TaskSeriesChanges 修改重复任务时提交的字段，nil表示不修改
+type TaskSeriesChanges struct {
+	Title        *string
+	Description  *string
+	RewardAmount *string
+	Difficulty   *string
+	ImageUrl     *string
+	Frequency    *string
+	Interval     *int
+	Weekdays     *string
+	MonthDay     *int
+	DueTime      *string
+	TimeZone     *string
+	EndDate      *string // 空字符串表示取消结束日期
+	ChildIDs     []uint
+}
+
+// TaskSeriesService 管理重复任务模板，并按日程生成任务实例
+// 实例只在出现的当天生成，因此修改模板只影响之后的实例；错过的日期（例如服务停机或暂停期间）不会补建
+type TaskSeriesService struct {
+	seriesRepo        *repository.TaskSeriesRepository
+	childRepo         *repository.ChildRepository
+	familyRepo        *repository.FamilyRepository
+	membershipService *MembershipService
+	broker            realtime.Broker
+}
+
+// NewTaskSeriesService 创建一个新的重复任务服务，生成的任务通过broker推送给孩子和家长
+func NewTaskSeriesService(
+	seriesRepo *repository.TaskSeriesRepository,
+	childRepo *repository.ChildRepository,
+	familyRepo *repository.FamilyRepository,
+	membershipService *MembershipService,
+	broker realtime.Broker,
+) *TaskSeriesService {
+	return &TaskSeriesService{
+		seriesRepo:        seriesRepo,
+		childRepo:         childRepo,
+		familyRepo:        familyRepo,
+		membershipService: membershipService,
+		broker:            broker,
+	}
+}
+
+// Create 创建重复任务，调用者必须可以管理该家庭；如果今天就是第一次出现，立即生成实例
+func (s *TaskSeriesService) Create(series *models.TaskSeries, childIDs []uint, now time.Time) error {
+	if err := s.membershipService.Authorize(series.FamilyID, series.CreatedBy, true); err != nil {
+		return err
+	}
+	if series.Interval == 0 {
+		series.Interval = 1
+	}
+	if series.DueTime == "" {
+		series.DueTime = "23:59"
+	}
+	if series.TimeZone == "" {
+		series.TimeZone = "UTC"
+	}
+	series.Status = models.TaskSeriesStatusActive
+	if series.StartDate.IsZero() {
+		// 默认从今天（按重复任务的时区）开始
+		if loc, err := time.LoadLocation(series.TimeZone); err == nil {
+			series.StartDate = utils.DateIn(now, loc)
+		}
+	}
+	if _, _, err := seriesRule(series); err != nil {
+		return err
+	}
+	if err := s.checkChildren(series.FamilyID, childIDs); err != nil {
+		return err
+	}
+
+	if err := s.seriesRepo.Create(series, childIDs); err != nil {
+		return err
+	}
+	_, err := s.materialize(series, now)
+	return err
+}
+
+// Get 获取重复任务详情，调用者必须是家庭成员
+func (s *TaskSeriesService) Get(id uint, walletAddress string, now time.Time) (*models.TaskSeries, error) {
+	series, err := s.seriesRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.membershipService.Authorize(series.FamilyID, walletAddress, false); err != nil {
+		return nil, err
+	}
+	s.fillNextOccurrences(series, now)
+	return series, nil
+}
+
+// List 获取调用者所在家庭的重复任务，familyID不为nil时只返回该家庭的
+func (s *TaskSeriesService) List(walletAddress string, familyID *uint, now time.Time) ([]*models.TaskSeries, error) {
+	var familyIDs []uint
+	if familyID != nil {
+		if err := s.membershipService.Authorize(*familyID, walletAddress, false); err != nil {
+			return nil, err
+		}
+		familyIDs = []uint{*familyID}
+	} else {
+		ids, err := s.membershipService.FamilyIDs(walletAddress)
+		if err != nil {
+			return nil, err
+		}
+		familyIDs = ids
+	}
+
+	list, err := s.seriesRepo.ListByFamilies(familyIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, series := range list {
+		s.fillNextOccurrences(series, now)
+	}
+	return list, nil
+}
+
+// Update 修改重复任务模板，只影响之后生成的实例，已经生成的任务保持不变
+func (s *TaskSeriesService) Update(id uint, actor string, changes TaskSeriesChanges, now time.Time) (*models.TaskSeries, error) {
+	series, err := s.managedSeries(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status == models.TaskSeriesStatusEnded {
+		return nil, fmt.Errorf("%w: the series has ended", ErrInvalidTaskSeries)
+	}
+
+	if changes.Title != nil {
+		series.Title = utils.SanitizeString(*changes.Title)
+	}
+	if changes.Description != nil {
+		series.Description = utils.SanitizeString(*changes.Description)
+	}
+	if changes.RewardAmount != nil {
+		series.RewardAmount = *changes.RewardAmount
+	}
+	if changes.Difficulty != nil {
+		series.Difficulty = *changes.Difficulty
+	}
+	if changes.ImageUrl != nil {
+		series.ImageUrl = changes.ImageUrl
+	}
+	if changes.Frequency != nil {
+		// 修改频率时清空只属于旧频率的参数
+		series.Frequency = *changes.Frequency
+		series.Weekdays = ""
+		series.MonthDay = 0
+		series.Interval = 1
+	}
+	if changes.Interval != nil {
+		series.Interval = *changes.Interval
+	}
+	if changes.Weekdays != nil {
+		series.Weekdays = *changes.Weekdays
+	}
+	if changes.MonthDay != nil {
+		series.MonthDay = *changes.MonthDay
+	}
+	if changes.DueTime != nil {
+		series.DueTime = *changes.DueTime
+	}
+	if changes.TimeZone != nil {
+		series.TimeZone = *changes.TimeZone
+	}
+	if changes.EndDate != nil {
+		if *changes.EndDate == "" {
+			series.EndDate = nil
+		} else {
+			endDate, err := utils.ParseDate(*changes.EndDate)
+			if err != nil {
+				return nil, fmt.Errorf("%w: end_date must use YYYY-MM-DD", ErrInvalidTaskSeries)
+			}
+			series.EndDate = &endDate
+		}
+	}
+	if _, _, err := seriesRule(series); err != nil {
+		return nil, err
+	}
+	if changes.ChildIDs != nil {
+		if err := s.checkChildren(series.FamilyID, changes.ChildIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.seriesRepo.Update(series, changes.ChildIDs); err != nil {
+		return nil, err
+	}
+	s.fillNextOccurrences(series, now)
+	return series, nil
+}
+
+// Pause 暂停重复任务，暂停期间不生成实例
+func (s *TaskSeriesService) Pause(id uint, actor string) (*models.TaskSeries, error) {
+	return s.setStatus(id, actor, models.TaskSeriesStatusActive, models.TaskSeriesStatusPaused)
+}
+
+// Resume 恢复暂停的重复任务，暂停期间错过的日期不会补建
+func (s *TaskSeriesService) Resume(id uint, actor string, now time.Time) (*models.TaskSeries, error) {
+	series, err := s.setStatus(id, actor, models.TaskSeriesStatusPaused, models.TaskSeriesStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.materialize(series, now); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// End 结束重复任务，已经生成的任务保持不变
+func (s *TaskSeriesService) End(id uint, actor string) (*models.TaskSeries, error) {
+	series, err := s.managedSeries(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.seriesRepo.UpdateStatus(series.ID, models.TaskSeriesStatusEnded); err != nil {
+		return nil, err
+	}
+	series.Status = models.TaskSeriesStatusEnded
+	return series, nil
+}
+
+// Skip 跳过某一次出现；该日期的任务已经生成但孩子还没有提交时会被删除
+func (s *TaskSeriesService) Skip(id uint, actor, date string) (*models.TaskSeries, error) {
+	series, err := s.managedSeries(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	occurrence, err := utils.ParseDate(date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must use YYYY-MM-DD", ErrInvalidTaskSeries)
+	}
+	rule, _, err := seriesRule(series)
+	if err != nil {
+		return nil, err
+	}
+	if !rule.Occurs(occurrence) {
+		return nil, fmt.Errorf("%w: the series does not occur on %s", ErrInvalidTaskSeries, date)
+	}
+	if err := s.seriesRepo.Skip(series.ID, occurrence); err != nil {
+		return nil, err
+	}
+	return s.seriesRepo.GetByID(series.ID)
+}
+
+// MaterializeDue 为所有进行中的重复任务生成今天的实例，返回创建的任务数
+func (s *TaskSeriesService) MaterializeDue(now time.Time) (int, error) {
+	list, err := s.seriesRepo.ListActive()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, series := range list {
+		created, err := s.materialize(series, now)
+		if err != nil {
+			log.Printf("[scheduler] 重复任务 %d 生成实例失败: %v", series.ID, err)
+			continue
+		}
+		total += created
+	}
+	return total, nil
+}
+
+// materialize 如果重复任务今天（按其时区）出现，为每个分配的孩子生成任务实例
+func (s *TaskSeriesService) materialize(series *models.TaskSeries, now time.Time) (int, error) {
+	if series.Status != models.TaskSeriesStatusActive {
+		return 0, nil
+	}
+	rule, loc, err := seriesRule(series)
+	if err != nil {
+		return 0, err
+	}
+
+	today := utils.DateIn(now, loc)
+	if series.GeneratedThrough != nil && !series.GeneratedThrough.Before(today) {
+		return 0, nil
+	}
+	if rule.Until != nil && today.After(*rule.Until) {
+		if err := s.seriesRepo.UpdateStatus(series.ID, models.TaskSeriesStatusEnded); err != nil {
+			return 0, err
+		}
+		series.Status = models.TaskSeriesStatusEnded
+		return 0, nil
+	}
+	if !rule.Occurs(today) || series.Skipped(today) {
+		return 0, nil
+	}
+
+	family, err := s.familyRepo.GetByID(series.FamilyID)
+	if err != nil {
+		return 0, err
+	}
+	hour, minute, _ := utils.ParseTimeOfDay(series.DueTime)
+	dueDate := time.Date(today.Year(), today.Month(), today.Day(), hour, minute, 0, 0, loc)
+
+	var tasks []*models.Task
+	for _, childID := range series.ChildIDs() {
+		// 孩子已被删除或已不在该家庭时跳过
+		child, err := s.childRepo.GetByID(childID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && child.ParentAddress != family.ParentAddress) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		assigned := childID
+		occurrence := today
+		due := dueDate
+		tasks = append(tasks, &models.Task{
+			Title:           series.Title,
+			Description:     series.Description,
+			RewardAmount:    series.RewardAmount,
+			Difficulty:      series.Difficulty,
+			Status:          "in_progress",
+			ImageUrl:        series.ImageUrl,
+			AssignedChildID: &assigned,
+			CreatedBy:       series.CreatedBy,
+			FamilyID:        &series.FamilyID,
+			SeriesID:        &series.ID,
+			OccurrenceDate:  &occurrence,
+			DueDate:         &due,
+		})
+	}
+
+	created, err := s.seriesRepo.Materialize(series.ID, today, tasks)
+	if err != nil {
+		return 0, err
+	}
+	series.GeneratedThrough = &today
+	if len(created) > 0 {
+		log.Printf("[scheduler] 重复任务 %d 生成了 %s 的 %d 个任务", series.ID, today.Format(utils.DateLayout), len(created))
+	}
+	for _, task := range created {
+		realtime.PublishTaskEvent(s.broker, task, &models.TaskEvent{Action: models.TaskEventCreated, Source: models.TaskEventSourceScheduler})
+	}
+	return len(created), nil
+}
+
+func (s *TaskSeriesService) setStatus(id uint, actor, from, to string) (*models.TaskSeries, error) {
+	series, err := s.managedSeries(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != from {
+		return nil, fmt.Errorf("%w: the series is %s", ErrInvalidTaskSeries, series.Status)
+	}
+	if err := s.seriesRepo.UpdateStatus(series.ID, to); err != nil {
+		return nil, err
+	}
+	series.Status = to
+	return series, nil
+}
+
+// managedSeries 获取调用者可以管理的重复任务
+func (s *TaskSeriesService) managedSeries(id uint, actor string) (*models.TaskSeries, error) {
+	series, err := s.seriesRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.membershipService.Authorize(series.FamilyID, actor, true); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// checkChildren 重复任务至少分配给一个孩子，且孩子必须属于该家庭
+func (s *TaskSeriesService) checkChildren(familyID uint, childIDs []uint) error {
+	if len(childIDs) == 0 {
+		return fmt.Errorf("%w: at least one child is required", ErrInvalidTaskSeries)
+	}
+	family, err := s.familyRepo.GetByID(familyID)
+	if err != nil {
+		return err
+	}
+	for _, childID := range childIDs {
+		child, err := s.childRepo.GetByID(childID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && child.ParentAddress != family.ParentAddress) {
+			return fmt.Errorf("%w: child %d does not belong to this family", ErrInvalidTaskSeries, childID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TaskSeriesService) fillNextOccurrences(series *models.TaskSeries, now time.Time) {
+	if series.Status == models.TaskSeriesStatusEnded {
+		return
+	}
+	rule, loc, err := seriesRule(series)
+	if err != nil {
+		return
+	}
+	from := utils.DateIn(now, loc)
+	if series.GeneratedThrough != nil && !series.GeneratedThrough.Before(from) {
+		from = from.AddDate(0, 0, 1)
+	}
+	series.NextOccurrences = nil
+	for _, date := range rule.Next(from, nextOccurrencesShown+len(series.Skips)) {
+		if series.Skipped(date) {
+			continue
+		}
+		if len(series.NextOccurrences) == nextOccurrencesShown {
+			break
+		}
+		series.NextOccurrences = append(series.NextOccurrences, date.Format(utils.DateLayout))
+	}
+}
+
+// seriesRule 校验模板并返回它的重复规则和时区
+func seriesRule(series *models.TaskSeries) (utils.RecurrenceRule, *time.Location, error) {
+	if series.Title == "" || series.Description == "" {
+		return utils.RecurrenceRule{}, nil, fmt.Errorf("%w: title and description are required", ErrInvalidTaskSeries)
+	}
+	if !utils.IsValidDifficulty(series.Difficulty) {
+		return utils.RecurrenceRule{}, nil, fmt.Errorf("%w: difficulty must be 'easy', 'medium', or 'hard'", ErrInvalidTaskSeries)
+	}
+	if _, err := utils.RewardToTokenAmount(series.RewardAmount); err != nil {
+		return utils.RecurrenceRule{}, nil, fmt.Errorf("%w: %v", ErrInvalidTaskSeries, err)
+	}
+	loc, err := time.LoadLocation(series.TimeZone)
+	if err != nil {
+		return utils.RecurrenceRule{}, nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidTaskSeries, series.TimeZone)
+	}
+	if _, _, err := utils.ParseTimeOfDay(series.DueTime); err != nil {
+		return utils.RecurrenceRule{}, nil, fmt.Errorf("%w: %v", ErrInvalidTaskSeries, err)
+	}
+	weekdays, err := utils.ParseWeekdays(series.Weekdays)
+	if err != nil {
+		return utils.RecurrenceRule{}, nil, fmt.Errorf("%w: %v", ErrInvalidTaskSeries, err)
+	}
+
+	rule := utils.RecurrenceRule{
+		Frequency: series.Frequency,
+		Interval:  series.Interval,
+		Weekdays:  weekdays,
+		MonthDay:  series.MonthDay,
+		Start:     series.StartDate,
+		Until:     series.EndDate,
+	}
+	if err := rule.Validate(); err != nil {
+		return utils.RecurrenceRule{}, nil, fmt.Errorf("%w: %v", ErrInvalidTaskSeries, err)
+	}
+	return rule, loc, nil
+}
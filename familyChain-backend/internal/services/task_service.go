@@ -50,15 +50,41 @@ func (s *TaskService) CreateTask(task *models.Task) error {
 		if err != nil {
 			return errors.New("assigned child not found")
 		}
-		if child.ParentAddress != task.CreatedBy {
-			return errors.New("cannot assign task to child not belonging to you")
+		if err := s.checkChildInFamily(task, child); err != nil {
+			return err
 		}
 		task.Status = "in_progress"
 	} else {
 		task.Status = "pending"
 	}
 
-	return s.taskRepo.Create(task)
+	return s.taskRepo.WithTransaction(func(repo *repository.TaskRepository) error {
+		if err := repo.Create(task); err != nil {
+			return err
+		}
+		return repo.Events().Create(&models.TaskEvent{
+			TaskID:   task.ID,
+			Action:   models.TaskEventCreated,
+			Actor:    task.CreatedBy,
+			ToStatus: task.Status,
+		})
+	})
+}
+
+// checkChildInFamily 验证孩子属于任务所在的家庭；没有家庭的任务只能分配给创建者自己的孩子
+func (s *TaskService) checkChildInFamily(task *models.Task, child *models.Child) error {
+	familyParent := task.CreatedBy
+	if task.FamilyID != nil {
+		family, err := s.familyRepo.GetByID(*task.FamilyID)
+		if err != nil {
+			return errors.New("task family not found")
+		}
+		familyParent = family.ParentAddress
+	}
+	if child.ParentAddress != familyParent {
+		return errors.New("cannot assign task to child outside the task's family")
+	}
+	return nil
 }
 
 // GetTasksByParent 获取家长创建的任务
@@ -76,8 +102,8 @@ func (s *TaskService) GetTaskByID(id uint) (*models.Task, error) {
 	return s.taskRepo.GetByID(id)
 }
 
-// UpdateTask 更新任务
-func (s *TaskService) UpdateTask(id uint, updates map[string]interface{}) error {
+// UpdateTask 更新任务，actor是操作者的钱包地址
+func (s *TaskService) UpdateTask(id uint, actor string, updates map[string]interface{}) error {
 	task, err := s.taskRepo.GetByID(id)
 	if err != nil {
 		return err
@@ -96,8 +122,8 @@ func (s *TaskService) UpdateTask(id uint, updates map[string]interface{}) error
 			if err != nil {
 				return errors.New("assigned child not found")
 			}
-			if child.ParentAddress != task.CreatedBy {
-				return errors.New("cannot assign task to child not belonging to you")
+			if err := s.checkChildInFamily(task, child); err != nil {
+				return err
 			}
 			updates["status"] = "in_progress"
 		} else {
@@ -105,7 +131,26 @@ func (s *TaskService) UpdateTask(id uint, updates map[string]interface{}) error
 		}
 	}
 
-	return s.taskRepo.Update(id, updates)
+	return s.taskRepo.WithTransaction(func(repo *repository.TaskRepository) error {
+		if err := repo.Update(id, updates); err != nil {
+			return err
+		}
+		action := models.TaskEventUpdated
+		if _, exists := updates["assigned_child_id"]; exists {
+			action = models.TaskEventAssigned
+		}
+		toStatus := task.Status
+		if status, ok := updates["status"].(string); ok {
+			toStatus = status
+		}
+		return repo.Events().Create(&models.TaskEvent{
+			TaskID:     id,
+			Action:     action,
+			Actor:      actor,
+			FromStatus: task.Status,
+			ToStatus:   toStatus,
+		})
+	})
 }
 
 // CompleteTask 完成任务
@@ -119,17 +164,42 @@ func (s *TaskService) CompleteTask(id uint, proof string) error {
 		return errors.New("task is not in progress")
 	}
 
+	if task.ChecklistRequired {
+		unchecked, err := s.taskRepo.Checklist().CountUncheckedRequired(id)
+		if err != nil {
+			return err
+		}
+		if unchecked > 0 {
+			return errors.New("all required checklist items must be checked before submitting")
+		}
+	}
+
 	updates := map[string]interface{}{
 		"status":          "completed",
 		"completion_proof": proof,
-		"completed_at":     time.Now(),
+		"submitted_at":     time.Now(),
 	}
 
-	return s.taskRepo.Update(id, updates)
+	event := &models.TaskEvent{
+		TaskID:     id,
+		Action:     models.TaskEventSubmitted,
+		FromStatus: task.Status,
+		ToStatus:   "completed",
+		Proof:      &proof,
+	}
+	if task.AssignedChild != nil {
+		event.Actor = task.AssignedChild.WalletAddress
+	}
+	return s.taskRepo.WithTransaction(func(repo *repository.TaskRepository) error {
+		if err := repo.Update(id, updates); err != nil {
+			return err
+		}
+		return repo.Events().Create(event)
+	})
 }
 
-// ApproveTask 批准任务
-func (s *TaskService) ApproveTask(id uint) error {
+// ApproveTask 批准任务，actor是操作者的钱包地址
+func (s *TaskService) ApproveTask(id uint, actor string) error {
 	task, err := s.taskRepo.GetByID(id)
 	if err != nil {
 		return err
@@ -149,6 +219,16 @@ func (s *TaskService) ApproveTask(id uint) error {
 		if err := repo.Update(id, updates); err != nil {
 			return err
 		}
+		if err := repo.Events().Create(&models.TaskEvent{
+			TaskID:       id,
+			Action:       models.TaskEventApproved,
+			Actor:        actor,
+			FromStatus:   task.Status,
+			ToStatus:     "approved",
+			RewardAmount: &task.RewardAmount,
+		}); err != nil {
+			return err
+		}
 
 		// 更新孩子的统计信息
 		if task.AssignedChildID != nil {
@@ -165,8 +245,8 @@ func (s *TaskService) ApproveTask(id uint) error {
 	return err
 }
 
-// RejectTask 拒绝任务
-func (s *TaskService) RejectTask(id uint, reason string) error {
+// RejectTask 拒绝任务，actor是操作者的钱包地址
+func (s *TaskService) RejectTask(id uint, actor, reason string) error {
 	task, err := s.taskRepo.GetByID(id)
 	if err != nil {
 		return err
@@ -176,13 +256,26 @@ func (s *TaskService) RejectTask(id uint, reason string) error {
 		return errors.New("task is not completed")
 	}
 
+	// 拒绝原因单独保存，孩子提交的凭证保持不变
 	updates := map[string]interface{}{
-		"status":          "rejected",
-		"completion_proof": reason, // 使用completion_proof字段存储拒绝原因
+		"status":           "rejected",
+		"rejection_reason": reason,
 		"rejected_at":      time.Now(),
 	}
 
-	return s.taskRepo.Update(id, updates)
+	return s.taskRepo.WithTransaction(func(repo *repository.TaskRepository) error {
+		if err := repo.Update(id, updates); err != nil {
+			return err
+		}
+		return repo.Events().Create(&models.TaskEvent{
+			TaskID:     id,
+			Action:     models.TaskEventRejected,
+			Actor:      actor,
+			FromStatus: task.Status,
+			ToStatus:   "rejected",
+			Reason:     &reason,
+		})
+	})
 }
 
 // GetTaskStatistics 获取任务统计信息
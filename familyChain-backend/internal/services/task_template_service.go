@@ -0,0 +1,345 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidTaskTemplate 任务模板或批量分配的参数无效
+	ErrInvalidTaskTemplate = errors.New("invalid task template")
+	// ErrBuiltInTemplate 内置模板只能使用，不能修改或删除
+	ErrBuiltInTemplate = errors.New("built-in templates cannot be modified")
+)
+
+// maxTemplateAssignees 一次批量分配最多的孩子数
+const maxTemplateAssignees = 20
+
+// TaskTemplateChanges 修改任务模板时提交的字段，nil表示不修改
+type TaskTemplateChanges struct {
+	Title             *string
+	Description       *string
+	Category          *string
+	RewardAmount      *string
+	Difficulty        *string
+	ProofType         *string
+	ProofInstructions *string
+	ImageUrl          *string
+}
+
+// TemplateAssignment 从模板为多个孩子创建任务时的参数
+type TemplateAssignment struct {
+	// FamilyID 使用内置模板时任务所属的家庭，家长属于多个家庭时必填
+	FamilyID     *uint
+	ChildIDs     []uint
+	DueDate      *time.Time
+	RewardAmount *string // 覆盖模板的奖励
+}
+
+// TaskTemplateService 管理家庭的任务模板和内置的入门模板，并从模板批量创建任务
+type TaskTemplateService struct {
+	templateRepo      *repository.TaskTemplateRepository
+	taskRepo          *repository.TaskRepository
+	childRepo         *repository.ChildRepository
+	familyRepo        *repository.FamilyRepository
+	membershipService *MembershipService
+	broker            realtime.Broker
+}
+
+// NewTaskTemplateService 创建一个新的任务模板服务，从模板分配的任务通过broker推送给孩子和家长
+func NewTaskTemplateService(
+	templateRepo *repository.TaskTemplateRepository,
+	taskRepo *repository.TaskRepository,
+	childRepo *repository.ChildRepository,
+	familyRepo *repository.FamilyRepository,
+	membershipService *MembershipService,
+	broker realtime.Broker,
+) *TaskTemplateService {
+	return &TaskTemplateService{
+		templateRepo:      templateRepo,
+		taskRepo:          taskRepo,
+		childRepo:         childRepo,
+		familyRepo:        familyRepo,
+		membershipService: membershipService,
+		broker:            broker,
+	}
+}
+
+// List 获取内置模板和调用者所在家庭的模板，familyID不为nil时只返回该家庭的模板和内置模板
+func (s *TaskTemplateService) List(walletAddress string, familyID *uint) ([]*models.TaskTemplate, error) {
+	var familyIDs []uint
+	if familyID != nil {
+		if err := s.membershipService.Authorize(*familyID, walletAddress, false); err != nil {
+			return nil, err
+		}
+		familyIDs = []uint{*familyID}
+	} else {
+		ids, err := s.membershipService.FamilyIDs(walletAddress)
+		if err != nil {
+			return nil, err
+		}
+		familyIDs = ids
+	}
+	return s.templateRepo.ListAvailable(familyIDs)
+}
+
+// Get 获取任务模板，家庭模板只有家庭成员可以查看
+func (s *TaskTemplateService) Get(id uint, walletAddress string) (*models.TaskTemplate, error) {
+	template, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !template.BuiltIn() {
+		if err := s.membershipService.Authorize(*template.FamilyID, walletAddress, false); err != nil {
+			return nil, err
+		}
+	}
+	return template, nil
+}
+
+// Create 在家庭中保存一个新模板，调用者必须可以管理该家庭
+func (s *TaskTemplateService) Create(template *models.TaskTemplate) error {
+	if template.FamilyID == nil {
+		return fmt.Errorf("%w: family is required", ErrInvalidTaskTemplate)
+	}
+	if err := s.membershipService.Authorize(*template.FamilyID, template.CreatedBy, true); err != nil {
+		return err
+	}
+	template.CatalogKey = nil
+	if template.ProofType == "" {
+		template.ProofType = models.ProofTypeText
+	}
+	if err := validateTemplate(template); err != nil {
+		return err
+	}
+	return s.templateRepo.Create(template)
+}
+
+// CreateFromTask 把已有任务的内容保存为所在家庭的模板，category为模板分类
+func (s *TaskTemplateService) CreateFromTask(taskID uint, actor, category string) (*models.TaskTemplate, error) {
+	task, err := s.taskRepo.GetByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.membershipService.AuthorizeTask(task, actor, true); err != nil {
+		return nil, err
+	}
+	if task.FamilyID == nil {
+		return nil, fmt.Errorf("%w: the task does not belong to a family", ErrInvalidTaskTemplate)
+	}
+
+	template := &models.TaskTemplate{
+		FamilyID:          task.FamilyID,
+		CreatedBy:         actor,
+		Title:             task.Title,
+		Description:       task.Description,
+		Category:          utils.SanitizeString(category),
+		RewardAmount:      task.RewardAmount,
+		Difficulty:        task.Difficulty,
+		ProofType:         task.ProofType,
+		ProofInstructions: task.ProofInstructions,
+		ImageUrl:          task.ImageUrl,
+	}
+	if err := s.Create(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Update 修改家庭的模板，已经从模板创建的任务不受影响
+func (s *TaskTemplateService) Update(id uint, actor string, changes TaskTemplateChanges) (*models.TaskTemplate, error) {
+	template, err := s.managedTemplate(id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.Title != nil {
+		template.Title = utils.SanitizeString(*changes.Title)
+	}
+	if changes.Description != nil {
+		template.Description = utils.SanitizeString(*changes.Description)
+	}
+	if changes.Category != nil {
+		template.Category = utils.SanitizeString(*changes.Category)
+	}
+	if changes.RewardAmount != nil {
+		template.RewardAmount = *changes.RewardAmount
+	}
+	if changes.Difficulty != nil {
+		template.Difficulty = *changes.Difficulty
+	}
+	if changes.ProofType != nil {
+		template.ProofType = *changes.ProofType
+	}
+	if changes.ProofInstructions != nil {
+		template.ProofInstructions = optionalString(*changes.ProofInstructions)
+	}
+	if changes.ImageUrl != nil {
+		template.ImageUrl = optionalString(*changes.ImageUrl)
+	}
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.Update(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// Delete 删除家庭的模板
+func (s *TaskTemplateService) Delete(id uint, actor string) error {
+	template, err := s.managedTemplate(id, actor)
+	if err != nil {
+		return err
+	}
+	return s.templateRepo.Delete(template.ID)
+}
+
+// Assign 从模板为每个孩子创建一个进行中的任务，所有任务在同一个事务中创建
+// 孩子必须属于任务所在的家庭；内置模板创建的任务属于调用者管理的家庭
+func (s *TaskTemplateService) Assign(id uint, actor string, assignment TemplateAssignment) ([]*models.Task, error) {
+	template, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var family *models.Family
+	if template.BuiltIn() {
+		family, err = s.membershipService.ResolveManagedFamily(actor, assignment.FamilyID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if assignment.FamilyID != nil && *assignment.FamilyID != *template.FamilyID {
+			return nil, fmt.Errorf("%w: the template belongs to another family", ErrInvalidTaskTemplate)
+		}
+		if err := s.membershipService.Authorize(*template.FamilyID, actor, true); err != nil {
+			return nil, err
+		}
+		family, err = s.familyRepo.GetByID(*template.FamilyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rewardAmount := template.RewardAmount
+	if assignment.RewardAmount != nil {
+		rewardAmount = *assignment.RewardAmount
+		if _, err := utils.RewardToTokenAmount(rewardAmount); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTaskTemplate, err)
+		}
+	}
+
+	children, err := s.familyChildren(family, assignment.ChildIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*models.Task, 0, len(children))
+	for _, child := range children {
+		childID := child.ID
+		templateID := template.ID
+		tasks = append(tasks, &models.Task{
+			Title:             template.Title,
+			Description:       template.Description,
+			RewardAmount:      rewardAmount,
+			Difficulty:        template.Difficulty,
+			Status:            "in_progress",
+			ImageUrl:          template.ImageUrl,
+			ProofType:         template.ProofType,
+			ProofInstructions: template.ProofInstructions,
+			AssignedChildID:   &childID,
+			CreatedBy:         actor,
+			FamilyID:          &family.ID,
+			TemplateID:        &templateID,
+			DueDate:           assignment.DueDate,
+		})
+	}
+	if err := s.taskRepo.CreateBatch(tasks); err != nil {
+		return nil, err
+	}
+	for i, child := range children {
+		tasks[i].AssignedChild = child
+		realtime.PublishTaskEvent(s.broker, tasks[i], &models.TaskEvent{Action: models.TaskEventCreated, Actor: tasks[i].CreatedBy})
+	}
+	return tasks, nil
+}
+
+// managedTemplate 获取调用者可以修改的家庭模板
+func (s *TaskTemplateService) managedTemplate(id uint, actor string) (*models.TaskTemplate, error) {
+	template, err := s.templateRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if template.BuiltIn() {
+		return nil, ErrBuiltInTemplate
+	}
+	if err := s.membershipService.Authorize(*template.FamilyID, actor, true); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// familyChildren 去掉重复的孩子ID，并检查每个孩子都属于该家庭
+func (s *TaskTemplateService) familyChildren(family *models.Family, childIDs []uint) ([]*models.Child, error) {
+	if len(childIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one child is required", ErrInvalidTaskTemplate)
+	}
+	if len(childIDs) > maxTemplateAssignees {
+		return nil, fmt.Errorf("%w: at most %d children can be assigned at once", ErrInvalidTaskTemplate, maxTemplateAssignees)
+	}
+
+	seen := make(map[uint]bool, len(childIDs))
+	children := make([]*models.Child, 0, len(childIDs))
+	for _, childID := range childIDs {
+		if seen[childID] {
+			continue
+		}
+		seen[childID] = true
+
+		child, err := s.childRepo.GetByID(childID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && child.ParentAddress != family.ParentAddress) {
+			return nil, fmt.Errorf("%w: child %d does not belong to this family", ErrInvalidTaskTemplate, childID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	return children, nil
+}
+
+// validateTemplate 检查模板的内容，从模板创建的任务会直接使用这些字段
+func validateTemplate(template *models.TaskTemplate) error {
+	if template.Title == "" || template.Description == "" {
+		return fmt.Errorf("%w: title and description are required", ErrInvalidTaskTemplate)
+	}
+	if !utils.IsValidDifficulty(template.Difficulty) {
+		return fmt.Errorf("%w: difficulty must be 'easy', 'medium', or 'hard'", ErrInvalidTaskTemplate)
+	}
+	if !utils.IsValidProofType(template.ProofType) {
+		return fmt.Errorf("%w: proof_type must be 'none', 'text', or 'photo'", ErrInvalidTaskTemplate)
+	}
+	if _, err := utils.RewardToTokenAmount(template.RewardAmount); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTaskTemplate, err)
+	}
+	return nil
+}
+
+// optionalString 空字符串表示清除可选字段
+func optionalString(value string) *string {
+	value = utils.SanitizeString(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
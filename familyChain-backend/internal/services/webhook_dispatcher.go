@@ -0,0 +1,272 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+)
+
+// EventWebhookTest 测试接口发送的事件类型，不受webhook的事件筛选限制
+const EventWebhookTest = "webhook.test"
+
+// webhook请求头
+const (
+	WebhookHeaderEvent     = "X-FamilyChain-Event"
+	WebhookHeaderDelivery  = "X-FamilyChain-Delivery"
+	WebhookHeaderSignature = "X-FamilyChain-Signature"
+)
+
+// maxWebhookResponseBody 投递日志中保存的响应内容的最大字节数
+const maxWebhookResponseBody = 1024
+
+// webhookPayload webhook的请求体
+type webhookPayload struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	FamilyID   uint        `json:"family_id"`
+	ChildID    *uint       `json:"child_id,omitempty"`
+	Data       interface{} `json:"data"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+// WebhookDispatcher 把家庭事件写入webhook投递队列，在后台签名发送，失败时按指数退避重试
+type WebhookDispatcher struct {
+	webhookRepo *repository.WebhookRepository
+	cfg         config.WebhookConfig
+	client      *http.Client
+}
+
+// NewWebhookDispatcher 创建webhook分发器
+func NewWebhookDispatcher(webhookRepo *repository.WebhookRepository, cfg config.WebhookConfig) *WebhookDispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, Control: rejectLinkLocal}
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		cfg:         cfg,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: dialer.DialContext},
+			// 不跟随重定向，3xx按投递失败处理
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// rejectLinkLocal 不允许连接链路本地地址（例如云服务器的元数据服务）
+// 家庭自动化通常部署在局域网中，所以允许私有地址和本机地址
+func rejectLinkLocal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+	return nil
+}
+
+// Enqueue 为家庭中订阅了该事件的webhook各创建一条投递，通过realtime.WithHook在事件发布时调用
+func (d *WebhookDispatcher) Enqueue(event realtime.Event) {
+	webhooks, err := d.webhookRepo.ListActiveByFamily(event.FamilyID)
+	if err != nil {
+		log.Printf("[webhook] 查询家庭 %d 的webhook失败: %v", event.FamilyID, err)
+		return
+	}
+
+	var deliveries []*models.WebhookDelivery
+	var eventID string
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(string(event.Type)) {
+			continue
+		}
+		if payload == nil {
+			if eventID, payload, err = newWebhookPayload(string(event.Type), event.FamilyID, event.ChildID, event.Data, event.OccurredAt); err != nil {
+				log.Printf("[webhook] 事件 %s 序列化失败: %v", event.Type, err)
+				return
+			}
+		}
+		deliveries = append(deliveries, newWebhookDelivery(webhook, string(event.Type), eventID, payload))
+	}
+
+	if err := d.webhookRepo.CreateDeliveries(deliveries); err != nil {
+		log.Printf("[webhook] 事件 %s 写入投递队列失败: %v", event.Type, err)
+	}
+}
+
+// SendTest 立即向webhook发送一条测试事件并返回投递记录，测试事件失败后和其他事件一样重试
+func (d *WebhookDispatcher) SendTest(ctx context.Context, webhook *models.Webhook, actor string) (*models.WebhookDelivery, error) {
+	eventID, payload, err := newWebhookPayload(EventWebhookTest, webhook.FamilyID, nil, map[string]interface{}{
+		"webhook_id": webhook.ID,
+		"actor":      actor,
+		"message":    "This is a test event from Family Task Chain",
+	}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := newWebhookDelivery(webhook, EventWebhookTest, eventID, payload)
+	if err := d.webhookRepo.CreateDeliveries([]*models.WebhookDelivery{delivery}); err != nil {
+		return nil, err
+	}
+	d.deliver(ctx, webhook, delivery)
+	return delivery, nil
+}
+
+// Run 循环投递到期的记录，直到ctx被取消
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	log.Printf("[webhook] 分发器已启动，轮询间隔: %v", d.cfg.PollInterval)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.DispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[webhook] 分发器已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue 投递一批到期的记录
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) {
+	deliveries, err := d.webhookRepo.GetDue(time.Now(), d.cfg.BatchSize)
+	if err != nil {
+		log.Printf("[webhook] 查询待投递记录失败: %v", err)
+		return
+	}
+
+	webhooks := make(map[uint]*models.Webhook)
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			if webhook, err = d.webhookRepo.GetByID(delivery.WebhookID); err != nil {
+				log.Printf("[webhook] 投递 %d 的webhook %d 读取失败: %v", delivery.ID, delivery.WebhookID, err)
+				continue
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+		d.deliver(ctx, webhook, delivery)
+	}
+}
+
+// deliver 发送一次请求并把结果写入投递记录
+func (d *WebhookDispatcher) deliver(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	timestamp := time.Now().Unix()
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		d.recordAttempt(delivery, nil, "", err, 0)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FamilyTaskChain-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderDelivery, delivery.EventID)
+	req.Header.Set(WebhookHeaderSignature, fmt.Sprintf("t=%d,v1=%s", timestamp, utils.WebhookSignature(webhook.Secret, timestamp, body)))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	duration := time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			// 服务正在停止，不计入失败次数
+			return
+		}
+		d.recordAttempt(delivery, nil, "", err, duration)
+		return
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	code := resp.StatusCode
+	if code < 200 || code >= 300 {
+		err = fmt.Errorf("unexpected response status %d", code)
+	}
+	d.recordAttempt(delivery, &code, string(responseBody), err, duration)
+}
+
+// recordAttempt 记录一次投递尝试，失败时按退避时间重新排期，超过最大次数后标记为失败
+func (d *WebhookDispatcher) recordAttempt(delivery *models.WebhookDelivery, code *int, responseBody string, cause error, duration time.Duration) {
+	now := time.Now()
+	durationMs := duration.Milliseconds()
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.ResponseBody = nil
+	if code != nil {
+		delivery.ResponseBody = &responseBody
+	}
+	delivery.DurationMs = &durationMs
+	delivery.LastError = nil
+
+	if cause == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	} else {
+		message := cause.Error()
+		delivery.LastError = &message
+		delivery.Status = models.WebhookDeliveryPending
+		if delivery.Attempts >= d.cfg.MaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+		}
+		delivery.NextAttemptAt = now.Add(exponentialBackoff(d.cfg.BaseBackoff, d.cfg.MaxBackoff, delivery.Attempts))
+		log.Printf("[webhook] 投递 %d (%s) 第 %d 次尝试失败: %v，状态: %s",
+			delivery.ID, delivery.EventType, delivery.Attempts, cause, delivery.Status)
+	}
+
+	if err := d.webhookRepo.SaveAttempt(delivery); err != nil {
+		log.Printf("[webhook] 投递 %d 结果写入失败: %v", delivery.ID, err)
+	}
+}
+
+// newWebhookPayload 生成唯一的事件ID和请求体
+func newWebhookPayload(eventType string, familyID uint, childID *uint, data interface{}, occurredAt time.Time) (string, []byte, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", nil, errors.New("failed to generate event id")
+	}
+	id := "evt_" + hex.EncodeToString(random)
+	payload, err := json.Marshal(webhookPayload{
+		ID:         id,
+		Type:       eventType,
+		FamilyID:   familyID,
+		ChildID:    childID,
+		Data:       data,
+		OccurredAt: occurredAt,
+	})
+	return id, payload, err
+}
+
+// newWebhookDelivery 创建一条待投递的记录
+func newWebhookDelivery(webhook *models.Webhook, eventType, eventID string, payload []byte) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   string(payload),
+	}
+}
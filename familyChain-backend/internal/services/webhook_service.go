@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/utils"
+)
+
+// ErrInvalidWebhook webhook的参数无效
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+const (
+	// maxWebhooksPerFamily 每个家庭最多登记的webhook数
+	maxWebhooksPerFamily = 10
+	// maxWebhookURLLength webhook地址的最大长度
+	maxWebhookURLLength = 2048
+	// defaultWebhookDeliveries 投递日志默认返回的记录数
+	defaultWebhookDeliveries = 50
+)
+
+// WebhookChanges 修改webhook时提交的字段，nil表示不修改
+type WebhookChanges struct {
+	URL         *string
+	Description *string
+	Events      *string
+	Active      *bool
+}
+
+// WebhookService 管理家庭的webhook，只有可以管理家庭的家长才能查看和修改
+type WebhookService struct {
+	webhookRepo       *repository.WebhookRepository
+	membershipService *MembershipService
+	dispatcher        *WebhookDispatcher
+}
+
+// NewWebhookService 创建一个新的webhook服务，测试事件通过dispatcher立即发送
+func NewWebhookService(webhookRepo *repository.WebhookRepository, membershipService *MembershipService, dispatcher *WebhookDispatcher) *WebhookService {
+	return &WebhookService{
+		webhookRepo:       webhookRepo,
+		membershipService: membershipService,
+		dispatcher:        dispatcher,
+	}
+}
+
+// Create 登记webhook并生成签名密钥，密钥只在这里返回一次
+func (s *WebhookService) Create(webhook *models.Webhook) (*models.WebhookWithSecret, error) {
+	if err := s.membershipService.Authorize(webhook.FamilyID, webhook.CreatedBy, true); err != nil {
+		return nil, err
+	}
+	if err := s.normalize(webhook); err != nil {
+		return nil, err
+	}
+
+	count, err := s.webhookRepo.CountByFamily(webhook.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerFamily {
+		return nil, fmt.Errorf("%w: a family can have at most %d webhooks", ErrInvalidWebhook, maxWebhooksPerFamily)
+	}
+
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	webhook.Active = true
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, err
+	}
+	return &models.WebhookWithSecret{Webhook: webhook, Secret: secret}, nil
+}
+
+// List 获取调用者可以管理的家庭的webhook，familyID不为nil时只返回该家庭的
+func (s *WebhookService) List(walletAddress string, familyID *uint) ([]*models.Webhook, error) {
+	var familyIDs []uint
+	if familyID != nil {
+		if err := s.membershipService.Authorize(*familyID, walletAddress, true); err != nil {
+			return nil, err
+		}
+		familyIDs = []uint{*familyID}
+	} else {
+		ids, err := s.membershipService.FamilyIDs(walletAddress)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if s.membershipService.Authorize(id, walletAddress, true) == nil {
+				familyIDs = append(familyIDs, id)
+			}
+		}
+	}
+	return s.webhookRepo.ListByFamilies(familyIDs)
+}
+
+// Get 获取webhook详情
+func (s *WebhookService) Get(id uint, walletAddress string) (*models.Webhook, error) {
+	return s.managedWebhook(id, walletAddress)
+}
+
+// Update 修改webhook，停用后不再投递，已经排队的投递在重新启用后继续
+func (s *WebhookService) Update(id uint, actor string, changes WebhookChanges) (*models.Webhook, error) {
+	webhook, err := s.managedWebhook(id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.URL != nil {
+		webhook.URL = *changes.URL
+	}
+	if changes.Description != nil {
+		webhook.Description = *changes.Description
+	}
+	if changes.Events != nil {
+		webhook.Events = *changes.Events
+	}
+	if changes.Active != nil {
+		webhook.Active = *changes.Active
+	}
+	if err := s.normalize(webhook); err != nil {
+		return nil, err
+	}
+
+	if err := s.webhookRepo.Update(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Delete 删除webhook和它的投递日志
+func (s *WebhookService) Delete(id uint, actor string) error {
+	if _, err := s.managedWebhook(id, actor); err != nil {
+		return err
+	}
+	return s.webhookRepo.Delete(id)
+}
+
+// SendTest 向webhook发送一条测试事件，返回包含响应状态码的投递记录
+func (s *WebhookService) SendTest(ctx context.Context, id uint, actor string) (*models.WebhookDelivery, error) {
+	webhook, err := s.managedWebhook(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.dispatcher.SendTest(ctx, webhook, actor)
+}
+
+// Deliveries 按时间倒序获取webhook最近的投递记录
+func (s *WebhookService) Deliveries(id uint, walletAddress string, limit int) ([]*models.WebhookDelivery, error) {
+	if _, err := s.managedWebhook(id, walletAddress); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > defaultWebhookDeliveries {
+		limit = defaultWebhookDeliveries
+	}
+	return s.webhookRepo.ListDeliveries(id, limit)
+}
+
+// managedWebhook 获取webhook并检查调用者可以管理它所在的家庭
+func (s *WebhookService) managedWebhook(id uint, actor string) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.membershipService.Authorize(webhook.FamilyID, actor, true); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// normalize 检查地址和事件筛选，去掉事件筛选中的空格和重复项
+func (s *WebhookService) normalize(webhook *models.Webhook) error {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	webhook.Description = utils.SanitizeString(webhook.Description)
+
+	if len(webhook.URL) > maxWebhookURLLength {
+		return fmt.Errorf("%w: url is too long", ErrInvalidWebhook)
+	}
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+
+	var events []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(webhook.Events, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !realtime.IsValidEventType(name) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, name)
+		}
+		seen[name] = true
+		events = append(events, name)
+	}
+	webhook.Events = strings.Join(events, ",")
+	return nil
+}
@@ -33,15 +33,16 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-// VerifySignature 验证以太坊签名
-func VerifySignature(walletAddress, message, signature string) (bool, error) {
-	// 检查是否为模拟签名（用于手动输入地址的测试或备用登录方式）
-	if strings.HasPrefix(signature, "0x") && strings.Count(signature, "0") >= 130 {
-		// 对于任何全0或大部分为0的签名，视为模拟签名（仅用于开发/测试环境）
-		fmt.Println("检测到模拟签名，允许绕过验证")
-		return true, nil
-	}
+// mockSignature 前端手动输入地址时使用的模拟签名（0x加130个0）
+var mockSignature = "0x" + strings.Repeat("0", 130)
+
+// IsMockSignature 判断是否为模拟签名，只有开启开发开关时才允许用它登录
+func IsMockSignature(signature string) bool {
+	return signature == mockSignature
+}
 
+// VerifySignature 验证以太坊personal_sign签名
+func VerifySignature(walletAddress, message, signature string) (bool, error) {
 	// 构造签名消息
 	fullMessage := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)
 	hash := crypto.Keccak256Hash([]byte(fullMessage))
@@ -51,6 +52,9 @@ func VerifySignature(walletAddress, message, signature string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if len(sig) != crypto.SignatureLength {
+		return false, fmt.Errorf("invalid signature length %d", len(sig))
+	}
 
 	// 调整v值（以太坊签名格式）
 	if sig[64] == 27 || sig[64] == 28 {
@@ -69,8 +73,3 @@ func VerifySignature(walletAddress, message, signature string) (bool, error) {
 	// 比较地址（忽略大小写）
 	return strings.EqualFold(walletAddress, recoveredAddr.Hex()), nil
 }
-
-// GetSignMessage 获取用于签名的消息
-func GetSignMessage(nonce string) string {
-	return fmt.Sprintf("Welcome to Family Task Chain!\n\nClick to sign in and accept the Terms of Service.\n\nThis request will not trigger a blockchain transaction or cost any gas fees.\n\nNonce: %s", nonce)
-}
@@ -11,28 +11,43 @@ type Claims struct {
 	UserID        uint   `json:"user_id"`
 	WalletAddress string `json:"wallet_address"`
 	Role          string `json:"role"`
+	SessionID     uint   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
 type JWTManager struct {
 	secretKey []byte
+	ttl       time.Duration
 }
 
-func NewJWTManager(secretKey string) *JWTManager {
+// NewJWTManager 创建JWT管理器，ttl为访问令牌的有效期
+func NewJWTManager(secretKey string, ttl time.Duration) *JWTManager {
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
 	return &JWTManager{
 		secretKey: []byte(secretKey),
+		ttl:       ttl,
 	}
 }
 
-func (j *JWTManager) GenerateToken(userID uint, walletAddress, role string) (string, error) {
+// TTL 返回访问令牌的有效期
+func (j *JWTManager) TTL() time.Duration {
+	return j.ttl
+}
+
+// GenerateToken 为会话签发短期访问令牌
+func (j *JWTManager) GenerateToken(userID uint, walletAddress, role string, sessionID uint) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		UserID:        userID,
 		WalletAddress: walletAddress,
 		Role:          role,
+		SessionID:     sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
@@ -57,4 +72,4 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	}
 
 	return nil, errors.New("invalid token")
-}
\ No newline at end of file
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// 内置时区数据库，容器镜像中没有zoneinfo时也能解析家庭设置的时区
+	_ "time/tzdata"
+)
+
+// 重复任务的频率，对应RRULE中的FREQ（工作日为FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR的简写）
+const (
+	FrequencyDaily    = "daily"
+	FrequencyWeekdays = "weekdays"
+	FrequencyWeekly   = "weekly"
+	FrequencyMonthly  = "monthly"
+)
+
+// DateLayout 重复任务中日期参数的格式
+const DateLayout = "2006-01-02"
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceRule 类似RRULE的重复规则，所有日期都是UTC零点表示的日历日期
+type RecurrenceRule struct {
+	Frequency string
+	Interval  int            // 每隔几天/周/月，默认1
+	Weekdays  []time.Weekday // 每周重复时的星期几，为空时使用开始日期的星期
+	MonthDay  int            // 每月重复时的日期，为空时使用开始日期；超过当月天数时取当月最后一天
+	Start     time.Time
+	Until     *time.Time // 最后一次可能出现的日期（包含）
+}
+
+// Validate 检查规则是否有效
+func (r RecurrenceRule) Validate() error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekdays, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return fmt.Errorf("invalid frequency %q", r.Frequency)
+	}
+	if r.Interval < 0 {
+		return errors.New("interval must be positive")
+	}
+	if r.Frequency == FrequencyWeekdays && r.Interval > 1 {
+		return errors.New("interval is not supported for weekdays")
+	}
+	if len(r.Weekdays) > 0 && r.Frequency != FrequencyWeekly {
+		return errors.New("weekdays can only be set for weekly schedules")
+	}
+	if r.MonthDay != 0 && (r.Frequency != FrequencyMonthly || r.MonthDay < 1 || r.MonthDay > 31) {
+		return errors.New("month_day must be between 1 and 31 and only set for monthly schedules")
+	}
+	if r.Start.IsZero() {
+		return errors.New("start date is required")
+	}
+	if r.Until != nil && r.Until.Before(r.Start) {
+		return errors.New("end date must not be before start date")
+	}
+	return nil
+}
+
+// Occurs 判断规则在某个日期是否出现
+func (r RecurrenceRule) Occurs(date time.Time) bool {
+	date = truncateDate(date)
+	start := truncateDate(r.Start)
+	if date.Before(start) || (r.Until != nil && date.After(truncateDate(*r.Until))) {
+		return false
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return daysBetween(start, date)%interval == 0
+	case FrequencyWeekdays:
+		return date.Weekday() != time.Saturday && date.Weekday() != time.Sunday
+	case FrequencyWeekly:
+		weekdays := r.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+		if !containsWeekday(weekdays, date.Weekday()) {
+			return false
+		}
+		// 以周一为一周的开始，计算与开始日期所在周相差的周数
+		return (daysBetween(startOfWeek(start), startOfWeek(date))/7)%interval == 0
+	case FrequencyMonthly:
+		months := (date.Year()-start.Year())*12 + int(date.Month()) - int(start.Month())
+		if months%interval != 0 {
+			return false
+		}
+		day := r.MonthDay
+		if day == 0 {
+			day = start.Day()
+		}
+		if last := daysInMonth(date); day > last {
+			day = last
+		}
+		return date.Day() == day
+	}
+	return false
+}
+
+// Next 返回从from（包含）开始的最多count个出现日期，最多向后查找两年
+func (r RecurrenceRule) Next(from time.Time, count int) []time.Time {
+	var dates []time.Time
+	date := truncateDate(from)
+	if start := truncateDate(r.Start); date.Before(start) {
+		date = start
+	}
+	limit := date.AddDate(2, 0, 0)
+	for ; len(dates) < count && !date.After(limit); date = date.AddDate(0, 0, 1) {
+		if r.Until != nil && date.After(truncateDate(*r.Until)) {
+			break
+		}
+		if r.Occurs(date) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// ParseWeekdays 解析RRULE BYDAY格式的星期列表，例如 "MO,WE,FR"
+func ParseWeekdays(value string) ([]time.Weekday, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	var weekdays []time.Weekday
+	for _, code := range strings.Split(value, ",") {
+		weekday, ok := weekdayCodes[strings.ToUpper(strings.TrimSpace(code))]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", code)
+		}
+		if !containsWeekday(weekdays, weekday) {
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	return weekdays, nil
+}
+
+// ParseDate 解析 2006-01-02 格式的日期
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse(DateLayout, strings.TrimSpace(value))
+}
+
+// DateIn 返回t在指定时区的日历日期（UTC零点）
+func DateIn(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseTimeOfDay 解析 15:04 格式的时间，返回小时和分钟
+func ParseTimeOfDay(value string) (int, int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q, use HH:MM", value)
+	}
+	return parsed.Hour(), parsed.Minute(), nil
+}
+
+func truncateDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func startOfWeek(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+func daysInMonth(date time.Time) int {
+	return time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, w := range weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
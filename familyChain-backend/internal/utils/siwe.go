@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SiweVersion 当前EIP-4361消息版本
+const SiweVersion = "1"
+
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+// EIP-4361要求nonce至少8位字母数字
+var siweNoncePattern = regexp.MustCompile(`^[a-zA-Z0-9]{8,}$`)
+
+// SiweMessage 表示一条Sign-In with Ethereum（EIP-4361）消息
+type SiweMessage struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// String 按EIP-4361格式生成待签名的消息文本
+func (m *SiweMessage) String() string {
+	var b strings.Builder
+	b.WriteString(m.Domain + siweHeaderSuffix + "\n")
+	b.WriteString(m.Address.Hex() + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "URI: %s\n", m.URI)
+	fmt.Fprintf(&b, "Version: %s\n", m.Version)
+	fmt.Fprintf(&b, "Chain ID: %d\n", m.ChainID)
+	fmt.Fprintf(&b, "Nonce: %s\n", m.Nonce)
+	fmt.Fprintf(&b, "Issued At: %s", m.IssuedAt.UTC().Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		fmt.Fprintf(&b, "\nExpiration Time: %s", m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		fmt.Fprintf(&b, "\nNot Before: %s", m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		fmt.Fprintf(&b, "\nRequest ID: %s", m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+	return b.String()
+}
+
+// ValidAt 检查消息在指定时间是否处于有效期内
+func (m *SiweMessage) ValidAt(now time.Time) error {
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return errors.New("message has expired")
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return errors.New("message is not yet valid")
+	}
+	return nil
+}
+
+// ParseSiweMessage 解析EIP-4361消息，并校验每个字段的格式
+func ParseSiweMessage(message string) (*SiweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 8 {
+		return nil, errors.New("message is too short")
+	}
+
+	msg := &SiweMessage{}
+
+	// 头部：域名和地址
+	if !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, errors.New("invalid message header")
+	}
+	msg.Domain = strings.TrimSuffix(lines[0], siweHeaderSuffix)
+	if msg.Domain == "" || strings.ContainsAny(msg.Domain, " /") {
+		return nil, errors.New("invalid domain")
+	}
+
+	if !common.IsHexAddress(lines[1]) || !strings.HasPrefix(lines[1], "0x") {
+		return nil, errors.New("invalid address")
+	}
+	msg.Address = common.HexToAddress(lines[1])
+	if msg.Address.Hex() != lines[1] {
+		return nil, errors.New("address is not EIP-55 checksummed")
+	}
+
+	if lines[2] != "" {
+		return nil, errors.New("expected empty line after address")
+	}
+
+	// 可选的声明，声明前后各有一个空行
+	i := 3
+	if lines[i] != "" {
+		msg.Statement = lines[i]
+		i++
+	}
+	if i >= len(lines) || lines[i] != "" {
+		return nil, errors.New("expected empty line before fields")
+	}
+	i++
+
+	// 字段按固定顺序排列，前五个是必填字段
+	field := func(name string, required bool) (string, bool, error) {
+		prefix := name + ": "
+		if i < len(lines) && strings.HasPrefix(lines[i], prefix) {
+			value := strings.TrimPrefix(lines[i], prefix)
+			i++
+			return value, true, nil
+		}
+		if required {
+			return "", false, fmt.Errorf("missing %s", name)
+		}
+		return "", false, nil
+	}
+
+	value, _, err := field("URI", true)
+	if err != nil {
+		return nil, err
+	}
+	if uri, err := url.Parse(value); err != nil || uri.Scheme == "" {
+		return nil, errors.New("invalid URI")
+	}
+	msg.URI = value
+
+	if msg.Version, _, err = field("Version", true); err != nil {
+		return nil, err
+	}
+	if msg.Version != SiweVersion {
+		return nil, fmt.Errorf("unsupported version %q", msg.Version)
+	}
+
+	if value, _, err = field("Chain ID", true); err != nil {
+		return nil, err
+	}
+	if msg.ChainID, err = strconv.ParseInt(value, 10, 64); err != nil || msg.ChainID <= 0 {
+		return nil, errors.New("invalid chain ID")
+	}
+
+	if msg.Nonce, _, err = field("Nonce", true); err != nil {
+		return nil, err
+	}
+	if !siweNoncePattern.MatchString(msg.Nonce) {
+		return nil, errors.New("invalid nonce")
+	}
+
+	if value, _, err = field("Issued At", true); err != nil {
+		return nil, err
+	}
+	if msg.IssuedAt, err = time.Parse(time.RFC3339, value); err != nil {
+		return nil, errors.New("invalid issued-at time")
+	}
+
+	if value, ok, _ := field("Expiration Time", false); ok {
+		expiration, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.New("invalid expiration time")
+		}
+		msg.ExpirationTime = &expiration
+	}
+
+	if value, ok, _ := field("Not Before", false); ok {
+		notBefore, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.New("invalid not-before time")
+		}
+		msg.NotBefore = &notBefore
+	}
+
+	if value, ok, _ := field("Request ID", false); ok {
+		msg.RequestID = value
+	}
+
+	if i < len(lines) && lines[i] == "Resources:" {
+		i++
+		for i < len(lines) && strings.HasPrefix(lines[i], "- ") {
+			resource := strings.TrimPrefix(lines[i], "- ")
+			if uri, err := url.Parse(resource); err != nil || uri.Scheme == "" {
+				return nil, fmt.Errorf("invalid resource %q", resource)
+			}
+			msg.Resources = append(msg.Resources, resource)
+			i++
+		}
+	}
+
+	if i != len(lines) {
+		return nil, fmt.Errorf("unexpected content on line %d", i+1)
+	}
+
+	return msg, nil
+}
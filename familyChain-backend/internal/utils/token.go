@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// weiPerToken 代币与ETH都使用18位小数
+var weiPerToken = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// TokensPerRewardUnit 每1单位任务奖励（ETH计价）对应的代币数量
+const TokensPerRewardUnit = 10000
+
+// RewardToTokenAmount 将任务奖励金额转换为代币的最小单位数量（18位小数）
+func RewardToTokenAmount(rewardAmount string) (*big.Int, error) {
+	reward, ok := new(big.Float).SetPrec(256).SetString(strings.TrimSpace(rewardAmount))
+	if !ok {
+		return nil, errors.New("invalid reward amount format")
+	}
+	if reward.Sign() < 0 {
+		return nil, errors.New("reward amount must not be negative")
+	}
+
+	// 奖励 × 10000 × 10^18
+	multiplier := new(big.Float).SetPrec(256).SetInt(new(big.Int).Mul(
+		big.NewInt(TokensPerRewardUnit),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+	))
+	amount, _ := new(big.Float).SetPrec(256).Mul(reward, multiplier).Int(nil)
+	return amount, nil
+}
+
+// WeiToEther 将wei转换为ETH计价的十进制字符串，例如 100000000000000000 -> "0.1"
+func WeiToEther(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	value := new(big.Rat).SetFrac(wei, weiPerToken).FloatString(18)
+	value = strings.TrimRight(value, "0")
+	return strings.TrimSuffix(value, ".")
+}
+
+// UnitsToTokenAmount 将整数个代币转换为代币的最小单位数量（18位小数），与TokenAmountToUnits相反
+func UnitsToTokenAmount(units int) *big.Int {
+	return new(big.Int).Mul(big.NewInt(int64(units)), weiPerToken)
+}
+
+// TokenAmountToUnits 将代币的最小单位数量转换为整数个代币（舍去小数部分）
+func TokenAmountToUnits(amount *big.Int) int {
+	if amount == nil {
+		return 0
+	}
+	return int(new(big.Int).Quo(amount, weiPerToken).Int64())
+}
+
+// ReduceReward 按百分比减少任务奖励，返回十进制字符串，例如 ("0.02", 25) -> "0.015"
+func ReduceReward(rewardAmount string, percent int) (string, error) {
+	if percent < 0 || percent > 100 {
+		return "", errors.New("reduction percent must be between 0 and 100")
+	}
+	reward, ok := new(big.Rat).SetString(strings.TrimSpace(rewardAmount))
+	if !ok {
+		return "", errors.New("invalid reward amount format")
+	}
+	reduced := new(big.Rat).Mul(reward, big.NewRat(int64(100-percent), 100))
+	value := strings.TrimRight(reduced.FloatString(18), "0")
+	return strings.TrimSuffix(value, "."), nil
+}
+
+// CompareRewards 比较两个奖励金额，a小于、等于、大于b时分别返回-1、0、1
+func CompareRewards(a, b string) (int, error) {
+	x, ok := new(big.Rat).SetString(strings.TrimSpace(a))
+	if !ok {
+		return 0, errors.New("invalid reward amount format")
+	}
+	y, ok := new(big.Rat).SetString(strings.TrimSpace(b))
+	if !ok {
+		return 0, errors.New("invalid reward amount format")
+	}
+	return x.Cmp(y), nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"regexp"
 	"strings"
 )
@@ -17,6 +18,12 @@ func IsValidEthereumAddress(address string) bool {
 	return matched
 }
 
+// IsValidTxHash 验证交易哈希格式
+func IsValidTxHash(hash string) bool {
+	matched, _ := regexp.MatchString("^0x[a-fA-F0-9]{64}$", hash)
+	return matched
+}
+
 // IsValidRole 验证用户角色
 func IsValidRole(role string) bool {
 	return role == "parent" || role == "child"
@@ -35,7 +42,7 @@ func IsValidDifficulty(difficulty string) bool {
 
 // IsValidTaskStatus 验证任务状态
 func IsValidTaskStatus(status string) bool {
-	validStatuses := []string{"pending", "in_progress", "completed", "approved", "rejected"}
+	validStatuses := []string{"pending", "in_progress", "completed", "approved", "rejected", "expired"}
 	for _, s := range validStatuses {
 		if s == status {
 			return true
@@ -47,4 +54,34 @@ func IsValidTaskStatus(status string) bool {
 // SanitizeString 清理字符串输入
 func SanitizeString(input string) string {
 	return strings.TrimSpace(input)
-}
\ No newline at end of file
+}
+// IsValidProofType 验证任务完成凭证类型
+func IsValidProofType(proofType string) bool {
+	return proofType == "none" || proofType == "text" || proofType == "photo"
+}
+
+// IsValidPhotoURL 验证照片凭证的地址，必须是上传接口返回的地址或外部图片链接
+func IsValidPhotoURL(url string) bool {
+	url = strings.TrimSpace(url)
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "/uploads/")
+}
+
+// ValidateCompletionProof 检查孩子提交的凭证是否满足任务要求的凭证类型
+// 照片凭证必须是上传接口返回的图片地址
+func ValidateCompletionProof(proofType, proof string) error {
+	proof = strings.TrimSpace(proof)
+	switch proofType {
+	case "none":
+		return nil
+	case "photo":
+		if IsValidPhotoURL(proof) {
+			return nil
+		}
+		return errors.New("this task requires a photo: upload an image and submit its URL as completion_proof")
+	default:
+		if proof == "" {
+			return errors.New("completion_proof is required for this task")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 链上副作用outbox：与业务数据在同一事务中写入，由后台分发器发送并记录收据
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind VARCHAR(32) NOT NULL, -- mint_reward
+    task_id INTEGER,
+    recipient VARCHAR(42) NOT NULL,
+    amount VARCHAR(78) NOT NULL, -- wei，十进制字符串
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sent, confirmed, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_error TEXT,
+    tx_hash VARCHAR(66),
+    nonce INTEGER, -- 签名交易的nonce，在广播之前记录
+    sent_at TIMESTAMP,
+    block_number INTEGER,
+    block_hash VARCHAR(66),
+    gas_used INTEGER,
+    receipt_status INTEGER,
+    confirmed_at TIMESTAMP,
+    locked_until TIMESTAMP, -- 分发器领取消息的租约
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_kind ON outbox_messages(kind);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_task_id ON outbox_messages(task_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_status ON outbox_messages(status);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_next_attempt_at ON outbox_messages(next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_tx_hash ON outbox_messages(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_locked_until ON outbox_messages(locked_until);
+
+-- 出站消息广播过的每一笔交易；替换卡住的交易后原交易仍可能被打包，需要保留所有哈希
+CREATE TABLE IF NOT EXISTS outbox_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id INTEGER NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    nonce INTEGER NOT NULL,
+    raw_tx TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (message_id) REFERENCES outbox_messages(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_transactions_message_id ON outbox_transactions(message_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_transactions_tx_hash ON outbox_transactions(tx_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_transactions;
+DROP TABLE IF EXISTS outbox_messages;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 链上事件索引器：每个合约已处理到的区块
+CREATE TABLE IF NOT EXISTS chain_cursors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    contract VARCHAR(64) NOT NULL UNIQUE, -- task_registry, reward_registry
+    address VARCHAR(42) NOT NULL,
+    last_block INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 兑换记录关联链上兑换ID和交易
+ALTER TABLE exchanges ADD COLUMN contract_exchange_id INTEGER;
+ALTER TABLE exchanges ADD COLUMN tx_hash VARCHAR(66);
+CREATE INDEX IF NOT EXISTS idx_exchanges_contract_exchange_id ON exchanges(contract_exchange_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_exchanges_contract_exchange_id;
+ALTER TABLE exchanges DROP COLUMN tx_hash;
+ALTER TABLE exchanges DROP COLUMN contract_exchange_id;
+DROP TABLE IF EXISTS chain_cursors;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 最近已索引区块的哈希，用于检测链重组
+CREATE TABLE IF NOT EXISTS indexed_blocks (
+    number INTEGER PRIMARY KEY,
+    hash VARCHAR(66) NOT NULL,
+    parent_hash VARCHAR(66) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 已写入数据库的合约事件及其修改前的值，区块被重组掉时据此撤销
+CREATE TABLE IF NOT EXISTS indexed_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    contract VARCHAR(64) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    block_number INTEGER NOT NULL,
+    block_hash VARCHAR(66) NOT NULL,
+    log_index INTEGER NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    effects TEXT, -- JSON
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_indexed_events_block_number ON indexed_events(block_number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_indexed_events_log ON indexed_events(block_hash, log_index);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS indexed_events;
+DROP TABLE IF EXISTS indexed_blocks;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Sign-In with Ethereum登录用的一次性nonce
+CREATE TABLE IF NOT EXISTS auth_nonces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nonce VARCHAR(64) NOT NULL UNIQUE,
+    wallet_address VARCHAR(42) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_nonces_wallet_address ON auth_nonces(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_auth_nonces_expires_at ON auth_nonces(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_auth_nonces_expires_at;
+DROP INDEX IF EXISTS idx_auth_nonces_wallet_address;
+DROP TABLE IF EXISTS auth_nonces;
+-- +goose StatementEnd
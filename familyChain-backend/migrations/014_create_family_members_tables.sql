@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 家庭成员：一个家庭可以有多位监护人（所有者、共同家长、只读成员）
+CREATE TABLE IF NOT EXISTS family_members (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    family_id INTEGER NOT NULL,
+    wallet_address VARCHAR(42) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'co_parent', 'viewer')),
+    invited_by VARCHAR(42),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (family_id) REFERENCES families(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_family_members_family_wallet ON family_members(family_id, wallet_address);
+CREATE INDEX IF NOT EXISTS idx_family_members_wallet_address ON family_members(wallet_address);
+
+-- 加入家庭的邀请，由被邀请的钱包接受或拒绝
+CREATE TABLE IF NOT EXISTS family_invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    family_id INTEGER NOT NULL,
+    invitee_address VARCHAR(42) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'co_parent', 'viewer')),
+    invited_by VARCHAR(42) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, accepted, declined, revoked
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (family_id) REFERENCES families(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_family_invitations_family_id ON family_invitations(family_id);
+CREATE INDEX IF NOT EXISTS idx_family_invitations_invitee_address ON family_invitations(invitee_address);
+CREATE INDEX IF NOT EXISTS idx_family_invitations_status ON family_invitations(status);
+
+-- 任务归属的家庭，授权按家庭成员身份而不是创建者地址判断
+ALTER TABLE tasks ADD COLUMN family_id INTEGER REFERENCES families(id);
+CREATE INDEX IF NOT EXISTS idx_tasks_family_id ON tasks(family_id);
+
+-- 现有家庭的所有者成员和任务的family_id在应用启动时回填（见config.backfillFamilyMembers）
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tasks_family_id;
+ALTER TABLE tasks DROP COLUMN family_id;
+DROP INDEX IF EXISTS idx_family_invitations_status;
+DROP INDEX IF EXISTS idx_family_invitations_invitee_address;
+DROP INDEX IF EXISTS idx_family_invitations_family_id;
+DROP TABLE IF EXISTS family_invitations;
+DROP INDEX IF EXISTS idx_family_members_wallet_address;
+DROP INDEX IF EXISTS idx_family_members_family_wallet;
+DROP TABLE IF EXISTS family_members;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 一次性家庭邀请码，只保存邀请码的SHA-256哈希
+CREATE TABLE IF NOT EXISTS family_invite_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    family_id INTEGER NOT NULL,
+    code_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('child', 'co_parent', 'viewer')),
+    child_name TEXT, -- 邀请孩子时预先填写的名字和年龄
+    child_age INTEGER,
+    created_by VARCHAR(42) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    redeemed_by VARCHAR(42),
+    redeemed_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (family_id) REFERENCES families(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_family_invite_codes_family_id ON family_invite_codes(family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_family_invite_codes_family_id;
+DROP TABLE IF EXISTS family_invite_codes;
+-- +goose StatementEnd
@@ -0,0 +1,72 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 重复任务模板，调度器在每次出现的当天为分配的孩子生成任务
+CREATE TABLE IF NOT EXISTS task_series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    family_id INTEGER NOT NULL,
+    created_by VARCHAR(42) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    reward_amount VARCHAR(78) NOT NULL,
+    difficulty VARCHAR(10) NOT NULL CHECK (difficulty IN ('easy', 'medium', 'hard')),
+    image_url TEXT,
+    frequency VARCHAR(20) NOT NULL CHECK (frequency IN ('daily', 'weekdays', 'weekly', 'monthly')),
+    repeat_interval INTEGER NOT NULL DEFAULT 1,
+    weekdays VARCHAR(32), -- RRULE BYDAY格式，例如 MO,WE,FR
+    month_day INTEGER,
+    due_time VARCHAR(5) NOT NULL DEFAULT '23:59',
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    start_date TIMESTAMP NOT NULL,
+    end_date TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'ended')),
+    generated_through TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (family_id) REFERENCES families(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_series_family_id ON task_series(family_id);
+CREATE INDEX IF NOT EXISTS idx_task_series_status ON task_series(status);
+
+CREATE TABLE IF NOT EXISTS task_series_children (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    series_id INTEGER NOT NULL,
+    child_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (series_id) REFERENCES task_series(id) ON DELETE CASCADE,
+    FOREIGN KEY (child_id) REFERENCES children(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_series_children_series_child ON task_series_children(series_id, child_id);
+CREATE INDEX IF NOT EXISTS idx_task_series_children_child_id ON task_series_children(child_id);
+
+CREATE TABLE IF NOT EXISTS task_series_skips (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    series_id INTEGER NOT NULL,
+    occurrence_date TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (series_id) REFERENCES task_series(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_series_skips_series_date ON task_series_skips(series_id, occurrence_date);
+
+-- 由重复任务生成的实例，同一孩子同一日期只有一个
+ALTER TABLE tasks ADD COLUMN series_id INTEGER REFERENCES task_series(id);
+ALTER TABLE tasks ADD COLUMN occurrence_date TIMESTAMP;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_series_occurrence ON tasks(assigned_child_id, series_id, occurrence_date);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tasks_series_occurrence;
+ALTER TABLE tasks DROP COLUMN occurrence_date;
+ALTER TABLE tasks DROP COLUMN series_id;
+DROP INDEX IF EXISTS idx_task_series_skips_series_date;
+DROP TABLE IF EXISTS task_series_skips;
+DROP INDEX IF EXISTS idx_task_series_children_child_id;
+DROP INDEX IF EXISTS idx_task_series_children_series_child;
+DROP TABLE IF EXISTS task_series_children;
+DROP INDEX IF EXISTS idx_task_series_status;
+DROP INDEX IF EXISTS idx_task_series_family_id;
+DROP TABLE IF EXISTS task_series;
+-- +goose StatementEnd
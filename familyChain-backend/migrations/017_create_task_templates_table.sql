@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 任务模板，family_id为空的是内置的入门模板（启动时按catalog_key写入）
+CREATE TABLE IF NOT EXISTS task_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    family_id INTEGER,
+    catalog_key VARCHAR(64),
+    created_by VARCHAR(42),
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    category VARCHAR(32),
+    reward_amount VARCHAR(78) NOT NULL,
+    difficulty VARCHAR(10) NOT NULL CHECK (difficulty IN ('easy', 'medium', 'hard')),
+    proof_type VARCHAR(10) NOT NULL DEFAULT 'text' CHECK (proof_type IN ('none', 'text', 'photo')),
+    proof_instructions TEXT,
+    image_url TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (family_id) REFERENCES families(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_templates_family_id ON task_templates(family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_templates_catalog_key ON task_templates(catalog_key);
+CREATE INDEX IF NOT EXISTS idx_task_templates_deleted_at ON task_templates(deleted_at);
+
+-- 任务的完成凭证要求，以及创建任务时使用的模板
+ALTER TABLE tasks ADD COLUMN proof_type VARCHAR(10) NOT NULL DEFAULT 'text';
+ALTER TABLE tasks ADD COLUMN proof_instructions TEXT;
+ALTER TABLE tasks ADD COLUMN template_id INTEGER REFERENCES task_templates(id);
+CREATE INDEX IF NOT EXISTS idx_tasks_template_id ON tasks(template_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tasks_template_id;
+ALTER TABLE tasks DROP COLUMN template_id;
+ALTER TABLE tasks DROP COLUMN proof_instructions;
+ALTER TABLE tasks DROP COLUMN proof_type;
+DROP INDEX IF EXISTS idx_task_templates_deleted_at;
+DROP INDEX IF EXISTS idx_task_templates_catalog_key;
+DROP INDEX IF EXISTS idx_task_templates_family_id;
+DROP TABLE IF EXISTS task_templates;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 超过截止时间还没有提交的任务会被调度器标记为 expired，并记录原因
+ALTER TABLE tasks ADD COLUMN expired_at TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN expiry_reason TEXT;
+ALTER TABLE tasks ADD COLUMN original_reward_amount VARCHAR(78); -- 迟交减少奖励前的金额
+
+-- 家庭的迟交规则
+ALTER TABLE families ADD COLUMN allow_late_submissions BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE families ADD COLUMN late_penalty_percent INTEGER NOT NULL DEFAULT 0 CHECK (late_penalty_percent BETWEEN 0 AND 100);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE families DROP COLUMN late_penalty_percent;
+ALTER TABLE families DROP COLUMN allow_late_submissions;
+ALTER TABLE tasks DROP COLUMN original_reward_amount;
+ALTER TABLE tasks DROP COLUMN expiry_reason;
+ALTER TABLE tasks DROP COLUMN expired_at;
+-- +goose StatementEnd
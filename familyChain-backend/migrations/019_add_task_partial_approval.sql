@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 家长批准时可以只发放部分奖励，并说明原因
+ALTER TABLE tasks ADD COLUMN approved_reward_amount VARCHAR(78);
+ALTER TABLE tasks ADD COLUMN approval_reason TEXT;
+
+-- 提交的任务可以退回给孩子重做，而不是直接拒绝
+ALTER TABLE tasks ADD COLUMN rework_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN rework_requested_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tasks DROP COLUMN rework_requested_at;
+ALTER TABLE tasks DROP COLUMN rework_count;
+ALTER TABLE tasks DROP COLUMN approval_reason;
+ALTER TABLE tasks DROP COLUMN approved_reward_amount;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 任务历史，每次状态变化追加一条记录，不修改也不删除
+-- 引入任务历史之前的任务由服务启动时的backfillTaskEvents根据任务上的时间字段补建
+CREATE TABLE IF NOT EXISTS task_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    action VARCHAR(32) NOT NULL, -- created, updated, assigned, submitted, approved, rejected, rework_requested, expired, reward_minted
+    source VARCHAR(16) NOT NULL DEFAULT 'api', -- api, scheduler, chain, outbox, backfill
+    actor VARCHAR(42), -- 操作者的钱包地址，系统操作为空
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    proof TEXT,
+    reason TEXT,
+    reward_amount VARCHAR(78),
+    tx_hash VARCHAR(66),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_events_tx_hash ON task_events(tx_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS task_events;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 任务清单中的步骤，孩子逐项勾选，proof_type为photo的步骤勾选时需要上传照片
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT 1,
+    proof_type VARCHAR(10) NOT NULL DEFAULT 'none' CHECK (proof_type IN ('none', 'photo')),
+    proof_url TEXT,
+    checked_at TIMESTAMP,
+    checked_by VARCHAR(42),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_checklist_items_task_id ON task_checklist_items(task_id);
+
+-- 为true时清单中必做的步骤全部勾选后才能提交任务
+ALTER TABLE tasks ADD COLUMN checklist_required BOOLEAN NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tasks DROP COLUMN checklist_required;
+DROP INDEX IF EXISTS idx_task_checklist_items_task_id;
+DROP TABLE IF EXISTS task_checklist_items;
+-- +goose StatementEnd
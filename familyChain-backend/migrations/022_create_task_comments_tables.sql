@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 任务下家长和孩子之间的留言
+CREATE TABLE IF NOT EXISTS task_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    author_address VARCHAR(42) NOT NULL,
+    author_role VARCHAR(10) NOT NULL CHECK (author_role IN ('parent', 'child')),
+    body TEXT NOT NULL,
+    image_url TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_comments_deleted_at ON task_comments(deleted_at);
+
+-- 每个用户在每个任务中读到的最后一条留言，用于计算未读数
+CREATE TABLE IF NOT EXISTS task_comment_reads (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    wallet_address VARCHAR(42) NOT NULL,
+    last_read_comment_id INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_comment_reads_task_wallet ON task_comment_reads(task_id, wallet_address);
+CREATE INDEX IF NOT EXISTS idx_task_comment_reads_wallet_address ON task_comment_reads(wallet_address);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_task_comment_reads_wallet_address;
+DROP INDEX IF EXISTS idx_task_comment_reads_task_wallet;
+DROP TABLE IF EXISTS task_comment_reads;
+DROP INDEX IF EXISTS idx_task_comments_deleted_at;
+DROP INDEX IF EXISTS idx_task_comments_task_id;
+DROP TABLE IF EXISTS task_comments;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 接收通知邮件的地址
+ALTER TABLE users ADD COLUMN email VARCHAR(255);
+
+-- 站内通知
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    wallet_address VARCHAR(42) NOT NULL,
+    family_id INTEGER NOT NULL,
+    type VARCHAR(40) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    task_id INTEGER,
+    exchange_id INTEGER,
+    reward_id INTEGER,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (family_id) REFERENCES families(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_wallet_address ON notifications(wallet_address);
+
+-- 用户按通知类型设置的渠道，没有记录时所有渠道都开启
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    wallet_address VARCHAR(42) NOT NULL,
+    type VARCHAR(40) NOT NULL,
+    in_app BOOLEAN NOT NULL,
+    email BOOLEAN NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_preferences_wallet_type ON notification_preferences(wallet_address, type);
+
+-- 通知邮件发送队列
+CREATE TABLE IF NOT EXISTS email_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    notification_id INTEGER,
+    recipient VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_messages_notification_id ON email_messages(notification_id);
+CREATE INDEX IF NOT EXISTS idx_email_messages_status ON email_messages(status);
+CREATE INDEX IF NOT EXISTS idx_email_messages_next_attempt_at ON email_messages(next_attempt_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_email_messages_next_attempt_at;
+DROP INDEX IF EXISTS idx_email_messages_status;
+DROP INDEX IF EXISTS idx_email_messages_notification_id;
+DROP TABLE IF EXISTS email_messages;
+DROP INDEX IF EXISTS idx_notification_preferences_wallet_type;
+DROP TABLE IF EXISTS notification_preferences;
+DROP INDEX IF EXISTS idx_notifications_wallet_address;
+DROP TABLE IF EXISTS notifications;
+ALTER TABLE users DROP COLUMN email;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 兑换需要家长批准或拒绝，代币在此之前托管在奖品合约中
+ALTER TABLE exchanges ADD COLUMN decided_by VARCHAR(42);
+ALTER TABLE exchanges ADD COLUMN decided_at TIMESTAMP;
+-- 批准（销毁托管代币）或拒绝（退还代币）的链上交易
+ALTER TABLE exchanges ADD COLUMN settlement_tx_hash VARCHAR(66);
+
+-- 一次链上兑换只能关联一条兑换记录，孩子提交和索引器同步并发时不会重复扣减库存
+DROP INDEX IF EXISTS idx_exchanges_contract_exchange_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchanges_contract_exchange ON exchanges(contract_exchange_id);
+
+-- 兑换的托管、批准和退还也通过outbox发送到链上
+ALTER TABLE outbox_messages ADD COLUMN exchange_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_exchange_id ON outbox_messages(exchange_id);
+-- 托管失败后已经排队的退还不再发送，状态为cancelled
+
+-- 旧版本的兑换在创建时就已销毁代币，按新的状态机迁移
+UPDATE exchanges SET status = 'fulfilled' WHERE status = 'completed';
+UPDATE exchanges SET status = 'approved' WHERE status = 'confirmed';
+UPDATE exchanges SET status = 'declined' WHERE status = 'cancelled';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE exchanges SET status = 'cancelled' WHERE status = 'declined';
+UPDATE exchanges SET status = 'confirmed' WHERE status = 'approved';
+UPDATE exchanges SET status = 'completed' WHERE status = 'fulfilled';
+DROP INDEX IF EXISTS idx_outbox_messages_exchange_id;
+ALTER TABLE outbox_messages DROP COLUMN exchange_id;
+DROP INDEX IF EXISTS idx_exchanges_contract_exchange;
+CREATE INDEX IF NOT EXISTS idx_exchanges_contract_exchange_id ON exchanges(contract_exchange_id);
+ALTER TABLE exchanges DROP COLUMN settlement_tx_hash;
+ALTER TABLE exchanges DROP COLUMN decided_at;
+ALTER TABLE exchanges DROP COLUMN decided_by;
+-- +goose StatementEnd
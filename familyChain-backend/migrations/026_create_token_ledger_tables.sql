@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 代币账本：索引器把RewardToken的每条Transfer事件记为一笔流水，一借一贷两条分录
+-- 金额是以wei为单位的十进制整数，超出了INTEGER的范围
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind VARCHAR(20) NOT NULL,
+    amount VARCHAR(78) NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    log_index INTEGER NOT NULL,
+    block_number INTEGER NOT NULL,
+    occurred_at TIMESTAMP,
+    created_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_kind ON ledger_transactions(kind);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_block_number ON ledger_transactions(block_number);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_transactions_log ON ledger_transactions(tx_hash, log_index);
+
+-- 每条分录记录账户的变化和记账后的余额
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    transaction_id INTEGER NOT NULL REFERENCES ledger_transactions(id),
+    account VARCHAR(42) NOT NULL,
+    account_type VARCHAR(20) NOT NULL,
+    child_id INTEGER,
+    amount VARCHAR(79) NOT NULL,
+    balance VARCHAR(79) NOT NULL,
+    created_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_child_id ON ledger_entries(child_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
+-- +goose StatementEnd
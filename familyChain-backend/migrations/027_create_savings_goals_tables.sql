@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 储蓄目标：孩子把一个奖品设为目标，可以把部分代币锁定到目标中，数量是整数个代币
+CREATE TABLE IF NOT EXISTS savings_goals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    child_id INTEGER NOT NULL,
+    reward_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    locked_amount INTEGER NOT NULL DEFAULT 0,
+    matched_amount INTEGER NOT NULL DEFAULT 0,
+    exchange_id INTEGER,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (child_id) REFERENCES children(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_savings_goals_child_id ON savings_goals(child_id);
+CREATE INDEX IF NOT EXISTS idx_savings_goals_reward_id ON savings_goals(reward_id);
+CREATE INDEX IF NOT EXISTS idx_savings_goals_status ON savings_goals(status);
+
+-- 家长的配捐，代币通过outbox铸造给孩子
+CREATE TABLE IF NOT EXISTS savings_contributions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    goal_id INTEGER NOT NULL,
+    contributed_by VARCHAR(42) NOT NULL,
+    amount INTEGER NOT NULL,
+    notes TEXT,
+    outbox_message_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (goal_id) REFERENCES savings_goals(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_savings_contributions_goal_id ON savings_contributions(goal_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS savings_contributions;
+DROP TABLE IF EXISTS savings_goals;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 孩子提出的奖品愿望：家长定价后创建奖品（reward_id），或者在decision_note中说明拒绝的原因
+CREATE TABLE IF NOT EXISTS reward_proposals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    family_id INTEGER NOT NULL,
+    child_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    image_url TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reward_id INTEGER,
+    decided_by VARCHAR(42),
+    decided_at TIMESTAMP,
+    decision_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (family_id) REFERENCES families(id) ON DELETE CASCADE,
+    FOREIGN KEY (child_id) REFERENCES children(id) ON DELETE CASCADE,
+    FOREIGN KEY (reward_id) REFERENCES rewards(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reward_proposals_family_id ON reward_proposals(family_id);
+CREATE INDEX IF NOT EXISTS idx_reward_proposals_child_id ON reward_proposals(child_id);
+CREATE INDEX IF NOT EXISTS idx_reward_proposals_status ON reward_proposals(status);
+
+-- 愿望相关的站内通知
+ALTER TABLE notifications ADD COLUMN proposal_id INTEGER;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notifications DROP COLUMN proposal_id;
+DROP TABLE IF EXISTS reward_proposals;
+-- +goose StatementEnd
@@ -6,34 +6,8 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 )
 
-// DeployTaskRegistry 是一个占位符部署函数，实际应用中需要替换为真实的部署逻辑
-func DeployTaskRegistry(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *TaskRegistry, error) {
-	// 这里应该是真实的部署逻辑
-	return common.Address{}, nil, nil, fmt.Errorf("DeployTaskRegistry not implemented")
-}
-
-// DeployFamilyRegistry 是一个占位符部署函数，实际应用中需要替换为真实的部署逻辑
-func DeployFamilyRegistry(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *FamilyRegistry, error) {
-	// 这里应该是真实的部署逻辑
-	return common.Address{}, nil, nil, fmt.Errorf("DeployFamilyRegistry not implemented")
-}
-
-// DeployRewardToken 是一个占位符部署函数，实际应用中需要替换为真实的部署逻辑
-func DeployRewardToken(auth *bind.TransactOpts, backend bind.ContractBackend, name string, symbol string) (common.Address, *types.Transaction, *RewardToken, error) {
-	// 这里应该是真实的部署逻辑
-	return common.Address{}, nil, nil, fmt.Errorf("DeployRewardToken not implemented")
-}
-
-// DeployRewardRegistry 是一个占位符部署函数，实际应用中需要替换为真实的部署逻辑
-func DeployRewardRegistry(auth *bind.TransactOpts, backend bind.ContractBackend, tokenAddress common.Address) (common.Address, *types.Transaction, *RewardRegistry, error) {
-	// 这里应该是真实的部署逻辑
-	return common.Address{}, nil, nil, fmt.Errorf("DeployRewardRegistry not implemented")
-}
-
 // 更新后的 GetTask 函数，适配新的合约调用方式
 func (cm *ContractManager) GetTask(taskID uint64) (Task, error) {
 	var task Task
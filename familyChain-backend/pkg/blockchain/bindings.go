@@ -22,6 +22,3 @@ package blockchain
 // func NewRewardRegistry(address common.Address, backend bind.ContractBackend) (*rewardregistry.RewardRegistry, error) {
 // 	return rewardregistry.NewRewardRegistry(address, backend)
 // }
-
-// 注意：由于我们没有生成 Deploy 函数，所以我们这里使用占位符函数
-// 在实际使用时，你可能需要手动编写部署逻辑或重新生成带有 bin 参数的绑定
@@ -2,7 +2,6 @@ package blockchain
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"log"
 	"math/big"
@@ -11,56 +10,57 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// EthClient wraps ethclient.Client to provide additional functionality
+// Backend is the part of an Ethereum node's API the backend relies on.
+// *ethclient.Client satisfies it, and tests plug in a simulated chain.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	BlockNumber(ctx context.Context) (uint64, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// EthClient wraps an Ethereum backend to provide additional functionality
 type EthClient struct {
-	client     *ethclient.Client
-	privateKey *ecdsa.PrivateKey
-	address    common.Address
-	chainID    *big.Int
+	client  Backend
+	signer  Signer
+	address common.Address
+	chainID *big.Int
 }
 
-// NewEthClient creates a new Ethereum client
-func NewEthClient(rpcURL string, privateKeyHex string) (*EthClient, error) {
+// NewEthClient creates a new Ethereum client that sends transactions signed by signer
+func NewEthClient(rpcURL string, signer Signer) (*EthClient, error) {
 	// Connect to Ethereum node
 	client, err := ethclient.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %v", err)
 	}
 
-	// Parse private key
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
-	}
-
-	// Get public key and address
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("error casting public key to ECDSA")
-	}
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
-
 	// Get chain ID
 	chainID, err := client.NetworkID(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chain ID: %v", err)
 	}
 
+	return NewEthClientWithBackend(client, signer, chainID), nil
+}
+
+// NewEthClientWithBackend wraps an already connected backend
+func NewEthClientWithBackend(client Backend, signer Signer, chainID *big.Int) *EthClient {
 	return &EthClient{
-		client:     client,
-		privateKey: privateKey,
-		address:    address,
-		chainID:    chainID,
-	}, nil
+		client:  client,
+		signer:  signer,
+		address: signer.Address(),
+		chainID: chainID,
+	}
 }
 
-// GetClient returns the underlying ethclient.Client
-func (ec *EthClient) GetClient() *ethclient.Client {
+// GetClient returns the underlying backend
+func (ec *EthClient) GetClient() Backend {
 	return ec.client
 }
 
@@ -69,9 +69,9 @@ func (ec *EthClient) GetAddress() common.Address {
 	return ec.address
 }
 
-// GetPrivateKey returns the private key associated with the client
-func (ec *EthClient) GetPrivateKey() *ecdsa.PrivateKey {
-	return ec.privateKey
+// GetSigner returns the signer used for the client's transactions
+func (ec *EthClient) GetSigner() Signer {
+	return ec.signer
 }
 
 // GetChainID returns the chain ID of the connected network
@@ -91,11 +91,7 @@ func (ec *EthClient) GetAuth() (*bind.TransactOpts, error) {
 		return nil, fmt.Errorf("failed to suggest gas price: %v", err)
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(ec.privateKey, ec.chainID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create keyed transactor: %v", err)
-	}
-
+	auth := NewTransactor(context.Background(), ec.signer, ec.chainID)
 	auth.Nonce = big.NewInt(int64(nonce))
 	auth.Value = big.NewInt(0)      // in wei
 	auth.GasLimit = uint64(3000000) // in units
@@ -127,7 +123,7 @@ func (ec *EthClient) SendTransaction(to common.Address, value *big.Int, data []b
 	)
 
 	// Sign transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(ec.chainID), ec.privateKey)
+	signedTx, err := ec.signer.SignTx(context.Background(), tx, ec.chainID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign transaction: %v", err)
 	}
@@ -171,5 +167,7 @@ func (ec *EthClient) GetBalance(address common.Address) (*big.Int, error) {
 
 // Close closes the client connection
 func (ec *EthClient) Close() {
-	ec.client.Close()
+	if closer, ok := ec.client.(interface{ Close() }); ok {
+		closer.Close()
+	}
 }
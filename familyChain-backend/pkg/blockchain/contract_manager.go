@@ -2,7 +2,7 @@ package blockchain
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
@@ -17,8 +17,9 @@ import (
 // ContractManager handles interactions with smart contracts
 type ContractManager struct {
 	client           *EthClient
-	privateKey       *ecdsa.PrivateKey
 	chainID          *big.Int
+	nonces           *NonceManager
+	confirmations    uint64
 	TaskRegistry     *TaskRegistry
 	FamilyRegistry   *FamilyRegistry
 	RewardToken      *RewardToken
@@ -32,8 +33,15 @@ type ContractManager struct {
 // NewContractManager creates a new contract manager instance
 func NewContractManager(client *EthClient, contractAddresses map[string]string) (*ContractManager, error) {
 	cm := &ContractManager{
-		client:  client,
-		chainID: client.GetChainID(),
+		client:        client,
+		chainID:       client.GetChainID(),
+		nonces:        NewNonceManager(client.GetClient()),
+		confirmations: 1,
+	}
+
+	// 启动时与链上待处理nonce对齐，之后由nonce管理器在本地分配
+	if err := cm.nonces.Sync(context.Background(), client.GetAddress()); err != nil {
+		log.Printf("Warning: failed to sync nonce for %s: %v", client.GetAddress().Hex(), err)
 	}
 
 	// 如果提供了合约地址，就使用它们
@@ -112,21 +120,21 @@ func (cm *ContractManager) initializeContracts() error {
 
 // DeployContracts deploys all contracts
 func (cm *ContractManager) DeployContracts() error {
-	auth, err := cm.client.GetAuth()
-	if err != nil {
-		return fmt.Errorf("failed to create auth: %v", err)
-	}
+	ctx := context.Background()
+	backend := cm.client.GetClient()
 
 	// 部署任务合约
-	taskAddress, tx, taskInstance, err := DeployTaskRegistry(auth, cm.client.GetClient())
+	tx, err := cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		address, tx, instance, err := DeployTaskRegistry(auth, backend)
+		cm.taskAddress, cm.TaskRegistry = address, instance
+		return tx, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to deploy task registry: %v", err)
 	}
-	cm.taskAddress = taskAddress
-	cm.TaskRegistry = taskInstance
 
 	log.Printf("TaskRegistry deployed with transaction: %s", tx.Hash().Hex())
-	log.Printf("TaskRegistry address: %s", taskAddress.Hex())
+	log.Printf("TaskRegistry address: %s", cm.taskAddress.Hex())
 
 	receipt, err := cm.client.WaitForTransaction(tx.Hash(), 5*time.Minute)
 	if err != nil {
@@ -135,15 +143,17 @@ func (cm *ContractManager) DeployContracts() error {
 	log.Printf("TaskRegistry deployed at block: %d", receipt.BlockNumber.Uint64())
 
 	// 部署家庭合约
-	familyAddress, tx, familyInstance, err := DeployFamilyRegistry(auth, cm.client.GetClient())
+	tx, err = cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		address, tx, instance, err := DeployFamilyRegistry(auth, backend)
+		cm.familyAddress, cm.FamilyRegistry = address, instance
+		return tx, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to deploy family registry: %v", err)
 	}
-	cm.familyAddress = familyAddress
-	cm.FamilyRegistry = familyInstance
 
 	log.Printf("FamilyRegistry deployed with transaction: %s", tx.Hash().Hex())
-	log.Printf("FamilyRegistry address: %s", familyAddress.Hex())
+	log.Printf("FamilyRegistry address: %s", cm.familyAddress.Hex())
 
 	receipt, err = cm.client.WaitForTransaction(tx.Hash(), 5*time.Minute)
 	if err != nil {
@@ -152,15 +162,17 @@ func (cm *ContractManager) DeployContracts() error {
 	log.Printf("FamilyRegistry deployed at block: %d", receipt.BlockNumber.Uint64())
 
 	// 部署代币合约
-	tokenAddress, tx, tokenInstance, err := DeployRewardToken(auth, cm.client.GetClient(), "TaskReward", "TRW")
+	tx, err = cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		address, tx, instance, err := DeployRewardToken(auth, backend, "TaskReward", "TRW")
+		cm.tokenAddress, cm.RewardToken = address, instance
+		return tx, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to deploy reward token: %v", err)
 	}
-	cm.tokenAddress = tokenAddress
-	cm.RewardToken = tokenInstance
 
 	log.Printf("RewardToken deployed with transaction: %s", tx.Hash().Hex())
-	log.Printf("RewardToken address: %s", tokenAddress.Hex())
+	log.Printf("RewardToken address: %s", cm.tokenAddress.Hex())
 
 	receipt, err = cm.client.WaitForTransaction(tx.Hash(), 5*time.Minute)
 	if err != nil {
@@ -171,6 +183,11 @@ func (cm *ContractManager) DeployContracts() error {
 	return nil
 }
 
+// SignerAddress returns the account the backend sends transactions from
+func (cm *ContractManager) SignerAddress() common.Address {
+	return cm.client.GetAddress()
+}
+
 // GetContractAddresses returns a map of contract addresses
 func (cm *ContractManager) GetContractAddresses() map[string]string {
 	return map[string]string{
@@ -187,14 +204,10 @@ func (cm *ContractManager) CreateTask(title, description string, reward *big.Int
 		return 0, fmt.Errorf("task registry not initialized")
 	}
 
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
-	if err != nil {
-		return 0, fmt.Errorf("failed to create auth: %v", err)
-	}
-
 	// 调用合约创建任务
-	tx, err := cm.TaskRegistry.CreateTask(auth, title, description, reward)
+	tx, err := cm.Transact(context.Background(), func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return cm.TaskRegistry.CreateTask(auth, title, description, reward)
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to create task: %v", err)
 	}
@@ -224,14 +237,10 @@ func (cm *ContractManager) AssignTask(taskID uint64, childAddress common.Address
 		return fmt.Errorf("task registry not initialized")
 	}
 
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
-	if err != nil {
-		return fmt.Errorf("failed to create auth: %v", err)
-	}
-
 	// 调用合约分配任务
-	tx, err := cm.TaskRegistry.AssignTask(auth, big.NewInt(int64(taskID)), childAddress)
+	tx, err := cm.Transact(context.Background(), func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return cm.TaskRegistry.AssignTask(auth, big.NewInt(int64(taskID)), childAddress)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to assign task: %v", err)
 	}
@@ -251,14 +260,10 @@ func (cm *ContractManager) CompleteTask(taskID uint64) error {
 		return fmt.Errorf("task registry not initialized")
 	}
 
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
-	if err != nil {
-		return fmt.Errorf("failed to create auth: %v", err)
-	}
-
 	// 调用合约完成任务
-	tx, err := cm.TaskRegistry.CompleteTask(auth, big.NewInt(int64(taskID)))
+	tx, err := cm.Transact(context.Background(), func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return cm.TaskRegistry.CompleteTask(auth, big.NewInt(int64(taskID)))
+	})
 	if err != nil {
 		return fmt.Errorf("failed to complete task: %v", err)
 	}
@@ -272,112 +277,286 @@ func (cm *ContractManager) CompleteTask(taskID uint64) error {
 	return nil
 }
 
-// ApproveTask approves a completed task and transfers reward
-func (cm *ContractManager) ApproveTask(taskID uint64, reward *big.Int) error {
+// RejectTask rejects a completed task
+func (cm *ContractManager) RejectTask(taskID uint64) error {
 	if cm.TaskRegistry == nil {
 		return fmt.Errorf("task registry not initialized")
 	}
 
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
+	// 调用合约拒绝任务
+	tx, err := cm.Transact(context.Background(), func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return cm.TaskRegistry.RejectTask(auth, big.NewInt(int64(taskID)))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create auth: %v", err)
+		return fmt.Errorf("failed to reject task: %v", err)
 	}
 
-	// 设置交易值为奖励金额
-	auth.Value = reward
+	// 等待交易确认
+	_, err = cm.client.WaitForTransaction(tx.Hash(), 2*time.Minute)
+	if err != nil {
+		return fmt.Errorf("error waiting for transaction: %v", err)
+	}
 
-	// 增加gas限制，确保交易有足够的gas执行
-	auth.GasLimit = 500000
+	return nil
+}
 
-	// 增加gas价格，确保交易能够被优先处理
-	if auth.GasPrice != nil {
-		increasedGasPrice := new(big.Int).Mul(auth.GasPrice, big.NewInt(200))
-		increasedGasPrice = increasedGasPrice.Div(increasedGasPrice, big.NewInt(100)) // 增加100%
-		auth.GasPrice = increasedGasPrice
-		log.Printf("[DEBUG] ApproveTask: 增加gas价格至原价的200%%: %s", auth.GasPrice.String())
+// MintReward mints reward tokens to the given address and returns the submitted transaction
+// without waiting for it to be mined
+func (cm *ContractManager) MintReward(ctx context.Context, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	if cm.RewardToken == nil {
+		return nil, fmt.Errorf("reward token not initialized")
 	}
 
-	// 调用合约批准任务
-	tx, err := cm.TaskRegistry.ApproveTask(auth, big.NewInt(int64(taskID)))
+	tx, err := cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		auth.GasLimit = 1000000
+		return cm.RewardToken.Mint(auth, to, amount)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to approve task: %v", err)
+		return nil, fmt.Errorf("failed to mint reward: %w", err)
 	}
 
-	log.Printf("[DEBUG] ApproveTask: 交易已提交，哈希: %s", tx.Hash().Hex())
+	log.Printf("Mint transaction submitted: %s (to %s, amount %s)", tx.Hash().Hex(), to.Hex(), amount.String())
+	return tx, nil
+}
 
-	// 等待交易确认
-	receipt, err := cm.client.WaitForTransaction(tx.Hash(), 2*time.Minute)
+// BurnTokens burns reward tokens from an account's wallet, used to settle exchanges that were never escrowed on chain
+func (cm *ContractManager) BurnTokens(ctx context.Context, from common.Address, amount *big.Int) (*types.Transaction, error) {
+	if cm.RewardToken == nil {
+		return nil, fmt.Errorf("reward token not initialized")
+	}
+
+	tx, err := cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		auth.GasLimit = 1000000
+		return cm.RewardToken.Burn(auth, from, amount)
+	})
 	if err != nil {
-		return fmt.Errorf("error waiting for transaction: %v", err)
+		return nil, fmt.Errorf("failed to burn tokens: %w", err)
 	}
 
-	log.Printf("[DEBUG] ApproveTask: 交易已确认，区块号: %d，状态: %d", receipt.BlockNumber.Uint64(), receipt.Status)
+	log.Printf("Burn transaction submitted: %s (from %s, amount %s)", tx.Hash().Hex(), from.Hex(), amount.String())
+	return tx, nil
+}
 
-	return nil
+// ChainExchange is an exchange record as stored by the RewardRegistry contract
+type ChainExchange struct {
+	ID          uint64
+	RewardID    uint64
+	Child       common.Address
+	TokenAmount *big.Int
+	Fulfilled   bool
+	Declined    bool
 }
 
-// RejectTask rejects a completed task
-func (cm *ContractManager) RejectTask(taskID uint64) error {
-	if cm.TaskRegistry == nil {
-		return fmt.Errorf("task registry not initialized")
+// Pending reports whether the exchange still holds the child's tokens in escrow
+func (e *ChainExchange) Pending() bool {
+	return e.ID != 0 && !e.Fulfilled && !e.Declined
+}
+
+// EscrowExchange exchanges a reward on behalf of a child; the registry moves the price from the
+// child's balance into escrow until the exchange is fulfilled or declined
+func (cm *ContractManager) EscrowExchange(ctx context.Context, child common.Address, rewardID *big.Int) (*types.Transaction, error) {
+	if cm.RewardRegistry == nil {
+		return nil, fmt.Errorf("reward registry not initialized")
 	}
 
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
+	tx, err := cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		auth.GasLimit = 1000000
+		return cm.RewardRegistry.ExchangeRewardFor(auth, child, rewardID)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create auth: %v", err)
+		return nil, fmt.Errorf("failed to escrow exchange: %w", err)
 	}
 
-	// 调用合约拒绝任务
-	tx, err := cm.TaskRegistry.RejectTask(auth, big.NewInt(int64(taskID)))
+	log.Printf("Escrow transaction submitted: %s (child %s, reward %s)", tx.Hash().Hex(), child.Hex(), rewardID.String())
+	return tx, nil
+}
+
+// FulfillExchange approves an on-chain exchange, burning the escrowed tokens
+func (cm *ContractManager) FulfillExchange(ctx context.Context, exchangeID *big.Int) (*types.Transaction, error) {
+	if cm.RewardRegistry == nil {
+		return nil, fmt.Errorf("reward registry not initialized")
+	}
+
+	tx, err := cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		auth.GasLimit = 1000000
+		return cm.RewardRegistry.FulfillExchange(auth, exchangeID)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to reject task: %v", err)
+		return nil, fmt.Errorf("failed to fulfill exchange: %w", err)
 	}
 
-	// 等待交易确认
-	_, err = cm.client.WaitForTransaction(tx.Hash(), 2*time.Minute)
+	log.Printf("Fulfill transaction submitted: %s (exchange %s)", tx.Hash().Hex(), exchangeID.String())
+	return tx, nil
+}
+
+// DeclineExchange declines an on-chain exchange, refunding the escrowed tokens to the child
+func (cm *ContractManager) DeclineExchange(ctx context.Context, exchangeID *big.Int) (*types.Transaction, error) {
+	if cm.RewardRegistry == nil {
+		return nil, fmt.Errorf("reward registry not initialized")
+	}
+
+	tx, err := cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		auth.GasLimit = 1000000
+		return cm.RewardRegistry.DeclineExchange(auth, exchangeID)
+	})
 	if err != nil {
-		return fmt.Errorf("error waiting for transaction: %v", err)
+		return nil, fmt.Errorf("failed to decline exchange: %w", err)
 	}
 
-	return nil
+	log.Printf("Decline transaction submitted: %s (exchange %s)", tx.Hash().Hex(), exchangeID.String())
+	return tx, nil
 }
 
-// TransferETH transfers ETH to the given address
-func (cm *ContractManager) TransferETH(to common.Address, amount *big.Int) (*types.Transaction, error) {
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
+// GetExchange reads an exchange record from the RewardRegistry; unknown IDs return a record with ID 0
+func (cm *ContractManager) GetExchange(ctx context.Context, exchangeID uint64) (*ChainExchange, error) {
+	if cm.RewardRegistry == nil {
+		return nil, fmt.Errorf("reward registry not initialized")
+	}
+
+	id, rewardID, child, amount, _, fulfilled, declined, err := cm.RewardRegistry.GetExchange(&bind.CallOpts{Context: ctx}, new(big.Int).SetUint64(exchangeID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create auth: %v", err)
+		return nil, fmt.Errorf("failed to get exchange: %w", err)
+	}
+	return &ChainExchange{
+		ID:          id.Uint64(),
+		RewardID:    rewardID.Uint64(),
+		Child:       child,
+		TokenAmount: amount,
+		Fulfilled:   fulfilled,
+		Declined:    declined,
+	}, nil
+}
+
+// ExchangeIDFromReceipt returns the exchange ID from the RewardExchanged event in a receipt
+func (cm *ContractManager) ExchangeIDFromReceipt(receipt *types.Receipt) (uint64, error) {
+	if cm.RewardRegistry == nil {
+		return 0, fmt.Errorf("reward registry not initialized")
 	}
 
-	// 设置交易值
-	auth.Value = amount
+	for _, entry := range receipt.Logs {
+		if entry.Address != cm.rewardRegAddress {
+			continue
+		}
+		if event, err := cm.RewardRegistry.ParseRewardExchanged(*entry); err == nil {
+			return event.ExchangeId.Uint64(), nil
+		}
+	}
+	return 0, fmt.Errorf("no RewardExchanged event in transaction %s", receipt.TxHash.Hex())
+}
 
-	// 创建交易对象
-	tx := types.NewTransaction(
-		auth.Nonce.Uint64(),
-		to,
-		auth.Value,
-		21000, // gas limit for standard ETH transfer
-		auth.GasPrice,
-		[]byte{}, // 没有额外数据
-	)
+// TokenBalance returns the RewardToken balance of an account in wei at a block; a nil block means the latest block
+func (cm *ContractManager) TokenBalance(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if cm.RewardToken == nil {
+		return nil, fmt.Errorf("reward token not initialized")
+	}
 
-	// 签名交易
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(cm.chainID), cm.client.GetPrivateKey())
+	balance, err := cm.RewardToken.BalanceOf(&bind.CallOpts{Context: ctx, BlockNumber: blockNumber}, account)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		return nil, fmt.Errorf("failed to get token balance: %w", err)
+	}
+	return balance, nil
+}
+
+// EnsureMinter checks that the backend signer is an authorized minter of the reward token
+// and tries to authorize it when it is not (requires the signer to own the token contract)
+func (cm *ContractManager) EnsureMinter(ctx context.Context) error {
+	if cm.RewardToken == nil {
+		return fmt.Errorf("reward token not initialized")
 	}
 
-	// 发送交易
-	err = cm.client.GetClient().SendTransaction(context.Background(), signedTx)
+	signer := cm.client.GetAddress()
+	isMinter, err := cm.RewardToken.AuthorizedMinters(&bind.CallOpts{Context: ctx}, signer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %v", err)
+		return fmt.Errorf("failed to check minter role: %w", err)
+	}
+	if isMinter {
+		return nil
 	}
 
-	return signedTx, nil
+	log.Printf("Account %s is not an authorized minter, trying to add it...", signer.Hex())
+	tx, err := cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		auth.GasLimit = 1000000
+		return cm.RewardToken.AddMinter(auth, signer)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add minter: %w", err)
+	}
+	if _, err := cm.WaitForTxReceipt(ctx, tx.Hash()); err != nil {
+		return fmt.Errorf("error waiting for add minter transaction: %w", err)
+	}
+
+	log.Printf("Account %s added as minter in transaction %s", signer.Hex(), tx.Hash().Hex())
+	return nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction, or ethereum.NotFound
+// if the transaction has not been mined yet
+func (cm *ContractManager) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return cm.client.GetClient().TransactionReceipt(ctx, txHash)
+}
+
+// SetRequiredConfirmations sets how many blocks (including the block a transaction
+// was mined in) must exist before the transaction is treated as final
+func (cm *ContractManager) SetRequiredConfirmations(confirmations uint64) {
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	cm.confirmations = confirmations
+}
+
+// RequiredConfirmations returns the configured confirmation depth, at least 1
+func (cm *ContractManager) RequiredConfirmations() uint64 {
+	if cm.confirmations == 0 {
+		return 1
+	}
+	return cm.confirmations
+}
+
+// ConfirmationCount returns how many confirmations a block has when head is the
+// latest block; a block equal to head has one confirmation
+func ConfirmationCount(blockNumber, head uint64) uint64 {
+	if head < blockNumber {
+		return 0
+	}
+	return head - blockNumber + 1
+}
+
+// BlockNumber returns the number of the most recent block
+func (cm *ContractManager) BlockNumber(ctx context.Context) (uint64, error) {
+	return cm.client.GetClient().BlockNumber(ctx)
+}
+
+// HeaderByNumber returns the header of the given block, or the latest header if number is nil
+func (cm *ContractManager) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return cm.client.GetClient().HeaderByNumber(ctx, number)
+}
+
+// TransferETH transfers ETH to the given address
+func (cm *ContractManager) TransferETH(to common.Address, amount *big.Int) (*types.Transaction, error) {
+	ctx := context.Background()
+	return cm.Transact(ctx, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		// 创建交易对象
+		tx := types.NewTransaction(
+			auth.Nonce.Uint64(),
+			to,
+			amount,
+			21000, // gas limit for standard ETH transfer
+			auth.GasPrice,
+			[]byte{}, // 没有额外数据
+		)
+
+		// 签名交易
+		signedTx, err := auth.Signer(auth.From, tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		}
+
+		// 发送交易
+		if err := cm.client.GetClient().SendTransaction(ctx, signedTx); err != nil {
+			return nil, fmt.Errorf("failed to send transaction: %v", err)
+		}
+		return signedTx, nil
+	})
 }
 
 // CreateFamily creates a new family
@@ -386,14 +565,10 @@ func (cm *ContractManager) CreateFamily(name string) (uint64, error) {
 		return 0, fmt.Errorf("family registry not initialized")
 	}
 
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
-	if err != nil {
-		return 0, fmt.Errorf("failed to create auth: %v", err)
-	}
-
 	// 调用合约创建家庭
-	tx, err := cm.FamilyRegistry.CreateFamily(auth, name)
+	tx, err := cm.Transact(context.Background(), func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return cm.FamilyRegistry.CreateFamily(auth, name)
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to create family: %v", err)
 	}
@@ -423,14 +598,10 @@ func (cm *ContractManager) AddChild(familyID uint64, childAddress common.Address
 		return fmt.Errorf("family registry not initialized")
 	}
 
-	// 获取交易选项
-	auth, err := cm.client.GetAuth()
-	if err != nil {
-		return fmt.Errorf("failed to create auth: %v", err)
-	}
-
 	// 调用合约添加孩子
-	tx, err := cm.FamilyRegistry.AddChild(auth, big.NewInt(int64(familyID)), childAddress, name, age)
+	tx, err := cm.Transact(context.Background(), func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return cm.FamilyRegistry.AddChild(auth, big.NewInt(int64(familyID)), childAddress, name, age)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to add child: %v", err)
 	}
@@ -456,164 +627,213 @@ type Task struct {
 	Approved    bool
 }
 
-// GetChildTransactOpts 获取孩子账户的交易选项
+// GetChildTransactOpts 获取代表孩子发起交易的选项
+// 后端不持有孩子的私钥，交易由后端账户签名，因此nonce来自后端账户的nonce管理器
 func (m *ContractManager) GetChildTransactOpts(ctx context.Context, childAddress common.Address) (*bind.TransactOpts, error) {
-	// 这个方法应该通过孩子的地址获取他们的私钥或使用特定的方法来签署交易
-	// 在此实现中，我们使用管理员账户模拟孩子账户的交易
+	auth, err := m.GetTransactOpts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auth.GasLimit = uint64(800000)
 
-	// 详细记录当前请求信息
-	log.Printf("[nonce管理] 开始为地址获取交易选项: %s", childAddress.Hex())
+	log.Printf("[nonce管理] 为孩子 %s 准备交易选项 - 签名账户: %s, Nonce: %d, GasPrice: %s",
+		childAddress.Hex(), auth.From.Hex(), auth.Nonce.Uint64(), auth.GasPrice.String())
 
-	client := m.client.GetClient()
+	return auth, nil
+}
 
-	// 1. 首先获取账户的已确认nonce (已经被打包进区块的交易数量)
-	stateNonce, err := client.NonceAt(ctx, childAddress, nil)
+// GetTransactOpts 获取后端账户的交易选项，nonce由nonce管理器预留
+// 优先使用Transact；直接使用返回的选项时，如果最终没有发送交易，需要调用ReleaseNonce归还nonce
+func (cm *ContractManager) GetTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
+	auth, err := cm.client.GetAuth()
 	if err != nil {
-		log.Printf("[nonce管理] 获取已确认nonce失败: %v", err)
-		return nil, fmt.Errorf("failed to get confirmed nonce: %w", err)
+		return nil, fmt.Errorf("failed to create auth: %v", err)
 	}
-	log.Printf("[nonce管理] 已确认nonce: %d", stateNonce)
 
-	// 2. 获取待处理nonce (包括内存池中的交易)
-	pendingNonce, err := client.PendingNonceAt(ctx, childAddress)
+	nonce, err := cm.nonces.Next(ctx, auth.From)
 	if err != nil {
-		log.Printf("[nonce管理] 获取待处理nonce失败: %v", err)
-		return nil, fmt.Errorf("failed to get pending nonce: %w", err)
+		return nil, fmt.Errorf("failed to reserve nonce: %w", err)
 	}
-	log.Printf("[nonce管理] 待处理nonce: %d", pendingNonce)
+	auth.Nonce = new(big.Int).SetUint64(nonce)
 
-	// 3. 选择正确的nonce值 (应该使用最大的那个)
-	var finalNonce uint64
-	if pendingNonce > stateNonce {
-		finalNonce = pendingNonce
-		log.Printf("[nonce管理] 使用待处理nonce: %d", finalNonce)
-	} else {
-		finalNonce = stateNonce
-		log.Printf("[nonce管理] 使用已确认nonce: %d", finalNonce)
+	// 增加gas价格，确保交易能够被优先处理
+	if auth.GasPrice != nil {
+		increasedGasPrice := new(big.Int).Mul(auth.GasPrice, big.NewInt(150))
+		increasedGasPrice = increasedGasPrice.Div(increasedGasPrice, big.NewInt(100)) // 增加50%
+		auth.GasPrice = increasedGasPrice
 	}
 
-	// 为解决持续的nonce错误，添加一个随机增量
-	// 这可以避免多个交易使用相同的nonce
-	randomIncrement := uint64(time.Now().UnixNano() % 1000)
-	finalNonce = finalNonce + randomIncrement
-	log.Printf("[nonce管理] 添加随机增量 %d，最终nonce: %d", randomIncrement, finalNonce)
+	return auth, nil
+}
 
-	// 4. 获取gas价格，添加重试机制
-	var gasPrice *big.Int
-	for retries := 0; retries < 3; retries++ {
-		gasPrice, err = client.SuggestGasPrice(ctx)
-		if err == nil {
-			break
-		}
-		log.Printf("[nonce管理] 获取gas价格失败(尝试 %d/3): %v", retries+1, err)
-		time.Sleep(time.Second) // 等待1秒后重试
-	}
-	if err != nil {
-		log.Printf("[nonce管理] 获取gas价格失败，所有尝试均失败: %v", err)
-		return nil, fmt.Errorf("failed to get gas price: %w", err)
+// ReleaseNonce 归还通过GetTransactOpts预留但没有发送交易的nonce
+func (cm *ContractManager) ReleaseNonce(auth *bind.TransactOpts) {
+	if auth == nil || auth.Nonce == nil {
+		return
 	}
+	cm.nonces.Release(auth.From, auth.Nonce.Uint64())
+}
 
-	// 增加gas价格，确保交易能够被优先处理
-	increasedGasPrice := new(big.Int).Mul(gasPrice, big.NewInt(150))
-	increasedGasPrice = increasedGasPrice.Div(increasedGasPrice, big.NewInt(100)) // 增加50%
-	log.Printf("[nonce管理] 原始gas价格: %s, 增加后: %s", gasPrice.String(), increasedGasPrice.String())
+// ErrNotBroadcast is returned, wrapped, when a transaction was signed and handed
+// to the BeforeBroadcast hook but sending it to the node failed. The node may
+// still have received it, so its nonce stays reserved and the caller should
+// keep tracking the transaction by hash instead of signing a new one.
+var ErrNotBroadcast = errors.New("transaction signed but not broadcast")
 
-	// 5. 创建交易签名器
-	auth, err := bind.NewKeyedTransactorWithChainID(m.client.GetPrivateKey(), m.client.GetChainID())
-	if err != nil {
-		log.Printf("[nonce管理] 创建交易签名器失败: %v", err)
-		return nil, fmt.Errorf("failed to create transaction signer: %w", err)
-	}
+// BeforeBroadcast receives a signed transaction before it is sent to the node.
+// When it returns an error the transaction is not sent.
+type BeforeBroadcast func(tx *types.Transaction) error
 
-	// 6. 设置交易参数
-	auth.Nonce = big.NewInt(int64(finalNonce))
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = uint64(800000)    // 进一步增加gas限制
-	auth.GasPrice = increasedGasPrice // 使用增加后的gas价格
+type beforeBroadcastKey struct{}
 
-	// 7. 记录最终交易参数
-	log.Printf("[nonce管理] 交易参数设置完成 - Nonce: %d, GasPrice: %s, GasLimit: %d, Chain ID: %s",
-		auth.Nonce.Uint64(), auth.GasPrice.String(), auth.GasLimit, m.client.GetChainID().String())
+// WithBeforeBroadcast returns a context that makes Transact sign transactions
+// without sending them, pass them to hook and only then broadcast them. Callers
+// use it to persist the hash and nonce first, so that a failed write after the
+// broadcast can never lead to the same side effect being sent twice.
+func WithBeforeBroadcast(ctx context.Context, hook BeforeBroadcast) context.Context {
+	return context.WithValue(ctx, beforeBroadcastKey{}, hook)
+}
 
-	return auth, nil
+func beforeBroadcast(ctx context.Context) BeforeBroadcast {
+	hook, _ := ctx.Value(beforeBroadcastKey{}).(BeforeBroadcast)
+	return hook
 }
 
-// syncNonce 尝试从区块链获取更准确的nonce值
-// 这是一个临时解决方案，用于处理nonce同步问题
-func (m *ContractManager) syncNonce(ctx context.Context, address common.Address, currentNonce *uint64) error {
-	// 如果有错误，我们只记录但不中断流程
-	client := m.client.GetClient()
+// Transact sends a transaction from the backend signer using a reserved nonce.
+// When the node rejects the nonce the nonce manager is re-synced with the chain
+// and the transaction is retried once; any other failure releases the nonce.
+// With a BeforeBroadcast hook in ctx the transaction is signed and handed to
+// the hook before it is broadcast; see WithBeforeBroadcast.
+func (cm *ContractManager) Transact(ctx context.Context, send func(auth *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	hook := beforeBroadcast(ctx)
+	for attempt := 1; ; attempt++ {
+		auth, err := cm.GetTransactOpts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		auth.Context = ctx
+		auth.NoSend = hook != nil
+
+		tx, err := send(auth)
+		if err == nil && hook != nil {
+			if err := hook(tx); err != nil {
+				cm.ReleaseNonce(auth)
+				return nil, fmt.Errorf("before broadcast: %w", err)
+			}
+			err = cm.client.GetClient().SendTransaction(ctx, tx)
+			if err != nil && !IsNonceError(err) {
+				// 节点可能已经收到了交易，nonce不能归还给其他交易
+				return tx, fmt.Errorf("%w: %v", ErrNotBroadcast, err)
+			}
+		}
+		if err == nil {
+			return tx, nil
+		}
 
-	// 尝试获取地址的交易数量作为nonce参考
-	count, err := client.PendingTransactionCount(ctx)
-	if err != nil {
-		return fmt.Errorf("获取待处理交易数量失败: %v", err)
-	}
-	log.Printf("当前待处理交易总数: %d", count)
+		if !IsNonceError(err) {
+			cm.ReleaseNonce(auth)
+			return nil, err
+		}
 
-	// 获取账户状态nonce (这是账户已确认的交易数量)
-	stateNonce, err := client.NonceAt(ctx, address, nil)
-	if err != nil {
-		log.Printf("获取账户状态nonce失败: %v", err)
-	} else {
-		log.Printf("账户状态nonce: %d", stateNonce)
-		if stateNonce > *currentNonce {
-			*currentNonce = stateNonce
-			log.Printf("使用账户状态nonce: %d", *currentNonce)
-			return nil
+		log.Printf("[nonce] 节点拒绝nonce %d: %v，重新同步", auth.Nonce.Uint64(), err)
+		if syncErr := cm.nonces.Sync(ctx, auth.From); syncErr != nil {
+			return nil, fmt.Errorf("%w (nonce resync failed: %v)", err, syncErr)
+		}
+		if attempt >= 2 {
+			return nil, err
 		}
 	}
+}
 
-	// 获取待处理nonce (包括内存池中的交易)
-	pendingNonce, err := client.PendingNonceAt(ctx, address)
-	if err != nil {
-		log.Printf("获取待处理nonce失败: %v", err)
-	} else {
-		log.Printf("待处理nonce: %d", pendingNonce)
-		if pendingNonce > *currentNonce {
-			*currentNonce = pendingNonce
-			log.Printf("使用待处理nonce: %d", *currentNonce)
-			return nil
+// ReplaceTransaction re-sends original, a transaction of the backend signer,
+// with the same nonce, recipient, value and data but a higher gas price, so
+// that it replaces the stuck original in the mempool. The original is passed
+// in rather than looked up, because a node that dropped it cannot return it.
+// A BeforeBroadcast hook in ctx sees the replacement before it is sent, as
+// with Transact.
+func (cm *ContractManager) ReplaceTransaction(ctx context.Context, original *types.Transaction) (*types.Transaction, error) {
+	client := cm.client.GetClient()
+
+	suggested, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas price: %w", err)
+	}
+
+	var replacement *types.Transaction
+	switch original.Type() {
+	case types.DynamicFeeTxType:
+		replacement = types.NewTx(&types.DynamicFeeTx{
+			ChainID:    cm.chainID,
+			Nonce:      original.Nonce(),
+			GasTipCap:  bumpGasPrice(original.GasTipCap(), nil),
+			GasFeeCap:  bumpGasPrice(original.GasFeeCap(), suggested),
+			Gas:        original.Gas(),
+			To:         original.To(),
+			Value:      original.Value(),
+			Data:       original.Data(),
+			AccessList: original.AccessList(),
+		})
+	default:
+		replacement = types.NewTx(&types.LegacyTx{
+			Nonce:    original.Nonce(),
+			GasPrice: bumpGasPrice(original.GasPrice(), suggested),
+			Gas:      original.Gas(),
+			To:       original.To(),
+			Value:    original.Value(),
+			Data:     original.Data(),
+		})
+	}
+
+	signedTx, err := cm.client.GetSigner().SignTx(ctx, replacement, cm.chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement transaction: %w", err)
+	}
+	hook := beforeBroadcast(ctx)
+	if hook != nil {
+		if err := hook(signedTx); err != nil {
+			return nil, fmt.Errorf("before broadcast: %w", err)
 		}
 	}
-
-	// 额外安全措施: 如果当前nonce看起来太低，增加一个合理的值
-	// 这可以避免反复使用太低的nonce值
-	if *currentNonce < 30 && count > 100 {
-		// 如果账户交易总数很高但nonce很低，可能是严重不同步
-		// 增加nonce到一个较高的值
-		newNonce := *currentNonce + 150
-		log.Printf("检测到可能的严重nonce不同步，尝试增加nonce: %d -> %d", *currentNonce, newNonce)
-		*currentNonce = newNonce
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		if hook != nil && !IsNonceError(err) {
+			return signedTx, fmt.Errorf("%w: %v", ErrNotBroadcast, err)
+		}
+		return nil, fmt.Errorf("failed to send replacement transaction: %w", err)
 	}
 
-	return nil
+	log.Printf("[nonce] 交易 %s (nonce %d) 已被替换为 %s", original.Hash().Hex(), original.Nonce(), signedTx.Hash().Hex())
+	return signedTx, nil
 }
 
-// GetTransactOpts 获取交易选项
-func (cm *ContractManager) GetTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
-	auth, err := cm.client.GetAuth()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create auth: %v", err)
-	}
-
-	// 增加gas价格，确保交易能够被优先处理
-	if auth.GasPrice != nil {
-		increasedGasPrice := new(big.Int).Mul(auth.GasPrice, big.NewInt(150))
-		increasedGasPrice = increasedGasPrice.Div(increasedGasPrice, big.NewInt(100)) // 增加50%
-		auth.GasPrice = increasedGasPrice
-		log.Printf("[DEBUG] 增加gas价格至原价的150%%: %s", auth.GasPrice.String())
-	}
+// ConfirmedNonce returns the number of transactions of the backend signer that
+// are included in the latest block. A nonce below it can no longer be used by
+// any pending or future transaction.
+func (cm *ContractManager) ConfirmedNonce(ctx context.Context) (uint64, error) {
+	return cm.client.GetClient().NonceAt(ctx, cm.SignerAddress(), nil)
+}
 
-	return auth, nil
+// bumpGasPrice raises a gas price by 25% (nodes require at least 10% to accept
+// a replacement), rounding up so that tiny tips still rise, and never returns
+// less than floor
+func bumpGasPrice(price *big.Int, floor *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(price, big.NewInt(125))
+	bumped.Add(bumped, big.NewInt(99))
+	bumped.Div(bumped, big.NewInt(100))
+	if floor != nil && bumped.Cmp(floor) < 0 {
+		return new(big.Int).Set(floor)
+	}
+	return bumped
 }
 
-// WaitForTxReceipt 等待交易确认并返回收据
+// WaitForTxReceipt 等待交易被打包并达到要求的确认数后返回收据
+// 每次轮询都重新获取收据，如果交易所在区块被重组掉，会继续等待它在新链上被打包
 func (cm *ContractManager) WaitForTxReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
-	log.Printf("[交易确认] 开始等待交易确认: %s", txHash.Hex())
+	required := cm.RequiredConfirmations()
+	log.Printf("[交易确认] 开始等待交易确认: %s, 需要确认数: %d", txHash.Hex(), required)
 
-	// 创建一个带有取消的上下文
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, 60*time.Second) // 增加超时时间到60秒
+	// 按需要的确认数延长超时时间
+	timeout := 60*time.Second + time.Duration(required-1)*15*time.Second
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// 创建一个ticker，每秒检查一次
@@ -622,39 +842,37 @@ func (cm *ContractManager) WaitForTxReceipt(ctx context.Context, txHash common.H
 
 	// 记录开始等待时间
 	startTime := time.Now()
-
-	// 设置最大重试次数
-	maxRetries := 60
 	retryCount := 0
+	var minedIn *common.Hash
 
 	for {
 		select {
 		case <-ctxWithTimeout.Done():
 			// 上下文已取消或超时
-			if ctx.Err() == context.DeadlineExceeded {
+			if ctxWithTimeout.Err() == context.DeadlineExceeded && ctx.Err() == nil {
 				log.Printf("[交易确认] 等待交易确认超时: %s, 已等待 %v 秒",
 					txHash.Hex(), time.Since(startTime).Seconds())
-				return nil, fmt.Errorf("waiting for transaction receipt timed out: %w", ctx.Err())
+				return nil, fmt.Errorf("waiting for transaction receipt timed out: %w", ctxWithTimeout.Err())
 			}
 			log.Printf("[交易确认] 上下文已取消: %s", txHash.Hex())
 			return nil, ctx.Err()
 		case <-ticker.C:
 			retryCount++
-			if retryCount > maxRetries {
-				log.Printf("[交易确认] 达到最大重试次数 %d，放弃等待: %s", maxRetries, txHash.Hex())
-				return nil, fmt.Errorf("reached maximum retry count waiting for transaction receipt")
-			}
 
 			// 每10次尝试记录一次日志
 			if retryCount%10 == 0 {
-				log.Printf("[交易确认] 尝试 %d/%d, 已等待 %.1f 秒: %s",
-					retryCount, maxRetries, time.Since(startTime).Seconds(), txHash.Hex())
+				log.Printf("[交易确认] 尝试 %d, 已等待 %.1f 秒: %s",
+					retryCount, time.Since(startTime).Seconds(), txHash.Hex())
 			}
 
 			// 尝试获取交易收据
-			receipt, err := cm.client.GetClient().TransactionReceipt(ctx, txHash)
+			receipt, err := cm.client.GetClient().TransactionReceipt(ctxWithTimeout, txHash)
 			if err != nil {
 				if err == ethereum.NotFound {
+					if minedIn != nil {
+						log.Printf("[交易确认] 交易 %s 所在区块 %s 已被重组，继续等待", txHash.Hex(), minedIn.Hex())
+						minedIn = nil
+					}
 					// 交易尚未被打包，继续等待
 					continue
 				}
@@ -662,6 +880,10 @@ func (cm *ContractManager) WaitForTxReceipt(ctx context.Context, txHash common.H
 				log.Printf("[交易确认] 获取交易收据失败: %v", err)
 				return nil, fmt.Errorf("failed to get transaction receipt: %w", err)
 			}
+			if minedIn != nil && *minedIn != receipt.BlockHash {
+				log.Printf("[交易确认] 交易 %s 因链重组被重新打包到区块 %d", txHash.Hex(), receipt.BlockNumber.Uint64())
+			}
+			minedIn = &receipt.BlockHash
 
 			// 获取到收据，检查状态
 			if receipt.Status == types.ReceiptStatusFailed {
@@ -669,6 +891,18 @@ func (cm *ContractManager) WaitForTxReceipt(ctx context.Context, txHash common.H
 				return receipt, fmt.Errorf("transaction failed with status: %d", receipt.Status)
 			}
 
+			// 检查确认数
+			if required > 1 {
+				head, err := cm.BlockNumber(ctxWithTimeout)
+				if err != nil {
+					log.Printf("[交易确认] 获取最新区块失败: %v", err)
+					continue
+				}
+				if ConfirmationCount(receipt.BlockNumber.Uint64(), head) < required {
+					continue
+				}
+			}
+
 			log.Printf("[交易确认] 交易成功确认: %s, 区块号: %d, Gas使用: %d, 确认用时: %.1f 秒",
 				txHash.Hex(), receipt.BlockNumber.Uint64(), receipt.GasUsed, time.Since(startTime).Seconds())
 			return receipt, nil
@@ -32,12 +32,34 @@ var (
 // FamilyRegistryMetaData contains all meta data concerning the FamilyRegistry contract.
 var FamilyRegistryMetaData = &bind.MetaData{
 	ABI: "[{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"childAddress\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint8\",\"name\":\"age\",\"type\":\"uint8\"}],\"name\":\"ChildAdded\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"childAddress\",\"type\":\"address\"}],\"name\":\"ChildRemoved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"parent\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"}],\"name\":\"FamilyCreated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"}],\"name\":\"FamilyUpdated\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"childAddress\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"uint8\",\"name\":\"age\",\"type\":\"uint8\"}],\"name\":\"addChild\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"childToFamily\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"}],\"name\":\"createFamily\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"families\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"parent\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"bool\",\"name\":\"active\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"familyCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"childAddress\",\"type\":\"address\"}],\"name\":\"getChild\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"uint8\",\"name\":\"\",\"type\":\"uint8\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"index\",\"type\":\"uint256\"}],\"name\":\"getChildAddressByIndex\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"}],\"name\":\"getChildCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"isChild\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"isParent\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"parentFamilies\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"childAddress\",\"type\":\"address\"}],\"name\":\"removeChild\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"}],\"name\":\"updateFamily\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x60806040526000600155348015601457600080fd5b50612071806100246000396000f3fe608060405234801561001057600080fd5b50600436106100cf5760003560e01c806382ef100f1161008c5780639fa1140c116100665780639fa1140c1461024d578063d083e40914610280578063fc91a8971461029c578063ffa65a20146102cc576100cf565b806382ef100f146101d157806387a69a7c146102015780639f9cc6a31461021d576100cf565b8063078beae5146100d45780630814ff13146101075780635e2ad09b1461013757806374956e7814610167578063784f7a93146101835780637a77af5c146101a1575b600080fd5b6100ee60048036038101906100e99190611339565b6102fc565b6040516100fe9493929190611461565b60405180910390f35b610121600480360381019061011c91906114d9565b6103e1565b60405161012e9190611506565b60405180910390f35b610151600480360381019061014c9190611521565b61042f565b60405161015e9190611561565b60405180910390f35b610181600480360381019061017c91906116ea565b610460565b005b61018b610895565b6040516101989190611561565b60405180910390f35b6101bb60048036038101906101b69190611339565b61089b565b6040516101c89190611561565b60405180910390f35b6101eb60048036038101906101e6919061176d565b6108bb565b6040516101f891906117ad565b60405180910390f35b61021b600480360381019061021691906117c8565b61096d565b005b61023760048036038101906102329190611808565b610dcb565b6040516102449190611561565b60405180910390f35b610267600480360381019061026291906117c8565b610f82565b6040516102779493929190611860565b60405180910390f35b61029a600480360381019061029591906118ac565b6110c8565b005b6102b660048036038101906102b191906114d9565b61122e565b6040516102c39190611506565b60405180910390f35b6102e660048036038101906102e191906114d9565b61127a565b6040516102f39190611561565b60405180910390f35b60006020528060005260406000206000915090508060000154908060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169080600201805461034b90611937565b80601f016020809104026020016040519081016040528092919081815260200182805461037790611937565b80156103c45780601f10610399576101008083540402835291602001916103c4565b820191906000526020600020905b8154815290600101906020018083116103a757829003601f168201915b5050505050908060030160009054906101000a900460ff16905084565b600080600260008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002080549050119050919050565b6002602052816000526040600020818154811061044b57600080fd5b90600052602060002001600091509150505481565b8360008082815260200190815260200160002060030160009054906101000a900460ff166104c3576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016104ba906119b4565b60405180910390fd5b3373ffffffffffffffffffffffffffffffffffffffff1660008083815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614610566576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161055d90611a46565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff16036105d5576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016105cc90611ab2565b60405180910390fd5b6000600360008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019081526020016000205414610657576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161064e90611b44565b60405180910390fd5b60405180608001604052808573ffffffffffffffffffffffffffffffffffffffff1681526020018481526020018360ff168152602001600115158152506004600087815260200190815260200160002060008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008201518160000160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555060208201518160010190816107409190611d10565b5060408201518160020160006101000a81548160ff021916908360ff16021790555060608201518160020160016101000a81548160ff02191690831515021790555090505060056000868152602001908152602001600020849080600181540180825580915050600190039060005260206000200160009091909190916101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555084600360008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020819055508373ffffffffffffffffffffffffffffffffffffffff16857fd9873979ce5fb9532e10a8a7b8abff463d0ec206b4de1c4bd377527f477256008585604051610886929190611de2565b60405180910390a35050505050565b60015481565b600060056000838152602001908152602001600020805490509050919050565b600060056000848152602001908152602001600020805490508210610915576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161090c90611e5e565b60405180910390fd5b60056000848152602001908152602001600020828154811061093a57610939611e7e565b5b9060005260206000200160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905092915050565b8160008082815260200190815260200160002060030160009054906101000a900460ff166109d0576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016109c7906119b4565b60405180910390fd5b3373ffffffffffffffffffffffffffffffffffffffff1660008083815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614610a73576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610a6a90611a46565b60405180910390fd5b82600360008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019081526020016000205414610af4576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610aeb90611f1f565b60405180910390fd5b600060056000858152602001908152602001600020905060005b8180549050811015610c8b578373ffffffffffffffffffffffffffffffffffffffff16828281548110610b4457610b43611e7e565b5b9060005260206000200160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1603610c7e578160018380549050610b9c9190611f6e565b81548110610bad57610bac611e7e565b5b9060005260206000200160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16828281548110610beb57610bea611e7e565b5b9060005260206000200160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555081805480610c4457610c43611fa2565b5b6001900381819060005260206000200160006101000a81549073ffffffffffffffffffffffffffffffffffffffff02191690559055610c8b565b8080600101915050610b0e565b506004600085815260200190815260200160002060008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600080820160006101000a81549073ffffffffffffffffffffffffffffffffffffffff0219169055600182016000610d149190611292565b6002820160006101000a81549060ff02191690556002820160016101000a81549060ff02191690555050600360008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600090558273ffffffffffffffffffffffffffffffffffffffff16847fba3641578d2900570c01f205ed35c07faca7ec9dac0e9e4f69b7d43fd303466860405160405180910390a350505050565b600060016000815480929190610de090611fd1565b9190505550604051806080016040528060015481526020013373ffffffffffffffffffffffffffffffffffffffff1681526020018381526020016001151581525060008060015481526020019081526020016000206000820151816000015560208201518160010160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055506040820151816002019081610e9b9190611d10565b5060608201518160030160006101000a81548160ff021916908315150217905550905050600260003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060015490806001815401808255809150506001900390600052602060002001600090919091909150553373ffffffffffffffffffffffffffffffffffffffff166001547fd7bd94563e5293bf92f0e48f697f09a7113742a3c029967ccbcd686cfadd0cbc84604051610f709190612019565b60405180910390a36001549050919050565b6000606060008060006004600088815260200190815260200160002060008773ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002090508060000160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16816001018260020160009054906101000a900460ff168360020160019054906101000a900460ff1682805461103690611937565b80601f016020809104026020016040519081016040528092919081815260200182805461106290611937565b80156110af5780601f10611084576101008083540402835291602001916110af565b820191906000526020600020905b81548152906001019060200180831161109257829003601f168201915b5050505050925094509450945094505092959194509250565b8160008082815260200190815260200160002060030160009054906101000a900460ff1661112b576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611122906119b4565b60405180910390fd5b3373ffffffffffffffffffffffffffffffffffffffff1660008083815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16146111ce576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016111c590611a46565b60405180910390fd5b8160008085815260200190815260200160002060020190816111f09190611d10565b50827f0111fc39a759d49b242d0f2a9db604740d45eae5a48cdb344cdff4ec3237ed0a836040516112219190612019565b60405180910390a2505050565b600080600360008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019081526020016000205414159050919050565b60036020528060005260406000206000915090505481565b50805461129e90611937565b6000825580601f106112b057506112cf565b601f0160209004906000526020600020908101906112ce91906112d2565b5b50565b5b808211156112eb5760008160009055506001016112d3565b5090565b6000604051905090565b600080fd5b600080fd5b6000819050919050565b61131681611303565b811461132157600080fd5b50565b6000813590506113338161130d565b92915050565b60006020828403121561134f5761134e6112f9565b5b600061135d84828501611324565b91505092915050565b61136f81611303565b82525050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b60006113a082611375565b9050919050565b6113b081611395565b82525050565b600081519050919050565b600082825260208201905092915050565b60005b838110156113f05780820151818401526020810190506113d5565b60008484015250505050565b6000601f19601f8301169050919050565b6000611418826113b6565b61142281856113c1565b93506114328185602086016113d2565b61143b816113fc565b840191505092915050565b60008115159050919050565b61145b81611446565b82525050565b60006080820190506114766000830187611366565b61148360208301866113a7565b8181036040830152611495818561140d565b90506114a46060830184611452565b95945050505050565b6114b681611395565b81146114c157600080fd5b50565b6000813590506114d3816114ad565b92915050565b6000602082840312156114ef576114ee6112f9565b5b60006114fd848285016114c4565b91505092915050565b600060208201905061151b6000830184611452565b92915050565b60008060408385031215611538576115376112f9565b5b6000611546858286016114c4565b925050602061155785828601611324565b9150509250929050565b60006020820190506115766000830184611366565b92915050565b600080fd5b600080fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b6115be826113fc565b810181811067ffffffffffffffff821117156115dd576115dc611586565b5b80604052505050565b60006115f06112ef565b90506115fc82826115b5565b919050565b600067ffffffffffffffff82111561161c5761161b611586565b5b611625826113fc565b9050602081019050919050565b82818337600083830152505050565b600061165461164f84611601565b6115e6565b9050828152602081018484840111156116705761166f611581565b5b61167b848285611632565b509392505050565b600082601f8301126116985761169761157c565b5b81356116a8848260208601611641565b91505092915050565b600060ff82169050919050565b6116c7816116b1565b81146116d257600080fd5b50565b6000813590506116e4816116be565b92915050565b60008060008060808587031215611704576117036112f9565b5b600061171287828801611324565b9450506020611723878288016114c4565b935050604085013567ffffffffffffffff811115611744576117436112fe565b5b61175087828801611683565b9250506060611761878288016116d5565b91505092959194509250565b60008060408385031215611784576117836112f9565b5b600061179285828601611324565b92505060206117a385828601611324565b9150509250929050565b60006020820190506117c260008301846113a7565b92915050565b600080604083850312156117df576117de6112f9565b5b60006117ed85828601611324565b92505060206117fe858286016114c4565b9150509250929050565b60006020828403121561181e5761181d6112f9565b5b600082013567ffffffffffffffff81111561183c5761183b6112fe565b5b61184884828501611683565b91505092915050565b61185a816116b1565b82525050565b600060808201905061187560008301876113a7565b8181036020830152611887818661140d565b90506118966040830185611851565b6118a36060830184611452565b95945050505050565b600080604083850312156118c3576118c26112f9565b5b60006118d185828601611324565b925050602083013567ffffffffffffffff8111156118f2576118f16112fe565b5b6118fe85828601611683565b9150509250929050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b6000600282049050600182168061194f57607f821691505b60208210810361196257611961611908565b5b50919050565b7f46616d696c7920646f6573206e6f742065786973740000000000000000000000600082015250565b600061199e6015836113c1565b91506119a982611968565b602082019050919050565b600060208201905081810360008301526119cd81611991565b9050919050565b7f4f6e6c792066616d696c7920706172656e742063616e20706572666f726d207460008201527f68697320616374696f6e00000000000000000000000000000000000000000000602082015250565b6000611a30602a836113c1565b9150611a3b826119d4565b604082019050919050565b60006020820190508181036000830152611a5f81611a23565b9050919050565b7f496e76616c6964206368696c6420616464726573730000000000000000000000600082015250565b6000611a9c6015836113c1565b9150611aa782611a66565b602082019050919050565b60006020820190508181036000830152611acb81611a8f565b9050919050565b7f4368696c6420616c72656164792062656c6f6e677320746f20612066616d696c60008201527f7900000000000000000000000000000000000000000000000000000000000000602082015250565b6000611b2e6021836113c1565b9150611b3982611ad2565b604082019050919050565b60006020820190508181036000830152611b5d81611b21565b9050919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b600060088302611bc67fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff82611b89565b611bd08683611b89565b95508019841693508086168417925050509392505050565b6000819050919050565b6000611c0d611c08611c0384611303565b611be8565b611303565b9050919050565b6000819050919050565b611c2783611bf2565b611c3b611c3382611c14565b848454611b96565b825550505050565b600090565b611c50611c43565b611c5b818484611c1e565b505050565b5b81811015611c7f57611c74600082611c48565b600181019050611c61565b5050565b601f821115611cc457611c9581611b64565b611c9e84611b79565b81016020851015611cad578190505b611cc1611cb985611b79565b830182611c60565b50505b505050565b600082821c905092915050565b6000611ce760001984600802611cc9565b1980831691505092915050565b6000611d008383611cd6565b9150826002028217905092915050565b611d19826113b6565b67ffffffffffffffff811115611d3257611d31611586565b5b611d3c8254611937565b611d47828285611c83565b600060209050601f831160018114611d7a5760008415611d68578287015190505b611d728582611cf4565b865550611dda565b601f198416611d8886611b64565b60005b82811015611db057848901518255600182019150602085019450602081019050611d8b565b86831015611dcd5784890151611dc9601f891682611cd6565b8355505b6001600288020188555050505b505050505050565b60006040820190508181036000830152611dfc818561140d565b9050611e0b6020830184611851565b9392505050565b7f496e646578206f7574206f6620626f756e647300000000000000000000000000600082015250565b6000611e486013836113c1565b9150611e5382611e12565b602082019050919050565b60006020820190508181036000830152611e7781611e3b565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b7f4368696c6420646f6573206e6f742062656c6f6e6720746f207468697320666160008201527f6d696c7900000000000000000000000000000000000000000000000000000000602082015250565b6000611f096024836113c1565b9150611f1482611ead565b604082019050919050565b60006020820190508181036000830152611f3881611efc565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b6000611f7982611303565b9150611f8483611303565b9250828203905081811115611f9c57611f9b611f3f565b5b92915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603160045260246000fd5b6000611fdc82611303565b91507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff820361200e5761200d611f3f565b5b600182019050919050565b60006020820190508181036000830152612033818461140d565b90509291505056fea2646970667358221220bc009fc77a9d4b0b08dec06551e19fa9057d9f7c3a46786148cba1c814f5500c64736f6c634300081e0033",
 }
 
 // FamilyRegistryABI is the input ABI used to generate the binding from.
 // Deprecated: Use FamilyRegistryMetaData.ABI instead.
 var FamilyRegistryABI = FamilyRegistryMetaData.ABI
 
+// FamilyRegistryBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use FamilyRegistryMetaData.Bin instead.
+var FamilyRegistryBin = FamilyRegistryMetaData.Bin
+
+// DeployFamilyRegistry deploys a new Ethereum contract, binding an instance of FamilyRegistry to it.
+func DeployFamilyRegistry(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *FamilyRegistry, error) {
+	parsed, err := FamilyRegistryMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(FamilyRegistryBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &FamilyRegistry{FamilyRegistryCaller: FamilyRegistryCaller{contract: contract}, FamilyRegistryTransactor: FamilyRegistryTransactor{contract: contract}, FamilyRegistryFilterer: FamilyRegistryFilterer{contract: contract}}, nil
+}
+
 // FamilyRegistry is an auto generated Go binding around an Ethereum contract.
 type FamilyRegistry struct {
 	FamilyRegistryCaller     // Read-only binding to the contract
@@ -31,13 +31,35 @@ var (
 
 // RewardRegistryMetaData contains all meta data concerning the RewardRegistry contract.
 var RewardRegistryMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_tokenAddress\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"OwnableInvalidOwner\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"OwnableUnauthorizedAccount\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"exchangeId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"parent\",\"type\":\"address\"}],\"name\":\"ExchangeFulfilled\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokenPrice\",\"type\":\"uint256\"}],\"name\":\"RewardCreated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"exchangeId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"child\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokenAmount\",\"type\":\"uint256\"}],\"name\":\"RewardExchanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokenPrice\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bool\",\"name\":\"active\",\"type\":\"bool\"}],\"name\":\"RewardUpdated\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"childExchanges\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_familyId\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"_name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_description\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_imageURI\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"_tokenPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_stock\",\"type\":\"uint256\"}],\"name\":\"createReward\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"exchangeCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_rewardId\",\"type\":\"uint256\"}],\"name\":\"exchangeReward\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"exchanges\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"child\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenAmount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"exchangeDate\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"fulfilled\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"familyRewards\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_exchangeId\",\"type\":\"uint256\"}],\"name\":\"fulfillExchange\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_child\",\"type\":\"address\"}],\"name\":\"getChildExchangeCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_child\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_index\",\"type\":\"uint256\"}],\"name\":\"getChildExchangeId\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_exchangeId\",\"type\":\"uint256\"}],\"name\":\"getExchange\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_familyId\",\"type\":\"uint256\"}],\"name\":\"getFamilyRewardCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_familyId\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_index\",\"type\":\"uint256\"}],\"name\":\"getFamilyRewardId\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_rewardId\",\"type\":\"uint256\"}],\"name\":\"getReward\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"rewardCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"rewards\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"description\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"imageURI\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"tokenPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"stock\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"active\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"tokenContract\",\"outputs\":[{\"internalType\":\"contractRewardToken\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_rewardId\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"_name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_description\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_imageURI\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"_tokenPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_stock\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"_active\",\"type\":\"bool\"}],\"name\":\"updateReward\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_tokenAddress\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"OwnableInvalidOwner\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"OwnableUnauthorizedAccount\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"exchangeId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"parent\",\"type\":\"address\"}],\"name\":\"ExchangeDeclined\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"exchangeId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"parent\",\"type\":\"address\"}],\"name\":\"ExchangeFulfilled\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokenPrice\",\"type\":\"uint256\"}],\"name\":\"RewardCreated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"exchangeId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"child\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokenAmount\",\"type\":\"uint256\"}],\"name\":\"RewardExchanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"tokenPrice\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bool\",\"name\":\"active\",\"type\":\"bool\"}],\"name\":\"RewardUpdated\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"childExchanges\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_familyId\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"_name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_description\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_imageURI\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"_tokenPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_stock\",\"type\":\"uint256\"}],\"name\":\"createReward\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_exchangeId\",\"type\":\"uint256\"}],\"name\":\"declineExchange\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"exchangeCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_rewardId\",\"type\":\"uint256\"}],\"name\":\"exchangeReward\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_child\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_rewardId\",\"type\":\"uint256\"}],\"name\":\"exchangeRewardFor\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"exchanges\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"rewardId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"child\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"tokenAmount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"exchangeDate\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"fulfilled\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"declined\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"familyRewards\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_exchangeId\",\"type\":\"uint256\"}],\"name\":\"fulfillExchange\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_child\",\"type\":\"address\"}],\"name\":\"getChildExchangeCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_child\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"_index\",\"type\":\"uint256\"}],\"name\":\"getChildExchangeId\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_exchangeId\",\"type\":\"uint256\"}],\"name\":\"getExchange\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_familyId\",\"type\":\"uint256\"}],\"name\":\"getFamilyRewardCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_familyId\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_index\",\"type\":\"uint256\"}],\"name\":\"getFamilyRewardId\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_rewardId\",\"type\":\"uint256\"}],\"name\":\"getReward\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"rewardCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"rewards\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"familyId\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"description\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"imageURI\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"tokenPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"stock\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"active\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"tokenContract\",\"outputs\":[{\"internalType\":\"contractRewardToken\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_rewardId\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"_name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_description\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_imageURI\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"_tokenPrice\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_stock\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"_active\",\"type\":\"bool\"}],\"name\":\"updateReward\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x60806040526000600355600060045534801561001a57600080fd5b50604051613154380380613154833981810160405281019061003c919061022d565b33600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036100af5760006040517f1e4fbdf70000000000000000000000000000000000000000000000000000000081526004016100a69190610269565b60405180910390fd5b6100be8161010660201b60201c565b5080600760006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555050610284565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050816000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b60006101fa826101cf565b9050919050565b61020a816101ef565b811461021557600080fd5b50565b60008151905061022781610201565b92915050565b600060208284031215610243576102426101ca565b5b600061025184828501610218565b91505092915050565b610263816101ef565b82525050565b600060208201905061027e600083018461025a565b92915050565b612ec1806102936000396000f3fe608060405234801561001057600080fd5b50600436106101425760003560e01c806377936d1a116100b85780638da5cb5b1161007c5780638da5cb5b146103c35780639b676dc7146103e1578063e72f1b0014610411578063f2fde38b14610441578063f301af421461045d578063f59cc39a1461049557610142565b806377936d1a146102e55780637908542514610315578063804212761461033357806383dbdb641461036357806383e8ef541461039357610142565b80632839fc291161010a5780632839fc291461021d57806355a373d614610253578063572584ad1461027157806368972e501461028d5780636a60630d146102ab578063715018a6146102db57610142565b80630670ea71146101475780630b9d58471461016357806313b237ce146101995780631c4b774b146101c95780631da30cc514610201575b600080fd5b610161600480360381019061015c9190611d51565b6104c5565b005b61017d60048036038101906101789190611d51565b6105ca565b6040516101909796959493929190611de9565b60405180910390f35b6101b360048036038101906101ae9190611e84565b6106e7565b6040516101c09190611ec4565b60405180910390f35b6101e360048036038101906101de9190611d51565b610703565b6040516101f899989796959493929190611f6f565b60405180910390f35b61021b60048036038101906102169190612172565b6109d1565b005b61023760048036038101906102329190611d51565b610b79565b60405161024a9796959493929190611de9565b60405180910390f35b61025b610bf5565b60405161026891906122c7565b60405180910390f35b61028b60048036038101906102869190611d51565b610c1b565b005b610295610dc4565b6040516102a29190611ec4565b60405180910390f35b6102c560048036038101906102c09190611d51565b610dca565b6040516102d29190611ec4565b60405180910390f35b6102e3610ddd565b005b6102ff60048036038101906102fa91906122e2565b610df1565b60405161030c9190611ec4565b60405180910390f35b61031d610e83565b60405161032a9190611ec4565b60405180910390f35b61034d60048036038101906103489190612322565b610e89565b60405161035a9190611ec4565b60405180910390f35b61037d60048036038101906103789190611e84565b61110c565b60405161038a9190611ec4565b60405180910390f35b6103ad60048036038101906103a89190612403565b61113d565b6040516103ba9190611ec4565b60405180910390f35b6103cb611189565b6040516103d89190612430565b60405180910390f35b6103fb60048036038101906103f691906122e2565b6111b2565b6040516104089190611ec4565b60405180910390f35b61042b60048036038101906104269190611d51565b6111e3565b6040516104389190611ec4565b60405180910390f35b61045b60048036038101906104569190612403565b611203565b005b61047760048036038101906104729190611d51565b611289565b60405161048c99989796959493929190611f6f565b60405180910390f35b6104af60048036038101906104aa9190611e84565b61149c565b6040516104bc9190611ec4565b60405180910390f35b60006104d082611586565b905060018160050160006101000a81548160ff021916908315150217905550600760009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16639dc29fac3083600301546040518363ffffffff1660e01b815260040161055092919061244b565b600060405180830381600087803b15801561056a57600080fd5b505af115801561057e573d6000803e3d6000fd5b505050503373ffffffffffffffffffffffffffffffffffffffff16827f362de7542ac4a995332d8029cd2fcb9c46c9668b23c962e0dba8c1a7f28a28e660405160405180910390a35050565b600080600080600080600080600260008a81526020019081526020016000206040518060e001604052908160008201548152602001600182015481526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200160038201548152602001600482015481526020016005820160009054906101000a900460ff161515151581526020016005820160019054906101000a900460ff1615151515815250509050806000015181602001518260400151836060015184608001518560a001518660c00151975097509750975097509750975050919395979092949650565b60006106f161177d565b6106fb8383611804565b905092915050565b60008060006060806060600080600080600160008c815260200190815260200160002060405180610120016040529081600082015481526020016001820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001600282015481526020016003820180546107ac906124a3565b80601f01602080910402602001604051908101604052809291908181526020018280546107d8906124a3565b80156108255780601f106107fa57610100808354040283529160200191610825565b820191906000526020600020905b81548152906001019060200180831161080857829003601f168201915b5050505050815260200160048201805461083e906124a3565b80601f016020809104026020016040519081016040528092919081815260200182805461086a906124a3565b80156108b75780601f1061088c576101008083540402835291602001916108b7565b820191906000526020600020905b81548152906001019060200180831161089a57829003601f168201915b505050505081526020016005820180546108d0906124a3565b80601f01602080910402602001604051908101604052809291908181526020018280546108fc906124a3565b80156109495780601f1061091e57610100808354040283529160200191610949565b820191906000526020600020905b81548152906001019060200180831161092c57829003601f168201915b5050505050815260200160068201548152602001600782015481526020016008820160009054906101000a900460ff1615151515815250509050806000015181602001518260400151836060015184608001518560a001518660c001518760e00151886101000151995099509950995099509950995099509950509193959799909294969850565b3373ffffffffffffffffffffffffffffffffffffffff166001600089815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614610a75576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610a6c90612546565b60405180910390fd5b60008311610ab8576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610aaf906125d8565b60405180910390fd5b600060016000898152602001908152602001600020905086816003019081610ae0919061279a565b5085816004019081610af2919061279a565b5084816005019081610b04919061279a565b50838160060181905550828160070181905550818160080160006101000a81548160ff021916908315150217905550877f22cedbe07fd5a5dca8cc6b034fc2cd0f0b9802543d3efe5c4b705ac3dcbcf522888685604051610b679392919061286c565b60405180910390a25050505050505050565b60026020528060005260406000206000915090508060000154908060010154908060020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16908060030154908060040154908060050160009054906101000a900460ff16908060050160019054906101000a900460ff16905087565b600760009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1681565b6000610c2682611586565b905060018160050160016101000a81548160ff02191690831515021790555060016000826001015481526020019081526020016000206007016000815480929190610c70906128d9565b9190505550600760009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1663a9059cbb8260020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1683600301546040518363ffffffff1660e01b8152600401610cfa92919061244b565b6020604051808303816000875af1158015610d19573d6000803e3d6000fd5b505050506040513d601f19601f82011682018060405250810190610d3d9190612936565b610d7c576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610d73906129af565b60405180910390fd5b3373ffffffffffffffffffffffffffffffffffffffff16827f88304dc3d0cd3d055e85e57a69430dfb05451c9ba05b22c619f765988855808360405160405180910390a35050565b60045481565b6000610dd63383611804565b9050919050565b610de561177d565b610def6000611c3b565b565b600060056000848152602001908152602001600020805490508210610e4b576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610e4290612a1b565b60405180910390fd5b600560008481526020019081526020016000208281548110610e7057610e6f612a3b565b5b9060005260206000200154905092915050565b60035481565b6000808311610ecd576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ec4906125d8565b60405180910390fd5b60008211610f10576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610f0790612ab6565b60405180910390fd5b60036000815480929190610f23906128d9565b919050555060405180610120016040528060035481526020013373ffffffffffffffffffffffffffffffffffffffff168152602001888152602001878152602001868152602001858152602001848152602001838152602001600115158152506001600060035481526020019081526020016000206000820151816000015560208201518160010160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550604082015181600201556060820151816003019081611008919061279a565b50608082015181600401908161101e919061279a565b5060a0820151816005019081611034919061279a565b5060c0820151816006015560e082015181600701556101008201518160080160006101000a81548160ff021916908315150217905550905050600560008881526020019081526020016000206003549080600181540180825580915050600190039060005260206000200160009091909190915055863373ffffffffffffffffffffffffffffffffffffffff166003547fb96d3f67d5ecf915732544d895500cf70d6ab68bccb6e156ce171f20019ec11689876040516110f5929190612ad6565b60405180910390a460035490509695505050505050565b6006602052816000526040600020818154811061112857600080fd5b90600052602060002001600091509150505481565b6000600660008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020805490509050919050565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b600560205281600052604060002081815481106111ce57600080fd5b90600052602060002001600091509150505481565b600060056000838152602001908152602001600020805490509050919050565b61120b61177d565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff160361127d5760006040517f1e4fbdf70000000000000000000000000000000000000000000000000000000081526004016112749190612430565b60405180910390fd5b61128681611c3b565b50565b60016020528060005260406000206000915090508060000154908060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16908060020154908060030180546112de906124a3565b80601f016020809104026020016040519081016040528092919081815260200182805461130a906124a3565b80156113575780601f1061132c57610100808354040283529160200191611357565b820191906000526020600020905b81548152906001019060200180831161133a57829003601f168201915b50505050509080600401805461136c906124a3565b80601f0160208091040260200160405190810160405280929190818152602001828054611398906124a3565b80156113e55780601f106113ba576101008083540402835291602001916113e5565b820191906000526020600020905b8154815290600101906020018083116113c857829003601f168201915b5050505050908060050180546113fa906124a3565b80601f0160208091040260200160405190810160405280929190818152602001828054611426906124a3565b80156114735780601f1061144857610100808354040283529160200191611473565b820191906000526020600020905b81548152906001019060200180831161145657829003601f168201915b5050505050908060060154908060070154908060080160009054906101000a900460ff16905089565b6000600660008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020805490508210611522576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161151990612a1b565b60405180910390fd5b600660008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020828154811061157357611572612a3b565b5b9060005260206000200154905092915050565b6000806002600084815260200190815260200160002090506000600160008360010154815260200190815260200160002090506000826000015403611600576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016115f790612b52565b60405180910390fd5b3373ffffffffffffffffffffffffffffffffffffffff168160010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16148061169057503373ffffffffffffffffffffffffffffffffffffffff16611678611189565b73ffffffffffffffffffffffffffffffffffffffff16145b6116cf576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016116c690612be4565b60405180910390fd5b8160050160009054906101000a900460ff1615611721576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161171890612c50565b60405180910390fd5b8160050160019054906101000a900460ff1615611773576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161176a90612cbc565b60405180910390fd5b8192505050919050565b611785611cff565b73ffffffffffffffffffffffffffffffffffffffff166117a3611189565b73ffffffffffffffffffffffffffffffffffffffff1614611802576117c6611cff565b6040517f118cdaa70000000000000000000000000000000000000000000000000000000081526004016117f99190612430565b60405180910390fd5b565b6000806001600084815260200190815260200160002090508060080160009054906101000a900460ff1661186d576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161186490612d28565b60405180910390fd5b60008160070154116118b4576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016118ab90612d94565b60405180910390fd5b60008160060154905080600760009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166370a08231876040518263ffffffff1660e01b81526004016119199190612430565b602060405180830381865afa158015611936573d6000803e3d6000fd5b505050506040513d601f19601f8201168201806040525081019061195a9190612dc9565b101561199b576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161199290612e42565b60405180910390fd5b600760009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16632efd5b0686836040518363ffffffff1660e01b81526004016119f892919061244b565b600060405180830381600087803b158015611a1257600080fd5b505af1158015611a26573d6000803e3d6000fd5b50505050816007016000815480929190611a3f90612e62565b919050555060046000815480929190611a57906128d9565b91905055506040518060e0016040528060045481526020018581526020018673ffffffffffffffffffffffffffffffffffffffff16815260200182815260200142815260200160001515815260200160001515815250600260006004548152602001908152602001600020600082015181600001556020820151816001015560408201518160020160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550606082015181600301556080820151816004015560a08201518160050160006101000a81548160ff02191690831515021790555060c08201518160050160016101000a81548160ff021916908315150217905550905050600660008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060045490806001815401808255809150506001900390600052602060002001600090919091909150558473ffffffffffffffffffffffffffffffffffffffff16846004547fe150ee44da4a37592e6368bbdc0d8901353c1bfb770394b3268e70c9b505505084604051611c269190611ec4565b60405180910390a46004549250505092915050565b60008060009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169050816000806101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600033905090565b6000604051905090565b600080fd5b600080fd5b6000819050919050565b611d2e81611d1b565b8114611d3957600080fd5b50565b600081359050611d4b81611d25565b92915050565b600060208284031215611d6757611d66611d11565b5b6000611d7584828501611d3c565b91505092915050565b611d8781611d1b565b82525050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000611db882611d8d565b9050919050565b611dc881611dad565b82525050565b60008115159050919050565b611de381611dce565b82525050565b600060e082019050611dfe600083018a611d7e565b611e0b6020830189611d7e565b611e186040830188611dbf565b611e256060830187611d7e565b611e326080830186611d7e565b611e3f60a0830185611dda565b611e4c60c0830184611dda565b98975050505050505050565b611e6181611dad565b8114611e6c57600080fd5b50565b600081359050611e7e81611e58565b92915050565b60008060408385031215611e9b57611e9a611d11565b5b6000611ea985828601611e6f565b9250506020611eba85828601611d3c565b9150509250929050565b6000602082019050611ed96000830184611d7e565b92915050565b600081519050919050565b600082825260208201905092915050565b60005b83811015611f19578082015181840152602081019050611efe565b60008484015250505050565b6000601f19601f8301169050919050565b6000611f4182611edf565b611f4b8185611eea565b9350611f5b818560208601611efb565b611f6481611f25565b840191505092915050565b600061012082019050611f85600083018c611d7e565b611f92602083018b611dbf565b611f9f604083018a611d7e565b8181036060830152611fb18189611f36565b90508181036080830152611fc58188611f36565b905081810360a0830152611fd98187611f36565b9050611fe860c0830186611d7e565b611ff560e0830185611d7e565b612003610100830184611dda565b9a9950505050505050505050565b600080fd5b600080fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b61205382611f25565b810181811067ffffffffffffffff821117156120725761207161201b565b5b80604052505050565b6000612085611d07565b9050612091828261204a565b919050565b600067ffffffffffffffff8211156120b1576120b061201b565b5b6120ba82611f25565b9050602081019050919050565b82818337600083830152505050565b60006120e96120e484612096565b61207b565b90508281526020810184848401111561210557612104612016565b5b6121108482856120c7565b509392505050565b600082601f83011261212d5761212c612011565b5b813561213d8482602086016120d6565b91505092915050565b61214f81611dce565b811461215a57600080fd5b50565b60008135905061216c81612146565b92915050565b600080600080600080600060e0888a03121561219157612190611d11565b5b600061219f8a828b01611d3c565b975050602088013567ffffffffffffffff8111156121c0576121bf611d16565b5b6121cc8a828b01612118565b965050604088013567ffffffffffffffff8111156121ed576121ec611d16565b5b6121f98a828b01612118565b955050606088013567ffffffffffffffff81111561221a57612219611d16565b5b6122268a828b01612118565b94505060806122378a828b01611d3c565b93505060a06122488a828b01611d3c565b92505060c06122598a828b0161215d565b91505092959891949750929550565b6000819050919050565b600061228d61228861228384611d8d565b612268565b611d8d565b9050919050565b600061229f82612272565b9050919050565b60006122b182612294565b9050919050565b6122c1816122a6565b82525050565b60006020820190506122dc60008301846122b8565b92915050565b600080604083850312156122f9576122f8611d11565b5b600061230785828601611d3c565b925050602061231885828601611d3c565b9150509250929050565b60008060008060008060c0878903121561233f5761233e611d11565b5b600061234d89828a01611d3c565b965050602087013567ffffffffffffffff81111561236e5761236d611d16565b5b61237a89828a01612118565b955050604087013567ffffffffffffffff81111561239b5761239a611d16565b5b6123a789828a01612118565b945050606087013567ffffffffffffffff8111156123c8576123c7611d16565b5b6123d489828a01612118565b93505060806123e589828a01611d3c565b92505060a06123f689828a01611d3c565b9150509295509295509295565b60006020828403121561241957612418611d11565b5b600061242784828501611e6f565b91505092915050565b60006020820190506124456000830184611dbf565b92915050565b60006040820190506124606000830185611dbf565b61246d6020830184611d7e565b9392505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806124bb57607f821691505b6020821081036124ce576124cd612474565b5b50919050565b7f4f6e6c792063726561746f722063616e2075706461746520746865207265776160008201527f7264000000000000000000000000000000000000000000000000000000000000602082015250565b6000612530602283611eea565b915061253b826124d4565b604082019050919050565b6000602082019050818103600083015261255f81612523565b9050919050565b7f546f6b656e207072696365206d7573742062652067726561746572207468616e60008201527f207a65726f000000000000000000000000000000000000000000000000000000602082015250565b60006125c2602583611eea565b91506125cd82612566565b604082019050919050565b600060208201905081810360008301526125f1816125b5565b9050919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b60006008830261265a7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8261261d565b612664868361261d565b95508019841693508086168417925050509392505050565b600061269761269261268d84611d1b565b612268565b611d1b565b9050919050565b6000819050919050565b6126b18361267c565b6126c56126bd8261269e565b84845461262a565b825550505050565b600090565b6126da6126cd565b6126e58184846126a8565b505050565b5b81811015612709576126fe6000826126d2565b6001810190506126eb565b5050565b601f82111561274e5761271f816125f8565b6127288461260d565b81016020851015612737578190505b61274b6127438561260d565b8301826126ea565b50505b505050565b600082821c905092915050565b600061277160001984600802612753565b1980831691505092915050565b600061278a8383612760565b9150826002028217905092915050565b6127a382611edf565b67ffffffffffffffff8111156127bc576127bb61201b565b5b6127c682546124a3565b6127d182828561270d565b600060209050601f83116001811461280457600084156127f2578287015190505b6127fc858261277e565b865550612864565b601f198416612812866125f8565b60005b8281101561283a57848901518255600182019150602085019450602081019050612815565b868310156128575784890151612853601f891682612760565b8355505b6001600288020188555050505b505050505050565b600060608201905081810360008301526128868186611f36565b90506128956020830185611d7e565b6128a26040830184611dda565b949350505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b60006128e482611d1b565b91507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8203612916576129156128aa565b5b600182019050919050565b60008151905061293081612146565b92915050565b60006020828403121561294c5761294b611d11565b5b600061295a84828501612921565b91505092915050565b7f526566756e64206661696c656400000000000000000000000000000000000000600082015250565b6000612999600d83611eea565b91506129a482612963565b602082019050919050565b600060208201905081810360008301526129c88161298c565b9050919050565b7f496e646578206f7574206f6620626f756e647300000000000000000000000000600082015250565b6000612a05601383611eea565b9150612a10826129cf565b602082019050919050565b60006020820190508181036000830152612a34816129f8565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b7f53746f636b206d7573742062652067726561746572207468616e207a65726f00600082015250565b6000612aa0601f83611eea565b9150612aab82612a6a565b602082019050919050565b60006020820190508181036000830152612acf81612a93565b9050919050565b60006040820190508181036000830152612af08185611f36565b9050612aff6020830184611d7e565b9392505050565b7f45786368616e676520646f6573206e6f74206578697374000000000000000000600082015250565b6000612b3c601783611eea565b9150612b4782612b06565b602082019050919050565b60006020820190508181036000830152612b6b81612b2f565b9050919050565b7f4f6e6c79207265776172642063726561746f722063616e20726576696577207460008201527f68652065786368616e6765000000000000000000000000000000000000000000602082015250565b6000612bce602b83611eea565b9150612bd982612b72565b604082019050919050565b60006020820190508181036000830152612bfd81612bc1565b9050919050565b7f45786368616e676520616c72656164792066756c66696c6c6564000000000000600082015250565b6000612c3a601a83611eea565b9150612c4582612c04565b602082019050919050565b60006020820190508181036000830152612c6981612c2d565b9050919050565b7f45786368616e676520616c7265616479206465636c696e656400000000000000600082015250565b6000612ca6601983611eea565b9150612cb182612c70565b602082019050919050565b60006020820190508181036000830152612cd581612c99565b9050919050565b7f526577617264206973206e6f7420616374697665000000000000000000000000600082015250565b6000612d12601483611eea565b9150612d1d82612cdc565b602082019050919050565b60006020820190508181036000830152612d4181612d05565b9050919050565b7f526577617264206f7574206f662073746f636b00000000000000000000000000600082015250565b6000612d7e601383611eea565b9150612d8982612d48565b602082019050919050565b60006020820190508181036000830152612dad81612d71565b9050919050565b600081519050612dc381611d25565b92915050565b600060208284031215612ddf57612dde611d11565b5b6000612ded84828501612db4565b91505092915050565b7f496e73756666696369656e7420746f6b656e2062616c616e6365000000000000600082015250565b6000612e2c601a83611eea565b9150612e3782612df6565b602082019050919050565b60006020820190508181036000830152612e5b81612e1f565b9050919050565b6000612e6d82611d1b565b915060008203612e8057612e7f6128aa565b5b60018203905091905056fea26469706673582212205f0156dc465dbb1ce000333c07a6942132e4464fd3184388a16961a2327a57e564736f6c634300081e0033",
 }
 
 // RewardRegistryABI is the input ABI used to generate the binding from.
 // Deprecated: Use RewardRegistryMetaData.ABI instead.
 var RewardRegistryABI = RewardRegistryMetaData.ABI
 
+// RewardRegistryBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use RewardRegistryMetaData.Bin instead.
+var RewardRegistryBin = RewardRegistryMetaData.Bin
+
+// DeployRewardRegistry deploys a new Ethereum contract, binding an instance of RewardRegistry to it.
+func DeployRewardRegistry(auth *bind.TransactOpts, backend bind.ContractBackend, _tokenAddress common.Address) (common.Address, *types.Transaction, *RewardRegistry, error) {
+	parsed, err := RewardRegistryMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(RewardRegistryBin), backend, _tokenAddress)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &RewardRegistry{RewardRegistryCaller: RewardRegistryCaller{contract: contract}, RewardRegistryTransactor: RewardRegistryTransactor{contract: contract}, RewardRegistryFilterer: RewardRegistryFilterer{contract: contract}}, nil
+}
+
 // RewardRegistry is an auto generated Go binding around an Ethereum contract.
 type RewardRegistry struct {
 	RewardRegistryCaller     // Read-only binding to the contract
@@ -244,7 +266,7 @@ func (_RewardRegistry *RewardRegistryCallerSession) ExchangeCount() (*big.Int, e
 
 // Exchanges is a free data retrieval call binding the contract method 0x2839fc29.
 //
-// Solidity: function exchanges(uint256 ) view returns(uint256 id, uint256 rewardId, address child, uint256 tokenAmount, uint256 exchangeDate, bool fulfilled)
+// Solidity: function exchanges(uint256 ) view returns(uint256 id, uint256 rewardId, address child, uint256 tokenAmount, uint256 exchangeDate, bool fulfilled, bool declined)
 func (_RewardRegistry *RewardRegistryCaller) Exchanges(opts *bind.CallOpts, arg0 *big.Int) (struct {
 	Id           *big.Int
 	RewardId     *big.Int
@@ -252,6 +274,7 @@ func (_RewardRegistry *RewardRegistryCaller) Exchanges(opts *bind.CallOpts, arg0
 	TokenAmount  *big.Int
 	ExchangeDate *big.Int
 	Fulfilled    bool
+	Declined     bool
 }, error) {
 	var out []interface{}
 	err := _RewardRegistry.contract.Call(opts, &out, "exchanges", arg0)
@@ -263,6 +286,7 @@ func (_RewardRegistry *RewardRegistryCaller) Exchanges(opts *bind.CallOpts, arg0
 		TokenAmount  *big.Int
 		ExchangeDate *big.Int
 		Fulfilled    bool
+		Declined     bool
 	})
 	if err != nil {
 		return *outstruct, err
@@ -274,6 +298,7 @@ func (_RewardRegistry *RewardRegistryCaller) Exchanges(opts *bind.CallOpts, arg0
 	outstruct.TokenAmount = *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
 	outstruct.ExchangeDate = *abi.ConvertType(out[4], new(*big.Int)).(**big.Int)
 	outstruct.Fulfilled = *abi.ConvertType(out[5], new(bool)).(*bool)
+	outstruct.Declined = *abi.ConvertType(out[6], new(bool)).(*bool)
 
 	return *outstruct, err
 
@@ -281,7 +306,7 @@ func (_RewardRegistry *RewardRegistryCaller) Exchanges(opts *bind.CallOpts, arg0
 
 // Exchanges is a free data retrieval call binding the contract method 0x2839fc29.
 //
-// Solidity: function exchanges(uint256 ) view returns(uint256 id, uint256 rewardId, address child, uint256 tokenAmount, uint256 exchangeDate, bool fulfilled)
+// Solidity: function exchanges(uint256 ) view returns(uint256 id, uint256 rewardId, address child, uint256 tokenAmount, uint256 exchangeDate, bool fulfilled, bool declined)
 func (_RewardRegistry *RewardRegistrySession) Exchanges(arg0 *big.Int) (struct {
 	Id           *big.Int
 	RewardId     *big.Int
@@ -289,13 +314,14 @@ func (_RewardRegistry *RewardRegistrySession) Exchanges(arg0 *big.Int) (struct {
 	TokenAmount  *big.Int
 	ExchangeDate *big.Int
 	Fulfilled    bool
+	Declined     bool
 }, error) {
 	return _RewardRegistry.Contract.Exchanges(&_RewardRegistry.CallOpts, arg0)
 }
 
 // Exchanges is a free data retrieval call binding the contract method 0x2839fc29.
 //
-// Solidity: function exchanges(uint256 ) view returns(uint256 id, uint256 rewardId, address child, uint256 tokenAmount, uint256 exchangeDate, bool fulfilled)
+// Solidity: function exchanges(uint256 ) view returns(uint256 id, uint256 rewardId, address child, uint256 tokenAmount, uint256 exchangeDate, bool fulfilled, bool declined)
 func (_RewardRegistry *RewardRegistryCallerSession) Exchanges(arg0 *big.Int) (struct {
 	Id           *big.Int
 	RewardId     *big.Int
@@ -303,6 +329,7 @@ func (_RewardRegistry *RewardRegistryCallerSession) Exchanges(arg0 *big.Int) (st
 	TokenAmount  *big.Int
 	ExchangeDate *big.Int
 	Fulfilled    bool
+	Declined     bool
 }, error) {
 	return _RewardRegistry.Contract.Exchanges(&_RewardRegistry.CallOpts, arg0)
 }
@@ -402,13 +429,13 @@ func (_RewardRegistry *RewardRegistryCallerSession) GetChildExchangeId(_child co
 
 // GetExchange is a free data retrieval call binding the contract method 0x0b9d5847.
 //
-// Solidity: function getExchange(uint256 _exchangeId) view returns(uint256, uint256, address, uint256, uint256, bool)
-func (_RewardRegistry *RewardRegistryCaller) GetExchange(opts *bind.CallOpts, _exchangeId *big.Int) (*big.Int, *big.Int, common.Address, *big.Int, *big.Int, bool, error) {
+// Solidity: function getExchange(uint256 _exchangeId) view returns(uint256, uint256, address, uint256, uint256, bool, bool)
+func (_RewardRegistry *RewardRegistryCaller) GetExchange(opts *bind.CallOpts, _exchangeId *big.Int) (*big.Int, *big.Int, common.Address, *big.Int, *big.Int, bool, bool, error) {
 	var out []interface{}
 	err := _RewardRegistry.contract.Call(opts, &out, "getExchange", _exchangeId)
 
 	if err != nil {
-		return *new(*big.Int), *new(*big.Int), *new(common.Address), *new(*big.Int), *new(*big.Int), *new(bool), err
+		return *new(*big.Int), *new(*big.Int), *new(common.Address), *new(*big.Int), *new(*big.Int), *new(bool), *new(bool), err
 	}
 
 	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
@@ -417,22 +444,23 @@ func (_RewardRegistry *RewardRegistryCaller) GetExchange(opts *bind.CallOpts, _e
 	out3 := *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
 	out4 := *abi.ConvertType(out[4], new(*big.Int)).(**big.Int)
 	out5 := *abi.ConvertType(out[5], new(bool)).(*bool)
+	out6 := *abi.ConvertType(out[6], new(bool)).(*bool)
 
-	return out0, out1, out2, out3, out4, out5, err
+	return out0, out1, out2, out3, out4, out5, out6, err
 
 }
 
 // GetExchange is a free data retrieval call binding the contract method 0x0b9d5847.
 //
-// Solidity: function getExchange(uint256 _exchangeId) view returns(uint256, uint256, address, uint256, uint256, bool)
-func (_RewardRegistry *RewardRegistrySession) GetExchange(_exchangeId *big.Int) (*big.Int, *big.Int, common.Address, *big.Int, *big.Int, bool, error) {
+// Solidity: function getExchange(uint256 _exchangeId) view returns(uint256, uint256, address, uint256, uint256, bool, bool)
+func (_RewardRegistry *RewardRegistrySession) GetExchange(_exchangeId *big.Int) (*big.Int, *big.Int, common.Address, *big.Int, *big.Int, bool, bool, error) {
 	return _RewardRegistry.Contract.GetExchange(&_RewardRegistry.CallOpts, _exchangeId)
 }
 
 // GetExchange is a free data retrieval call binding the contract method 0x0b9d5847.
 //
-// Solidity: function getExchange(uint256 _exchangeId) view returns(uint256, uint256, address, uint256, uint256, bool)
-func (_RewardRegistry *RewardRegistryCallerSession) GetExchange(_exchangeId *big.Int) (*big.Int, *big.Int, common.Address, *big.Int, *big.Int, bool, error) {
+// Solidity: function getExchange(uint256 _exchangeId) view returns(uint256, uint256, address, uint256, uint256, bool, bool)
+func (_RewardRegistry *RewardRegistryCallerSession) GetExchange(_exchangeId *big.Int) (*big.Int, *big.Int, common.Address, *big.Int, *big.Int, bool, bool, error) {
 	return _RewardRegistry.Contract.GetExchange(&_RewardRegistry.CallOpts, _exchangeId)
 }
 
@@ -731,6 +759,27 @@ func (_RewardRegistry *RewardRegistryTransactorSession) CreateReward(_familyId *
 	return _RewardRegistry.Contract.CreateReward(&_RewardRegistry.TransactOpts, _familyId, _name, _description, _imageURI, _tokenPrice, _stock)
 }
 
+// DeclineExchange is a paid mutator transaction binding the contract method 0x572584ad.
+//
+// Solidity: function declineExchange(uint256 _exchangeId) returns()
+func (_RewardRegistry *RewardRegistryTransactor) DeclineExchange(opts *bind.TransactOpts, _exchangeId *big.Int) (*types.Transaction, error) {
+	return _RewardRegistry.contract.Transact(opts, "declineExchange", _exchangeId)
+}
+
+// DeclineExchange is a paid mutator transaction binding the contract method 0x572584ad.
+//
+// Solidity: function declineExchange(uint256 _exchangeId) returns()
+func (_RewardRegistry *RewardRegistrySession) DeclineExchange(_exchangeId *big.Int) (*types.Transaction, error) {
+	return _RewardRegistry.Contract.DeclineExchange(&_RewardRegistry.TransactOpts, _exchangeId)
+}
+
+// DeclineExchange is a paid mutator transaction binding the contract method 0x572584ad.
+//
+// Solidity: function declineExchange(uint256 _exchangeId) returns()
+func (_RewardRegistry *RewardRegistryTransactorSession) DeclineExchange(_exchangeId *big.Int) (*types.Transaction, error) {
+	return _RewardRegistry.Contract.DeclineExchange(&_RewardRegistry.TransactOpts, _exchangeId)
+}
+
 // ExchangeReward is a paid mutator transaction binding the contract method 0x6a60630d.
 //
 // Solidity: function exchangeReward(uint256 _rewardId) returns(uint256)
@@ -752,6 +801,27 @@ func (_RewardRegistry *RewardRegistryTransactorSession) ExchangeReward(_rewardId
 	return _RewardRegistry.Contract.ExchangeReward(&_RewardRegistry.TransactOpts, _rewardId)
 }
 
+// ExchangeRewardFor is a paid mutator transaction binding the contract method 0x13b237ce.
+//
+// Solidity: function exchangeRewardFor(address _child, uint256 _rewardId) returns(uint256)
+func (_RewardRegistry *RewardRegistryTransactor) ExchangeRewardFor(opts *bind.TransactOpts, _child common.Address, _rewardId *big.Int) (*types.Transaction, error) {
+	return _RewardRegistry.contract.Transact(opts, "exchangeRewardFor", _child, _rewardId)
+}
+
+// ExchangeRewardFor is a paid mutator transaction binding the contract method 0x13b237ce.
+//
+// Solidity: function exchangeRewardFor(address _child, uint256 _rewardId) returns(uint256)
+func (_RewardRegistry *RewardRegistrySession) ExchangeRewardFor(_child common.Address, _rewardId *big.Int) (*types.Transaction, error) {
+	return _RewardRegistry.Contract.ExchangeRewardFor(&_RewardRegistry.TransactOpts, _child, _rewardId)
+}
+
+// ExchangeRewardFor is a paid mutator transaction binding the contract method 0x13b237ce.
+//
+// Solidity: function exchangeRewardFor(address _child, uint256 _rewardId) returns(uint256)
+func (_RewardRegistry *RewardRegistryTransactorSession) ExchangeRewardFor(_child common.Address, _rewardId *big.Int) (*types.Transaction, error) {
+	return _RewardRegistry.Contract.ExchangeRewardFor(&_RewardRegistry.TransactOpts, _child, _rewardId)
+}
+
 // FulfillExchange is a paid mutator transaction binding the contract method 0x0670ea71.
 //
 // Solidity: function fulfillExchange(uint256 _exchangeId) returns()
@@ -836,6 +906,159 @@ func (_RewardRegistry *RewardRegistryTransactorSession) UpdateReward(_rewardId *
 	return _RewardRegistry.Contract.UpdateReward(&_RewardRegistry.TransactOpts, _rewardId, _name, _description, _imageURI, _tokenPrice, _stock, _active)
 }
 
+// RewardRegistryExchangeDeclinedIterator is returned from FilterExchangeDeclined and is used to iterate over the raw logs and unpacked data for ExchangeDeclined events raised by the RewardRegistry contract.
+type RewardRegistryExchangeDeclinedIterator struct {
+	Event *RewardRegistryExchangeDeclined // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *RewardRegistryExchangeDeclinedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(RewardRegistryExchangeDeclined)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(RewardRegistryExchangeDeclined)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *RewardRegistryExchangeDeclinedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *RewardRegistryExchangeDeclinedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// RewardRegistryExchangeDeclined represents a ExchangeDeclined event raised by the RewardRegistry contract.
+type RewardRegistryExchangeDeclined struct {
+	ExchangeId *big.Int
+	Parent     common.Address
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterExchangeDeclined is a free log retrieval operation binding the contract event 0x88304dc3d0cd3d055e85e57a69430dfb05451c9ba05b22c619f7659888558083.
+//
+// Solidity: event ExchangeDeclined(uint256 indexed exchangeId, address indexed parent)
+func (_RewardRegistry *RewardRegistryFilterer) FilterExchangeDeclined(opts *bind.FilterOpts, exchangeId []*big.Int, parent []common.Address) (*RewardRegistryExchangeDeclinedIterator, error) {
+
+	var exchangeIdRule []interface{}
+	for _, exchangeIdItem := range exchangeId {
+		exchangeIdRule = append(exchangeIdRule, exchangeIdItem)
+	}
+	var parentRule []interface{}
+	for _, parentItem := range parent {
+		parentRule = append(parentRule, parentItem)
+	}
+
+	logs, sub, err := _RewardRegistry.contract.FilterLogs(opts, "ExchangeDeclined", exchangeIdRule, parentRule)
+	if err != nil {
+		return nil, err
+	}
+	return &RewardRegistryExchangeDeclinedIterator{contract: _RewardRegistry.contract, event: "ExchangeDeclined", logs: logs, sub: sub}, nil
+}
+
+// WatchExchangeDeclined is a free log subscription operation binding the contract event 0x88304dc3d0cd3d055e85e57a69430dfb05451c9ba05b22c619f7659888558083.
+//
+// Solidity: event ExchangeDeclined(uint256 indexed exchangeId, address indexed parent)
+func (_RewardRegistry *RewardRegistryFilterer) WatchExchangeDeclined(opts *bind.WatchOpts, sink chan<- *RewardRegistryExchangeDeclined, exchangeId []*big.Int, parent []common.Address) (event.Subscription, error) {
+
+	var exchangeIdRule []interface{}
+	for _, exchangeIdItem := range exchangeId {
+		exchangeIdRule = append(exchangeIdRule, exchangeIdItem)
+	}
+	var parentRule []interface{}
+	for _, parentItem := range parent {
+		parentRule = append(parentRule, parentItem)
+	}
+
+	logs, sub, err := _RewardRegistry.contract.WatchLogs(opts, "ExchangeDeclined", exchangeIdRule, parentRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(RewardRegistryExchangeDeclined)
+				if err := _RewardRegistry.contract.UnpackLog(event, "ExchangeDeclined", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseExchangeDeclined is a log parse operation binding the contract event 0x88304dc3d0cd3d055e85e57a69430dfb05451c9ba05b22c619f7659888558083.
+//
+// Solidity: event ExchangeDeclined(uint256 indexed exchangeId, address indexed parent)
+func (_RewardRegistry *RewardRegistryFilterer) ParseExchangeDeclined(log types.Log) (*RewardRegistryExchangeDeclined, error) {
+	event := new(RewardRegistryExchangeDeclined)
+	if err := _RewardRegistry.contract.UnpackLog(event, "ExchangeDeclined", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // RewardRegistryExchangeFulfilledIterator is returned from FilterExchangeFulfilled and is used to iterate over the raw logs and unpacked data for ExchangeFulfilled events raised by the RewardRegistry contract.
 type RewardRegistryExchangeFulfilledIterator struct {
 	Event *RewardRegistryExchangeFulfilled // Event containing the contract specifics and raw log
@@ -31,13 +31,35 @@ var (
 
 // RewardTokenMetaData contains all meta data concerning the RewardToken contract.
 var RewardTokenMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"symbol\",\"type\":\"string\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"allowance\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"needed\",\"type\":\"uint256\"}],\"name\":\"ERC20InsufficientAllowance\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"balance\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"needed\",\"type\":\"uint256\"}],\"name\":\"ERC20InsufficientBalance\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"approver\",\"type\":\"address\"}],\"name\":\"ERC20InvalidApprover\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"}],\"name\":\"ERC20InvalidReceiver\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"}],\"name\":\"ERC20InvalidSender\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"ERC20InvalidSpender\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"OwnableInvalidOwner\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"OwnableUnauthorizedAccount\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"MinterAdded\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"MinterRemoved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"TokensBurned\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"TokensMinted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"addMinter\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"allowance\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"authorizedMinters\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"burn\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"decimals\",\"outputs\":[{\"internalType\":\"uint8\",\"name\":\"\",\"type\":\"uint8\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"mint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"removeMinter\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transfer\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"transferReward\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	ABI: "[{\"inputs\":[{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"symbol\",\"type\":\"string\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"allowance\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"needed\",\"type\":\"uint256\"}],\"name\":\"ERC20InsufficientAllowance\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"balance\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"needed\",\"type\":\"uint256\"}],\"name\":\"ERC20InsufficientBalance\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"approver\",\"type\":\"address\"}],\"name\":\"ERC20InvalidApprover\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"}],\"name\":\"ERC20InvalidReceiver\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"}],\"name\":\"ERC20InvalidSender\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"ERC20InvalidSpender\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"OwnableInvalidOwner\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"OwnableUnauthorizedAccount\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"MinterAdded\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"MinterRemoved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"previousOwner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"OwnershipTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"TokensBurned\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"escrow\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"TokensEscrowed\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"TokensMinted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"addMinter\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"allowance\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"name\":\"authorizedMinters\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"burn\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"decimals\",\"outputs\":[{\"internalType\":\"uint8\",\"name\":\"\",\"type\":\"uint8\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"escrow\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"mint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"minter\",\"type\":\"address\"}],\"name\":\"removeMinter\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"renounceOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transfer\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"newOwner\",\"type\":\"address\"}],\"name\":\"transferOwnership\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"transferReward\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x608060405234801561001057600080fd5b5060405161273938038061273983398181016040528101906100329190610373565b3382828160039081610044919061060c565b508060049081610054919061060c565b505050600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036100c95760006040517f1e4fbdf70000000000000000000000000000000000000000000000000000000081526004016100c0919061071f565b60405180910390fd5b6100d88161013860201b60201c565b506001600660003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81548160ff021916908315150217905550505061073a565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600560006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b6000604051905090565b600080fd5b600080fd5b600080fd5b600080fd5b6000601f19601f8301169050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b6102658261021c565b810181811067ffffffffffffffff821117156102845761028361022d565b5b80604052505050565b60006102976101fe565b90506102a3828261025c565b919050565b600067ffffffffffffffff8211156102c3576102c261022d565b5b6102cc8261021c565b9050602081019050919050565b60005b838110156102f75780820151818401526020810190506102dc565b60008484015250505050565b6000610316610311846102a8565b61028d565b90508281526020810184848401111561033257610331610217565b5b61033d8482856102d9565b509392505050565b600082601f83011261035a57610359610212565b5b815161036a848260208601610303565b91505092915050565b6000806040838503121561038a57610389610208565b5b600083015167ffffffffffffffff8111156103a8576103a761020d565b5b6103b485828601610345565b925050602083015167ffffffffffffffff8111156103d5576103d461020d565b5b6103e185828601610345565b9150509250929050565b600081519050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b6000600282049050600182168061043d57607f821691505b6020821081036104505761044f6103f6565b5b50919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b6000600883026104b87fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8261047b565b6104c2868361047b565b95508019841693508086168417925050509392505050565b6000819050919050565b6000819050919050565b60006105096105046104ff846104da565b6104e4565b6104da565b9050919050565b6000819050919050565b610523836104ee565b61053761052f82610510565b848454610488565b825550505050565b600090565b61054c61053f565b61055781848461051a565b505050565b5b8181101561057b57610570600082610544565b60018101905061055d565b5050565b601f8211156105c05761059181610456565b61059a8461046b565b810160208510156105a9578190505b6105bd6105b58561046b565b83018261055c565b50505b505050565b600082821c905092915050565b60006105e3600019846008026105c5565b1980831691505092915050565b60006105fc83836105d2565b9150826002028217905092915050565b610615826103eb565b67ffffffffffffffff81111561062e5761062d61022d565b5b6106388254610425565b61064382828561057f565b600060209050601f8311600181146106765760008415610664578287015190505b61066e85826105f0565b8655506106d6565b601f19841661068486610456565b60005b828110156106ac57848901518255600182019150602085019450602081019050610687565b868310156106c957848901516106c5601f8916826105d2565b8355505b6001600288020188555050505b505050505050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000610709826106de565b9050919050565b610719816106fe565b82525050565b60006020820190506107346000830184610710565b92915050565b611ff0806107496000396000f3fe608060405234801561001057600080fd5b50600436106101215760003560e01c806370a08231116100ad5780639dc29fac116100715780639dc29fac146102e2578063a9059cbb146102fe578063aa2fe91b1461032e578063dd62ed3e1461035e578063f2fde38b1461038e57610121565b806370a0823114610250578063715018a6146102805780638da5cb5b1461028a57806395d89b41146102a8578063983b2d56146102c657610121565b806323b872dd116100f457806323b872dd146101ae5780632efd5b06146101de5780633092afd5146101fa578063313ce5671461021657806340c10f191461023457610121565b806306fdde0314610126578063095ea7b31461014457806315490ebb1461017457806318160ddd14610190575b600080fd5b61012e6103aa565b60405161013b9190611794565b60405180910390f35b61015e6004803603810190610159919061184f565b61043c565b60405161016b91906118aa565b60405180910390f35b61018e6004803603810190610189919061184f565b61045f565b005b610198610570565b6040516101a591906118d4565b60405180910390f35b6101c860048036038101906101c391906118ef565b61057a565b6040516101d591906118aa565b60405180910390f35b6101f860048036038101906101f3919061184f565b6105a9565b005b610214600480360381019061020f9190611942565b610763565b005b61021e610895565b60405161022b919061198b565b60405180910390f35b61024e6004803603810190610249919061184f565b61089e565b005b61026a60048036038101906102659190611942565b6109f5565b60405161027791906118d4565b60405180910390f35b610288610a3d565b005b610292610a51565b60405161029f91906119b5565b60405180910390f35b6102b0610a7b565b6040516102bd9190611794565b60405180910390f35b6102e060048036038101906102db9190611942565b610b0d565b005b6102fc60048036038101906102f7919061184f565b610c22565b005b6103186004803603810190610313919061184f565b610dc4565b60405161032591906118aa565b60405180910390f35b61034860048036038101906103439190611942565b610de7565b60405161035591906118aa565b60405180910390f35b610378600480360381019061037391906119d0565b610e07565b60405161038591906118d4565b60405180910390f35b6103a860048036038101906103a39190611942565b610e8e565b005b6060600380546103b990611a3f565b80601f01602080910402602001604051908101604052809291908181526020018280546103e590611a3f565b80156104325780601f1061040757610100808354040283529160200191610432565b820191906000526020600020905b81548152906001019060200180831161041557829003601f168201915b5050505050905090565b600080610447610f14565b9050610454818585610f1c565b600191505092915050565b600660003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff166104eb576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016104e290611ae2565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff160361055a576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161055190611b74565b60405180910390fd5b61056c610565610a51565b8383610f2e565b5050565b6000600254905090565b600080610585610f14565b9050610592858285611022565b61059d858585610f2e565b60019150509392505050565b600660003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff16610635576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161062c90611ae2565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036106a4576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161069b90611c06565b60405180910390fd5b806106ae836109f5565b10156106ef576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016106e690611c98565b60405180910390fd5b6106fa823383610f2e565b3373ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff167f70185d8046dbe466ba5a465f6e8ce12b3ced94f7c519a4125a79ae86db3c72538360405161075791906118d4565b60405180910390a35050565b61076b6110b7565b600660008273ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff166107f7576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016107ee90611d04565b60405180910390fd5b6000600660008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81548160ff0219169083151502179055508073ffffffffffffffffffffffffffffffffffffffff167fe94479a9f7e1952cc78f2d6baab678adc1b772d936c6583def489e524cb6669260405160405180910390a250565b60006012905090565b600660003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff1661092a576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161092190611ae2565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603610999576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161099090611d70565b60405180910390fd5b6109a3828261113e565b8173ffffffffffffffffffffffffffffffffffffffff167f3f2c9d57c068687834f0de942a9babb9e5acab57d516d3480a3c16ee165a4273826040516109e991906118d4565b60405180910390a25050565b60008060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020549050919050565b610a456110b7565b610a4f60006111c0565b565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b606060048054610a8a90611a3f565b80601f0160208091040260200160405190810160405280929190818152602001828054610ab690611a3f565b8015610b035780601f10610ad857610100808354040283529160200191610b03565b820191906000526020600020905b815481529060010190602001808311610ae657829003601f168201915b5050505050905090565b610b156110b7565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1603610b84576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610b7b90611ddc565b60405180910390fd5b6001600660008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060006101000a81548160ff0219169083151502179055508073ffffffffffffffffffffffffffffffffffffffff167f6ae172837ea30b801fbfcdd4108aa1d5bf8ff775444fd70256b44e6bf3dfc3f660405160405180910390a250565b600660003373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060009054906101000a900460ff16610cae576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ca590611ae2565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603610d1d576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610d1490611e6e565b60405180910390fd5b80610d27836109f5565b1015610d68576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610d5f90611f00565b60405180910390fd5b610d728282611286565b8173ffffffffffffffffffffffffffffffffffffffff167ffd38818f5291bf0bb3a2a48aadc06ba8757865d1dabd804585338aab3009dcb682604051610db891906118d4565b60405180910390a25050565b600080610dcf610f14565b9050610ddc818585610f2e565b600191505092915050565b60066020528060005260406000206000915054906101000a900460ff1681565b6000600160008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002054905092915050565b610e966110b7565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff1603610f085760006040517f1e4fbdf7000000000000000000000000000000000000000000000000000000008152600401610eff91906119b5565b60405180910390fd5b610f11816111c0565b50565b600033905090565b610f298383836001611308565b505050565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1603610fa05760006040517f96c6fd1e000000000000000000000000000000000000000000000000000000008152600401610f9791906119b5565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036110125760006040517fec442f0500000000000000000000000000000000000000000000000000000000815260040161100991906119b5565b60405180910390fd5b61101d8383836114df565b505050565b600061102e8484610e07565b90507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8110156110b157818110156110a1578281836040517ffb8f41b200000000000000000000000000000000000000000000000000000000815260040161109893929190611f20565b60405180910390fd5b6110b084848484036000611308565b5b50505050565b6110bf610f14565b73ffffffffffffffffffffffffffffffffffffffff166110dd610a51565b73ffffffffffffffffffffffffffffffffffffffff161461113c57611100610f14565b6040517f118cdaa700000000000000000000000000000000000000000000000000000000815260040161113391906119b5565b60405180910390fd5b565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036111b05760006040517fec442f050000000000000000000000000000000000000000000000000000000081526004016111a791906119b5565b60405180910390fd5b6111bc600083836114df565b5050565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600560006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036112f85760006040517f96c6fd1e0000000000000000000000000000000000000000000000000000000081526004016112ef91906119b5565b60405180910390fd5b611304826000836114df565b5050565b600073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff160361137a5760006040517fe602df0500000000000000000000000000000000000000000000000000000000815260040161137191906119b5565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff16036113ec5760006040517f94280d620000000000000000000000000000000000000000000000000000000081526004016113e391906119b5565b60405180910390fd5b81600160008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020019081526020016000208190555080156114d9578273ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff167f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925846040516114d091906118d4565b60405180910390a35b50505050565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff16036115315780600260008282546115259190611f86565b92505081905550611604565b60008060008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020549050818110156115bd578381836040517fe450d38c0000000000000000000000000000000000000000000000000000000081526004016115b493929190611f20565b60405180910390fd5b8181036000808673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002081905550505b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff160361164d578060026000828254039250508190555061169a565b806000808473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600082825401925050819055505b8173ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef836040516116f791906118d4565b60405180910390a3505050565b600081519050919050565b600082825260208201905092915050565b60005b8381101561173e578082015181840152602081019050611723565b60008484015250505050565b6000601f19601f8301169050919050565b600061176682611704565b611770818561170f565b9350611780818560208601611720565b6117898161174a565b840191505092915050565b600060208201905081810360008301526117ae818461175b565b905092915050565b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b60006117e6826117bb565b9050919050565b6117f6816117db565b811461180157600080fd5b50565b600081359050611813816117ed565b92915050565b6000819050919050565b61182c81611819565b811461183757600080fd5b50565b60008135905061184981611823565b92915050565b60008060408385031215611866576118656117b6565b5b600061187485828601611804565b92505060206118858582860161183a565b9150509250929050565b60008115159050919050565b6118a48161188f565b82525050565b60006020820190506118bf600083018461189b565b92915050565b6118ce81611819565b82525050565b60006020820190506118e960008301846118c5565b92915050565b600080600060608486031215611908576119076117b6565b5b600061191686828701611804565b935050602061192786828701611804565b92505060406119388682870161183a565b9150509250925092565b600060208284031215611958576119576117b6565b5b600061196684828501611804565b91505092915050565b600060ff82169050919050565b6119858161196f565b82525050565b60006020820190506119a0600083018461197c565b92915050565b6119af816117db565b82525050565b60006020820190506119ca60008301846119a6565b92915050565b600080604083850312156119e7576119e66117b6565b5b60006119f585828601611804565b9250506020611a0685828601611804565b9150509250929050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b60006002820490506001821680611a5757607f821691505b602082108103611a6a57611a69611a10565b5b50919050565b7f43616c6c6572206973206e6f7420616e20617574686f72697a6564206d696e7460008201527f6572000000000000000000000000000000000000000000000000000000000000602082015250565b6000611acc60228361170f565b9150611ad782611a70565b604082019050919050565b60006020820190508181036000830152611afb81611abf565b9050919050565b7f45524332303a207472616e7366657220746f20746865207a65726f206164647260008201527f6573730000000000000000000000000000000000000000000000000000000000602082015250565b6000611b5e60238361170f565b9150611b6982611b02565b604082019050919050565b60006020820190508181036000830152611b8d81611b51565b9050919050565b7f45524332303a20657363726f772066726f6d20746865207a65726f206164647260008201527f6573730000000000000000000000000000000000000000000000000000000000602082015250565b6000611bf060238361170f565b9150611bfb82611b94565b604082019050919050565b60006020820190508181036000830152611c1f81611be3565b9050919050565b7f45524332303a20657363726f7720616d6f756e7420657863656564732062616c60008201527f616e636500000000000000000000000000000000000000000000000000000000602082015250565b6000611c8260248361170f565b9150611c8d82611c26565b604082019050919050565b60006020820190508181036000830152611cb181611c75565b9050919050565b7f41646472657373206973206e6f742061206d696e746572000000000000000000600082015250565b6000611cee60178361170f565b9150611cf982611cb8565b602082019050919050565b60006020820190508181036000830152611d1d81611ce1565b9050919050565b7f45524332303a206d696e7420746f20746865207a65726f206164647265737300600082015250565b6000611d5a601f8361170f565b9150611d6582611d24565b602082019050919050565b60006020820190508181036000830152611d8981611d4d565b9050919050565b7f496e76616c6964206d696e746572206164647265737300000000000000000000600082015250565b6000611dc660168361170f565b9150611dd182611d90565b602082019050919050565b60006020820190508181036000830152611df581611db9565b9050919050565b7f45524332303a206275726e2066726f6d20746865207a65726f2061646472657360008201527f7300000000000000000000000000000000000000000000000000000000000000602082015250565b6000611e5860218361170f565b9150611e6382611dfc565b604082019050919050565b60006020820190508181036000830152611e8781611e4b565b9050919050565b7f45524332303a206275726e20616d6f756e7420657863656564732062616c616e60008201527f6365000000000000000000000000000000000000000000000000000000000000602082015250565b6000611eea60228361170f565b9150611ef582611e8e565b604082019050919050565b60006020820190508181036000830152611f1981611edd565b9050919050565b6000606082019050611f3560008301866119a6565b611f4260208301856118c5565b611f4f60408301846118c5565b949350505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b6000611f9182611819565b9150611f9c83611819565b9250828201905080821115611fb457611fb3611f57565b5b9291505056fea264697066735822122089ad8c0b07a4a6ccce47793a56185c236aef59b59c3a7792f9bff59728c9437d64736f6c634300081e0033",
 }
 
 // RewardTokenABI is the input ABI used to generate the binding from.
 // Deprecated: Use RewardTokenMetaData.ABI instead.
 var RewardTokenABI = RewardTokenMetaData.ABI
 
+// RewardTokenBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use RewardTokenMetaData.Bin instead.
+var RewardTokenBin = RewardTokenMetaData.Bin
+
+// DeployRewardToken deploys a new Ethereum contract, binding an instance of RewardToken to it.
+func DeployRewardToken(auth *bind.TransactOpts, backend bind.ContractBackend, name string, symbol string) (common.Address, *types.Transaction, *RewardToken, error) {
+	parsed, err := RewardTokenMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(RewardTokenBin), backend, name, symbol)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &RewardToken{RewardTokenCaller: RewardTokenCaller{contract: contract}, RewardTokenTransactor: RewardTokenTransactor{contract: contract}, RewardTokenFilterer: RewardTokenFilterer{contract: contract}}, nil
+}
+
 // RewardToken is an auto generated Go binding around an Ethereum contract.
 type RewardToken struct {
 	RewardTokenCaller     // Read-only binding to the contract
@@ -491,6 +513,27 @@ func (_RewardToken *RewardTokenTransactorSession) Burn(from common.Address, amou
 	return _RewardToken.Contract.Burn(&_RewardToken.TransactOpts, from, amount)
 }
 
+// Escrow is a paid mutator transaction binding the contract method 0x2efd5b06.
+//
+// Solidity: function escrow(address from, uint256 amount) returns()
+func (_RewardToken *RewardTokenTransactor) Escrow(opts *bind.TransactOpts, from common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _RewardToken.contract.Transact(opts, "escrow", from, amount)
+}
+
+// Escrow is a paid mutator transaction binding the contract method 0x2efd5b06.
+//
+// Solidity: function escrow(address from, uint256 amount) returns()
+func (_RewardToken *RewardTokenSession) Escrow(from common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _RewardToken.Contract.Escrow(&_RewardToken.TransactOpts, from, amount)
+}
+
+// Escrow is a paid mutator transaction binding the contract method 0x2efd5b06.
+//
+// Solidity: function escrow(address from, uint256 amount) returns()
+func (_RewardToken *RewardTokenTransactorSession) Escrow(from common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _RewardToken.Contract.Escrow(&_RewardToken.TransactOpts, from, amount)
+}
+
 // Mint is a paid mutator transaction binding the contract method 0x40c10f19.
 //
 // Solidity: function mint(address to, uint256 amount) returns()
@@ -1378,6 +1421,160 @@ func (_RewardToken *RewardTokenFilterer) ParseTokensBurned(log types.Log) (*Rewa
 	return event, nil
 }
 
+// RewardTokenTokensEscrowedIterator is returned from FilterTokensEscrowed and is used to iterate over the raw logs and unpacked data for TokensEscrowed events raised by the RewardToken contract.
+type RewardTokenTokensEscrowedIterator struct {
+	Event *RewardTokenTokensEscrowed // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *RewardTokenTokensEscrowedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(RewardTokenTokensEscrowed)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(RewardTokenTokensEscrowed)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *RewardTokenTokensEscrowedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *RewardTokenTokensEscrowedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// RewardTokenTokensEscrowed represents a TokensEscrowed event raised by the RewardToken contract.
+type RewardTokenTokensEscrowed struct {
+	From   common.Address
+	Escrow common.Address
+	Amount *big.Int
+	Raw    types.Log // Blockchain specific contextual infos
+}
+
+// FilterTokensEscrowed is a free log retrieval operation binding the contract event 0x70185d8046dbe466ba5a465f6e8ce12b3ced94f7c519a4125a79ae86db3c7253.
+//
+// Solidity: event TokensEscrowed(address indexed from, address indexed escrow, uint256 amount)
+func (_RewardToken *RewardTokenFilterer) FilterTokensEscrowed(opts *bind.FilterOpts, from []common.Address, escrow []common.Address) (*RewardTokenTokensEscrowedIterator, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var escrowRule []interface{}
+	for _, escrowItem := range escrow {
+		escrowRule = append(escrowRule, escrowItem)
+	}
+
+	logs, sub, err := _RewardToken.contract.FilterLogs(opts, "TokensEscrowed", fromRule, escrowRule)
+	if err != nil {
+		return nil, err
+	}
+	return &RewardTokenTokensEscrowedIterator{contract: _RewardToken.contract, event: "TokensEscrowed", logs: logs, sub: sub}, nil
+}
+
+// WatchTokensEscrowed is a free log subscription operation binding the contract event 0x70185d8046dbe466ba5a465f6e8ce12b3ced94f7c519a4125a79ae86db3c7253.
+//
+// Solidity: event TokensEscrowed(address indexed from, address indexed escrow, uint256 amount)
+func (_RewardToken *RewardTokenFilterer) WatchTokensEscrowed(opts *bind.WatchOpts, sink chan<- *RewardTokenTokensEscrowed, from []common.Address, escrow []common.Address) (event.Subscription, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var escrowRule []interface{}
+	for _, escrowItem := range escrow {
+		escrowRule = append(escrowRule, escrowItem)
+	}
+
+	logs, sub, err := _RewardToken.contract.WatchLogs(opts, "TokensEscrowed", fromRule, escrowRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(RewardTokenTokensEscrowed)
+				if err := _RewardToken.contract.UnpackLog(event, "TokensEscrowed", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTokensEscrowed is a log parse operation binding the contract event 0x70185d8046dbe466ba5a465f6e8ce12b3ced94f7c519a4125a79ae86db3c7253.
+//
+// Solidity: event TokensEscrowed(address indexed from, address indexed escrow, uint256 amount)
+func (_RewardToken *RewardTokenFilterer) ParseTokensEscrowed(log types.Log) (*RewardTokenTokensEscrowed, error) {
+	event := new(RewardTokenTokensEscrowed)
+	if err := _RewardToken.contract.UnpackLog(event, "TokensEscrowed", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // RewardTokenTokensMintedIterator is returned from FilterTokensMinted and is used to iterate over the raw logs and unpacked data for TokensMinted events raised by the RewardToken contract.
 type RewardTokenTokensMintedIterator struct {
 	Event *RewardTokenTokensMinted // Event containing the contract specifics and raw log
@@ -31,13 +31,35 @@ var (
 
 // TaskRegistryMetaData contains all meta data concerning the TaskRegistry contract.
 var TaskRegistryMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"RewardTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"approvedBy\",\"type\":\"address\"}],\"name\":\"TaskApproved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"assignedTo\",\"type\":\"address\"}],\"name\":\"TaskAssigned\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"completedBy\",\"type\":\"address\"}],\"name\":\"TaskCompleted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"title\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"reward\",\"type\":\"uint256\"}],\"name\":\"TaskCreated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"rejectedBy\",\"type\":\"address\"}],\"name\":\"TaskRejected\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"approveTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"childAddress\",\"type\":\"address\"}],\"name\":\"assignTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"completeTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"title\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"description\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"reward\",\"type\":\"uint256\"}],\"name\":\"createTask\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"getTask\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"rejectTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"taskCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"tasks\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"assignedTo\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"title\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"description\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"reward\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"completed\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"rejected\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"withdraw\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"stateMutability\":\"payable\",\"type\":\"receive\"}]",
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"RewardTransferred\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"approvedBy\",\"type\":\"address\"}],\"name\":\"TaskApproved\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"approvedBy\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"payout\",\"type\":\"uint256\"}],\"name\":\"TaskApprovedWithPayout\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"assignedTo\",\"type\":\"address\"}],\"name\":\"TaskAssigned\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"completedBy\",\"type\":\"address\"}],\"name\":\"TaskCompleted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"title\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"reward\",\"type\":\"uint256\"}],\"name\":\"TaskCreated\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"rejectedBy\",\"type\":\"address\"}],\"name\":\"TaskRejected\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"returnedBy\",\"type\":\"address\"}],\"name\":\"TaskReturnedForRework\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"approveTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"payout\",\"type\":\"uint256\"}],\"name\":\"approveTaskWithPayout\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"childAddress\",\"type\":\"address\"}],\"name\":\"assignTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"completeTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"title\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"description\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"reward\",\"type\":\"uint256\"}],\"name\":\"createTask\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"getTask\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"rejectTask\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"taskId\",\"type\":\"uint256\"}],\"name\":\"returnForRework\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"taskCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"tasks\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"id\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"creator\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"assignedTo\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"title\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"description\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"reward\",\"type\":\"uint256\"},{\"internalType\":\"bool\",\"name\":\"completed\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"approved\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"rejected\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"withdraw\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"stateMutability\":\"payable\",\"type\":\"receive\"}]",
+	Bin: "0x60806040526000600155348015601457600080fd5b5033600260006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550612a65806100656000396000f3fe6080604052600436106100ab5760003560e01c80638d977672116100645780638d977672146101be5780638da5cb5b14610203578063b6cb58a51461022e578063d7a2b24714610259578063e1e2955814610282578063ed23cebf146102ab576100b2565b80630a07fae6146100b75780631d65e77e146100e05780633ccfd60b1461012557806341a4e30a1461013c5780635293ee811461016c5780637d81b40b14610195576100b2565b366100b257005b600080fd5b3480156100c357600080fd5b506100de60048036038101906100d99190611bd7565b6102d4565b005b3480156100ec57600080fd5b5061010760048036038101906101029190611bd7565b6105a3565b60405161011c99989796959493929190611cff565b60405180910390f35b34801561013157600080fd5b5061013a610856565b005b61015660048036038101906101519190611ecf565b610951565b6040516101639190611f5a565b60405180910390f35b34801561017857600080fd5b50610193600480360381019061018e9190611fa1565b610bca565b005b3480156101a157600080fd5b506101bc60048036038101906101b79190611bd7565b610dad565b005b3480156101ca57600080fd5b506101e560048036038101906101e09190611bd7565b6110e8565b6040516101fa99989796959493929190611cff565b60405180910390f35b34801561020f57600080fd5b506102186112ad565b6040516102259190611fe1565b60405180910390f35b34801561023a57600080fd5b506102436112d3565b6040516102509190611f5a565b60405180910390f35b34801561026557600080fd5b50610280600480360381019061027b9190611ffc565b6112d9565b005b34801561028e57600080fd5b506102a960048036038101906102a49190611bd7565b61179d565b005b3480156102b757600080fd5b506102d260048036038101906102cd9190611bd7565b61194d565b005b3373ffffffffffffffffffffffffffffffffffffffff1660008083815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614610377576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161036e906120ae565b60405180910390fd5b60008082815260200190815260200160002060060160009054906101000a900460ff166103d9576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016103d09061211a565b60405180910390fd5b60008082815260200190815260200160002060060160019054906101000a900460ff161561043c576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161043390612186565b60405180910390fd5b600160008083815260200190815260200160002060060160016101000a81548160ff021916908315150217905550600080600083815260200190815260200160002060020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905060008060008481526020019081526020016000206005015490503373ffffffffffffffffffffffffffffffffffffffff16837fb664fa2a6a4e034515db6ca96da0095c8d853e3349904bd555c50d1ac9a6359560405160405180910390a38173ffffffffffffffffffffffffffffffffffffffff16837f99f2f3325e35634c09536d4983b1b2b0c9313e74f2b74508c7a1a521f6fce9ab836040516105499190611f5a565b60405180910390a360008290508073ffffffffffffffffffffffffffffffffffffffff166108fc839081150290604051600060405180830381858888f1935050505015801561059c573d6000803e3d6000fd5b5050505050565b600080600060608060008060008060008060008c815260200190815260200160002060405180610120016040529081600082015481526020016001820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1681526020016002820160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001600382018054610697906121d5565b80601f01602080910402602001604051908101604052809291908181526020018280546106c3906121d5565b80156107105780601f106106e557610100808354040283529160200191610710565b820191906000526020600020905b8154815290600101906020018083116106f357829003601f168201915b50505050508152602001600482018054610729906121d5565b80601f0160208091040260200160405190810160405280929190818152602001828054610755906121d5565b80156107a25780601f10610777576101008083540402835291602001916107a2565b820191906000526020600020905b81548152906001019060200180831161078557829003601f168201915b50505050508152602001600582015481526020016006820160009054906101000a900460ff161515151581526020016006820160019054906101000a900460ff161515151581526020016006820160029054906101000a900460ff1615151515815250509050806000015181602001518260400151836060015184608001518560a001518660c001518760e00151886101000151995099509950995099509950995099509950509193959799909294969850565b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff163373ffffffffffffffffffffffffffffffffffffffff16146108e6576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016108dd90612252565b60405180910390fd5b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166108fc479081150290604051600060405180830381858888f1935050505015801561094e573d6000803e3d6000fd5b50565b6000813414610995576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161098c906122be565b60405180910390fd5b600160008154809291906109a89061230d565b919050555060405180610120016040528060015481526020013373ffffffffffffffffffffffffffffffffffffffff168152602001600073ffffffffffffffffffffffffffffffffffffffff1681526020018581526020018481526020018381526020016000151581526020016000151581526020016000151581525060008060015481526020019081526020016000206000820151816000015560208201518160010160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff16021790555060408201518160020160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055506060820151816003019081610ae69190612501565b506080820151816004019081610afc9190612501565b5060a0820151816005015560c08201518160060160006101000a81548160ff02191690831515021790555060e08201518160060160016101000a81548160ff0219169083151502179055506101008201518160060160026101000a81548160ff0219169083151502179055509050503373ffffffffffffffffffffffffffffffffffffffff166001547f9e3c9757475c00b86393e183b68033bb76e48fa164849c7428ad17f62e1954a78685604051610bb69291906125d3565b60405180910390a360015490509392505050565b3373ffffffffffffffffffffffffffffffffffffffff1660008084815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614610c6d576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610c6490612675565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff1660008084815260200190815260200160002060020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614610d11576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610d08906126e1565b60405180910390fd5b8060008084815260200190815260200160002060020160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508073ffffffffffffffffffffffffffffffffffffffff16827f52476d55ecef5cf13caa64038f297fe6bbf865d9584a98b8722a15a6d5db128f60405160405180910390a35050565b3373ffffffffffffffffffffffffffffffffffffffff1660008083815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614610e50576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610e4790612773565b60405180910390fd5b60008082815260200190815260200160002060060160009054906101000a900460ff16610eb2576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610ea99061211a565b60405180910390fd5b60008082815260200190815260200160002060060160019054906101000a900460ff1615610f15576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610f0c90612186565b60405180910390fd5b60008082815260200190815260200160002060060160029054906101000a900460ff1615610f78576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401610f6f906127df565b60405180910390fd5b600160008083815260200190815260200160002060060160026101000a81548160ff021916908315150217905550600080600083815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1690508073ffffffffffffffffffffffffffffffffffffffff166108fc600080858152602001908152602001600020600501549081150290604051600060405180830381858888f1935050505015801561103b573d6000803e3d6000fd5b503373ffffffffffffffffffffffffffffffffffffffff16827fae1c93c6393c5abab2eaef81c691beeb381335c7913caabf8feaa0b24bd03cc060405160405180910390a38073ffffffffffffffffffffffffffffffffffffffff16827f99f2f3325e35634c09536d4983b1b2b0c9313e74f2b74508c7a1a521f6fce9ab600080868152602001908152602001600020600501546040516110dc9190611f5a565b60405180910390a35050565b60006020528060005260406000206000915090508060000154908060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16908060020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff169080600301805461115d906121d5565b80601f0160208091040260200160405190810160405280929190818152602001828054611189906121d5565b80156111d65780601f106111ab576101008083540402835291602001916111d6565b820191906000526020600020905b8154815290600101906020018083116111b957829003601f168201915b5050505050908060040180546111eb906121d5565b80601f0160208091040260200160405190810160405280929190818152602001828054611217906121d5565b80156112645780601f1061123957610100808354040283529160200191611264565b820191906000526020600020905b81548152906001019060200180831161124757829003601f168201915b5050505050908060050154908060060160009054906101000a900460ff16908060060160019054906101000a900460ff16908060060160029054906101000a900460ff16905089565b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1681565b60015481565b3373ffffffffffffffffffffffffffffffffffffffff1660008084815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff161461137c576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611373906120ae565b60405180910390fd5b60008083815260200190815260200160002060060160009054906101000a900460ff166113de576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016113d59061211a565b60405180910390fd5b60008083815260200190815260200160002060060160019054906101000a900460ff1615611441576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161143890612186565b60405180910390fd5b60008083815260200190815260200160002060060160029054906101000a900460ff16156114a4576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161149b906127df565b60405180910390fd5b600080838152602001908152602001600020600501548111156114fc576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016114f39061284b565b60405180910390fd5b600160008084815260200190815260200160002060060160016101000a81548160ff021916908315150217905550600080600084815260200190815260200160002060020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905060008260008086815260200190815260200160002060050154611587919061286b565b90503373ffffffffffffffffffffffffffffffffffffffff16847fb664fa2a6a4e034515db6ca96da0095c8d853e3349904bd555c50d1ac9a6359560405160405180910390a33373ffffffffffffffffffffffffffffffffffffffff16847fb5aba0739d87247d97a60420a45393af47f4f52a5e0edd647e2b1e758953aae3856040516116149190611f5a565b60405180910390a360008311156116bc578173ffffffffffffffffffffffffffffffffffffffff166108fc849081150290604051600060405180830381858888f1935050505015801561166b573d6000803e3d6000fd5b508173ffffffffffffffffffffffffffffffffffffffff16847f99f2f3325e35634c09536d4983b1b2b0c9313e74f2b74508c7a1a521f6fce9ab856040516116b39190611f5a565b60405180910390a35b600081111561179757600080600086815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1690508073ffffffffffffffffffffffffffffffffffffffff166108fc839081150290604051600060405180830381858888f19350505050158015611745573d6000803e3d6000fd5b508073ffffffffffffffffffffffffffffffffffffffff16857f99f2f3325e35634c09536d4983b1b2b0c9313e74f2b74508c7a1a521f6fce9ab8460405161178d9190611f5a565b60405180910390a3505b50505050565b3373ffffffffffffffffffffffffffffffffffffffff1660008083815260200190815260200160002060020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff1614611840576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161183790612911565b60405180910390fd5b60008082815260200190815260200160002060060160009054906101000a900460ff16156118a3576040517f08c379a000000000000000000000000000000000000000000000000000000000815260040161189a9061297d565b60405180910390fd5b600160008083815260200190815260200160002060060160006101000a81548160ff02191690831515021790555060008082815260200190815260200160002060020160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16817fbb5889c77948badf90e8a5c73d55265e5f5d6e4837a79a78c5669691b897faed60405160405180910390a350565b3373ffffffffffffffffffffffffffffffffffffffff1660008083815260200190815260200160002060010160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16146119f0576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016119e790612a0f565b60405180910390fd5b60008082815260200190815260200160002060060160009054906101000a900460ff16611a52576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611a499061211a565b60405180910390fd5b60008082815260200190815260200160002060060160019054906101000a900460ff1615611ab5576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611aac90612186565b60405180910390fd5b60008082815260200190815260200160002060060160029054906101000a900460ff1615611b18576040517f08c379a0000000000000000000000000000000000000000000000000000000008152600401611b0f906127df565b60405180910390fd5b600080600083815260200190815260200160002060060160006101000a81548160ff0219169083151502179055503373ffffffffffffffffffffffffffffffffffffffff16817f7fdc0bb5ce638a37ce967076946e30ed49a5af6be9f993aea898ed443834392460405160405180910390a350565b6000604051905090565b600080fd5b600080fd5b6000819050919050565b611bb481611ba1565b8114611bbf57600080fd5b50565b600081359050611bd181611bab565b92915050565b600060208284031215611bed57611bec611b97565b5b6000611bfb84828501611bc2565b91505092915050565b611c0d81611ba1565b82525050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000611c3e82611c13565b9050919050565b611c4e81611c33565b82525050565b600081519050919050565b600082825260208201905092915050565b60005b83811015611c8e578082015181840152602081019050611c73565b60008484015250505050565b6000601f19601f8301169050919050565b6000611cb682611c54565b611cc08185611c5f565b9350611cd0818560208601611c70565b611cd981611c9a565b840191505092915050565b60008115159050919050565b611cf981611ce4565b82525050565b600061012082019050611d15600083018c611c04565b611d22602083018b611c45565b611d2f604083018a611c45565b8181036060830152611d418189611cab565b90508181036080830152611d558188611cab565b9050611d6460a0830187611c04565b611d7160c0830186611cf0565b611d7e60e0830185611cf0565b611d8c610100830184611cf0565b9a9950505050505050505050565b600080fd5b600080fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b611ddc82611c9a565b810181811067ffffffffffffffff82111715611dfb57611dfa611da4565b5b80604052505050565b6000611e0e611b8d565b9050611e1a8282611dd3565b919050565b600067ffffffffffffffff821115611e3a57611e39611da4565b5b611e4382611c9a565b9050602081019050919050565b82818337600083830152505050565b6000611e72611e6d84611e1f565b611e04565b905082815260208101848484011115611e8e57611e8d611d9f565b5b611e99848285611e50565b509392505050565b600082601f830112611eb657611eb5611d9a565b5b8135611ec6848260208601611e5f565b91505092915050565b600080600060608486031215611ee857611ee7611b97565b5b600084013567ffffffffffffffff811115611f0657611f05611b9c565b5b611f1286828701611ea1565b935050602084013567ffffffffffffffff811115611f3357611f32611b9c565b5b611f3f86828701611ea1565b9250506040611f5086828701611bc2565b9150509250925092565b6000602082019050611f6f6000830184611c04565b92915050565b611f7e81611c33565b8114611f8957600080fd5b50565b600081359050611f9b81611f75565b92915050565b60008060408385031215611fb857611fb7611b97565b5b6000611fc685828601611bc2565b9250506020611fd785828601611f8c565b9150509250929050565b6000602082019050611ff66000830184611c45565b92915050565b6000806040838503121561201357612012611b97565b5b600061202185828601611bc2565b925050602061203285828601611bc2565b9150509250929050565b7f4f6e6c79207461736b2063726561746f722063616e20617070726f766520746860008201527f65207461736b0000000000000000000000000000000000000000000000000000602082015250565b6000612098602683611c5f565b91506120a38261203c565b604082019050919050565b600060208201905081810360008301526120c78161208b565b9050919050565b7f5461736b206e6f7420636f6d706c657465642079657400000000000000000000600082015250565b6000612104601683611c5f565b915061210f826120ce565b602082019050919050565b60006020820190508181036000830152612133816120f7565b9050919050565b7f5461736b20616c726561647920617070726f7665640000000000000000000000600082015250565b6000612170601583611c5f565b915061217b8261213a565b602082019050919050565b6000602082019050818103600083015261219f81612163565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806121ed57607f821691505b602082108103612200576121ff6121a6565b5b50919050565b7f4f6e6c79206f776e65722063616e207769746864726177000000000000000000600082015250565b600061223c601783611c5f565b915061224782612206565b602082019050919050565b6000602082019050818103600083015261226b8161222f565b9050919050565b7f6d73672e76616c7565206d75737420657175616c207265776172640000000000600082015250565b60006122a8601b83611c5f565b91506122b382612272565b602082019050919050565b600060208201905081810360008301526122d78161229b565b9050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b600061231882611ba1565b91507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff820361234a576123496122de565b5b600182019050919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b6000600883026123b77fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8261237a565b6123c1868361237a565b95508019841693508086168417925050509392505050565b6000819050919050565b60006123fe6123f96123f484611ba1565b6123d9565b611ba1565b9050919050565b6000819050919050565b612418836123e3565b61242c61242482612405565b848454612387565b825550505050565b600090565b612441612434565b61244c81848461240f565b505050565b5b8181101561247057612465600082612439565b600181019050612452565b5050565b601f8211156124b55761248681612355565b61248f8461236a565b8101602085101561249e578190505b6124b26124aa8561236a565b830182612451565b50505b505050565b600082821c905092915050565b60006124d8600019846008026124ba565b1980831691505092915050565b60006124f183836124c7565b9150826002028217905092915050565b61250a82611c54565b67ffffffffffffffff81111561252357612522611da4565b5b61252d82546121d5565b612538828285612474565b600060209050601f83116001811461256b5760008415612559578287015190505b61256385826124e5565b8655506125cb565b601f19841661257986612355565b60005b828110156125a15784890151825560018201915060208501945060208101905061257c565b868310156125be57848901516125ba601f8916826124c7565b8355505b6001600288020188555050505b505050505050565b600060408201905081810360008301526125ed8185611cab565b90506125fc6020830184611c04565b9392505050565b7f4f6e6c79207461736b2063726561746f722063616e2061737369676e2074686560008201527f207461736b000000000000000000000000000000000000000000000000000000602082015250565b600061265f602583611c5f565b915061266a82612603565b604082019050919050565b6000602082019050818103600083015261268e81612652565b9050919050565b7f5461736b20616c72656164792061737369676e65640000000000000000000000600082015250565b60006126cb601583611c5f565b91506126d682612695565b602082019050919050565b600060208201905081810360008301526126fa816126be565b9050919050565b7f4f6e6c79207461736b2063726561746f722063616e2072656a6563742074686560008201527f207461736b000000000000000000000000000000000000000000000000000000602082015250565b600061275d602583611c5f565b915061276882612701565b604082019050919050565b6000602082019050818103600083015261278c81612750565b9050919050565b7f5461736b20616c72656164792072656a65637465640000000000000000000000600082015250565b60006127c9601583611c5f565b91506127d482612793565b602082019050919050565b600060208201905081810360008301526127f8816127bc565b9050919050565b7f5061796f75742065786365656473207265776172640000000000000000000000600082015250565b6000612835601583611c5f565b9150612840826127ff565b602082019050919050565b6000602082019050818103600083015261286481612828565b9050919050565b600061287682611ba1565b915061288183611ba1565b9250828203905081811115612899576128986122de565b5b92915050565b7f4f6e6c792061737369676e6564206368696c642063616e20636f6d706c65746560008201527f20746865207461736b0000000000000000000000000000000000000000000000602082015250565b60006128fb602983611c5f565b91506129068261289f565b604082019050919050565b6000602082019050818103600083015261292a816128ee565b9050919050565b7f5461736b20616c726561647920636f6d706c6574656400000000000000000000600082015250565b6000612967601683611c5f565b915061297282612931565b602082019050919050565b600060208201905081810360008301526129968161295a565b9050919050565b7f4f6e6c79207461736b2063726561746f722063616e2072657475726e2074686560008201527f207461736b000000000000000000000000000000000000000000000000000000602082015250565b60006129f9602583611c5f565b9150612a048261299d565b604082019050919050565b60006020820190508181036000830152612a28816129ec565b905091905056fea264697066735822122065f7a0553cc0d632f5fce552b341df3fcd9cb924bbd5a8bac1249b791ef0c30264736f6c634300081e0033",
 }
 
 // TaskRegistryABI is the input ABI used to generate the binding from.
 // Deprecated: Use TaskRegistryMetaData.ABI instead.
 var TaskRegistryABI = TaskRegistryMetaData.ABI
 
+// TaskRegistryBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use TaskRegistryMetaData.Bin instead.
+var TaskRegistryBin = TaskRegistryMetaData.Bin
+
+// DeployTaskRegistry deploys a new Ethereum contract, binding an instance of TaskRegistry to it.
+func DeployTaskRegistry(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *TaskRegistry, error) {
+	parsed, err := TaskRegistryMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(TaskRegistryBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &TaskRegistry{TaskRegistryCaller: TaskRegistryCaller{contract: contract}, TaskRegistryTransactor: TaskRegistryTransactor{contract: contract}, TaskRegistryFilterer: TaskRegistryFilterer{contract: contract}}, nil
+}
+
 // TaskRegistry is an auto generated Go binding around an Ethereum contract.
 type TaskRegistry struct {
 	TaskRegistryCaller     // Read-only binding to the contract
@@ -382,6 +404,27 @@ func (_TaskRegistry *TaskRegistryTransactorSession) ApproveTask(taskId *big.Int)
 	return _TaskRegistry.Contract.ApproveTask(&_TaskRegistry.TransactOpts, taskId)
 }
 
+// ApproveTaskWithPayout is a paid mutator transaction binding the contract method 0xd7a2b247.
+//
+// Solidity: function approveTaskWithPayout(uint256 taskId, uint256 payout) returns()
+func (_TaskRegistry *TaskRegistryTransactor) ApproveTaskWithPayout(opts *bind.TransactOpts, taskId *big.Int, payout *big.Int) (*types.Transaction, error) {
+	return _TaskRegistry.contract.Transact(opts, "approveTaskWithPayout", taskId, payout)
+}
+
+// ApproveTaskWithPayout is a paid mutator transaction binding the contract method 0xd7a2b247.
+//
+// Solidity: function approveTaskWithPayout(uint256 taskId, uint256 payout) returns()
+func (_TaskRegistry *TaskRegistrySession) ApproveTaskWithPayout(taskId *big.Int, payout *big.Int) (*types.Transaction, error) {
+	return _TaskRegistry.Contract.ApproveTaskWithPayout(&_TaskRegistry.TransactOpts, taskId, payout)
+}
+
+// ApproveTaskWithPayout is a paid mutator transaction binding the contract method 0xd7a2b247.
+//
+// Solidity: function approveTaskWithPayout(uint256 taskId, uint256 payout) returns()
+func (_TaskRegistry *TaskRegistryTransactorSession) ApproveTaskWithPayout(taskId *big.Int, payout *big.Int) (*types.Transaction, error) {
+	return _TaskRegistry.Contract.ApproveTaskWithPayout(&_TaskRegistry.TransactOpts, taskId, payout)
+}
+
 // AssignTask is a paid mutator transaction binding the contract method 0x5293ee81.
 //
 // Solidity: function assignTask(uint256 taskId, address childAddress) returns()
@@ -466,6 +509,27 @@ func (_TaskRegistry *TaskRegistryTransactorSession) RejectTask(taskId *big.Int)
 	return _TaskRegistry.Contract.RejectTask(&_TaskRegistry.TransactOpts, taskId)
 }
 
+// ReturnForRework is a paid mutator transaction binding the contract method 0xed23cebf.
+//
+// Solidity: function returnForRework(uint256 taskId) returns()
+func (_TaskRegistry *TaskRegistryTransactor) ReturnForRework(opts *bind.TransactOpts, taskId *big.Int) (*types.Transaction, error) {
+	return _TaskRegistry.contract.Transact(opts, "returnForRework", taskId)
+}
+
+// ReturnForRework is a paid mutator transaction binding the contract method 0xed23cebf.
+//
+// Solidity: function returnForRework(uint256 taskId) returns()
+func (_TaskRegistry *TaskRegistrySession) ReturnForRework(taskId *big.Int) (*types.Transaction, error) {
+	return _TaskRegistry.Contract.ReturnForRework(&_TaskRegistry.TransactOpts, taskId)
+}
+
+// ReturnForRework is a paid mutator transaction binding the contract method 0xed23cebf.
+//
+// Solidity: function returnForRework(uint256 taskId) returns()
+func (_TaskRegistry *TaskRegistryTransactorSession) ReturnForRework(taskId *big.Int) (*types.Transaction, error) {
+	return _TaskRegistry.Contract.ReturnForRework(&_TaskRegistry.TransactOpts, taskId)
+}
+
 // Withdraw is a paid mutator transaction binding the contract method 0x3ccfd60b.
 //
 // Solidity: function withdraw() returns()
@@ -815,6 +879,160 @@ func (_TaskRegistry *TaskRegistryFilterer) ParseTaskApproved(log types.Log) (*Ta
 	return event, nil
 }
 
+// TaskRegistryTaskApprovedWithPayoutIterator is returned from FilterTaskApprovedWithPayout and is used to iterate over the raw logs and unpacked data for TaskApprovedWithPayout events raised by the TaskRegistry contract.
+type TaskRegistryTaskApprovedWithPayoutIterator struct {
+	Event *TaskRegistryTaskApprovedWithPayout // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TaskRegistryTaskApprovedWithPayoutIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TaskRegistryTaskApprovedWithPayout)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TaskRegistryTaskApprovedWithPayout)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TaskRegistryTaskApprovedWithPayoutIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TaskRegistryTaskApprovedWithPayoutIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TaskRegistryTaskApprovedWithPayout represents a TaskApprovedWithPayout event raised by the TaskRegistry contract.
+type TaskRegistryTaskApprovedWithPayout struct {
+	TaskId     *big.Int
+	ApprovedBy common.Address
+	Payout     *big.Int
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterTaskApprovedWithPayout is a free log retrieval operation binding the contract event 0xb5aba0739d87247d97a60420a45393af47f4f52a5e0edd647e2b1e758953aae3.
+//
+// Solidity: event TaskApprovedWithPayout(uint256 indexed taskId, address indexed approvedBy, uint256 payout)
+func (_TaskRegistry *TaskRegistryFilterer) FilterTaskApprovedWithPayout(opts *bind.FilterOpts, taskId []*big.Int, approvedBy []common.Address) (*TaskRegistryTaskApprovedWithPayoutIterator, error) {
+
+	var taskIdRule []interface{}
+	for _, taskIdItem := range taskId {
+		taskIdRule = append(taskIdRule, taskIdItem)
+	}
+	var approvedByRule []interface{}
+	for _, approvedByItem := range approvedBy {
+		approvedByRule = append(approvedByRule, approvedByItem)
+	}
+
+	logs, sub, err := _TaskRegistry.contract.FilterLogs(opts, "TaskApprovedWithPayout", taskIdRule, approvedByRule)
+	if err != nil {
+		return nil, err
+	}
+	return &TaskRegistryTaskApprovedWithPayoutIterator{contract: _TaskRegistry.contract, event: "TaskApprovedWithPayout", logs: logs, sub: sub}, nil
+}
+
+// WatchTaskApprovedWithPayout is a free log subscription operation binding the contract event 0xb5aba0739d87247d97a60420a45393af47f4f52a5e0edd647e2b1e758953aae3.
+//
+// Solidity: event TaskApprovedWithPayout(uint256 indexed taskId, address indexed approvedBy, uint256 payout)
+func (_TaskRegistry *TaskRegistryFilterer) WatchTaskApprovedWithPayout(opts *bind.WatchOpts, sink chan<- *TaskRegistryTaskApprovedWithPayout, taskId []*big.Int, approvedBy []common.Address) (event.Subscription, error) {
+
+	var taskIdRule []interface{}
+	for _, taskIdItem := range taskId {
+		taskIdRule = append(taskIdRule, taskIdItem)
+	}
+	var approvedByRule []interface{}
+	for _, approvedByItem := range approvedBy {
+		approvedByRule = append(approvedByRule, approvedByItem)
+	}
+
+	logs, sub, err := _TaskRegistry.contract.WatchLogs(opts, "TaskApprovedWithPayout", taskIdRule, approvedByRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TaskRegistryTaskApprovedWithPayout)
+				if err := _TaskRegistry.contract.UnpackLog(event, "TaskApprovedWithPayout", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTaskApprovedWithPayout is a log parse operation binding the contract event 0xb5aba0739d87247d97a60420a45393af47f4f52a5e0edd647e2b1e758953aae3.
+//
+// Solidity: event TaskApprovedWithPayout(uint256 indexed taskId, address indexed approvedBy, uint256 payout)
+func (_TaskRegistry *TaskRegistryFilterer) ParseTaskApprovedWithPayout(log types.Log) (*TaskRegistryTaskApprovedWithPayout, error) {
+	event := new(TaskRegistryTaskApprovedWithPayout)
+	if err := _TaskRegistry.contract.UnpackLog(event, "TaskApprovedWithPayout", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
 // TaskRegistryTaskAssignedIterator is returned from FilterTaskAssigned and is used to iterate over the raw logs and unpacked data for TaskAssigned events raised by the TaskRegistry contract.
 type TaskRegistryTaskAssignedIterator struct {
 	Event *TaskRegistryTaskAssigned // Event containing the contract specifics and raw log
@@ -1428,3 +1646,156 @@ func (_TaskRegistry *TaskRegistryFilterer) ParseTaskRejected(log types.Log) (*Ta
 	event.Raw = log
 	return event, nil
 }
+
+// TaskRegistryTaskReturnedForReworkIterator is returned from FilterTaskReturnedForRework and is used to iterate over the raw logs and unpacked data for TaskReturnedForRework events raised by the TaskRegistry contract.
+type TaskRegistryTaskReturnedForReworkIterator struct {
+	Event *TaskRegistryTaskReturnedForRework // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TaskRegistryTaskReturnedForReworkIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TaskRegistryTaskReturnedForRework)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TaskRegistryTaskReturnedForRework)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TaskRegistryTaskReturnedForReworkIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TaskRegistryTaskReturnedForReworkIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TaskRegistryTaskReturnedForRework represents a TaskReturnedForRework event raised by the TaskRegistry contract.
+type TaskRegistryTaskReturnedForRework struct {
+	TaskId     *big.Int
+	ReturnedBy common.Address
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterTaskReturnedForRework is a free log retrieval operation binding the contract event 0x7fdc0bb5ce638a37ce967076946e30ed49a5af6be9f993aea898ed4438343924.
+//
+// Solidity: event TaskReturnedForRework(uint256 indexed taskId, address indexed returnedBy)
+func (_TaskRegistry *TaskRegistryFilterer) FilterTaskReturnedForRework(opts *bind.FilterOpts, taskId []*big.Int, returnedBy []common.Address) (*TaskRegistryTaskReturnedForReworkIterator, error) {
+
+	var taskIdRule []interface{}
+	for _, taskIdItem := range taskId {
+		taskIdRule = append(taskIdRule, taskIdItem)
+	}
+	var returnedByRule []interface{}
+	for _, returnedByItem := range returnedBy {
+		returnedByRule = append(returnedByRule, returnedByItem)
+	}
+
+	logs, sub, err := _TaskRegistry.contract.FilterLogs(opts, "TaskReturnedForRework", taskIdRule, returnedByRule)
+	if err != nil {
+		return nil, err
+	}
+	return &TaskRegistryTaskReturnedForReworkIterator{contract: _TaskRegistry.contract, event: "TaskReturnedForRework", logs: logs, sub: sub}, nil
+}
+
+// WatchTaskReturnedForRework is a free log subscription operation binding the contract event 0x7fdc0bb5ce638a37ce967076946e30ed49a5af6be9f993aea898ed4438343924.
+//
+// Solidity: event TaskReturnedForRework(uint256 indexed taskId, address indexed returnedBy)
+func (_TaskRegistry *TaskRegistryFilterer) WatchTaskReturnedForRework(opts *bind.WatchOpts, sink chan<- *TaskRegistryTaskReturnedForRework, taskId []*big.Int, returnedBy []common.Address) (event.Subscription, error) {
+
+	var taskIdRule []interface{}
+	for _, taskIdItem := range taskId {
+		taskIdRule = append(taskIdRule, taskIdItem)
+	}
+	var returnedByRule []interface{}
+	for _, returnedByItem := range returnedBy {
+		returnedByRule = append(returnedByRule, returnedByItem)
+	}
+
+	logs, sub, err := _TaskRegistry.contract.WatchLogs(opts, "TaskReturnedForRework", taskIdRule, returnedByRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TaskRegistryTaskReturnedForRework)
+				if err := _TaskRegistry.contract.UnpackLog(event, "TaskReturnedForRework", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTaskReturnedForRework is a log parse operation binding the contract event 0x7fdc0bb5ce638a37ce967076946e30ed49a5af6be9f993aea898ed4438343924.
+//
+// Solidity: event TaskReturnedForRework(uint256 indexed taskId, address indexed returnedBy)
+func (_TaskRegistry *TaskRegistryFilterer) ParseTaskReturnedForRework(log types.Log) (*TaskRegistryTaskReturnedForRework, error) {
+	event := new(TaskRegistryTaskReturnedForRework)
+	if err := _TaskRegistry.contract.UnpackLog(event, "TaskReturnedForRework", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,152 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NonceBackend is the subset of an Ethereum client the nonce manager needs to
+// reconcile its local view with the chain. Both *ethclient.Client and the
+// simulated backend satisfy it.
+type NonceBackend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out nonces for signing addresses. Nonces are reserved
+// locally so that concurrent senders never reuse one, and the local counter is
+// reconciled with PendingNonceAt on first use and whenever the node reports a
+// nonce error.
+type NonceManager struct {
+	backend  NonceBackend
+	mu       sync.Mutex
+	accounts map[common.Address]*accountNonces
+}
+
+// accountNonces tracks the nonce state of a single signing address
+type accountNonces struct {
+	synced   bool
+	next     uint64   // 下一个从未分配过的nonce
+	released []uint64 // 已分配但未发送、可以复用的nonce（升序）
+}
+
+// NewNonceManager creates a nonce manager backed by the given client
+func NewNonceManager(backend NonceBackend) *NonceManager {
+	return &NonceManager{
+		backend:  backend,
+		accounts: make(map[common.Address]*accountNonces),
+	}
+}
+
+// Next reserves the next nonce for the address. The caller must either send a
+// transaction with it or hand it back with Release.
+func (nm *NonceManager) Next(ctx context.Context, address common.Address) (uint64, error) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	acct := nm.account(address)
+	if !acct.synced {
+		if err := nm.syncLocked(ctx, address, acct); err != nil {
+			return 0, err
+		}
+	}
+
+	// 优先复用被释放的nonce，避免出现空洞导致后续交易卡住
+	if len(acct.released) > 0 {
+		nonce := acct.released[0]
+		acct.released = acct.released[1:]
+		return nonce, nil
+	}
+
+	nonce := acct.next
+	acct.next++
+	return nonce, nil
+}
+
+// Release returns a reserved nonce whose transaction was never accepted by the
+// node so that the next sender reuses it.
+func (nm *NonceManager) Release(address common.Address, nonce uint64) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	acct := nm.account(address)
+	if nonce >= acct.next {
+		return
+	}
+	for _, n := range acct.released {
+		if n == nonce {
+			return
+		}
+	}
+	acct.released = append(acct.released, nonce)
+	sort.Slice(acct.released, func(i, j int) bool { return acct.released[i] < acct.released[j] })
+}
+
+// Sync reconciles the local counter for the address with the node's pending
+// nonce, discarding any released nonces the chain has already consumed. It is
+// called on startup and after the node rejects a nonce as too low or too high.
+func (nm *NonceManager) Sync(ctx context.Context, address common.Address) error {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	return nm.syncLocked(ctx, address, nm.account(address))
+}
+
+// Peek returns the nonce Next would hand out without reserving it
+func (nm *NonceManager) Peek(address common.Address) (uint64, bool) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	acct, ok := nm.accounts[address]
+	if !ok || !acct.synced {
+		return 0, false
+	}
+	if len(acct.released) > 0 {
+		return acct.released[0], true
+	}
+	return acct.next, true
+}
+
+func (nm *NonceManager) account(address common.Address) *accountNonces {
+	acct, ok := nm.accounts[address]
+	if !ok {
+		acct = &accountNonces{}
+		nm.accounts[address] = acct
+	}
+	return acct
+}
+
+func (nm *NonceManager) syncLocked(ctx context.Context, address common.Address, acct *accountNonces) error {
+	pending, err := nm.backend.PendingNonceAt(ctx, address)
+	if err != nil {
+		return fmt.Errorf("failed to get pending nonce for %s: %w", address.Hex(), err)
+	}
+
+	if acct.synced && acct.next != pending {
+		log.Printf("[nonce] %s 本地nonce %d 与链上待处理nonce %d 不一致，已同步", address.Hex(), acct.next, pending)
+	}
+
+	acct.next = pending
+	acct.released = acct.released[:0]
+	acct.synced = true
+	return nil
+}
+
+// IsNonceError reports whether err is the node rejecting a transaction because
+// of its nonce, in which case the nonce manager should be re-synced.
+func IsNonceError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") ||
+		strings.Contains(msg, "nonce too high") ||
+		strings.Contains(msg, "invalid nonce") ||
+		strings.Contains(msg, "invalid transaction nonce") ||
+		strings.Contains(msg, "replacement transaction underpriced")
+}
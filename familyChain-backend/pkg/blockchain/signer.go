@@ -0,0 +1,239 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signer signs transactions on behalf of a single account. The backend never
+// needs the private key itself, so implementations may keep it in memory,
+// decrypt it from a keystore file, or delegate signing to an external service.
+type Signer interface {
+	// Address returns the account the signer signs for
+	Address() common.Address
+	// SignTx returns a copy of tx signed for the given chain
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// NewTransactor returns transact options that sign with the given signer
+func NewTransactor(ctx context.Context, signer Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From:    signer.Address(),
+		Context: ctx,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, chainID)
+		},
+	}
+}
+
+// MemorySigner signs with a private key held in memory. It is meant for tests
+// and local development; production deployments should use a keystore file or
+// a remote signer.
+type MemorySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewMemorySigner creates a signer from a private key
+func NewMemorySigner(key *ecdsa.PrivateKey) *MemorySigner {
+	return &MemorySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// NewMemorySignerFromHex creates a signer from a hex encoded private key, with or without 0x prefix
+func NewMemorySignerFromHex(privateKeyHex string) (*MemorySigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	return NewMemorySigner(key), nil
+}
+
+// GenerateMemorySigner creates a signer for a freshly generated key
+func GenerateMemorySigner() (*MemorySigner, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewMemorySigner(key), nil
+}
+
+// Address returns the signer's account
+func (s *MemorySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx with the in-memory key
+func (s *MemorySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// KeystoreSigner signs with a key loaded from an encrypted go-ethereum keystore
+// (V3 JSON) file. The key is decrypted once when the signer is created and is
+// never handed out.
+type KeystoreSigner struct {
+	signer *MemorySigner
+}
+
+// NewKeystoreSigner decrypts the keystore file at path with the passphrase
+func NewKeystoreSigner(path, passphrase string) (*KeystoreSigner, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore file: %v", err)
+	}
+	return &KeystoreSigner{signer: NewMemorySigner(key.PrivateKey)}, nil
+}
+
+// Address returns the keystore account
+func (s *KeystoreSigner) Address() common.Address {
+	return s.signer.Address()
+}
+
+// SignTx signs tx with the decrypted keystore key
+func (s *KeystoreSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.signer.SignTx(ctx, tx, chainID)
+}
+
+// RemoteSigner delegates signing to an external signer speaking Clef's
+// external API (account_signTransaction) over HTTP JSON-RPC, so the backend
+// never holds the key.
+type RemoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+	timeout time.Duration
+}
+
+// remoteSignTxArgs is the transaction argument of account_signTransaction
+type remoteSignTxArgs struct {
+	From                 common.MixedcaseAddress  `json:"from"`
+	To                   *common.MixedcaseAddress `json:"to"`
+	Gas                  hexutil.Uint64           `json:"gas"`
+	GasPrice             *hexutil.Big             `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big             `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big             `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big              `json:"value"`
+	Nonce                hexutil.Uint64           `json:"nonce"`
+	Data                 *hexutil.Bytes           `json:"data,omitempty"`
+	ChainID              *hexutil.Big             `json:"chainId,omitempty"`
+}
+
+// remoteSignTxResult is the result of account_signTransaction
+type remoteSignTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// NewRemoteSigner connects to a Clef-compatible signer at url that signs for address
+func NewRemoteSigner(url string, address common.Address) (*RemoteSigner, error) {
+	client, err := rpc.DialHTTP(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote signer: %v", err)
+	}
+	return &RemoteSigner{
+		client:  client,
+		address: address,
+		// Clef may wait for a human to approve the request
+		timeout: 2 * time.Minute,
+	}, nil
+}
+
+// Address returns the account the remote signer signs for
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx asks the remote signer to sign tx and verifies the result matches the request
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := remoteSignTxArgs{
+		From:    common.NewMixedcaseAddress(s.address),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if to := tx.To(); to != nil {
+		mixed := common.NewMixedcaseAddress(*to)
+		args.To = &mixed
+	}
+	if data := tx.Data(); len(data) > 0 {
+		input := hexutil.Bytes(data)
+		args.Data = &input
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var result remoteSignTxResult
+	if err := s.client.CallContext(ctx, &result, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signer: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid transaction: %v", err)
+	}
+
+	// 不信任远程签名器返回的内容，确认签名者和交易内容与请求一致
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an unsigned transaction: %v", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("remote signer signed as %s, expected %s", sender.Hex(), s.address.Hex())
+	}
+	if !sameTransaction(tx, signed) {
+		return nil, errors.New("remote signer modified the transaction")
+	}
+	return signed, nil
+}
+
+// Close closes the connection to the remote signer
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+// sameTransaction reports whether two transactions carry the same payload, ignoring signatures
+func sameTransaction(a, b *types.Transaction) bool {
+	if a.Nonce() != b.Nonce() || a.Gas() != b.Gas() ||
+		a.Value().Cmp(b.Value()) != 0 ||
+		a.GasPrice().Cmp(b.GasPrice()) != 0 ||
+		a.GasTipCap().Cmp(b.GasTipCap()) != 0 ||
+		string(a.Data()) != string(b.Data()) {
+		return false
+	}
+	if (a.To() == nil) != (b.To() == nil) {
+		return false
+	}
+	return a.To() == nil || *a.To() == *b.To()
+}
@@ -0,0 +1,1067 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
+	"eth-for-babies-backend/internal/utils"
+)
+
+// TestTaskRewardFlow drives a task from creation to a redeemed reward: the
+// wallets send their transactions straight to the contracts, as the frontend
+// does, and report them to the API; the backend mints the reward through the
+// outbox, escrowed exchanges are burned on approval and refunded on decline,
+// and the indexer agrees with what the API recorded.
+func TestTaskRewardFlow(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	parentToken := env.login(env.parent, "parent")
+	childToken := env.login(env.child, "child")
+
+	// 家庭和孩子
+	var family models.Family
+	env.do(http.MethodPost, "/api/v1/families", parentToken, map[string]interface{}{
+		"name": "Test Family",
+	}, http.StatusCreated, &family)
+
+	var child models.Child
+	env.do(http.MethodPost, "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"wallet_address": env.child.address.Hex(),
+		"age":            10,
+	}, http.StatusCreated, &child)
+	require.Equal(t, env.child.wallet(), child.WalletAddress)
+
+	// 创建任务：家长在链上锁定奖励，再把链上任务ID交给后端
+	reward := "0.01"
+	rewardWei, ok := new(big.Int).SetString("10000000000000000", 10)
+	require.True(t, ok)
+
+	parentAuth := env.transactor(env.parent)
+	parentAuth.Value = rewardWei
+	receipt := env.mined(env.taskRegistry.CreateTask(parentAuth, "Clean room", "Tidy up the toys", rewardWei))
+	created, err := env.taskRegistry.ParseTaskCreated(*receipt.Logs[0])
+	require.NoError(t, err)
+	contractTaskID := created.TaskId.Uint64()
+
+	var task models.Task
+	env.do(http.MethodPost, "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":            "Clean room",
+		"description":      "Tidy up the toys",
+		"reward_amount":    reward,
+		"difficulty":       "easy",
+		"contract_task_id": contractTaskID,
+	}, http.StatusCreated, &task)
+	require.Equal(t, "pending", task.Status)
+
+	// 分配任务
+	env.mined(env.taskRegistry.AssignTask(env.transactor(env.parent), created.TaskId, env.child.address))
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/direct-assign", task.ID), parentToken, map[string]interface{}{
+		"assigned_child_id": fmt.Sprint(child.ID),
+	}, http.StatusOK, &task)
+	require.Equal(t, "in_progress", task.Status)
+
+	// 孩子完成任务
+	env.mined(env.taskRegistry.CompleteTask(env.transactor(env.child), created.TaskId))
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/complete", task.ID), childToken, map[string]interface{}{
+		"completion_proof": "photo of a tidy room",
+	}, http.StatusOK, &task)
+	require.Equal(t, "completed", task.Status)
+
+	// 家长批准，铸币请求写入outbox
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/approve", task.ID), parentToken, nil, http.StatusOK, &task)
+	require.Equal(t, "approved", task.Status)
+	require.NotNil(t, task.Mint)
+	require.Equal(t, models.OutboxStatusPending, task.Mint.Status)
+
+	// 分发器发送铸币交易并等到确认，确认后推送给孩子
+	events, unsubscribe := env.broker.Subscribe(realtime.Subscription{FamilyIDs: []uint{family.ID}, ChildID: &child.ID})
+	defer unsubscribe()
+	env.outbox.DispatchDue(ctx)
+
+	var mint models.OutboxMessage
+	require.NoError(t, env.db.First(&mint, task.Mint.ID).Error)
+	require.Equal(t, models.OutboxStatusConfirmed, mint.Status, "last error: %v", mint.LastError)
+	require.NotNil(t, mint.TxHash)
+
+	select {
+	case event := <-events:
+		require.Equal(t, realtime.EventTokensMinted, event.Type)
+		payload, ok := event.Data.(realtime.MintPayload)
+		require.True(t, ok)
+		require.Equal(t, *mint.TxHash, payload.TxHash)
+	default:
+		t.Fatal("tokens.minted was not published")
+	}
+
+	minted, err := utils.RewardToTokenAmount(reward)
+	require.NoError(t, err)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(minted), "child balance %s, want %s", env.tokenBalance(env.child), minted)
+
+	// 家长在链上创建奖品并登记到后端
+	price := 30
+	priceWei := new(big.Int).Mul(big.NewInt(int64(price)), big.NewInt(1e18))
+	receipt = env.mined(env.rewardRegistry.CreateReward(env.transactor(env.parent),
+		new(big.Int).SetUint64(uint64(family.ID)), "Ice cream", "One scoop", "ipfs://ice-cream", priceWei, big.NewInt(5)))
+	rewardCreated, err := env.rewardRegistry.ParseRewardCreated(*receipt.Logs[0])
+	require.NoError(t, err)
+	contractRewardID := uint(rewardCreated.RewardId.Uint64())
+
+	var prize models.Reward
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/rewards/family/%d", family.ID), parentToken, map[string]interface{}{
+		"name":               "Ice cream",
+		"description":        "One scoop",
+		"image_url":          "ipfs://ice-cream",
+		"token_price":        price,
+		"stock":              5,
+		"contract_reward_id": contractRewardID,
+	}, http.StatusCreated, &prize)
+
+	// 孩子在链上兑换（代币托管到奖品合约），再把链上兑换ID交给后端核对
+	registryAddress := common.HexToAddress(env.cm.GetContractAddresses()["reward"])
+	receipt = env.mined(env.rewardRegistry.ExchangeReward(env.transactor(env.child), rewardCreated.RewardId))
+	exchanged, err := env.rewardRegistry.ParseRewardExchanged(*receipt.Logs[len(receipt.Logs)-1])
+	require.NoError(t, err)
+
+	// 链上兑换ID属于别人或不存在时拒绝
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id":            prize.ID,
+		"contract_exchange_id": exchanged.ExchangeId.Uint64() + 1,
+	}, http.StatusBadRequest, nil)
+
+	var exchange models.Exchange
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id":            prize.ID,
+		"contract_exchange_id": exchanged.ExchangeId.Uint64(),
+	}, http.StatusCreated, &exchange)
+	require.Equal(t, models.ExchangeStatusPending, exchange.Status)
+	require.NotNil(t, exchange.ContractExchangeID)
+	require.Equal(t, exchanged.ExchangeId.Uint64(), *exchange.ContractExchangeID)
+
+	remaining := new(big.Int).Sub(minted, priceWei)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(remaining))
+	escrowed, err := env.rewardToken.BalanceOf(nil, registryAddress)
+	require.NoError(t, err)
+	require.Equal(t, 0, escrowed.Cmp(priceWei))
+
+	// 家长批准，分发器在链上销毁托管的代币
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/approve", exchange.ID), parentToken, nil, http.StatusOK, &exchange)
+	require.Equal(t, models.ExchangeStatusApproved, exchange.Status)
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/decline", exchange.ID), parentToken, nil, http.StatusConflict, nil)
+	env.outbox.DispatchDue(ctx)
+
+	require.NoError(t, env.db.First(&exchange, exchange.ID).Error)
+	require.NotNil(t, exchange.SettlementTxHash)
+	escrowed, err = env.rewardToken.BalanceOf(nil, registryAddress)
+	require.NoError(t, err)
+	require.Zero(t, escrowed.Sign())
+	_, _, _, _, _, fulfilled, _, err := env.rewardRegistry.GetExchange(nil, exchanged.ExchangeId)
+	require.NoError(t, err)
+	require.True(t, fulfilled)
+
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/fulfill", exchange.ID), parentToken, nil, http.StatusOK, &exchange)
+	require.Equal(t, models.ExchangeStatusFulfilled, exchange.Status)
+
+	// 孩子通过API兑换，后端代孩子在链上兑换并托管代币；余额以账本为准，先让索引器同步铸币和第一次兑换
+	var statement models.LedgerStatement
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/children/%d/ledger", child.ID), childToken, nil, http.StatusOK, &statement)
+	require.Equal(t, "0", statement.Balance)
+	require.NoError(t, env.indexer.Sync(ctx))
+
+	var declined models.Exchange
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id": prize.ID,
+	}, http.StatusCreated, &declined)
+	require.Nil(t, declined.ContractExchangeID)
+
+	// 链上余额够兑换更贵的奖品，但扣除尚未上链的托管后不够，后端拒绝兑换且不扣库存
+	bikePrice := 50
+	bikeReceipt := env.mined(env.rewardRegistry.CreateReward(env.transactor(env.parent),
+		new(big.Int).SetUint64(uint64(family.ID)), "Bike ride", "One hour", "ipfs://bike",
+		new(big.Int).Mul(big.NewInt(int64(bikePrice)), big.NewInt(1e18)), big.NewInt(2)))
+	bikeCreated, err := env.rewardRegistry.ParseRewardCreated(*bikeReceipt.Logs[0])
+	require.NoError(t, err)
+	var bike models.Reward
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/rewards/family/%d", family.ID), parentToken, map[string]interface{}{
+		"name":               "Bike ride",
+		"image_url":          "ipfs://bike",
+		"token_price":        bikePrice,
+		"stock":              2,
+		"contract_reward_id": uint(bikeCreated.RewardId.Uint64()),
+	}, http.StatusCreated, &bike)
+	require.Equal(t, 1, env.tokenBalance(env.child).Cmp(new(big.Int).Mul(big.NewInt(int64(bikePrice)), big.NewInt(1e18))))
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id": bike.ID,
+	}, http.StatusBadRequest, nil)
+	require.NoError(t, env.db.First(&bike, bike.ID).Error)
+	require.Equal(t, 2, bike.Stock)
+
+	env.outbox.DispatchDue(ctx)
+
+	require.NoError(t, env.db.First(&declined, declined.ID).Error)
+	require.NotNil(t, declined.ContractExchangeID)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(new(big.Int).Sub(remaining, priceWei)))
+
+	// 家长拒绝，库存恢复，托管的代币退还给孩子
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/decline", declined.ID), parentToken, map[string]interface{}{
+		"notes": "Not before dinner",
+	}, http.StatusOK, &declined)
+	require.Equal(t, models.ExchangeStatusDeclined, declined.Status)
+	env.outbox.DispatchDue(ctx)
+
+	require.NoError(t, env.db.First(&declined, declined.ID).Error)
+	require.NotNil(t, declined.SettlementTxHash)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(remaining))
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/fulfill", declined.ID), parentToken, nil, http.StatusConflict, nil)
+
+	// 索引器同步链上事件，不重复创建兑换、不重复改库存
+	require.NoError(t, env.indexer.Sync(ctx))
+
+	require.NoError(t, env.db.First(&exchange, exchange.ID).Error)
+	require.Equal(t, models.ExchangeStatusFulfilled, exchange.Status)
+	require.NotNil(t, exchange.TxHash)
+	require.Equal(t, receipt.TxHash.Hex(), *exchange.TxHash)
+	require.NoError(t, env.db.First(&declined, declined.ID).Error)
+	require.Equal(t, models.ExchangeStatusDeclined, declined.Status)
+
+	require.NoError(t, env.db.First(&prize, prize.ID).Error)
+	require.Equal(t, 4, prize.Stock)
+	_, _, _, _, _, _, _, stock, _, err := env.rewardRegistry.GetReward(nil, rewardCreated.RewardId)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, stock.Int64())
+
+	var exchanges int64
+	require.NoError(t, env.db.Model(&models.Exchange{}).Count(&exchanges).Error)
+	require.EqualValues(t, 2, exchanges)
+
+	// 账本记录了铸币、两次托管、销毁和退还，与链上余额一致
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/children/%d/ledger", child.ID), parentToken, nil, http.StatusOK, &statement)
+	require.Equal(t, remaining.String(), statement.Balance)
+	require.Equal(t, minted.String(), statement.Minted)
+	kinds := make([]models.LedgerKind, 0, len(statement.Entries))
+	for _, entry := range statement.Entries {
+		kinds = append(kinds, entry.Kind)
+	}
+	require.Equal(t, []models.LedgerKind{
+		models.LedgerKindRefund, models.LedgerKindEscrow, models.LedgerKindEscrow, models.LedgerKindMint,
+	}, kinds)
+
+	var report models.LedgerReport
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/families/%d/ledger/reconciliation", family.ID), parentToken, nil, http.StatusOK, &report)
+	require.NotNil(t, report.Block)
+	require.Zero(t, report.Mismatches)
+	require.Len(t, report.Children, 1)
+	require.True(t, report.Children[0].Matched)
+	require.Equal(t, remaining.String(), report.Children[0].ChainBalance)
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/families/%d/ledger/reconciliation", family.ID), childToken, nil, http.StatusForbidden, nil)
+
+	var issued *big.Int
+	ledgerRepo := repository.NewLedgerRepository(env.db)
+	escrowed, err = ledgerRepo.Balance(strings.ToLower(registryAddress.Hex()))
+	require.NoError(t, err)
+	require.Zero(t, escrowed.Sign())
+	issued, err = ledgerRepo.Balance(strings.ToLower(common.Address{}.Hex()))
+	require.NoError(t, err)
+	require.Equal(t, new(big.Int).Neg(remaining).String(), issued.String())
+
+	// 模拟节点不能读取历史状态，还没有同步的链上转账显示为差异，索引器同步后一致
+	env.mined(env.rewardToken.Transfer(env.transactor(env.child), env.parent.address, big.NewInt(1)))
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/families/%d/ledger/reconciliation", family.ID), parentToken, nil, http.StatusOK, &report)
+	require.True(t, report.ChainAtLatest)
+	require.Equal(t, 1, report.Mismatches)
+	require.Equal(t, "-1", report.Children[0].Difference)
+
+	require.NoError(t, env.indexer.Sync(ctx))
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/families/%d/ledger/reconciliation", family.ID), parentToken, nil, http.StatusOK, &report)
+	require.Zero(t, report.Mismatches)
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/children/%d/ledger", child.ID), childToken, nil, http.StatusOK, &statement)
+	require.Equal(t, models.LedgerKindTransfer, statement.Entries[0].Kind)
+	require.Equal(t, "-1", statement.Entries[0].Amount)
+
+	// 孩子为自行车存钱，家长的配捐由分发器铸造给孩子，索引器同步后计入账本
+	var goal models.SavingsGoal
+	env.do(http.MethodPost, "/api/v1/savings-goals", childToken, map[string]interface{}{
+		"reward_id": bike.ID,
+	}, http.StatusCreated, &goal)
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/savings-goals/%d/lock", goal.ID), childToken, map[string]interface{}{
+		"amount": 1,
+	}, http.StatusOK, &goal)
+	var contribution models.SavingsContribution
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/savings-goals/%d/contributions", goal.ID), parentToken, map[string]interface{}{
+		"amount": 5,
+	}, http.StatusCreated, &contribution)
+	require.NotNil(t, contribution.OutboxMessageID)
+	balanceBefore := env.tokenBalance(env.child)
+	env.outbox.DispatchDue(ctx)
+	contributed := new(big.Int).Mul(big.NewInt(5), big.NewInt(1e18))
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(new(big.Int).Add(balanceBefore, contributed)))
+
+	require.NoError(t, env.indexer.Sync(ctx))
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/children/%d/ledger", child.ID), childToken, nil, http.StatusOK, &statement)
+	require.Equal(t, models.LedgerKindMint, statement.Entries[0].Kind)
+	require.Equal(t, new(big.Int).Add(minted, contributed).String(), statement.Minted)
+	var progress models.SavingsProgress
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/savings-goals/%d/progress", goal.ID), childToken, nil, http.StatusOK, &progress)
+	require.Equal(t, new(big.Int).Mul(big.NewInt(6), big.NewInt(1e18)).String(), progress.Reserved)
+	require.Equal(t, new(big.Int).Mul(big.NewInt(int64(bikePrice)), big.NewInt(1e18)).String(), progress.Target)
+
+	// 链上事件不会把已批准的任务状态回退
+	require.NoError(t, env.db.First(&task, task.ID).Error)
+	require.Equal(t, "approved", task.Status)
+	require.NotNil(t, task.ContractTaskID)
+	require.Equal(t, contractTaskID, *task.ContractTaskID)
+}
+
+// TestPartialCreditFlow sends a submitted task back for rework and then approves
+// it with half of the reward: the contract pays the child half of the escrow and
+// refunds the rest to the parent, and the backend mints tokens for the half.
+func TestPartialCreditFlow(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	parentToken := env.login(env.parent, "parent")
+	childToken := env.login(env.child, "child")
+
+	env.do(http.MethodPost, "/api/v1/families", parentToken, map[string]interface{}{
+		"name": "Test Family",
+	}, http.StatusCreated, nil)
+	var child models.Child
+	env.do(http.MethodPost, "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"wallet_address": env.child.address.Hex(),
+		"age":            10,
+	}, http.StatusCreated, &child)
+
+	reward := "0.01"
+	rewardWei, ok := new(big.Int).SetString("10000000000000000", 10)
+	require.True(t, ok)
+
+	parentAuth := env.transactor(env.parent)
+	parentAuth.Value = rewardWei
+	receipt := env.mined(env.taskRegistry.CreateTask(parentAuth, "Clean room", "Tidy up the toys", rewardWei))
+	created, err := env.taskRegistry.ParseTaskCreated(*receipt.Logs[0])
+	require.NoError(t, err)
+	env.mined(env.taskRegistry.AssignTask(env.transactor(env.parent), created.TaskId, env.child.address))
+
+	var task models.Task
+	env.do(http.MethodPost, "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Clean room",
+		"description":       "Tidy up the toys",
+		"reward_amount":     reward,
+		"difficulty":        "easy",
+		"assigned_child_id": child.ID,
+		"contract_task_id":  created.TaskId.Uint64(),
+	}, http.StatusCreated, &task)
+	taskPath := fmt.Sprintf("/api/v1/tasks/%d", task.ID)
+
+	// 第一次提交被退回重做，链上奖励继续锁定
+	env.mined(env.taskRegistry.CompleteTask(env.transactor(env.child), created.TaskId))
+	env.do(http.MethodPost, taskPath+"/complete", childToken, map[string]interface{}{
+		"completion_proof": "photo of a tidy room",
+	}, http.StatusOK, &task)
+	env.mined(env.taskRegistry.ReturnForRework(env.transactor(env.parent), created.TaskId))
+	env.do(http.MethodPost, taskPath+"/reject", parentToken, map[string]interface{}{
+		"rework": true,
+		"reason": "The toys are still under the bed",
+	}, http.StatusOK, &task)
+	require.Equal(t, "in_progress", task.Status)
+	require.Equal(t, 1, task.ReworkCount)
+
+	// 再次提交后按一半的奖励批准
+	env.mined(env.taskRegistry.CompleteTask(env.transactor(env.child), created.TaskId))
+	env.do(http.MethodPost, taskPath+"/complete", childToken, map[string]interface{}{
+		"completion_proof": "photo of the room and under the bed",
+	}, http.StatusOK, &task)
+
+	childBefore, err := env.chain.BalanceAt(ctx, env.child.address, nil)
+	require.NoError(t, err)
+	payout := new(big.Int).Div(rewardWei, big.NewInt(2))
+	receipt = env.mined(env.taskRegistry.ApproveTaskWithPayout(env.transactor(env.parent), created.TaskId, payout))
+	childAfter, err := env.chain.BalanceAt(ctx, env.child.address, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, new(big.Int).Sub(childAfter, childBefore).Cmp(payout))
+
+	var refunded *big.Int
+	for _, entry := range receipt.Logs {
+		if transfer, err := env.taskRegistry.ParseRewardTransferred(*entry); err == nil && transfer.Recipient == env.parent.address {
+			refunded = transfer.Amount
+		}
+	}
+	require.NotNil(t, refunded)
+	require.Equal(t, 0, new(big.Int).Sub(rewardWei, payout).Cmp(refunded))
+
+	env.do(http.MethodPost, taskPath+"/approve", parentToken, map[string]interface{}{
+		"percent": 50,
+		"reason":  "Only half of the room was tidied",
+	}, http.StatusOK, &task)
+	require.Equal(t, "approved", task.Status)
+	require.NotNil(t, task.ApprovedRewardAmount)
+	require.Equal(t, "0.005", *task.ApprovedRewardAmount)
+
+	env.outbox.DispatchDue(ctx)
+	minted, err := utils.RewardToTokenAmount("0.005")
+	require.NoError(t, err)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(minted), "child balance %s, want %s", env.tokenBalance(env.child), minted)
+
+	// 确认的铸币交易追加到任务历史
+	var mint models.OutboxMessage
+	require.NoError(t, env.db.First(&mint, task.Mint.ID).Error)
+	var mintEvent models.TaskEvent
+	require.NoError(t, env.db.Where("task_id = ? AND action = ?", task.ID, models.TaskEventRewardMinted).First(&mintEvent).Error)
+	require.NotNil(t, mintEvent.TxHash)
+	require.Equal(t, *mint.TxHash, *mintEvent.TxHash)
+
+	// 索引器重放退回和批准事件，不会重复计数或改变已批准的任务
+	require.NoError(t, env.indexer.Sync(ctx))
+	require.NoError(t, env.db.First(&task, task.ID).Error)
+	require.Equal(t, "approved", task.Status)
+	require.Equal(t, 1, task.ReworkCount)
+	require.Equal(t, "0.005", *task.ApprovedRewardAmount)
+}
+
+// TestLedgerHeldExchangeBurns redeems a reward that is not registered on
+// chain: the client's token_burned flag is ignored, the tokens stay in the
+// child's wallet while the exchange is pending, a decline sends nothing and an
+// approval burns the price from the wallet.
+func TestLedgerHeldExchangeBurns(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	parentToken := env.login(env.parent, "parent")
+	childToken := env.login(env.child, "child")
+
+	var family models.Family
+	env.do(http.MethodPost, "/api/v1/families", parentToken, map[string]interface{}{
+		"name": "Burn Family",
+	}, http.StatusCreated, &family)
+	var child models.Child
+	env.do(http.MethodPost, "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"wallet_address": env.child.address.Hex(),
+		"age":            10,
+	}, http.StatusCreated, &child)
+
+	outboxRepo := repository.NewOutboxRepository(env.db)
+	env.queueMint(outboxRepo)
+	env.outbox.DispatchDue(ctx)
+	require.NoError(t, env.indexer.Sync(ctx))
+
+	price := 2
+	priceWei := new(big.Int).Mul(big.NewInt(int64(price)), big.NewInt(1e18))
+	var prize models.Reward
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/rewards/family/%d", family.ID), parentToken, map[string]interface{}{
+		"name":        "Story time",
+		"image_url":   "ipfs://story",
+		"token_price": price,
+		"stock":       3,
+	}, http.StatusCreated, &prize)
+
+	// 旧客户端声称已经销毁代币，后端不信任这个标记，代币仍在孩子的钱包里
+	var approved, declined models.Exchange
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id":    prize.ID,
+		"token_burned": true,
+	}, http.StatusCreated, &approved)
+	require.Nil(t, approved.ContractExchangeID)
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id": prize.ID,
+	}, http.StatusCreated, &declined)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(outboxMintAmount))
+
+	// 拒绝只释放账本中的扣留，不上链
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/decline", declined.ID), parentToken, nil, http.StatusOK, &declined)
+	var settlements int64
+	require.NoError(t, env.db.Model(&models.OutboxMessage{}).Where("exchange_id = ?", declined.ID).Count(&settlements).Error)
+	require.Zero(t, settlements)
+
+	// 批准后分发器从孩子的钱包销毁代币
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/approve", approved.ID), parentToken, nil, http.StatusOK, &approved)
+	burn, err := outboxRepo.GetByExchange(approved.ID, models.OutboxKindBurnExchange)
+	require.NoError(t, err)
+	require.Equal(t, priceWei.String(), burn.Amount)
+	env.outbox.DispatchDue(ctx)
+
+	burn = env.outboxMessage(outboxRepo, burn.ID)
+	require.Equal(t, models.OutboxStatusConfirmed, burn.Status, "last error: %v", burn.LastError)
+	require.NoError(t, env.db.First(&approved, approved.ID).Error)
+	require.NotNil(t, approved.SettlementTxHash)
+	require.Equal(t, *burn.TxHash, *approved.SettlementTxHash)
+	remaining := new(big.Int).Sub(outboxMintAmount, priceWei)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(remaining))
+
+	// 索引器把销毁记入账本，余额与链上一致
+	require.NoError(t, env.indexer.Sync(ctx))
+	var statement models.LedgerStatement
+	env.do(http.MethodGet, fmt.Sprintf("/api/v1/children/%d/ledger", child.ID), childToken, nil, http.StatusOK, &statement)
+	require.Equal(t, remaining.String(), statement.Balance)
+	require.Equal(t, models.LedgerKindBurn, statement.Entries[0].Kind)
+}
+
+// TestChainExchangeLinkedOnce syncs the child's on-chain exchange while the
+// API is verifying the same exchange: the unique contract_exchange_id makes
+// the API return the indexer's record, and the stock is decremented once.
+func TestChainExchangeLinkedOnce(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	parentToken := env.login(env.parent, "parent")
+	childToken := env.login(env.child, "child")
+
+	var family models.Family
+	env.do(http.MethodPost, "/api/v1/families", parentToken, map[string]interface{}{
+		"name": "Race Family",
+	}, http.StatusCreated, &family)
+	env.do(http.MethodPost, "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"wallet_address": env.child.address.Hex(),
+		"age":            10,
+	}, http.StatusCreated, nil)
+	env.queueMint(repository.NewOutboxRepository(env.db))
+	env.outbox.DispatchDue(ctx)
+
+	priceWei := new(big.Int).Mul(big.NewInt(2), big.NewInt(1e18))
+	receipt := env.mined(env.rewardRegistry.CreateReward(env.transactor(env.parent),
+		new(big.Int).SetUint64(uint64(family.ID)), "Story time", "One story", "ipfs://story", priceWei, big.NewInt(5)))
+	rewardCreated, err := env.rewardRegistry.ParseRewardCreated(*receipt.Logs[0])
+	require.NoError(t, err)
+	var prize models.Reward
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/rewards/family/%d", family.ID), parentToken, map[string]interface{}{
+		"name":               "Story time",
+		"image_url":          "ipfs://story",
+		"token_price":        2,
+		"stock":              5,
+		"contract_reward_id": uint(rewardCreated.RewardId.Uint64()),
+	}, http.StatusCreated, &prize)
+
+	receipt = env.mined(env.rewardRegistry.ExchangeReward(env.transactor(env.child), rewardCreated.RewardId))
+	exchanged, err := env.rewardRegistry.ParseRewardExchanged(*receipt.Logs[len(receipt.Logs)-1])
+	require.NoError(t, err)
+
+	// API查不到这次链上兑换之后、写入之前，索引器同步了兑换事件
+	synced := false
+	require.NoError(t, env.db.Callback().Query().After("gorm:query").Register("test:sync_exchange", func(tx *gorm.DB) {
+		if synced || !strings.Contains(tx.Statement.SQL.String(), "contract_exchange_id =") {
+			return
+		}
+		synced = true
+		require.NoError(t, env.indexer.Sync(ctx))
+	}))
+	t.Cleanup(func() { env.db.Callback().Query().Remove("test:sync_exchange") })
+
+	var exchange models.Exchange
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id":            prize.ID,
+		"contract_exchange_id": exchanged.ExchangeId.Uint64(),
+	}, http.StatusCreated, &exchange)
+	require.True(t, synced)
+	require.Equal(t, "从链上同步的兑换", exchange.Notes, "the API must return the indexer's record")
+
+	var linked int64
+	require.NoError(t, env.db.Model(&models.Exchange{}).Where("contract_exchange_id = ?", exchanged.ExchangeId.Uint64()).Count(&linked).Error)
+	require.EqualValues(t, 1, linked)
+	require.NoError(t, env.db.First(&prize, prize.ID).Error)
+	require.Equal(t, 4, prize.Stock)
+}
+
+// TestFailedEscrowSettlesFromWallet approves and declines exchanges whose
+// escrow is still queued, then lets the escrows revert until they fail: the
+// approval burns the price from the child's wallet instead of fulfilling an
+// exchange that never reached the chain, and the refund is cancelled.
+func TestFailedEscrowSettlesFromWallet(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	parentToken := env.login(env.parent, "parent")
+	childToken := env.login(env.child, "child")
+
+	var family models.Family
+	env.do(http.MethodPost, "/api/v1/families", parentToken, map[string]interface{}{
+		"name": "Escrow Family",
+	}, http.StatusCreated, &family)
+	env.do(http.MethodPost, "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"wallet_address": env.child.address.Hex(),
+		"age":            10,
+	}, http.StatusCreated, nil)
+	outboxRepo := repository.NewOutboxRepository(env.db)
+	env.queueMint(outboxRepo)
+	env.outbox.DispatchDue(ctx)
+	require.NoError(t, env.indexer.Sync(ctx))
+
+	price := big.NewInt(2)
+	priceWei := new(big.Int).Mul(price, big.NewInt(1e18))
+	receipt := env.mined(env.rewardRegistry.CreateReward(env.transactor(env.parent),
+		new(big.Int).SetUint64(uint64(family.ID)), "Story time", "One story", "ipfs://story", priceWei, big.NewInt(5)))
+	rewardCreated, err := env.rewardRegistry.ParseRewardCreated(*receipt.Logs[0])
+	require.NoError(t, err)
+	var prize models.Reward
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/rewards/family/%d", family.ID), parentToken, map[string]interface{}{
+		"name":               "Story time",
+		"image_url":          "ipfs://story",
+		"token_price":        price.Int64(),
+		"stock":              5,
+		"contract_reward_id": uint(rewardCreated.RewardId.Uint64()),
+	}, http.StatusCreated, &prize)
+
+	// 奖品在链上下架，代孩子兑换的托管交易都会回滚
+	env.mined(env.rewardRegistry.UpdateReward(env.transactor(env.parent), rewardCreated.RewardId,
+		"Story time", "One story", "ipfs://story", priceWei, big.NewInt(5), false))
+
+	var approved, declined models.Exchange
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{"reward_id": prize.ID}, http.StatusCreated, &approved)
+	env.do(http.MethodPost, "/api/v1/exchanges", childToken, map[string]interface{}{"reward_id": prize.ID}, http.StatusCreated, &declined)
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/approve", approved.ID), parentToken, nil, http.StatusOK, nil)
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/exchanges/%d/decline", declined.ID), parentToken, nil, http.StatusOK, nil)
+
+	settlement := func(exchangeID uint) *models.OutboxMessage {
+		var msg models.OutboxMessage
+		require.NoError(t, env.db.Where("exchange_id = ? AND kind <> ?", exchangeID, models.OutboxKindEscrowExchange).First(&msg).Error)
+		return &msg
+	}
+	for i := 0; i < 10 && settlement(approved.ID).Status != models.OutboxStatusConfirmed; i++ {
+		env.outbox.DispatchDue(ctx)
+		time.Sleep(env.cfg.Outbox.MaxBackoff)
+	}
+
+	escrow, err := outboxRepo.GetByExchange(approved.ID, models.OutboxKindEscrowExchange)
+	require.NoError(t, err)
+	require.Equal(t, models.OutboxStatusFailed, escrow.Status)
+	burn := settlement(approved.ID)
+	require.Equal(t, models.OutboxKindBurnExchange, burn.Kind)
+	require.Equal(t, models.OutboxStatusConfirmed, burn.Status, "last error: %v", burn.LastError)
+	require.NoError(t, env.db.First(&approved, approved.ID).Error)
+	require.NotNil(t, approved.SettlementTxHash)
+	require.Equal(t, *burn.TxHash, *approved.SettlementTxHash)
+
+	refund := settlement(declined.ID)
+	require.Equal(t, models.OutboxKindRefundExchange, refund.Kind)
+	require.Equal(t, models.OutboxStatusCancelled, refund.Status)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(new(big.Int).Sub(outboxMintAmount, priceWei)))
+}
+
+// TestIndexerKeepsExpiredTask expires a task in a family that does not accept
+// late submissions and then syncs its on-chain assignment and completion: the
+// indexer neither reopens nor completes the expired task.
+func TestIndexerKeepsExpiredTask(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	parentToken := env.login(env.parent, "parent")
+
+	env.do(http.MethodPost, "/api/v1/families", parentToken, map[string]interface{}{
+		"name": "Strict Family",
+	}, http.StatusCreated, nil)
+	var child models.Child
+	env.do(http.MethodPost, "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"wallet_address": env.child.address.Hex(),
+		"age":            10,
+	}, http.StatusCreated, &child)
+
+	rewardWei := big.NewInt(1e16)
+	parentAuth := env.transactor(env.parent)
+	parentAuth.Value = rewardWei
+	receipt := env.mined(env.taskRegistry.CreateTask(parentAuth, "Clean room", "Tidy up the toys", rewardWei))
+	created, err := env.taskRegistry.ParseTaskCreated(*receipt.Logs[0])
+	require.NoError(t, err)
+
+	var task models.Task
+	env.do(http.MethodPost, "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Clean room",
+		"description":       "Tidy up the toys",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": child.ID,
+		"contract_task_id":  created.TaskId.Uint64(),
+	}, http.StatusCreated, &task)
+
+	// 任务过期之后，孩子才在链上接受并完成任务
+	expired, err := repository.NewTaskRepository(env.db).Expire(&task, map[string]interface{}{"expired_at": time.Now()},
+		&models.TaskEvent{Action: models.TaskEventExpired, Source: models.TaskEventSourceScheduler})
+	require.NoError(t, err)
+	require.True(t, expired)
+	env.mined(env.taskRegistry.AssignTask(env.transactor(env.parent), created.TaskId, env.child.address))
+	env.mined(env.taskRegistry.CompleteTask(env.transactor(env.child), created.TaskId))
+
+	require.NoError(t, env.indexer.Sync(ctx))
+	require.NoError(t, env.db.First(&task, task.ID).Error)
+	require.Equal(t, models.TaskStatusExpired, task.Status)
+	require.Nil(t, task.SubmittedAt)
+}
+
+// TestOutboxLostBroadcastReply loses the node's reply to a mint: the hash and
+// nonce were recorded before the broadcast, so the next pass finds the receipt
+// instead of minting again, and two overlapping passes never claim the same message.
+func TestOutboxLostBroadcastReply(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	outboxRepo := repository.NewOutboxRepository(env.db)
+	reload := func(id uint) *models.OutboxMessage {
+		msg, err := outboxRepo.GetByID(id)
+		require.NoError(t, err)
+		return msg
+	}
+
+	amount := new(big.Int).Mul(big.NewInt(5), big.NewInt(1e18))
+	msg := &models.OutboxMessage{
+		Kind:      models.OutboxKindMintReward,
+		Recipient: env.child.wallet(),
+		Amount:    amount.String(),
+	}
+	require.NoError(t, outboxRepo.Create(msg))
+
+	// 领取后租约到期前，其他分发器领取不到同一条消息
+	claimed, err := outboxRepo.ClaimDue(time.Now(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	again, err := outboxRepo.ClaimDue(time.Now(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, again)
+	env.outbox.DispatchDue(ctx)
+	msg = reload(msg.ID)
+	require.Nil(t, msg.TxHash, "a claimed message must not be sent by another dispatcher")
+	require.NoError(t, outboxRepo.Release(msg.ID))
+
+	// 交易已上链，但分发器收到网络错误
+	env.chain.loseReplies(1)
+	env.outbox.DispatchDue(ctx)
+	msg = reload(msg.ID)
+	require.Equal(t, models.OutboxStatusSent, msg.Status)
+	require.NotNil(t, msg.TxHash)
+	require.NotNil(t, msg.Nonce)
+	require.Nil(t, msg.LockedUntil)
+	sentHash := *msg.TxHash
+
+	// 退避结束后只检查收据，不会重新签名发送
+	time.Sleep(2 * env.cfg.Outbox.BaseBackoff)
+	env.outbox.DispatchDue(ctx)
+	msg = reload(msg.ID)
+	require.Equal(t, models.OutboxStatusConfirmed, msg.Status, "last error: %v", msg.LastError)
+	require.Equal(t, sentHash, *msg.TxHash)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(amount), "child balance %s, want %s", env.tokenBalance(env.child), amount)
+}
+
+// TestOutboxKeepsPollingThroughNodeErrors fails the receipt lookups of a sent
+// mint more often than MaxAttempts: the errors are not counted as attempts and
+// the message stays sent until the node answers and the mint confirms.
+func TestOutboxKeepsPollingThroughNodeErrors(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	outboxRepo := repository.NewOutboxRepository(env.db)
+
+	msg := env.queueMint(outboxRepo)
+	env.chain.failReceipts(env.cfg.Outbox.MaxAttempts + 1)
+	for i := 0; i <= env.cfg.Outbox.MaxAttempts; i++ {
+		env.outbox.DispatchDue(ctx)
+		msg = env.outboxMessage(outboxRepo, msg.ID)
+		require.Equal(t, models.OutboxStatusSent, msg.Status)
+		require.Zero(t, msg.Attempts)
+		require.NotNil(t, msg.LastError)
+		time.Sleep(2 * env.cfg.Outbox.PollInterval)
+	}
+
+	// 节点恢复后确认同一笔交易，只铸造一次
+	sentHash := *msg.TxHash
+	env.outbox.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	require.Equal(t, models.OutboxStatusConfirmed, msg.Status, "last error: %v", msg.LastError)
+	require.Equal(t, sentHash, *msg.TxHash)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(outboxMintAmount))
+}
+
+// TestTransactResyncsNonce sends a transaction from the operator key outside
+// the backend: the node rejects the stale nonce once, the nonce manager is
+// re-synced and the retry mints exactly once.
+func TestTransactResyncsNonce(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	amount := big.NewInt(1e18)
+	first, err := env.cm.MintReward(ctx, env.child.address, amount)
+	env.mined(first, err)
+
+	// 另一个进程用同一个密钥发送交易，本地nonce落后于链上
+	other := env.operatorTransfer(ctx)
+	env.mined(other, env.chain.SendTransaction(ctx, other))
+	require.Equal(t, first.Nonce()+1, other.Nonce())
+
+	tx, err := env.cm.MintReward(ctx, env.child.address, amount)
+	env.mined(tx, err)
+	require.Equal(t, first.Nonce()+2, tx.Nonce(), "retry must use the resynced nonce")
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(new(big.Int).Mul(amount, big.NewInt(2))))
+}
+
+// TestOutboxReplacesStuckMint holds a mint in the mempool past StuckAfter: the
+// replacement pays enough to be accepted by the pool, both hashes are kept for
+// the nonce and the mined replacement confirms the message.
+func TestOutboxReplacesStuckMint(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	dispatcher, outboxRepo := env.stuckDispatcher()
+
+	env.chain.holdBlocks(t, true)
+	msg := env.queueMint(outboxRepo)
+	dispatcher.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	require.Equal(t, models.OutboxStatusSent, msg.Status)
+	original := env.chain.pooled(env.operator.address, *msg.Nonce)
+	require.NotNil(t, original)
+	require.Equal(t, original.Hash().Hex(), *msg.TxHash)
+
+	time.Sleep(2 * env.cfg.Outbox.PollInterval)
+	dispatcher.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	replacement := env.chain.pooled(env.operator.address, *msg.Nonce)
+	require.NotEqual(t, original.Hash(), replacement.Hash(), "the mempool must accept the bumped replacement")
+	require.Equal(t, replacement.Hash().Hex(), *msg.TxHash)
+	require.Equal(t, 1, replacement.GasTipCap().Cmp(original.GasTipCap()))
+
+	txs, err := outboxRepo.Transactions(msg.ID)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Equal(t, original.Hash().Hex(), txs[0].TxHash)
+	require.Equal(t, replacement.Hash().Hex(), txs[1].TxHash)
+	require.Equal(t, txs[0].Nonce, txs[1].Nonce)
+
+	env.chain.holdBlocks(t, false)
+	time.Sleep(2 * env.cfg.Outbox.PollInterval)
+	dispatcher.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	require.Equal(t, models.OutboxStatusConfirmed, msg.Status, "last error: %v", msg.LastError)
+	require.Equal(t, replacement.Hash().Hex(), *msg.TxHash)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(outboxMintAmount))
+}
+
+// TestOutboxOriginalMinesAfterReplacement mines the original mint after it was
+// replaced: the dispatcher finds the original's receipt among the hashes sent
+// for the nonce and confirms it instead of minting again.
+func TestOutboxOriginalMinesAfterReplacement(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	dispatcher, outboxRepo := env.stuckDispatcher()
+
+	env.chain.holdBlocks(t, true)
+	msg := env.queueMint(outboxRepo)
+	dispatcher.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	original := env.chain.pooled(env.operator.address, *msg.Nonce)
+	require.NotNil(t, original)
+
+	time.Sleep(2 * env.cfg.Outbox.PollInterval)
+	dispatcher.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	require.NotEqual(t, original.Hash().Hex(), *msg.TxHash)
+
+	// 矿工打包了原交易，替换交易被丢弃
+	env.chain.mine(t, original)
+	env.chain.holdBlocks(t, false)
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * env.cfg.Outbox.PollInterval)
+		dispatcher.DispatchDue(ctx)
+	}
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	require.Equal(t, models.OutboxStatusConfirmed, msg.Status, "last error: %v", msg.LastError)
+	require.Equal(t, original.Hash().Hex(), *msg.TxHash)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(outboxMintAmount), "child balance %s, want %s", env.tokenBalance(env.child), outboxMintAmount)
+}
+
+// TestOutboxResendsWhenNonceTaken mines another operator transaction with the
+// mint's nonce: none of the mint's hashes can be mined any more, so the
+// message is sent again with a new nonce and mints once.
+func TestOutboxResendsWhenNonceTaken(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	dispatcher, outboxRepo := env.stuckDispatcher()
+
+	env.chain.holdBlocks(t, true)
+	msg := env.queueMint(outboxRepo)
+	dispatcher.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	require.NotNil(t, msg.Nonce)
+	takenNonce := *msg.Nonce
+
+	other := env.operatorTransfer(ctx)
+	require.Equal(t, takenNonce, other.Nonce())
+	env.chain.mine(t, other)
+	env.chain.holdBlocks(t, false)
+
+	time.Sleep(2 * env.cfg.Outbox.PollInterval)
+	dispatcher.DispatchDue(ctx)
+	msg = env.outboxMessage(outboxRepo, msg.ID)
+	require.Equal(t, models.OutboxStatusPending, msg.Status)
+	require.Nil(t, msg.TxHash)
+	require.Nil(t, msg.Nonce)
+
+	for i := 0; i < 3 && msg.Status != models.OutboxStatusConfirmed; i++ {
+		time.Sleep(2 * env.cfg.Outbox.BaseBackoff)
+		dispatcher.DispatchDue(ctx)
+		msg = env.outboxMessage(outboxRepo, msg.ID)
+	}
+	require.Equal(t, models.OutboxStatusConfirmed, msg.Status, "last error: %v", msg.LastError)
+	require.Equal(t, takenNonce+1, *msg.Nonce)
+	require.Equal(t, 0, env.tokenBalance(env.child).Cmp(outboxMintAmount))
+}
+
+// outboxMintAmount is the amount queued by queueMint
+var outboxMintAmount = new(big.Int).Mul(big.NewInt(5), big.NewInt(1e18))
+
+// stuckDispatcher returns a dispatcher that replaces any transaction that is
+// not mined by its next pass
+func (env *testEnv) stuckDispatcher() (*services.OutboxDispatcher, *repository.OutboxRepository) {
+	cfg := env.cfg.Outbox
+	cfg.StuckAfter = time.Millisecond
+	outboxRepo := repository.NewOutboxRepository(env.db)
+	return services.NewOutboxDispatcher(outboxRepo, env.cm, cfg, nil), outboxRepo
+}
+
+// queueMint queues a reward mint of outboxMintAmount to the child
+func (env *testEnv) queueMint(outboxRepo *repository.OutboxRepository) *models.OutboxMessage {
+	msg := &models.OutboxMessage{
+		Kind:      models.OutboxKindMintReward,
+		Recipient: env.child.wallet(),
+		Amount:    outboxMintAmount.String(),
+	}
+	require.NoError(env.t, outboxRepo.Create(msg))
+	return msg
+}
+
+// outboxMessage reloads an outbox message from the database
+func (env *testEnv) outboxMessage(outboxRepo *repository.OutboxRepository, id uint) *models.OutboxMessage {
+	msg, err := outboxRepo.GetByID(id)
+	require.NoError(env.t, err)
+	return msg
+}
+
+// operatorTransfer signs a transfer from the operator key with the first nonce
+// not yet mined, as another process sharing the key would; it competes with
+// any operator transaction held in the mempool for that nonce
+func (env *testEnv) operatorTransfer(ctx context.Context) *types.Transaction {
+	nonce, err := env.chain.NonceAt(ctx, env.operator.address, nil)
+	require.NoError(env.t, err)
+	gasPrice, err := env.chain.SuggestGasPrice(ctx)
+	require.NoError(env.t, err)
+	tx, err := types.SignNewTx(env.operator.key, types.LatestSignerForChainID(env.chainID), &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &env.parent.address,
+		Value:    big.NewInt(1),
+		Gas:      21000,
+		GasPrice: new(big.Int).Mul(gasPrice, big.NewInt(2)),
+	})
+	require.NoError(env.t, err)
+	return tx
+}
+
+// TestIndexerRollsBackReorg indexes a task submission and a mint, forks the
+// chain below them and checks that the indexer undoes both, keeps the parent's
+// later rejection of the task and rewinds every cursor to the common ancestor.
+func TestIndexerRollsBackReorg(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	parentToken := env.login(env.parent, "parent")
+	env.do(http.MethodPost, "/api/v1/families", parentToken, map[string]interface{}{
+		"name": "Test Family",
+	}, http.StatusCreated, nil)
+	var child models.Child
+	env.do(http.MethodPost, "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"wallet_address": env.child.address.Hex(),
+		"age":            10,
+	}, http.StatusCreated, &child)
+
+	rewardWei := big.NewInt(1e16)
+	parentAuth := env.transactor(env.parent)
+	parentAuth.Value = rewardWei
+	receipt := env.mined(env.taskRegistry.CreateTask(parentAuth, "Clean room", "Tidy up the toys", rewardWei))
+	created, err := env.taskRegistry.ParseTaskCreated(*receipt.Logs[0])
+	require.NoError(t, err)
+	env.mined(env.taskRegistry.AssignTask(env.transactor(env.parent), created.TaskId, env.child.address))
+
+	var task models.Task
+	env.do(http.MethodPost, "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Clean room",
+		"description":       "Tidy up the toys",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": child.ID,
+		"contract_task_id":  created.TaskId.Uint64(),
+	}, http.StatusCreated, &task)
+	require.NoError(t, env.indexer.Sync(ctx))
+	ancestor := env.chain.Blockchain().CurrentBlock()
+
+	countLedgerEntries := func() int64 {
+		var count int64
+		require.NoError(t, env.db.Model(&models.LedgerEntry{}).Count(&count).Error)
+		return count
+	}
+	countChainSubmissions := func() int64 {
+		var count int64
+		require.NoError(t, env.db.Model(&models.TaskEvent{}).
+			Where("task_id = ? AND action = ? AND source = ?", task.ID, models.TaskEventSubmitted, models.TaskEventSourceChain).
+			Count(&count).Error)
+		return count
+	}
+	ledgerBefore := countLedgerEntries()
+
+	// 孩子在链上提交任务，后端铸造代币，索引器把两个区块写入数据库
+	env.mined(env.taskRegistry.CompleteTask(env.transactor(env.child), created.TaskId))
+	env.mined(env.cm.MintReward(ctx, env.child.address, big.NewInt(1e18)))
+	require.NoError(t, env.indexer.Sync(ctx))
+	require.NoError(t, env.db.First(&task, task.ID).Error)
+	require.Equal(t, "completed", task.Status)
+	require.NotNil(t, task.SubmittedAt)
+	require.Equal(t, int64(1), countChainSubmissions())
+	require.Greater(t, countLedgerEntries(), ledgerBefore)
+
+	// 家长在索引之后通过API拒绝了任务
+	env.do(http.MethodPost, fmt.Sprintf("/api/v1/tasks/%d/reject", task.ID), parentToken, map[string]interface{}{
+		"reason": "The toys are still on the floor",
+	}, http.StatusOK, &task)
+	require.Equal(t, "rejected", task.Status)
+
+	// 从共同祖先分叉出一条更长的链，提交和铸币都不在新链上
+	require.NoError(t, env.chain.Fork(ctx, ancestor.Hash()))
+	for i := 0; i < 3; i++ {
+		env.chain.Commit()
+	}
+	require.Equal(t, ancestor.Number.Uint64()+3, env.chain.Blockchain().CurrentBlock().Number.Uint64())
+	require.NoError(t, env.indexer.Sync(ctx))
+
+	taskID := task.ID
+	task = models.Task{}
+	require.NoError(t, env.db.First(&task, taskID).Error)
+	require.Equal(t, "rejected", task.Status, "the rejection written after the event must survive the rollback")
+	require.NotNil(t, task.RejectedAt)
+	require.Nil(t, task.SubmittedAt, "columns only the event wrote are restored")
+	require.Equal(t, int64(0), countChainSubmissions())
+	require.Equal(t, ledgerBefore, countLedgerEntries())
+
+	var cursors []models.ChainCursor
+	require.NoError(t, env.db.Find(&cursors).Error)
+	require.Len(t, cursors, 3)
+	for _, cursor := range cursors {
+		require.Equal(t, ancestor.Number.Uint64(), cursor.LastBlock, cursor.Contract)
+	}
+	var orphaned int64
+	require.NoError(t, env.db.Model(&models.IndexedBlock{}).Where("number > ?", ancestor.Number.Uint64()).Count(&orphaned).Error)
+	require.Zero(t, orphaned)
+
+	// 下一轮从共同祖先之后索引新链
+	require.NoError(t, env.indexer.Sync(ctx))
+	require.NoError(t, env.db.Find(&cursors).Error)
+	for _, cursor := range cursors {
+		require.Equal(t, ancestor.Number.Uint64()+3, cursor.LastBlock, cursor.Contract)
+	}
+}
@@ -0,0 +1,475 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"eth-for-babies-backend/internal/api/routes"
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
+	"eth-for-babies-backend/pkg/blockchain"
+)
+
+// simulatedChain adapts the go-ethereum simulated backend to blockchain.Backend.
+// Every accepted transaction is mined right away, like an auto-mining dev node,
+// so handlers and background services see receipts without a block producer.
+// Tests can make it lose the node's reply to a send, fail receipt lookups, or
+// hold sent transactions in a mempool that follows the replacement rules of a real node until they
+// mine a chosen one.
+type simulatedChain struct {
+	*backends.SimulatedBackend
+
+	mu sync.Mutex
+	// lostReplies 接下来这么多次发送会被节点接受，但调用方收到网络错误
+	lostReplies int
+	// receiptErrors 接下来这么多次查询收据返回网络错误
+	receiptErrors int
+	// hold 为true时交易只进入pool，由测试决定打包哪一笔
+	hold bool
+	pool map[pooledKey]*types.Transaction
+}
+
+// pooledKey identifies a transaction slot in the mempool
+type pooledKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+// BlockNumber returns the number of the latest mined block
+func (c *simulatedChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.Blockchain().CurrentBlock().Number.Uint64(), nil
+}
+
+// SendTransaction mines tx, or adds it to the mempool while blocks are held
+func (c *simulatedChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hold {
+		if err := c.addToPool(ctx, tx); err != nil {
+			return err
+		}
+	} else {
+		if err := c.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+			return err
+		}
+		c.Commit()
+	}
+	if c.lostReplies > 0 {
+		c.lostReplies--
+		return errors.New("connection reset by peer")
+	}
+	return nil
+}
+
+// addToPool accepts tx into the mempool: a transaction for a nonce that is
+// already pooled must pay at least 10% more to replace it, as geth requires
+func (c *simulatedChain) addToPool(ctx context.Context, tx *types.Transaction) error {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return err
+	}
+	mined, err := c.SimulatedBackend.NonceAt(ctx, from, nil)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() < mined {
+		return fmt.Errorf("nonce too low: address %s, tx: %d state: %d", from.Hex(), tx.Nonce(), mined)
+	}
+
+	key := pooledKey{from: from, nonce: tx.Nonce()}
+	if pooled, ok := c.pool[key]; ok {
+		if !outbids(tx.GasFeeCap(), pooled.GasFeeCap()) || !outbids(tx.GasTipCap(), pooled.GasTipCap()) {
+			return errors.New("replacement transaction underpriced")
+		}
+	} else if next := c.pendingNonce(from, mined); tx.Nonce() != next {
+		return fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), next)
+	}
+	if c.pool == nil {
+		c.pool = make(map[pooledKey]*types.Transaction)
+	}
+	c.pool[key] = tx
+	return nil
+}
+
+// outbids reports whether price is at least 10% above pooled
+func outbids(price, pooled *big.Int) bool {
+	threshold := new(big.Int).Mul(pooled, big.NewInt(110))
+	return new(big.Int).Mul(price, big.NewInt(100)).Cmp(threshold) >= 0
+}
+
+// pendingNonce returns the first nonce of from after its pooled transactions
+func (c *simulatedChain) pendingNonce(from common.Address, mined uint64) uint64 {
+	next := mined
+	for {
+		if _, ok := c.pool[pooledKey{from: from, nonce: next}]; !ok {
+			return next
+		}
+		next++
+	}
+}
+
+// PendingNonceAt includes the transactions waiting in the mempool
+func (c *simulatedChain) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mined, err := c.SimulatedBackend.NonceAt(ctx, account, nil)
+	if err != nil {
+		return 0, err
+	}
+	if pending := c.pendingNonce(account, mined); pending > mined {
+		return pending, nil
+	}
+	return c.SimulatedBackend.PendingNonceAt(ctx, account)
+}
+
+// TransactionByHash also finds transactions waiting in the mempool
+func (c *simulatedChain) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	c.mu.Lock()
+	for _, tx := range c.pool {
+		if tx.Hash() == txHash {
+			c.mu.Unlock()
+			return tx, true, nil
+		}
+	}
+	c.mu.Unlock()
+	return c.SimulatedBackend.TransactionByHash(ctx, txHash)
+}
+
+// TransactionReceipt fails while receipt errors are queued
+func (c *simulatedChain) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	c.mu.Lock()
+	if c.receiptErrors > 0 {
+		c.receiptErrors--
+		c.mu.Unlock()
+		return nil, errors.New("connection refused")
+	}
+	c.mu.Unlock()
+	return c.SimulatedBackend.TransactionReceipt(ctx, txHash)
+}
+
+// pooled returns the mempool transaction of from for nonce
+func (c *simulatedChain) pooled(from common.Address, nonce uint64) *types.Transaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pool[pooledKey{from: from, nonce: nonce}]
+}
+
+// mine includes tx in a new block, dropping whatever the mempool held for its
+// nonce; tx does not have to be the pooled transaction
+func (c *simulatedChain) mine(t *testing.T, tx *types.Transaction) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	require.NoError(t, err)
+	delete(c.pool, pooledKey{from: from, nonce: tx.Nonce()})
+	require.NoError(t, c.SimulatedBackend.SendTransaction(context.Background(), tx))
+	c.Commit()
+}
+
+// loseReplies makes the next n sends succeed on chain but fail for the caller
+func (c *simulatedChain) loseReplies(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lostReplies = n
+}
+
+// failReceipts makes the next n receipt lookups fail with a network error
+func (c *simulatedChain) failReceipts(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.receiptErrors = n
+}
+
+// holdBlocks keeps sent transactions in the mempool until it is called with
+// false, which mines the pooled transactions in nonce order
+func (c *simulatedChain) holdBlocks(t *testing.T, hold bool) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hold = hold
+	if hold {
+		return
+	}
+
+	pooled := make([]*types.Transaction, 0, len(c.pool))
+	for _, tx := range c.pool {
+		pooled = append(pooled, tx)
+	}
+	sort.Slice(pooled, func(i, j int) bool { return pooled[i].Nonce() < pooled[j].Nonce() })
+	for _, tx := range pooled {
+		require.NoError(t, c.SimulatedBackend.SendTransaction(context.Background(), tx))
+	}
+	c.pool = nil
+	c.Commit()
+}
+
+// account is a funded externally owned account on the simulated chain
+type account struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func newAccount(t *testing.T) *account {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return &account{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// wallet returns the address in the lowercase form the backend stores
+func (a *account) wallet() string {
+	return strings.ToLower(a.address.Hex())
+}
+
+// testEnv wires the contracts deployed on a simulated chain into the
+// ContractManager, the HTTP routes and the background services, backed by an
+// in-memory SQLite database.
+type testEnv struct {
+	t       *testing.T
+	chain   *simulatedChain
+	chainID *big.Int
+	db      *gorm.DB
+	cfg     *config.Config
+	router  *gin.Engine
+	cm      *blockchain.ContractManager
+	outbox  *services.OutboxDispatcher
+	indexer *services.EventIndexer
+	broker  *realtime.MemoryBroker
+
+	// operator 后端签名账户，部署合约并拥有代币合约的铸币权限
+	operator *account
+	parent   *account
+	child    *account
+
+	taskRegistry   *blockchain.TaskRegistry
+	familyRegistry *blockchain.FamilyRegistry
+	rewardToken    *blockchain.RewardToken
+	rewardRegistry *blockchain.RewardRegistry
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	env := &testEnv{
+		t:        t,
+		operator: newAccount(t),
+		parent:   newAccount(t),
+		child:    newAccount(t),
+	}
+
+	funds := new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		env.operator.address: {Balance: funds},
+		env.parent.address:   {Balance: funds},
+		env.child.address:    {Balance: funds},
+	}, 30_000_000)
+	t.Cleanup(func() { sim.Close() })
+	env.chain = &simulatedChain{SimulatedBackend: sim}
+	env.chainID = sim.Blockchain().Config().ChainID
+
+	addresses := env.deployContracts()
+
+	// 后端通过与生产环境相同的签名器和合约管理器访问模拟链
+	client := blockchain.NewEthClientWithBackend(env.chain, blockchain.NewMemorySigner(env.operator.key), env.chainID)
+	cm, err := blockchain.NewContractManager(client, addresses)
+	require.NoError(t, err)
+	env.cm = cm
+
+	env.cfg = &config.Config{
+		Environment: "test",
+		JWTSecret:   "e2e-test-secret",
+		Database: config.DatabaseConfig{
+			Driver: "sqlite",
+			DSN:    fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_")),
+		},
+		Blockchain: config.BlockchainConfig{
+			ChainID:               env.chainID.Int64(),
+			Confirmations:         1,
+			TaskRegistryAddress:   addresses["task"],
+			FamilyRegistryAddress: addresses["family"],
+			RewardTokenAddress:    addresses["token"],
+			RewardRegistryAddress: addresses["reward"],
+		},
+		Outbox: config.OutboxConfig{
+			PollInterval: 10 * time.Millisecond,
+			BatchSize:    10,
+			MaxAttempts:  3,
+			BaseBackoff:  10 * time.Millisecond,
+			MaxBackoff:   100 * time.Millisecond,
+			StuckAfter:   time.Minute,
+		},
+		Indexer: config.IndexerConfig{
+			Enabled:      true,
+			PollInterval: 10 * time.Millisecond,
+			BlockRange:   1000,
+			ReorgWindow:  128,
+		},
+		Auth: config.AuthConfig{
+			Domain:   "localhost:5173",
+			URI:      "http://localhost:5173",
+			NonceTTL: 5 * time.Minute,
+		},
+	}
+	cm.SetRequiredConfirmations(env.cfg.Blockchain.Confirmations)
+
+	db, err := config.InitDatabase(env.cfg)
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	env.db = db
+
+	env.broker = realtime.NewMemoryBroker(16)
+	env.router = routes.SetupRoutes(db, env.cfg, cm, env.broker)
+	env.outbox = services.NewOutboxDispatcher(repository.NewOutboxRepository(db), cm, env.cfg.Outbox, env.broker)
+	env.indexer = services.NewEventIndexer(db, cm, env.cfg.Indexer)
+
+	return env
+}
+
+// deployContracts deploys the four contracts from the operator account and
+// lets the reward registry burn tokens on exchange
+func (env *testEnv) deployContracts() map[string]string {
+	t := env.t
+	auth := env.transactor(env.operator)
+
+	taskAddr, _, taskRegistry, err := blockchain.DeployTaskRegistry(auth, env.chain)
+	require.NoError(t, err)
+	familyAddr, _, familyRegistry, err := blockchain.DeployFamilyRegistry(auth, env.chain)
+	require.NoError(t, err)
+	tokenAddr, _, rewardToken, err := blockchain.DeployRewardToken(auth, env.chain, "TaskReward", "TRW")
+	require.NoError(t, err)
+	rewardAddr, _, rewardRegistry, err := blockchain.DeployRewardRegistry(auth, env.chain, tokenAddr)
+	require.NoError(t, err)
+
+	env.mined(rewardToken.AddMinter(auth, rewardAddr))
+
+	env.taskRegistry = taskRegistry
+	env.familyRegistry = familyRegistry
+	env.rewardToken = rewardToken
+	env.rewardRegistry = rewardRegistry
+
+	return map[string]string{
+		"task":   taskAddr.Hex(),
+		"family": familyAddr.Hex(),
+		"token":  tokenAddr.Hex(),
+		"reward": rewardAddr.Hex(),
+	}
+}
+
+// transactor returns transact options signed by the account, as a wallet in the frontend would
+func (env *testEnv) transactor(a *account) *bind.TransactOpts {
+	auth, err := bind.NewKeyedTransactorWithChainID(a.key, env.chainID)
+	require.NoError(env.t, err)
+	return auth
+}
+
+// mined asserts that a transaction sent from a test account succeeded and returns its receipt
+func (env *testEnv) mined(tx *types.Transaction, err error) *types.Receipt {
+	env.t.Helper()
+	require.NoError(env.t, err)
+	receipt, err := env.chain.TransactionReceipt(context.Background(), tx.Hash())
+	require.NoError(env.t, err)
+	require.Equal(env.t, types.ReceiptStatusSuccessful, receipt.Status, "transaction %s reverted", tx.Hash().Hex())
+	return receipt
+}
+
+// tokenBalance returns the reward token balance of the account
+func (env *testEnv) tokenBalance(a *account) *big.Int {
+	env.t.Helper()
+	balance, err := env.rewardToken.BalanceOf(&bind.CallOpts{}, a.address)
+	require.NoError(env.t, err)
+	return balance
+}
+
+// login signs in with the account's key through the Sign-In with Ethereum flow and returns a JWT
+func (env *testEnv) login(a *account, role string) string {
+	env.t.Helper()
+
+	var nonce struct {
+		Message string `json:"message"`
+	}
+	env.do(http.MethodGet, "/api/v1/auth/nonce/"+a.address.Hex(), "", nil, http.StatusOK, &nonce)
+
+	signature, err := crypto.Sign(accounts.TextHash([]byte(nonce.Message)), a.key)
+	require.NoError(env.t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+
+	var session struct {
+		Token string `json:"token"`
+	}
+	env.do(http.MethodPost, "/api/v1/auth/login", "", map[string]interface{}{
+		"message":   nonce.Message,
+		"signature": hexutil.Encode(signature),
+		"role":      role,
+	}, http.StatusOK, &session)
+	require.NotEmpty(env.t, session.Token)
+	return session.Token
+}
+
+// do sends a JSON request to the router, checks the status code and decodes the
+// response's data field into out when out is not nil
+func (env *testEnv) do(method, path, token string, body interface{}, wantStatus int, out interface{}) {
+	env.t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		require.NoError(env.t, err)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	env.router.ServeHTTP(w, req)
+	require.Equal(env.t, wantStatus, w.Code, "%s %s: %s", method, path, w.Body.String())
+
+	if out == nil {
+		return
+	}
+	var response struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	require.NoError(env.t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.True(env.t, response.Success, "%s %s: %s", method, path, w.Body.String())
+	require.NoError(env.t, json.Unmarshal(response.Data, out))
+}
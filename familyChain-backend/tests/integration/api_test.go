@@ -1,332 +1,2225 @@
 package integration
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/ecdsa"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"eth-for-babies-backend/internal/api/routes"
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
+	"eth-for-babies-backend/internal/utils"
 )
 
+// testAPI is the router backed by an in-memory database, without a blockchain connection
+type testAPI struct {
+	t      *testing.T
+	cfg    *config.Config
+	db     *gorm.DB
+	router *gin.Engine
+	broker *realtime.MemoryBroker
+	// webhooks delivers the webhook events queued by the router, tests call DispatchDue directly
+	webhooks *services.WebhookDispatcher
+}
+
 // Setup test environment
-func setupTestRouter() *gin.Engine {
+func setupTestAPI(t *testing.T) *testAPI {
 	gin.SetMode(gin.TestMode)
-	r := gin.Default()
 
-	// Initialize routes (simplified version for testing)
-	routes.SetupRoutes(r)
+	cfg := &config.Config{
+		Environment: "test",
+		JWTSecret:   "integration-test-secret",
+		Database: config.DatabaseConfig{
+			Driver: "sqlite",
+			DSN:    fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_")),
+		},
+		Blockchain: config.BlockchainConfig{
+			ChainID: 1337,
+		},
+		Auth: config.AuthConfig{
+			Domain:           "localhost:5173",
+			URI:              "http://localhost:5173",
+			NonceTTL:         5 * time.Minute,
+			InviteCodeSecret: "integration-invite-secret",
+		},
+		Webhook: config.WebhookConfig{
+			BatchSize:   20,
+			MaxAttempts: 3,
+			Timeout:     5 * time.Second,
+		},
+		// 邮件只写入队列，需要发送的测试自己创建分发器
+		Notification: config.NotificationConfig{
+			LowStockThreshold: 1,
+			SMTP:              config.SMTPConfig{Host: "127.0.0.1", From: "noreply@familychain.test"},
+			BatchSize:         20,
+			MaxAttempts:       3,
+		},
+	}
 
-	return r
+	db, err := config.InitDatabase(cfg)
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	broker := realtime.NewMemoryBroker(16)
+	webhooks := services.NewWebhookDispatcher(repository.NewWebhookRepository(db), cfg.Webhook)
+	notifications := services.NewNotificationService(
+		repository.NewNotificationRepository(db), repository.NewFamilyMemberRepository(db), repository.NewChildRepository(db),
+		repository.NewUserRepository(db), cfg.Notification.SMTP.Configured(),
+	)
+	return &testAPI{
+		t:        t,
+		cfg:      cfg,
+		db:       db,
+		router:   routes.SetupRoutes(db, cfg, nil, realtime.WithHook(broker, webhooks.Enqueue, notifications.Notify)),
+		broker:   broker,
+		webhooks: webhooks,
+	}
 }
 
-// Test user registration
-func TestUserRegistration(t *testing.T) {
-	router := setupTestRouter()
-
-	// Create test user data
-	userData := map[string]interface{}{
-		"username":      "testuser",
-		"password":      "password123",
-		"email":         "test@example.com",
-		"walletAddress": "0x1234567890abcdef",
+// request performs a JSON request and returns the decoded response body
+func (api *testAPI) request(method, path, token string, body interface{}) (int, map[string]interface{}) {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
 	}
 
-	jsonData, _ := json.Marshal(userData)
-
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/v1/auth/register", bytes.NewBuffer(jsonData))
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
 	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
-	// Perform request
 	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	api.router.ServeHTTP(w, req)
 
-	// Assert status code
-	assert.Equal(t, http.StatusCreated, w.Code)
-
-	// Parse response
 	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-
-	// Assert response
-	assert.Nil(t, err)
-	assert.Equal(t, true, response["success"])
-	assert.NotNil(t, response["user"])
+	require.NoError(api.t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+	return w.Code, response
 }
 
-// Test user login
-func TestUserLogin(t *testing.T) {
-	router := setupTestRouter()
+// signInMessage fetches the EIP-4361 message the server issued for the wallet
+func (api *testAPI) signInMessage(address string) string {
+	code, response := api.request("GET", "/api/v1/auth/nonce/"+address, "", nil)
+	require.Equal(api.t, http.StatusOK, code)
+	return data(response)["message"].(string)
+}
 
-	// Create login data
-	loginData := map[string]interface{}{
-		"username": "testuser",
-		"password": "password123",
-	}
+// sign signs the message with personal_sign as a wallet would
+func sign(t *testing.T, key *ecdsa.PrivateKey, message string) string {
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	require.NoError(t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
 
-	jsonData, _ := json.Marshal(loginData)
+// login signs the sign-in message with the wallet key and returns a JWT
+func (api *testAPI) login(key *ecdsa.PrivateKey, role string) string {
+	return api.loginSession(key, role)["token"].(string)
+}
 
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
+// loginSession signs in and returns the access token, refresh token and user
+func (api *testAPI) loginSession(key *ecdsa.PrivateKey, role string) map[string]interface{} {
+	message := api.signInMessage(crypto.PubkeyToAddress(key.PublicKey).Hex())
+
+	code, response := api.request("POST", "/api/v1/auth/login", "", map[string]interface{}{
+		"message":   message,
+		"signature": sign(api.t, key, message),
+		"role":      role,
+	})
+	require.Equal(api.t, http.StatusOK, code, response)
+	return data(response)
+}
 
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+// data returns the data object of a response
+func data(response map[string]interface{}) map[string]interface{} {
+	return response["data"].(map[string]interface{})
+}
 
-	// Assert status code
-	assert.Equal(t, http.StatusOK, w.Code)
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return key
+}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+// mint records tokens minted to a wallet in the ledger, as the indexer would, so the child has a balance to exchange
+func (api *testAPI) mint(wallet string, units int) {
+	var count int64
+	require.NoError(api.t, api.db.Model(&models.LedgerTransaction{}).Count(&count).Error)
+	amount := utils.UnitsToTokenAmount(units).String()
+	err := repository.NewLedgerRepository(api.db).Post(&models.LedgerTransaction{
+		Kind: models.LedgerKindMint, Amount: amount, TxHash: fmt.Sprintf("0x%064x", count+1), OccurredAt: time.Now(),
+	}, []models.LedgerEntry{
+		{Account: strings.ToLower(common.Address{}.Hex()), AccountType: models.LedgerAccountIssuance, Amount: "-" + amount},
+		{Account: strings.ToLower(wallet), AccountType: models.LedgerAccountChild, Amount: amount},
+	})
+	require.NoError(api.t, err)
+}
 
-	// Assert response
-	assert.Nil(t, err)
+// Test user registration
+func TestUserRegistration(t *testing.T) {
+	api := setupTestAPI(t)
+	address := crypto.PubkeyToAddress(newKey(t).PublicKey).Hex()
+
+	code, response := api.request("POST", "/api/v1/auth/register", "", map[string]interface{}{
+		"wallet_address": address,
+		"role":           "parent",
+	})
+	assert.Equal(t, http.StatusCreated, code)
 	assert.Equal(t, true, response["success"])
-	assert.NotNil(t, response["token"])
+	assert.NotNil(t, data(response)["user"])
+
+	// 重复注册
+	code, _ = api.request("POST", "/api/v1/auth/register", "", map[string]interface{}{
+		"wallet_address": address,
+		"role":           "parent",
+	})
+	assert.Equal(t, http.StatusConflict, code)
 }
 
-// Test create family
-func TestCreateFamily(t *testing.T) {
-	router := setupTestRouter()
-
-	// Create test token (would normally come from login)
-	token := "test-token"
-
-	// Create family data
-	familyData := map[string]interface{}{
-		"name": "Test Family",
-	}
+// Test user login
+func TestUserLogin(t *testing.T) {
+	api := setupTestAPI(t)
+	key := newKey(t)
+
+	token := api.login(key, "parent")
+	assert.NotEmpty(t, token)
+
+	var user models.User
+	require.NoError(t, api.db.Where("wallet_address = ?", strings.ToLower(crypto.PubkeyToAddress(key.PublicKey).Hex())).First(&user).Error)
+	assert.Equal(t, "parent", user.Role)
+
+	// 用别的钱包签名
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	message := api.signInMessage(address)
+	code, _ := api.request("POST", "/api/v1/auth/login", "", map[string]interface{}{
+		"message":   message,
+		"signature": sign(t, newKey(t), message),
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// 同一条消息只能登录一次
+	message = api.signInMessage(address)
+	signature := sign(t, key, message)
+	code, _ = api.request("POST", "/api/v1/auth/login", "", map[string]interface{}{
+		"message":   message,
+		"signature": signature,
+	})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", "/api/v1/auth/login", "", map[string]interface{}{
+		"message":   message,
+		"signature": signature,
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// 模拟签名默认不被接受
+	code, _ = api.request("POST", "/api/v1/auth/login", "", map[string]interface{}{
+		"message":   api.signInMessage(address),
+		"signature": "0x" + strings.Repeat("0", 130),
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
 
-	jsonData, _ := json.Marshal(familyData)
+// Test refresh token rotation and reuse detection
+func TestRefreshToken(t *testing.T) {
+	api := setupTestAPI(t)
+	session := api.loginSession(newKey(t), "parent")
+	refreshToken := session["refresh_token"].(string)
+	require.NotEmpty(t, refreshToken)
+
+	// 刷新令牌轮换
+	code, response := api.request("POST", "/api/v1/auth/refresh", "", map[string]interface{}{
+		"refresh_token": refreshToken,
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	rotated := data(response)
+	assert.NotEqual(t, refreshToken, rotated["refresh_token"])
+
+	code, _ = api.request("GET", "/api/v1/tasks", rotated["token"].(string), nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	// 重复使用旧的刷新令牌会撤销整个会话
+	code, _ = api.request("POST", "/api/v1/auth/refresh", "", map[string]interface{}{
+		"refresh_token": refreshToken,
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = api.request("POST", "/api/v1/auth/refresh", "", map[string]interface{}{
+		"refresh_token": rotated["refresh_token"],
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = api.request("GET", "/api/v1/tasks", rotated["token"].(string), nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = api.request("POST", "/api/v1/auth/refresh", "", map[string]interface{}{
+		"refresh_token": "unknown",
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
 
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/v1/families", bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+// Test logout and logout from all devices
+func TestLogout(t *testing.T) {
+	api := setupTestAPI(t)
+	key := newKey(t)
+	first := api.loginSession(key, "parent")
+	second := api.loginSession(key, "parent")
+	third := api.loginSession(key, "parent")
+
+	// 登出只撤销当前会话
+	code, _ := api.request("POST", "/api/v1/auth/logout", first["token"].(string), nil)
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = api.request("GET", "/api/v1/tasks", first["token"].(string), nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = api.request("POST", "/api/v1/auth/refresh", "", map[string]interface{}{
+		"refresh_token": first["refresh_token"],
+	})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = api.request("GET", "/api/v1/tasks", second["token"].(string), nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	// 所有设备登出
+	code, response := api.request("POST", "/api/v1/auth/logout-all", second["token"].(string), nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.EqualValues(t, 2, data(response)["revoked_sessions"])
+
+	code, _ = api.request("GET", "/api/v1/tasks", third["token"].(string), nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
 
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+// Test that protected routes require a token
+func TestProtectedRoutesRequireToken(t *testing.T) {
+	api := setupTestAPI(t)
 
-	// Assert status code
-	assert.Equal(t, http.StatusCreated, w.Code)
+	code, response := api.request("GET", "/api/v1/tasks", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.NotEqual(t, true, response["success"])
+}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+// Test create family
+func TestCreateFamily(t *testing.T) {
+	api := setupTestAPI(t)
+	token := api.login(newKey(t), "parent")
 
-	// Assert response
-	assert.Nil(t, err)
+	code, response := api.request("POST", "/api/v1/families", token, map[string]interface{}{
+		"name": "Test Family",
+	})
+	assert.Equal(t, http.StatusCreated, code)
 	assert.Equal(t, true, response["success"])
-	assert.NotNil(t, response["family"])
+	assert.Equal(t, "Test Family", data(response)["name"])
+
+	// 每个家长只能有一个家庭
+	code, _ = api.request("POST", "/api/v1/families", token, map[string]interface{}{
+		"name": "Second Family",
+	})
+	assert.Equal(t, http.StatusConflict, code)
 }
 
 // Test create child
 func TestCreateChild(t *testing.T) {
-	router := setupTestRouter()
+	api := setupTestAPI(t)
+	token := api.login(newKey(t), "parent")
+	childAddress := crypto.PubkeyToAddress(newKey(t).PublicKey).Hex()
+
+	code, _ := api.request("POST", "/api/v1/families", token, map[string]interface{}{"name": "Test Family"})
+	require.Equal(t, http.StatusCreated, code)
+
+	code, response := api.request("POST", "/api/v1/children", token, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": childAddress,
+	})
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, true, response["success"])
+	assert.Equal(t, strings.ToLower(childAddress), data(response)["wallet_address"])
+}
 
-	// Create test token (would normally come from login)
-	token := "test-token"
+// Test the task lifecycle without a blockchain connection: the mint is queued in the outbox
+func TestTaskLifecycle(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Test Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	// 创建任务
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":         "Test Task",
+		"description":   "This is a test task",
+		"reward_amount": "0.01",
+		"difficulty":    "easy",
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	taskID := data(response)["id"]
+	assert.Equal(t, "pending", data(response)["status"])
+
+	// 孩子不能创建任务
+	code, _ = api.request("POST", "/api/v1/tasks", childToken, map[string]interface{}{
+		"title":         "Test Task",
+		"description":   "This is a test task",
+		"reward_amount": "0.01",
+		"difficulty":    "easy",
+	})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 获取任务列表
+	code, response = api.request("GET", "/api/v1/tasks", parentToken, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 1)
+
+	// 分配任务
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/direct-assign", taskID), parentToken, map[string]interface{}{
+		"assigned_child_id": fmt.Sprint(childID),
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "in_progress", data(response)["status"])
+
+	// 完成任务
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/complete", taskID), childToken, map[string]interface{}{
+		"completion_proof": "Task completed proof",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "completed", data(response)["status"])
+
+	// 批准任务
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/approve", taskID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "approved", data(response)["status"])
+
+	var mints []models.OutboxMessage
+	require.NoError(t, api.db.Find(&mints).Error)
+	require.Len(t, mints, 1)
+	assert.Equal(t, models.OutboxKindMintReward, mints[0].Kind)
+	assert.Equal(t, models.OutboxStatusPending, mints[0].Status)
+	assert.Equal(t, "100000000000000000000", mints[0].Amount)
+
+	// 不能重复批准
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/approve", taskID), parentToken, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
 
-	// Create child data
-	childData := map[string]interface{}{
-		"name":          "Test Child",
-		"age":           10,
-		"familyId":      1,
-		"walletAddress": "0x0987654321abcdef",
+// Test co-parents and viewers: authorization follows family membership rather than the task creator
+func TestFamilyMembership(t *testing.T) {
+	api := setupTestAPI(t)
+	ownerToken := api.login(newKey(t), "parent")
+	coParentKey, viewerKey := newKey(t), newKey(t)
+	coParentToken := api.login(coParentKey, "parent")
+	viewerToken := api.login(viewerKey, "parent")
+	outsiderToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	code, response := api.request("POST", "/api/v1/families", ownerToken, map[string]interface{}{"name": "Test Family"})
+	require.Equal(t, http.StatusCreated, code)
+	familyID := data(response)["id"]
+	code, response = api.request("POST", "/api/v1/children", ownerToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	// 邀请共同家长和只读成员
+	invite := func(key *ecdsa.PrivateKey, role string) interface{} {
+		code, response := api.request("POST", fmt.Sprintf("/api/v1/families/%v/invitations", familyID), ownerToken, map[string]interface{}{
+			"wallet_address": crypto.PubkeyToAddress(key.PublicKey).Hex(),
+			"role":           role,
+		})
+		require.Equal(t, http.StatusCreated, code, response)
+		return data(response)["id"]
 	}
+	coParentInvitation := invite(coParentKey, models.FamilyRoleCoParent)
+	viewerInvitation := invite(viewerKey, models.FamilyRoleViewer)
+
+	// 只有所有者可以邀请，且不能重复邀请
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/families/%v/invitations", familyID), outsiderToken, map[string]interface{}{
+		"wallet_address": crypto.PubkeyToAddress(newKey(t).PublicKey).Hex(),
+		"role":           models.FamilyRoleCoParent,
+	})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/families/%v/invitations", familyID), ownerToken, map[string]interface{}{
+		"wallet_address": crypto.PubkeyToAddress(viewerKey.PublicKey).Hex(),
+		"role":           models.FamilyRoleViewer,
+	})
+	assert.Equal(t, http.StatusConflict, code)
+
+	// 接受邀请前不能访问家庭
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/families/%v", familyID), coParentToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 被邀请人可以看到待处理的邀请，别人不能替他接受
+	code, response = api.request("GET", "/api/v1/invitations", coParentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 1)
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/invitations/%v/accept", coParentInvitation), outsiderToken, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/invitations/%v/accept", coParentInvitation), coParentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, models.FamilyRoleCoParent, data(response)["role"])
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/invitations/%v/accept", viewerInvitation), viewerToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/invitations/%v/accept", viewerInvitation), viewerToken, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/families/%v/members", familyID), viewerToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 3)
+
+	// 共同家长可以看到孩子并为孩子创建任务
+	code, response = api.request("GET", "/api/v1/children/my", coParentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 1)
+
+	code, response = api.request("POST", "/api/v1/tasks", coParentToken, map[string]interface{}{
+		"title":             "Make your bed",
+		"description":       "Every morning",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": childID,
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	taskID := data(response)["id"]
+	assert.Equal(t, familyID, data(response)["family_id"])
+
+	// 只读成员不能创建任务
+	code, _ = api.request("POST", "/api/v1/tasks", viewerToken, map[string]interface{}{
+		"title":         "Not allowed",
+		"description":   "Viewer",
+		"reward_amount": "0.01",
+		"difficulty":    "easy",
+	})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 所有家庭成员都能看到任务，家庭外的家长不能
+	code, response = api.request("GET", "/api/v1/tasks", ownerToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 1)
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/tasks/%v", taskID), viewerToken, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/tasks/%v", taskID), outsiderToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/complete", taskID), childToken, map[string]interface{}{
+		"completion_proof": "Done",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+
+	// 只读成员不能审批，所有者可以审批共同家长创建的任务
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/approve", taskID), viewerToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/approve", taskID), ownerToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "approved", data(response)["status"])
+
+	// 奖品：共同家长可以创建，只读成员只能查看，家庭外的家长不能查看
+	rewardsPath := fmt.Sprintf("/api/v1/rewards/family/%v", familyID)
+	reward := map[string]interface{}{"name": "Ice cream", "image_url": "/uploads/ice-cream.png", "token_price": 10, "stock": 1}
+	code, _ = api.request("POST", rewardsPath, viewerToken, reward)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, response = api.request("POST", rewardsPath, coParentToken, reward)
+	require.Equal(t, http.StatusCreated, code, response)
+	code, response = api.request("GET", rewardsPath, viewerToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 1)
+	code, _ = api.request("GET", rewardsPath, childToken, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.request("GET", rewardsPath, outsiderToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 成员管理：创建者不能被移除，只读成员可以自己退出
+	var members []models.FamilyMember
+	require.NoError(t, api.db.Where("family_id = ?", familyID).Order("id").Find(&members).Error)
+	require.Len(t, members, 3)
+	code, _ = api.request("DELETE", fmt.Sprintf("/api/v1/families/%v/members/%d", familyID, members[0].ID), ownerToken, nil)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = api.request("DELETE", fmt.Sprintf("/api/v1/families/%v/members/%d", familyID, members[1].ID), viewerToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("DELETE", fmt.Sprintf("/api/v1/families/%v/members/%d", familyID, members[2].ID), viewerToken, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/tasks/%v", taskID), viewerToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+}
 
-	jsonData, _ := json.Marshal(childData)
-
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/v1/children", bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+func TestFamilyInviteCodes(t *testing.T) {
+	api := setupTestAPI(t)
+	ownerToken := api.login(newKey(t), "parent")
+	coParentToken := api.login(newKey(t), "parent")
+
+	code, response := api.request("POST", "/api/v1/families", ownerToken, map[string]interface{}{"name": "Invite Family"})
+	require.Equal(t, http.StatusCreated, code)
+	familyID := data(response)["id"]
+	codesPath := fmt.Sprintf("/api/v1/families/%v/invite-codes", familyID)
+
+	issue := func(token string, body map[string]interface{}) (int, map[string]interface{}) {
+		code, response := api.request("POST", codesPath, token, body)
+		if code != http.StatusCreated {
+			return code, nil
+		}
+		return code, data(response)
+	}
 
-	// Assert status code
-	assert.Equal(t, http.StatusCreated, w.Code)
+	// 邀请孩子需要填写名字和年龄，家庭外的家长不能生成邀请码
+	code, _ = issue(ownerToken, map[string]interface{}{"role": models.InviteRoleChild})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = issue(coParentToken, map[string]interface{}{"role": models.InviteRoleChild, "child_name": "Kid", "child_age": 8})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, issued := issue(ownerToken, map[string]interface{}{"role": models.InviteRoleChild, "child_name": "Kid", "child_age": 8})
+	require.Equal(t, http.StatusCreated, code)
+	childCode := issued["code"].(string)
+	assert.Contains(t, issued["link"], "http://localhost:5173/join?code=")
+
+	code, response = api.request("POST", "/api/v1/auth/invite-codes/preview", "", map[string]interface{}{"code": childCode})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Invite Family", data(response)["family_name"])
+	assert.Equal(t, models.InviteRoleChild, data(response)["role"])
+
+	// 篡改过的邀请码在签名校验时被拒绝
+	code, _ = api.request("POST", "/api/v1/auth/invite-codes/preview", "", map[string]interface{}{"code": childCode + "x"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 旧版本获取nonce时创建的临时用户携带邀请码登录，自动转为孩子并加入家庭
+	childKey := newKey(t)
+	childAddress := strings.ToLower(crypto.PubkeyToAddress(childKey.PublicKey).Hex())
+	require.NoError(t, api.db.Create(&models.User{WalletAddress: childAddress, Role: "temp"}).Error)
+	loginWithCode := func(key *ecdsa.PrivateKey, inviteCode string) (int, map[string]interface{}) {
+		message := api.signInMessage(crypto.PubkeyToAddress(key.PublicKey).Hex())
+		return api.request("POST", "/api/v1/auth/login", "", map[string]interface{}{
+			"message":     message,
+			"signature":   sign(t, key, message),
+			"invite_code": inviteCode,
+		})
+	}
+	code, response = loginWithCode(childKey, childCode)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "child", data(response)["user"].(map[string]interface{})["role"])
+	childToken := data(response)["token"].(string)
+	code, response = api.request("GET", "/api/v1/children/my", childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	children := response["data"].([]interface{})
+	require.Len(t, children, 1)
+	assert.Equal(t, "Kid", children[0].(map[string]interface{})["name"])
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/rewards/family/%v", familyID), childToken, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	// 邀请码只能使用一次
+	code, _ = loginWithCode(newKey(t), childCode)
+	assert.Equal(t, http.StatusConflict, code)
+
+	// 只有所有者可以邀请监护人；已登录的家长可以直接兑换
+	code, issued = issue(ownerToken, map[string]interface{}{"role": models.FamilyRoleCoParent})
+	require.Equal(t, http.StatusCreated, code)
+	coParentCode := issued["code"].(string)
+	code, _ = api.request("POST", "/api/v1/invite-codes/redeem", childToken, map[string]interface{}{"code": coParentCode})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, response = api.request("POST", "/api/v1/invite-codes/redeem", coParentToken, map[string]interface{}{"code": coParentCode})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, models.FamilyRoleCoParent, data(response)["member"].(map[string]interface{})["role"])
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/families/%v", familyID), coParentToken, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = issue(coParentToken, map[string]interface{}{"role": models.FamilyRoleViewer})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 撤销和过期的邀请码不能再兑换
+	code, issued = issue(ownerToken, map[string]interface{}{"role": models.FamilyRoleViewer})
+	require.Equal(t, http.StatusCreated, code)
+	invite := issued["invite"].(map[string]interface{})
+	code, _ = api.request("DELETE", fmt.Sprintf("%s/%v", codesPath, invite["id"]), ownerToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = loginWithCode(newKey(t), issued["code"].(string))
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, issued = issue(ownerToken, map[string]interface{}{"role": models.FamilyRoleViewer})
+	require.Equal(t, http.StatusCreated, code)
+	require.NoError(t, api.db.Model(&models.FamilyInviteCode{}).
+		Where("id = ?", issued["invite"].(map[string]interface{})["id"]).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	code, _ = loginWithCode(newKey(t), issued["code"].(string))
+	assert.Equal(t, http.StatusConflict, code)
+
+	// 明文邀请码不会出现在列表中
+	code, response = api.request("GET", codesPath, ownerToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, response["data"], 4)
+	for _, item := range response["data"].([]interface{}) {
+		assert.NotContains(t, item, "code_hash")
+		assert.NotContains(t, item, "code")
+	}
+}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+func TestTaskSeries(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Series Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	series := map[string]interface{}{
+		"title":         "Make your bed",
+		"description":   "Every day",
+		"reward_amount": "0.01",
+		"difficulty":    "easy",
+		"child_ids":     []interface{}{childID},
+		"frequency":     "daily",
+		"due_time":      "20:00",
+	}
+	code, _ = api.request("POST", "/api/v1/task-series", childToken, series)
+	assert.Equal(t, http.StatusForbidden, code)
+	series["weekdays"] = "MO"
+	code, _ = api.request("POST", "/api/v1/task-series", parentToken, series)
+	assert.Equal(t, http.StatusBadRequest, code)
+	delete(series, "weekdays")
+
+	// 每天重复的任务创建后立即生成今天的实例
+	code, response = api.request("POST", "/api/v1/task-series", parentToken, series)
+	require.Equal(t, http.StatusCreated, code, response)
+	seriesID := data(response)["id"]
+	today := time.Now().UTC().Format("2006-01-02")
+
+	code, response = api.request("GET", "/api/v1/tasks", childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	tasks := response["data"].([]interface{})
+	require.Len(t, tasks, 1)
+	task := tasks[0].(map[string]interface{})
+	assert.Equal(t, seriesID, task["series_id"])
+	assert.Equal(t, "in_progress", task["status"])
+	assert.Contains(t, task["due_date"], today+"T20:00:00")
+
+	// 详情返回之后几次出现的日期，不包含已经生成的今天
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/task-series/%v", seriesID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	next := data(response)["next_occurrences"].([]interface{})
+	require.Len(t, next, 5)
+	assert.Equal(t, time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02"), next[0])
+
+	// 修改模板不影响已经生成的任务
+	code, _ = api.request("PUT", fmt.Sprintf("/api/v1/task-series/%v", seriesID), parentToken, map[string]interface{}{"title": "Tidy your room"})
+	require.Equal(t, http.StatusOK, code)
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/tasks/%v", task["id"]), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Make your bed", data(response)["title"])
+
+	// 跳过今天会删除还没有提交的实例
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/task-series/%v/skip", seriesID), parentToken, map[string]interface{}{"date": today})
+	require.Equal(t, http.StatusOK, code)
+	code, response = api.request("GET", "/api/v1/tasks", childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 0)
+
+	// 暂停、恢复和结束
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/task-series/%v/pause", seriesID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.TaskSeriesStatusPaused, data(response)["status"])
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/task-series/%v/pause", seriesID), parentToken, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/task-series/%v/resume", seriesID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, response = api.request("DELETE", fmt.Sprintf("/api/v1/task-series/%v", seriesID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.TaskSeriesStatusEnded, data(response)["status"])
+	code, _ = api.request("PUT", fmt.Sprintf("/api/v1/task-series/%v", seriesID), parentToken, map[string]interface{}{"title": "Again"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, response = api.request("GET", "/api/v1/task-series", parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 1)
+}
 
-	// Assert response
-	assert.Nil(t, err)
-	assert.Equal(t, true, response["success"])
-	assert.NotNil(t, response["child"])
+func TestTaskTemplates(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKeys := []*ecdsa.PrivateKey{newKey(t), newKey(t)}
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Template Family"})
+	require.Equal(t, http.StatusCreated, code)
+	var childIDs []interface{}
+	for _, key := range childKeys {
+		code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+			"name":           "Test Child",
+			"age":            10,
+			"wallet_address": crypto.PubkeyToAddress(key.PublicKey).Hex(),
+		})
+		require.Equal(t, http.StatusCreated, code)
+		childIDs = append(childIDs, data(response)["id"])
+	}
+	childToken := api.login(childKeys[0], "child")
+
+	// 内置模板对所有家长可见，孩子不能使用模板
+	code, _ = api.request("GET", "/api/v1/task-templates", childToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, response := api.request("GET", "/api/v1/task-templates", parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	catalog := map[string]map[string]interface{}{}
+	for _, item := range response["data"].([]interface{}) {
+		template := item.(map[string]interface{})
+		catalog[template["catalog_key"].(string)] = template
+	}
+	makeBed := catalog["make-bed"]
+	require.NotNil(t, makeBed)
+	assert.Equal(t, models.ProofTypePhoto, makeBed["proof_type"])
+	code, _ = api.request("DELETE", fmt.Sprintf("/api/v1/task-templates/%v", makeBed["id"]), parentToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 一次为两个孩子创建任务
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/task-templates/%v/assign", makeBed["id"]), parentToken, map[string]interface{}{
+		"child_ids": childIDs,
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	tasks := response["data"].([]interface{})
+	require.Len(t, tasks, 2)
+	task := tasks[0].(map[string]interface{})
+	assert.Equal(t, "in_progress", task["status"])
+	assert.Equal(t, makeBed["id"], task["template_id"])
+
+	// 照片凭证必须是图片地址
+	taskPath := fmt.Sprintf("/api/v1/tasks/%v/complete", task["id"])
+	code, _ = api.request("POST", taskPath, childToken, map[string]interface{}{"completion_proof": "done"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, response = api.request("POST", taskPath, childToken, map[string]interface{}{"completion_proof": "/uploads/images/bed.png"})
+	require.Equal(t, http.StatusOK, code, response)
+
+	// 把家庭的任务保存为模板，然后修改和删除
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":         "Walk the dog",
+		"description":   "Around the block",
+		"reward_amount": "0.01",
+		"difficulty":    "easy",
+		"proof_type":    "none",
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	code, response = api.request("POST", "/api/v1/task-templates", parentToken, map[string]interface{}{
+		"task_id":  data(response)["id"],
+		"category": "pets",
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	templateID := data(response)["id"]
+	assert.Equal(t, "Walk the dog", data(response)["title"])
+	assert.Equal(t, models.ProofTypeNone, data(response)["proof_type"])
+
+	code, response = api.request("PUT", fmt.Sprintf("/api/v1/task-templates/%v", templateID), parentToken, map[string]interface{}{"reward_amount": "0.02"})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "0.02", data(response)["reward_amount"])
+
+	// 不需要凭证的任务可以直接提交
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/task-templates/%v/assign", templateID), parentToken, map[string]interface{}{
+		"child_ids": childIDs[:1],
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	task = response["data"].([]interface{})[0].(map[string]interface{})
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/complete", task["id"]), childToken, map[string]interface{}{})
+	require.Equal(t, http.StatusOK, code, response)
+
+	code, _ = api.request("DELETE", fmt.Sprintf("/api/v1/task-templates/%v", templateID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/task-templates/%v", templateID), parentToken, nil)
+	assert.Equal(t, http.StatusNotFound, code)
 }
 
-// Test create task
-func TestCreateTask(t *testing.T) {
-	router := setupTestRouter()
+func TestTaskExpiry(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	code, response := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Expiry Family"})
+	require.Equal(t, http.StatusCreated, code)
+	familyID := data(response)["id"]
+	code, response = api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	// 迟交的任务奖励减半
+	familyPath := fmt.Sprintf("/api/v1/families/%v", familyID)
+	code, _ = api.request("PUT", familyPath, parentToken, map[string]interface{}{"late_penalty_percent": 101})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, response = api.request("PUT", familyPath, parentToken, map[string]interface{}{
+		"allow_late_submissions": true,
+		"late_penalty_percent":   50,
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, true, data(response)["allow_late_submissions"])
+
+	createTask := func() interface{} {
+		code, response := api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+			"title":             "Test Task",
+			"description":       "This is a test task",
+			"reward_amount":     "0.01",
+			"difficulty":        "easy",
+			"assigned_child_id": childID,
+			"due_date":          time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		})
+		require.Equal(t, http.StatusCreated, code, response)
+		return data(response)["id"]
+	}
+	expireOverdue := func() {
+		expiry := services.NewTaskExpiryService(repository.NewTaskRepository(api.db), repository.NewFamilyRepository(api.db))
+		_, err := expiry.ExpireOverdue(time.Now())
+		require.NoError(t, err)
+	}
 
-	// Create test token (would normally come from login)
-	token := "test-token"
+	lateTaskID := createTask()
+	expireOverdue()
+
+	// 孩子能看到任务过期的原因和减少后的奖励
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/tasks/%v", lateTaskID), childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	task := data(response)
+	assert.Equal(t, models.TaskStatusExpired, task["status"])
+	assert.Equal(t, "0.005", task["reward_amount"])
+	assert.Equal(t, "0.01", task["original_reward_amount"])
+	assert.Contains(t, task["expiry_reason"], "50% less reward")
+
+	// 迟交后按减少的奖励铸币
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/complete", lateTaskID), childToken, map[string]interface{}{
+		"completion_proof": "Done late",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/approve", lateTaskID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	var mint models.OutboxMessage
+	require.NoError(t, api.db.First(&mint).Error)
+	assert.Equal(t, "50000000000000000000", mint.Amount)
+
+	// 不允许迟交时过期的任务不能提交
+	code, _ = api.request("PUT", familyPath, parentToken, map[string]interface{}{"allow_late_submissions": false})
+	require.Equal(t, http.StatusOK, code)
+	closedTaskID := createTask()
+	expireOverdue()
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/tasks/%v", closedTaskID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "0.01", data(response)["reward_amount"])
+	assert.Contains(t, data(response)["expiry_reason"], "Late submissions are not accepted")
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/complete", closedTaskID), childToken, map[string]interface{}{
+		"completion_proof": "Done late",
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
 
-	// Create task data
-	taskData := map[string]interface{}{
-		"title":       "Test Task",
-		"description": "This is a test task",
-		"reward":      "0.01",
-		"familyId":    1,
+func TestPartialApprovalAndRework(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Rework Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Test Task",
+		"description":       "This is a test task",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": childID,
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	taskPath := fmt.Sprintf("/api/v1/tasks/%v", data(response)["id"])
+	submit := func() {
+		code, response := api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{
+			"completion_proof": "Done",
+		})
+		require.Equal(t, http.StatusOK, code, response)
 	}
 
-	jsonData, _ := json.Marshal(taskData)
+	// 退回重做必须说明原因，任务回到进行中
+	submit()
+	code, _ = api.request("POST", taskPath+"/reject", parentToken, map[string]interface{}{"rework": true})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, response = api.request("POST", taskPath+"/reject", parentToken, map[string]interface{}{
+		"rework": true,
+		"reason": "The toys are still on the floor",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	task := data(response)
+	assert.Equal(t, "in_progress", task["status"])
+	assert.EqualValues(t, 1, task["rework_count"])
+	assert.Equal(t, "The toys are still on the floor", task["rejection_reason"])
+	assert.Nil(t, task["rejected_at"])
+
+	// 部分批准：参数无效或没有原因时返回400
+	submit()
+	for _, body := range []map[string]interface{}{
+		{"percent": 50},
+		{"percent": 101, "reason": "Half done"},
+		{"reward_amount": "0.02", "reason": "Too much"},
+		{"percent": 50, "reward_amount": "0.005", "reason": "Both"},
+	} {
+		code, _ = api.request("POST", taskPath+"/approve", parentToken, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
 
-	// Create request
-	req, _ := http.NewRequest("POST", "/api/v1/tasks", bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+	code, response = api.request("POST", taskPath+"/approve", parentToken, map[string]interface{}{
+		"percent": 50,
+		"reason":  "Only half of the room was tidied",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	task = data(response)
+	assert.Equal(t, "approved", task["status"])
+	assert.Equal(t, "0.01", task["reward_amount"])
+	assert.Equal(t, "0.005", task["approved_reward_amount"])
+	assert.Equal(t, "Only half of the room was tidied", task["approval_reason"])
+
+	// 铸币数量按实际发放的奖励计算；累计奖励以账本为准，铸币同步之前进度中为0
+	var mint models.OutboxMessage
+	require.NoError(t, api.db.First(&mint).Error)
+	assert.Equal(t, "50000000000000000000", mint.Amount)
+	var child models.Child
+	require.NoError(t, api.db.First(&child, childID).Error)
+	assert.Equal(t, 1, child.TotalTasksCompleted)
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/children/%v/progress", childID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "0", data(response)["total_rewards_earned"])
+	assert.Equal(t, "0", data(response)["tokens_earned"])
+}
 
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+func TestTaskHistory(t *testing.T) {
+	api := setupTestAPI(t)
+	parentKey := newKey(t)
+	parentToken := api.login(parentKey, "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	otherToken := api.login(newKey(t), "parent")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "History Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":         "Test Task",
+		"description":   "This is a test task",
+		"reward_amount": "0.01",
+		"difficulty":    "easy",
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	taskPath := fmt.Sprintf("/api/v1/tasks/%v", data(response)["id"])
+
+	code, _ = api.request("POST", taskPath+"/direct-assign", parentToken, map[string]interface{}{
+		"assigned_child_id": fmt.Sprint(childID),
+	})
+	require.Equal(t, http.StatusOK, code)
+
+	// 两次提交的凭证都保留在历史中，第一次被退回重做
+	code, _ = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{
+		"completion_proof": "First try",
+		"tx_hash":          "not-a-hash",
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+	completeTx := "0x" + strings.Repeat("ab", 32)
+	code, _ = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{
+		"completion_proof": "First try",
+		"tx_hash":          completeTx,
+	})
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", taskPath+"/reject", parentToken, map[string]interface{}{
+		"rework": true,
+		"reason": "Please finish the shelves",
+	})
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{
+		"completion_proof": "Second try",
+	})
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", taskPath+"/approve", parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	code, response = api.request("GET", taskPath+"/history", childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	events := response["data"].([]interface{})
+	actions := make([]string, 0, len(events))
+	for _, event := range events {
+		actions = append(actions, event.(map[string]interface{})["action"].(string))
+	}
+	assert.Equal(t, []string{"created", "assigned", "submitted", "rework_requested", "submitted", "approved"}, actions)
+
+	parentWallet := strings.ToLower(crypto.PubkeyToAddress(parentKey.PublicKey).Hex())
+	first := events[2].(map[string]interface{})
+	assert.Equal(t, "First try", first["proof"])
+	assert.Equal(t, completeTx, first["tx_hash"])
+	assert.Equal(t, "in_progress", first["from_status"])
+	assert.Equal(t, "completed", first["to_status"])
+	rework := events[3].(map[string]interface{})
+	assert.Equal(t, "Please finish the shelves", rework["reason"])
+	assert.Equal(t, parentWallet, rework["actor"])
+	assert.Equal(t, "Second try", events[4].(map[string]interface{})["proof"])
+	assert.Equal(t, "0.01", events[5].(map[string]interface{})["reward_amount"])
+
+	// 其他家庭的家长不能查看
+	code, _ = api.request("GET", taskPath+"/history", otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+}
 
-	// Assert status code
-	assert.Equal(t, http.StatusCreated, w.Code)
+func TestTaskChecklist(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Checklist Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	// 没有步骤时不能要求完成清单
+	code, _ = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":              "Clean your room",
+		"description":        "Make it spotless",
+		"reward_amount":      "0.01",
+		"difficulty":         "medium",
+		"checklist_required": true,
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":              "Clean your room",
+		"description":        "Make it spotless",
+		"reward_amount":      "0.01",
+		"difficulty":         "medium",
+		"proof_type":         "none",
+		"assigned_child_id":  childID,
+		"checklist_required": true,
+		"checklist": []map[string]interface{}{
+			{"title": "Make the bed", "proof_type": "photo"},
+			{"title": "Put toys away"},
+			{"title": "Dust the shelves", "required": false},
+		},
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	task := data(response)
+	taskPath := fmt.Sprintf("/api/v1/tasks/%v", task["id"])
+	checklist := task["checklist"].([]interface{})
+	require.Len(t, checklist, 3)
+	itemPath := func(i int) string {
+		return fmt.Sprintf("%s/checklist/%v", taskPath, checklist[i].(map[string]interface{})["id"])
+	}
+	assert.Equal(t, "Make the bed", checklist[0].(map[string]interface{})["title"])
+	assert.Equal(t, false, checklist[2].(map[string]interface{})["required"])
+
+	// 必做的步骤没有全部勾选时不能提交
+	code, response = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, response["error"], "2 remaining")
+
+	// 需要照片的步骤必须带照片地址
+	code, _ = api.request("POST", itemPath(0)+"/check", childToken, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = api.request("POST", itemPath(0)+"/check", parentToken, map[string]interface{}{"proof_url": "/uploads/bed.jpg"})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, response = api.request("POST", itemPath(0)+"/check", childToken, map[string]interface{}{"proof_url": "/uploads/bed.jpg"})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "/uploads/bed.jpg", data(response)["proof_url"])
+	assert.NotEmpty(t, data(response)["checked_at"])
+
+	code, _ = api.request("POST", itemPath(1)+"/check", childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", itemPath(1)+"/uncheck", childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 家长调整清单：保留ID的步骤保留勾选状态，删除的步骤不再要求
+	code, response = api.request("PUT", taskPath+"/checklist", parentToken, map[string]interface{}{
+		"items": []map[string]interface{}{
+			{"id": checklist[0].(map[string]interface{})["id"], "title": "Make the bed", "proof_type": "photo"},
+			{"title": "Open the window", "required": false},
+		},
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	updated := data(response)["checklist"].([]interface{})
+	require.Len(t, updated, 2)
+	assert.NotEmpty(t, updated[0].(map[string]interface{})["checked_at"])
+	assert.Equal(t, "Open the window", updated[1].(map[string]interface{})["title"])
+	assert.Equal(t, true, data(response)["checklist_required"])
+
+	code, response = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{})
+	require.Equal(t, http.StatusOK, code, response)
+
+	// 提交后不能再修改勾选
+	code, _ = api.request("POST", itemPath(0)+"/uncheck", childToken, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, response = api.request("GET", taskPath, parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, data(response)["checklist"], 2)
+}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+func TestTaskComments(t *testing.T) {
+	api := setupTestAPI(t)
+	parentKey := newKey(t)
+	parentToken := api.login(parentKey, "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	otherToken := api.login(newKey(t), "parent")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Comment Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Test Task",
+		"description":       "This is a test task",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": childID,
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	taskID := data(response)["id"]
+	commentsPath := fmt.Sprintf("/api/v1/tasks/%v/comments", taskID)
+
+	unread := func(token string) float64 {
+		code, response := api.request("GET", "/api/v1/tasks/unread-comments", token, nil)
+		require.Equal(t, http.StatusOK, code, response)
+		return data(response)["total"].(float64)
+	}
 
-	// Assert response
-	assert.Nil(t, err)
-	assert.Equal(t, true, response["success"])
-	assert.NotNil(t, response["task"])
+	code, response = api.request("POST", commentsPath, childToken, map[string]interface{}{
+		"body": "Do I need to vacuum too?",
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	assert.Equal(t, "child", data(response)["author_role"])
+	childCommentID := data(response)["id"]
+
+	// 自己的留言不算未读
+	assert.Equal(t, float64(1), unread(parentToken))
+	assert.Equal(t, float64(0), unread(childToken))
+
+	code, _ = api.request("POST", commentsPath, parentToken, map[string]interface{}{
+		"body":      "Yes, like this",
+		"image_url": "javascript:alert(1)",
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, response = api.request("POST", commentsPath, parentToken, map[string]interface{}{
+		"body":      "Yes, like this",
+		"image_url": "/uploads/vacuum.jpg",
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	assert.Equal(t, "parent", data(response)["author_role"])
+	assert.Equal(t, "/uploads/vacuum.jpg", data(response)["image_url"])
+
+	// 回复时之前的留言也算已读
+	assert.Equal(t, float64(0), unread(parentToken))
+	assert.Equal(t, float64(1), unread(childToken))
+
+	code, response = api.request("GET", commentsPath, childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 2)
+	assert.Equal(t, float64(1), unread(childToken))
+
+	code, _ = api.request("POST", commentsPath+"/read", childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), unread(childToken))
+
+	// 其他家庭的家长不能查看或发表留言
+	code, _ = api.request("GET", commentsPath, otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("POST", commentsPath, otherToken, map[string]interface{}{"body": "Hello"})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, float64(0), unread(otherToken))
+
+	// 只有作者可以删除留言
+	code, _ = api.request("DELETE", fmt.Sprintf("%s/%v", commentsPath, childCommentID), parentToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("DELETE", fmt.Sprintf("%s/%v", commentsPath, childCommentID), childToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, response = api.request("GET", commentsPath, parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["data"], 1)
 }
 
-// Test get tasks by family
-func TestGetTasksByFamily(t *testing.T) {
-	router := setupTestRouter()
-
-	// Create test token (would normally come from login)
-	token := "test-token"
+// sseEvent is one message read from the event stream
+type sseEvent struct {
+	event string
+	data  map[string]interface{}
+}
 
-	// Create request
-	req, _ := http.NewRequest("GET", "/api/v1/families/1/tasks", nil)
+// openEventStream connects to the event stream and returns the messages it receives
+func openEventStream(t *testing.T, server *httptest.Server, token string) <-chan sseEvent {
+	req, err := http.NewRequest("GET", server.URL+"/api/v1/events", nil)
+	require.NoError(t, err)
 	req.Header.Set("Authorization", "Bearer "+token)
-
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
-
-	// Assert status code
-	assert.Equal(t, http.StatusOK, w.Code)
-
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-
-	// Assert response
-	assert.Nil(t, err)
-	assert.Equal(t, true, response["success"])
-	assert.NotNil(t, response["tasks"])
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var current sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				current.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.data)
+			case line == "" && current.event != "":
+				events <- current
+				current = sseEvent{}
+			}
+		}
+	}()
+	return events
 }
 
-// Test assign task to child
-func TestAssignTask(t *testing.T) {
-	router := setupTestRouter()
-
-	// Create test token (would normally come from login)
-	token := "test-token"
+// nextEvent waits for the next message on the stream
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "event stream closed")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return sseEvent{}
+	}
+}
 
-	// Create assignment data
-	assignData := map[string]interface{}{
-		"childId": 1,
+func TestRealtimeEvents(t *testing.T) {
+	api := setupTestAPI(t)
+	server := httptest.NewServer(api.router)
+	t.Cleanup(server.Close)
+
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	siblingKey := newKey(t)
+	siblingToken := api.login(siblingKey, "child")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Realtime Family"})
+	require.Equal(t, http.StatusCreated, code)
+	childIDs := make([]interface{}, 0, 2)
+	for _, key := range []*ecdsa.PrivateKey{childKey, siblingKey} {
+		code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+			"name":           "Test Child",
+			"age":            10,
+			"wallet_address": crypto.PubkeyToAddress(key.PublicKey).Hex(),
+		})
+		require.Equal(t, http.StatusCreated, code, response)
+		childIDs = append(childIDs, data(response)["id"])
 	}
 
-	jsonData, _ := json.Marshal(assignData)
+	// 未登录不能订阅
+	resp, err := server.Client().Get(server.URL + "/api/v1/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	parentEvents := openEventStream(t, server, parentToken)
+	childEvents := openEventStream(t, server, childToken)
+	siblingEvents := openEventStream(t, server, siblingToken)
+	for _, events := range []<-chan sseEvent{parentEvents, childEvents, siblingEvents} {
+		require.Equal(t, "ready", nextEvent(t, events).event)
+	}
 
-	// Create request
-	req, _ := http.NewRequest("PUT", "/api/v1/tasks/1/assign", bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+	code, response := api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Test Task",
+		"description":       "This is a test task",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": childIDs[0],
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	taskID := data(response)["id"]
+
+	for _, events := range []<-chan sseEvent{parentEvents, childEvents} {
+		event := nextEvent(t, events)
+		assert.Equal(t, "task.assigned", event.event)
+		assert.Equal(t, taskID, event.data["data"].(map[string]interface{})["task_id"])
+	}
 
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/complete", taskID), childToken, map[string]interface{}{
+		"completion_proof": "Done",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	event := nextEvent(t, parentEvents)
+	assert.Equal(t, "task.submitted", event.event)
+	assert.Equal(t, "completed", event.data["data"].(map[string]interface{})["status"])
+	assert.Equal(t, "task.submitted", nextEvent(t, childEvents).event)
+
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/reject", taskID), parentToken, map[string]interface{}{
+		"reason": "Not done yet",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	event = nextEvent(t, childEvents)
+	assert.Equal(t, "task.rejected", event.event)
+	assert.Equal(t, "Not done yet", event.data["data"].(map[string]interface{})["reason"])
+
+	// 兄弟姐妹收不到别人的任务事件，第一条收到的是分配给自己的任务
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Sibling Task",
+		"description":       "This is a test task",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": childIDs[1],
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	event = nextEvent(t, siblingEvents)
+	assert.Equal(t, "task.assigned", event.event)
+	assert.Equal(t, data(response)["id"], event.data["data"].(map[string]interface{})["task_id"])
+}
 
-	// Assert status code
-	assert.Equal(t, http.StatusOK, w.Code)
+// webhookRequest is a request received by the test webhook receiver
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+func TestWebhooks(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	otherToken := api.login(newKey(t), "parent")
+
+	// 本地接收方，status为下一次返回的状态码
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	received := make(chan webhookRequest, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- webhookRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Webhook Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	code, _ = api.request("POST", "/api/v1/webhooks", parentToken, map[string]interface{}{"url": "ftp://example.com/hook"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = api.request("POST", "/api/v1/webhooks", parentToken, map[string]interface{}{"url": receiver.URL, "events": "task.unknown"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = api.request("POST", "/api/v1/webhooks", childToken, map[string]interface{}{"url": receiver.URL})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, response = api.request("POST", "/api/v1/webhooks", parentToken, map[string]interface{}{
+		"url":         receiver.URL,
+		"description": "Home automation",
+		"events":      "task.assigned, task.approved,task.assigned",
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	webhook := data(response)
+	secret := webhook["secret"].(string)
+	assert.True(t, strings.HasPrefix(secret, "whsec_"))
+	assert.Equal(t, "task.assigned,task.approved", webhook["events"])
+	assert.Equal(t, true, webhook["active"])
+	webhookPath := fmt.Sprintf("/api/v1/webhooks/%v", webhook["id"])
+
+	// 密钥只在创建时返回
+	code, response = api.request("GET", webhookPath, parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, data(response), "secret")
+	code, _ = api.request("GET", webhookPath, otherToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	verify := func(request webhookRequest) map[string]interface{} {
+		var timestamp int64
+		var signature string
+		_, err := fmt.Sscanf(strings.Replace(request.header.Get("X-FamilyChain-Signature"), ",v1=", " ", 1), "t=%d %s", &timestamp, &signature)
+		require.NoError(t, err)
+		assert.Equal(t, utils.WebhookSignature(secret, timestamp, request.body), signature)
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(request.body, &payload))
+		assert.Equal(t, payload["type"], request.header.Get("X-FamilyChain-Event"))
+		assert.Equal(t, payload["id"], request.header.Get("X-FamilyChain-Delivery"))
+		return payload
+	}
 
-	// Assert response
-	assert.Nil(t, err)
-	assert.Equal(t, true, response["success"])
+	// 测试事件立即发送
+	code, response = api.request("POST", webhookPath+"/test", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "delivered", data(response)["status"])
+	assert.Equal(t, float64(http.StatusOK), data(response)["response_code"])
+	assert.Equal(t, "webhook.test", verify(<-received)["type"])
+
+	// 接收方返回错误时重试，直到成功
+	status.Store(http.StatusInternalServerError)
+	code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+		"title":             "Test Task",
+		"description":       "This is a test task",
+		"reward_amount":     "0.01",
+		"difficulty":        "easy",
+		"assigned_child_id": childID,
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	taskID := data(response)["id"]
+
+	api.webhooks.DispatchDue(context.Background())
+	first := verify(<-received)
+	assert.Equal(t, "task.assigned", first["type"])
+	assert.Equal(t, taskID, first["data"].(map[string]interface{})["task_id"])
+
+	status.Store(http.StatusOK)
+	api.webhooks.DispatchDue(context.Background())
+	assert.Equal(t, first["id"], verify(<-received)["id"])
+
+	// 没有订阅的事件不投递
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/complete", taskID), childToken, map[string]interface{}{
+		"completion_proof": "Done",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	api.webhooks.DispatchDue(context.Background())
+	assert.Len(t, received, 0)
+
+	code, response = api.request("GET", webhookPath+"/deliveries", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	deliveries := response["data"].([]interface{})
+	require.Len(t, deliveries, 2)
+	latest := deliveries[0].(map[string]interface{})
+	assert.Equal(t, "task.assigned", latest["event_type"])
+	assert.Equal(t, "delivered", latest["status"])
+	assert.Equal(t, float64(2), latest["attempts"])
+	assert.Equal(t, float64(http.StatusOK), latest["response_code"])
+
+	// 停用后不再投递
+	code, response = api.request("PUT", webhookPath, parentToken, map[string]interface{}{"active": false})
+	require.Equal(t, http.StatusOK, code, response)
+	code, _ = api.request("POST", fmt.Sprintf("/api/v1/tasks/%v/approve", taskID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	api.webhooks.DispatchDue(context.Background())
+	assert.Len(t, received, 0)
+
+	code, _ = api.request("DELETE", webhookPath, parentToken, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = api.request("GET", webhookPath, parentToken, nil)
+	assert.Equal(t, http.StatusNotFound, code)
 }
 
-// Test complete task
-func TestCompleteTask(t *testing.T) {
-	router := setupTestRouter()
+// smtpMessage is a message accepted by the test SMTP server
+type smtpMessage struct {
+	to   string
+	data string
+}
 
-	// Create test token (would normally come from login)
-	token := "test-token"
+// startSMTPServer runs a minimal SMTP server on a random local port that accepts every message
+func startSMTPServer(t *testing.T) (int, <-chan smtpMessage) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan smtpMessage, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+				reply("220 localhost ESMTP")
+
+				var message smtpMessage
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+						reply("250 localhost")
+					case strings.HasPrefix(command, "RCPT TO:"):
+						message.to = strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+						reply("250 OK")
+					case command == "DATA":
+						reply("354 End data with <CR><LF>.<CR><LF>")
+						var data strings.Builder
+						for {
+							line, err := reader.ReadString('\n')
+							if err != nil {
+								return
+							}
+							if line == ".\r\n" {
+								break
+							}
+							data.WriteString(line)
+						}
+						message.data = data.String()
+						messages <- message
+						message = smtpMessage{}
+						reply("250 OK")
+					case command == "QUIT":
+						reply("221 Bye")
+						return
+					default:
+						reply("250 OK")
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, messages
+}
 
-	// Create completion data
-	completeData := map[string]interface{}{
-		"proof": "Task completed proof",
+// Test notifications: the in-app inbox, per-type channel preferences and emails sent through a local SMTP server
+func TestNotifications(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	port, messages := startSMTPServer(t)
+	smtpConfig := api.cfg.Notification.SMTP
+	smtpConfig.Port = port
+	emails := services.NewEmailDispatcher(repository.NewNotificationRepository(api.db), services.NewSMTPMailer(smtpConfig), api.cfg.Notification)
+
+	code, response := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Notification Family"})
+	require.Equal(t, http.StatusCreated, code, response)
+	familyID := data(response)["id"]
+	code, response = api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	childID := data(response)["id"]
+
+	// 默认所有渠道都开启
+	code, response = api.request("GET", "/api/v1/notifications/preferences", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, true, data(response)["email_enabled"])
+	assert.Nil(t, data(response)["email"])
+	preferences := data(response)["preferences"].([]interface{})
+	require.Len(t, preferences, len(models.NotificationTypes))
+	for _, preference := range preferences {
+		assert.Equal(t, true, preference.(map[string]interface{})["in_app"])
+		assert.Equal(t, true, preference.(map[string]interface{})["email"])
 	}
 
-	jsonData, _ := json.Marshal(completeData)
+	code, _ = api.request("PUT", "/api/v1/notifications/preferences", parentToken, map[string]interface{}{"email": "not an email"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = api.request("PUT", "/api/v1/notifications/preferences", parentToken, map[string]interface{}{
+		"preferences": []map[string]interface{}{{"type": "task_unknown", "in_app": true}},
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 家长不接收兑换申请的邮件，孩子不接收批准的站内通知
+	code, response = api.request("PUT", "/api/v1/notifications/preferences", parentToken, map[string]interface{}{
+		"email":       "parent@example.com",
+		"preferences": []map[string]interface{}{{"type": "exchange_requested", "in_app": true, "email": false}},
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "parent@example.com", data(response)["email"])
+	code, response = api.request("PUT", "/api/v1/notifications/preferences", childToken, map[string]interface{}{
+		"preferences": []map[string]interface{}{{"type": "task_approved", "in_app": false, "email": false}},
+	})
+	require.Equal(t, http.StatusOK, code, response)
+
+	createTask := func(title string) string {
+		code, response := api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+			"title":             title,
+			"description":       "This is a test task",
+			"reward_amount":     "0.01",
+			"difficulty":        "easy",
+			"assigned_child_id": childID,
+		})
+		require.Equal(t, http.StatusCreated, code, response)
+		taskPath := fmt.Sprintf("/api/v1/tasks/%v", data(response)["id"])
+		code, response = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{
+			"completion_proof": "Done",
+		})
+		require.Equal(t, http.StatusOK, code, response)
+		return taskPath
+	}
 
-	// Create request
-	req, _ := http.NewRequest("PUT", "/api/v1/tasks/1/complete", bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+	// 提交任务时通知家长，邮件在后台发送
+	approvedPath := createTask("Make the bed")
+	code, response = api.request("GET", "/api/v1/notifications", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(1), data(response)["unread"])
+	inbox := data(response)["notifications"].([]interface{})
+	require.Len(t, inbox, 1)
+	submitted := inbox[0].(map[string]interface{})
+	assert.Equal(t, "task_submitted", submitted["type"])
+	assert.Contains(t, submitted["body"], "Test Child submitted \"Make the bed\"")
+
+	emails.DispatchDue(context.Background())
+	message := <-messages
+	assert.Equal(t, "parent@example.com", message.to)
+	assert.Contains(t, message.data, "Subject: Family Task Chain: Task submitted for review")
+	assert.Contains(t, message.data, "Make the bed")
+
+	// 孩子关闭了批准的站内通知，拒绝的通知带原因
+	code, _ = api.request("POST", approvedPath+"/approve", parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, response = api.request("POST", createTask("Feed the cat")+"/reject", parentToken, map[string]interface{}{
+		"reason": "The bowl is still empty",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	code, response = api.request("GET", "/api/v1/notifications", childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	inbox = data(response)["notifications"].([]interface{})
+	require.Len(t, inbox, 1)
+	rejected := inbox[0].(map[string]interface{})
+	assert.Equal(t, "task_rejected", rejected["type"])
+	assert.Contains(t, rejected["body"], "The bowl is still empty")
+	emails.DispatchDue(context.Background())
+	assert.Contains(t, (<-messages).data, "Feed the cat")
+
+	// 兑换后库存降到阈值，家长收到兑换申请和库存不足两条通知，只有库存不足发送邮件
+	code, response = api.request("POST", fmt.Sprintf("/api/v1/rewards/family/%v", familyID), parentToken, map[string]interface{}{
+		"name": "Ice cream", "image_url": "/uploads/ice-cream.png", "token_price": 10, "stock": 2,
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	api.mint(crypto.PubkeyToAddress(childKey.PublicKey).Hex(), 10)
+	code, response = api.request("POST", "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id": data(response)["id"],
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+
+	emails.DispatchDue(context.Background())
+	message = <-messages
+	assert.Contains(t, message.data, "Subject: Family Task Chain: Reward running low")
+	assert.Len(t, messages, 0)
+
+	code, response = api.request("GET", "/api/v1/notifications?unread=true", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(4), data(response)["unread"])
+	inbox = data(response)["notifications"].([]interface{})
+	require.Len(t, inbox, 4)
+	assert.Equal(t, "reward_low_stock", inbox[0].(map[string]interface{})["type"])
+	assert.Equal(t, "exchange_requested", inbox[1].(map[string]interface{})["type"])
+
+	// 库存不足只通知家长
+	code, response = api.request("GET", "/api/v1/notifications", childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Len(t, data(response)["notifications"], 1)
+
+	// 标记已读：只能标记自己的通知
+	readPath := fmt.Sprintf("/api/v1/notifications/%v/read", submitted["id"])
+	code, _ = api.request("POST", readPath, childToken, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, response = api.request("POST", readPath, parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.NotNil(t, data(response)["read_at"])
+
+	code, response = api.request("POST", "/api/v1/notifications/read-all", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(3), data(response)["marked"])
+	code, response = api.request("GET", "/api/v1/notifications?unread=true", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(0), data(response)["unread"])
+	assert.Len(t, data(response)["notifications"], 0)
+}
 
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+// Test the exchange approval workflow: pending exchanges hold stock until a parent approves or declines them,
+// only approved exchanges can be fulfilled, escrowed exchanges queue fulfill and refund transactions,
+// and approved exchanges that were only held in the ledger queue a burn
+func TestExchangeApproval(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	childWallet := strings.ToLower(crypto.PubkeyToAddress(childKey.PublicKey).Hex())
+
+	code, response := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Exchange Family"})
+	require.Equal(t, http.StatusCreated, code, response)
+	familyID := data(response)["id"]
+	code, response = api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": childWallet,
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+
+	createReward := func(body map[string]interface{}) uint {
+		code, response := api.request("POST", fmt.Sprintf("/api/v1/rewards/family/%v", familyID), parentToken, body)
+		require.Equal(t, http.StatusCreated, code, response)
+		return uint(data(response)["id"].(float64))
+	}
+	exchange := func(rewardID uint) string {
+		code, response := api.request("POST", "/api/v1/exchanges", childToken, map[string]interface{}{"reward_id": rewardID})
+		require.Equal(t, http.StatusCreated, code, response)
+		assert.Equal(t, "pending", data(response)["status"])
+		return fmt.Sprintf("/api/v1/exchanges/%v", data(response)["id"])
+	}
+	stock := func(rewardID uint) int {
+		var reward models.Reward
+		require.NoError(t, api.db.First(&reward, rewardID).Error)
+		return reward.Stock
+	}
 
-	// Assert status code
-	assert.Equal(t, http.StatusOK, w.Code)
+	// 只在后端登记的奖品：兑换时扣减库存，不托管代币
+	iceCream := createReward(map[string]interface{}{"name": "Ice cream", "image_url": "/uploads/ice-cream.png", "token_price": 10, "stock": 3})
+
+	// 账本中没有代币时不能兑换，客户端声称已经销毁代币也一样
+	for _, body := range []map[string]interface{}{
+		{"reward_id": iceCream},
+		{"reward_id": iceCream, "token_burned": true},
+	} {
+		code, response = api.request("POST", "/api/v1/exchanges", childToken, body)
+		assert.Equal(t, http.StatusBadRequest, code, response)
+		assert.Equal(t, "余额不足，无法兑换奖品", response["error"])
+	}
+	assert.Equal(t, 3, stock(iceCream))
+
+	// 三次兑换冰淇淋各10，其中一次被拒绝；两次兑换自行车各25，还有一张贴纸
+	api.mint(childWallet, 71)
+	approvedPath := exchange(iceCream)
+	assert.Equal(t, 2, stock(iceCream))
+
+	// 孩子不能审核，未批准的兑换不能交付
+	code, _ = api.request("POST", approvedPath+"/approve", childToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("POST", approvedPath+"/fulfill", parentToken, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, response = api.request("POST", approvedPath+"/approve", parentToken, map[string]interface{}{"notes": "Enjoy"})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "approved", data(response)["status"])
+	assert.Equal(t, "Enjoy", data(response)["notes"])
+	assert.NotNil(t, data(response)["decided_by"])
+	assert.NotNil(t, data(response)["decided_at"])
+	code, _ = api.request("POST", approvedPath+"/decline", parentToken, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, response = api.request("POST", approvedPath+"/fulfill", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "fulfilled", data(response)["status"])
+	assert.NotNil(t, data(response)["completed_date"])
+
+	// 拒绝后库存恢复
+	declinedPath := exchange(iceCream)
+	assert.Equal(t, 1, stock(iceCream))
+	code, response = api.request("POST", declinedPath+"/decline", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "declined", data(response)["status"])
+	assert.Equal(t, 2, stock(iceCream))
+
+	// 旧接口：completed表示批准并交付
+	legacyPath := exchange(iceCream)
+	code, response = api.request("PUT", legacyPath+"/status", parentToken, map[string]interface{}{"status": "completed"})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "fulfilled", data(response)["status"])
+
+	messages := func(path string) []models.OutboxMessage {
+		var exchangeID uint
+		_, err := fmt.Sscanf(path, "/api/v1/exchanges/%d", &exchangeID)
+		require.NoError(t, err)
+		var msgs []models.OutboxMessage
+		require.NoError(t, api.db.Where("exchange_id = ?", exchangeID).Order("id").Find(&msgs).Error)
+		return msgs
+	}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+	// 没有托管的兑换只在账本中扣留代币：批准时从孩子的钱包销毁，拒绝时不上链
+	for _, path := range []string{approvedPath, legacyPath} {
+		msgs := messages(path)
+		require.Len(t, msgs, 1, path)
+		assert.Equal(t, models.OutboxKindBurnExchange, msgs[0].Kind)
+		assert.Equal(t, childWallet, strings.ToLower(msgs[0].Recipient))
+		assert.Equal(t, utils.UnitsToTokenAmount(10).String(), msgs[0].Amount)
+	}
+	assert.Empty(t, messages(declinedPath))
+
+	// 登记在链上的奖品：兑换时托管代币，批准时销毁，拒绝时退还
+	contractRewardID := 7
+	bike := createReward(map[string]interface{}{
+		"name": "Bike ride", "image_url": "/uploads/bike.png", "token_price": 25, "stock": 5, "contract_reward_id": contractRewardID,
+	})
+
+	approvedPath = exchange(bike)
+	declinedPath = exchange(bike)
+	code, _ = api.request("POST", approvedPath+"/approve", parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = api.request("POST", declinedPath+"/decline", parentToken, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 4, stock(bike))
+
+	wantAmount := utils.UnitsToTokenAmount(25).String()
+	for path, settlement := range map[string]models.OutboxKind{
+		approvedPath: models.OutboxKindFulfillExchange,
+		declinedPath: models.OutboxKindRefundExchange,
+	} {
+		msgs := messages(path)
+		require.Len(t, msgs, 2, path)
+		assert.Equal(t, models.OutboxKindEscrowExchange, msgs[0].Kind)
+		assert.Equal(t, settlement, msgs[1].Kind)
+		for _, msg := range msgs {
+			assert.Equal(t, childWallet, strings.ToLower(msg.Recipient))
+			assert.Equal(t, wantAmount, msg.Amount)
+			assert.Equal(t, models.OutboxStatusPending, msg.Status)
+		}
+	}
 
-	// Assert response
-	assert.Nil(t, err)
-	assert.Equal(t, true, response["success"])
+	// 最后一件被兑换后再兑换返回409
+	lastOne := createReward(map[string]interface{}{"name": "Sticker", "image_url": "/uploads/sticker.png", "token_price": 1, "stock": 1})
+	exchange(lastOne)
+	code, response = api.request("POST", "/api/v1/exchanges", childToken, map[string]interface{}{"reward_id": lastOne})
+	assert.Equal(t, http.StatusConflict, code, response)
+	assert.Zero(t, stock(lastOne))
+
+	// 没有配置区块链时不能核对孩子提交的链上兑换
+	code, response = api.request("POST", "/api/v1/exchanges", childToken, map[string]interface{}{
+		"reward_id": bike, "contract_exchange_id": 1,
+	})
+	assert.Equal(t, http.StatusBadRequest, code, response)
 }
 
-// Test approve task
-func TestApproveTask(t *testing.T) {
-	router := setupTestRouter()
-
-	// Create test token (would normally come from login)
-	token := "test-token"
-
-	// Create request
-	req, _ := http.NewRequest("PUT", "/api/v1/tasks/1/approve", nil)
-	req.Header.Set("Authorization", "Bearer "+token)
+// Test the ledger endpoints without a blockchain connection: the ledger is empty and cannot be reconciled
+func TestTokenLedger(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	otherChildToken := api.login(newKey(t), "child")
+
+	code, response := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Ledger Family"})
+	require.Equal(t, http.StatusCreated, code, response)
+	familyID := data(response)["id"]
+	code, response = api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	ledgerPath := fmt.Sprintf("/api/v1/children/%v/ledger", data(response)["id"])
+
+	// 家长和孩子本人都可以查看，没有分录时余额为0
+	for _, token := range []string{parentToken, childToken} {
+		code, response = api.request("GET", ledgerPath, token, nil)
+		require.Equal(t, http.StatusOK, code, response)
+		assert.Equal(t, "0", data(response)["balance"])
+		assert.Equal(t, "0", data(response)["minted"])
+		assert.Empty(t, data(response)["entries"])
+	}
+	code, _ = api.request("GET", ledgerPath, otherChildToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	reconcilePath := fmt.Sprintf("/api/v1/families/%v/ledger/reconciliation", familyID)
+	code, _ = api.request("GET", reconcilePath, childToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("GET", reconcilePath, parentToken, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
 
-	// Perform request
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+// Test savings goals without a blockchain connection: locks are not checked against a ledger balance
+func TestSavingsGoals(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	otherChildToken := api.login(newKey(t), "child")
+
+	code, response := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Savings Family"})
+	require.Equal(t, http.StatusCreated, code, response)
+	familyID := data(response)["id"]
+	code, response = api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+	childID := data(response)["id"]
+
+	createReward := func(name string, price int) float64 {
+		code, response := api.request("POST", fmt.Sprintf("/api/v1/rewards/family/%v", familyID), parentToken, map[string]interface{}{
+			"name": name, "image_url": "/uploads/reward.png", "token_price": price, "stock": 1,
+		})
+		require.Equal(t, http.StatusCreated, code, response)
+		return data(response)["id"].(float64)
+	}
+	bike := createReward("Bike", 100)
+
+	// 只有孩子可以设置目标，同一个奖品只能有一个进行中的目标
+	code, _ = api.request("POST", "/api/v1/savings-goals", parentToken, map[string]interface{}{"reward_id": bike})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, response = api.request("POST", "/api/v1/savings-goals", childToken, map[string]interface{}{"reward_id": bike})
+	require.Equal(t, http.StatusCreated, code, response)
+	assert.Equal(t, "active", data(response)["status"])
+	goalPath := fmt.Sprintf("/api/v1/savings-goals/%v", data(response)["id"])
+	code, _ = api.request("POST", "/api/v1/savings-goals", childToken, map[string]interface{}{"reward_id": bike})
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = api.request("GET", goalPath, otherChildToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, response = api.request("POST", goalPath+"/lock", childToken, map[string]interface{}{"amount": 40})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(40), data(response)["locked_amount"])
+	code, _ = api.request("POST", goalPath+"/lock", childToken, map[string]interface{}{"amount": 70})
+	assert.Equal(t, http.StatusBadRequest, code, "the goal cannot hold more than the price")
+	code, _ = api.request("POST", goalPath+"/lock", parentToken, map[string]interface{}{"amount": 1})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 家长配捐孩子锁定的数量，代币通过outbox铸造给孩子
+	code, _ = api.request("POST", goalPath+"/contributions", childToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, response = api.request("POST", goalPath+"/contributions", parentToken, map[string]interface{}{"notes": "Matched"})
+	require.Equal(t, http.StatusCreated, code, response)
+	assert.Equal(t, float64(40), data(response)["amount"])
+	assert.NotNil(t, data(response)["outbox_message_id"])
+
+	// 没有铸币记录时没有预计完成日期
+	code, response = api.request("GET", goalPath+"/progress", childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(80), data(response)["percent"])
+	assert.Equal(t, utils.UnitsToTokenAmount(80).String(), data(response)["saved"])
+	assert.Nil(t, data(response)["estimated_completion"])
+
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/children/%v/savings-goals?active=true", childID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Len(t, response["data"], 1)
+
+	// 兑换目标奖品后目标结束
+	api.mint(crypto.PubkeyToAddress(childKey.PublicKey).Hex(), 100)
+	code, response = api.request("POST", "/api/v1/exchanges", childToken, map[string]interface{}{"reward_id": bike})
+	require.Equal(t, http.StatusCreated, code, response)
+	code, response = api.request("GET", goalPath, parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "redeemed", data(response)["status"])
+	assert.NotNil(t, data(response)["exchange_id"])
+	code, _ = api.request("DELETE", goalPath, childToken, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, response = api.request("POST", "/api/v1/savings-goals", childToken, map[string]interface{}{"reward_id": createReward("Kite", 20)})
+	require.Equal(t, http.StatusCreated, code, response)
+	kitePath := fmt.Sprintf("/api/v1/savings-goals/%v", data(response)["id"])
+	code, response = api.request("DELETE", kitePath, parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	code, response = api.request("GET", kitePath, childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "cancelled", data(response)["status"])
+}
 
-	// Assert status code
-	assert.Equal(t, http.StatusOK, w.Code)
+// Test reward proposals: a child proposes a wish, a parent turns it into a reward with a price or declines it with a note
+func TestRewardProposals(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	outsiderToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+	otherChildToken := api.login(newKey(t), "child")
+
+	code, response := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Wishlist Family"})
+	require.Equal(t, http.StatusCreated, code, response)
+	familyID := data(response)["id"]
+	code, response = api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code, response)
+
+	// 只有孩子可以提出愿望，名称必填
+	code, _ = api.request("POST", "/api/v1/reward-proposals", parentToken, map[string]interface{}{"name": "Bike"})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("POST", "/api/v1/reward-proposals", childToken, map[string]interface{}{"description": "No name"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	propose := func(name string) string {
+		code, response := api.request("POST", "/api/v1/reward-proposals", childToken, map[string]interface{}{
+			"name": name, "description": "I would really like this", "image_url": "/uploads/wish.png",
+		})
+		require.Equal(t, http.StatusCreated, code, response)
+		assert.Equal(t, "pending", data(response)["status"])
+		return fmt.Sprintf("/api/v1/reward-proposals/%v", data(response)["id"])
+	}
+	bikePath := propose("Bike")
+	kitePath := propose("Kite")
+
+	// 提出愿望时通知家长
+	code, response = api.request("GET", "/api/v1/notifications", parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	inbox := data(response)["notifications"].([]interface{})
+	require.Len(t, inbox, 2)
+	assert.Equal(t, "reward_proposed", inbox[0].(map[string]interface{})["type"])
+	assert.Contains(t, inbox[1].(map[string]interface{})["body"], "Test Child would like \"Bike\"")
+
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/families/%v/reward-proposals?status=pending", familyID), parentToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Len(t, response["data"], 2)
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/families/%v/reward-proposals?status=unknown", familyID), parentToken, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = api.request("GET", fmt.Sprintf("/api/v1/families/%v/reward-proposals", familyID), outsiderToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("GET", bikePath, otherChildToken, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// 家长定价并改名，愿望变成家庭的奖品
+	code, _ = api.request("POST", bikePath+"/accept", childToken, map[string]interface{}{"token_price": 50})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("POST", bikePath+"/accept", outsiderToken, map[string]interface{}{"token_price": 50})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = api.request("POST", bikePath+"/accept", parentToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusBadRequest, code, "a price is required")
+	code, response = api.request("POST", bikePath+"/accept", parentToken, map[string]interface{}{
+		"token_price": 50, "name": "Blue bike", "note": "For your birthday",
+	})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "accepted", data(response)["status"])
+	reward := data(response)["reward"].(map[string]interface{})
+	assert.Equal(t, "Blue bike", reward["name"])
+	assert.Equal(t, float64(50), reward["token_price"])
+	assert.Equal(t, float64(1), reward["stock"])
+	assert.Equal(t, "/uploads/wish.png", reward["image_url"])
+	code, _ = api.request("POST", bikePath+"/accept", parentToken, map[string]interface{}{"token_price": 60})
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, response = api.request("GET", fmt.Sprintf("/api/v1/rewards/family/%v", familyID), childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Len(t, response["data"], 1)
+
+	// 拒绝必须说明原因
+	code, _ = api.request("POST", kitePath+"/decline", parentToken, map[string]interface{}{})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, response = api.request("POST", kitePath+"/decline", parentToken, map[string]interface{}{"note": "We already have two kites"})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, "declined", data(response)["status"])
+	assert.Nil(t, data(response)["reward_id"])
+	code, _ = api.request("POST", kitePath+"/accept", parentToken, map[string]interface{}{"token_price": 10})
+	assert.Equal(t, http.StatusConflict, code)
+
+	// 孩子收到处理结果
+	code, response = api.request("GET", "/api/v1/reward-proposals/my", childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Len(t, response["data"], 2)
+	code, response = api.request("GET", "/api/v1/notifications", childToken, nil)
+	require.Equal(t, http.StatusOK, code, response)
+	inbox = data(response)["notifications"].([]interface{})
+	require.Len(t, inbox, 2)
+	assert.Equal(t, "reward_proposal_decided", inbox[0].(map[string]interface{})["type"])
+	assert.Contains(t, inbox[0].(map[string]interface{})["body"], "We already have two kites")
+	assert.Contains(t, inbox[1].(map[string]interface{})["body"], "\"Blue bike\" was added to the rewards for 50 tokens")
+}
 
-	// Parse response
-	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+// Test that approving or rejecting a task which another request reviewed after it was loaded
+// returns 409 and writes neither the event nor the mint
+func TestConcurrentTaskReview(t *testing.T) {
+	api := setupTestAPI(t)
+	parentToken := api.login(newKey(t), "parent")
+	childKey := newKey(t)
+	childToken := api.login(childKey, "child")
+
+	code, _ := api.request("POST", "/api/v1/families", parentToken, map[string]interface{}{"name": "Race Family"})
+	require.Equal(t, http.StatusCreated, code)
+	code, response := api.request("POST", "/api/v1/children", parentToken, map[string]interface{}{
+		"name":           "Test Child",
+		"age":            10,
+		"wallet_address": crypto.PubkeyToAddress(childKey.PublicKey).Hex(),
+	})
+	require.Equal(t, http.StatusCreated, code)
+	childID := data(response)["id"]
+
+	// 处理器读取任务之后，另一个请求抢先批准了任务
+	var raced atomic.Bool
+	require.NoError(t, api.db.Callback().Query().After("gorm:query").Register("test:race_review", func(tx *gorm.DB) {
+		if tx.Statement.Schema == nil || tx.Statement.Schema.Table != "tasks" || !raced.CompareAndSwap(true, false) {
+			return
+		}
+		require.NoError(t, api.db.Exec("UPDATE tasks SET status = ? WHERE status = ?", "approved", "completed").Error)
+	}))
+	t.Cleanup(func() { api.db.Callback().Query().Remove("test:race_review") })
+
+	for _, action := range []string{"approve", "reject"} {
+		code, response = api.request("POST", "/api/v1/tasks", parentToken, map[string]interface{}{
+			"title":             "Test Task",
+			"description":       "This is a test task",
+			"reward_amount":     "0.01",
+			"difficulty":        "easy",
+			"assigned_child_id": childID,
+		})
+		require.Equal(t, http.StatusCreated, code, response)
+		taskPath := fmt.Sprintf("/api/v1/tasks/%v", data(response)["id"])
+		code, response = api.request("POST", taskPath+"/complete", childToken, map[string]interface{}{
+			"completion_proof": "Done",
+		})
+		require.Equal(t, http.StatusOK, code, response)
+
+		raced.Store(true)
+		code, response = api.request("POST", taskPath+"/"+action, parentToken, map[string]interface{}{
+			"reason": "Not good enough",
+		})
+		assert.Equal(t, http.StatusConflict, code, response)
+	}
 
-	// Assert response
-	assert.Nil(t, err)
-	assert.Equal(t, true, response["success"])
+	var mints, events int64
+	require.NoError(t, api.db.Model(&models.OutboxMessage{}).Count(&mints).Error)
+	assert.Zero(t, mints)
+	require.NoError(t, api.db.Model(&models.TaskEvent{}).
+		Where("action IN ?", []models.TaskEventAction{models.TaskEventApproved, models.TaskEventRejected}).Count(&events).Error)
+	assert.Zero(t, events)
+	var child models.Child
+	require.NoError(t, api.db.First(&child, childID).Error)
+	assert.Zero(t, child.TotalTasksCompleted)
 }
@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eth-for-babies-backend/pkg/blockchain"
+)
+
+// fakeNonceBackend reports a pending nonce the test can move, as other senders
+// sharing the key would
+type fakeNonceBackend struct {
+	mu      sync.Mutex
+	pending uint64
+	calls   int
+	err     error
+}
+
+func (b *fakeNonceBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls++
+	return b.pending, b.err
+}
+
+var nonceTestAddress = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+func TestNonceManager_NextSyncsOnceAndIncrements(t *testing.T) {
+	backend := &fakeNonceBackend{pending: 7}
+	nm := blockchain.NewNonceManager(backend)
+	ctx := context.Background()
+
+	_, ok := nm.Peek(nonceTestAddress)
+	assert.False(t, ok, "nothing to peek before the first sync")
+
+	for want := uint64(7); want < 10; want++ {
+		nonce, err := nm.Next(ctx, nonceTestAddress)
+		require.NoError(t, err)
+		assert.Equal(t, want, nonce)
+	}
+	assert.Equal(t, 1, backend.calls, "only the first Next asks the node")
+
+	next, ok := nm.Peek(nonceTestAddress)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(10), next)
+}
+
+func TestNonceManager_NextIsUniqueUnderConcurrency(t *testing.T) {
+	nm := blockchain.NewNonceManager(&fakeNonceBackend{})
+	ctx := context.Background()
+
+	const senders = 50
+	nonces := make(chan uint64, senders)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := nm.Next(ctx, nonceTestAddress)
+			assert.NoError(t, err)
+			nonces <- nonce
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+
+	seen := make(map[uint64]bool)
+	for nonce := range nonces {
+		assert.False(t, seen[nonce], "nonce %d handed out twice", nonce)
+		seen[nonce] = true
+	}
+	// 没有空洞：0到senders-1各分配一次
+	for nonce := uint64(0); nonce < senders; nonce++ {
+		assert.True(t, seen[nonce], "nonce %d was skipped", nonce)
+	}
+}
+
+func TestNonceManager_ReleaseReusesLowestNonce(t *testing.T) {
+	nm := blockchain.NewNonceManager(&fakeNonceBackend{pending: 3})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := nm.Next(ctx, nonceTestAddress)
+		require.NoError(t, err)
+	}
+	// 已分配3、4、5；归还5和3，重复归还和未分配的nonce被忽略
+	nm.Release(nonceTestAddress, 5)
+	nm.Release(nonceTestAddress, 3)
+	nm.Release(nonceTestAddress, 3)
+	nm.Release(nonceTestAddress, 6)
+	nm.Release(nonceTestAddress, 42)
+
+	for _, want := range []uint64{3, 5, 6, 7} {
+		nonce, err := nm.Next(ctx, nonceTestAddress)
+		require.NoError(t, err)
+		assert.Equal(t, want, nonce)
+	}
+}
+
+func TestNonceManager_SyncResetsToPendingNonce(t *testing.T) {
+	backend := &fakeNonceBackend{pending: 1}
+	nm := blockchain.NewNonceManager(backend)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := nm.Next(ctx, nonceTestAddress)
+		require.NoError(t, err)
+	}
+	nm.Release(nonceTestAddress, 2)
+
+	// 其他发送者用掉了nonce 1到9，被归还的nonce 2已经不能再用
+	backend.pending = 10
+	require.NoError(t, nm.Sync(ctx, nonceTestAddress))
+	nonce, err := nm.Next(ctx, nonceTestAddress)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), nonce)
+
+	backend.err = errors.New("connection refused")
+	assert.Error(t, nm.Sync(ctx, nonceTestAddress))
+}
+
+func TestIsNonceError(t *testing.T) {
+	for _, msg := range []string{
+		"nonce too low",
+		"Nonce too high",
+		"invalid nonce",
+		"invalid transaction nonce: got 4, want 5",
+		"replacement transaction underpriced",
+	} {
+		assert.True(t, blockchain.IsNonceError(errors.New(msg)), msg)
+	}
+	assert.False(t, blockchain.IsNonceError(nil))
+	assert.False(t, blockchain.IsNonceError(errors.New("execution reverted")))
+}
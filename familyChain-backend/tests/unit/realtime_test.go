@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eth-for-babies-backend/internal/realtime"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestSubscription_Matches(t *testing.T) {
+	parent := realtime.Subscription{FamilyIDs: []uint{1, 2}}
+	child := realtime.Subscription{FamilyIDs: []uint{1}, ChildID: uintPtr(10)}
+
+	familyEvent := realtime.Event{FamilyID: 1}
+	ownEvent := realtime.Event{FamilyID: 1, ChildID: uintPtr(10)}
+	siblingEvent := realtime.Event{FamilyID: 1, ChildID: uintPtr(11)}
+	otherFamily := realtime.Event{FamilyID: 3, ChildID: uintPtr(10)}
+	parentsOnly := realtime.Event{FamilyID: 1, ParentsOnly: true}
+
+	assert.True(t, parent.Matches(familyEvent))
+	assert.True(t, parent.Matches(siblingEvent))
+	assert.False(t, parent.Matches(otherFamily))
+	assert.True(t, parent.Matches(parentsOnly))
+
+	assert.True(t, child.Matches(familyEvent))
+	assert.True(t, child.Matches(ownEvent))
+	assert.False(t, child.Matches(siblingEvent))
+	assert.False(t, child.Matches(otherFamily))
+	assert.False(t, child.Matches(parentsOnly))
+}
+
+func TestMemoryBroker_PublishSubscribe(t *testing.T) {
+	broker := realtime.NewMemoryBroker(1)
+	events, unsubscribe := broker.Subscribe(realtime.Subscription{FamilyIDs: []uint{1}})
+
+	broker.Publish(realtime.Event{Type: realtime.EventTaskAssigned, FamilyID: 2})
+	broker.Publish(realtime.Event{Type: realtime.EventTaskAssigned, FamilyID: 1})
+	// 缓冲区已满，发布不会阻塞，这条事件被丢弃
+	broker.Publish(realtime.Event{Type: realtime.EventTaskSubmitted, FamilyID: 1})
+
+	event := <-events
+	assert.Equal(t, realtime.EventTaskAssigned, event.Type)
+	assert.Equal(t, uint64(2), event.ID)
+	assert.False(t, event.OccurredAt.IsZero())
+
+	unsubscribe()
+	unsubscribe()
+	_, open := <-events
+	require.False(t, open)
+
+	// 取消订阅后发布不会出错
+	broker.Publish(realtime.Event{Type: realtime.EventTaskAssigned, FamilyID: 1})
+}
+
+func TestWithHook(t *testing.T) {
+	broker := realtime.NewMemoryBroker(1)
+	events, unsubscribe := broker.Subscribe(realtime.Subscription{FamilyIDs: []uint{1}})
+	defer unsubscribe()
+
+	var hooked []realtime.Event
+	hookedBroker := realtime.WithHook(broker, func(event realtime.Event) {
+		hooked = append(hooked, event)
+	})
+	hookedBroker.Publish(realtime.Event{Type: realtime.EventTokensMinted, FamilyID: 1})
+
+	require.Len(t, hooked, 1)
+	assert.Equal(t, realtime.EventTokensMinted, hooked[0].Type)
+	assert.False(t, hooked[0].OccurredAt.IsZero())
+	event := <-events
+	assert.Equal(t, hooked[0].OccurredAt, event.OccurredAt)
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eth-for-babies-backend/internal/utils"
+)
+
+func date(t *testing.T, value string) time.Time {
+	parsed, err := utils.ParseDate(value)
+	require.NoError(t, err)
+	return parsed
+}
+
+func formatDates(dates []time.Time) []string {
+	formatted := make([]string, 0, len(dates))
+	for _, d := range dates {
+		formatted = append(formatted, d.Format(utils.DateLayout))
+	}
+	return formatted
+}
+
+func TestRecurrenceRule_Next(t *testing.T) {
+	// 2026-10-05 是周一
+	start := date(t, "2026-10-05")
+	until := date(t, "2026-10-08")
+
+	tests := []struct {
+		name string
+		rule utils.RecurrenceRule
+		from string
+		want []string
+	}{
+		{
+			name: "daily",
+			rule: utils.RecurrenceRule{Frequency: utils.FrequencyDaily, Start: start},
+			from: "2026-10-04",
+			want: []string{"2026-10-05", "2026-10-06", "2026-10-07"},
+		},
+		{
+			name: "every other day",
+			rule: utils.RecurrenceRule{Frequency: utils.FrequencyDaily, Interval: 2, Start: start},
+			from: "2026-10-06",
+			want: []string{"2026-10-07", "2026-10-09", "2026-10-11"},
+		},
+		{
+			name: "weekdays skip the weekend",
+			rule: utils.RecurrenceRule{Frequency: utils.FrequencyWeekdays, Start: start},
+			from: "2026-10-09",
+			want: []string{"2026-10-09", "2026-10-12", "2026-10-13"},
+		},
+		{
+			name: "weekly on monday and friday",
+			rule: utils.RecurrenceRule{Frequency: utils.FrequencyWeekly, Weekdays: []time.Weekday{time.Monday, time.Friday}, Start: start},
+			from: "2026-10-05",
+			want: []string{"2026-10-05", "2026-10-09", "2026-10-12"},
+		},
+		{
+			name: "every other week defaults to the start weekday",
+			rule: utils.RecurrenceRule{Frequency: utils.FrequencyWeekly, Interval: 2, Start: start},
+			from: "2026-10-05",
+			want: []string{"2026-10-05", "2026-10-19", "2026-11-02"},
+		},
+		{
+			name: "monthly on the 31st uses the last day of shorter months",
+			rule: utils.RecurrenceRule{Frequency: utils.FrequencyMonthly, MonthDay: 31, Start: start},
+			from: "2026-10-05",
+			want: []string{"2026-10-31", "2026-11-30", "2026-12-31"},
+		},
+		{
+			name: "until is inclusive",
+			rule: utils.RecurrenceRule{Frequency: utils.FrequencyDaily, Start: start, Until: &until},
+			from: "2026-10-07",
+			want: []string{"2026-10-07", "2026-10-08"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.rule.Validate())
+			assert.Equal(t, tt.want, formatDates(tt.rule.Next(date(t, tt.from), 3)))
+		})
+	}
+}
+
+func TestRecurrenceRule_Validate(t *testing.T) {
+	start := date(t, "2026-10-05")
+	before := date(t, "2026-10-01")
+
+	invalid := []utils.RecurrenceRule{
+		{Frequency: "hourly", Start: start},
+		{Frequency: utils.FrequencyDaily},
+		{Frequency: utils.FrequencyDaily, Weekdays: []time.Weekday{time.Monday}, Start: start},
+		{Frequency: utils.FrequencyWeekdays, Interval: 2, Start: start},
+		{Frequency: utils.FrequencyMonthly, MonthDay: 32, Start: start},
+		{Frequency: utils.FrequencyDaily, Start: start, Until: &before},
+	}
+	for _, rule := range invalid {
+		assert.Error(t, rule.Validate(), "%+v", rule)
+	}
+
+	weekdays, err := utils.ParseWeekdays("mo, WE,FR,MO")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Wednesday, time.Friday}, weekdays)
+	_, err = utils.ParseWeekdays("MO,XX")
+	assert.Error(t, err)
+}
+
+func TestDateIn(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// UTC 16:30 在上海已经是第二天
+	now := time.Date(2026, 10, 5, 16, 30, 0, 0, time.UTC)
+	assert.Equal(t, "2026-10-06", utils.DateIn(now, loc).Format(utils.DateLayout))
+	assert.Equal(t, "2026-10-05", utils.DateIn(now, time.UTC).Format(utils.DateLayout))
+}
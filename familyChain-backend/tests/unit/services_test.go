@@ -1,328 +1,902 @@
 package unit
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
+	"eth-for-babies-backend/internal/config"
 	"eth-for-babies-backend/internal/models"
+	"eth-for-babies-backend/internal/realtime"
+	"eth-for-babies-backend/internal/repository"
 	"eth-for-babies-backend/internal/services"
+	"eth-for-babies-backend/internal/utils"
 )
 
-// Mock repositories
-type MockTaskRepository struct {
-	mock.Mock
-}
+const (
+	parentAddress = "0x1111111111111111111111111111111111111111"
+	otherParent   = "0x2222222222222222222222222222222222222222"
+	childAddress  = "0x3333333333333333333333333333333333333333"
+	// 测试账本时用作奖品合约和零地址
+	registryAddress = "0x4444444444444444444444444444444444444444"
+	zeroAddress     = "0x0000000000000000000000000000000000000000"
+)
 
-func (m *MockTaskRepository) Create(task *models.Task) error {
-	args := m.Called(task)
-	return args.Error(0)
+// Repositories backed by an in-memory database
+type testRepos struct {
+	db         *gorm.DB
+	taskRepo   *repository.TaskRepository
+	familyRepo *repository.FamilyRepository
+	childRepo  *repository.ChildRepository
 }
 
-func (m *MockTaskRepository) GetByID(id int64) (*models.Task, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+func setupRepos(t *testing.T) *testRepos {
+	cfg := &config.Config{
+		Environment: "test",
+		Database: config.DatabaseConfig{
+			Driver: "sqlite",
+			DSN:    fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_")),
+		},
 	}
-	return args.Get(0).(*models.Task), args.Error(1)
-}
-
-func (m *MockTaskRepository) GetAll() ([]*models.Task, error) {
-	args := m.Called()
-	return args.Get(0).([]*models.Task), args.Error(1)
-}
-
-func (m *MockTaskRepository) GetByFamilyID(familyID int64) ([]*models.Task, error) {
-	args := m.Called(familyID)
-	return args.Get(0).([]*models.Task), args.Error(1)
-}
-
-func (m *MockTaskRepository) GetByChildID(childID int64) ([]*models.Task, error) {
-	args := m.Called(childID)
-	return args.Get(0).([]*models.Task), args.Error(1)
-}
-
-func (m *MockTaskRepository) Update(task *models.Task) error {
-	args := m.Called(task)
-	return args.Error(0)
-}
-
-func (m *MockTaskRepository) Delete(id int64) error {
-	args := m.Called(id)
-	return args.Error(0)
-}
 
-type MockFamilyRepository struct {
-	mock.Mock
-}
-
-func (m *MockFamilyRepository) Create(family *models.Family) error {
-	args := m.Called(family)
-	return args.Error(0)
+	db, err := config.InitDatabase(cfg)
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return &testRepos{
+		db:         db,
+		taskRepo:   repository.NewTaskRepository(db),
+		familyRepo: repository.NewFamilyRepository(db),
+		childRepo:  repository.NewChildRepository(db),
+	}
 }
 
-func (m *MockFamilyRepository) GetByID(id int64) (*models.Family, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+func (r *testRepos) createChild(t *testing.T, parent, wallet string) *models.Child {
+	child := &models.Child{
+		Name:          "Test Child",
+		Age:           10,
+		ParentAddress: parent,
+		WalletAddress: wallet,
 	}
-	return args.Get(0).(*models.Family), args.Error(1)
+	require.NoError(t, r.childRepo.Create(child))
+	return child
 }
 
-func (m *MockFamilyRepository) GetByParentID(parentID int64) ([]*models.Family, error) {
-	args := m.Called(parentID)
-	return args.Get(0).([]*models.Family), args.Error(1)
-}
+// Tests for TaskService
+func TestTaskService_CreateTask(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewTaskService(repos.taskRepo, repos.childRepo, repos.familyRepo)
 
-func (m *MockFamilyRepository) Update(family *models.Family) error {
-	args := m.Called(family)
-	return args.Error(0)
-}
+	task := &models.Task{
+		Title:        "Test Task",
+		Description:  "Test Description",
+		RewardAmount: "0.01",
+		Difficulty:   "easy",
+		CreatedBy:    parentAddress,
+	}
 
-func (m *MockFamilyRepository) Delete(id int64) error {
-	args := m.Called(id)
-	return args.Error(0)
-}
+	err := service.CreateTask(task)
 
-type MockChildRepository struct {
-	mock.Mock
+	assert.NoError(t, err)
+	assert.NotZero(t, task.ID)
+	assert.Equal(t, "pending", task.Status)
 }
 
-func (m *MockChildRepository) Create(child *models.Child) error {
-	args := m.Called(child)
-	return args.Error(0)
-}
+func TestTaskService_CreateTaskValidation(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewTaskService(repos.taskRepo, repos.childRepo, repos.familyRepo)
+	child := repos.createChild(t, otherParent, childAddress)
 
-func (m *MockChildRepository) GetByID(id int64) (*models.Child, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+	cases := map[string]*models.Task{
+		"missing title":   {RewardAmount: "0.01", Difficulty: "easy", CreatedBy: parentAddress},
+		"invalid reward":  {Title: "Test Task", RewardAmount: "abc", Difficulty: "easy", CreatedBy: parentAddress},
+		"zero reward":     {Title: "Test Task", RewardAmount: "0", Difficulty: "easy", CreatedBy: parentAddress},
+		"someone's child": {Title: "Test Task", RewardAmount: "0.01", Difficulty: "easy", CreatedBy: parentAddress, AssignedChildID: &child.ID},
 	}
-	return args.Get(0).(*models.Child), args.Error(1)
-}
-
-func (m *MockChildRepository) GetByFamilyID(familyID int64) ([]*models.Child, error) {
-	args := m.Called(familyID)
-	return args.Get(0).([]*models.Child), args.Error(1)
-}
-
-func (m *MockChildRepository) GetByWalletAddress(walletAddress string) (*models.Child, error) {
-	args := m.Called(walletAddress)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+	for name, task := range cases {
+		assert.Error(t, service.CreateTask(task), name)
 	}
-	return args.Get(0).(*models.Child), args.Error(1)
-}
-
-func (m *MockChildRepository) Update(child *models.Child) error {
-	args := m.Called(child)
-	return args.Error(0)
-}
-
-func (m *MockChildRepository) Delete(id int64) error {
-	args := m.Called(id)
-	return args.Error(0)
 }
 
-// Tests for TaskService
-func TestTaskService_CreateTask(t *testing.T) {
-	mockRepo := new(MockTaskRepository)
-	service := services.NewTaskService(mockRepo)
+func TestTaskService_GetTaskByID(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewTaskService(repos.taskRepo, repos.childRepo, repos.familyRepo)
 
 	task := &models.Task{
-		Title:       "Test Task",
-		Description: "Test Description",
-		Reward:      "0.01",
-		Status:      "available",
-		CreatorID:   1,
-		FamilyID:    1,
+		Title:        "Test Task",
+		Description:  "Test Description",
+		RewardAmount: "0.01",
+		Difficulty:   "easy",
+		CreatedBy:    parentAddress,
 	}
+	require.NoError(t, service.CreateTask(task))
 
-	// Set up expectations
-	mockRepo.On("Create", task).Return(nil)
+	result, err := service.GetTaskByID(task.ID)
 
-	// Call the service
-	err := service.CreateTask(task)
-
-	// Assert expectations
 	assert.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, task.Title, result.Title)
+	assert.Equal(t, task.RewardAmount, result.RewardAmount)
 }
 
-func TestTaskService_GetTaskByID(t *testing.T) {
-	mockRepo := new(MockTaskRepository)
-	service := services.NewTaskService(mockRepo)
+func TestTaskService_AssignTask(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewTaskService(repos.taskRepo, repos.childRepo, repos.familyRepo)
+	child := repos.createChild(t, parentAddress, childAddress)
 
 	task := &models.Task{
-		ID:          1,
-		Title:       "Test Task",
-		Description: "Test Description",
-		Reward:      "0.01",
-		Status:      "available",
-		CreatorID:   1,
-		FamilyID:    1,
+		Title:        "Test Task",
+		Description:  "Test Description",
+		RewardAmount: "0.01",
+		Difficulty:   "easy",
+		CreatedBy:    parentAddress,
 	}
+	require.NoError(t, service.CreateTask(task))
 
-	// Set up expectations
-	mockRepo.On("GetByID", int64(1)).Return(task, nil)
-
-	// Call the service
-	result, err := service.GetTaskByID(1)
+	err := service.UpdateTask(task.ID, parentAddress, map[string]interface{}{"assigned_child_id": child.ID})
+	require.NoError(t, err)
 
-	// Assert expectations
-	assert.NoError(t, err)
-	assert.Equal(t, task, result)
-	mockRepo.AssertExpectations(t)
+	result, err := service.GetTaskByID(task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "in_progress", result.Status)
+	require.NotNil(t, result.AssignedChildID)
+	assert.Equal(t, child.ID, *result.AssignedChildID)
 }
 
-func TestTaskService_AssignTask(t *testing.T) {
-	mockRepo := new(MockTaskRepository)
-	service := services.NewTaskService(mockRepo)
+func TestTaskService_RejectTaskKeepsProof(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewTaskService(repos.taskRepo, repos.childRepo, repos.familyRepo)
+	child := repos.createChild(t, parentAddress, childAddress)
 
 	task := &models.Task{
-		ID:          1,
-		Title:       "Test Task",
-		Description: "Test Description",
-		Reward:      "0.01",
-		Status:      "available",
-		CreatorID:   1,
-		FamilyID:    1,
+		Title:           "Test Task",
+		Description:     "Test Description",
+		RewardAmount:    "0.01",
+		Difficulty:      "easy",
+		CreatedBy:       parentAddress,
+		AssignedChildID: &child.ID,
 	}
+	require.NoError(t, service.CreateTask(task))
+	require.NoError(t, service.CompleteTask(task.ID, "photo of a tidy room"))
+	require.NoError(t, service.RejectTask(task.ID, parentAddress, "Still messy"))
+
+	result, err := service.GetTaskByID(task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "rejected", result.Status)
+	require.NotNil(t, result.CompletionProof)
+	assert.Equal(t, "photo of a tidy room", *result.CompletionProof)
+	require.NotNil(t, result.RejectionReason)
+	assert.Equal(t, "Still messy", *result.RejectionReason)
+
+	// 每次状态变化都追加到任务历史
+	events, err := repository.NewTaskEventRepository(repos.db).ListByTask(task.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, models.TaskEventCreated, events[0].Action)
+	assert.Equal(t, "in_progress", events[0].ToStatus)
+	assert.Equal(t, models.TaskEventSubmitted, events[1].Action)
+	assert.Equal(t, childAddress, events[1].Actor)
+	assert.Equal(t, "photo of a tidy room", *events[1].Proof)
+	assert.Equal(t, models.TaskEventRejected, events[2].Action)
+	assert.Equal(t, parentAddress, events[2].Actor)
+	assert.Equal(t, "completed", events[2].FromStatus)
+	assert.Equal(t, "Still messy", *events[2].Reason)
+}
+
+func TestTaskService_CompleteTaskRequiresChecklist(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewTaskService(repos.taskRepo, repos.childRepo, repos.familyRepo)
+	child := repos.createChild(t, parentAddress, childAddress)
 
-	updatedTask := &models.Task{
-		ID:          1,
-		Title:       "Test Task",
-		Description: "Test Description",
-		Reward:      "0.01",
-		Status:      "in-progress",
-		CreatorID:   1,
-		FamilyID:    1,
-		AssignedTo:  2,
+	task := &models.Task{
+		Title:             "Clean your room",
+		Description:       "Step by step",
+		RewardAmount:      "0.01",
+		Difficulty:        "medium",
+		CreatedBy:         parentAddress,
+		AssignedChildID:   &child.ID,
+		ChecklistRequired: true,
+		Checklist: []models.TaskChecklistItem{
+			{Position: 0, Title: "Make the bed", Required: true},
+			{Position: 1, Title: "Dust the shelves", Required: false},
+		},
 	}
+	require.NoError(t, service.CreateTask(task))
 
-	// Set up expectations
-	mockRepo.On("GetByID", int64(1)).Return(task, nil)
-	mockRepo.On("Update", updatedTask).Return(nil)
+	err := service.CompleteTask(task.ID, "done")
+	assert.EqualError(t, err, "all required checklist items must be checked before submitting")
 
-	// Call the service
-	err := service.AssignTask(1, 2)
+	// 只需要勾选必做的步骤
+	checklist := repository.NewTaskChecklistRepository(repos.db)
+	items, err := checklist.ListByTask(task.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	now := time.Now()
+	items[0].CheckedAt = &now
+	require.NoError(t, checklist.Update(&items[0]))
 
-	// Assert expectations
-	assert.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	require.NoError(t, service.CompleteTask(task.ID, "done"))
 }
 
 // Tests for FamilyService
 func TestFamilyService_CreateFamily(t *testing.T) {
-	mockRepo := new(MockFamilyRepository)
-	service := services.NewFamilyService(mockRepo)
+	repos := setupRepos(t)
+	service := services.NewFamilyService(repos.familyRepo, repos.childRepo)
 
 	family := &models.Family{
-		Name:     "Test Family",
-		ParentID: 1,
+		Name:          "Test Family",
+		ParentAddress: parentAddress,
 	}
 
-	// Set up expectations
-	mockRepo.On("Create", family).Return(nil)
-
-	// Call the service
 	err := service.CreateFamily(family)
 
-	// Assert expectations
 	assert.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	assert.NotZero(t, family.ID)
+
+	// 每个家长只能有一个家庭
+	err = service.CreateFamily(&models.Family{Name: "Second Family", ParentAddress: parentAddress})
+	assert.Error(t, err)
 }
 
 func TestFamilyService_GetFamilyByID(t *testing.T) {
-	mockRepo := new(MockFamilyRepository)
-	service := services.NewFamilyService(mockRepo)
+	repos := setupRepos(t)
+	service := services.NewFamilyService(repos.familyRepo, repos.childRepo)
 
 	family := &models.Family{
-		ID:       1,
-		Name:     "Test Family",
-		ParentID: 1,
+		Name:          "Test Family",
+		ParentAddress: parentAddress,
 	}
+	require.NoError(t, service.CreateFamily(family))
 
-	// Set up expectations
-	mockRepo.On("GetByID", int64(1)).Return(family, nil)
-
-	// Call the service
-	result, err := service.GetFamilyByID(1)
+	result, err := service.GetFamilyByID(family.ID)
 
-	// Assert expectations
 	assert.NoError(t, err)
-	assert.Equal(t, family, result)
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, family.Name, result.Name)
+	assert.Equal(t, parentAddress, result.ParentAddress)
 }
 
 // Tests for ChildService
 func TestChildService_CreateChild(t *testing.T) {
-	mockRepo := new(MockChildRepository)
-	service := services.NewChildService(mockRepo)
+	repos := setupRepos(t)
+	service := services.NewChildService(repos.childRepo, repos.familyRepo, repos.taskRepo, repository.NewLedgerRepository(repos.db))
 
 	child := &models.Child{
 		Name:          "Test Child",
 		Age:           10,
-		FamilyID:      1,
-		WalletAddress: "0x1234567890",
+		ParentAddress: parentAddress,
+		WalletAddress: childAddress,
 	}
 
-	// Set up expectations
-	mockRepo.On("Create", child).Return(nil)
-
-	// Call the service
 	err := service.CreateChild(child)
 
-	// Assert expectations
 	assert.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	assert.NotZero(t, child.ID)
+	assert.Equal(t, "0", child.TotalRewardsEarned)
+
+	// 年龄超出范围
+	err = service.CreateChild(&models.Child{Name: "Too Old", Age: 30, ParentAddress: parentAddress})
+	assert.Error(t, err)
 }
 
 func TestChildService_GetChildByID(t *testing.T) {
-	mockRepo := new(MockChildRepository)
-	service := services.NewChildService(mockRepo)
+	repos := setupRepos(t)
+	service := services.NewChildService(repos.childRepo, repos.familyRepo, repos.taskRepo, repository.NewLedgerRepository(repos.db))
+	child := repos.createChild(t, parentAddress, childAddress)
 
-	child := &models.Child{
-		ID:            1,
-		Name:          "Test Child",
-		Age:           10,
-		FamilyID:      1,
-		WalletAddress: "0x1234567890",
-	}
+	result, err := service.GetChildByID(child.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, child.Name, result.Name)
+	assert.Equal(t, childAddress, result.WalletAddress)
+}
 
-	// Set up expectations
-	mockRepo.On("GetByID", int64(1)).Return(child, nil)
+func TestChildService_GetChildByWalletAddress(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewChildService(repos.childRepo, repos.familyRepo, repos.taskRepo, repository.NewLedgerRepository(repos.db))
+	child := repos.createChild(t, parentAddress, childAddress)
 
-	// Call the service
-	result, err := service.GetChildByID(1)
+	result, err := service.GetChildByWalletAddress(childAddress)
 
-	// Assert expectations
 	assert.NoError(t, err)
-	assert.Equal(t, child, result)
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, child.ID, result.ID)
+
+	_, err = service.GetChildByWalletAddress("0x1234567890")
+	assert.Error(t, err)
 }
 
-func TestChildService_GetChildByWalletAddress(t *testing.T) {
-	mockRepo := new(MockChildRepository)
-	service := services.NewChildService(mockRepo)
+// Tests for MembershipService
+func TestMembershipService_ResolveManagedFamily(t *testing.T) {
+	repos := setupRepos(t)
+	memberRepo := repository.NewFamilyMemberRepository(repos.db)
+	service := services.NewMembershipService(memberRepo, repository.NewFamilyInvitationRepository(repos.db), repos.familyRepo, repos.childRepo)
+
+	first := &models.Family{Name: "First", ParentAddress: parentAddress}
+	second := &models.Family{Name: "Second", ParentAddress: otherParent}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(first))
+	require.NoError(t, repos.familyRepo.CreateWithOwner(second))
+
+	// 创建者默认使用自己的家庭
+	family, err := service.ResolveManagedFamily(parentAddress, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, family.ID)
+
+	// 不是成员
+	_, err = service.ResolveManagedFamily(childAddress, nil)
+	assert.True(t, errors.Is(err, services.ErrNotFamilyMember))
+
+	// 只读成员不能管理
+	grandparent := "0x4444444444444444444444444444444444444444"
+	require.NoError(t, memberRepo.Create(&models.FamilyMember{FamilyID: first.ID, WalletAddress: grandparent, Role: models.FamilyRoleViewer}))
+	_, err = service.ResolveManagedFamily(grandparent, nil)
+	assert.True(t, errors.Is(err, services.ErrFamilyPermission))
+	assert.NoError(t, service.Authorize(first.ID, grandparent, false))
+
+	// 共同家长属于多个家庭时必须指定家庭
+	require.NoError(t, memberRepo.Create(&models.FamilyMember{FamilyID: second.ID, WalletAddress: grandparent, Role: models.FamilyRoleCoParent}))
+	family, err = service.ResolveManagedFamily(grandparent, nil)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, family.ID)
+
+	require.NoError(t, memberRepo.UpdateRole(mustMember(t, memberRepo, first.ID, grandparent).ID, models.FamilyRoleCoParent))
+	_, err = service.ResolveManagedFamily(grandparent, nil)
+	assert.True(t, errors.Is(err, services.ErrFamilyRequired))
+	family, err = service.ResolveManagedFamily(grandparent, &first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, family.ID)
+}
 
-	child := &models.Child{
-		ID:            1,
-		Name:          "Test Child",
-		Age:           10,
-		FamilyID:      1,
-		WalletAddress: "0x1234567890",
+func mustMember(t *testing.T, repo *repository.FamilyMemberRepository, familyID uint, wallet string) *models.FamilyMember {
+	member, err := repo.GetByFamilyAndWallet(familyID, wallet)
+	require.NoError(t, err)
+	require.NotNil(t, member)
+	return member
+}
+
+func TestTaskSeriesService_Materialize(t *testing.T) {
+	repos := setupRepos(t)
+	memberRepo := repository.NewFamilyMemberRepository(repos.db)
+	membership := services.NewMembershipService(memberRepo, repository.NewFamilyInvitationRepository(repos.db), repos.familyRepo, repos.childRepo)
+	service := services.NewTaskSeriesService(repository.NewTaskSeriesRepository(repos.db), repos.childRepo, repos.familyRepo, membership, realtime.Nop{})
+
+	family := &models.Family{Name: "Series Family", ParentAddress: parentAddress}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(family))
+	child := repos.createChild(t, parentAddress, childAddress)
+	otherChild := repos.createChild(t, otherParent, "0x5555555555555555555555555555555555555555")
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	// 上海时间上午10点；2026-10-05 是周一
+	at := func(day int) time.Time { return time.Date(2026, 10, day, 10, 0, 0, 0, shanghai) }
+
+	series := &models.TaskSeries{
+		FamilyID:     family.ID,
+		CreatedBy:    parentAddress,
+		Title:        "Make your bed",
+		Description:  "Every school morning",
+		RewardAmount: "0.01",
+		Difficulty:   "easy",
+		Frequency:    "weekly",
+		Weekdays:     "MO,WE,FR",
+		DueTime:      "18:00",
+		TimeZone:     "Asia/Shanghai",
+		StartDate:    time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC),
+	}
+
+	// 孩子必须属于该家庭
+	err = service.Create(series, []uint{otherChild.ID}, at(5))
+	assert.True(t, errors.Is(err, services.ErrInvalidTaskSeries))
+
+	// 创建当天就是第一次出现，立即生成实例
+	require.NoError(t, service.Create(series, []uint{child.ID}, at(5)))
+	seriesTasks := func() []*models.Task {
+		var tasks []*models.Task
+		require.NoError(t, repos.db.Where("series_id = ?", series.ID).Order("occurrence_date").Find(&tasks).Error)
+		return tasks
+	}
+	tasks := seriesTasks()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "in_progress", tasks[0].Status)
+	assert.Equal(t, child.ID, *tasks[0].AssignedChildID)
+	assert.True(t, tasks[0].DueDate.Equal(time.Date(2026, 10, 5, 18, 0, 0, 0, shanghai)))
+
+	// 同一天重复运行不会重复生成；周二不出现
+	created, err := service.MaterializeDue(at(5))
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+	created, err = service.MaterializeDue(at(6))
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+
+	// 修改只影响之后的实例
+	newTitle := "Make your bed neatly"
+	_, err = service.Update(series.ID, parentAddress, services.TaskSeriesChanges{Title: &newTitle}, at(6))
+	require.NoError(t, err)
+	created, err = service.MaterializeDue(at(7))
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	tasks = seriesTasks()
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "Make your bed", tasks[0].Title)
+	assert.Equal(t, newTitle, tasks[1].Title)
+
+	// 跳过周五；不出现的日期不能跳过
+	_, err = service.Skip(series.ID, parentAddress, "2026-10-08")
+	assert.True(t, errors.Is(err, services.ErrInvalidTaskSeries))
+	_, err = service.Skip(series.ID, parentAddress, "2026-10-09")
+	require.NoError(t, err)
+	created, err = service.MaterializeDue(at(9))
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+
+	// 暂停期间不生成，恢复后不补建错过的日期
+	created, err = service.MaterializeDue(at(12))
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	_, err = service.Pause(series.ID, parentAddress)
+	require.NoError(t, err)
+	created, err = service.MaterializeDue(at(14))
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+	_, err = service.Resume(series.ID, parentAddress, at(15))
+	require.NoError(t, err)
+	created, err = service.MaterializeDue(at(16))
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	dates := make([]string, 0)
+	for _, task := range seriesTasks() {
+		dates = append(dates, task.OccurrenceDate.Format("2006-01-02"))
 	}
+	assert.Equal(t, []string{"2026-10-05", "2026-10-07", "2026-10-12", "2026-10-16"}, dates)
 
-	// Set up expectations
-	mockRepo.On("GetByWalletAddress", "0x1234567890").Return(child, nil)
+	// 只有可以管理家庭的成员能修改
+	_, err = service.Pause(series.ID, otherParent)
+	assert.True(t, errors.Is(err, services.ErrNotFamilyMember))
+}
+
+func TestTaskTemplateService_Assign(t *testing.T) {
+	repos := setupRepos(t)
+	memberRepo := repository.NewFamilyMemberRepository(repos.db)
+	membership := services.NewMembershipService(memberRepo, repository.NewFamilyInvitationRepository(repos.db), repos.familyRepo, repos.childRepo)
+	service := services.NewTaskTemplateService(repository.NewTaskTemplateRepository(repos.db), repos.taskRepo, repos.childRepo, repos.familyRepo, membership, realtime.Nop{})
+
+	family := &models.Family{Name: "Template Family", ParentAddress: parentAddress}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(family))
+	child := repos.createChild(t, parentAddress, childAddress)
+	sibling := repos.createChild(t, parentAddress, "0x4444444444444444444444444444444444444444")
+	otherChild := repos.createChild(t, otherParent, "0x5555555555555555555555555555555555555555")
+
+	// 内置模板在启动时写入，所有家庭都能看到但不能修改
+	templates, err := service.List(parentAddress, nil)
+	require.NoError(t, err)
+	require.Len(t, templates, len(models.StarterTaskTemplates()))
+	builtIn := templates[0]
+	assert.True(t, builtIn.BuiltIn())
+	title := "Renamed"
+	_, err = service.Update(builtIn.ID, parentAddress, services.TaskTemplateChanges{Title: &title})
+	assert.True(t, errors.Is(err, services.ErrBuiltInTemplate))
+
+	template := &models.TaskTemplate{
+		FamilyID:     &family.ID,
+		CreatedBy:    parentAddress,
+		Title:        "Water the plants",
+		Description:  "Water every plant in the garden",
+		RewardAmount: "0.01",
+		Difficulty:   "easy",
+		ProofType:    models.ProofTypePhoto,
+	}
+	require.NoError(t, service.Create(template))
+
+	// 其他家庭的家长看不到也不能使用该模板
+	templates, err = service.List(otherParent, nil)
+	require.NoError(t, err)
+	assert.Len(t, templates, len(models.StarterTaskTemplates()))
+	_, err = service.Assign(template.ID, otherParent, services.TemplateAssignment{ChildIDs: []uint{otherChild.ID}})
+	assert.True(t, errors.Is(err, services.ErrNotFamilyMember))
+
+	// 有一个孩子不属于该家庭时一个任务都不创建
+	_, err = service.Assign(template.ID, parentAddress, services.TemplateAssignment{ChildIDs: []uint{child.ID, otherChild.ID}})
+	assert.True(t, errors.Is(err, services.ErrInvalidTaskTemplate))
+	var count int64
+	require.NoError(t, repos.db.Model(&models.Task{}).Where("template_id = ?", template.ID).Count(&count).Error)
+	assert.Zero(t, count)
+
+	// 为每个孩子创建一个进行中的任务，重复的孩子只创建一次
+	reward := "0.02"
+	tasks, err := service.Assign(template.ID, parentAddress, services.TemplateAssignment{
+		ChildIDs:     []uint{child.ID, sibling.ID, child.ID},
+		RewardAmount: &reward,
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	for _, task := range tasks {
+		assert.Equal(t, "in_progress", task.Status)
+		assert.Equal(t, "0.02", task.RewardAmount)
+		assert.Equal(t, models.ProofTypePhoto, task.ProofType)
+		assert.Equal(t, family.ID, *task.FamilyID)
+		assert.Equal(t, template.ID, *task.TemplateID)
+	}
+	assert.Equal(t, child.ID, *tasks[0].AssignedChildID)
+	assert.Equal(t, sibling.ID, *tasks[1].AssignedChildID)
+
+	// 内置模板创建的任务属于调用者的家庭
+	tasks, err = service.Assign(builtIn.ID, parentAddress, services.TemplateAssignment{ChildIDs: []uint{child.ID}})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, builtIn.Title, tasks[0].Title)
+	assert.Equal(t, family.ID, *tasks[0].FamilyID)
+}
 
-	// Call the service
-	result, err := service.GetChildByWalletAddress("0x1234567890")
+func TestTaskExpiryService_ExpireOverdue(t *testing.T) {
+	repos := setupRepos(t)
+	service := services.NewTaskExpiryService(repos.taskRepo, repos.familyRepo)
+
+	family := &models.Family{Name: "Expiry Family", ParentAddress: parentAddress}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(family))
+	child := repos.createChild(t, parentAddress, childAddress)
+
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	createTask := func(status string, due *time.Time, assigned bool) *models.Task {
+		task := &models.Task{
+			Title:        "Test Task",
+			Description:  "Test Description",
+			RewardAmount: "0.02",
+			Difficulty:   "easy",
+			Status:       status,
+			CreatedBy:    parentAddress,
+			FamilyID:     &family.ID,
+			DueDate:      due,
+		}
+		if assigned {
+			task.AssignedChildID = &child.ID
+		}
+		require.NoError(t, repos.taskRepo.Create(task))
+		return task
+	}
+	reload := func(task *models.Task) *models.Task {
+		result, err := repos.taskRepo.GetByID(task.ID)
+		require.NoError(t, err)
+		return result
+	}
 
-	// Assert expectations
-	assert.NoError(t, err)
-	assert.Equal(t, child, result)
-	mockRepo.AssertExpectations(t)
+	overdue := createTask("in_progress", &past, true)
+	unassigned := createTask("pending", &past, false)
+	submitted := createTask("completed", &past, true)
+	notDue := createTask("in_progress", &future, true)
+	noDueDate := createTask("in_progress", nil, true)
+
+	// 家庭不允许迟交：只标记过期，奖励不变
+	expired, err := service.ExpireOverdue(now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, expired)
+
+	result := reload(overdue)
+	assert.Equal(t, models.TaskStatusExpired, result.Status)
+	assert.Equal(t, "0.02", result.RewardAmount)
+	assert.Nil(t, result.OriginalRewardAmount)
+	require.NotNil(t, result.ExpiredAt)
+	assert.Contains(t, *result.ExpiryReason, "Late submissions are not accepted")
+	assert.Contains(t, *reload(unassigned).ExpiryReason, "never assigned")
+	assert.Equal(t, "completed", reload(submitted).Status)
+	assert.Equal(t, "in_progress", reload(notDue).Status)
+	assert.Equal(t, "in_progress", reload(noDueDate).Status)
+
+	events, err := repository.NewTaskEventRepository(repos.db).ListByTask(overdue.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.TaskEventExpired, events[0].Action)
+	assert.Equal(t, models.TaskEventSourceScheduler, events[0].Source)
+	assert.Equal(t, "in_progress", events[0].FromStatus)
+	assert.Equal(t, *result.ExpiryReason, *events[0].Reason)
+
+	// 已经过期的任务不会再次处理
+	expired, err = service.ExpireOverdue(now)
+	require.NoError(t, err)
+	assert.Zero(t, expired)
+
+	// 允许迟交时按百分比减少奖励，并保留原来的奖励
+	family.AllowLateSubmissions = true
+	family.LatePenaltyPercent = 25
+	require.NoError(t, repos.db.Save(family).Error)
+	late := createTask("in_progress", &past, true)
+	expired, err = service.ExpireOverdue(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	result = reload(late)
+	assert.Equal(t, models.TaskStatusExpired, result.Status)
+	assert.Equal(t, "0.015", result.RewardAmount)
+	require.NotNil(t, result.OriginalRewardAmount)
+	assert.Equal(t, "0.02", *result.OriginalRewardAmount)
+	assert.Contains(t, *result.ExpiryReason, "25% less reward (0.015 instead of 0.02)")
+}
+
+// Tests for ExchangeRepository
+func TestExchangeRepository_CreatePendingDoesNotOversell(t *testing.T) {
+	repos := setupRepos(t)
+	rewardRepo := repository.NewRewardRepository(repos.db)
+	exchangeRepo := repository.NewExchangeRepository(repos.db)
+
+	family := &models.Family{Name: "Exchange Family", ParentAddress: parentAddress}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(family))
+	child := repos.createChild(t, parentAddress, childAddress)
+	reward := &models.Reward{FamilyID: family.ID, Name: "Last Ice Cream", TokenPrice: 10, Stock: 1, Active: true, CreatedBy: 1}
+	require.NoError(t, rewardRepo.Create(reward))
+
+	// 并发兑换最后一件，只有一个成功
+	const attempts = 8
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exchange := &models.Exchange{RewardID: reward.ID, ChildID: child.ID, TokenAmount: 10, Status: models.ExchangeStatusPending}
+			if err := exchangeRepo.CreatePending(exchange, "", nil, false); err == nil {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), created.Load())
+	result, err := rewardRepo.GetByID(reward.ID)
+	require.NoError(t, err)
+	assert.Zero(t, result.Stock)
+	var count int64
+	require.NoError(t, repos.db.Model(&models.Exchange{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	err = exchangeRepo.CreatePending(&models.Exchange{RewardID: reward.ID, ChildID: child.ID, TokenAmount: 10}, "", nil, false)
+	assert.ErrorIs(t, err, repository.ErrOutOfStock)
+	err = rewardRepo.UpdateStock(reward.ID+100, -1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestExchangeRepository_CreatePendingChecksBalance(t *testing.T) {
+	repos := setupRepos(t)
+	rewardRepo := repository.NewRewardRepository(repos.db)
+	exchangeRepo := repository.NewExchangeRepository(repos.db)
+
+	family := &models.Family{Name: "Exchange Family", ParentAddress: parentAddress}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(family))
+	child := repos.createChild(t, parentAddress, childAddress)
+	reward := &models.Reward{FamilyID: family.ID, Name: "Bike Ride", TokenPrice: 25, Stock: 6, Active: true, CreatedBy: 1}
+	require.NoError(t, rewardRepo.Create(reward))
+
+	wei := func(units int) string { return utils.UnitsToTokenAmount(units).String() }
+	create := func(escrowed bool) (*models.Exchange, *models.OutboxMessage, error) {
+		var escrow *models.OutboxMessage
+		if escrowed {
+			escrow = &models.OutboxMessage{Kind: models.OutboxKindEscrowExchange, Recipient: childAddress, Amount: wei(25)}
+		}
+		exchange := &models.Exchange{RewardID: reward.ID, ChildID: child.ID, TokenAmount: 25, Status: models.ExchangeStatusPending}
+		return exchange, escrow, exchangeRepo.CreatePending(exchange, childAddress, escrow, true)
+	}
+	decline := func(exchange *models.Exchange) {
+		require.NoError(t, repos.db.Model(exchange).Update("status", models.ExchangeStatusDeclined).Error)
+	}
+
+	// 账本中没有铸币时不能兑换，不托管的兑换也一样
+	_, _, err := create(true)
+	assert.ErrorIs(t, err, repository.ErrInsufficientBalance)
+	_, _, err = create(false)
+	assert.ErrorIs(t, err, repository.ErrInsufficientBalance)
+
+	// 账本余额60：托管25、在账本中扣留25之后，第三次兑换不够
+	ledgerRepo := repository.NewLedgerRepository(repos.db)
+	require.NoError(t, ledgerRepo.Post(&models.LedgerTransaction{Kind: models.LedgerKindMint, Amount: wei(60), TxHash: "0x01"}, []models.LedgerEntry{
+		{Account: zeroAddress, AccountType: models.LedgerAccountIssuance, Amount: "-" + wei(60)},
+		{Account: childAddress, AccountType: models.LedgerAccountChild, ChildID: &child.ID, Amount: wei(60)},
+	}))
+	escrowedExchange, escrow, err := create(true)
+	require.NoError(t, err)
+	held, _, err := create(false)
+	require.NoError(t, err)
+	_, _, err = create(true)
+	assert.ErrorIs(t, err, repository.ErrInsufficientBalance)
+
+	// 拒绝后账本中的扣留释放
+	decline(held)
+	third, thirdEscrow, err := create(true)
+	require.NoError(t, err)
+
+	// 托管失败的兑换仍在待审核，代币留在钱包里，改为在账本中扣留
+	require.NoError(t, repos.db.Model(thirdEscrow).Update("status", models.OutboxStatusFailed).Error)
+	_, _, err = create(false)
+	assert.ErrorIs(t, err, repository.ErrInsufficientBalance)
+	decline(third)
+
+	// 已确认但索引器还没有记账的托管仍然占用余额；记账后账本余额已经扣除了这笔托管
+	require.NoError(t, repos.db.Model(escrow).Updates(map[string]interface{}{"status": models.OutboxStatusConfirmed, "tx_hash": "0x02"}).Error)
+	require.NoError(t, ledgerRepo.Post(&models.LedgerTransaction{Kind: models.LedgerKindEscrow, Amount: wei(25), TxHash: "0x02"}, []models.LedgerEntry{
+		{Account: childAddress, AccountType: models.LedgerAccountChild, ChildID: &child.ID, Amount: "-" + wei(25)},
+		{Account: registryAddress, AccountType: models.LedgerAccountEscrow, Amount: wei(25)},
+	}))
+	require.NoError(t, repos.db.Model(escrowedExchange).Update("contract_exchange_id", 1).Error)
+	approved, _, err := create(false)
+	require.NoError(t, err)
+
+	// 批准后排队销毁的代币在记账之前仍然占用余额
+	require.NoError(t, repos.db.Model(approved).Update("status", models.ExchangeStatusApproved).Error)
+	require.NoError(t, repository.NewOutboxRepository(repos.db).Create(&models.OutboxMessage{
+		Kind: models.OutboxKindBurnExchange, ExchangeID: &approved.ID, Recipient: childAddress, Amount: wei(25),
+	}))
+	_, _, err = create(false)
+	assert.ErrorIs(t, err, repository.ErrInsufficientBalance)
+
+	balance, err := ledgerRepo.Balance(childAddress)
+	require.NoError(t, err)
+	assert.Equal(t, wei(35), balance.String())
+	escrowed, err := ledgerRepo.Balance(registryAddress)
+	require.NoError(t, err)
+	assert.Equal(t, wei(25), escrowed.String())
+	issued, err := ledgerRepo.Balance(zeroAddress)
+	require.NoError(t, err)
+	assert.Equal(t, "-"+wei(60), issued.String())
+	err = ledgerRepo.Post(&models.LedgerTransaction{Kind: models.LedgerKindTransfer, Amount: "1", TxHash: "0x03"}, []models.LedgerEntry{
+		{Account: childAddress, AccountType: models.LedgerAccountChild, Amount: "-1"},
+		{Account: registryAddress, AccountType: models.LedgerAccountExternal, Amount: "2"},
+	})
+	assert.Error(t, err, "unbalanced transactions are rejected")
+
+	// 四次兑换成功，拒绝不经过仓库所以库存没有恢复
+	result, err := rewardRepo.GetByID(reward.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Stock)
+}
+
+// Tests for SavingsService
+func TestSavingsService_LockContributeAndProgress(t *testing.T) {
+	repos := setupRepos(t)
+	rewardRepo := repository.NewRewardRepository(repos.db)
+	exchangeRepo := repository.NewExchangeRepository(repos.db)
+	goalRepo := repository.NewSavingsGoalRepository(repos.db)
+	ledgerRepo := repository.NewLedgerRepository(repos.db)
+	savingsService := services.NewSavingsService(goalRepo, rewardRepo, ledgerRepo, nil)
+
+	family := &models.Family{Name: "Savings Family", ParentAddress: parentAddress}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(family))
+	child, err := repos.childRepo.GetByID(repos.createChild(t, parentAddress, childAddress).ID)
+	require.NoError(t, err)
+	bike := &models.Reward{FamilyID: family.ID, Name: "Bike", TokenPrice: 100, Stock: 1, Active: true, CreatedBy: 1}
+	require.NoError(t, rewardRepo.Create(bike))
+	iceCream := &models.Reward{FamilyID: family.ID, Name: "Ice Cream", TokenPrice: 30, Stock: 5, Active: true, CreatedBy: 1}
+	require.NoError(t, rewardRepo.Create(iceCream))
+
+	// 十天前铸造了60个代币，最近30天平均每天2个
+	minted := utils.UnitsToTokenAmount(60).String()
+	require.NoError(t, ledgerRepo.Post(&models.LedgerTransaction{Kind: models.LedgerKindMint, Amount: minted, TxHash: "0x01", OccurredAt: time.Now().AddDate(0, 0, -10)}, []models.LedgerEntry{
+		{Account: zeroAddress, AccountType: models.LedgerAccountIssuance, Amount: "-" + minted},
+		{Account: childAddress, AccountType: models.LedgerAccountChild, ChildID: &child.ID, Amount: minted},
+	}))
+
+	goal, err := savingsService.Create(child, bike.ID)
+	require.NoError(t, err)
+	_, err = savingsService.Create(child, bike.ID)
+	assert.ErrorIs(t, err, services.ErrSavingsGoalExists)
+
+	// 锁定时在事务中核对账本余额
+	_, err = goalRepo.Lock(goal, childAddress, 70, bike.TokenPrice, true)
+	assert.ErrorIs(t, err, repository.ErrInsufficientBalance)
+	updated, err := goalRepo.Lock(goal, childAddress, 50, bike.TokenPrice, true)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	// 锁定的代币不能用来兑换其他奖品
+	exchangeIceCream := func() error {
+		escrow := &models.OutboxMessage{Kind: models.OutboxKindEscrowExchange, Recipient: childAddress, Amount: utils.UnitsToTokenAmount(30).String()}
+		exchange := &models.Exchange{RewardID: iceCream.ID, ChildID: child.ID, TokenAmount: 30, Status: models.ExchangeStatusPending}
+		return exchangeRepo.CreatePending(exchange, childAddress, escrow, true)
+	}
+	assert.ErrorIs(t, exchangeIceCream(), repository.ErrInsufficientBalance)
+	goal, err = savingsService.Get(goal.ID)
+	require.NoError(t, err)
+	_, err = savingsService.Unlock(goal, child, 60)
+	assert.ErrorIs(t, err, services.ErrInvalidSavingsGoal)
+	goal, err = savingsService.Unlock(goal, child, 30)
+	require.NoError(t, err)
+	assert.Equal(t, 20, goal.LockedAmount)
+	require.NoError(t, exchangeIceCream())
+
+	// 锁定20，余额60减去托管中的30和锁定的20后可用10；还差70，按每天2个需要35天
+	progress, err := savingsService.Progress(goal, child)
+	require.NoError(t, err)
+	assert.Equal(t, utils.UnitsToTokenAmount(100).String(), progress.Target)
+	assert.Equal(t, utils.UnitsToTokenAmount(20).String(), progress.Reserved)
+	assert.Equal(t, utils.UnitsToTokenAmount(10).String(), progress.Available)
+	assert.Equal(t, utils.UnitsToTokenAmount(30).String(), progress.Saved)
+	assert.Equal(t, utils.UnitsToTokenAmount(70).String(), progress.Remaining)
+	assert.Equal(t, 30, progress.Percent)
+	assert.Equal(t, utils.UnitsToTokenAmount(2).String(), progress.EarningRate)
+	require.NotNil(t, progress.EstimatedCompletion)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 35), *progress.EstimatedCompletion, time.Minute)
+
+	// 家长不指定数量时配捐孩子锁定的数量，配捐的代币铸造给孩子
+	contribution, err := savingsService.Contribute(goal, child, parentAddress, nil, "Matching your savings")
+	require.NoError(t, err)
+	assert.Equal(t, 20, contribution.Amount)
+	require.NotNil(t, contribution.OutboxMessageID)
+	var mint models.OutboxMessage
+	require.NoError(t, repos.db.First(&mint, *contribution.OutboxMessageID).Error)
+	assert.Equal(t, models.OutboxKindMintContribution, mint.Kind)
+	assert.Equal(t, utils.UnitsToTokenAmount(20).String(), mint.Amount)
+
+	goal, err = savingsService.Get(goal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 20, goal.MatchedAmount)
+	require.Len(t, goal.Contributions, 1)
+	_, err = savingsService.Contribute(goal, child, parentAddress, nil, "")
+	assert.ErrorIs(t, err, services.ErrInvalidSavingsGoal, "everything locked is already matched")
+	tooMuch := 70
+	_, err = savingsService.Contribute(goal, child, parentAddress, &tooMuch, "")
+	assert.ErrorIs(t, err, services.ErrInvalidSavingsGoal)
+	_, err = savingsService.Unlock(goal, child, 30)
+	assert.ErrorIs(t, err, services.ErrInvalidSavingsGoal, "matched tokens cannot be unlocked")
+
+	// 兑换目标奖品后目标结束
+	bikeExchange := &models.Exchange{RewardID: bike.ID, ChildID: child.ID, TokenAmount: 100, Status: models.ExchangeStatusPending}
+	require.NoError(t, exchangeRepo.CreatePending(bikeExchange, "", nil, false))
+	goal, err = savingsService.Get(goal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SavingsGoalRedeemed, goal.Status)
+	require.NotNil(t, goal.ExchangeID)
+	assert.Equal(t, bikeExchange.ID, *goal.ExchangeID)
+	_, err = savingsService.Lock(goal, child, 1)
+	assert.ErrorIs(t, err, services.ErrSavingsGoalState)
+
+	// 删除奖品时进行中的目标被取消
+	next, err := savingsService.Create(child, iceCream.ID)
+	require.NoError(t, err)
+	require.NoError(t, rewardRepo.Delete(iceCream.ID))
+	next, err = savingsService.Get(next.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SavingsGoalCancelled, next.Status)
+	assert.Nil(t, next.Reward)
+	_, err = savingsService.Progress(next, child)
+	assert.ErrorIs(t, err, services.ErrSavingsGoalState)
+}
+
+func TestRewardProposalService_DecidesOnce(t *testing.T) {
+	repos := setupRepos(t)
+	proposalRepo := repository.NewRewardProposalRepository(repos.db)
+	proposalService := services.NewRewardProposalService(proposalRepo, nil)
+
+	family := &models.Family{Name: "Wishlist Family", ParentAddress: parentAddress}
+	require.NoError(t, repos.familyRepo.CreateWithOwner(family))
+	child, err := repos.childRepo.GetByWalletAddress(repos.createChild(t, parentAddress, childAddress).WalletAddress)
+	require.NoError(t, err)
+
+	proposal, err := proposalService.Propose(child, models.RewardProposalCreateRequest{Name: "Bike", Description: "A red one"})
+	require.NoError(t, err)
+	assert.Equal(t, family.ID, proposal.FamilyID)
+
+	// 两个家长同时处理同一个愿望，先到的生效，另一个不会再创建奖品
+	stale := *proposal
+	accepted, err := proposalService.Accept(proposal, 1, parentAddress, models.RewardProposalAcceptRequest{TokenPrice: 40})
+	require.NoError(t, err)
+	require.NotNil(t, accepted.Reward)
+	assert.Equal(t, "Bike", accepted.Reward.Name)
+	assert.Equal(t, "A red one", accepted.Reward.Description)
+	assert.Equal(t, 40, accepted.Reward.TokenPrice)
+
+	_, err = proposalService.Accept(&stale, 1, parentAddress, models.RewardProposalAcceptRequest{TokenPrice: 60})
+	assert.ErrorIs(t, err, services.ErrRewardProposalState)
+	_, err = proposalService.Decline(&stale, parentAddress, "Too expensive")
+	assert.ErrorIs(t, err, services.ErrRewardProposalState)
+
+	var rewards int64
+	require.NoError(t, repos.db.Model(&models.Reward{}).Where("family_id = ?", family.ID).Count(&rewards).Error)
+	assert.Equal(t, int64(1), rewards)
 }
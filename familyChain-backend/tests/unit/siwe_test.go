@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"eth-for-babies-backend/internal/config"
+	"eth-for-babies-backend/internal/repository"
+	"eth-for-babies-backend/internal/services"
+	"eth-for-babies-backend/internal/utils"
+)
+
+const siweExample = `example.com wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ExampleOrg Terms of Service: https://example.com/tos
+
+URI: https://example.com/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Expiration Time: 2021-09-30T16:35:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParseSiweMessage(t *testing.T) {
+	msg, err := utils.ParseSiweMessage(siweExample)
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com", msg.Domain)
+	assert.Equal(t, common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), msg.Address)
+	assert.Equal(t, "I accept the ExampleOrg Terms of Service: https://example.com/tos", msg.Statement)
+	assert.Equal(t, "https://example.com/login", msg.URI)
+	assert.EqualValues(t, 1, msg.ChainID)
+	assert.Equal(t, "32891756", msg.Nonce)
+	assert.Equal(t, time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC), msg.IssuedAt)
+	require.NotNil(t, msg.ExpirationTime)
+	assert.Len(t, msg.Resources, 2)
+
+	// 生成的文本与原消息一致
+	assert.Equal(t, siweExample, msg.String())
+
+	assert.NoError(t, msg.ValidAt(msg.IssuedAt))
+	assert.Error(t, msg.ValidAt(*msg.ExpirationTime))
+}
+
+func TestParseSiweMessageWithoutStatement(t *testing.T) {
+	message := strings.Replace(siweExample, "I accept the ExampleOrg Terms of Service: https://example.com/tos\n\n", "\n", 1)
+
+	msg, err := utils.ParseSiweMessage(message)
+	require.NoError(t, err)
+	assert.Empty(t, msg.Statement)
+	assert.Equal(t, message, msg.String())
+}
+
+func TestParseSiweMessageRejectsMalformedFields(t *testing.T) {
+	cases := map[string][2]string{
+		"bad header":        {"wants you to sign in", "wants to sign in"},
+		"lowercase address": {"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"},
+		"bad version":       {"Version: 1", "Version: 2"},
+		"bad chain ID":      {"Chain ID: 1", "Chain ID: one"},
+		"short nonce":       {"Nonce: 32891756", "Nonce: 123"},
+		"bad issued at":     {"Issued At: 2021-09-30T16:25:24Z", "Issued At: yesterday"},
+		"missing nonce":     {"Nonce: 32891756\n", ""},
+		"bad expiration":    {"Expiration Time: 2021-09-30T16:35:24Z", "Expiration Time: soon"},
+		"trailing content":  {"my-web2-claim.json", "my-web2-claim.json\nextra"},
+	}
+	for name, c := range cases {
+		_, err := utils.ParseSiweMessage(strings.Replace(siweExample, c[0], c[1], 1))
+		assert.Error(t, err, name)
+	}
+}
+
+func newAuthService(t *testing.T, allowMock bool) *services.AuthService {
+	repos := setupRepos(t)
+	return services.NewAuthService(repository.NewAuthNonceRepository(repos.db), config.AuthConfig{
+		Domain:              "localhost:5173",
+		URI:                 "http://localhost:5173",
+		Statement:           "Sign in to Family Task Chain.",
+		NonceTTL:            5 * time.Minute,
+		AllowMockSignatures: allowMock,
+	}, 1337)
+}
+
+func personalSign(t *testing.T, key *ecdsa.PrivateKey, message string) string {
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	require.NoError(t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(signature)
+}
+
+func TestAuthService_VerifyLogin(t *testing.T) {
+	service := newAuthService(t, false)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	issued, err := service.IssueNonce(strings.ToLower(address.Hex()))
+	require.NoError(t, err)
+	message := issued.String()
+
+	// 别的钱包签名
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = service.VerifyLogin(message, personalSign(t, other, message))
+	assert.True(t, errors.Is(err, services.ErrLoginRejected))
+
+	// 模拟签名未开启
+	_, err = service.VerifyLogin(message, "0x"+strings.Repeat("0", 130))
+	assert.True(t, errors.Is(err, services.ErrLoginRejected))
+
+	msg, err := service.VerifyLogin(message, personalSign(t, key, message))
+	require.NoError(t, err)
+	assert.Equal(t, address, msg.Address)
+
+	// nonce只能使用一次
+	_, err = service.VerifyLogin(message, personalSign(t, key, message))
+	assert.True(t, errors.Is(err, services.ErrLoginRejected))
+}
+
+func TestAuthService_VerifyLoginChecksFields(t *testing.T) {
+	service := newAuthService(t, false)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tamper := map[string]func(m *utils.SiweMessage){
+		"domain":   func(m *utils.SiweMessage) { m.Domain = "evil.example" },
+		"uri":      func(m *utils.SiweMessage) { m.URI = "https://evil.example" },
+		"chain id": func(m *utils.SiweMessage) { m.ChainID = 1 },
+		"expired": func(m *utils.SiweMessage) {
+			expired := time.Now().Add(-time.Minute)
+			m.ExpirationTime = &expired
+		},
+		"future": func(m *utils.SiweMessage) { m.IssuedAt = time.Now().Add(time.Hour) },
+		"nonce":  func(m *utils.SiweMessage) { m.Nonce = "unknownnonce123" },
+	}
+	for name, modify := range tamper {
+		issued, err := service.IssueNonce(crypto.PubkeyToAddress(key.PublicKey).Hex())
+		require.NoError(t, err)
+		modify(issued)
+		message := issued.String()
+
+		_, err = service.VerifyLogin(message, personalSign(t, key, message))
+		assert.True(t, errors.Is(err, services.ErrLoginRejected), name)
+	}
+}
+
+func TestAuthService_MockSignatureBehindFlag(t *testing.T) {
+	service := newAuthService(t, true)
+	address := "0x3333333333333333333333333333333333333333"
+
+	issued, err := service.IssueNonce(address)
+	require.NoError(t, err)
+
+	msg, err := service.VerifyLogin(issued.String(), "0x"+strings.Repeat("0", 130))
+	require.NoError(t, err)
+	assert.True(t, strings.EqualFold(address, msg.Address.Hex()))
+
+	// 模拟签名也不能重放nonce
+	_, err = service.VerifyLogin(issued.String(), "0x"+strings.Repeat("0", 130))
+	assert.True(t, errors.Is(err, services.ErrLoginRejected))
+}
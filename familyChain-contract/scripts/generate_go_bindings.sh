@@ -10,6 +10,7 @@ NC='\033[0m' # 无颜色
 CONTRACT_DIR="$(pwd)"
 BACKEND_DIR="../familyChain-backend"
 ABI_DIR="${CONTRACT_DIR}/abi"
+ARTIFACTS_DIR="${CONTRACT_DIR}/artifacts/solidity"
 OUTPUT_DIR="${BACKEND_DIR}/pkg/blockchain/contracts"
 
 # 确保输出目录存在
@@ -33,6 +34,8 @@ generate_binding() {
     local abi_file="${ABI_DIR}/${contract_name}.json"
     local output_dir="${OUTPUT_DIR}/${package_name}"
     local output_file="${output_dir}/${package_name}.go"
+    local artifact_file="${ARTIFACTS_DIR}/${contract_name}.sol/${contract_name}.json"
+    local bin_file="${output_dir}/${contract_name}.bin"
     
     # 确保输出目录存在
     mkdir -p ${output_dir}
@@ -40,10 +43,21 @@ generate_binding() {
     if [ -f "${abi_file}" ]; then
         echo -e "${YELLOW}正在生成 ${contract_name} 的 Go 绑定...${NC}"
         
+        # 编译产物中有字节码时一并传给 abigen，生成 Deploy 函数
+        local bin_args=""
+        if [ -f "${artifact_file}" ]; then
+            node -e "process.stdout.write(require('${artifact_file}').bytecode)" > ${bin_file}
+            bin_args="--bin=${bin_file}"
+        else
+            echo -e "${YELLOW}⚠️ 未找到编译产物 ${artifact_file}，生成的绑定不包含 Deploy 函数${NC}"
+        fi
+
         # 使用包名作为包，合约名称作为类型前缀
-        abigen --abi=${abi_file} --pkg=${package_name} --type=${type_name} --out=${output_file}
+        abigen --abi=${abi_file} ${bin_args} --pkg=${package_name} --type=${type_name} --out=${output_file}
+        local status=$?
+        rm -f ${bin_file}
         
-        if [ $? -eq 0 ]; then
+        if [ $status -eq 0 ]; then
             echo -e "${GREEN}✅ 成功生成 ${contract_name} 的 Go 绑定: ${output_file}${NC}"
         else
             echo -e "${RED}❌ 生成 ${contract_name} 的 Go 绑定失败${NC}"
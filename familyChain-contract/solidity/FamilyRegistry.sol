@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/**
+ * @title FamilyRegistry
+ * @dev Contract for registering families and the children that belong to them
+ */
+contract FamilyRegistry {
+    struct Family {
+        uint256 id;
+        address parent;
+        string name;
+        bool active;
+    }
+
+    struct Child {
+        address childAddress;
+        string name;
+        uint8 age;
+        bool active;
+    }
+
+    mapping(uint256 => Family) public families;
+    uint256 public familyCount = 0;
+
+    // Families created by each parent
+    mapping(address => uint256[]) public parentFamilies;
+    // Family each child belongs to (0 when the address is not a child)
+    mapping(address => uint256) public childToFamily;
+
+    mapping(uint256 => mapping(address => Child)) private children;
+    mapping(uint256 => address[]) private familyChildren;
+
+    // Events
+    event FamilyCreated(uint256 indexed familyId, address indexed parent, string name);
+    event FamilyUpdated(uint256 indexed familyId, string name);
+    event ChildAdded(uint256 indexed familyId, address indexed childAddress, string name, uint8 age);
+    event ChildRemoved(uint256 indexed familyId, address indexed childAddress);
+
+    modifier onlyFamilyParent(uint256 familyId) {
+        require(families[familyId].active, "Family does not exist");
+        require(families[familyId].parent == msg.sender, "Only family parent can perform this action");
+        _;
+    }
+
+    /**
+     * @dev Creates a new family with the caller as its parent
+     */
+    function createFamily(string memory name) public returns (uint256) {
+        familyCount++;
+        families[familyCount] = Family(familyCount, msg.sender, name, true);
+        parentFamilies[msg.sender].push(familyCount);
+
+        emit FamilyCreated(familyCount, msg.sender, name);
+        return familyCount;
+    }
+
+    /**
+     * @dev Renames a family
+     */
+    function updateFamily(uint256 familyId, string memory name) public onlyFamilyParent(familyId) {
+        families[familyId].name = name;
+
+        emit FamilyUpdated(familyId, name);
+    }
+
+    /**
+     * @dev Adds a child to a family; a child can only belong to one family
+     */
+    function addChild(uint256 familyId, address childAddress, string memory name, uint8 age) public onlyFamilyParent(familyId) {
+        require(childAddress != address(0), "Invalid child address");
+        require(childToFamily[childAddress] == 0, "Child already belongs to a family");
+
+        children[familyId][childAddress] = Child(childAddress, name, age, true);
+        familyChildren[familyId].push(childAddress);
+        childToFamily[childAddress] = familyId;
+
+        emit ChildAdded(familyId, childAddress, name, age);
+    }
+
+    /**
+     * @dev Removes a child from a family
+     */
+    function removeChild(uint256 familyId, address childAddress) public onlyFamilyParent(familyId) {
+        require(childToFamily[childAddress] == familyId, "Child does not belong to this family");
+
+        address[] storage members = familyChildren[familyId];
+        for (uint256 i = 0; i < members.length; i++) {
+            if (members[i] == childAddress) {
+                members[i] = members[members.length - 1];
+                members.pop();
+                break;
+            }
+        }
+
+        delete children[familyId][childAddress];
+        delete childToFamily[childAddress];
+
+        emit ChildRemoved(familyId, childAddress);
+    }
+
+    /**
+     * @dev Returns a child's details
+     */
+    function getChild(uint256 familyId, address childAddress) public view returns (address, string memory, uint8, bool) {
+        Child storage child = children[familyId][childAddress];
+        return (child.childAddress, child.name, child.age, child.active);
+    }
+
+    /**
+     * @dev Returns the number of children in a family
+     */
+    function getChildCount(uint256 familyId) public view returns (uint256) {
+        return familyChildren[familyId].length;
+    }
+
+    /**
+     * @dev Returns the address of the child at the given index of a family
+     */
+    function getChildAddressByIndex(uint256 familyId, uint256 index) public view returns (address) {
+        require(index < familyChildren[familyId].length, "Index out of bounds");
+        return familyChildren[familyId][index];
+    }
+
+    /**
+     * @dev Returns whether the account has created at least one family
+     */
+    function isParent(address account) public view returns (bool) {
+        return parentFamilies[account].length > 0;
+    }
+
+    /**
+     * @dev Returns whether the account belongs to a family as a child
+     */
+    function isChild(address account) public view returns (bool) {
+        return childToFamily[account] != 0;
+    }
+}
@@ -22,14 +22,15 @@ contract RewardRegistry is Ownable {
         bool active;          // 是否激活
     }
 
-    // 兑换记录结构
+    // 兑换记录结构，代币在家长审核之前托管在本合约中
     struct Exchange {
         uint256 id;
         uint256 rewardId;     // 奖品ID
         address child;        // 兑换的孩子地址
         uint256 tokenAmount;  // 代币数量
         uint256 exchangeDate; // 兑换日期
-        bool fulfilled;       // 是否已发放
+        bool fulfilled;       // 是否已批准（托管的代币已销毁）
+        bool declined;        // 是否已拒绝（托管的代币已退还）
     }
 
     // 合约状态变量
@@ -51,6 +52,7 @@ contract RewardRegistry is Ownable {
     event RewardUpdated(uint256 indexed rewardId, string name, uint256 tokenPrice, bool active);
     event RewardExchanged(uint256 indexed exchangeId, uint256 indexed rewardId, address indexed child, uint256 tokenAmount);
     event ExchangeFulfilled(uint256 indexed exchangeId, address indexed parent);
+    event ExchangeDeclined(uint256 indexed exchangeId, address indexed parent);
     
     /**
      * @dev 构造函数，设置代币合约地址
@@ -135,58 +137,101 @@ contract RewardRegistry is Ownable {
     }
     
     /**
-     * @dev 兑换奖品
+     * @dev 兑换奖品，代币托管在本合约中，等待家长批准或拒绝
      * @param _rewardId 奖品ID
      */
     function exchangeReward(uint256 _rewardId) public returns (uint256) {
+        return _exchange(msg.sender, _rewardId);
+    }
+
+    /**
+     * @dev 后端代孩子兑换奖品（孩子通过API申请兑换时）
+     * @param _child 孩子地址
+     * @param _rewardId 奖品ID
+     */
+    function exchangeRewardFor(address _child, uint256 _rewardId) public onlyOwner returns (uint256) {
+        return _exchange(_child, _rewardId);
+    }
+
+    /**
+     * @dev 批准兑换，销毁托管的代币并标记为已发放
+     * @param _exchangeId 兑换记录ID
+     */
+    function fulfillExchange(uint256 _exchangeId) public {
+        Exchange storage exchange = _pendingExchange(_exchangeId);
+
+        exchange.fulfilled = true;
+        tokenContract.burn(address(this), exchange.tokenAmount);
+
+        emit ExchangeFulfilled(_exchangeId, msg.sender);
+    }
+
+    /**
+     * @dev 拒绝兑换，把托管的代币退还给孩子并恢复库存
+     * @param _exchangeId 兑换记录ID
+     */
+    function declineExchange(uint256 _exchangeId) public {
+        Exchange storage exchange = _pendingExchange(_exchangeId);
+
+        exchange.declined = true;
+        rewards[exchange.rewardId].stock++;
+        require(tokenContract.transfer(exchange.child, exchange.tokenAmount), "Refund failed");
+
+        emit ExchangeDeclined(_exchangeId, msg.sender);
+    }
+
+    /**
+     * @dev 创建兑换记录并托管孩子的代币
+     */
+    function _exchange(address _child, uint256 _rewardId) internal returns (uint256) {
         Reward storage reward = rewards[_rewardId];
-        
+
         require(reward.active, "Reward is not active");
         require(reward.stock > 0, "Reward out of stock");
-        
+
         uint256 tokenPrice = reward.tokenPrice;
-        
+
         // 检查代币余额
-        require(tokenContract.balanceOf(msg.sender) >= tokenPrice, "Insufficient token balance");
-        
-        // 扣除代币
-        tokenContract.burn(msg.sender, tokenPrice);
-        
+        require(tokenContract.balanceOf(_child) >= tokenPrice, "Insufficient token balance");
+
+        // 托管代币
+        tokenContract.escrow(_child, tokenPrice);
+
         // 减少库存
         reward.stock--;
-        
+
         // 创建兑换记录
         exchangeCount++;
         exchanges[exchangeCount] = Exchange(
             exchangeCount,
             _rewardId,
-            msg.sender,
+            _child,
             tokenPrice,
             block.timestamp,
+            false,
             false
         );
-        
+
         // 添加到孩子的兑换记录
-        childExchanges[msg.sender].push(exchangeCount);
-        
-        emit RewardExchanged(exchangeCount, _rewardId, msg.sender, tokenPrice);
+        childExchanges[_child].push(exchangeCount);
+
+        emit RewardExchanged(exchangeCount, _rewardId, _child, tokenPrice);
         return exchangeCount;
     }
-    
+
     /**
-     * @dev 标记兑换记录为已发放
-     * @param _exchangeId 兑换记录ID
+     * @dev 获取待审核的兑换记录，只有奖品创建者或合约所有者（后端）可以审核
      */
-    function fulfillExchange(uint256 _exchangeId) public {
+    function _pendingExchange(uint256 _exchangeId) internal view returns (Exchange storage) {
         Exchange storage exchange = exchanges[_exchangeId];
         Reward storage reward = rewards[exchange.rewardId];
-        
-        require(reward.creator == msg.sender, "Only reward creator can fulfill the exchange");
+
+        require(exchange.id != 0, "Exchange does not exist");
+        require(reward.creator == msg.sender || owner() == msg.sender, "Only reward creator can review the exchange");
         require(!exchange.fulfilled, "Exchange already fulfilled");
-        
-        exchange.fulfilled = true;
-        
-        emit ExchangeFulfilled(_exchangeId, msg.sender);
+        require(!exchange.declined, "Exchange already declined");
+
+        return exchange;
     }
     
     /**
@@ -251,7 +296,7 @@ contract RewardRegistry is Ownable {
      * @param _exchangeId 兑换记录ID
      */
     function getExchange(uint256 _exchangeId) public view returns (
-        uint256, uint256, address, uint256, uint256, bool
+        uint256, uint256, address, uint256, uint256, bool, bool
     ) {
         Exchange memory exchange = exchanges[_exchangeId];
         return (
@@ -260,7 +305,8 @@ contract RewardRegistry is Ownable {
             exchange.child,
             exchange.tokenAmount,
             exchange.exchangeDate,
-            exchange.fulfilled
+            exchange.fulfilled,
+            exchange.declined
         );
     }
 } 
\ No newline at end of file
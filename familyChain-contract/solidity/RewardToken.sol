@@ -15,6 +15,7 @@ contract RewardToken is ERC20, Ownable {
     event MinterRemoved(address indexed minter);
     event TokensMinted(address indexed to, uint256 amount);
     event TokensBurned(address indexed from, uint256 amount);
+    event TokensEscrowed(address indexed from, address indexed escrow, uint256 amount);
 
     /**
      * @dev Constructor that gives the msg.sender all of existing tokens.
@@ -68,6 +69,17 @@ contract RewardToken is ERC20, Ownable {
         emit TokensBurned(from, amount);
     }
     
+    /**
+     * @dev Moves `amount` tokens from `from` to the calling minter, which holds them in escrow
+     * until it burns them or transfers them back
+     */
+    function escrow(address from, uint256 amount) public onlyMinter {
+        require(from != address(0), "ERC20: escrow from the zero address");
+        require(balanceOf(from) >= amount, "ERC20: escrow amount exceeds balance");
+        _transfer(from, msg.sender, amount);
+        emit TokensEscrowed(from, msg.sender, amount);
+    }
+
     /**
      * @dev Transfers rewards from contract owner to child
      */
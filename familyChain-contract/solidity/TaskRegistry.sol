@@ -34,6 +34,8 @@ contract TaskRegistry {
     event TaskApproved(uint256 indexed taskId, address indexed approvedBy);
     event RewardTransferred(uint256 indexed taskId, address indexed recipient, uint256 amount);
     event TaskRejected(uint256 indexed taskId, address indexed rejectedBy);
+    event TaskApprovedWithPayout(uint256 indexed taskId, address indexed approvedBy, uint256 payout);
+    event TaskReturnedForRework(uint256 indexed taskId, address indexed returnedBy);
 
     /**
      * @dev Creates a new task
@@ -104,6 +106,50 @@ contract TaskRegistry {
         recipient.transfer(rewardAmount);
     }
 
+    /**
+     * @dev Approves a completed task with partial credit: transfers `payout` to the child
+     * and refunds the rest of the escrowed reward to the creator
+     */
+    function approveTaskWithPayout(uint256 taskId, uint256 payout) public {
+        require(tasks[taskId].creator == msg.sender, "Only task creator can approve the task");
+        require(tasks[taskId].completed, "Task not completed yet");
+        require(!tasks[taskId].approved, "Task already approved");
+        require(!tasks[taskId].rejected, "Task already rejected");
+        require(payout <= tasks[taskId].reward, "Payout exceeds reward");
+
+        tasks[taskId].approved = true;
+
+        address payable recipient = payable(tasks[taskId].assignedTo);
+        uint256 refund = tasks[taskId].reward - payout;
+
+        emit TaskApproved(taskId, msg.sender);
+        emit TaskApprovedWithPayout(taskId, msg.sender, payout);
+
+        if (payout > 0) {
+            recipient.transfer(payout);
+            emit RewardTransferred(taskId, recipient, payout);
+        }
+        if (refund > 0) {
+            address payable creatorAddress = payable(tasks[taskId].creator);
+            creatorAddress.transfer(refund);
+            emit RewardTransferred(taskId, creatorAddress, refund);
+        }
+    }
+
+    /**
+     * @dev Sends a completed task back to the child for rework; the reward stays in escrow
+     */
+    function returnForRework(uint256 taskId) public {
+        require(tasks[taskId].creator == msg.sender, "Only task creator can return the task");
+        require(tasks[taskId].completed, "Task not completed yet");
+        require(!tasks[taskId].approved, "Task already approved");
+        require(!tasks[taskId].rejected, "Task already rejected");
+
+        tasks[taskId].completed = false;
+
+        emit TaskReturnedForRework(taskId, msg.sender);
+    }
+
     /**
      * @dev Rejects a completed task and refunds the reward to the creator
      */
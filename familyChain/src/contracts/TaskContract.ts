@@ -9,6 +9,8 @@ interface TaskContract extends ethers.BaseContract {
   assignTask(taskId: number, childAddress: string): Promise<ethers.ContractTransactionResponse>;
   completeTask(taskId: number): Promise<ethers.ContractTransactionResponse>;
   approveTask(taskId: number): Promise<ethers.ContractTransactionResponse>;
+  approveTaskWithPayout(taskId: number, payout: ethers.BigNumberish): Promise<ethers.ContractTransactionResponse>;
+  returnForRework(taskId: number): Promise<ethers.ContractTransactionResponse>;
   rejectTask(taskId: number): Promise<ethers.ContractTransactionResponse>;
   getTask(taskId: number): Promise<[bigint, string, string, string, string, bigint, boolean, boolean, boolean]>;
   owner(): Promise<string>;
@@ -181,6 +183,24 @@ export const approveTask = async (
   return tx.wait();
 };
 
+// Pays part of the escrowed reward to the child and refunds the rest to the parent
+export const approveTaskWithPayout = async (
+  contract: TaskContract,
+  taskId: number,
+  payout: ethers.BigNumberish
+) => {
+  const tx = await contract.approveTaskWithPayout(taskId, payout);
+  return tx.wait();
+};
+
+export const returnForRework = async (
+  contract: TaskContract,
+  taskId: number
+) => {
+  const tx = await contract.returnForRework(taskId);
+  return tx.wait();
+};
+
 export const rejectTask = async (
   contract: TaskContract,
   taskId: number
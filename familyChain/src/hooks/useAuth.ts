@@ -39,6 +39,7 @@ export const useAuth = () => {
       } catch (error) {
         // 清除无效的本地数据
         localStorage.removeItem('auth_token');
+        localStorage.removeItem('refresh_token');
         localStorage.removeItem('user_data');
       }
     }
@@ -69,6 +70,7 @@ export const useAuth = () => {
       const maxNonceRetries = 3;
       let nonceResponse = null;
       let nonce = '';
+      let signInMessage = '';
       let connectionError = false;
       
       for (let i = 0; i < maxNonceRetries; i++) {
@@ -82,6 +84,7 @@ export const useAuth = () => {
           
           if (nonceResponse.success && nonceResponse.data) {
             nonce = nonceResponse.data.nonce;
+            signInMessage = nonceResponse.data.message;
             console.log(`成功获取nonce: ${nonce}`);
             connectionError = false;
             break;
@@ -113,6 +116,7 @@ export const useAuth = () => {
           nonceResponse = await authApi.getNonce(walletAddress);
           if (nonceResponse.success && nonceResponse.data) {
             nonce = nonceResponse.data.nonce;
+            signInMessage = nonceResponse.data.message;
             console.log(`注册后成功获取nonce: ${nonce}`);
           } else {
             throw new Error(nonceResponse.error || '获取nonce失败');
@@ -125,8 +129,8 @@ export const useAuth = () => {
         }
       }
       
-      // 2. 签名消息
-      const message = `Welcome to Family Task Chain!\n\nClick to sign in and accept the Terms of Service.\n\nThis request will not trigger a blockchain transaction or cost any gas fees.\n\nNonce: ${nonce}`;
+      // 2. 签名消息：优先使用服务器生成的 EIP-4361 消息，离线时才使用本地消息
+      const message = signInMessage || `Welcome to Family Task Chain!\n\nClick to sign in and accept the Terms of Service.\n\nThis request will not trigger a blockchain transaction or cost any gas fees.\n\nNonce: ${nonce}`;
       console.log('待签名消息:', message);
       
       // 如果是手动输入地址，使用特殊方式处理
@@ -138,11 +142,12 @@ export const useAuth = () => {
           const mockSignature = '0x' + '0'.repeat(130); // 模拟签名
           
           // 调用后端登录API
-          const loginResponse = await authApi.login(walletAddress, mockSignature, role);
+          const loginResponse = await authApi.login(walletAddress, message, mockSignature, role);
           
           if (loginResponse.success && loginResponse.data) {
-            const { token, user } = loginResponse.data;
-            
+            const { token, refresh_token, user } = loginResponse.data;
+
+            apiClient.setRefreshToken(refresh_token);
             // 保存真实的JWT token
             apiClient.setToken(token);
             localStorage.setItem('auth_token', token);
@@ -164,10 +169,11 @@ export const useAuth = () => {
             const registerResponse = await authApi.register(walletAddress, role);
             if (registerResponse.success) {
               // 注册成功后再次尝试登录
-              const retryLoginResponse = await authApi.login(walletAddress, mockSignature, role);
+              const retryLoginResponse = await authApi.login(walletAddress, message, mockSignature, role);
               if (retryLoginResponse.success && retryLoginResponse.data) {
-                const { token, user } = retryLoginResponse.data;
-                
+                const { token, refresh_token, user } = retryLoginResponse.data;
+
+                apiClient.setRefreshToken(refresh_token);
                 apiClient.setToken(token);
                 localStorage.setItem('auth_token', token);
                 localStorage.setItem('user_data', JSON.stringify(user));
@@ -235,7 +241,7 @@ export const useAuth = () => {
             await new Promise(resolve => setTimeout(resolve, delay));
           }
           
-          loginResponse = await authApi.login(walletAddress, signature, role);
+          loginResponse = await authApi.login(walletAddress, message, signature, role);
           
           if (loginResponse.success && loginResponse.data) {
             loginSuccess = true;
@@ -299,7 +305,8 @@ export const useAuth = () => {
       }
 
       // 登录成功
-      const { token, user } = loginResponse!.data!;
+      const { token, refresh_token, user } = loginResponse!.data!;
+      apiClient.setRefreshToken(refresh_token);
       apiClient.setToken(token);
       localStorage.setItem('auth_token', token);
       localStorage.setItem('user_data', JSON.stringify(user));
@@ -346,6 +353,7 @@ export const useAuth = () => {
     } finally {
       apiClient.clearToken();
       localStorage.removeItem('auth_token');
+      localStorage.removeItem('refresh_token');
       localStorage.removeItem('user_data');
       setAuthState({
         user: null,
@@ -359,11 +367,7 @@ export const useAuth = () => {
   // 刷新令牌
   const refreshToken = useCallback(async () => {
     try {
-      const response = await authApi.refresh();
-      if (response.success && response.data) {
-        apiClient.setToken(response.data.token);
-        return true;
-      }
+      return await authApi.refresh();
     } catch (error) {
       console.warn('刷新令牌失败:', error);
     }
@@ -204,9 +204,9 @@ const RewardStore = () => {
         console.log('此奖品有区块链ID，尝试通过智能合约兑换:', reward.contract_reward_id);
         
         // 调用智能合约进行兑换
-        const contractSuccess = await exchangeRewardWithContract(reward.contract_reward_id);
+        const contractExchangeId = await exchangeRewardWithContract(reward.contract_reward_id);
         
-        if (contractSuccess) {
+        if (contractExchangeId !== null) {
           console.log('区块链兑换成功，清除余额缓存');
           
           // 清除余额缓存，以便下次获取到最新余额
@@ -221,11 +221,11 @@ const RewardStore = () => {
           
           toast.success('区块链兑换成功！', { duration: 4000 });
           
-          // 调用后端API记录兑换，标记为代币已在区块链上销毁
+          // 把链上兑换ID交给后端核对，代币已经托管在奖品合约中，等待家长审核
           const response = await exchangeApi.create({
             reward_id: reward.id,
             notes: `由${currentChild.name}通过区块链兑换`,
-            token_burned: true // 告诉后端代币已在区块链上销毁
+            contract_exchange_id: contractExchangeId
           });
           
           console.log('API兑换响应:', response);
@@ -244,12 +244,12 @@ const RewardStore = () => {
               child_id: currentChild.id,
               child_name: currentChild.name,
               token_amount: reward.token_price,
-              status: 'completed', // 已经自动完成
+              status: 'pending', // 等待家长审核
               exchange_date: new Date().toISOString(),
-              completed_date: new Date().toISOString(),
               created_at: new Date().toISOString(),
               updated_at: new Date().toISOString(),
-              notes: `由${currentChild.name}通过区块链兑换，已自动完成`
+              notes: `由${currentChild.name}通过区块链兑换`,
+              contract_exchange_id: contractExchangeId
             };
             
             setExchanges(prev => [newExchange, ...prev]);
@@ -283,8 +283,7 @@ const RewardStore = () => {
       // 调用后端API处理兑换
       const response = await exchangeApi.create({
         reward_id: reward.id,
-        notes: `由${currentChild.name}兑换`,
-        token_burned: false // 告诉后端代币在前端未销毁，需要后端处理
+        notes: `由${currentChild.name}兑换`
       });
       
       console.log('API兑换响应:', response);
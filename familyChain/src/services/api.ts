@@ -25,6 +25,7 @@ interface User {
   id: number;
   wallet_address: string;
   role: 'parent' | 'child';
+  email?: string;
   created_at: string;
   updated_at: string;
 }
@@ -34,6 +35,9 @@ interface Family {
   id: number;
   name: string;
   parent_address: string;
+  // 迟交规则：过期任务是否还能提交，以及迟交减少奖励的百分比
+  allow_late_submissions: boolean;
+  late_penalty_percent: number;
   created_at: string;
   updated_at: string;
   children?: Child[];
@@ -60,7 +64,7 @@ interface Task {
   description: string;
   reward_amount: string;
   difficulty: 'easy' | 'medium' | 'hard';
-  status: 'pending' | 'in_progress' | 'completed' | 'approved' | 'rejected';
+  status: 'pending' | 'in_progress' | 'completed' | 'approved' | 'rejected' | 'expired';
   assigned_child_id?: number;
   created_by: string;
   contract_task_id?: number;
@@ -68,8 +72,242 @@ interface Task {
   created_at: string;
   updated_at: string;
   image_url?: string;
+  family_id?: number;
+  series_id?: number;
+  occurrence_date?: string;
+  template_id?: number;
+  proof_type: ProofType;
+  proof_instructions?: string;
+  completion_proof?: string;
+  // 过期时记录的时间和原因；迟交减少奖励时保留原来的奖励
+  expired_at?: string;
+  expiry_reason?: string;
+  original_reward_amount?: string;
+  // 部分完成时实际发放的奖励和批准说明
+  approved_reward_amount?: string;
+  approval_reason?: string;
+  rejection_reason?: string;
+  rework_count?: number;
+  rework_requested_at?: string;
+  checklist_required?: boolean;
+  checklist?: TaskChecklistItem[];
 }
 
+// 任务清单中的一步，proof_type 为 photo 的步骤勾选时需要照片
+interface TaskChecklistItem {
+  id: number;
+  task_id: number;
+  position: number;
+  title: string;
+  required: boolean;
+  proof_type: 'none' | 'photo';
+  proof_url?: string;
+  checked_at?: string;
+  checked_by?: string;
+}
+
+// 创建或修改清单时的一步，带 id 的步骤保留勾选状态
+interface ChecklistItemInput {
+  id?: number;
+  title: string;
+  required?: boolean;
+  proof_type?: 'none' | 'photo';
+}
+
+// 批准任务时只发放部分奖励：percent 和 reward_amount 只能提供一个，此时 reason 必填
+interface PartialApproval {
+  percent?: number;
+  reward_amount?: string;
+  reason: string;
+}
+
+// 任务下的留言
+interface TaskComment {
+  id: number;
+  task_id: number;
+  author_address: string;
+  author_role: 'parent' | 'child';
+  body: string;
+  image_url?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+// 当前用户每个任务的未读留言数，只包含有未读留言的任务
+interface UnreadComments {
+  total: number;
+  tasks: { task_id: number; unread: number }[];
+}
+
+// 任务历史中的一条记录，按发生顺序返回
+interface TaskEvent {
+  id: number;
+  task_id: number;
+  action: 'created' | 'updated' | 'assigned' | 'submitted' | 'approved' | 'rejected' | 'rework_requested' | 'expired' | 'reward_minted';
+  source: 'api' | 'scheduler' | 'chain' | 'outbox' | 'backfill';
+  actor?: string;
+  from_status?: string;
+  to_status: string;
+  proof?: string;
+  reason?: string;
+  reward_amount?: string;
+  tx_hash?: string;
+  created_at: string;
+}
+
+// 实时推送的事件类型
+type RealtimeEventType =
+  | 'task.assigned'
+  | 'task.submitted'
+  | 'task.approved'
+  | 'task.rejected'
+  | 'tokens.minted'
+  | 'exchange.created'
+  | 'exchange.approved'
+  | 'exchange.declined'
+  | 'exchange.fulfilled'
+  | 'reward.low_stock'
+  | 'reward.proposed'
+  | 'reward.proposal_accepted'
+  | 'reward.proposal_declined';
+
+// 实时推送的事件，data 的内容取决于事件类型
+interface RealtimeEvent {
+  id: number;
+  type: RealtimeEventType;
+  family_id: number;
+  child_id?: number;
+  data: Record<string, any>;
+  occurred_at: string;
+}
+
+// 家庭的 webhook，events 为逗号分隔的事件类型，为空表示全部事件；secret 只在创建时返回
+interface Webhook {
+  id: number;
+  family_id: number;
+  url: string;
+  description: string;
+  events: string;
+  active: boolean;
+  created_by: string;
+  created_at: string;
+  updated_at: string;
+  secret?: string;
+}
+
+// webhook 的一次投递及最后一次请求的结果
+interface WebhookDelivery {
+  id: number;
+  webhook_id: number;
+  event_id: string;
+  event_type: RealtimeEventType | 'webhook.test';
+  payload: string;
+  status: 'pending' | 'delivered' | 'failed';
+  attempts: number;
+  next_attempt_at: string;
+  response_code?: number;
+  response_body?: string;
+  last_error?: string;
+  duration_ms?: number;
+  delivered_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+// 通知类型
+type NotificationType =
+  | 'task_submitted'
+  | 'task_approved'
+  | 'task_rejected'
+  | 'exchange_requested'
+  | 'exchange_decided'
+  | 'reward_low_stock'
+  | 'reward_proposed'
+  | 'reward_proposal_decided';
+
+// 站内通知
+interface Notification {
+  id: number;
+  wallet_address: string;
+  family_id: number;
+  type: NotificationType;
+  title: string;
+  body: string;
+  task_id?: number;
+  exchange_id?: number;
+  reward_id?: number;
+  proposal_id?: number;
+  read_at?: string;
+  created_at: string;
+}
+
+// 一种通知的渠道设置
+interface NotificationPreference {
+  type: NotificationType;
+  in_app: boolean;
+  email: boolean;
+}
+
+// 通知设置，email_enabled 表示服务器是否可以发送邮件
+interface NotificationSettings {
+  email: string | null;
+  email_enabled: boolean;
+  preferences: NotificationPreference[];
+}
+
+// 完成任务需要的凭证：不需要、文字说明或照片地址
+type ProofType = 'none' | 'text' | 'photo';
+
+// 任务模板类型，family_id 为空的是内置模板
+interface TaskTemplate {
+  id: number;
+  family_id?: number;
+  catalog_key?: string;
+  title: string;
+  description: string;
+  category?: string;
+  reward_amount: string;
+  difficulty: 'easy' | 'medium' | 'hard';
+  proof_type: ProofType;
+  proof_instructions?: string;
+  image_url?: string;
+}
+
+type TaskTemplateInput = Omit<TaskTemplate, 'id' | 'family_id' | 'catalog_key'> & {
+  family_id?: number;
+};
+
+// 重复任务类型
+interface TaskSeries {
+  id: number;
+  family_id: number;
+  title: string;
+  description: string;
+  reward_amount: string;
+  difficulty: 'easy' | 'medium' | 'hard';
+  image_url?: string;
+  frequency: 'daily' | 'weekdays' | 'weekly' | 'monthly';
+  interval: number;
+  weekdays?: string;
+  month_day?: number;
+  due_time: string;
+  time_zone: string;
+  start_date: string;
+  end_date?: string;
+  status: 'active' | 'paused' | 'ended';
+  children?: { child_id: number }[];
+  next_occurrences?: string[];
+}
+
+type TaskSeriesInput = Omit<TaskSeries, 'id' | 'family_id' | 'status' | 'children' | 'next_occurrences' | 'interval' | 'due_time' | 'time_zone' | 'start_date'> & {
+  family_id?: number;
+  child_ids: number[];
+  interval?: number;
+  due_time?: string;
+  time_zone?: string;
+  start_date?: string;
+};
+
 // 奖品相关类型
 interface Reward {
   id: number;
@@ -91,10 +329,16 @@ interface Exchange {
   reward_id: number;
   child_id: number;
   token_amount: number;
-  status: 'pending' | 'completed' | 'cancelled' | 'confirmed' | 'failed';
+  // completed、cancelled、confirmed、failed 是旧版本的状态，后端已不再返回
+  status: 'pending' | 'approved' | 'declined' | 'fulfilled' | 'completed' | 'cancelled' | 'confirmed' | 'failed';
   exchange_date: string;
   completed_date?: string;
   notes?: string;
+  contract_exchange_id?: number;
+  tx_hash?: string;
+  decided_by?: string; // 批准或拒绝的家长钱包地址
+  decided_at?: string;
+  settlement_tx_hash?: string; // 销毁或退还托管代币的交易
   created_at: string;
   updated_at: string;
   reward_name?: string;
@@ -103,10 +347,112 @@ interface Exchange {
   token_burned?: boolean; // 标记代币是否已在前端销毁
 }
 
+// 代币账本的一条分录，金额和余额都是以 wei 为单位的十进制字符串
+interface LedgerEntry {
+  id: number;
+  transaction_id: number;
+  account: string;
+  account_type: 'child' | 'escrow' | 'issuance' | 'external';
+  child_id?: number;
+  amount: string; // 带符号，转出为负
+  balance: string; // 记账后的余额
+  kind: 'mint' | 'burn' | 'transfer' | 'escrow' | 'refund';
+  tx_hash: string;
+  created_at: string;
+}
+
+// 孩子的账本余额和最近的分录
+interface LedgerStatement {
+  child_id: number;
+  wallet_address: string;
+  balance: string;
+  minted: string;
+  entries: LedgerEntry[];
+}
+
+// 家庭的账本核对报告，difference 是链上余额减去账本余额
+interface LedgerReport {
+  family_id: number;
+  block: number | null;
+  chain_at_latest: boolean;
+  mismatches: number;
+  children: {
+    child_id: number;
+    child_name: string;
+    wallet_address: string;
+    ledger_balance: string;
+    chain_balance: string;
+    difference: string;
+    matched: boolean;
+  }[];
+}
+
+// 储蓄目标，数量都是整数个代币
+interface SavingsGoal {
+  id: number;
+  child_id: number;
+  reward_id: number;
+  status: 'active' | 'redeemed' | 'cancelled';
+  locked_amount: number; // 孩子自己锁定的代币
+  matched_amount: number; // 家长配捐的代币
+  exchange_id?: number;
+  closed_at?: string;
+  created_at: string;
+  updated_at: string;
+  reward?: Reward;
+  contributions?: SavingsContribution[];
+}
+
+// 家长为储蓄目标配捐的一笔代币
+interface SavingsContribution {
+  id: number;
+  goal_id: number;
+  contributed_by: string;
+  amount: number;
+  notes?: string;
+  outbox_message_id?: number;
+  created_at: string;
+}
+
+// 储蓄目标的进度，代币数量是以 wei 为单位的十进制字符串
+interface SavingsProgress {
+  goal: SavingsGoal;
+  target: string;
+  reserved: string;
+  available: string;
+  saved: string;
+  remaining: string;
+  percent: number;
+  earning_rate: string; // 最近 rate_window_days 天平均每天铸造的代币
+  rate_window_days: number;
+  estimated_completion: string | null;
+}
+
+// 孩子提出的奖品愿望，家长接受后 reward_id 指向创建的奖品
+interface RewardProposal {
+  id: number;
+  family_id: number;
+  child_id: number;
+  name: string;
+  description?: string;
+  image_url?: string;
+  status: 'pending' | 'accepted' | 'declined';
+  reward_id?: number;
+  decided_by?: string;
+  decided_at?: string;
+  decision_note?: string;
+  created_at: string;
+  updated_at: string;
+  child?: Child;
+  reward?: Reward;
+}
+
 // HTTP 请求工具函数
 class ApiClient {
   private baseURL: string;
   private token: string | null = null;
+  // 正在进行的刷新请求，多个请求同时遇到401时共用
+  private refreshing: Promise<boolean> | null = null;
 
   constructor(baseURL: string) {
     this.baseURL = baseURL;
@@ -115,7 +461,8 @@ class ApiClient {
 
   private async request<T>(
     endpoint: string,
-    options: RequestInit = {}
+    options: RequestInit = {},
+    retried = false
   ): Promise<ApiResponse<T>> {
     const url = `${this.baseURL}${endpoint}`;
     const headers: Record<string, string> = {
@@ -205,6 +552,11 @@ class ApiClient {
       }
 
       if (!response.ok) {
+        // 访问令牌过期时用刷新令牌换取新令牌，然后重试一次
+        if (response.status === 401 && !retried && !endpoint.startsWith('/auth/') && await this.refreshSession()) {
+          return this.request<T>(endpoint, options, true);
+        }
+
         // 特殊处理500错误
         if (response.status === 500) {
           console.error('服务器内部错误:', data);
@@ -243,6 +595,40 @@ class ApiClient {
     console.log('Token已设置:', token.substring(0, 20) + '...');
   }
 
+  // 保存刷新令牌
+  setRefreshToken(refreshToken: string) {
+    localStorage.setItem('refresh_token', refreshToken);
+  }
+
+  // 用刷新令牌轮换出新的访问令牌和刷新令牌，失败时清除登录状态
+  refreshSession(): Promise<boolean> {
+    const refreshToken = localStorage.getItem('refresh_token');
+    if (!refreshToken) return Promise.resolve(false);
+
+    if (!this.refreshing) {
+      this.refreshing = fetch(`${this.baseURL}/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      })
+        .then(async response => {
+          const body = await response.json().catch(() => null);
+          if (!response.ok || !body?.data?.token) {
+            this.clearToken();
+            return false;
+          }
+          this.setToken(body.data.token);
+          this.setRefreshToken(body.data.refresh_token);
+          return true;
+        })
+        .catch(() => false)
+        .finally(() => {
+          this.refreshing = null;
+        });
+    }
+    return this.refreshing;
+  }
+
   // 获取当前token
   getToken(): string | null {
     return this.token || localStorage.getItem('auth_token');
@@ -258,6 +644,7 @@ class ApiClient {
   clearToken() {
     this.token = null;
     localStorage.removeItem('auth_token');
+    localStorage.removeItem('refresh_token');
     console.log('Token已清除');
   }
 
@@ -419,11 +806,13 @@ export const authApi = {
     }
     
     // 使用API客户端的缓存避免机制
-    return apiClient.get<{ nonce: string }>(`/auth/nonce/${address}`);
+    // message 是服务器生成的 EIP-4361 登录消息，需要用钱包签名
+    return apiClient.get<{ nonce: string; message: string }>(`/auth/nonce/${address}`);
   },
 
-  // 钱包登录
-  login: (walletAddress: string, signature: string, role?: 'parent' | 'child') => {
+  // 钱包登录，提交签名后的 EIP-4361 消息
+  // inviteCode 为可选的家庭邀请码，携带时角色由邀请码决定
+  login: (walletAddress: string, message: string, signature: string, role?: 'parent' | 'child', inviteCode?: string) => {
     // 规范化地址
     const address = walletAddress.toLowerCase();
     
@@ -432,13 +821,15 @@ export const authApi = {
       console.log(`签名: ${signature.substring(0, 20)}...`);
     }
     
-    return apiClient.post<{ token: string; user: User }>('/auth/login', {
+    return apiClient.post<{ token: string; refresh_token: string; user: User }>('/auth/login', {
       wallet_address: address,
+      message,
       signature,
       // 添加自定义标记和时间戳，避免服务端缓存
       _t: Date.now(),
       _r: Math.random().toString(36).substring(2, 10),
       ...(role && { role }),
+      ...(inviteCode && { invite_code: inviteCode }),
     });
   },
 
@@ -461,10 +852,13 @@ export const authApi = {
   },
 
   // 刷新令牌
-  refresh: () => apiClient.post<{ token: string }>('/auth/refresh'),
+  refresh: () => apiClient.refreshSession(),
 
-  // 登出
+  // 登出当前设备
   logout: () => apiClient.post('/auth/logout'),
+
+  // 登出所有设备
+  logoutAll: () => apiClient.post('/auth/logout-all'),
 };
 
 // 家庭相关 API
@@ -483,9 +877,21 @@ export const familyApi = {
   update: (id: number, data: Partial<Family>) =>
     apiClient.put<Family>(`/families/${id}`, data),
 
-  // 添加家庭成员
-  addMember: (id: number, walletAddress: string) =>
-    apiClient.post(`/families/${id}/members`, { wallet_address: walletAddress }),
+  // 邀请家庭成员（仅所有者）
+  inviteMember: (id: number, walletAddress: string, role: 'co_parent' | 'viewer') =>
+    apiClient.post(`/families/${id}/invitations`, { wallet_address: walletAddress, role }),
+
+  // 生成一次性邀请码和邀请链接
+  createInviteCode: (id: number, data: { role: 'child' | 'co_parent' | 'viewer'; child_name?: string; child_age?: number }) =>
+    apiClient.post<{ invite: any; code: string; link: string }>(`/families/${id}/invite-codes`, data),
+
+  // 登录前预览邀请码
+  previewInviteCode: (code: string) =>
+    apiClient.post<{ family_id: number; family_name: string; role: string; child_name?: string; expires_at: string }>('/auth/invite-codes/preview', { code }),
+
+  // 已登录用户兑换邀请码
+  redeemInviteCode: (code: string) =>
+    apiClient.post('/invite-codes/redeem', { code }),
 };
 
 // 儿童相关 API
@@ -515,7 +921,7 @@ export const childApi = {
 // 任务相关 API
 export const taskApi = {
   // 创建任务
-  create: (taskData: Omit<Task, 'id' | 'created_at' | 'updated_at'>) =>
+  create: (taskData: Omit<Task, 'id' | 'created_at' | 'updated_at' | 'checklist'> & { checklist?: ChecklistItemInput[] }) =>
     apiClient.post<Task>('/tasks', taskData),
 
   // 上传图片
@@ -578,17 +984,100 @@ export const taskApi = {
   // 完成任务
   complete: (id: number, submissionNote?: string) => {
     console.log(`[API] 完成任务 ${id}，备注:`, submissionNote);
-    // 使用空对象作为默认请求体，确保即使没有备注也发送一个有效的JSON
-    const payload = {};
+    // 凭证是否必填取决于任务的 proof_type，照片任务提交上传后的图片地址
+    const payload = submissionNote ? { completion_proof: submissionNote } : {};
     return apiClient.post<Task>(`/tasks/${id}/complete`, payload);
   },
 
-  // 批准任务
-  approve: (id: number) => apiClient.post(`/tasks/${id}/approve`),
+  // 批准任务，不传 partial 时发放全部奖励
+  approve: (id: number, partial?: PartialApproval) =>
+    apiClient.post<Task>(`/tasks/${id}/approve`, partial),
+
+  // 拒绝任务；rework 为 true 时退回给孩子重做，reason 必填
+  reject: (id: number, reason?: string, rework?: boolean) =>
+    apiClient.post<Task>(`/tasks/${id}/reject`, { reason, rework }),
+
+  // 替换任务清单（家长）
+  updateChecklist: (id: number, items: ChecklistItemInput[], checklistRequired?: boolean) =>
+    apiClient.put<Task>(`/tasks/${id}/checklist`, { items, checklist_required: checklistRequired }),
+
+  // 勾选清单中的一步（孩子），照片步骤需要先上传照片
+  checkItem: (id: number, itemId: number, proofUrl?: string) =>
+    apiClient.post<TaskChecklistItem>(`/tasks/${id}/checklist/${itemId}/check`, proofUrl ? { proof_url: proofUrl } : {}),
+
+  // 取消勾选清单中的一步（孩子）
+  uncheckItem: (id: number, itemId: number) =>
+    apiClient.post<TaskChecklistItem>(`/tasks/${id}/checklist/${itemId}/uncheck`),
+
+  // 获取任务留言
+  getComments: (id: number) => apiClient.get<TaskComment[]>(`/tasks/${id}/comments`),
+
+  // 发表留言，图片需要先通过 uploadImage 上传
+  addComment: (id: number, body: string, imageUrl?: string) =>
+    apiClient.post<TaskComment>(`/tasks/${id}/comments`, imageUrl ? { body, image_url: imageUrl } : { body }),
+
+  // 删除自己的留言
+  deleteComment: (id: number, commentId: number) => apiClient.delete(`/tasks/${id}/comments/${commentId}`),
+
+  // 把任务的留言标记为已读
+  markCommentsRead: (id: number) => apiClient.post(`/tasks/${id}/comments/read`),
+
+  // 获取每个任务的未读留言数
+  getUnreadComments: () => apiClient.get<UnreadComments>('/tasks/unread-comments'),
+
+  // 获取任务历史
+  history: (id: number) => apiClient.get<TaskEvent[]>(`/tasks/${id}/history`),
+};
+
+// 重复任务相关 API
+export const taskSeriesApi = {
+  // 创建重复任务
+  create: (data: TaskSeriesInput) => apiClient.post<TaskSeries>('/task-series', data),
+
+  // 获取重复任务列表
+  getAll: (familyId?: number) =>
+    apiClient.get<TaskSeries[]>(familyId ? `/task-series?family_id=${familyId}` : '/task-series'),
+
+  // 获取重复任务详情
+  getById: (id: number) => apiClient.get<TaskSeries>(`/task-series/${id}`),
+
+  // 修改重复任务，只影响之后生成的任务
+  update: (id: number, data: Partial<TaskSeriesInput>) => apiClient.put<TaskSeries>(`/task-series/${id}`, data),
+
+  // 跳过某一天
+  skip: (id: number, date: string) => apiClient.post<TaskSeries>(`/task-series/${id}/skip`, { date }),
+
+  // 暂停和恢复
+  pause: (id: number) => apiClient.post<TaskSeries>(`/task-series/${id}/pause`),
+  resume: (id: number) => apiClient.post<TaskSeries>(`/task-series/${id}/resume`),
+
+  // 结束重复任务
+  end: (id: number) => apiClient.delete(`/task-series/${id}`),
+};
+
+// 任务模板相关 API
+export const taskTemplateApi = {
+  // 获取内置模板和家庭模板
+  getAll: (familyId?: number) =>
+    apiClient.get<TaskTemplate[]>(familyId ? `/task-templates?family_id=${familyId}` : '/task-templates'),
+
+  // 获取模板详情
+  getById: (id: number) => apiClient.get<TaskTemplate>(`/task-templates/${id}`),
+
+  // 新建模板
+  create: (data: TaskTemplateInput) => apiClient.post<TaskTemplate>('/task-templates', data),
+
+  // 把已有任务保存为模板
+  saveTask: (taskId: number, category?: string) =>
+    apiClient.post<TaskTemplate>('/task-templates', { task_id: taskId, category }),
+
+  // 修改和删除家庭模板，内置模板不能修改
+  update: (id: number, data: Partial<TaskTemplateInput>) => apiClient.put<TaskTemplate>(`/task-templates/${id}`, data),
+  delete: (id: number) => apiClient.delete(`/task-templates/${id}`),
 
-  // 拒绝任务
-  reject: (id: number, reason?: string) =>
-    apiClient.post(`/tasks/${id}/reject`, { reason }),
+  // 从模板为多个孩子各创建一个任务
+  assign: (id: number, data: { child_ids: number[]; due_date?: string; reward_amount?: string; family_id?: number }) =>
+    apiClient.post<Task[]>(`/task-templates/${id}/assign`, data),
 };
 
 // 合约相关 API
@@ -609,9 +1098,193 @@ export const contractApi = {
     apiClient.get<any>(`/contracts/transactions/${hash}`),
 };
 
+// 实时推送 API
+export const eventsApi = {
+  // 订阅家庭中的变化，返回用于断开连接的函数
+  // EventSource 不能设置请求头，所以用 fetch 读取 SSE 流；收到 ready 后应重新获取一次数据
+  subscribe: (
+    onEvent: (event: RealtimeEvent) => void,
+    options: { onReady?: () => void; onClose?: (reason?: string) => void } = {}
+  ): (() => void) => {
+    const controller = new AbortController();
+
+    (async () => {
+      let reason: string | undefined;
+      try {
+        const response = await fetch(`${API_BASE_URL}/events`, {
+          headers: {
+            Accept: 'text/event-stream',
+            Authorization: `Bearer ${apiClient.getToken() ?? ''}`,
+          },
+          signal: controller.signal,
+        });
+        if (!response.ok || !response.body) {
+          reason = `HTTP ${response.status}`;
+          return;
+        }
+
+        const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+        let buffer = '';
+        for (;;) {
+          const { value, done } = await reader.read();
+          if (done) break;
+          buffer += value;
+
+          let end;
+          while ((end = buffer.indexOf('\n\n')) >= 0) {
+            const message = buffer.slice(0, end);
+            buffer = buffer.slice(end + 2);
+
+            let name = '';
+            let data = '';
+            for (const line of message.split('\n')) {
+              if (line.startsWith('event: ')) name = line.slice(7);
+              else if (line.startsWith('data: ')) data += line.slice(6);
+            }
+            if (!name) continue; // 心跳
+            if (name === 'ready') {
+              options.onReady?.();
+            } else if (name === 'revoked') {
+              reason = 'revoked';
+            } else {
+              onEvent(JSON.parse(data) as RealtimeEvent);
+            }
+          }
+        }
+      } catch (error) {
+        if (controller.signal.aborted) return;
+        reason = error instanceof Error ? error.message : String(error);
+      }
+      options.onClose?.(reason);
+    })();
+
+    return () => controller.abort();
+  },
+};
+
+// 家庭 webhook 相关 API
+export const webhookApi = {
+  create: (data: { url: string; events?: string; description?: string; family_id?: number }) =>
+    apiClient.post<Webhook>('/webhooks', data),
+
+  getAll: (familyId?: number) =>
+    apiClient.get<Webhook[]>(familyId ? `/webhooks?family_id=${familyId}` : '/webhooks'),
+
+  getById: (id: number) =>
+    apiClient.get<Webhook>(`/webhooks/${id}`),
+
+  update: (id: number, data: { url?: string; events?: string; description?: string; active?: boolean }) =>
+    apiClient.put<Webhook>(`/webhooks/${id}`, data),
+
+  delete: (id: number) =>
+    apiClient.delete<void>(`/webhooks/${id}`),
+
+  // 立即发送一条测试事件，返回投递结果
+  sendTest: (id: number) =>
+    apiClient.post<WebhookDelivery>(`/webhooks/${id}/test`),
+
+  // 最近的投递记录
+  getDeliveries: (id: number, limit?: number) =>
+    apiClient.get<WebhookDelivery[]>(limit ? `/webhooks/${id}/deliveries?limit=${limit}` : `/webhooks/${id}/deliveries`),
+};
+
+// 通知相关 API
+export const notificationApi = {
+  getAll: (options: { unread?: boolean; limit?: number } = {}) => {
+    const params = new URLSearchParams();
+    if (options.unread) params.set('unread', 'true');
+    if (options.limit) params.set('limit', String(options.limit));
+    const query = params.toString();
+    return apiClient.get<{ unread: number; notifications: Notification[] }>(query ? `/notifications?${query}` : '/notifications');
+  },
+
+  markRead: (id: number) =>
+    apiClient.post<Notification>(`/notifications/${id}/read`),
+
+  markAllRead: () =>
+    apiClient.post<{ marked: number }>('/notifications/read-all'),
+
+  getPreferences: () =>
+    apiClient.get<NotificationSettings>('/notifications/preferences'),
+
+  // email 为空字符串时删除邮件地址，没有提交的通知类型保持不变
+  updatePreferences: (data: { email?: string; preferences?: NotificationPreference[] }) =>
+    apiClient.put<NotificationSettings>('/notifications/preferences', data),
+};
+
+// 代币账本相关 API
+export const ledgerApi = {
+  // 孩子的余额和最近的分录，孩子只能查看自己的
+  getChildLedger: (childId: number, limit?: number) =>
+    apiClient.get<LedgerStatement>(limit ? `/children/${childId}/ledger?limit=${limit}` : `/children/${childId}/ledger`),
+
+  // 家长核对家庭中每个孩子的账本余额与链上余额
+  reconcile: (familyId: number) =>
+    apiClient.get<LedgerReport>(`/families/${familyId}/ledger/reconciliation`),
+};
+
+// 储蓄目标相关 API
+export const savingsApi = {
+  // 孩子把奖品设为储蓄目标
+  create: (rewardId: number) =>
+    apiClient.post<SavingsGoal>('/savings-goals', { reward_id: rewardId }),
+
+  getByChild: (childId: number, activeOnly: boolean = false) =>
+    apiClient.get<SavingsGoal[]>(activeOnly ? `/children/${childId}/savings-goals?active=true` : `/children/${childId}/savings-goals`),
+
+  getById: (id: number) =>
+    apiClient.get<SavingsGoal>(`/savings-goals/${id}`),
+
+  getProgress: (id: number) =>
+    apiClient.get<SavingsProgress>(`/savings-goals/${id}/progress`),
+
+  lock: (id: number, amount: number) =>
+    apiClient.post<SavingsGoal>(`/savings-goals/${id}/lock`, { amount }),
+
+  unlock: (id: number, amount: number) =>
+    apiClient.post<SavingsGoal>(`/savings-goals/${id}/unlock`, { amount }),
+
+  // 家长配捐，不传 amount 时配捐孩子锁定的数量中还没有配捐的部分
+  contribute: (id: number, data: { amount?: number; notes?: string } = {}) =>
+    apiClient.post<SavingsContribution>(`/savings-goals/${id}/contributions`, data),
+
+  cancel: (id: number) =>
+    apiClient.delete(`/savings-goals/${id}`),
+};
+
+// 奖品愿望相关 API
+export const rewardProposalApi = {
+  // 孩子提出愿望
+  create: (data: { name: string; description?: string; image_url?: string }) =>
+    apiClient.post<RewardProposal>('/reward-proposals', data),
+
+  getMy: () =>
+    apiClient.get<RewardProposal[]>('/reward-proposals/my'),
+
+  getByFamily: (familyId: number, status?: RewardProposal['status']) =>
+    apiClient.get<RewardProposal[]>(status ? `/families/${familyId}/reward-proposals?status=${status}` : `/families/${familyId}/reward-proposals`),
+
+  getById: (id: number) =>
+    apiClient.get<RewardProposal>(`/reward-proposals/${id}`),
+
+  // 家长定价，把愿望变成奖品；名称、描述和图片不传时沿用孩子填写的
+  accept: (id: number, data: {
+    token_price: number;
+    stock?: number;
+    name?: string;
+    description?: string;
+    image_url?: string;
+    contract_reward_id?: number;
+    note?: string;
+  }) => apiClient.post<RewardProposal>(`/reward-proposals/${id}/accept`, data),
+
+  decline: (id: number, note: string) =>
+    apiClient.post<RewardProposal>(`/reward-proposals/${id}/decline`, { note }),
+};
+
 // 导出 API 客户端
 export { apiClient };
-export type { ApiResponse, User, Family, Child, Task, Reward, Exchange };
+export type { ApiResponse, User, Family, Child, Task, TaskChecklistItem, ChecklistItemInput, PartialApproval, TaskComment, UnreadComments, TaskEvent, RealtimeEvent, RealtimeEventType, Webhook, WebhookDelivery, Notification, NotificationType, NotificationPreference, NotificationSettings, TaskSeries, TaskTemplate, ProofType, Reward, Exchange, LedgerEntry, LedgerStatement, LedgerReport, SavingsGoal, SavingsContribution, SavingsProgress, RewardProposal };
 
 // 奖品相关 API
 export const rewardApi = {
@@ -672,7 +1345,8 @@ export const rewardApi = {
 // 兑换相关 API
 export const exchangeApi = {
   // 兑换奖品
-  create: (data: { reward_id: number; notes?: string; token_burned?: boolean }) =>
+  // 孩子已在链上兑换时传入 contract_exchange_id，token_burned 已废弃，后端不再信任
+  create: (data: { reward_id: number; notes?: string; contract_exchange_id?: number; token_burned?: boolean }) =>
     apiClient.post<Exchange>('/exchanges', data),
 
   // 获取孩子的兑换记录
@@ -864,15 +1538,19 @@ export const exchangeApi = {
   // 获取兑换详情
   getById: (id: number) => apiClient.get<Exchange>(`/exchanges/${id}`),
 
-  // 更新兑换状态
-  updateStatus: (id: number, status: 'pending' | 'completed' | 'cancelled', notes?: string) =>
-    apiClient.put<Exchange>(`/exchanges/${id}`, { status, notes }),
+  // 更新兑换状态（旧接口）
+  updateStatus: (id: number, status: 'completed' | 'cancelled', notes?: string) =>
+    apiClient.put<Exchange>(`/exchanges/${id}/status`, { status, notes }),
 
-  // 批准兑换
+  // 批准兑换，托管的代币被销毁
   approve: (id: number, notes?: string) =>
-    apiClient.put<Exchange>(`/exchanges/${id}`, { status: 'completed', notes }),
+    apiClient.post<Exchange>(`/exchanges/${id}/approve`, { notes }),
+
+  // 拒绝兑换，代币退还给孩子
+  decline: (id: number, notes?: string) =>
+    apiClient.post<Exchange>(`/exchanges/${id}/decline`, { notes }),
 
-  // 取消兑换
-  cancel: (id: number, notes?: string) =>
-    apiClient.put<Exchange>(`/exchanges/${id}`, { status: 'cancelled', notes }),
+  // 已批准的兑换交付奖品
+  fulfill: (id: number, notes?: string) =>
+    apiClient.post<Exchange>(`/exchanges/${id}/fulfill`, { notes }),
 };
\ No newline at end of file
@@ -208,16 +208,16 @@ export const clearBalanceCache = (walletAddress?: string): void => {
 /**
  * 通过合约兑换奖品
  * @param rewardId 奖品ID
- * @returns 交易结果
+ * @returns 链上兑换ID，兑换失败时返回null
  */
-export const exchangeRewardWithContract = async (rewardId: number): Promise<boolean> => {
+export const exchangeRewardWithContract = async (rewardId: number): Promise<number | null> => {
   try {
     console.log(`通过合约兑换奖品: rewardId=${rewardId}`);
     
     // 检查是否有window.ethereum
     if (!window.ethereum) {
       console.error('未找到MetaMask或其他以太坊提供者');
-      return false;
+      return null;
     }
     
     // 创建provider和signer
@@ -231,7 +231,7 @@ export const exchangeRewardWithContract = async (rewardId: number): Promise<bool
     const rewardRegistryAddress = import.meta.env.VITE_REWARD_CONTRACT_ADDRESS || '0x0000000000000000000000000000000000000000';
     if (!rewardRegistryAddress || rewardRegistryAddress === '0x0000000000000000000000000000000000000000') {
       console.error('RewardRegistry合约地址未配置，请检查环境变量VITE_REWARD_CONTRACT_ADDRESS');
-      return false;
+      return null;
     }
     
     console.log('使用RewardRegistry合约地址:', rewardRegistryAddress);
@@ -257,6 +257,17 @@ export const exchangeRewardWithContract = async (rewardId: number): Promise<bool
           ],
           "stateMutability": "nonpayable",
           "type": "function"
+        },
+        {
+          "anonymous": false,
+          "inputs": [
+            { "indexed": true, "internalType": "uint256", "name": "exchangeId", "type": "uint256" },
+            { "indexed": true, "internalType": "uint256", "name": "rewardId", "type": "uint256" },
+            { "indexed": true, "internalType": "address", "name": "child", "type": "address" },
+            { "indexed": false, "internalType": "uint256", "name": "tokenAmount", "type": "uint256" }
+          ],
+          "name": "RewardExchanged",
+          "type": "event"
         }
       ];
       
@@ -311,14 +322,26 @@ export const exchangeRewardWithContract = async (rewardId: number): Promise<bool
       // 清除余额缓存，确保下次获取最新余额
       clearBalanceCache();
       
-      return true;
+      // 从RewardExchanged事件中读取链上兑换ID，后端据此核对并关联兑换
+      for (const log of receipt.logs) {
+        try {
+          const parsed = rewardRegistryContract.interface.parseLog(log);
+          if (parsed && parsed.name === 'RewardExchanged') {
+            return Number(parsed.args.exchangeId);
+          }
+        } catch {
+          // 不是奖品合约的事件，例如代币的Transfer
+        }
+      }
+      console.error('兑换交易中没有找到RewardExchanged事件');
+      return null;
     } catch (contractError) {
       console.error('调用合约方法失败:', contractError);
-      return false;
+      return null;
     }
   } catch (error) {
     console.error('通过合约兑换奖品失败:', error);
-    return false;
+    return null;
   }
 };
 